@@ -0,0 +1,51 @@
+package ast
+
+import (
+	"QIQ/cmd/qiq/position"
+	"fmt"
+	"strings"
+)
+
+// -------------------------------------- Attribute -------------------------------------- MARK: Attribute
+
+// Spec: https://www.php.net/manual/en/language.attributes.syntax.php
+
+type Attribute struct {
+	Position *position.Position
+	Name     string
+	// Arguments in the order of declaration
+	Arguments []*AttributeArgument
+}
+
+func NewAttribute(pos *position.Position, name string, arguments []*AttributeArgument) *Attribute {
+	return &Attribute{Position: pos, Name: name, Arguments: arguments}
+}
+
+func (attribute *Attribute) GetPosString() string {
+	return attribute.Position.ToPosString()
+}
+
+func (attribute *Attribute) String() string {
+	arguments := []string{}
+	for _, argument := range attribute.Arguments {
+		arguments = append(arguments, argument.String())
+	}
+	return fmt.Sprintf("{Attribute - name: %s, arguments: {%s}}", attribute.Name, strings.Join(arguments, ", "))
+}
+
+type AttributeArgument struct {
+	// Name of a named argument (e.g. "methods" in `#[Route(methods: ["GET"])]`). Empty for positional arguments.
+	Name  string
+	Value IExpression
+}
+
+func NewAttributeArgument(name string, value IExpression) *AttributeArgument {
+	return &AttributeArgument{Name: name, Value: value}
+}
+
+func (argument *AttributeArgument) String() string {
+	if argument.Name == "" {
+		return ToString(argument.Value)
+	}
+	return argument.Name + ": " + ToString(argument.Value)
+}
@@ -81,21 +81,26 @@ func (visitor DumpVisitor) ProcessCastExpr(stmt *CastExpression, _ any) (any, er
 	), nil
 }
 
+// ProcessClassConstantAccessExpr implements Visitor.
+func (visitor DumpVisitor) ProcessClassConstantAccessExpr(stmt *ClassConstantAccessExpression, _ any) (any, error) {
+	return fmt.Sprintf("{%s - scope: %s, constant: %s}", stmt.GetKind(), ToString(stmt.Scope), stmt.ConstantName), nil
+}
+
 // ProcessClassDeclarationStmt implements Visitor.
 func (visitor DumpVisitor) ProcessClassDeclarationStmt(stmt *ClassDeclarationStatement, context any) (any, error) {
 	constants := ""
 	constantsKeys := slices.Sorted(maps.Keys(stmt.Constants))
 	for _, key := range constantsKeys {
 		constant := stmt.Constants[key]
-		constants += "{visibility: \"" + constant.Visiblity + "\", name: \"" + constant.Name + "\", " + ToString(constant.Value) + "}, "
+		constants += fmt.Sprintf("{attributes: %s, visibility: \"%s\", name: \"%s\", %s}, ", constant.Attributes, constant.Visiblity, constant.Name, ToString(constant.Value))
 	}
 
 	methods := ""
 	methodsKeys := slices.Sorted(maps.Keys(stmt.Methods))
 	for _, key := range methodsKeys {
 		method := stmt.Methods[key]
-		methods += fmt.Sprintf("{name: %s, modifiers: %s, return type: {%s}, parameters: %s, body: %s}",
-			method.Name, common.ImplodeStrSlice(method.Modifiers), common.ImplodeStrSlice(method.ReturnType), method.Params, ToString(method.Body),
+		methods += fmt.Sprintf("{attributes: %s, name: %s, modifiers: %s, return type: {%s}, parameters: %s, body: %s}",
+			method.Attributes, method.Name, common.ImplodeStrSlice(method.Modifiers), method.ReturnType, method.Params, ToString(method.Body),
 		)
 	}
 
@@ -108,14 +113,22 @@ func (visitor DumpVisitor) ProcessClassDeclarationStmt(stmt *ClassDeclarationSta
 	propertiesKeys := slices.Sorted(maps.Keys(stmt.Properties))
 	for _, key := range propertiesKeys {
 		property := stmt.Properties[key]
-		properties += fmt.Sprintf("{name: %s, isStatic: %v, visibility: %s, type: {%s}, initialValue: %s}",
-			property.Name, property.IsStatic, property.Visibility, common.ImplodeStrSlice(property.Type), ToString(property.InitialValue),
+		properties += fmt.Sprintf("{attributes: %s, name: %s, isStatic: %v, visibility: %s, type: {%s}, initialValue: %s}",
+			property.Attributes, property.Name, property.IsStatic, property.Visibility, property.Type, ToString(property.InitialValue),
 		)
 	}
 
 	return fmt.Sprintf(
-		"{%s - name: \"%s\", isAbstract: %v, isFinal: %v, extends: \"%s\" , implements: %s, constants: {%s}, methods: {%s}, traits: {%s}, properties: {%s} }",
-		stmt.GetKind(), stmt.Name, stmt.IsAbstract, stmt.IsFinal, stmt.BaseClass, common.ImplodeStrSlice(stmt.Interfaces), constants, methods, traits, properties,
+		"{%s - attributes: %s, name: \"%s\", isAbstract: %v, isFinal: %v, extends: \"%s\" , implements: %s, constants: {%s}, methods: {%s}, traits: {%s}, properties: {%s} }",
+		stmt.GetKind(), stmt.Attributes, stmt.Name, stmt.IsAbstract, stmt.IsFinal, stmt.BaseClass, common.ImplodeStrSlice(stmt.Interfaces), constants, methods, traits, properties,
+	), nil
+}
+
+// ProcessCloneExpr implements Visitor.
+func (visitor DumpVisitor) ProcessCloneExpr(stmt *CloneExpression, _ any) (any, error) {
+	return fmt.Sprintf(
+		"{%s - expr: %s }",
+		stmt.GetKind(), ToString(stmt.Expr),
 	), nil
 }
 
@@ -265,8 +278,8 @@ func (visitor DumpVisitor) ProcessFunctionCallExpr(stmt *FunctionCallExpression,
 
 // ProcessFunctionDefinitionStmt implements Visitor.
 func (visitor DumpVisitor) ProcessFunctionDefinitionStmt(stmt *FunctionDefinitionStatement, _ any) (any, error) {
-	return fmt.Sprintf("{%s - name: %s, params: %s, body: %s, returnType: %s}",
-		stmt.GetKind(), stmt.FunctionName, stmt.Params, ToString(stmt.Body), stmt.ReturnType,
+	return fmt.Sprintf("{%s - attributes: %s, name: %s, params: %s, body: %s, returnType: %s}",
+		stmt.GetKind(), stmt.Attributes, stmt.FunctionName, stmt.Params, ToString(stmt.Body), stmt.ReturnType,
 	), nil
 }
 
@@ -328,7 +341,18 @@ func (visitor DumpVisitor) ProcessLogicalNotExpr(stmt *LogicalNotExpression, _ a
 
 // ProcessMemberAccessExpr implements Visitor.
 func (visitor DumpVisitor) ProcessMemberAccessExpr(stmt *MemberAccessExpression, _ any) (any, error) {
-	return fmt.Sprintf("{%s - object: %s, member: %s}", stmt.GetKind(), ToString(stmt.Object), ToString(stmt.Member)), nil
+	return fmt.Sprintf(
+		"{%s - object: %s, member: %s, nullsafe: %t}",
+		stmt.GetKind(), ToString(stmt.Object), ToString(stmt.Member), stmt.IsNullsafe,
+	), nil
+}
+
+// ProcessMemberCallExpr implements Visitor.
+func (visitor DumpVisitor) ProcessMemberCallExpr(stmt *MemberCallExpression, _ any) (any, error) {
+	return fmt.Sprintf(
+		"{%s - object: %s, member: %s, arguments: %s, nullsafe: %t}",
+		stmt.GetKind(), ToString(stmt.Object), ToString(stmt.Member), dumpExpressions(stmt.Arguments), stmt.IsNullsafe,
+	), nil
 }
 
 // ProcessObjectCreationExpr implements Visitor.
@@ -379,6 +403,19 @@ func (visitor DumpVisitor) ProcessReturnStmt(stmt *ReturnStatement, _ any) (any,
 	return fmt.Sprintf("{%s - %s}", stmt.GetKind(), ToString(stmt.Expr)), nil
 }
 
+// ProcessScopedCallExpr implements Visitor.
+func (visitor DumpVisitor) ProcessScopedCallExpr(stmt *ScopedCallExpression, _ any) (any, error) {
+	return fmt.Sprintf(
+		"{%s - scope: %s, member: %s, arguments: %s}",
+		stmt.GetKind(), ToString(stmt.Scope), ToString(stmt.Member), dumpExpressions(stmt.Arguments),
+	), nil
+}
+
+// ProcessShellCommandExpr implements Visitor.
+func (visitor DumpVisitor) ProcessShellCommandExpr(stmt *ShellCommandExpression, _ any) (any, error) {
+	return fmt.Sprintf("{%s - command: %s }", stmt.GetKind(), ToString(stmt.Command)), nil
+}
+
 // ProcessSimpleAssignmentExpr implements Visitor.
 func (visitor DumpVisitor) ProcessSimpleAssignmentExpr(stmt *SimpleAssignmentExpression, _ any) (any, error) {
 	return fmt.Sprintf(
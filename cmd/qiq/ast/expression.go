@@ -62,6 +62,21 @@ func (stmt *ParenthesizedExpression) Process(visitor Visitor, context any) (any,
 	return visitor.ProcessParenthesizedExpr(stmt, context)
 }
 
+// -------------------------------------- CloneExpression -------------------------------------- MARK: CloneExpression
+
+type CloneExpression struct {
+	*Expression
+	Expr IExpression
+}
+
+func NewCloneExpr(id int64, pos *position.Position, expr IExpression) *CloneExpression {
+	return &CloneExpression{Expression: NewExpr(id, CloneExpr, pos), Expr: expr}
+}
+
+func (stmt *CloneExpression) Process(visitor Visitor, context any) (any, error) {
+	return visitor.ProcessCloneExpr(stmt, context)
+}
+
 // -------------------------------------- ErrorControlExpression -------------------------------------- MARK: ErrorControlExpression
 
 type ErrorControlExpression struct {
@@ -91,6 +106,22 @@ func (stmt *PrintExpression) Process(visitor Visitor, context any) (any, error)
 	return visitor.ProcessPrintExpr(stmt, context)
 }
 
+// -------------------------------------- ShellCommandExpression -------------------------------------- MARK: ShellCommandExpression
+
+type ShellCommandExpression struct {
+	*Expression
+	// Command as double quoted string (e.g. "git log -n $count")
+	Command IExpression
+}
+
+func NewShellCommandExpr(id int64, pos *position.Position, command IExpression) *ShellCommandExpression {
+	return &ShellCommandExpression{Expression: NewExpr(id, ShellCommandExpr, pos), Command: command}
+}
+
+func (stmt *ShellCommandExpression) Process(visitor Visitor, context any) (any, error) {
+	return visitor.ProcessShellCommandExpr(stmt, context)
+}
+
 // -------------------------------------- TextExpression -------------------------------------- MARK: TextExpression
 
 type TextExpression struct {
@@ -126,10 +157,16 @@ func (stmt *VariableNameExpression) Process(visitor Visitor, context any) (any,
 type SimpleVariableExpression struct {
 	*Expression
 	VariableName IExpression
+	// Slot of the variable in the scope of the function body (-1 if the variable is accessed by its name)
+	Slot int
+	// Set if the variable is a superglobal (e.g. `$_GET`)
+	IsSuperglobal bool
 }
 
 func NewSimpleVariableExpr(id int64, variableName IExpression) *SimpleVariableExpression {
-	return &SimpleVariableExpression{Expression: NewExpr(id, SimpleVariableExpr, variableName.GetPosition()), VariableName: variableName}
+	return &SimpleVariableExpression{
+		Expression: NewExpr(id, SimpleVariableExpr, variableName.GetPosition()), VariableName: variableName, Slot: -1,
+	}
 }
 
 func (stmt *SimpleVariableExpression) Process(visitor Visitor, context any) (any, error) {
@@ -388,12 +425,19 @@ type StringLiteralExpression struct {
 	*Expression
 	StringType StringType
 	Value      string
+	// Content of a double quoted or heredoc string before the escape sequences are replaced.
+	// The variable substitution requires it to distinguish between "\$a" and "$a".
+	RawValue string
 }
 
 func NewStringLiteralExpr(id int64, pos *position.Position, value string, stringType StringType) *StringLiteralExpression {
 	return &StringLiteralExpression{Expression: NewExpr(id, StringLiteralExpr, pos), Value: value, StringType: stringType}
 }
 
+func NewInterpolatedStringLiteralExpr(id int64, pos *position.Position, value string, rawValue string, stringType StringType) *StringLiteralExpression {
+	return &StringLiteralExpression{Expression: NewExpr(id, StringLiteralExpr, pos), Value: value, RawValue: rawValue, StringType: stringType}
+}
+
 func (stmt *StringLiteralExpression) Process(visitor Visitor, context any) (any, error) {
 	return visitor.ProcessStringLiteralExpr(stmt, context)
 }
@@ -680,16 +724,74 @@ func (stmt *ObjectCreationExpression) Process(visitor Visitor, context any) (any
 
 type MemberAccessExpression struct {
 	*Expression
-	Object IExpression
-	Member IExpression
+	Object     IExpression
+	Member     IExpression
+	IsNullsafe bool
 }
 
-func NewMemberAccessExpr(id int64, pos *position.Position, object, member IExpression) *MemberAccessExpression {
+func NewMemberAccessExpr(id int64, pos *position.Position, object, member IExpression, isNullsafe bool) *MemberAccessExpression {
 	return &MemberAccessExpression{Expression: NewExpr(id, MemberAccessExpr, pos),
-		Object: object, Member: member,
+		Object: object, Member: member, IsNullsafe: isNullsafe,
 	}
 }
 
 func (stmt *MemberAccessExpression) Process(visitor Visitor, context any) (any, error) {
 	return visitor.ProcessMemberAccessExpr(stmt, context)
 }
+
+// -------------------------------------- MemberCallExpression -------------------------------------- MARK: MemberCallExpression
+
+type MemberCallExpression struct {
+	*Expression
+	Object     IExpression
+	Member     IExpression
+	Arguments  []IExpression
+	IsNullsafe bool
+}
+
+func NewMemberCallExpr(id int64, pos *position.Position, object, member IExpression, arguments []IExpression, isNullsafe bool) *MemberCallExpression {
+	return &MemberCallExpression{Expression: NewExpr(id, MemberCallExpr, pos),
+		Object: object, Member: member, Arguments: arguments, IsNullsafe: isNullsafe,
+	}
+}
+
+func (stmt *MemberCallExpression) Process(visitor Visitor, context any) (any, error) {
+	return visitor.ProcessMemberCallExpr(stmt, context)
+}
+
+// -------------------------------------- ScopedCallExpression -------------------------------------- MARK: ScopedCallExpression
+
+type ScopedCallExpression struct {
+	*Expression
+	Scope     IExpression
+	Member    IExpression
+	Arguments []IExpression
+}
+
+func NewScopedCallExpr(id int64, pos *position.Position, scope, member IExpression, arguments []IExpression) *ScopedCallExpression {
+	return &ScopedCallExpression{Expression: NewExpr(id, ScopedCallExpr, pos),
+		Scope: scope, Member: member, Arguments: arguments,
+	}
+}
+
+func (stmt *ScopedCallExpression) Process(visitor Visitor, context any) (any, error) {
+	return visitor.ProcessScopedCallExpr(stmt, context)
+}
+
+// -------------------------------------- ClassConstantAccessExpression -------------------------------------- MARK: ClassConstantAccessExpression
+
+type ClassConstantAccessExpression struct {
+	*Expression
+	Scope        IExpression
+	ConstantName string
+}
+
+func NewClassConstantAccessExpr(id int64, pos *position.Position, scope IExpression, constantName string) *ClassConstantAccessExpression {
+	return &ClassConstantAccessExpression{Expression: NewExpr(id, ClassConstantAccessExpr, pos),
+		Scope: scope, ConstantName: constantName,
+	}
+}
+
+func (stmt *ClassConstantAccessExpression) Process(visitor Visitor, context any) (any, error) {
+	return visitor.ProcessClassConstantAccessExpr(stmt, context)
+}
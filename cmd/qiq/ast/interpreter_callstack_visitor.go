@@ -79,21 +79,26 @@ func (visitor InterpreterCallStackVisitor) ProcessCastExpr(stmt *CastExpression,
 	), nil
 }
 
+// ProcessClassConstantAccessExpr implements Visitor.
+func (visitor InterpreterCallStackVisitor) ProcessClassConstantAccessExpr(stmt *ClassConstantAccessExpression, _ any) (any, error) {
+	return fmt.Sprintf("{%s - scope: %s, constant: %s, pos: %s}", stmt.GetKind(), ToString(stmt.Scope), stmt.ConstantName, stmt.GetPosString()), nil
+}
+
 // ProcessClassDeclarationStmt implements Visitor.
 func (visitor InterpreterCallStackVisitor) ProcessClassDeclarationStmt(stmt *ClassDeclarationStatement, context any) (any, error) {
 	constants := ""
 	constantsKeys := slices.Sorted(maps.Keys(stmt.Constants))
 	for _, key := range constantsKeys {
 		constant := stmt.Constants[key]
-		constants += "{visibility: \"" + constant.Visiblity + "\", name: \"" + constant.Name + "\", " + ToString(constant.Value) + "}, "
+		constants += fmt.Sprintf("{attributes: %s, visibility: \"%s\", name: \"%s\", %s}, ", constant.Attributes, constant.Visiblity, constant.Name, ToString(constant.Value))
 	}
 
 	methods := ""
 	methodsKeys := slices.Sorted(maps.Keys(stmt.Methods))
 	for _, key := range methodsKeys {
 		method := stmt.Methods[key]
-		methods += fmt.Sprintf("{name: %s, modifiers: %s, return type: {%s}, parameters: %s, body: %s}",
-			method.Name, common.ImplodeStrSlice(method.Modifiers), common.ImplodeStrSlice(method.ReturnType), method.Params, ToString(method.Body),
+		methods += fmt.Sprintf("{attributes: %s, name: %s, modifiers: %s, return type: {%s}, parameters: %s, body: %s}",
+			method.Attributes, method.Name, common.ImplodeStrSlice(method.Modifiers), method.ReturnType, method.Params, ToString(method.Body),
 		)
 	}
 
@@ -106,14 +111,22 @@ func (visitor InterpreterCallStackVisitor) ProcessClassDeclarationStmt(stmt *Cla
 	propertiesKeys := slices.Sorted(maps.Keys(stmt.Properties))
 	for _, key := range propertiesKeys {
 		property := stmt.Properties[key]
-		properties += fmt.Sprintf("{name: %s, isStatic: %v, visibility: %s, type: {%s}, initialValue: %s}",
-			property.Name, property.IsStatic, property.Visibility, common.ImplodeStrSlice(property.Type), ToString(property.InitialValue),
+		properties += fmt.Sprintf("{attributes: %s, name: %s, isStatic: %v, visibility: %s, type: {%s}, initialValue: %s}",
+			property.Attributes, property.Name, property.IsStatic, property.Visibility, property.Type, ToString(property.InitialValue),
 		)
 	}
 
 	return fmt.Sprintf(
-		"{%s - name: \"%s\", isAbstract: %v, isFinal: %v, extends: \"%s\" , implements: %s, constants: {%s}, methods: {%s}, traits: {%s}, properties: {%s}, pos: %s }",
-		stmt.GetKind(), stmt.Name, stmt.IsAbstract, stmt.IsFinal, stmt.BaseClass, common.ImplodeStrSlice(stmt.Interfaces), constants, methods, traits, properties, stmt.GetPosString(),
+		"{%s - attributes: %s, name: \"%s\", isAbstract: %v, isFinal: %v, extends: \"%s\" , implements: %s, constants: {%s}, methods: {%s}, traits: {%s}, properties: {%s}, pos: %s }",
+		stmt.GetKind(), stmt.Attributes, stmt.Name, stmt.IsAbstract, stmt.IsFinal, stmt.BaseClass, common.ImplodeStrSlice(stmt.Interfaces), constants, methods, traits, properties, stmt.GetPosString(),
+	), nil
+}
+
+// ProcessCloneExpr implements Visitor.
+func (visitor InterpreterCallStackVisitor) ProcessCloneExpr(stmt *CloneExpression, _ any) (any, error) {
+	return fmt.Sprintf(
+		"{%s - expr: %s, pos: %s}",
+		stmt.GetKind(), ToString(stmt.Expr), stmt.GetPosString(),
 	), nil
 }
 
@@ -263,8 +276,8 @@ func (visitor InterpreterCallStackVisitor) ProcessFunctionCallExpr(stmt *Functio
 
 // ProcessFunctionDefinitionStmt implements Visitor.
 func (visitor InterpreterCallStackVisitor) ProcessFunctionDefinitionStmt(stmt *FunctionDefinitionStatement, _ any) (any, error) {
-	return fmt.Sprintf("{%s - name: %s, params: %s, body: %s, returnType: %s, pos: %s}",
-		stmt.GetKind(), stmt.FunctionName, stmt.Params, ToString(stmt.Body), stmt.ReturnType, stmt.GetPosString(),
+	return fmt.Sprintf("{%s - attributes: %s, name: %s, params: %s, body: %s, returnType: %s, pos: %s}",
+		stmt.GetKind(), stmt.Attributes, stmt.FunctionName, stmt.Params, ToString(stmt.Body), stmt.ReturnType, stmt.GetPosString(),
 	), nil
 }
 
@@ -326,7 +339,18 @@ func (visitor InterpreterCallStackVisitor) ProcessLogicalNotExpr(stmt *LogicalNo
 
 // ProcessMemberAccessExpr implements Visitor.
 func (visitor InterpreterCallStackVisitor) ProcessMemberAccessExpr(stmt *MemberAccessExpression, _ any) (any, error) {
-	return fmt.Sprintf("{%s - object: %s, member: %s, pos: %s}", stmt.GetKind(), ToString(stmt.Object), ToString(stmt.Member), stmt.GetPosString()), nil
+	return fmt.Sprintf(
+		"{%s - object: %s, member: %s, nullsafe: %t, pos: %s}",
+		stmt.GetKind(), ToString(stmt.Object), ToString(stmt.Member), stmt.IsNullsafe, stmt.GetPosString(),
+	), nil
+}
+
+// ProcessMemberCallExpr implements Visitor.
+func (visitor InterpreterCallStackVisitor) ProcessMemberCallExpr(stmt *MemberCallExpression, _ any) (any, error) {
+	return fmt.Sprintf(
+		"{%s - object: %s, member: %s, arguments: %s, nullsafe: %t, pos: %s}",
+		stmt.GetKind(), ToString(stmt.Object), ToString(stmt.Member), dumpInternalCallstackExpressions(stmt.Arguments), stmt.IsNullsafe, stmt.GetPosString(),
+	), nil
 }
 
 // ProcessObjectCreationExpr implements Visitor.
@@ -377,6 +401,19 @@ func (visitor InterpreterCallStackVisitor) ProcessReturnStmt(stmt *ReturnStateme
 	return fmt.Sprintf("{%s - %s, pos: %s }", stmt.GetKind(), ToString(stmt.Expr), stmt.GetPosString()), nil
 }
 
+// ProcessScopedCallExpr implements Visitor.
+func (visitor InterpreterCallStackVisitor) ProcessScopedCallExpr(stmt *ScopedCallExpression, _ any) (any, error) {
+	return fmt.Sprintf(
+		"{%s - scope: %s, member: %s, arguments: %s, pos: %s}",
+		stmt.GetKind(), ToString(stmt.Scope), ToString(stmt.Member), dumpInternalCallstackExpressions(stmt.Arguments), stmt.GetPosString(),
+	), nil
+}
+
+// ProcessShellCommandExpr implements Visitor.
+func (visitor InterpreterCallStackVisitor) ProcessShellCommandExpr(stmt *ShellCommandExpression, _ any) (any, error) {
+	return fmt.Sprintf("{%s - command: %s, pos: %s }", stmt.GetKind(), ToString(stmt.Command), stmt.GetPosString()), nil
+}
+
 // ProcessSimpleAssignmentExpr implements Visitor.
 func (visitor InterpreterCallStackVisitor) ProcessSimpleAssignmentExpr(stmt *SimpleAssignmentExpression, _ any) (any, error) {
 	return fmt.Sprintf(
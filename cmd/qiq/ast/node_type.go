@@ -7,43 +7,48 @@ const (
 	ProgramNode NodeType = "Program"
 	TextNode    NodeType = "Text"
 	// Expressions
-	ArrayLiteralExpr       NodeType = "ArrayLiteralExpression"
-	ArrayNextKeyExpr       NodeType = "ArrayNextKeyExpression"
-	BinaryOpExpr           NodeType = "BinaryOpExpression"
-	CastExpr               NodeType = "CastExpression"
-	CoalesceExpr           NodeType = "CoalesceExpression"
-	CompoundAssignmentExpr NodeType = "CompoundAssignmentExpression"
-	ConditionalExpr        NodeType = "ConditionalExpression"
-	ConstantAccessExpr     NodeType = "ConstantAccessExpression"
-	EmptyIntrinsicExpr     NodeType = "EmptyIntrinsicExpression"
-	EqualityExpr           NodeType = "EqualityExpression"
-	ErrorControlExpr       NodeType = "ErrorControlExpression"
-	EvalIntrinsicExpr      NodeType = "EvalIntrinsicExpression"
-	ExitIntrinsicExpr      NodeType = "ExitIntrinsicExpression"
-	FloatingLiteralExpr    NodeType = "FloatingLiteralExpression"
-	FunctionCallExpr       NodeType = "FunctionCallExpression"
-	IncludeExpr            NodeType = "IncludeExpression"
-	IncludeOnceExpr        NodeType = "IncludeOnceExpression"
-	IntegerLiteralExpr     NodeType = "IntegerLiteralExpression"
-	IssetIntrinsicExpr     NodeType = "IssetIntrinsicExpression"
-	LogicalNotExpr         NodeType = "LogicalNotExpression"
-	MemberAccessExpr       NodeType = "MemberAccessExpression"
-	ObjectCreationExpr     NodeType = "ObjectCreationExpression"
-	ParenthesizedExpr      NodeType = "ParenthesizedExpression"
-	PostfixIncExpr         NodeType = "PostfixIncExpression"
-	PrefixIncExpr          NodeType = "PrefixIncExpression"
-	PrintExpr              NodeType = "PrintExpression"
-	RelationalExpr         NodeType = "RelationalExpression"
-	RequireExpr            NodeType = "RequireExpression"
-	RequireOnceExpr        NodeType = "RequireOnceExpression"
-	ShiftExpr              NodeType = "ShiftExpression"
-	SimpleAssignmentExpr   NodeType = "SimpleAssignmentExpression"
-	SimpleVariableExpr     NodeType = "SimpleVariableExpression"
-	StringLiteralExpr      NodeType = "StringLiteralExpression"
-	SubscriptExpr          NodeType = "SubscriptExpression"
-	UnaryOpExpr            NodeType = "UnaryOpExpression"
-	UnsetIntrinsicExpr     NodeType = "UnsetIntrinsicExpression"
-	VariableNameExpr       NodeType = "VariableNameExpression"
+	ArrayLiteralExpr        NodeType = "ArrayLiteralExpression"
+	ArrayNextKeyExpr        NodeType = "ArrayNextKeyExpression"
+	BinaryOpExpr            NodeType = "BinaryOpExpression"
+	CastExpr                NodeType = "CastExpression"
+	ClassConstantAccessExpr NodeType = "ClassConstantAccessExpression"
+	CloneExpr               NodeType = "CloneExpression"
+	CoalesceExpr            NodeType = "CoalesceExpression"
+	CompoundAssignmentExpr  NodeType = "CompoundAssignmentExpression"
+	ConditionalExpr         NodeType = "ConditionalExpression"
+	ConstantAccessExpr      NodeType = "ConstantAccessExpression"
+	EmptyIntrinsicExpr      NodeType = "EmptyIntrinsicExpression"
+	EqualityExpr            NodeType = "EqualityExpression"
+	ErrorControlExpr        NodeType = "ErrorControlExpression"
+	EvalIntrinsicExpr       NodeType = "EvalIntrinsicExpression"
+	ExitIntrinsicExpr       NodeType = "ExitIntrinsicExpression"
+	FloatingLiteralExpr     NodeType = "FloatingLiteralExpression"
+	FunctionCallExpr        NodeType = "FunctionCallExpression"
+	IncludeExpr             NodeType = "IncludeExpression"
+	IncludeOnceExpr         NodeType = "IncludeOnceExpression"
+	IntegerLiteralExpr      NodeType = "IntegerLiteralExpression"
+	IssetIntrinsicExpr      NodeType = "IssetIntrinsicExpression"
+	LogicalNotExpr          NodeType = "LogicalNotExpression"
+	MemberAccessExpr        NodeType = "MemberAccessExpression"
+	MemberCallExpr          NodeType = "MemberCallExpression"
+	ObjectCreationExpr      NodeType = "ObjectCreationExpression"
+	ParenthesizedExpr       NodeType = "ParenthesizedExpression"
+	PostfixIncExpr          NodeType = "PostfixIncExpression"
+	PrefixIncExpr           NodeType = "PrefixIncExpression"
+	PrintExpr               NodeType = "PrintExpression"
+	RelationalExpr          NodeType = "RelationalExpression"
+	RequireExpr             NodeType = "RequireExpression"
+	RequireOnceExpr         NodeType = "RequireOnceExpression"
+	ScopedCallExpr          NodeType = "ScopedCallExpression"
+	ShellCommandExpr        NodeType = "ShellCommandExpression"
+	ShiftExpr               NodeType = "ShiftExpression"
+	SimpleAssignmentExpr    NodeType = "SimpleAssignmentExpression"
+	SimpleVariableExpr      NodeType = "SimpleVariableExpression"
+	StringLiteralExpr       NodeType = "StringLiteralExpression"
+	SubscriptExpr           NodeType = "SubscriptExpression"
+	UnaryOpExpr             NodeType = "UnaryOpExpression"
+	UnsetIntrinsicExpr      NodeType = "UnsetIntrinsicExpression"
+	VariableNameExpr        NodeType = "VariableNameExpression"
 	// Statements
 	BreakStmt              NodeType = "BreakStatement"
 	CompoundStmt           NodeType = "CompoundStatement"
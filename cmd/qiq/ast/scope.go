@@ -0,0 +1,30 @@
+package ast
+
+// The variables of a function or method body.
+// The resolver assigns each variable whose name is known at compile time to a fixed slot.
+type Scope struct {
+	// Names of the variables by slot
+	Variables []string
+	// Slots of the parameters
+	Params []int
+	slots  map[string]int
+}
+
+func NewScope() *Scope {
+	return &Scope{Variables: []string{}, Params: []int{}, slots: map[string]int{}}
+}
+
+// Add the variable if it is not in the scope yet. Returns the slot of the variable.
+func (scope *Scope) Add(variableName string) int {
+	if slot, found := scope.slots[variableName]; found {
+		return slot
+	}
+	scope.Variables = append(scope.Variables, variableName)
+	scope.slots[variableName] = len(scope.Variables) - 1
+	return len(scope.Variables) - 1
+}
+
+func (scope *Scope) Lookup(variableName string) (int, bool) {
+	slot, found := scope.slots[variableName]
+	return slot, found
+}
@@ -56,14 +56,17 @@ func (stmt *Statement) Process(visitor Visitor, context any) (any, error) {
 
 type MethodDefinitionStatement struct {
 	*Statement
+	Attributes []*Attribute
 	Modifiers  []string
 	Name       string
 	Params     []FunctionParameter
 	Body       *CompoundStatement
-	ReturnType []string
+	ReturnType *TypeDeclaration
+	// Variables of the body (set by the resolver)
+	Scope *Scope
 }
 
-func NewMethodDefinitionStmt(id int64, pos *position.Position, name string, modifiers []string, params []FunctionParameter, body *CompoundStatement, returnType []string) *MethodDefinitionStatement {
+func NewMethodDefinitionStmt(id int64, pos *position.Position, name string, modifiers []string, params []FunctionParameter, body *CompoundStatement, returnType *TypeDeclaration) *MethodDefinitionStatement {
 	return &MethodDefinitionStatement{Statement: NewStmt(id, MethodDefinitionStmt, pos),
 		Name:       name,
 		Modifiers:  modifiers,
@@ -80,19 +83,24 @@ func (stmt *MethodDefinitionStatement) Process(visitor Visitor, context any) (an
 // -------------------------------------- FunctionDefinitionStatement -------------------------------------- MARK: FunctionDefinitionStatement
 
 type FunctionParameter struct {
-	Type []string
-	Name string
+	// Type is nil if the parameter has no type declaration
+	Type       *TypeDeclaration
+	Name       string
+	Attributes []*Attribute
 }
 
 type FunctionDefinitionStatement struct {
 	*Statement
+	Attributes   []*Attribute
 	FunctionName string
 	Params       []FunctionParameter
 	Body         *CompoundStatement
-	ReturnType   []string
+	ReturnType   *TypeDeclaration
+	// Variables of the body (set by the resolver)
+	Scope *Scope
 }
 
-func NewFunctionDefinitionStmt(id int64, pos *position.Position, functionName string, params []FunctionParameter, body *CompoundStatement, returnType []string) *FunctionDefinitionStatement {
+func NewFunctionDefinitionStmt(id int64, pos *position.Position, functionName string, params []FunctionParameter, body *CompoundStatement, returnType *TypeDeclaration) *FunctionDefinitionStatement {
 	return &FunctionDefinitionStatement{Statement: NewStmt(id, FunctionDefinitionStmt, pos),
 		FunctionName: functionName, Params: params, Body: body, ReturnType: returnType,
 	}
@@ -202,9 +210,10 @@ func (stmt *EchoStatement) Process(visitor Visitor, context any) (any, error) {
 
 type ClassConstDeclarationStatement struct {
 	*Statement
-	Name      string
-	Value     IExpression
-	Visiblity string
+	Attributes []*Attribute
+	Name       string
+	Value      IExpression
+	Visiblity  string
 }
 
 func NewClassConstDeclarationStmt(id int64, pos *position.Position, name string, value IExpression, visibility string) *ClassConstDeclarationStatement {
@@ -322,14 +331,15 @@ func (stmt *GlobalDeclarationStatement) Process(visitor Visitor, context any) (a
 
 type PropertyDeclarationStatement struct {
 	*Statement
+	Attributes   []*Attribute
 	Visibility   string
 	IsStatic     bool
 	Name         string
-	Type         []string
+	Type         *TypeDeclaration
 	InitialValue IExpression
 }
 
-func NewPropertyDeclarationStmt(id int64, pos *position.Position, name, visibility string, isStatic bool, pType []string, initialValue IExpression) *PropertyDeclarationStatement {
+func NewPropertyDeclarationStmt(id int64, pos *position.Position, name, visibility string, isStatic bool, pType *TypeDeclaration, initialValue IExpression) *PropertyDeclarationStatement {
 	return &PropertyDeclarationStatement{
 		Statement:    NewStmt(id, PropertyDeclarationStmt, pos),
 		Name:         name,
@@ -348,6 +358,7 @@ func (stmt *PropertyDeclarationStatement) Process(visitor Visitor, context any)
 
 type ClassDeclarationStatement struct {
 	*Statement
+	Attributes     []*Attribute
 	IsAbstract     bool
 	IsFinal        bool
 	Name           string
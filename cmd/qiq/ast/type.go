@@ -0,0 +1,137 @@
+package ast
+
+import (
+	"slices"
+	"strings"
+)
+
+// -------------------------------------- TypeDeclaration -------------------------------------- MARK: TypeDeclaration
+
+// Spec: https://www.php.net/manual/en/language.types.declarations.php
+
+type TypeKind string
+
+const (
+	NamedType        TypeKind = "NamedType"
+	UnionType        TypeKind = "UnionType"
+	IntersectionType TypeKind = "IntersectionType"
+)
+
+// Built-in types are stored lowercase. All other names are class or interface names.
+var builtinTypes = []string{
+	"array", "bool", "callable", "false", "float", "int", "iterable", "mixed", "never", "null",
+	"object", "parent", "self", "static", "string", "true", "void",
+}
+
+type TypeDeclaration struct {
+	Kind TypeKind
+	// Name of the type if kind is NamedType
+	Name string
+	// Member types if kind is UnionType or IntersectionType
+	Types []*TypeDeclaration
+}
+
+func NewNamedType(name string) *TypeDeclaration {
+	if slices.Contains(builtinTypes, strings.ToLower(name)) {
+		name = strings.ToLower(name)
+	}
+	return &TypeDeclaration{Kind: NamedType, Name: name}
+}
+
+func NewUnionType(types ...*TypeDeclaration) *TypeDeclaration {
+	return &TypeDeclaration{Kind: UnionType, Types: types}
+}
+
+func NewIntersectionType(types ...*TypeDeclaration) *TypeDeclaration {
+	return &TypeDeclaration{Kind: IntersectionType, Types: types}
+}
+
+// Spec: https://www.php.net/manual/en/language.types.type-system.php#language.types.type-system.composite.nullable
+// A nullable type "?T" is the same as the union type "T|null".
+func NewNullableType(typeDecl *TypeDeclaration) *TypeDeclaration {
+	return NewUnionType(typeDecl, NewNamedType("null"))
+}
+
+func (typeDecl *TypeDeclaration) IsBuiltin() bool {
+	return typeDecl.Kind == NamedType && slices.Contains(builtinTypes, typeDecl.Name)
+}
+
+// Returns true if the given built-in type is part of the type (e.g. "null" for "?int").
+func (typeDecl *TypeDeclaration) Contains(builtinType string) bool {
+	if typeDecl == nil {
+		return false
+	}
+	if typeDecl.Kind == NamedType {
+		return typeDecl.Name == builtinType
+	}
+	if typeDecl.Kind == UnionType {
+		return slices.ContainsFunc(typeDecl.Types, func(t *TypeDeclaration) bool { return t.Contains(builtinType) })
+	}
+	return false
+}
+
+// Returns the named types of a union type or the type itself.
+func (typeDecl *TypeDeclaration) Alternatives() []*TypeDeclaration {
+	if typeDecl.Kind == UnionType {
+		return typeDecl.Types
+	}
+	return []*TypeDeclaration{typeDecl}
+}
+
+// Order in which PHP prints the built-in types of a union type
+var builtinTypeOrder = []string{
+	"static", "callable", "iterable", "object", "array", "string", "int", "float", "bool", "false", "true", "void", "never",
+}
+
+// Returns the type in the format used by PHP in error messages (e.g. "?int", "A|B", "(A&B)|null").
+func (typeDecl *TypeDeclaration) String() string {
+	if typeDecl == nil {
+		return ""
+	}
+
+	switch typeDecl.Kind {
+	case NamedType:
+		return typeDecl.Name
+
+	case IntersectionType:
+		names := []string{}
+		for _, t := range typeDecl.Types {
+			names = append(names, t.String())
+		}
+		return strings.Join(names, "&")
+
+	case UnionType:
+		if slices.ContainsFunc(typeDecl.Types, func(t *TypeDeclaration) bool { return t.Kind == NamedType && t.Name == "mixed" }) {
+			return "mixed"
+		}
+
+		// Class types first, then built-in types in PHP's order
+		names := []string{}
+		builtins := []string{}
+		for _, t := range typeDecl.Types {
+			switch {
+			case t.Kind == IntersectionType:
+				names = append(names, "("+t.String()+")")
+			case t.IsBuiltin() && t.Name != "self" && t.Name != "parent":
+				builtins = append(builtins, t.Name)
+			default:
+				names = append(names, t.Name)
+			}
+		}
+		for _, name := range builtinTypeOrder {
+			if slices.Contains(builtins, name) {
+				names = append(names, name)
+			}
+		}
+		if slices.Contains(builtins, "null") {
+			if len(names) == 1 && !strings.HasPrefix(names[0], "(") {
+				return "?" + names[0]
+			}
+			names = append(names, "null")
+		}
+		return strings.Join(names, "|")
+
+	default:
+		return ""
+	}
+}
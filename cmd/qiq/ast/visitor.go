@@ -26,6 +26,8 @@ type Visitor interface {
 	ProcessArrayNextKeyExpr(stmt *ArrayNextKeyExpression, context any) (any, error)
 	ProcessBinaryOpExpr(stmt *BinaryOpExpression, context any) (any, error)
 	ProcessCastExpr(stmt *CastExpression, context any) (any, error)
+	ProcessClassConstantAccessExpr(stmt *ClassConstantAccessExpression, context any) (any, error)
+	ProcessCloneExpr(stmt *CloneExpression, context any) (any, error)
 	ProcessCoalesceExpr(stmt *CoalesceExpression, context any) (any, error)
 	ProcessCompoundAssignmentExpr(stmt *CompoundAssignmentExpression, context any) (any, error)
 	ProcessConditionalExpr(stmt *ConditionalExpression, context any) (any, error)
@@ -45,6 +47,7 @@ type Visitor interface {
 	ProcessLogicalExpr(stmt *LogicalExpression, context any) (any, error)
 	ProcessLogicalNotExpr(stmt *LogicalNotExpression, context any) (any, error)
 	ProcessMemberAccessExpr(stmt *MemberAccessExpression, context any) (any, error)
+	ProcessMemberCallExpr(stmt *MemberCallExpression, context any) (any, error)
 	ProcessObjectCreationExpr(stmt *ObjectCreationExpression, context any) (any, error)
 	ProcessParenthesizedExpr(stmt *ParenthesizedExpression, context any) (any, error)
 	ProcessPostfixIncExpr(stmt *PostfixIncExpression, context any) (any, error)
@@ -53,6 +56,8 @@ type Visitor interface {
 	ProcessRelationalExpr(stmt *RelationalExpression, context any) (any, error)
 	ProcessRequireExpr(stmt *RequireExpression, context any) (any, error)
 	ProcessRequireOnceExpr(stmt *RequireOnceExpression, context any) (any, error)
+	ProcessScopedCallExpr(stmt *ScopedCallExpression, context any) (any, error)
+	ProcessShellCommandExpr(stmt *ShellCommandExpression, context any) (any, error)
 	ProcessSimpleAssignmentExpr(stmt *SimpleAssignmentExpression, context any) (any, error)
 	ProcessSimpleVariableExpr(stmt *SimpleVariableExpression, context any) (any, error)
 	ProcessStringLiteralExpr(stmt *StringLiteralExpression, context any) (any, error)
@@ -60,6 +60,8 @@ func IsCastTypeKeyword(token string) bool {
 // Spec: https://phplang.org/spec/13-functions.html#grammar-base-type-declaration
 var paramTypeKeywords = []string{
 	"mixed", "array", "bool", "float", "int", "null", "string",
+	// Non-spec:
+	"callable", "false", "iterable", "object", "parent", "self", "static", "true",
 }
 
 func IsParamTypeKeyword(token string) bool {
@@ -79,7 +81,7 @@ func IsReturnTypeKeyword(token string) bool {
 	// Keywords are not case-sensitive.
 	token = strings.ToLower(token)
 
-	return token == "void" || slices.Contains(paramTypeKeywords, token)
+	return token == "void" || token == "never" || slices.Contains(paramTypeKeywords, token)
 }
 
 // Spec: https://phplang.org/spec/14-classes.html#grammar-visibility-modifier
@@ -2,6 +2,7 @@ package common
 
 import (
 	"fmt"
+	"math"
 	"regexp"
 	"strconv"
 	"strings"
@@ -266,3 +267,179 @@ func FloatingLiteralToFloat64WithSign(str string, leadingNumeric bool) float64 {
 	}
 	return result
 }
+
+// FormatFloat returns the textual representation of a float as PHP produces it
+// for the given precision (see ini directives "precision" and "serialize_precision").
+// A negative precision selects the shortest representation that can be read back without loss.
+func FormatFloat(value float64, precision int64) string {
+	return FormatFloatWithExponentChar(value, precision, 'E')
+}
+
+// FormatFloatWithExponentChar is like FormatFloat but uses the given character
+// to introduce the exponent (e.g. 'e' for the printf specifier "%g").
+func FormatFloatWithExponentChar(value float64, precision int64, exponentChar byte) string {
+	if math.IsNaN(value) {
+		return "NAN"
+	}
+	if math.IsInf(value, 1) {
+		return "INF"
+	}
+	if math.IsInf(value, -1) {
+		return "-INF"
+	}
+
+	// A precision of zero is treated as one
+	if precision == 0 {
+		precision = 1
+	}
+	// The shortest representation switches to the exponential format after 17 digits
+	formatPrecision := int(precision - 1)
+	if precision < 0 {
+		formatPrecision = -1
+		precision = 17
+	}
+
+	// Significant digits without trailing zeros and the position of the decimal point relative to them
+	mantissa, exponent, _ := strings.Cut(strconv.FormatFloat(math.Abs(value), 'e', formatPrecision, 64), "e")
+	digits := strings.TrimRight(strings.Replace(mantissa, ".", "", 1), "0")
+	decimalPoint, _ := strconv.Atoi(exponent)
+	decimalPoint++
+	if digits == "" {
+		digits = "0"
+		decimalPoint = 1
+	}
+
+	var result strings.Builder
+	if math.Signbit(value) {
+		result.WriteString("-")
+	}
+
+	switch {
+	case decimalPoint < -3 || int64(decimalPoint) > precision:
+		// Exponential format (e.g. 1.0E+25)
+		result.WriteString(digits[:1] + ".")
+		if len(digits) == 1 {
+			result.WriteString("0")
+		} else {
+			result.WriteString(digits[1:])
+		}
+		result.WriteByte(exponentChar)
+		if decimalPoint-1 < 0 {
+			result.WriteString("-" + strconv.Itoa(1-decimalPoint))
+		} else {
+			result.WriteString("+" + strconv.Itoa(decimalPoint-1))
+		}
+	case decimalPoint <= 0:
+		// Standard format with leading zeros (e.g. 0.001)
+		result.WriteString("0." + strings.Repeat("0", -decimalPoint) + digits)
+	case decimalPoint >= len(digits):
+		// Standard format without fraction (e.g. 100)
+		result.WriteString(digits + strings.Repeat("0", decimalPoint-len(digits)))
+	default:
+		// Standard format (e.g. 1.5)
+		result.WriteString(digits[:decimalPoint] + "." + digits[decimalPoint:])
+	}
+	return result.String()
+}
+
+// RoundHalfUp rounds the value to the given number of decimal places (negative places round to tens, hundreds, ...).
+// Like PHP, the value is pre-rounded to 15 significant digits first so that e.g. 1.005
+// is rounded to 1.01 although its binary representation is slightly smaller.
+func RoundHalfUp(value float64, places int) float64 {
+	if math.IsNaN(value) || math.IsInf(value, 0) || value == 0 {
+		return value
+	}
+
+	mantissa, exponentStr, _ := strings.Cut(strconv.FormatFloat(math.Abs(value), 'e', 14, 64), "e")
+	digits := strings.Replace(mantissa, ".", "", 1)
+	exponent, _ := strconv.Atoi(exponentStr)
+
+	// Number of significant digits that are kept
+	keep := exponent + 1 + places
+	if keep >= len(digits) {
+		return value
+	}
+	if keep < 0 {
+		return math.Copysign(0, value)
+	}
+
+	rounded, _ := strconv.ParseInt("0"+digits[:keep], 10, 64)
+	if digits[keep] >= '5' {
+		rounded++
+	}
+	result, _ := strconv.ParseFloat(fmt.Sprintf("%de%d", rounded, exponent+1-keep), 64)
+	return math.Copysign(result, value)
+}
+
+// -------------------------------------- Numeric string -------------------------------------- MARK: Numeric string
+
+// Spec: https://www.php.net/manual/en/language.types.numeric-strings.php
+const numericStrWhitespace = " \t\n\r\v\f"
+
+var integerNumericStrPattern = regexp.MustCompile(`^[+-]?\d+$`)
+var floatNumericStrPattern = regexp.MustCompile(`^[+-]?(\d+(\.\d*)?|\.\d+)([eE][+-]?\d+)?$`)
+
+func IsIntegerNumericString(str string) bool {
+	// Spec: https://www.php.net/manual/en/language.types.numeric-strings.php
+
+	// WHITESPACES      \s*
+	// LNUM             [0-9]+
+	// INT_NUM_STRING   WHITESPACES [+-]? LNUM WHITESPACES
+	return integerNumericStrPattern.MatchString(strings.Trim(str, numericStrWhitespace))
+}
+
+func IsNumericString(str string) bool {
+	// Spec: https://www.php.net/manual/en/language.types.numeric-strings.php
+
+	// DNUM             ([0-9]*)[\.]{LNUM}) | ({LNUM}[\.][0-9]*)
+	// EXPONENT_DNUM    (({LNUM} | {DNUM}) [eE][+-]? {LNUM})
+	// FLOAT_NUM_STRING WHITESPACES [+-]? (DNUM | EXPONENT_DNUM) WHITESPACES
+	// NUM_STRING       (INT_NUM_STRING | FLOAT_NUM_STRING)
+	return floatNumericStrPattern.MatchString(strings.Trim(str, numericStrWhitespace))
+}
+
+var leadingNumericStrPattern = regexp.MustCompile(`^[ \t\n\r\v\f]*[+-]?(\d+(\.\d*)?|\.\d+)([eE][+-]?\d+)?`)
+
+type NumericString struct {
+	IsFloat bool
+	Int     int64
+	Float   float64
+	// The number is followed by characters other than whitespaces (leading-numeric string)
+	TrailingData bool
+}
+
+// ParseNumericString parses a numeric or leading-numeric string.
+// It returns false if the string is non-numeric.
+func ParseNumericString(str string) (NumericString, bool) {
+	// Spec: https://www.php.net/manual/en/language.types.numeric-strings.php
+
+	// LNUM                    [0-9]+
+	// INT_NUM_STRING          WHITESPACES [+-]? LNUM WHITESPACES
+	// FLOAT_NUM_STRING        WHITESPACES [+-]? (DNUM | EXPONENT_DNUM) WHITESPACES
+	// LEADING_NUMERIC_STRING  WHITESPACES [+-]? (LNUM | DNUM | EXPONENT_DNUM) ANYTHING
+	match := leadingNumericStrPattern.FindString(str)
+	if match == "" {
+		return NumericString{}, false
+	}
+
+	result := NumericString{TrailingData: strings.Trim(str[len(match):], numericStrWhitespace) != ""}
+	number := strings.TrimLeft(match, numericStrWhitespace)
+	if !strings.ContainsAny(number, ".eE") {
+		if intValue, err := strconv.ParseInt(number, 10, 64); err == nil {
+			result.Int = intValue
+			return result, true
+		}
+		// Integers that do not fit into an int are floats
+	}
+	result.IsFloat = true
+	result.Float, _ = strconv.ParseFloat(number, 64)
+	return result, true
+}
+
+func NumericStringToInt64(str string) (int64, error) {
+	return strconv.ParseInt(strings.Trim(str, numericStrWhitespace), 10, 64)
+}
+
+func NumericStringToFloat64(str string) (float64, error) {
+	return strconv.ParseFloat(strings.Trim(str, numericStrWhitespace), 64)
+}
@@ -0,0 +1,111 @@
+package common
+
+import (
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestFormatFloat(t *testing.T) {
+	doTest := func(t *testing.T, value float64, precision int64, output string) {
+		if got := FormatFloat(value, precision); got != output {
+			t.Errorf("\nExpected: \"%s\".\nGot: \"%s\"", output, got)
+		}
+	}
+
+	// Precision
+	doTest(t, 1.0, 14, "1")
+	doTest(t, -1.5, 14, "-1.5")
+	doTest(t, 0.1+0.2, 14, "0.3")
+	doTest(t, 1.0/3, 14, "0.33333333333333")
+	doTest(t, 3.14159, 3, "3.14")
+	doTest(t, 100, 0, "1.0E+2")
+	doTest(t, 0.5, 14, "0.5")
+	doTest(t, 0.05, 14, "0.05")
+	doTest(t, 0.0001, 14, "0.0001")
+
+	// Shortest representation
+	doTest(t, 0.1, -1, "0.1")
+	doTest(t, 1.0/3, -1, "0.3333333333333333")
+	doTest(t, 1e15, -1, "1000000000000000")
+	doTest(t, 1e100, -1, "1.0E+100")
+	doTest(t, 1.5e-7, -1, "1.5E-7")
+	doTest(t, 9223372036854775808, -1, "9.223372036854776E+18")
+
+	// Exponential format
+	doTest(t, 1e25, 14, "1.0E+25")
+	doTest(t, 1e14, 14, "1.0E+14")
+	doTest(t, 0.00001, 14, "1.0E-5")
+	doTest(t, 9223372036854775808, 14, "9.2233720368548E+18")
+
+	// Special values
+	doTest(t, 0, 14, "0")
+	doTest(t, math.Copysign(0, -1), 14, "-0")
+	doTest(t, math.Inf(1), 14, "INF")
+	doTest(t, math.Inf(-1), 14, "-INF")
+	doTest(t, math.NaN(), 14, "NAN")
+}
+
+func FuzzFormatFloat(f *testing.F) {
+	f.Add(0.1)
+	f.Add(1e25)
+	f.Add(-1.5e-7)
+	f.Fuzz(func(t *testing.T, value float64) {
+		// The shortest representation must be read back without loss
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return
+		}
+		str := FormatFloat(value, -1)
+		parsed, err := strconv.ParseFloat(str, 64)
+		if err != nil || parsed != value {
+			t.Errorf("%v was formatted as \"%s\"", value, str)
+		}
+	})
+}
+
+func TestRoundHalfUp(t *testing.T) {
+	doTest := func(t *testing.T, value float64, places int, expected float64) {
+		if got := RoundHalfUp(value, places); got != expected {
+			t.Errorf("\nRound %v to %d places\nExpected: %v.\nGot: %v", value, places, expected, got)
+		}
+	}
+
+	doTest(t, 1.005, 2, 1.01)
+	doTest(t, 2.5, 0, 3)
+	doTest(t, -2.5, 0, -3)
+	doTest(t, 0.285, 2, 0.29)
+	doTest(t, 1.4999, 0, 1)
+	doTest(t, 1234.5678, -2, 1200)
+	doTest(t, 0.004, 0, 0)
+	doTest(t, 1e20, 2, 1e20)
+}
+
+func TestParseNumericString(t *testing.T) {
+	doTest := func(t *testing.T, input string, expected NumericString, isNumeric bool) {
+		got, ok := ParseNumericString(input)
+		if ok != isNumeric || got != expected {
+			t.Errorf("\nInput: \"%s\"\nExpected: %v, %v.\nGot: %v, %v", input, expected, isNumeric, got, ok)
+		}
+	}
+
+	doTest(t, "42", NumericString{Int: 42}, true)
+	doTest(t, " \t-42\n ", NumericString{Int: -42}, true)
+	doTest(t, "+1.5", NumericString{IsFloat: true, Float: 1.5}, true)
+	doTest(t, ".5", NumericString{IsFloat: true, Float: 0.5}, true)
+	doTest(t, "1.", NumericString{IsFloat: true, Float: 1}, true)
+	doTest(t, "1e3", NumericString{IsFloat: true, Float: 1000}, true)
+	doTest(t, "99999999999999999999", NumericString{IsFloat: true, Float: 1e20}, true)
+
+	// Leading-numeric strings
+	doTest(t, "12abc", NumericString{Int: 12, TrailingData: true}, true)
+	doTest(t, "1e", NumericString{Int: 1, TrailingData: true}, true)
+	doTest(t, "0x1A", NumericString{Int: 0, TrailingData: true}, true)
+	doTest(t, "1.5 apples", NumericString{IsFloat: true, Float: 1.5, TrailingData: true}, true)
+
+	// Non-numeric strings
+	doTest(t, "", NumericString{}, false)
+	doTest(t, " ", NumericString{}, false)
+	doTest(t, "abc", NumericString{}, false)
+	doTest(t, ".", NumericString{}, false)
+	doTest(t, "- 1", NumericString{}, false)
+}
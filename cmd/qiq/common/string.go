@@ -2,11 +2,14 @@ package common
 
 import (
 	"regexp"
+	"strconv"
 	"strings"
 )
 
 const (
-	nameRegex string = `[_a-zA-Z\x80-\xff][_a-zA-Z0-9\x80-\xff]*`
+	// Go regular expressions match runes and not bytes. Every byte in the range 0x80-0xff is part of a multi-byte
+	// UTF-8 sequence or an invalid byte (U+FFFD). Both are matched by "[^\x00-\x7f]".
+	nameRegex string = `(?:[_a-zA-Z]|[^\x00-\x7f])(?:[_a-zA-Z0-9]|[^\x00-\x7f])*`
 )
 
 func IsVariableName(name string) bool {
@@ -201,38 +204,138 @@ func IsDoubleQuotedStringLiteral(str string) bool {
 	return match
 }
 
-func ReplaceDoubleQuoteControlChars(str string) string {
-	findCtrlChars := regexp.MustCompile(
-		`(\\"|\\\\|\\\$|\\e|\\f|\\n|\\r|\\t|\\v)|` +
-			`(\\[0-7]{1,3})|` +
-			`(\\[xX][0-9a-fA-F]{1,2})|` +
-			`(\\u\{[0-9a-fA-F]+\})|` +
-			`([^"\\])|` +
-			`(\\[^"\\$efnrtvxX]|\\[0-7])`,
-	)
+var doubleQuoteCtrlCharsRegex = regexp.MustCompile(
+	`(\\"|\\\\|\\\$|\\e|\\f|\\n|\\r|\\t|\\v)|` +
+		`(\\[0-7]{1,3})|` +
+		`(\\[xX][0-9a-fA-F]{1,2})|` +
+		`(\\u\{[0-9a-fA-F]+\})|` +
+		`([^"\\])|` +
+		`(\\[^"\\$efnrtvxX]|\\[0-7])`,
+)
 
-	return findCtrlChars.ReplaceAllStringFunc(str,
+func ReplaceDoubleQuoteControlChars(str string) string {
+	return doubleQuoteCtrlCharsRegex.ReplaceAllStringFunc(str,
 		func(sub string) string {
 			switch sub {
 			case `\"`:
 				return `"`
-			case `\\`:
-				return `\`
-			case `\r`:
-				return "\r"
-			case `\n`:
-				return "\n"
-			case `\t`:
-				return "\t"
 			default:
-				return sub
+				return replaceEscapeSequence(sub)
 			}
 		},
 	)
 }
 
+// Replace an escape sequence of a double quoted or heredoc string with the bytes it represents.
+// Unknown escape sequences are returned unchanged.
+func replaceEscapeSequence(sub string) string {
+	if len(sub) < 2 || sub[0] != '\\' {
+		return sub
+	}
+
+	switch sub[1] {
+	case '\\':
+		return `\`
+	case '$':
+		return "$"
+	case 'e':
+		return "\x1b"
+	case 'f':
+		return "\f"
+	case 'n':
+		return "\n"
+	case 'r':
+		return "\r"
+	case 't':
+		return "\t"
+	case 'v':
+		return "\v"
+	case '0', '1', '2', '3', '4', '5', '6', '7':
+		// Spec: https://www.php.net/manual/en/language.types.string.php#language.types.string.syntax.double
+		// Octal escape sequences above "\377" silently overflow (e.g. "\400" === "\000")
+		code, err := strconv.ParseUint(sub[1:], 8, 16)
+		if err != nil {
+			return sub
+		}
+		return string([]byte{byte(code)})
+	case 'x', 'X':
+		code, err := strconv.ParseUint(sub[2:], 16, 8)
+		if err != nil {
+			return sub
+		}
+		return string([]byte{byte(code)})
+	case 'u':
+		if len(sub) < 4 || sub[2] != '{' {
+			return sub
+		}
+		codepoint, err := strconv.ParseUint(sub[3:len(sub)-1], 16, 32)
+		if err != nil || codepoint > MaxUnicodeCodepoint {
+			return sub
+		}
+		return EncodeUtf8(uint32(codepoint))
+	default:
+		return sub
+	}
+}
+
+const MaxUnicodeCodepoint = 0x10FFFF
+
+// Encode a codepoint as UTF-8. Other than utf8.EncodeRune, surrogates are encoded as they are (like PHP does).
+func EncodeUtf8(codepoint uint32) string {
+	switch {
+	case codepoint < 0x80:
+		return string([]byte{byte(codepoint)})
+	case codepoint < 0x800:
+		return string([]byte{0xC0 | byte(codepoint>>6), 0x80 | byte(codepoint&0x3F)})
+	case codepoint < 0x10000:
+		return string([]byte{0xE0 | byte(codepoint>>12), 0x80 | byte(codepoint>>6&0x3F), 0x80 | byte(codepoint&0x3F)})
+	default:
+		return string([]byte{
+			0xF0 | byte(codepoint>>18), 0x80 | byte(codepoint>>12&0x3F), 0x80 | byte(codepoint>>6&0x3F), 0x80 | byte(codepoint&0x3F),
+		})
+	}
+}
+
+// Check if the string contains a unicode escape sequence with a codepoint above U+10FFFF (e.g. "\u{110000}").
+// Escaped backslashes (e.g. "\\u{110000}") are skipped.
+func HasInvalidUnicodeEscape(str string) bool {
+	for _, sub := range doubleQuoteCtrlCharsRegex.FindAllString(str, -1) {
+		if !strings.HasPrefix(sub, `\u{`) {
+			continue
+		}
+		codepoint, err := strconv.ParseUint(sub[3:len(sub)-1], 16, 64)
+		if err != nil || codepoint > MaxUnicodeCodepoint {
+			return true
+		}
+	}
+	return false
+}
+
 func DoubleQuotedStringLiteralToString(str string) string {
-	return ReplaceDoubleQuoteControlChars(extractStringContent(str))
+	return ReplaceDoubleQuoteControlChars(DoubleQuotedStringLiteralContent(str))
+}
+
+// Get the content of a double quoted string without replacing the escape sequences
+func DoubleQuotedStringLiteralContent(str string) string {
+	return extractStringContent(str)
+}
+
+func IsShellCommandLiteral(str string) bool {
+	// Spec: https://phplang.org/spec/10-expressions.html#grammar-shell-command-expression
+
+	// shell-command-expression:
+	//    `   dq-char-sequence(opt)   `
+
+	return len(str) >= 2 && str[0:1] == "`" && str[len(str)-1:] == "`"
+}
+
+func ShellCommandLiteralToString(str string) string {
+	return ReplaceDoubleQuoteControlChars(ShellCommandLiteralContent(str))
+}
+
+// Get the content of a shell command without replacing the escape sequences
+func ShellCommandLiteralContent(str string) string {
+	return strings.ReplaceAll(extractStringContent(str), "\\`", "`")
 }
 
 func IsHeredocStringLiteral(str string) bool {
@@ -285,6 +388,11 @@ func IsHeredocStringLiteral(str string) bool {
 }
 
 func HeredocStringLiteralToString(str string) string {
+	return ReplaceHeredocControlChars(HeredocStringLiteralContent(str))
+}
+
+// Get the body of a heredoc string without replacing the escape sequences
+func HeredocStringLiteralContent(str string) string {
 	lines := strings.SplitN(str, "\n", 2)
 	if len(lines) < 2 {
 		return ""
@@ -295,37 +403,20 @@ func HeredocStringLiteralToString(str string) string {
 	if len(bodyLines) < 2 {
 		return ""
 	}
-	body = strings.Join(bodyLines[:len(bodyLines)-1], "\n")
-
-	return ReplaceHeredocControlChars(body)
+	return strings.Join(bodyLines[:len(bodyLines)-1], "\n")
 }
 
-func ReplaceHeredocControlChars(str string) string {
-	findCtrlChars := regexp.MustCompile(
-		`(\\\\|\\\$|\\e|\\f|\\n|\\r|\\t|\\v)|` +
-			`(\\[0-7]{1,3})|` +
-			`(\\[xX][0-9a-fA-F]{1,2})|` +
-			`(\\u\{[0-9a-fA-F]+\})|` +
-			`([^"\\])|` +
-			`(\\[^"\\$efnrtvxX]|\\[0-7])`,
-	)
+var heredocCtrlCharsRegex = regexp.MustCompile(
+	`(\\\\|\\\$|\\e|\\f|\\n|\\r|\\t|\\v)|` +
+		`(\\[0-7]{1,3})|` +
+		`(\\[xX][0-9a-fA-F]{1,2})|` +
+		`(\\u\{[0-9a-fA-F]+\})|` +
+		`([^"\\])|` +
+		`(\\[^"\\$efnrtvxX]|\\[0-7])`,
+)
 
-	return findCtrlChars.ReplaceAllStringFunc(str,
-		func(sub string) string {
-			switch sub {
-			case `\\`:
-				return `\`
-			case `\r`:
-				return "\r"
-			case `\n`:
-				return "\n"
-			case `\t`:
-				return "\t"
-			default:
-				return sub
-			}
-		},
-	)
+func ReplaceHeredocControlChars(str string) string {
+	return heredocCtrlCharsRegex.ReplaceAllStringFunc(str, replaceEscapeSequence)
 }
 
 func TrimTrailingLineBreak(str string) string {
@@ -350,21 +441,126 @@ func ReplaceUnderscores(str string) string {
 	return strings.ReplaceAll(str, "_", "")
 }
 
+// Replace the byte at the given position with the first byte of new
 func ReplaceAtPos(str string, new string, pos int) string {
-	out := []rune(str)
-	out[pos] = []rune(new)[0]
-	return string(out)
+	return str[:pos] + new[:1] + str[pos+1:]
 }
 
 func ExtendWithSpaces(str string, length int) string {
-	input := []rune(str)
-	if len(input) > length {
+	if len(str) > length {
 		return str
 	}
+	return str + strings.Repeat(" ", length-len(str))
+}
+
+// NaturalCompare compares two strings using a "natural order" algorithm (like strnatcmp and strnatcasecmp).
+// It returns -1, 0 or 1 if lhs is less than, equal to, or greater than rhs.
+func NaturalCompare(lhs string, rhs string, ignoreCase bool) int {
+	// Spec: https://github.com/php/php-src/blob/master/ext/standard/strnatcmp.c
+	if lhs == "" || rhs == "" {
+		return compareInts(len(lhs), len(rhs))
+	}
+
+	// Bytes beyond the end of a string are read as NUL bytes
+	byteAt := func(str string, pos int) byte {
+		if pos < len(str) {
+			return str[pos]
+		}
+		return 0
+	}
+	isDigit := func(char byte) bool { return char >= '0' && char <= '9' }
+	isSpace := func(char byte) bool { return char == ' ' || (char >= '\t' && char <= '\r') }
+
+	lhsPos, rhsPos := 0, 0
+	leading := true
+	for {
+		lhsChar, rhsChar := lhs[lhsPos], rhs[rhsPos]
+
+		// Skip over leading zeros
+		for leading && lhsChar == '0' && isDigit(byteAt(lhs, lhsPos+1)) {
+			lhsPos++
+			lhsChar = lhs[lhsPos]
+		}
+		for leading && rhsChar == '0' && isDigit(byteAt(rhs, rhsPos+1)) {
+			rhsPos++
+			rhsChar = rhs[rhsPos]
+		}
+		leading = false
+
+		// Skip consecutive whitespace
+		for isSpace(lhsChar) {
+			lhsPos++
+			lhsChar = byteAt(lhs, lhsPos)
+		}
+		for isSpace(rhsChar) {
+			rhsPos++
+			rhsChar = byteAt(rhs, rhsPos)
+		}
+
+		// Process run of digits
+		if isDigit(lhsChar) && isDigit(rhsChar) {
+			// Numbers with leading zeros are compared as fractional part (left-aligned).
+			// Otherwise, the longest run of digits wins and if they have the same length, the first different digit.
+			fractional := lhsChar == '0' || rhsChar == '0'
+			bias := 0
+			for ; ; lhsPos, rhsPos = lhsPos+1, rhsPos+1 {
+				lhsIsDigit, rhsIsDigit := isDigit(byteAt(lhs, lhsPos)), isDigit(byteAt(rhs, rhsPos))
+				if !lhsIsDigit && !rhsIsDigit {
+					break
+				}
+				if !lhsIsDigit {
+					return -1
+				}
+				if !rhsIsDigit {
+					return 1
+				}
+				if result := compareInts(int(lhs[lhsPos]), int(rhs[rhsPos])); result != 0 {
+					if fractional {
+						return result
+					}
+					if bias == 0 {
+						bias = result
+					}
+				}
+			}
+			if bias != 0 {
+				return bias
+			}
+			if lhsPos >= len(lhs) || rhsPos >= len(rhs) {
+				return compareInts(len(lhs)-lhsPos, len(rhs)-rhsPos)
+			}
+			lhsChar, rhsChar = lhs[lhsPos], rhs[rhsPos]
+		}
+
+		if ignoreCase {
+			lhsChar, rhsChar = toUpperAscii(lhsChar), toUpperAscii(rhsChar)
+		}
+		if result := compareInts(int(lhsChar), int(rhsChar)); result != 0 {
+			return result
+		}
+
+		lhsPos++
+		rhsPos++
+		if lhsPos >= len(lhs) || rhsPos >= len(rhs) {
+			return compareInts(len(lhs)-lhsPos, len(rhs)-rhsPos)
+		}
+	}
+}
+
+func compareInts(lhs int, rhs int) int {
+	switch {
+	case lhs < rhs:
+		return -1
+	case lhs > rhs:
+		return 1
+	default:
+		return 0
+	}
+}
 
-	padding := make([]rune, length-len(input))
-	for i := range padding {
-		padding[i] = ' '
+func toUpperAscii(char byte) byte {
+	if char >= 'a' && char <= 'z' {
+		return char - 32
 	}
-	return string(append(input, padding...))
+	return char
 }
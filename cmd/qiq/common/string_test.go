@@ -1,6 +1,11 @@
 package common
 
-import "testing"
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
 
 func TestReplaceSingleQuoteControlChars(t *testing.T) {
 	doTest := func(t *testing.T, input string, output string) {
@@ -36,6 +41,52 @@ func TestReplaceDoubleQuoteControlChars(t *testing.T) {
 	doTest(t, `\\n`, `\n`)
 	doTest(t, `\\\n`, `\`+"\n")
 	doTest(t, `\\hi\\\n`, `\hi\`+"\n")
+
+	doTest(t, `\e\f\v\$`, "\x1b\f\v$")
+	doTest(t, `\x41\X4a\x7\xFF`, "AJ\x07\xff")
+	doTest(t, `\101\0\7a\400\8`, "A\x00\x07a\x00\\8")
+	doTest(t, `\u{41}\u{0000E4}\u{1F600}\u{D800}\u{110000}`, "A\u00e4\U0001F600\xed\xa0\x80\\u{110000}")
+	doTest(t, `\\x41\\101\\u{41}`, `\x41\101\u{41}`)
+	doTest(t, "\xff\x80\\x00", "\xff\x80\x00")
+}
+
+func FuzzReplaceDoubleQuoteControlChars(f *testing.F) {
+	f.Add([]byte("abc"))
+	f.Add([]byte{0x00, 0x7f, 0x80, 0xff})
+	f.Add([]byte("\xc3\xa4\\\""))
+	f.Fuzz(func(t *testing.T, input []byte) {
+		// Every byte written as hexadecimal and as octal escape sequence must result in the same bytes
+		var hexEscaped, octalEscaped strings.Builder
+		for _, b := range input {
+			fmt.Fprintf(&hexEscaped, `\x%x`, b)
+			fmt.Fprintf(&octalEscaped, `\%03o`, b)
+		}
+		if got := ReplaceDoubleQuoteControlChars(hexEscaped.String()); got != string(input) {
+			t.Errorf("\nExpected: %x\nGot:      %x", input, got)
+		}
+		if got := ReplaceHeredocControlChars(octalEscaped.String()); got != string(input) {
+			t.Errorf("\nExpected: %x\nGot:      %x", input, got)
+		}
+	})
+}
+
+func FuzzEncodeUtf8(f *testing.F) {
+	f.Add(uint32(0x41))
+	f.Add(uint32(0xE4))
+	f.Add(uint32(0x20AC))
+	f.Add(uint32(0x1F600))
+	f.Add(uint32(0x10FFFF))
+	f.Fuzz(func(t *testing.T, codepoint uint32) {
+		codepoint %= MaxUnicodeCodepoint + 1
+		if utf8.ValidRune(rune(codepoint)) {
+			if got := EncodeUtf8(codepoint); got != string(rune(codepoint)) {
+				t.Errorf("\nExpected: %x\nGot:      %x", string(rune(codepoint)), got)
+			}
+		}
+		if got := ReplaceDoubleQuoteControlChars(fmt.Sprintf(`\u{%x}`, codepoint)); got != EncodeUtf8(codepoint) {
+			t.Errorf("\nExpected: %x\nGot:      %x", EncodeUtf8(codepoint), got)
+		}
+	})
 }
 
 func TestReplaceAtPos(t *testing.T) {
@@ -48,6 +99,7 @@ func TestReplaceAtPos(t *testing.T) {
 	doTest(t, "abc", "*", 1, "a*c")
 	doTest(t, "abc", "**", 1, "a*c")
 	doTest(t, "abc", "**", 0, "*bc")
+	doTest(t, "\xc3\xa4", "\xff", 1, "\xc3\xff")
 }
 
 func TestExtendWithSpaces(t *testing.T) {
@@ -61,6 +113,7 @@ func TestExtendWithSpaces(t *testing.T) {
 	doTest(t, "abc", 1, "abc")
 	doTest(t, "abc", 4, "abc ")
 	doTest(t, "abc", 10, "abc       ")
+	doTest(t, "\xc3\xa4", 3, "\xc3\xa4 ")
 }
 
 func TestIsName(t *testing.T) {
@@ -113,3 +166,26 @@ func TestIsVariableName(t *testing.T) {
 	doTest(t, "$0", false)
 	doTest(t, "var", false)
 }
+
+func TestNaturalCompare(t *testing.T) {
+	doTest := func(t *testing.T, lhs string, rhs string, ignoreCase bool, output int) {
+		if got := NaturalCompare(lhs, rhs, ignoreCase); got != output {
+			t.Errorf("\nInput: \"%s\", \"%s\"\nExpected: \"%d\" Got: \"%d\"", lhs, rhs, output, got)
+		}
+	}
+
+	doTest(t, "img12.png", "img10.png", false, 1)
+	doTest(t, "img2.png", "img10.png", false, -1)
+	doTest(t, "img1.png", "img1.png", false, 0)
+	doTest(t, "IMG1", "img2", false, -1)
+	doTest(t, "img1", "IMG2", false, 1)
+	doTest(t, "img1", "IMG2", true, -1)
+	doTest(t, "Hello", "hello", true, 0)
+	doTest(t, "1.02", "1.1", false, -1)
+	doTest(t, "007", "7", false, 0)
+	doTest(t, "a  1", "a 1", false, 0)
+	doTest(t, "", "a", false, -1)
+	doTest(t, "a", "", false, 1)
+	doTest(t, "abc", "ab", false, 1)
+	doTest(t, "x2-g8", "x2-y7", false, -1)
+}
@@ -4,6 +4,7 @@ import (
 	"QIQ/cmd/qiq/common"
 	"QIQ/cmd/qiq/phpError"
 	"slices"
+	"strconv"
 	"strings"
 )
 
@@ -24,6 +25,7 @@ var allowedDirectives = map[string]int{
 	"arg_separator.input":           INI_SYSTEM,
 	"arg_separator.output":          INI_ALL,
 	"default_charset":               INI_ALL,
+	"disable_functions":             INI_SYSTEM,
 	"error_reporting":               INI_ALL,
 	"expose_php":                    INI_SYSTEM,
 	"file_uploads":                  INI_SYSTEM,
@@ -32,26 +34,44 @@ var allowedDirectives = map[string]int{
 	"internal_encoding":             INI_ALL,
 	"max_input_nesting_level":       INI_PERDIR,
 	"max_input_vars":                INI_PERDIR,
+	"mbstring.detect_order":         INI_ALL,
 	"mbstring.encoding_translation": INI_PERDIR,
+	"mbstring.strict_detection":     INI_ALL,
+	"opcache.enable":                INI_ALL,
+	"opcache.revalidate_freq":       INI_ALL,
+	"opcache.validate_timestamps":   INI_ALL,
 	"open_basedir":                  INI_ALL,
 	"output_encoding":               INI_ALL,
+	"pcre.backtrack_limit":          INI_ALL,
+	"pcre.recursion_limit":          INI_ALL,
 	"post_max_size":                 INI_PERDIR,
+	"precision":                     INI_ALL,
+	"qiq.engine":                    INI_SYSTEM,
 	"register_argc_argv":            INI_PERDIR,
+	"serialize_precision":           INI_ALL,
 	"short_open_tag":                INI_PERDIR,
 	"session.name":                  INI_ALL,
 	"session.save_path":             INI_ALL,
+	"unserialize_max_depth":         INI_ALL,
 	"upload_max_filesize":           INI_PERDIR,
 	"upload_tmp_dir":                INI_SYSTEM,
 	"variables_order":               INI_PERDIR,
+	"zend.enable_gc":                INI_ALL,
 }
 
 var boolDirectives = []string{
-	"expose_php", "file_uploads", "mbstring.encoding_translation",
-	"register_argc_argv", "short_open_tag",
+	"expose_php", "file_uploads", "mbstring.encoding_translation", "mbstring.strict_detection",
+	"opcache.enable", "opcache.validate_timestamps", "register_argc_argv", "short_open_tag", "zend.enable_gc",
 }
 
 var intDirectives = []string{
-	"error_reporting", "max_input_nesting_level", "max_input_vars",
+	"error_reporting", "max_input_nesting_level", "max_input_vars", "opcache.revalidate_freq",
+	"pcre.backtrack_limit", "pcre.recursion_limit", "precision", "serialize_precision",
+	"unserialize_max_depth",
+}
+
+var enumDirectives = map[string][]string{
+	"qiq.engine": {"ast", "vm"},
 }
 
 type Ini struct {
@@ -66,6 +86,7 @@ func NewDefaultIni() *Ini {
 			"arg_separator.input":           "&",
 			"arg_separator.output":          "&",
 			"default_charset":               "UTF-8",
+			"disable_functions":             "",
 			"error_reporting":               "0",
 			"expose_php":                    "",
 			"file_uploads":                  "1",
@@ -74,17 +95,29 @@ func NewDefaultIni() *Ini {
 			"internal_encoding":             "",
 			"max_input_nesting_level":       "64",
 			"max_input_vars":                "1000",
+			"mbstring.detect_order":         "",
 			"mbstring.encoding_translation": "",
+			"mbstring.strict_detection":     "",
+			"opcache.enable":                "1",
+			"opcache.revalidate_freq":       "2",
+			"opcache.validate_timestamps":   "1",
 			"open_basedir":                  "",
 			"output_encoding":               "",
+			"pcre.backtrack_limit":          "1000000",
+			"pcre.recursion_limit":          "100000",
 			"post_max_size":                 "8M",
+			"precision":                     "14",
+			"qiq.engine":                    "vm",
 			"register_argc_argv":            "",
+			"serialize_precision":           "-1",
 			"short_open_tag":                "",
 			"session.name":                  "PHPSESSID",
 			"session.save_path":             "",
+			"unserialize_max_depth":         "4096",
 			"upload_max_filesize":           "2M",
 			"upload_tmp_dir":                "",
 			"variables_order":               "EGPCS",
+			"zend.enable_gc":                "1",
 		},
 	}
 }
@@ -142,6 +175,10 @@ func (ini *Ini) Set(directive string, value string, source int) phpError.Error {
 		return nil
 	}
 
+	if allowedValues, found := enumDirectives[directive]; found && !slices.Contains(allowedValues, value) {
+		return phpError.NewError("Invalid value \"%s\" for %s. Allowed values: %s", value, directive, strings.Join(allowedValues, ", "))
+	}
+
 	ini.directives[directive] = value
 	return nil
 }
@@ -167,6 +204,9 @@ func (ini *Ini) GetInt(directive string) int64 {
 	if err != nil {
 		return -1
 	}
+	if intVal, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return intVal
+	}
 	if common.IsIntegerLiteralWithSign(value, false) {
 		intVal, _ := common.IntegerLiteralToInt64WithSign(value, false)
 		return intVal
@@ -0,0 +1,192 @@
+package interpreter
+
+import (
+	"QIQ/cmd/qiq/ast"
+	"QIQ/cmd/qiq/runtime"
+	"QIQ/cmd/qiq/runtime/values"
+)
+
+// -------------------------------------- Opcodes -------------------------------------- MARK: Opcodes
+
+type opcode uint8
+
+const (
+	// Push the constant with the index arg
+	opConst opcode = iota
+	// Push the value of the named constant with the name index arg
+	opConstant
+	// Discard the topmost value
+	opPop
+	// Duplicate the topmost value
+	opDup
+
+	// Push the value of the variable arg. With arg2 = 1 undefined variables are not reported.
+	opLoad
+	// Pop a value and assign it to the variable arg. With arg2 = 1 the assignment is a statement of its own
+	// and the objects that became unreferenced are freed afterwards.
+	opStore
+	// Same as opStore but the value stays on the stack
+	opAssign
+	// Unset the variable arg
+	opUnset
+	// Increment or decrement the variable arg (see incDec* flags in arg2)
+	opIncDec
+	// Prepare the assignment to an element of the variable arg: null is converted to an empty array.
+	// If the variable contains any other value than an array, the whole assignment is processed by the
+	// tree-walking interpreter and the execution continues at arg2.
+	opInitDim
+	// Pop a value and arg2 keys and assign the value to the array element of the variable arg. The value is pushed.
+	opAssignDim
+	// Pop a key and a container and push the element. With arg2 = 1 no warnings are reported and errors result in null.
+	opFetchDim
+
+	// Binary operators with fast paths for integers
+	opAdd
+	opSub
+	opMul
+	opMod
+	opConcat
+	// Any other binary operator with the operator at the name index arg
+	opBinary
+	opLess
+	opLessEqual
+	opGreater
+	opGreaterEqual
+	// Any other relational operator with the operator at the name index arg
+	opRelational
+	opEqual
+	opNotEqual
+	opIdentical
+	opNotIdentical
+	// Any other equality operator with the operator at the name index arg
+	opEquality
+	// Unary operator with the operator at the name index arg
+	opUnary
+	opNot
+	opToBool
+	// Cast with the cast type at the name index arg
+	opCast
+
+	// Continue the execution at arg
+	opJump
+	// Pop a value and jump to arg if the value is false
+	opJumpIfFalse
+	// Pop two values, compare them with the comparison opcode arg2 (e.g. opLess) and jump to arg if the result is false
+	opCompareJump
+	// Pop a value and jump to arg if the value is true
+	opJumpIfTrue
+	// Jump to arg and keep the value if it is not null. Otherwise the value is discarded.
+	opJumpIfNotNull
+	// Pop a value and jump to arg if the value is null
+	opJumpIfNull
+
+	opEcho
+	opPrint
+	// Return the topmost value
+	opReturn
+	opReturnVoid
+	// End of the code without a return statement
+	opEnd
+
+	opNewArray
+	// Pop a value and append it to the array on the stack
+	opArrayAppend
+	// Pop a value and a key and insert it into the array on the stack
+	opArrayInsert
+	// Push the interpolated double quoted string with the index arg
+	opInterpolate
+
+	// Resolve the function of the call site arg and check the argument count
+	opCheckCall
+	// Pop arg2 arguments and call the function of the call site arg
+	opCall
+
+	// Pop a value and start iterating over it. If it is not iterable, the execution continues at arg.
+	opIterInit
+	// Push the next value (and the key if arg2 = 1) of the current iterator.
+	// If there are no more elements, the iterator is removed and the execution continues at arg.
+	opIterNext
+	// Remove the current iterator (e.g. if a foreach loop is left with break)
+	opIterPop
+
+	// Destruct and free the objects that became unreferenced during the statement
+	opFreeObjects
+	// Suppress all errors (e.g. for the condition of "??")
+	opSuppressErrors
+	// Restore the error reporting that was active before opSuppressErrors
+	opRestoreErrors
+
+	// Process the node with the tree-walking interpreter. The loops surrounding the node are described
+	// by the jump table arg (-1 if there are none). With arg2 = 1 the result is pushed.
+	opFallback
+)
+
+// Flags of opIncDec
+const (
+	incDecPrefix    = 1
+	incDecDecrement = 2
+	// The result is not used (e.g. `$i++;`)
+	incDecDiscard = 4
+)
+
+// -------------------------------------- Compiled unit -------------------------------------- MARK: Compiled unit
+
+type instruction struct {
+	op   opcode
+	arg  int
+	arg2 int
+	// Node the instruction was compiled from. It is used for error messages and by opFallback.
+	node ast.IStatement
+}
+
+type compiledVariable struct {
+	name string
+	// Named variables are accessed by name instead of a slot
+	// (e.g. variables of the global scope and superglobals)
+	named bool
+}
+
+type callSite struct {
+	expr *ast.FunctionCallExpression
+	name string
+	// Resolved on the first call
+	native   runtime.NativeFunction
+	function *ast.FunctionDefinitionStatement
+	// Compiled body of the user function
+	unit *compiledUnit
+}
+
+type interpolation struct {
+	expr *ast.StringLiteralExpression
+	// Text and variable substitutions of the string and the index of each substituted variable
+	parts     []stringPart
+	variables []int
+}
+
+// Targets of break and continue for a loop surrounding a fallback instruction
+type loopTarget struct {
+	breakTarget    int
+	continueTarget int
+	// Number of foreach iterators before entering the loop
+	outerIterators int
+	// Number of foreach iterators inside the loop
+	innerIterators int
+}
+
+// A function body or a program compiled to bytecode
+type compiledUnit struct {
+	code           []instruction
+	constants      []values.RuntimeValue
+	names          []string
+	variables      []compiledVariable
+	callSites      []*callSite
+	interpolations []*interpolation
+	// Jump tables of fallback instructions. The innermost loop comes first.
+	jumpTables [][]loopTarget
+	// Number of variables that are stored in the slots of the environment
+	slots int
+	// The code contains opSuppressErrors
+	suppressesErrors bool
+	// Maximum number of values on the stack
+	maxStackDepth int
+}
@@ -0,0 +1,1004 @@
+package interpreter
+
+import (
+	"QIQ/cmd/qiq/ast"
+	"QIQ/cmd/qiq/runtime/values"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// The compiler translates the AST of a program or function body into bytecode for the VM (see vm.go).
+// Nodes that are not supported by the compiler are processed by the tree-walking interpreter (see opFallback),
+// so that both engines always produce the same output.
+
+type compilerLoop struct {
+	breakJumps     []int
+	continueJumps  []int
+	breakTarget    int
+	continueTarget int
+	outerIterators int
+	innerIterators int
+}
+
+type constantKey struct {
+	valueType values.ValueType
+	value     any
+}
+
+type compiler struct {
+	interpreter     *Interpreter
+	unit            *compiledUnit
+	variableIndexes map[string]int
+	constantIndexes map[constantKey]int
+	nameIndexes     map[string]int
+	loops           []*compilerLoop
+	iterators       int
+	// Number of emitted instructions that might release an object (see compileStmt)
+	releases int
+	// Loops of the jump tables. They are resolved after the compilation.
+	jumpTables [][]*compilerLoop
+	// Index of the jump table for the current loops (-1 if there is none yet)
+	jumpTable int
+	// Number of values on the stack after the last emitted instruction (see stackEffect)
+	stackDepth int
+	// Index of the latest instruction that is the target of a jump
+	jumpTarget int
+}
+
+var simpleVariableSubstitutionRegex = regexp.MustCompile(`^\$[A-Za-z_][A-Za-z0-9_]*$`)
+
+var magicConstants = []string{"__DIR__", "__FILE__", "__LINE__", "__FUNCTION__", "__CLASS__", "__METHOD__", "PHP_BUILD_DATE"}
+
+// The variables of the scope are the first variables of the compiled unit and use the same slots.
+func newCompiler(interpreter *Interpreter, scope *ast.Scope) *compiler {
+	compiler := &compiler{
+		interpreter:     interpreter,
+		unit:            &compiledUnit{},
+		variableIndexes: map[string]int{},
+		constantIndexes: map[constantKey]int{},
+		nameIndexes:     map[string]int{},
+		jumpTable:       -1,
+	}
+	if scope != nil {
+		for slot, name := range scope.Variables {
+			compiler.unit.variables = append(compiler.unit.variables, compiledVariable{name: name})
+			compiler.variableIndexes[name] = slot
+		}
+		compiler.unit.slots = len(scope.Variables)
+	}
+	return compiler
+}
+
+// Compile the statements of a program. The variables are stored in the environment.
+func (interpreter *Interpreter) compileProgram(statements []ast.IStatement) *compiledUnit {
+	compiler := newCompiler(interpreter, nil)
+	for _, stmt := range statements {
+		compiler.compileStmt(stmt)
+	}
+	return compiler.finish()
+}
+
+// Compile the body of a function or method. The result is cached.
+func (interpreter *Interpreter) compileFunctionBody(function ast.IStatement, scope *ast.Scope, body *ast.CompoundStatement) *compiledUnit {
+	if unit, found := interpreter.compiledFunctions[function]; found {
+		return unit
+	}
+
+	compiler := newCompiler(interpreter, scope)
+	compiler.compileStmt(body)
+	unit := compiler.finish()
+	interpreter.compiledFunctions[function] = unit
+	return unit
+}
+
+func (compiler *compiler) finish() *compiledUnit {
+	compiler.emit(opEnd, 0, 0, nil)
+
+	for _, loops := range compiler.jumpTables {
+		table := make([]loopTarget, len(loops))
+		for index, loop := range loops {
+			table[index] = loopTarget{
+				breakTarget:    loop.breakTarget,
+				continueTarget: loop.continueTarget,
+				outerIterators: loop.outerIterators,
+				innerIterators: loop.innerIterators,
+			}
+		}
+		compiler.unit.jumpTables = append(compiler.unit.jumpTables, table)
+	}
+
+	return compiler.unit
+}
+
+// -------------------------------------- Helpers -------------------------------------- MARK: Helpers
+
+func (compiler *compiler) emit(op opcode, arg int, arg2 int, node ast.IStatement) int {
+	switch op {
+	case opCall, opFallback, opUnset, opAssign, opAssignDim, opIterInit:
+		compiler.releases++
+	case opStore:
+		// Statement-level stores free the unreferenced objects themselves
+		if arg2 == 0 {
+			compiler.releases++
+		}
+	case opSuppressErrors:
+		compiler.unit.suppressesErrors = true
+	}
+	compiler.stackDepth = max(compiler.stackDepth+stackEffect(op, arg2), 0)
+	compiler.unit.maxStackDepth = max(compiler.unit.maxStackDepth, compiler.stackDepth)
+
+	// A comparison followed by opJumpIfFalse (e.g. the condition of a loop) is fused to opCompareJump.
+	// It is not possible if another jump continues at the opJumpIfFalse.
+	if last := len(compiler.unit.code) - 1; op == opJumpIfFalse && last >= 0 && compiler.jumpTarget != last+1 {
+		switch comparison := compiler.unit.code[last].op; comparison {
+		case opLess, opLessEqual, opGreater, opGreaterEqual, opEqual, opNotEqual, opIdentical, opNotIdentical:
+			compiler.unit.code[last].op = opCompareJump
+			compiler.unit.code[last].arg = arg
+			compiler.unit.code[last].arg2 = int(comparison)
+			return last
+		}
+	}
+
+	compiler.unit.code = append(compiler.unit.code, instruction{op: op, arg: arg, arg2: arg2, node: node})
+	return len(compiler.unit.code) - 1
+}
+
+// Number of values an instruction pushes minus the number of values it pops.
+// The instructions are counted in the order they are emitted, so the branches of the ternary operator are added up.
+// The maximum depth is only the initial capacity of the stack (see newFrame).
+func stackEffect(op opcode, arg2 int) int {
+	switch op {
+	case opConst, opConstant, opDup, opLoad, opNewArray, opInterpolate:
+		return 1
+	case opIncDec:
+		if arg2&incDecDiscard != 0 {
+			return 0
+		}
+		return 1
+	case opIterNext:
+		return 1 + arg2
+	case opFallback:
+		return arg2
+	case opCall:
+		return 1 - arg2
+	case opAssignDim:
+		return -arg2
+	case opArrayInsert:
+		return -2
+	case opPop, opStore, opFetchDim, opJumpIfFalse, opJumpIfTrue, opJumpIfNotNull, opJumpIfNull,
+		opEcho, opReturn, opArrayAppend, opIterInit,
+		opAdd, opSub, opMul, opMod, opConcat, opBinary,
+		opLess, opLessEqual, opGreater, opGreaterEqual, opRelational,
+		opEqual, opNotEqual, opIdentical, opNotIdentical, opEquality:
+		return -1
+	default:
+		return 0
+	}
+}
+
+// Let the jump instruction at the given index continue at the next emitted instruction
+func (compiler *compiler) patchJump(index int) {
+	compiler.unit.code[index].arg = len(compiler.unit.code)
+	compiler.jumpTarget = len(compiler.unit.code)
+}
+
+// Push a constant value. The nil value is used for the missing key in `$a[] = 1;`.
+func (compiler *compiler) emitConst(value values.RuntimeValue, node ast.IStatement) {
+	if value == nil {
+		compiler.unit.constants = append(compiler.unit.constants, nil)
+		compiler.emit(opConst, len(compiler.unit.constants)-1, 0, node)
+		return
+	}
+
+	var key constantKey
+	switch value.GetType() {
+	case values.IntValue:
+		key = constantKey{values.IntValue, value.(*values.Int).Value}
+	case values.FloatValue:
+		key = constantKey{values.FloatValue, value.(*values.Float).Value}
+	case values.StrValue:
+		key = constantKey{values.StrValue, value.(*values.Str).Value}
+	default:
+		compiler.unit.constants = append(compiler.unit.constants, value)
+		compiler.emit(opConst, len(compiler.unit.constants)-1, 0, node)
+		return
+	}
+
+	index, found := compiler.constantIndexes[key]
+	if !found {
+		compiler.unit.constants = append(compiler.unit.constants, value)
+		index = len(compiler.unit.constants) - 1
+		compiler.constantIndexes[key] = index
+	}
+	compiler.emit(opConst, index, 0, node)
+}
+
+func (compiler *compiler) name(name string) int {
+	if index, found := compiler.nameIndexes[name]; found {
+		return index
+	}
+	compiler.unit.names = append(compiler.unit.names, name)
+	compiler.nameIndexes[name] = len(compiler.unit.names) - 1
+	return len(compiler.unit.names) - 1
+}
+
+func (compiler *compiler) variable(name string) int {
+	if index, found := compiler.variableIndexes[name]; found {
+		return index
+	}
+
+	// Variables that are not in the scope (e.g. superglobals and global variables) are accessed by name
+	compiler.unit.variables = append(compiler.unit.variables, compiledVariable{name: name, named: true})
+	compiler.variableIndexes[name] = len(compiler.unit.variables) - 1
+	return len(compiler.unit.variables) - 1
+}
+
+// Get the name of a variable like `$a`. Variable variables like `$$a` are not static.
+func staticVariableName(expr ast.IExpression) (string, bool) {
+	variable, ok := expr.(*ast.SimpleVariableExpression)
+	if !ok {
+		return "", false
+	}
+	name, ok := variable.VariableName.(*ast.VariableNameExpression)
+	if !ok {
+		return "", false
+	}
+	return name.VariableName, true
+}
+
+// Member accesses and member calls can short-circuit the rest of the chain (nullsafe operator)
+func isNullsafeChain(expr ast.IExpression) bool {
+	switch expr.GetKind() {
+	case ast.MemberAccessExpr, ast.MemberCallExpr:
+		return true
+	case ast.SubscriptExpr:
+		return isNullsafeChain(expr.(*ast.SubscriptExpression).Variable)
+	default:
+		return false
+	}
+}
+
+// Process the node with the tree-walking interpreter
+func (compiler *compiler) emitFallback(node ast.IStatement, pushResult bool) {
+	jumpTable := -1
+	if len(compiler.loops) > 0 {
+		if compiler.jumpTable == -1 {
+			loops := slices.Clone(compiler.loops)
+			slices.Reverse(loops)
+			compiler.jumpTables = append(compiler.jumpTables, loops)
+			compiler.jumpTable = len(compiler.jumpTables) - 1
+		}
+		jumpTable = compiler.jumpTable
+	}
+
+	push := 0
+	if pushResult {
+		push = 1
+	}
+	compiler.emit(opFallback, jumpTable, push, node)
+}
+
+func (compiler *compiler) pushLoop() *compilerLoop {
+	loop := &compilerLoop{outerIterators: compiler.iterators, innerIterators: compiler.iterators}
+	compiler.loops = append(compiler.loops, loop)
+	compiler.jumpTable = -1
+	return loop
+}
+
+func (compiler *compiler) popLoop(loop *compilerLoop) {
+	loop.breakTarget = len(compiler.unit.code)
+	for _, jump := range loop.breakJumps {
+		compiler.unit.code[jump].arg = loop.breakTarget
+	}
+	for _, jump := range loop.continueJumps {
+		compiler.unit.code[jump].arg = loop.continueTarget
+	}
+	compiler.loops = compiler.loops[:len(compiler.loops)-1]
+	compiler.jumpTable = -1
+}
+
+// -------------------------------------- Statements -------------------------------------- MARK: Statements
+
+func (compiler *compiler) compileStmt(stmt ast.IStatement) {
+	releases := compiler.releases
+	if !compiler.compileNativeStmt(stmt) {
+		compiler.emitFallback(stmt, false)
+		return
+	}
+	// Free the objects that might have become unreferenced during the statement
+	if compiler.releases > releases && isGarbageSafePoint(stmt) {
+		compiler.emit(opFreeObjects, 0, 0, stmt)
+	}
+}
+
+func (compiler *compiler) compileNativeStmt(stmt ast.IStatement) bool {
+	switch stmt := stmt.(type) {
+	case *ast.CompoundStatement:
+		for _, statement := range stmt.Statements {
+			compiler.compileStmt(statement)
+		}
+		return true
+
+	case *ast.ExpressionStatement:
+		compiler.compileExprStmt(stmt.Expr)
+		return true
+
+	case *ast.EchoStatement:
+		for _, expr := range stmt.Expressions {
+			compiler.compileExpr(expr)
+			compiler.emit(opEcho, 0, 0, expr)
+		}
+		return true
+
+	case *ast.IfStatement:
+		endJumps := []int{}
+		branches := append([]*ast.IfStatement{stmt}, stmt.ElseIf...)
+		for _, branch := range branches {
+			compiler.compileExpr(branch.Condition)
+			nextBranch := compiler.emit(opJumpIfFalse, 0, 0, branch.Condition)
+			compiler.compileStmt(branch.IfBlock)
+			endJumps = append(endJumps, compiler.emit(opJump, 0, 0, nil))
+			compiler.patchJump(nextBranch)
+		}
+		if stmt.ElseBlock != nil {
+			compiler.compileStmt(stmt.ElseBlock)
+		}
+		for _, jump := range endJumps {
+			compiler.patchJump(jump)
+		}
+		return true
+
+	case *ast.WhileStatement:
+		loop := compiler.pushLoop()
+		loop.continueTarget = len(compiler.unit.code)
+		compiler.compileExpr(stmt.Condition)
+		loop.breakJumps = append(loop.breakJumps, compiler.emit(opJumpIfFalse, 0, 0, stmt.Condition))
+		compiler.compileStmt(stmt.Block)
+		compiler.emit(opJump, loop.continueTarget, 0, nil)
+		compiler.popLoop(loop)
+		return true
+
+	case *ast.DoStatement:
+		loop := compiler.pushLoop()
+		start := len(compiler.unit.code)
+		compiler.compileStmt(stmt.Block)
+		loop.continueTarget = len(compiler.unit.code)
+		compiler.compileExpr(stmt.Condition)
+		compiler.emit(opJumpIfTrue, start, 0, stmt.Condition)
+		compiler.popLoop(loop)
+		return true
+
+	case *ast.ForStatement:
+		if stmt.Initializer != nil {
+			for _, statement := range stmt.Initializer.Statements {
+				compiler.compileExprStmt(statement)
+			}
+		}
+		loop := compiler.pushLoop()
+		start := len(compiler.unit.code)
+		if stmt.Control != nil {
+			for index, statement := range stmt.Control.Statements {
+				if index < len(stmt.Control.Statements)-1 {
+					compiler.compileExprStmt(statement)
+					continue
+				}
+				compiler.compileExpr(statement)
+				loop.breakJumps = append(loop.breakJumps, compiler.emit(opJumpIfFalse, 0, 0, statement))
+			}
+		}
+		compiler.compileStmt(stmt.Block)
+		loop.continueTarget = len(compiler.unit.code)
+		if stmt.EndOfLoop != nil {
+			for _, statement := range stmt.EndOfLoop.Statements {
+				compiler.compileExprStmt(statement)
+			}
+		}
+		compiler.emit(opJump, start, 0, nil)
+		compiler.popLoop(loop)
+		return true
+
+	case *ast.ForeachStatement:
+		valueName, ok := staticVariableName(stmt.Value)
+		if !ok {
+			return false
+		}
+		keyName := ""
+		if stmt.Key != nil {
+			if keyName, ok = staticVariableName(stmt.Key); !ok {
+				return false
+			}
+		}
+
+		compiler.compileExpr(stmt.Collection)
+		iterInit := compiler.emit(opIterInit, 0, 0, stmt.Collection)
+		compiler.iterators++
+		loop := compiler.pushLoop()
+		loop.outerIterators = compiler.iterators - 1
+		loop.continueTarget = len(compiler.unit.code)
+		withKey := 0
+		if stmt.Key != nil {
+			withKey = 1
+		}
+		iterNext := compiler.emit(opIterNext, 0, withKey, stmt)
+		if stmt.Key != nil {
+			compiler.emit(opStore, compiler.variable(keyName), 0, stmt.Key)
+		}
+		compiler.emit(opStore, compiler.variable(valueName), 0, stmt.Value)
+		compiler.compileStmt(stmt.Block)
+		compiler.emit(opJump, loop.continueTarget, 0, nil)
+		compiler.iterators--
+		compiler.popLoop(loop)
+		compiler.patchJump(iterInit)
+		compiler.patchJump(iterNext)
+		return true
+
+	case *ast.ReturnStatement:
+		if stmt.Expr == nil {
+			compiler.emit(opReturnVoid, 0, 0, stmt)
+			return true
+		}
+		compiler.compileExpr(stmt.Expr)
+		compiler.emit(opReturn, 0, 0, stmt)
+		return true
+
+	case *ast.BreakStatement:
+		return compiler.compileBreak(stmt.Expr, true)
+
+	case *ast.ContinueStatement:
+		return compiler.compileBreak(stmt.Expr, false)
+
+	case *ast.GlobalDeclarationStatement, *ast.FunctionDefinitionStatement, *ast.ClassDeclarationStatement,
+		*ast.ConstDeclarationStatement, *ast.DeclareStatement, *ast.ThrowStatement:
+		return false
+
+	default:
+		// Expressions used as statements
+		compiler.compileExprStmt(stmt)
+		return true
+	}
+}
+
+func (compiler *compiler) compileBreak(levelExpr ast.IExpression, isBreak bool) bool {
+	level, ok := levelExpr.(*ast.IntegerLiteralExpression)
+	if !ok || level.Value < 1 || level.Value > int64(len(compiler.loops)) {
+		return false
+	}
+
+	target := compiler.loops[len(compiler.loops)-int(level.Value)]
+	// Remove the iterators of the foreach loops that are left
+	iterators := target.innerIterators
+	if isBreak {
+		iterators = target.outerIterators
+	}
+	for range compiler.iterators - iterators {
+		compiler.emit(opIterPop, 0, 0, nil)
+	}
+
+	jump := compiler.emit(opJump, 0, 0, nil)
+	if isBreak {
+		target.breakJumps = append(target.breakJumps, jump)
+	} else {
+		target.continueJumps = append(target.continueJumps, jump)
+	}
+	return true
+}
+
+// Compile an expression whose result is not used
+func (compiler *compiler) compileExprStmt(expr ast.IExpression) {
+	switch expr := expr.(type) {
+	case *ast.TextExpression:
+		compiler.emitConst(values.NewStr(expr.Value), expr)
+		compiler.emit(opEcho, 0, 0, expr)
+		return
+
+	case *ast.SimpleAssignmentExpression:
+		if name, ok := staticVariableName(expr.Variable); ok {
+			compiler.compileExpr(expr.Value)
+			compiler.emit(opStore, compiler.variable(name), 1, expr)
+			return
+		}
+
+	case *ast.CompoundAssignmentExpression:
+		if name, ok := staticVariableName(expr.Variable); ok && expr.Operator != "??" {
+			variable := compiler.variable(name)
+			compiler.emit(opLoad, variable, 0, expr.Variable)
+			compiler.compileExpr(expr.Value)
+			compiler.emitBinary(expr.Operator, expr)
+			compiler.emit(opStore, variable, 1, expr)
+			return
+		}
+
+	case *ast.PrefixIncExpression:
+		if compiler.compileIncDec(expr.UnaryOpExpression, incDecPrefix|incDecDiscard) {
+			return
+		}
+
+	case *ast.PostfixIncExpression:
+		if compiler.compileIncDec(expr.UnaryOpExpression, incDecDiscard) {
+			return
+		}
+	}
+
+	if !compiler.compileNativeExpr(expr) {
+		compiler.emitFallback(expr, false)
+		return
+	}
+	compiler.emit(opPop, 0, 0, nil)
+}
+
+// -------------------------------------- Expressions -------------------------------------- MARK: Expressions
+
+// Compile an expression that pushes its result
+func (compiler *compiler) compileExpr(expr ast.IExpression) {
+	if !compiler.compileNativeExpr(expr) {
+		compiler.emitFallback(expr, true)
+	}
+}
+
+// Compile an expression natively. Returns false without emitting any code if the expression is not supported.
+func (compiler *compiler) compileNativeExpr(expr ast.IExpression) bool {
+	switch expr := expr.(type) {
+	case *ast.TextExpression:
+		compiler.emitConst(values.NewStr(expr.Value), expr)
+		compiler.emit(opEcho, 0, 0, expr)
+		compiler.emitConst(values.NewVoid(), expr)
+		return true
+
+	case *ast.IntegerLiteralExpression:
+		compiler.emitConst(values.NewInt(expr.Value), expr)
+		return true
+
+	case *ast.FloatingLiteralExpression:
+		compiler.emitConst(values.NewFloat(expr.Value), expr)
+		return true
+
+	case *ast.StringLiteralExpression:
+		return compiler.compileStringLiteral(expr)
+
+	case *ast.ArrayLiteralExpression:
+		if array, ok := compiler.constantArray(expr); ok {
+			compiler.emitConst(array, expr)
+			return true
+		}
+		compiler.emit(opNewArray, 0, 0, expr)
+		for _, key := range expr.Keys {
+			if key.GetKind() == ast.ArrayNextKeyExpr {
+				compiler.compileExpr(expr.Elements[key])
+				compiler.emit(opArrayAppend, 0, 0, expr)
+				continue
+			}
+			compiler.compileExpr(key)
+			compiler.compileExpr(expr.Elements[key])
+			compiler.emit(opArrayInsert, 0, 0, expr)
+		}
+		return true
+
+	case *ast.ConstantAccessExpression:
+		if slices.Contains(magicConstants, expr.ConstantName) {
+			return false
+		}
+		if value, found := compiler.interpreter.env.predefinedConstants[expr.ConstantName]; found {
+			compiler.emitConst(value, expr)
+			return true
+		}
+		compiler.emit(opConstant, compiler.name(expr.ConstantName), 0, expr)
+		return true
+
+	case *ast.SimpleVariableExpression:
+		name, ok := staticVariableName(expr)
+		if !ok {
+			return false
+		}
+		compiler.emit(opLoad, compiler.variable(name), 0, expr)
+		return true
+
+	case *ast.ParenthesizedExpression:
+		compiler.compileExpr(expr.Expr)
+		return true
+
+	case *ast.SimpleAssignmentExpression:
+		return compiler.compileAssignment(expr)
+
+	case *ast.CompoundAssignmentExpression:
+		name, ok := staticVariableName(expr.Variable)
+		if !ok || expr.Operator == "??" {
+			return false
+		}
+		variable := compiler.variable(name)
+		compiler.emit(opLoad, variable, 0, expr.Variable)
+		compiler.compileExpr(expr.Value)
+		compiler.emitBinary(expr.Operator, expr)
+		compiler.emit(opAssign, variable, 0, expr)
+		return true
+
+	case *ast.LogicalExpression:
+		return compiler.compileLogical(expr)
+
+	case *ast.RelationalExpression:
+		compiler.compileExpr(expr.Lhs)
+		compiler.compileExpr(expr.Rhs)
+		switch expr.Operator {
+		case "<":
+			compiler.emit(opLess, 0, 0, expr)
+		case "<=":
+			compiler.emit(opLessEqual, 0, 0, expr)
+		case ">":
+			compiler.emit(opGreater, 0, 0, expr)
+		case ">=":
+			compiler.emit(opGreaterEqual, 0, 0, expr)
+		default:
+			compiler.emit(opRelational, compiler.name(expr.Operator), 0, expr)
+		}
+		return true
+
+	case *ast.EqualityExpression:
+		compiler.compileExpr(expr.Lhs)
+		compiler.compileExpr(expr.Rhs)
+		switch expr.Operator {
+		case "==":
+			compiler.emit(opEqual, 0, 0, expr)
+		case "!=":
+			compiler.emit(opNotEqual, 0, 0, expr)
+		case "===":
+			compiler.emit(opIdentical, 0, 0, expr)
+		case "!==":
+			compiler.emit(opNotIdentical, 0, 0, expr)
+		default:
+			compiler.emit(opEquality, compiler.name(expr.Operator), 0, expr)
+		}
+		return true
+
+	case *ast.BinaryOpExpression:
+		compiler.compileExpr(expr.Lhs)
+		compiler.compileExpr(expr.Rhs)
+		compiler.emitBinary(expr.Operator, expr)
+		return true
+
+	case *ast.LogicalNotExpression:
+		compiler.compileExpr(expr.Expr)
+		compiler.emit(opNot, 0, 0, expr)
+		return true
+
+	case *ast.CastExpression:
+		compiler.compileExpr(expr.Expr)
+		compiler.emit(opCast, compiler.name(expr.Operator), 0, expr)
+		return true
+
+	case *ast.PrefixIncExpression:
+		return compiler.compileIncDec(expr.UnaryOpExpression, incDecPrefix)
+
+	case *ast.PostfixIncExpression:
+		return compiler.compileIncDec(expr.UnaryOpExpression, 0)
+
+	case *ast.UnaryOpExpression:
+		compiler.compileExpr(expr.Expr)
+		compiler.emit(opUnary, compiler.name(expr.Operator), 0, expr)
+		return true
+
+	case *ast.ConditionalExpression:
+		compiler.compileExpr(expr.Cond)
+		if expr.IfExpr == nil {
+			// Short ternary `e1 ?: e3`: The result is the value of e1 if it is true
+			compiler.emit(opDup, 0, 0, nil)
+			end := compiler.emit(opJumpIfTrue, 0, 0, expr.Cond)
+			compiler.emit(opPop, 0, 0, nil)
+			compiler.compileExpr(expr.ElseExpr)
+			compiler.patchJump(end)
+			return true
+		}
+		elseBranch := compiler.emit(opJumpIfFalse, 0, 0, expr.Cond)
+		compiler.compileExpr(expr.IfExpr)
+		end := compiler.emit(opJump, 0, 0, nil)
+		compiler.patchJump(elseBranch)
+		compiler.compileExpr(expr.ElseExpr)
+		compiler.patchJump(end)
+		return true
+
+	case *ast.CoalesceExpression:
+		if isQuietExpr(expr.Cond) {
+			compiler.compileQuietExpr(expr.Cond)
+		} else if expr.Cond.GetKind() == ast.SubscriptExpr {
+			// Only the tree-walking interpreter reads all elements of a subscript quietly (see ProcessCoalesceExpr)
+			return false
+		} else {
+			compiler.emit(opSuppressErrors, 0, 0, expr)
+			compiler.compileExpr(expr.Cond)
+			compiler.emit(opRestoreErrors, 0, 0, expr)
+		}
+		end := compiler.emit(opJumpIfNotNull, 0, 0, expr)
+		compiler.compileExpr(expr.ElseExpr)
+		compiler.patchJump(end)
+		return true
+
+	case *ast.SubscriptExpression:
+		if expr.Index == nil || isNullsafeChain(expr.Variable) {
+			return false
+		}
+		compiler.compileExpr(expr.Variable)
+		compiler.compileExpr(expr.Index)
+		compiler.emit(opFetchDim, 0, 0, expr)
+		return true
+
+	case *ast.PrintExpression:
+		compiler.compileExpr(expr.Expr)
+		compiler.emit(opPrint, 0, 0, expr)
+		return true
+
+	case *ast.IssetIntrinsicExpression:
+		for _, arg := range expr.Arguments {
+			if !isQuietExpr(arg) {
+				return false
+			}
+		}
+		falseJumps := []int{}
+		for _, arg := range expr.Arguments {
+			compiler.compileQuietExpr(arg)
+			falseJumps = append(falseJumps, compiler.emit(opJumpIfNull, 0, 0, arg))
+		}
+		compiler.emitConst(values.NewBool(true), expr)
+		end := compiler.emit(opJump, 0, 0, nil)
+		for _, jump := range falseJumps {
+			compiler.patchJump(jump)
+		}
+		compiler.emitConst(values.NewBool(false), expr)
+		compiler.patchJump(end)
+		return true
+
+	case *ast.EmptyIntrinsicExpression:
+		arg := expr.Arguments[0]
+		if ast.IsVariableExpr(arg) {
+			if !isQuietExpr(arg) {
+				return false
+			}
+			compiler.compileQuietExpr(arg)
+		} else {
+			compiler.compileExpr(arg)
+		}
+		compiler.emit(opNot, 0, 0, expr)
+		return true
+
+	case *ast.UnsetIntrinsicExpression:
+		names := make([]string, len(expr.Arguments))
+		for index, arg := range expr.Arguments {
+			name, ok := staticVariableName(arg)
+			if !ok {
+				return false
+			}
+			names[index] = name
+		}
+		for _, name := range names {
+			compiler.emit(opUnset, compiler.variable(name), 0, expr)
+		}
+		compiler.emitConst(values.NewVoid(), expr)
+		return true
+
+	case *ast.FunctionCallExpression:
+		return compiler.compileFunctionCall(expr)
+
+	default:
+		return false
+	}
+}
+
+func (compiler *compiler) emitBinary(operator string, node ast.IStatement) {
+	switch operator {
+	case "+":
+		compiler.emit(opAdd, 0, 0, node)
+	case "-":
+		compiler.emit(opSub, 0, 0, node)
+	case "*":
+		compiler.emit(opMul, 0, 0, node)
+	case "%":
+		compiler.emit(opMod, 0, 0, node)
+	case ".":
+		compiler.emit(opConcat, 0, 0, node)
+	default:
+		compiler.emit(opBinary, compiler.name(operator), 0, node)
+	}
+}
+
+func (compiler *compiler) compileLogical(expr *ast.LogicalExpression) bool {
+	switch expr.Operator {
+	case "&&", "||":
+		compiler.compileExpr(expr.Lhs)
+		shortCircuit := opJumpIfFalse
+		if expr.Operator == "||" {
+			shortCircuit = opJumpIfTrue
+		}
+		shortCircuitJump := compiler.emit(shortCircuit, 0, 0, expr.Lhs)
+		compiler.compileExpr(expr.Rhs)
+		compiler.emit(opToBool, 0, 0, expr.Rhs)
+		end := compiler.emit(opJump, 0, 0, nil)
+		compiler.patchJump(shortCircuitJump)
+		compiler.emitConst(values.NewBool(expr.Operator == "||"), expr)
+		compiler.patchJump(end)
+		return true
+	case "xor":
+		compiler.compileExpr(expr.Lhs)
+		compiler.emit(opToBool, 0, 0, expr.Lhs)
+		compiler.compileExpr(expr.Rhs)
+		compiler.emit(opToBool, 0, 0, expr.Rhs)
+		compiler.emit(opNotIdentical, 0, 0, expr)
+		return true
+	default:
+		return false
+	}
+}
+
+func (compiler *compiler) compileIncDec(expr *ast.UnaryOpExpression, flags int) bool {
+	name, ok := staticVariableName(expr.Expr)
+	if !ok {
+		return false
+	}
+	if expr.Operator == "--" {
+		flags |= incDecDecrement
+	}
+	compiler.emit(opIncDec, compiler.variable(name), flags, expr.Expr)
+	return true
+}
+
+func (compiler *compiler) compileAssignment(expr *ast.SimpleAssignmentExpression) bool {
+	if name, ok := staticVariableName(expr.Variable); ok {
+		compiler.compileExpr(expr.Value)
+		compiler.emit(opAssign, compiler.variable(name), 0, expr)
+		return true
+	}
+
+	if expr.Variable.GetKind() != ast.SubscriptExpr {
+		return false
+	}
+
+	// Assignment to an array element: `$a[1][] = 2;`
+	keys := []ast.IExpression{}
+	base := expr.Variable
+	for base.GetKind() == ast.SubscriptExpr {
+		keys = append([]ast.IExpression{base.(*ast.SubscriptExpression).Index}, keys...)
+		base = base.(*ast.SubscriptExpression).Variable
+	}
+	name, ok := staticVariableName(base)
+	if !ok {
+		return false
+	}
+
+	variable := compiler.variable(name)
+	initDim := compiler.emit(opInitDim, variable, 0, expr)
+	for _, key := range keys {
+		if key == nil {
+			compiler.emitConst(nil, expr)
+			continue
+		}
+		compiler.compileExpr(key)
+	}
+	compiler.compileExpr(expr.Value)
+	compiler.emit(opAssignDim, variable, len(keys), expr)
+	compiler.unit.code[initDim].arg2 = len(compiler.unit.code)
+	compiler.jumpTarget = len(compiler.unit.code)
+	return true
+}
+
+func (compiler *compiler) compileFunctionCall(expr *ast.FunctionCallExpression) bool {
+	nameExpr, ok := expr.FunctionName.(*ast.StringLiteralExpression)
+	if !ok || strings.Contains(nameExpr.Value, "$") {
+		return false
+	}
+	// Arguments passed by reference are assigned by the tree-walking interpreter
+	if len(compiler.interpreter.env.lookupNativeFunctionByRefParams(nameExpr.Value)) > 0 {
+		return false
+	}
+
+	compiler.unit.callSites = append(compiler.unit.callSites, &callSite{expr: expr, name: nameExpr.Value})
+	site := len(compiler.unit.callSites) - 1
+
+	compiler.emit(opCheckCall, site, 0, expr)
+	for _, arg := range expr.Arguments {
+		compiler.compileExpr(arg)
+	}
+	compiler.emit(opCall, site, len(expr.Arguments), expr)
+	return true
+}
+
+func (compiler *compiler) compileStringLiteral(expr *ast.StringLiteralExpression) bool {
+	if expr.StringType != ast.DoubleQuotedString && expr.StringType != ast.HeredocString {
+		compiler.emitConst(values.NewStr(expr.Value), expr)
+		return true
+	}
+
+	parts := splitStringLiteral(expr)
+	if !slices.ContainsFunc(parts, func(part stringPart) bool { return part.substitution != "" }) {
+		compiler.emitConst(values.NewStr(expr.Value), expr)
+		return true
+	}
+
+	// Only the substitution of simple variables (e.g. "$a" or "{$a}") is compiled
+	interpolation := &interpolation{expr: expr, parts: parts}
+	for _, part := range parts {
+		if part.substitution == "" {
+			continue
+		}
+		varExpr := part.substitution
+		if varExpr[0] == '{' {
+			varExpr = varExpr[1 : len(varExpr)-1]
+		}
+		if !simpleVariableSubstitutionRegex.MatchString(varExpr) {
+			return false
+		}
+		interpolation.variables = append(interpolation.variables, compiler.variable(varExpr))
+	}
+
+	compiler.unit.interpolations = append(compiler.unit.interpolations, interpolation)
+	compiler.emit(opInterpolate, len(compiler.unit.interpolations)-1, 0, expr)
+	return true
+}
+
+// Build an array literal that only contains literals at compile time
+func (compiler *compiler) constantArray(expr *ast.ArrayLiteralExpression) (*values.Array, bool) {
+	toConstant := func(expr ast.IExpression) (values.RuntimeValue, bool) {
+		switch expr := expr.(type) {
+		case *ast.IntegerLiteralExpression:
+			return values.NewInt(expr.Value), true
+		case *ast.FloatingLiteralExpression:
+			return values.NewFloat(expr.Value), true
+		case *ast.StringLiteralExpression:
+			if expr.StringType == ast.DoubleQuotedString || expr.StringType == ast.HeredocString {
+				return nil, false
+			}
+			return values.NewStr(expr.Value), true
+		case *ast.ArrayLiteralExpression:
+			return compiler.constantArray(expr)
+		default:
+			return nil, false
+		}
+	}
+
+	array := values.NewArray()
+	for _, key := range expr.Keys {
+		var keyValue values.RuntimeValue
+		if key.GetKind() != ast.ArrayNextKeyExpr {
+			var ok bool
+			if keyValue, ok = toConstant(key); !ok {
+				return nil, false
+			}
+		}
+		value, ok := toConstant(expr.Elements[key])
+		if !ok {
+			return nil, false
+		}
+		if err := array.SetElement(keyValue, value); err != nil {
+			return nil, false
+		}
+	}
+	return array, true
+}
+
+// Expressions that can be evaluated without warnings and errors (e.g. the arguments of isset)
+func isQuietExpr(expr ast.IExpression) bool {
+	switch expr := expr.(type) {
+	case *ast.SimpleVariableExpression:
+		_, ok := staticVariableName(expr)
+		return ok
+	case *ast.IntegerLiteralExpression:
+		return true
+	case *ast.StringLiteralExpression:
+		return expr.StringType == ast.SingleQuotedString
+	case *ast.SubscriptExpression:
+		return expr.Index != nil && isQuietExpr(expr.Variable) && isQuietExpr(expr.Index)
+	default:
+		return false
+	}
+}
+
+// Compile an expression that is accepted by isQuietExpr
+func (compiler *compiler) compileQuietExpr(expr ast.IExpression) {
+	switch expr := expr.(type) {
+	case *ast.SimpleVariableExpression:
+		name, _ := staticVariableName(expr)
+		compiler.emit(opLoad, compiler.variable(name), 1, expr)
+	case *ast.SubscriptExpression:
+		compiler.compileQuietExpr(expr.Variable)
+		compiler.compileQuietExpr(expr.Index)
+		compiler.emit(opFetchDim, 0, 1, expr)
+	default:
+		compiler.compileExpr(expr)
+	}
+}
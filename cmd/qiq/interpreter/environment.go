@@ -4,6 +4,7 @@ import (
 	"QIQ/cmd/qiq/ast"
 	"QIQ/cmd/qiq/phpError"
 	"QIQ/cmd/qiq/request"
+	"QIQ/cmd/qiq/resolver"
 	"QIQ/cmd/qiq/runtime"
 	"QIQ/cmd/qiq/runtime/stdlib"
 	"QIQ/cmd/qiq/runtime/values"
@@ -12,40 +13,62 @@ import (
 )
 
 type Environment struct {
-	parent          *Environment
+	parent *Environment
+	// Environment of the global scope (the root of the parent chain)
+	global          *Environment
 	globalVariables []string
-	variables       map[string]values.RuntimeValue
-	constants       map[string]values.RuntimeValue
-	functions       map[string]*ast.FunctionDefinitionStatement
-	objects         []*values.Object
+	// Variables of a function or method body that are assigned to slots by the resolver
+	scope *ast.Scope
+	slots []values.RuntimeValue
+	// Variables that are not assigned to a slot and the order of their declaration
+	variables     map[string]values.RuntimeValue
+	variableNames []string
+	constants     map[string]values.RuntimeValue
+	functions     map[string]*ast.FunctionDefinitionStatement
+	// Only the objects that became unreferenced after this mark are freed by this scope (see GarbageCollector.Mark)
+	garbageMark int
 	// StdLib
 	predefinedVariables map[string]values.RuntimeValue
 	predefinedConstants map[string]values.RuntimeValue
 	nativeFunctions     map[string]runtime.NativeFunction
+	// Indexes of native function parameters that are passed by reference
+	nativeFunctionByRefParams map[string][]int
 	// Context
 	CurrentFunction *ast.FunctionDefinitionStatement
+	CurrentClass    *ast.ClassDeclarationStatement
 	CurrentObject   *values.Object
 	CurrentMethod   *ast.MethodDefinitionStatement
 }
 
 func NewEnvironment(parentEnv *Environment, request *request.Request, interpreter runtime.Interpreter) (*Environment, phpError.Error) {
-	env := &Environment{
-		parent:    parentEnv,
-		variables: map[string]values.RuntimeValue{},
-		constants: map[string]values.RuntimeValue{},
-		functions: map[string]*ast.FunctionDefinitionStatement{},
-		objects:   []*values.Object{},
-		// StdLib
-		predefinedVariables: map[string]values.RuntimeValue{},
-		predefinedConstants: map[string]values.RuntimeValue{},
-		nativeFunctions:     map[string]runtime.NativeFunction{},
+	env := &Environment{parent: parentEnv}
+	if parentEnv == nil {
+		env.global = env
+	} else {
+		env.global = parentEnv.global
 	}
 
+	// The other maps are only required in the global environment.
+	// Environments of function calls create them on demand, so that calling a function is cheap.
+	// The map of the variables is created by the first variable that is not stored in a slot.
 	if parentEnv == nil {
+		env.variables = map[string]values.RuntimeValue{}
+		env.constants = map[string]values.RuntimeValue{}
+		env.functions = map[string]*ast.FunctionDefinitionStatement{}
+		// StdLib
+		env.predefinedVariables = map[string]values.RuntimeValue{}
+		env.predefinedConstants = map[string]values.RuntimeValue{}
+		env.nativeFunctions = map[string]runtime.NativeFunction{}
+		env.nativeFunctionByRefParams = map[string][]int{}
+
 		stdlib.Register(env)
+		disableFunctions(env, interpreter)
 		if err := registerPredefinedVariables(env, request, interpreter); err != nil {
 			return env, err
 		}
+		for _, value := range env.predefinedVariables {
+			values.Retain(value)
+		}
 		registerPredefinedConstants(env)
 	}
 
@@ -54,79 +77,137 @@ func NewEnvironment(parentEnv *Environment, request *request.Request, interprete
 
 // -------------------------------------- Variables -------------------------------------- MARK: Variables
 
-func (env *Environment) declareVariable(variableName string, value values.RuntimeValue) (values.RuntimeValue, phpError.Error) {
-	if slices.Contains(env.globalVariables, variableName) {
-		return env.parent.declareVariable(variableName, value)
+// Use the slots of the scope for the variables of a function or method body
+func (env *Environment) useScope(scope *ast.Scope) {
+	if scope == nil {
+		return
 	}
-
-	env.variables[variableName] = values.DeepCopy(value)
-	return value, nil
+	env.scope = scope
+	env.slots = make([]values.RuntimeValue, len(scope.Variables))
 }
 
-func (env *Environment) resolvePredefinedVariable(variableName string) (*Environment, phpError.Error) {
-	if _, ok := env.predefinedVariables[variableName]; ok {
-		return env, nil
-	}
+func (env *Environment) hasSlot(slot int) bool {
+	return slot >= 0 && slot < len(env.slots)
+}
 
-	if env.parent == nil {
-		return nil, phpError.NewWarning("Undefined variable %s", variableName)
+// Get the slot of a variable that is accessed by name (e.g. `$$name`)
+func (env *Environment) lookupSlot(variableName string) (int, bool) {
+	if env.scope == nil {
+		return 0, false
 	}
+	return env.scope.Lookup(variableName)
+}
 
-	return env.parent.resolvePredefinedVariable(variableName)
+func (env *Environment) isGlobalVariable(variableName string) bool {
+	return len(env.globalVariables) > 0 && slices.Contains(env.globalVariables, variableName)
 }
 
-func (env *Environment) resolveVariable(variableName string) (*Environment, phpError.Error) {
-	environment, err := env.resolvePredefinedVariable(variableName)
-	if err == nil {
-		return environment, nil
+func (env *Environment) declareVariable(variableName string, value values.RuntimeValue) (values.RuntimeValue, phpError.Error) {
+	if env.isGlobalVariable(variableName) {
+		return env.global.declareVariable(variableName, value)
 	}
 
-	if slices.Contains(env.globalVariables, variableName) {
-		rootEnv := env
-		for rootEnv.parent != nil {
-			rootEnv = rootEnv.parent
-		}
-		return rootEnv, nil
+	if slot, found := env.lookupSlot(variableName); found {
+		env.setSlot(slot, value)
+		return value, nil
 	}
 
-	if _, ok := env.variables[variableName]; ok {
-		return env, nil
+	currentValue, ok := env.variables[variableName]
+	newValue := values.Copy(value)
+	values.Retain(newValue)
+	values.Release(currentValue)
+	if env.variables == nil {
+		env.variables = map[string]values.RuntimeValue{}
+	}
+	env.variables[variableName] = newValue
+	if !ok {
+		env.variableNames = append(env.variableNames, variableName)
 	}
+	return value, nil
+}
+
+// Assign a copy of the value to the slot. The variable holds a reference to the new value instead of the old one.
+func (env *Environment) setSlot(slot int, value values.RuntimeValue) {
+	newValue := values.Copy(value)
+	values.Retain(newValue)
+	values.Release(env.slots[slot])
+	env.slots[slot] = newValue
+}
 
-	return nil, err
+// Declare a variable by name (e.g. by extract)
+func (env *Environment) AddVariable(variableName string, value values.RuntimeValue) {
+	env.declareVariable(variableName, value)
 }
 
 func (env *Environment) LookupVariable(variableName string) (values.RuntimeValue, phpError.Error) {
-	environment, err := env.resolveVariable(variableName)
-	if err != nil {
-		return values.NewNull(), err
-	}
-	if value, ok := environment.predefinedVariables[variableName]; ok {
+	if value, ok := env.global.predefinedVariables[variableName]; ok {
 		return value, nil
 	}
-	if slices.Contains(env.globalVariables, variableName) {
-		value, ok := environment.variables[variableName]
+	if env.isGlobalVariable(variableName) {
+		value, ok := env.global.variables[variableName]
 		if ok {
 			return value, nil
 		}
 		return values.NewNull(), nil
 	}
-	if value, ok := environment.variables[variableName]; ok {
+	if slot, found := env.lookupSlot(variableName); found {
+		if value := env.slots[slot]; value != nil {
+			return value, nil
+		}
+	} else if value, ok := env.variables[variableName]; ok {
 		return value, nil
 	}
 	return values.NewNull(), phpError.NewWarning("Undefined variable %s", variableName)
 }
 
+// Get the names of the variables that are defined in the current scope
+func (env *Environment) GetVariableNames() []string {
+	variableNames := []string{}
+	if env == env.global {
+		for _, variableName := range resolver.Superglobals {
+			if _, ok := env.predefinedVariables[variableName]; ok {
+				variableNames = append(variableNames, variableName)
+			}
+		}
+	}
+	for slot, value := range env.slots {
+		if value != nil {
+			variableNames = append(variableNames, env.scope.Variables[slot])
+		}
+	}
+	variableNames = append(variableNames, env.variableNames...)
+	for _, variableName := range env.globalVariables {
+		if _, ok := env.global.variables[variableName]; ok {
+			variableNames = append(variableNames, variableName)
+		}
+	}
+	return variableNames
+}
+
 func (env *Environment) unsetVariable(variableName string) {
-	environment, err := env.resolveVariable(variableName)
-	if err != nil {
+	if _, ok := env.global.predefinedVariables[variableName]; ok {
+		return
+	}
+	if env.isGlobalVariable(variableName) {
+		env.global.unsetVariable(variableName)
+		return
+	}
+	if slot, found := env.lookupSlot(variableName); found {
+		values.Release(env.slots[slot])
+		env.slots[slot] = nil
 		return
 	}
-	delete(environment.variables, variableName)
+	value, ok := env.variables[variableName]
+	if !ok {
+		return
+	}
+	values.Release(value)
+	delete(env.variables, variableName)
+	env.variableNames = slices.DeleteFunc(env.variableNames, func(name string) bool { return name == variableName })
 }
 
 func (env *Environment) addGlobalVariable(variableName string) {
-	if env.parent == nil {
+	if env == env.global {
 		return
 	}
 	if slices.Contains(env.globalVariables, variableName) {
@@ -135,26 +216,25 @@ func (env *Environment) addGlobalVariable(variableName string) {
 	env.globalVariables = append(env.globalVariables, variableName)
 }
 
-func (env *Environment) getAllObjects() []*values.Object {
-	objects := []*values.Object{}
-	for _, variable := range env.variables {
-		if variable.GetType() != values.ObjectValue {
-			continue
-		}
-		objects = append(objects, variable.(*values.Object))
+// Release the values of all variables of the scope (e.g. at the end of a function call)
+func (env *Environment) releaseVariables() {
+	for slot, value := range env.slots {
+		values.Release(value)
+		env.slots[slot] = nil
 	}
-	return objects
-}
-
-// -------------------------------------- Objects -------------------------------------- MARK: Objects
-
-func (env *Environment) AddObject(object *values.Object) {
-	env.objects = append(env.objects, object)
+	for _, variableName := range env.variableNames {
+		values.Release(env.variables[variableName])
+		delete(env.variables, variableName)
+	}
+	env.variableNames = nil
 }
 
 // -------------------------------------- Constants -------------------------------------- MARK: Constants
 
 func (env *Environment) AddConstant(name string, value values.RuntimeValue) {
+	if env.constants == nil {
+		env.constants = map[string]values.RuntimeValue{}
+	}
 	env.constants[name] = value
 }
 
@@ -163,11 +243,7 @@ func (env *Environment) AddPredefinedConstant(name string, value values.RuntimeV
 }
 
 func (env *Environment) declareConstant(constantName string, value values.RuntimeValue) (values.RuntimeValue, phpError.Error) {
-	// Get "global" environment
-	var environment *Environment = env
-	for environment.parent != nil {
-		environment = environment.parent
-	}
+	environment := env.global
 
 	if _, err := environment.LookupConstant(constantName); err == nil {
 		return values.NewVoid(), phpError.NewWarning("Constant %s already defined", constantName)
@@ -179,11 +255,7 @@ func (env *Environment) declareConstant(constantName string, value values.Runtim
 }
 
 func (env *Environment) LookupConstant(constantName string) (values.RuntimeValue, phpError.Error) {
-	// Get "global" environment
-	var environment *Environment = env
-	for environment.parent != nil {
-		environment = environment.parent
-	}
+	environment := env.global
 
 	if value, ok := environment.predefinedConstants[constantName]; ok {
 		return value, nil
@@ -207,8 +279,11 @@ func (env *Environment) FunctionExists(functionName string) bool {
 
 // -------------------------------------- Native functions -------------------------------------- MARK: Native functions
 
-func (env *Environment) AddNativeFunction(functionName string, function runtime.NativeFunction) {
+func (env *Environment) AddNativeFunction(functionName string, function runtime.NativeFunction, byRefParams ...int) {
 	env.nativeFunctions[functionName] = function
+	if len(byRefParams) > 0 {
+		env.nativeFunctionByRefParams[functionName] = byRefParams
+	}
 }
 
 func (env *Environment) resolveNativeFunction(functionName string) (*Environment, phpError.Error) {
@@ -238,6 +313,31 @@ func (env *Environment) lookupNativeFunction(functionName string) (runtime.Nativ
 	return value, nil
 }
 
+// Spec: https://www.php.net/manual/en/ini.core.php#ini.disable-functions
+// Functions listed in the ini directive "disable_functions" are not defined.
+func disableFunctions(env *Environment, interpreter runtime.Interpreter) {
+	if interpreter == nil {
+		return
+	}
+
+	for _, functionName := range strings.Split(interpreter.GetIni().GetStr("disable_functions"), ",") {
+		functionName = strings.ToLower(strings.TrimSpace(functionName))
+		delete(env.nativeFunctions, functionName)
+		delete(env.nativeFunctionByRefParams, functionName)
+	}
+}
+
+// Get the indexes of the parameters that are passed by reference
+func (env *Environment) lookupNativeFunctionByRefParams(functionName string) []int {
+	functionName = strings.ToLower(functionName)
+
+	environment, err := env.resolveNativeFunction(functionName)
+	if err != nil {
+		return []int{}
+	}
+	return environment.nativeFunctionByRefParams[functionName]
+}
+
 // -------------------------------------- User functions -------------------------------------- MARK: User functions
 
 func (env *Environment) defineUserFunction(function *ast.FunctionDefinitionStatement) phpError.Error {
@@ -252,6 +352,9 @@ func (env *Environment) defineUserFunction(function *ast.FunctionDefinitionState
 
 	functionName := strings.ToLower(function.FunctionName)
 
+	if env.functions == nil {
+		env.functions = map[string]*ast.FunctionDefinitionStatement{}
+	}
 	env.functions[functionName] = function
 
 	return nil
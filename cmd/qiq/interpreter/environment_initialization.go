@@ -79,9 +79,9 @@ func registerPredefinedVariables(environment *Environment, request *request.Requ
 
 // TODO Replace with std lib func array_merge
 func mergeArrays(a, b *values.Array) {
-	for _, key := range b.Keys {
+	for _, key := range b.GetKeys() {
 		value, _ := b.GetElement(key)
-		a.SetElement(key, values.DeepCopy(value))
+		a.SetElement(key, value)
 	}
 }
 
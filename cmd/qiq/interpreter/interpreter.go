@@ -2,7 +2,6 @@ package interpreter
 
 import (
 	"QIQ/cmd/qiq/ast"
-	"QIQ/cmd/qiq/common"
 	"QIQ/cmd/qiq/ini"
 	"QIQ/cmd/qiq/parser"
 	"QIQ/cmd/qiq/phpError"
@@ -10,6 +9,7 @@ import (
 	"QIQ/cmd/qiq/runtime/outputBuffer"
 	"QIQ/cmd/qiq/runtime/values"
 	"QIQ/cmd/qiq/stats"
+	"strings"
 )
 
 var _ ast.Visitor = &Interpreter{}
@@ -18,6 +18,7 @@ type Interpreter struct {
 	filename           string
 	includedFiles      []string
 	classDeclarations  map[string]*ast.ClassDeclarationStatement
+	nativeMethods      map[*ast.MethodDefinitionStatement]nativeMethod
 	ini                *ini.Ini
 	request            *request.Request
 	response           *request.Response
@@ -25,8 +26,16 @@ type Interpreter struct {
 	env                *Environment
 	cache              map[int64]values.RuntimeValue
 	outputBufferStack  *outputBuffer.Stack
-	result             string
+	result             strings.Builder
 	resultRuntimeValue values.RuntimeValue
+	lastObjectHandle   int64
+	resources          *values.ResourceRegistry
+	// Engine that executes the programs: "vm" or "ast" (see ini directive "qiq.engine")
+	engine            string
+	compiledFunctions map[ast.IStatement]*compiledUnit
+	gc                *values.GarbageCollector
+	// Request-scoped state of the stdlib extensions
+	extensionStates map[string]any
 	// Status
 	suppressWarning bool
 	exitCalled      bool
@@ -37,12 +46,18 @@ func NewInterpreter(ini *ini.Ini, r *request.Request, filename string) (*Interpr
 		filename:          filename,
 		includedFiles:     []string{},
 		classDeclarations: map[string]*ast.ClassDeclarationStatement{},
+		nativeMethods:     map[*ast.MethodDefinitionStatement]nativeMethod{},
 		ini:               ini,
 		request:           r,
 		response:          request.NewResponse(),
 		parser:            parser.NewParser(ini),
 		cache:             map[int64]values.RuntimeValue{},
 		outputBufferStack: outputBuffer.NewStack(),
+		engine:            ini.GetStr("qiq.engine"),
+		compiledFunctions: map[ast.IStatement]*compiledUnit{},
+		gc:                values.NewGarbageCollector(),
+		resources:         values.NewResourceRegistry(),
+		extensionStates:   map[string]any{},
 	}
 
 	var err phpError.Error
@@ -52,12 +67,17 @@ func NewInterpreter(ini *ini.Ini, r *request.Request, filename string) (*Interpr
 	}
 
 	interpreter.classDeclarations["stdClass"] = ast.NewClassDeclarationStmt(0, nil, "stdClass", false, false)
+	// Objects of classes that are not defined or not allowed when unserializing
+	interpreter.classDeclarations["__PHP_Incomplete_Class"] = ast.NewClassDeclarationStmt(0, nil, "__PHP_Incomplete_Class", false, true)
+	// State of an incremental hash (see hash_init)
+	interpreter.classDeclarations["HashContext"] = ast.NewClassDeclarationStmt(0, nil, "HashContext", false, true)
+	interpreter.registerReflectionClasses()
 
 	if ini.GetBool("register_argc_argv") {
 		server := interpreter.env.predefinedVariables["$_SERVER"].(*values.Array)
 		if !server.Contains(values.NewStr("argc")) && !server.Contains(values.NewStr("argv")) {
 			server.SetElement(values.NewStr("argv"), interpreter.env.predefinedVariables["$_GET"])
-			server.SetElement(values.NewStr("argc"), values.NewInt(int64(len(interpreter.env.predefinedVariables["$_GET"].(*values.Array).Keys))))
+			server.SetElement(values.NewStr("argc"), values.NewInt(int64(interpreter.env.predefinedVariables["$_GET"].(*values.Array).Count())))
 		}
 	}
 
@@ -80,20 +100,44 @@ func (interpreter *Interpreter) GetOutputBufferStack() *outputBuffer.Stack {
 	return interpreter.outputBufferStack
 }
 
+func (interpreter *Interpreter) GetExtensionState(name string, newState func() any) any {
+	state, found := interpreter.extensionStates[name]
+	if !found {
+		state = newState()
+		interpreter.extensionStates[name] = state
+	}
+	return state
+}
+
 func (interpreter *Interpreter) Process(sourceCode string) (string, phpError.Error) {
 	return interpreter.process(sourceCode, interpreter.env, false)
 }
 
+// Process the file of the interpreter. The parsed program is taken from the opcache if possible.
+func (interpreter *Interpreter) ProcessFile() (string, phpError.Error) {
+	program, err, fileErr := interpreter.parseFile(interpreter.filename)
+	if fileErr != nil {
+		return interpreter.result.String(), phpError.NewError("Failed opening '%s' for reading", interpreter.filename)
+	}
+	if err != nil {
+		return interpreter.result.String(), err
+	}
+	return interpreter.processParsedProgram(program, interpreter.env)
+}
+
 func (interpreter *Interpreter) process(sourceCode string, env *Environment, resetResult bool) (string, phpError.Error) {
 	if resetResult {
-		interpreter.result = ""
+		interpreter.result.Reset()
 	}
 
 	program, err := interpreter.parser.ProduceAST(sourceCode, interpreter.filename)
 	if err != nil {
-		return interpreter.result, err
+		return interpreter.result.String(), err
 	}
+	return interpreter.processParsedProgram(program, env)
+}
 
+func (interpreter *Interpreter) processParsedProgram(program *ast.Program, env *Environment) (string, phpError.Error) {
 	stat := stats.Start()
 	defer stats.StopAndPrint(stat, "Interpreter")
 
@@ -101,39 +145,59 @@ func (interpreter *Interpreter) process(sourceCode string, env *Environment, res
 	parser.PrintParserCallstack("Interpreter callstack", nil)
 	parser.PrintParserCallstack("---------------------", nil)
 
+	var err phpError.Error
 	interpreter.resultRuntimeValue = nil
 	interpreter.resultRuntimeValue, err = interpreter.processProgram(program, env)
+	// Handle exit event - Stop code execution
+	if err != nil && err.GetErrorType() == phpError.EventError && err.GetMessage() == phpError.ExitEvent {
+		err = nil
+	}
+	if err == nil && !interpreter.exitCalled {
+		interpreter.ProcessExitIntrinsicExpr(ast.NewExitIntrinsic(0, nil, ast.NewIntegerLiteralExpr(0, nil, 0)), interpreter.env)
+	}
+	interpreter.flushOutputBuffers()
 
-	return interpreter.result, err
+	return interpreter.result.String(), err
 }
 
+// Process a program, an included file or the code of eval
 func (interpreter *Interpreter) processProgram(program *ast.Program, env *Environment) (values.RuntimeValue, phpError.Error) {
 	err := interpreter.scanForFunctionDefinition(program.GetStatements(), env)
 	if err != nil {
 		return values.NewVoid(), err
 	}
 
-	defer interpreter.flushOutputBuffers()
+	// The program must not free the temporaries of the statement that includes it
+	garbageMark := env.garbageMark
+	env.garbageMark = interpreter.gc.Mark()
+	defer func() { env.garbageMark = garbageMark }()
 
 	var runtimeValue values.RuntimeValue = values.NewVoid()
-	for _, stmt := range program.GetStatements() {
-		if runtimeValue, err = interpreter.processStmt(stmt, env); err != nil {
-			// Handle exit event - Stop code execution
-			if err.GetErrorType() == phpError.EventError && err.GetMessage() == phpError.ExitEvent {
+	if interpreter.engine == engineAST {
+		for _, stmt := range program.GetStatements() {
+			if runtimeValue, err = interpreter.processStmt(stmt, env); err != nil {
 				break
 			}
-			return runtimeValue, err
 		}
+	} else {
+		runtimeValue, err = interpreter.processCompiledProgram(program, env)
 	}
+	return runtimeValue, err
+}
 
-	if !interpreter.exitCalled {
-		interpreter.ProcessExitIntrinsicExpr(ast.NewExitIntrinsic(0, nil, ast.NewIntegerLiteralExpr(0, nil, 0)), interpreter.env)
+func (interpreter *Interpreter) processStmt(stmt ast.IStatement, env any) (values.RuntimeValue, phpError.Error) {
+	runtimeValue, err := interpreter.processChainStmt(stmt, env)
+	// Spec: https://www.php.net/manual/en/language.oop5.basic.php#language.oop5.basic.nullsafe
+	// The nullsafe operator short-circuits the rest of the chain. The result of the whole chain is null.
+	if err != nil && err.GetErrorType() == phpError.EventError && err.GetMessage() == phpError.NullsafeEvent {
+		return values.NewNull(), nil
 	}
-
-	return runtimeValue, nil
+	return runtimeValue, err
 }
 
-func (interpreter *Interpreter) processStmt(stmt ast.IStatement, env any) (value values.RuntimeValue, phpErr phpError.Error) {
+// Process a statement without resolving a nullsafe short-circuit.
+// This is used for the dereferencable expression of a chain element (e.g. `$a?->b` in `$a?->b->c()`).
+func (interpreter *Interpreter) processChainStmt(stmt ast.IStatement, env any) (value values.RuntimeValue, phpErr phpError.Error) {
 	defer func() {
 		if r := recover(); r != nil {
 			value = r.(ValueOrError).Value
@@ -147,22 +211,26 @@ func (interpreter *Interpreter) processStmt(stmt ast.IStatement, env any) (value
 		phpErr = err.(phpError.Error)
 	}
 
-	// Destruct unused objects
-	if stmt.GetKind() != ast.ObjectCreationExpr {
-		for index, object := range env.(*Environment).objects {
-			if object.IsUsed {
-				continue
-			}
-			if err := interpreter.destructObject(object, env.(*Environment)); err != nil {
-				interpreter.PrintError(err)
-			}
-			env.(*Environment).objects = common.RemoveIndex(env.(*Environment).objects, index)
-		}
+	// Free the objects that became unreferenced during the statement.
+	// Expressions are skipped because their temporaries are still in use.
+	if phpErr == nil && isGarbageSafePoint(stmt) {
+		interpreter.collectGarbage(env.(*Environment))
 	}
 
 	return runtimeValue.(values.RuntimeValue), phpErr
 }
 
+// Check if the garbage can be collected after the statement.
+// Statements that leave the current block (e.g. return) are excluded because their value is still in use.
+func isGarbageSafePoint(stmt ast.IStatement) bool {
+	switch stmt.GetKind() {
+	case ast.ExpressionStmt, ast.EchoStmt, ast.IfStmt, ast.WhileStmt, ast.DoStmt, ast.ForStmt, ast.ForeachStmt:
+		return true
+	default:
+		return false
+	}
+}
+
 type ValueOrError struct {
 	Value values.RuntimeValue
 	Error error
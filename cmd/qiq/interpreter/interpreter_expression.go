@@ -8,6 +8,7 @@ import (
 	"QIQ/cmd/qiq/runtime"
 	"QIQ/cmd/qiq/runtime/stdlib/variableHandling"
 	"QIQ/cmd/qiq/runtime/values"
+	"slices"
 	"strings"
 )
 
@@ -69,6 +70,18 @@ func (interpreter *Interpreter) ProcessSimpleAssignmentExpr(expr *ast.SimpleAssi
 			phpError.NewError("processSimpleAssignmentExpr: Invalid variable: %s", expr.Variable)
 	}
 
+	if expr.Variable.GetKind() == ast.MemberAccessExpr {
+		value := must(interpreter.processStmt(expr.Value, env))
+		return interpreter.assignProperty(expr.Variable.(*ast.MemberAccessExpression), value, env.(*Environment))
+	}
+
+	// Variables assigned to a slot by the resolver are accessed without their name
+	if variable, ok := expr.Variable.(*ast.SimpleVariableExpression); ok && env.(*Environment).hasSlot(variable.Slot) {
+		value := must(interpreter.processStmt(expr.Value, env))
+		env.(*Environment).setSlot(variable.Slot, value)
+		return value, nil
+	}
+
 	variableName := mustOrVoid(interpreter.varExprToVarName(expr.Variable, env.(*Environment)))
 	currentValue, _ := env.(*Environment).LookupVariable(variableName)
 
@@ -76,31 +89,14 @@ func (interpreter *Interpreter) ProcessSimpleAssignmentExpr(expr *ast.SimpleAssi
 		if expr.Variable.(*ast.SubscriptExpression).Index == nil {
 			return values.NewVoid(), phpError.NewError("[] operator not supported for strings in %s", expr.Variable.GetPosString())
 		}
-		if expr.Variable.(*ast.SubscriptExpression).Index.GetKind() != ast.IntegerLiteralExpr {
-			indexType, err := literalExprTypeToRuntimeValue(expr.Variable.(*ast.SubscriptExpression).Index)
-			if err != nil {
-				return values.NewVoid(), err
-			}
-			return values.NewVoid(), phpError.NewError("Cannot access offset of type %s on string in %s", indexType, expr.Variable.(*ast.SubscriptExpression).Index.GetPosString())
-		}
-
-		key := expr.Variable.(*ast.SubscriptExpression).Index.(*ast.IntegerLiteralExpression).Value
+		key := must(interpreter.processStmt(expr.Variable.(*ast.SubscriptExpression).Index, env))
 		value := must(interpreter.processStmt(expr.Value, env))
 
 		currentValue, _ = env.(*Environment).LookupVariable(variableName)
-		str := currentValue.(*values.Str).Value
-
-		valueStr, err := variableHandling.StrVal(value)
+		str, err := interpreter.writeStringOffset(currentValue.(*values.Str).Value, key, value, expr)
 		if err != nil {
 			return values.NewVoid(), err
 		}
-		if valueStr == "" {
-			return values.NewVoid(),
-				phpError.NewError("Cannot assign an empty string to a string offset in %s", expr.Value.GetPosString())
-		}
-
-		str = common.ExtendWithSpaces(str, int(key+1))
-		str = common.ReplaceAtPos(str, valueStr, int(key))
 
 		_, err = env.(*Environment).declareVariable(variableName, values.NewStr(str))
 		return value, err
@@ -111,11 +107,7 @@ func (interpreter *Interpreter) ProcessSimpleAssignmentExpr(expr *ast.SimpleAssi
 		currentValue, _ = env.(*Environment).LookupVariable(variableName)
 	}
 
-	if currentValue.GetType() == values.ArrayValue {
-		if expr.Variable.GetKind() != ast.SubscriptExpr {
-			return values.NewVoid(), phpError.NewError("processSimpleAssignmentExpr: Unsupported variable type %s", expr.Variable.GetKind())
-		}
-
+	if currentValue.GetType() == values.ArrayValue && expr.Variable.GetKind() == ast.SubscriptExpr {
 		keys := []ast.IExpression{expr.Variable.(*ast.SubscriptExpression).Index}
 		subarray := expr.Variable.(*ast.SubscriptExpression).Variable
 		for subarray.GetKind() == ast.SubscriptExpr {
@@ -123,44 +115,27 @@ func (interpreter *Interpreter) ProcessSimpleAssignmentExpr(expr *ast.SimpleAssi
 			subarray = subarray.(*ast.SubscriptExpression).Variable
 		}
 
-		var value values.RuntimeValue
+		// Evaluate the keys in source order before the value
+		keyValues := make([]values.RuntimeValue, len(keys))
 		for i := len(keys) - 1; i >= 0; i-- {
-			if currentValue.GetType() != values.ArrayValue {
-				return values.NewVoid(), phpError.NewError("processSimpleAssignmentExpr: Unexpected currentValue type %s", currentValue.GetType())
-			}
-
-			array := currentValue.(*values.Array)
-			var keyValue values.RuntimeValue = nil
 			if keys[i] != nil {
-				keyValue = must(interpreter.processStmt(keys[i], env))
-			}
-
-			if i == 0 {
-				value = must(interpreter.processStmt(expr.Value, env))
-				if err := array.SetElement(keyValue, values.DeepCopy(value)); err != nil {
-					return values.NewVoid(), err
-				}
-				break
+				keyValues[len(keys)-1-i] = must(interpreter.processStmt(keys[i], env))
 			}
+		}
+		value := must(interpreter.processStmt(expr.Value, env))
 
-			if array.Contains(keyValue) {
-				currentValue, _ = array.GetElement(keyValue)
-			} else {
-				newArray := values.NewArray()
-				if err := array.SetElement(keyValue, newArray); err != nil {
-					return values.NewVoid(), err
-				}
-				currentValue = newArray
-			}
+		currentValue, _ = env.(*Environment).LookupVariable(variableName)
+		if currentValue.GetType() != values.ArrayValue {
+			return values.NewVoid(), phpError.NewError("processSimpleAssignmentExpr: Unexpected currentValue type %s", currentValue.GetType())
+		}
+		if err := assignArrayElement(currentValue.(*values.Array), keyValues, value); err != nil {
+			return values.NewVoid(), err
 		}
 
 		return value, nil
 	}
 
 	value := must(interpreter.processStmt(expr.Value, env))
-	if value.GetType() == values.ObjectValue {
-		value.(*values.Object).IsUsed = true
-	}
 	return env.(*Environment).declareVariable(variableName, value)
 }
 
@@ -168,80 +143,55 @@ func (interpreter *Interpreter) ProcessSimpleAssignmentExpr(expr *ast.SimpleAssi
 func (interpreter *Interpreter) ProcessSubscriptExpr(expr *ast.SubscriptExpression, env any) (any, error) {
 	// Spec: https://phplang.org/spec/10-expressions.html#grammar-subscript-expression
 
-	variable := must(interpreter.lookupVariable(expr.Variable, env.(*Environment)))
+	variable, err := interpreter.processDereferencableExpr(expr.Variable, env.(*Environment))
+	if err != nil {
+		return variable, err
+	}
 
 	if variable.GetType() == values.StrValue {
 		if expr.Index == nil {
 			return values.NewVoid(), phpError.NewError("Cannot use [] for reading in %s", expr.Variable.GetPosString())
 		}
-		if expr.Index.GetKind() != ast.IntegerLiteralExpr {
-			indexType, err := literalExprTypeToRuntimeValue(expr.Index)
-			if err != nil {
-				return values.NewVoid(), err
-			}
-			return values.NewVoid(), phpError.NewError("Cannot access offset of type %s on string in %s", indexType, expr.Index.GetPosString())
-		}
-
-		key := expr.Index.(*ast.IntegerLiteralExpression).Value
-		str := variable.(*values.Str).Value
-
-		if len(str) <= int(key) {
-			return values.NewVoid(), phpError.NewError("Uninitialized string offset %d in %s", key, expr.Index.GetPosString())
-		}
-
-		return values.NewStr(str[key : key+1]), nil
+		key := must(interpreter.processStmt(expr.Index, env))
+		return interpreter.readStringOffset(variable.(*values.Str).Value, key, expr, interpreter.suppressWarning)
 	}
 
 	// Spec: https://phplang.org/spec/10-expressions.html#grammar-subscript-expression
 	// dereferencable-expression designates an array
 	if variable.GetType() == values.ArrayValue {
-		array := variable.(*values.Array)
-
-		keys := []ast.IExpression{expr.Index}
-		subarray := expr.Variable
-		for subarray.GetKind() == ast.SubscriptExpr {
-			keys = append(keys, subarray.(*ast.SubscriptExpression).Index)
-			subarray = subarray.(*ast.SubscriptExpression).Variable
+		if expr.Index == nil {
+			return values.NewVoid(), phpError.NewError("Cannot use [] for reading in %s", expr.Variable.GetPosString())
 		}
 
-		for i := len(keys) - 1; i >= 0; i-- {
-			// TODO processSubscriptExpr - no key
-			// Spec: https://phplang.org/spec/10-expressions.html#grammar-subscript-expression
-			// If expression is omitted, a new element is inserted. Its key has type int and is one more than the highest, previously assigned int key for this array. If this is the first element with an int key, key 0 is used. If the largest previously assigned int key is the largest integer value that can be represented, the new element is not added. The result is the added new element, or NULL if the element was not added.
-
-			keyValue := must(interpreter.processStmt(keys[i], env))
-			exists := array.Contains(keyValue)
-
-			if i == 0 {
-				// Spec: https://phplang.org/spec/10-expressions.html#grammar-subscript-expression
-				// If expression is present, if the designated element exists,
-				// the type and value of the result is the type and value of that element;
-				// otherwise, the result is NULL.
-				if exists {
-					element, _ := array.GetElement(keyValue)
-					return element, nil
-				} else {
-					return values.NewNull(), nil
-				}
-			}
+		array := variable.(*values.Array)
+		keyValue := must(interpreter.processStmt(expr.Index, env))
+
+		// Spec: https://phplang.org/spec/10-expressions.html#grammar-subscript-expression
+		// If expression is present, if the designated element exists,
+		// the type and value of the result is the type and value of that element;
+		// otherwise, the result is NULL.
+		if array.Contains(keyValue) {
+			element, _ := array.GetElement(keyValue)
+			return element, nil
+		}
+		return values.NewNull(), nil
 
-			if exists {
-				element, _ := array.GetElement(keyValue)
-				if element.GetType() != values.ArrayValue {
-					return values.NewNull(), phpError.NewError("ProcessSubscriptExpr: Expected type Array. Got: %s", element.GetType())
-				}
-				array = element.(*values.Array)
-				continue
-			}
-			return values.NewNull(), phpError.NewError("ProcessSubscriptExpr: Array does not contain key: %s", values.ToString(keyValue))
+		// TODO processSubscriptExpr
+		// If the usage context is as the left-hand side of a simple-assignment-expression, the value of the new element is the value of the right-hand side of that simple-assignment-expression.
+		// If the usage context is as the left-hand side of a compound-assignment-expression: the expression e1 op= e2 is evaluated as e1 = NULL op (e2).
+		// If the usage context is as the operand of the postfix- or prefix-increment or decrement operator, the value of the new element is considered to be NULL.
+	}
 
-			// TODO processSubscriptExpr
-			// If the usage context is as the left-hand side of a simple-assignment-expression, the value of the new element is the value of the right-hand side of that simple-assignment-expression.
-			// If the usage context is as the left-hand side of a compound-assignment-expression: the expression e1 op= e2 is evaluated as e1 = NULL op (e2).
-			// If the usage context is as the operand of a postfix- or prefix-increment or decrement operator, the value of the new element is considered to be NULL.
+	if variable.GetType() == values.NullValue {
+		if !interpreter.suppressWarning {
+			interpreter.PrintError(phpError.NewWarning("Trying to access array offset on value of type null in %s", expr.Variable.GetPosString()))
 		}
+		return values.NewNull(), nil
 	}
 
+	if interpreter.suppressWarning {
+		return values.NewNull(), nil
+	}
 	return values.NewVoid(), phpError.NewError("Unsupported subscript expression: %s", ast.ToString(expr))
 
 	/*
@@ -316,17 +266,32 @@ func (interpreter *Interpreter) ProcessSubscriptExpr(expr *ast.SubscriptExpressi
 // ProcessFunctionCallExpr implements Visitor.
 func (interpreter *Interpreter) ProcessFunctionCallExpr(expr *ast.FunctionCallExpression, env any) (any, error) {
 	functionNameRuntime := must(interpreter.processStmt(expr.FunctionName, env))
-	functionName := mustOrVoid(variableHandling.StrVal(functionNameRuntime))
+	functionName := mustOrVoid(interpreter.strVal(functionNameRuntime))
 
 	// Lookup native function
 	nativeFunction, err := env.(*Environment).lookupNativeFunction(functionName)
 	if err == nil {
+		byRefParams := env.(*Environment).lookupNativeFunctionByRefParams(functionName)
 		functionArguments := make([]values.RuntimeValue, len(expr.Arguments))
 		for index, arg := range expr.Arguments {
+			// By-reference arguments are allowed to be undefined (e.g. `$output` of exec)
+			if runtime.IsByRefParam(byRefParams, index) && ast.IsVariableExpr(arg) {
+				interpreter.suppressWarning = true
+				runtimeValue, err := interpreter.processStmt(arg, env)
+				interpreter.suppressWarning = false
+				if err != nil {
+					runtimeValue = values.NewNull()
+				}
+				functionArguments[index] = values.Copy(runtimeValue)
+				continue
+			}
+
 			runtimeValue := must(interpreter.processStmt(arg, env))
-			functionArguments[index] = values.DeepCopy(runtimeValue)
+			functionArguments[index] = values.Copy(runtimeValue)
 		}
-		return nativeFunction(functionArguments, runtime.NewContext(interpreter, env.(*Environment), expr))
+		result, err := nativeFunction(functionArguments, runtime.NewContext(interpreter, env.(*Environment), expr))
+		discardArguments(functionArguments, result)
+		return result, err
 	}
 
 	// Lookup user function
@@ -337,6 +302,7 @@ func (interpreter *Interpreter) ProcessFunctionCallExpr(expr *ast.FunctionCallEx
 		return values.NewVoid(), err
 	}
 	functionEnv.CurrentFunction = userFunction
+	functionEnv.useScope(userFunction.Scope)
 
 	if len(userFunction.Params) != len(expr.Arguments) {
 		return values.NewVoid(), phpError.NewError(
@@ -348,42 +314,212 @@ func (interpreter *Interpreter) ProcessFunctionCallExpr(expr *ast.FunctionCallEx
 		runtimeValue := must(interpreter.processStmt(expr.Arguments[index], env))
 
 		// Check if the parameter types match
-		err = checkParameterTypes(runtimeValue, param.Type)
-		if err != nil && err.GetMessage() == "Types do not match" {
-			givenType, err := variableHandling.GetType(runtimeValue)
-			if err != nil {
-				return values.NewVoid(), err
-			}
-			return values.NewVoid(), phpError.NewError(
-				"Uncaught TypeError: %s(): Argument #%d (%s) must be of type %s, %s given",
-				userFunction.FunctionName, index+1, param.Name, strings.Join(param.Type, "|"), givenType,
-			)
+		runtimeValue, err := interpreter.checkArgumentType(runtimeValue, userFunction.FunctionName, index, param, functionEnv, expr.Arguments[index])
+		if err != nil {
+			return values.NewVoid(), err
 		}
 		// Declare parameter in function environment
-		functionEnv.declareVariable(param.Name, values.DeepCopy(runtimeValue))
+		functionEnv.declareVariable(param.Name, runtimeValue)
 	}
 
-	runtimeValue, err := interpreter.processStmt(userFunction.Body, functionEnv)
-	interpreter.destructAllObjects(functionEnv)
+	runtimeValue, err := interpreter.processFunctionBody(userFunction, userFunction.Body, functionEnv)
 	if err != nil && !(err.GetErrorType() == phpError.EventError && err.GetMessage() == phpError.ReturnEvent) {
 		return runtimeValue, err
 	}
-	err = checkParameterTypes(runtimeValue, userFunction.ReturnType)
-	if err != nil && err.GetMessage() == "Types do not match" {
-		givenType, err := variableHandling.GetType(runtimeValue)
-		if runtimeValue.GetType() == values.VoidValue {
-			givenType = "void"
+	interpreter.releaseEnvironment(functionEnv, runtimeValue)
+	return interpreter.checkReturnType(runtimeValue, userFunction.ReturnType, userFunction.FunctionName, functionEnv, userFunction)
+
+}
+
+// Assign a value to the variable that was passed to a by-reference parameter of a native function
+func (interpreter *Interpreter) SetByRefArgument(context runtime.Context, index int, value values.RuntimeValue) phpError.Error {
+	call, ok := context.Stmt.(*ast.FunctionCallExpression)
+	if !ok || index >= len(call.Arguments) {
+		// Optional parameter was not passed
+		return nil
+	}
+
+	argument := call.Arguments[index]
+	env := context.Env.(*Environment)
+	switch argument.GetKind() {
+	case ast.SimpleVariableExpr:
+		variableName, err := interpreter.varExprToVarName(argument, env)
+		if err != nil {
+			return err
+		}
+		_, err = env.declareVariable(variableName, value)
+		return err
+	case ast.MemberAccessExpr:
+		_, err := interpreter.assignProperty(argument.(*ast.MemberAccessExpression), value, env)
+		return err
+	case ast.SubscriptExpr:
+		// Array elements (e.g. `sort($data["items"])`)
+		keys := []ast.IExpression{}
+		variable := argument
+		for variable.GetKind() == ast.SubscriptExpr {
+			keys = append(keys, variable.(*ast.SubscriptExpression).Index)
+			variable = variable.(*ast.SubscriptExpression).Variable
 		}
+		variableName, err := interpreter.varExprToVarName(variable, env)
 		if err != nil {
-			return runtimeValue, err
+			return err
 		}
-		return runtimeValue, phpError.NewError(
-			"Uncaught TypeError: %s(): Return value must be of type %s, %s given",
-			userFunction.FunctionName, strings.Join(userFunction.ReturnType, "|"), givenType,
+
+		// Evaluate the keys in source order
+		keyValues := make([]values.RuntimeValue, len(keys))
+		for i := len(keys) - 1; i >= 0; i-- {
+			if keys[i] != nil {
+				if keyValues[len(keys)-1-i], err = interpreter.processStmt(keys[i], env); err != nil {
+					return err
+				}
+			}
+		}
+
+		currentValue, _ := env.LookupVariable(variableName)
+		if currentValue.GetType() == values.NullValue {
+			env.declareVariable(variableName, values.NewArray())
+			currentValue, _ = env.LookupVariable(variableName)
+		}
+		if currentValue.GetType() != values.ArrayValue {
+			return phpError.NewError("Uncaught Error: Argument #%d could not be passed by reference in %s", index+1, argument.GetPosString())
+		}
+		return assignArrayElement(currentValue.(*values.Array), keyValues, value)
+	default:
+		return phpError.NewError("Uncaught Error: Argument #%d could not be passed by reference in %s", index+1, argument.GetPosString())
+	}
+}
+
+// Call the function designated by the callable with the given arguments.
+// Arguments exceeding the parameters of a user function are ignored.
+func (interpreter *Interpreter) CallFunction(context runtime.Context, callable values.RuntimeValue, args []values.RuntimeValue) (values.RuntimeValue, phpError.Error) {
+	// TODO Support closures
+	env := context.Env.(*Environment)
+	object, method, ok := toMethodCallable(callable)
+	var scopeClass *ast.ClassDeclarationStatement
+	if ok {
+		scopeClass = object.Class
+	} else {
+		var err phpError.Error
+		if scopeClass, method, ok, err = interpreter.toStaticMethodCallable(callable, context); err != nil {
+			return values.NewVoid(), err
+		}
+	}
+	if ok {
+		methodDefinition, class, found := interpreter.lookupMethod(scopeClass, method)
+		if !found {
+			return values.NewVoid(), phpError.NewError("Uncaught Error: Call to undefined method %s::%s() in %s", scopeClass.Name, method, context.Stmt.GetPosString())
+		}
+		if object == nil && !slices.Contains(methodDefinition.Modifiers, "static") {
+			return values.NewVoid(), phpError.NewError(
+				"Uncaught Error: Non-static method %s::%s() cannot be called statically in %s",
+				class.Name, methodDefinition.Name, context.Stmt.GetPosString(),
+			)
+		}
+		if _, isNative := interpreter.nativeMethods[methodDefinition]; !isNative {
+			if len(methodDefinition.Params) > len(args) {
+				return values.NewVoid(), phpError.NewError(
+					"Uncaught ArgumentCountError: %s::%s() expects exactly %d arguments, %d given",
+					class.Name, methodDefinition.Name, len(methodDefinition.Params), len(args),
+				)
+			}
+			args = args[:len(methodDefinition.Params)]
+		}
+		argStmts := make([]ast.IStatement, len(args))
+		for index := range argStmts {
+			argStmts[index] = context.Stmt
+		}
+		return interpreter.callMethodWithValues(class, methodDefinition, object, args, argStmts, env)
+	}
+	if callable.GetType() != values.StrValue {
+		return values.NewVoid(), phpError.NewError("Uncaught Error: Value not callable in %s", context.Stmt.GetPosString())
+	}
+	functionName := callable.(*values.Str).Value
+
+	// Lookup native function
+	if nativeFunction, err := env.lookupNativeFunction(functionName); err == nil {
+		return nativeFunction(args, runtime.NewContext(interpreter, env, context.Stmt))
+	}
+
+	// Lookup user function
+	userFunction, err := env.lookupUserFunction(functionName)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	functionEnv, err := NewEnvironment(env, nil, interpreter)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+	functionEnv.CurrentFunction = userFunction
+	functionEnv.useScope(userFunction.Scope)
+
+	if len(userFunction.Params) > len(args) {
+		return values.NewVoid(), phpError.NewError(
+			"Uncaught ArgumentCountError: %s() expects exactly %d arguments, %d given",
+			userFunction.FunctionName, len(userFunction.Params), len(args),
 		)
 	}
-	return runtimeValue, nil
+	for index, param := range userFunction.Params {
+		// Check if the parameter types match
+		runtimeValue, err := interpreter.checkArgumentType(args[index], userFunction.FunctionName, index, param, functionEnv, context.Stmt)
+		if err != nil {
+			return values.NewVoid(), err
+		}
+		// Declare parameter in function environment
+		functionEnv.declareVariable(param.Name, runtimeValue)
+	}
+
+	runtimeValue, err := interpreter.processFunctionBody(userFunction, userFunction.Body, functionEnv)
+	if err != nil && !(err.GetErrorType() == phpError.EventError && err.GetMessage() == phpError.ReturnEvent) {
+		return runtimeValue, err
+	}
+	interpreter.releaseEnvironment(functionEnv, runtimeValue)
+	return interpreter.checkReturnType(runtimeValue, userFunction.ReturnType, userFunction.FunctionName, functionEnv, userFunction)
+}
+
+// Split a method callable (e.g. [$object, "method"]) into the object and the name of the method
+func toMethodCallable(callable values.RuntimeValue) (*values.Object, string, bool) {
+	if callable.GetType() != values.ArrayValue || callable.(*values.Array).Count() != 2 {
+		return nil, "", false
+	}
+	object, found := callable.(*values.Array).GetElement(values.NewInt(0))
+	if !found || object.GetType() != values.ObjectValue {
+		return nil, "", false
+	}
+	method, found := callable.(*values.Array).GetElement(values.NewInt(1))
+	if !found || method.GetType() != values.StrValue {
+		return nil, "", false
+	}
+	return object.(*values.Object), method.(*values.Str).Value, true
+}
 
+// Get the class and the method of a static method callable ("MyClass::method" or ["MyClass", "method"])
+func (interpreter *Interpreter) toStaticMethodCallable(
+	callable values.RuntimeValue, context runtime.Context,
+) (class *ast.ClassDeclarationStatement, method string, ok bool, err phpError.Error) {
+	var className string
+	switch callable.GetType() {
+	case values.StrValue:
+		className, method, ok = strings.Cut(callable.(*values.Str).Value, "::")
+	case values.ArrayValue:
+		if callable.(*values.Array).Count() != 2 {
+			return nil, "", false, nil
+		}
+		classValue, _ := callable.(*values.Array).GetElement(values.NewInt(0))
+		methodValue, _ := callable.(*values.Array).GetElement(values.NewInt(1))
+		if classValue == nil || methodValue == nil || classValue.GetType() != values.StrValue || methodValue.GetType() != values.StrValue {
+			return nil, "", false, nil
+		}
+		className, method, ok = classValue.(*values.Str).Value, methodValue.(*values.Str).Value, true
+	}
+	if !ok {
+		return nil, "", false, nil
+	}
+	class, found := interpreter.GetClass(className)
+	if !found {
+		return nil, "", false, phpError.NewError("Uncaught Error: Class \"%s\" not found in %s", className, context.Stmt.GetPosString())
+	}
+	return class, method, true, nil
 }
 
 // ProcessEmptyIntrinsicExpr implements Visitor.
@@ -427,15 +563,19 @@ func (interpreter *Interpreter) ProcessEvalIntrinsicExpr(expr *ast.EvalIntrinsic
 	if err != nil {
 		return values.NewVoid(), phpError.NewParseError("%s", err.Error())
 	}
-	expressionStr, err := variableHandling.StrVal(expression)
+	expressionStr, err := interpreter.strVal(expression)
 	if err != nil {
 		return values.NewVoid(), err
 	}
 
-	_, err = interpreter.process("<?php "+expressionStr+" ?>", env.(*Environment), false)
+	program, err := interpreter.parser.ProduceAST("<?php "+expressionStr+" ?>", interpreter.filename)
+	if err != nil {
+		return values.NewBool(false), err
+	}
+	runtimeValue, err := interpreter.processProgram(program, env.(*Environment))
 	if err != nil {
 		if err.GetErrorType() == phpError.EventError && err.GetMessage() == phpError.ReturnEvent {
-			return interpreter.resultRuntimeValue, nil
+			return runtimeValue, nil
 		}
 		return values.NewBool(false), err
 	}
@@ -459,10 +599,6 @@ func (interpreter *Interpreter) ProcessExitIntrinsicExpr(expr *ast.ExitIntrinsic
 	//   1. Writes the optional string to STDOUT.
 	//   2. Calls any functions registered via the library function register_shutdown_function in their order of registration.
 
-	// Spec: https://phplang.org/spec/10-expressions.html#grammar-exit-intrinsic
-	// Invokes destructors for all remaining instances
-	interpreter.destructAllObjects(env.(*Environment))
-
 	expression := expr.Arguments[0]
 	if expression != nil {
 		exprValue := must(interpreter.processStmt(expression, env))
@@ -478,9 +614,11 @@ func (interpreter *Interpreter) ProcessExitIntrinsicExpr(expr *ast.ExitIntrinsic
 	}
 
 	// TODO processExitIntrinsicExpr - call shutdown functions
-	// TODO processExitIntrinsicExpr - call destructors
 
+	// Spec: https://phplang.org/spec/10-expressions.html#grammar-exit-intrinsic
+	// Invokes destructors for all remaining instances
 	interpreter.exitCalled = true
+	interpreter.destructObjectsOnShutdown()
 
 	return values.NewVoid(), phpError.NewEvent(phpError.ExitEvent)
 }
@@ -501,14 +639,14 @@ func (interpreter *Interpreter) ProcessIssetIntrinsicExpr(expr *ast.IssetIntrins
 	defer func() { interpreter.suppressWarning = false }()
 
 	for _, arg := range expr.Arguments {
-		if arg.GetKind() == ast.SubscriptExpr {
-			runtimeValue, err := interpreter.processStmt(arg, env)
-			if err != nil || runtimeValue.GetType() == values.NullValue {
+		if arg.GetKind() == ast.SimpleVariableExpr {
+			runtimeValue, _ := interpreter.lookupVariable(arg, env.(*Environment))
+			if runtimeValue.GetType() == values.NullValue {
 				return values.NewBool(false), nil
 			}
 		} else {
-			runtimeValue, _ := interpreter.lookupVariable(arg, env.(*Environment))
-			if runtimeValue.GetType() == values.NullValue {
+			runtimeValue, err := interpreter.processStmt(arg, env)
+			if err != nil || runtimeValue.GetType() == values.NullValue {
 				return values.NewBool(false), nil
 			}
 		}
@@ -524,12 +662,54 @@ func (interpreter *Interpreter) ProcessUnsetIntrinsicExpr(expr *ast.UnsetIntrins
 	// An attempt to unset a non-existent variable (such as a non-existent element in an array) is ignored.
 
 	for _, arg := range expr.Arguments {
+		if arg.GetKind() == ast.SubscriptExpr {
+			if err := interpreter.unsetArrayElement(arg.(*ast.SubscriptExpression), env.(*Environment)); err != nil {
+				return values.NewVoid(), err
+			}
+			continue
+		}
 		variableName := mustOrVoid(interpreter.varExprToVarName(arg, env.(*Environment)))
 		env.(*Environment).unsetVariable(variableName)
 	}
 	return values.NewVoid(), nil
 }
 
+func (interpreter *Interpreter) unsetArrayElement(expr *ast.SubscriptExpression, env *Environment) phpError.Error {
+	keys := []ast.IExpression{expr.Index}
+	variable := expr.Variable
+	for variable.GetKind() == ast.SubscriptExpr {
+		keys = append(keys, variable.(*ast.SubscriptExpression).Index)
+		variable = variable.(*ast.SubscriptExpression).Variable
+	}
+
+	variableName := mustOrVoid(interpreter.varExprToVarName(variable, env))
+	currentValue, err := env.LookupVariable(variableName)
+	if err != nil {
+		// Unsetting an element of an undefined variable is ignored
+		return nil
+	}
+
+	for i := len(keys) - 1; i >= 0; i-- {
+		if currentValue.GetType() != values.ArrayValue {
+			return nil
+		}
+		if keys[i] == nil {
+			return phpError.NewError("Cannot use [] for unsetting in %s", expr.GetPosString())
+		}
+
+		array := currentValue.(*values.Array)
+		keyValue := must(interpreter.processStmt(keys[i], env))
+		if !array.Contains(keyValue) {
+			return nil
+		}
+		if i == 0 {
+			return array.RemoveElement(keyValue)
+		}
+		currentValue, _ = array.GetElementForWrite(keyValue)
+	}
+	return nil
+}
+
 // ProcessConstantAccessExpr implements Visitor.
 func (interpreter *Interpreter) ProcessConstantAccessExpr(expr *ast.ConstantAccessExpression, env any) (any, error) {
 	// Magic constants
@@ -573,12 +753,12 @@ func (interpreter *Interpreter) ProcessConstantAccessExpr(expr *ast.ConstantAcce
 	// The class name. The class name includes the namespace it was declared in (e.g. Foo\Bar).
 	// When used inside a trait method, __CLASS__ is the name of the class the trait is used in.
 	if expr.ConstantName == "__CLASS__" {
-		if environment.CurrentObject != nil {
+		if environment.CurrentClass != nil {
 			namespace := ""
-			if environment.CurrentObject.Class.GetPosition().File.Namespace != nil {
-				namespace = environment.CurrentObject.Class.GetPosition().File.Namespace.ToString()
+			if environment.CurrentClass.GetPosition().File.Namespace != nil {
+				namespace = environment.CurrentClass.GetPosition().File.Namespace.ToString()
 			}
-			return values.NewStr(namespace + environment.CurrentObject.Class.Name), nil
+			return values.NewStr(namespace + environment.CurrentClass.Name), nil
 		}
 		return values.NewStr(""), nil
 	}
@@ -590,7 +770,7 @@ func (interpreter *Interpreter) ProcessConstantAccessExpr(expr *ast.ConstantAcce
 			return values.NewStr(environment.CurrentFunction.FunctionName), nil
 		}
 		if environment.CurrentMethod != nil {
-			return values.NewStr(environment.CurrentObject.Class.Name + "::" + environment.CurrentMethod.Name), nil
+			return values.NewStr(environment.CurrentClass.Name + "::" + environment.CurrentMethod.Name), nil
 		}
 		return values.NewStr(""), nil
 	}
@@ -615,7 +795,11 @@ func (interpreter *Interpreter) ProcessCompoundAssignmentExpr(expr *ast.Compound
 
 	operand1 := must(interpreter.processStmt(expr.Variable, env))
 	operand2 := must(interpreter.processStmt(expr.Value, env))
-	newValue := must(calculate(operand1, expr.Operator, operand2))
+	newValue := must(interpreter.calculate(operand1, expr.Operator, operand2, expr))
+
+	if expr.Variable.GetKind() == ast.MemberAccessExpr {
+		return interpreter.assignProperty(expr.Variable.(*ast.MemberAccessExpression), newValue, env.(*Environment))
+	}
 
 	variableName := mustOrVoid(interpreter.varExprToVarName(expr.Variable, env.(*Environment)))
 
@@ -662,10 +846,16 @@ func (interpreter *Interpreter) ProcessCoalesceExpr(expr *ast.CoalesceExpression
 	errorReporting, _ := interpreter.ini.Get("error_reporting")
 	// Suppress all errors
 	interpreter.ini.Set("error_reporting", "0", ini.INI_ALL)
+	// Elements that do not exist (e.g. string offsets) are NULL like in isset
+	suppressWarning := interpreter.suppressWarning
+	if expr.Cond.GetKind() == ast.SubscriptExpr {
+		interpreter.suppressWarning = true
+	}
 
 	cond, err := interpreter.processStmt(expr.Cond, env)
 
 	// Restore previous error reporting
+	interpreter.suppressWarning = suppressWarning
 	interpreter.ini.Set("error_reporting", errorReporting, ini.INI_ALL)
 
 	if err != nil {
@@ -707,7 +897,7 @@ func (interpreter *Interpreter) ProcessEqualityExpr(expr *ast.EqualityExpression
 func (interpreter *Interpreter) ProcessBinaryOpExpr(expr *ast.BinaryOpExpression, env any) (any, error) {
 	lhs := must(interpreter.processStmt(expr.Lhs, env))
 	rhs := must(interpreter.processStmt(expr.Rhs, env))
-	return calculate(lhs, expr.Operator, rhs)
+	return interpreter.calculate(lhs, expr.Operator, rhs, expr)
 }
 
 // ProcessUnaryExpr implements Visitor.
@@ -729,8 +919,11 @@ func (interpreter *Interpreter) ProcessCastExpr(expr *ast.CastExpression, env an
 	// A cast-type of "object" results in a conversion to type "object".
 
 	value := must(interpreter.processStmt(expr.Expr, env))
+	return interpreter.castValue(expr.Operator, value)
+}
 
-	switch expr.Operator {
+func (interpreter *Interpreter) castValue(castType string, value values.RuntimeValue) (values.RuntimeValue, phpError.Error) {
+	switch castType {
 	case "array":
 		// Spec: https://phplang.org/spec/10-expressions.html#grammar-cast-type
 		// A cast-type of "array" results in a conversion to type array.
@@ -739,7 +932,8 @@ func (interpreter *Interpreter) ProcessCastExpr(expr *ast.CastExpression, env an
 		// Spec: https://phplang.org/spec/10-expressions.html#grammar-cast-type
 		// A cast-type of "binary" is reserved for future use in dealing with so-called binary strings. For now, it is fully equivalent to "string" cast.
 		// A cast-type of "string" results in a conversion to type "string".
-		return variableHandling.ToValueType(values.StrValue, value, true)
+		str, err := interpreter.strVal(value)
+		return values.NewStr(str), err
 	case "bool", "boolean":
 		// Spec: https://phplang.org/spec/10-expressions.html#grammar-cast-type
 		// A cast-type of "bool" or "boolean" results in a conversion to type "bool".
@@ -753,7 +947,7 @@ func (interpreter *Interpreter) ProcessCastExpr(expr *ast.CastExpression, env an
 		// A cast-type of "int" or "integer" results in a conversion to type "int".
 		return variableHandling.ToValueType(values.IntValue, value, true)
 	default:
-		return values.NewVoid(), phpError.NewError("processCastExpr: Unsupported cast type %s", expr.Operator)
+		return values.NewVoid(), phpError.NewError("processCastExpr: Unsupported cast type %s", castType)
 	}
 }
 
@@ -822,6 +1016,18 @@ func (interpreter *Interpreter) ProcessPrefixIncExpr(expr *ast.PrefixIncExpressi
 	return newValue, nil
 }
 
+// ProcessShellCommandExpr implements Visitor.
+func (interpreter *Interpreter) ProcessShellCommandExpr(expr *ast.ShellCommandExpression, env any) (any, error) {
+	// Spec: https://phplang.org/spec/10-expressions.html#grammar-shell-command-expression
+	// This expression is identical in behavior to the library function shell_exec.
+	// If shell_exec is disabled, the shell command expression is disabled as well.
+
+	command := must(interpreter.processStmt(expr.Command, env))
+
+	shellExec := mustOrVoid(env.(*Environment).lookupNativeFunction("shell_exec"))
+	return shellExec([]values.RuntimeValue{command}, runtime.NewContext(interpreter, env.(*Environment), expr))
+}
+
 // ProcessPrintExpr implements Visitor.
 func (interpreter *Interpreter) ProcessPrintExpr(expr *ast.PrintExpression, env any) (any, error) {
 	// Spec: https://phplang.org/spec/10-expressions.html#grammar-print-expression
@@ -830,7 +1036,7 @@ func (interpreter *Interpreter) ProcessPrintExpr(expr *ast.PrintExpression, env
 
 	runtimeValue := must(interpreter.processStmt(expr.Expr, env))
 
-	str, err := variableHandling.StrVal(runtimeValue)
+	str, err := interpreter.strVal(runtimeValue)
 	if err == nil {
 		interpreter.Print(str)
 	}
@@ -882,39 +1088,63 @@ func (interpreter *Interpreter) ProcessObjectCreationExpr(stmt *ast.ObjectCreati
 	if !found {
 		return values.NewVoid(), phpError.NewError("Cannot create object. Class \"%s\" not found.", stmt.Designator)
 	}
-	object := values.NewObject(class)
+	object := interpreter.NewObject(class)
 
+	// The new object must not be freed when the constructor releases $this
+	values.Retain(object)
+	defer values.Release(object)
 	if err := interpreter.initObject(object, stmt.Args, env); err != nil {
 		return values.NewVoid(), err
 	}
 
-	env.(*Environment).AddObject(object)
-
 	return object, nil
 }
 
 func (interpreter *Interpreter) initObject(object *values.Object, constructorArgs []ast.IExpression, env any) phpError.Error {
+	if err := interpreter.initProperties(object, env.(*Environment)); err != nil {
+		return err
+	}
+
+	// Call constructor
+	if _, found := object.GetMethod("__construct"); found {
+		if _, err := interpreter.CallMethod(object, "__construct", constructorArgs, env.(*Environment)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Initialize the properties of the object (and its parent classes) with their default values
+func (interpreter *Interpreter) initProperties(object *values.Object, env *Environment) phpError.Error {
 	initializeProperties := func(properties map[string]*ast.PropertyDeclarationStatement) phpError.Error {
-		// Initialize properties
 		for _, property := range properties {
 			if property.InitialValue == nil {
-				object.SetProperty(property.Name, values.NewNull())
+				// Spec: https://www.php.net/manual/en/language.oop5.properties.php#language.oop5.properties.typed-properties
+				// Typed properties must be initialized before accessing.
+				// Untyped properties are implicitly initialized with null.
+				if property.Type == nil {
+					object.SetProperty(property.Name, values.NewNull())
+				}
 			} else {
 				value, err := interpreter.processStmt(property.InitialValue, env)
 				if err != nil {
 					return phpError.NewError("Failed to initialize property \"%s\": %s", property.Name, err)
 				}
+				// The default value must match the type. Only int to float is converted.
+				value, isValid, err := interpreter.checkType(value, property.Type, true, env, property)
+				if err != nil {
+					return err
+				}
+				if !isValid {
+					return phpError.NewError(
+						"Cannot use %s as default value for property %s::%s of type %s in %s",
+						getTypeErrorName(value), object.Class.Name, property.Name, property.Type, property.GetPosString(),
+					)
+				}
 				object.SetProperty(property.Name, value)
 			}
 		}
-
-		// Call constructor
-		if _, found := object.GetMethod("__construct"); found {
-			if _, err := interpreter.CallMethod(object, "__construct", constructorArgs, env.(*Environment)); err != nil {
-				return err
-			}
-		}
-
 		return nil
 	}
 
@@ -935,25 +1165,69 @@ func (interpreter *Interpreter) initObject(object *values.Object, constructorArg
 		baseClass = baseClassDecl.BaseClass
 	}
 
-	if err := initializeProperties(object.Class.Properties); err != nil {
-		return err
+	return initializeProperties(object.Class.Properties)
+}
+
+// Create an object with the default property values without calling the constructor
+func (interpreter *Interpreter) NewObjectWithoutConstructor(context runtime.Context, class *ast.ClassDeclarationStatement) (*values.Object, phpError.Error) {
+	object := interpreter.NewObject(class)
+	if err := interpreter.initProperties(object, context.Env.(*Environment)); err != nil {
+		return nil, err
 	}
+	return object, nil
+}
 
-	return nil
+// ProcessCloneExpr implements Visitor.
+func (interpreter *Interpreter) ProcessCloneExpr(expr *ast.CloneExpression, env any) (any, error) {
+	// Spec: https://phplang.org/spec/10-expressions.html#the-clone-operator
+	runtimeValue := must(interpreter.processStmt(expr.Expr, env))
+	if runtimeValue.GetType() != values.ObjectValue {
+		return values.NewVoid(), phpError.NewError("Uncaught Error: __clone method called on non-object in %s", expr.GetPosString())
+	}
+	object := runtimeValue.(*values.Object)
+
+	// Spec: https://www.php.net/manual/en/language.oop5.cloning.php
+	// Once the cloning is complete, if a __clone() method is defined, then the newly created object's __clone() method will be called,
+	// to allow any necessary properties that need to be changed.
+	methodDefinition, class, found := interpreter.lookupMethod(object.Class, "__clone")
+	if found {
+		if visibility := getMethodVisibility(methodDefinition); !interpreter.isMemberAccessible(class, visibility, env.(*Environment)) {
+			return values.NewVoid(), phpError.NewError(
+				"Uncaught Error: Call to %s %s::__clone() from %s in %s", visibility, class.Name, getScopeName(env.(*Environment)), expr.GetPosString(),
+			)
+		}
+	}
+
+	interpreter.lastObjectHandle++
+	clone, err := object.Clone(interpreter.lastObjectHandle)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	if found {
+		// The clone must not be freed when __clone releases $this
+		values.Retain(clone)
+		defer values.Release(clone)
+		if _, err := interpreter.callMethod(class, methodDefinition, clone, []ast.IExpression{}, env.(*Environment)); err != nil {
+			return values.NewVoid(), err
+		}
+	}
+
+	return clone, nil
 }
 
+// ProcessMemberAccessExpr implements Visitor.
 func (interpreter *Interpreter) ProcessMemberAccessExpr(stmt *ast.MemberAccessExpression, env any) (any, error) {
-	variableName := mustOrVoid(interpreter.varExprToVarName(stmt.Object, env.(*Environment)))
-	runtimeObject, err := env.(*Environment).LookupVariable(variableName)
+	runtimeObject, err := interpreter.processDereferencableExpr(stmt.Object, env.(*Environment))
 	if err != nil {
-		return values.NewVoid(), err
+		return runtimeObject, err
 	}
 
-	if stmt.Member.GetKind() != ast.ConstantAccessExpr {
-		return values.NewVoid(), phpError.NewError("ProcessMemberAccessExpr: Unsupported member type: %s", stmt.Member.GetKind())
+	if stmt.IsNullsafe && runtimeObject.GetType() == values.NullValue {
+		return values.NewNull(), phpError.NewEvent(phpError.NullsafeEvent)
 	}
 
-	member := stmt.Member.(*ast.ConstantAccessExpression).ConstantName
+	member := mustOrVoid(interpreter.memberNameToString(stmt.Member, env.(*Environment)))
 
 	if runtimeObject.GetType() != values.ObjectValue {
 		return values.NewVoid(), phpError.NewError(
@@ -963,9 +1237,14 @@ func (interpreter *Interpreter) ProcessMemberAccessExpr(stmt *ast.MemberAccessEx
 	}
 
 	object := runtimeObject.(*values.Object)
-	// property, found := object.Class.Properties["$" + member]
 	value, found := object.GetProperty("$" + member)
 	if !found {
+		if property, class, found := interpreter.lookupProperty(object.Class, "$"+member); found && property.Type != nil {
+			return values.NewVoid(), phpError.NewError(
+				"Uncaught Error: Typed property %s::$%s must not be accessed before initialization in %s",
+				class.Name, member, stmt.Member.GetPosString(),
+			)
+		}
 		return values.NewVoid(), phpError.NewError("Undefined property: %s::$%s in %s",
 			object.Class.Name, member, stmt.Member.GetPosString())
 	}
@@ -973,3 +1252,152 @@ func (interpreter *Interpreter) ProcessMemberAccessExpr(stmt *ast.MemberAccessEx
 
 	return value, nil
 }
+
+func (interpreter *Interpreter) assignProperty(expr *ast.MemberAccessExpression, value values.RuntimeValue, env *Environment) (values.RuntimeValue, phpError.Error) {
+	if expr.IsNullsafe {
+		return values.NewVoid(), phpError.NewError("Can't use nullsafe operator in write context in %s", expr.GetPosString())
+	}
+
+	runtimeObject, err := interpreter.processDereferencableExpr(expr.Object, env)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	member, err := interpreter.memberNameToString(expr.Member, env)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	if runtimeObject.GetType() != values.ObjectValue {
+		return values.NewVoid(), phpError.NewError(
+			"Uncaught Error: Attempt to assign property \"%s\" on %s in %s",
+			member, strings.ToLower(values.ToPhpType(runtimeObject)), expr.GetPosString(),
+		)
+	}
+
+	// TODO Check if visibility --> != public, ...
+
+	object := runtimeObject.(*values.Object)
+	if property, class, found := interpreter.lookupProperty(object.Class, "$"+member); found && property.Type != nil {
+		propertyEnv, err := NewEnvironment(env, nil, interpreter)
+		if err != nil {
+			return values.NewVoid(), err
+		}
+		propertyEnv.CurrentClass = class
+		propertyEnv.CurrentObject = object
+
+		var isValid bool
+		value, isValid, err = interpreter.checkType(value, property.Type, isStrictType(expr), propertyEnv, expr)
+		if err != nil {
+			return values.NewVoid(), err
+		}
+		if !isValid {
+			return values.NewVoid(), phpError.NewError(
+				"Uncaught TypeError: Cannot assign %s to property %s::$%s of type %s in %s",
+				getTypeErrorName(value), class.Name, member, property.Type, expr.GetPosString(),
+			)
+		}
+	}
+
+	object.SetProperty("$"+member, values.Copy(value))
+	return value, nil
+}
+
+// ProcessMemberCallExpr implements Visitor.
+func (interpreter *Interpreter) ProcessMemberCallExpr(stmt *ast.MemberCallExpression, env any) (any, error) {
+	runtimeObject, err := interpreter.processDereferencableExpr(stmt.Object, env.(*Environment))
+	if err != nil {
+		return runtimeObject, err
+	}
+
+	if stmt.IsNullsafe && runtimeObject.GetType() == values.NullValue {
+		return values.NewNull(), phpError.NewEvent(phpError.NullsafeEvent)
+	}
+
+	method := mustOrVoid(interpreter.memberNameToString(stmt.Member, env.(*Environment)))
+
+	if runtimeObject.GetType() != values.ObjectValue {
+		return values.NewVoid(), phpError.NewError(
+			"Uncaught Error: Call to a member function %s() on %s in %s",
+			method, strings.ToLower(values.ToPhpType(runtimeObject)), stmt.GetPosString(),
+		)
+	}
+
+	object := runtimeObject.(*values.Object)
+	methodDefinition, class, found := interpreter.lookupMethod(object.Class, method)
+	if !found {
+		return values.NewVoid(), phpError.NewError("Uncaught Error: Call to undefined method %s::%s() in %s", object.Class.Name, method, stmt.GetPosString())
+	}
+	if err := interpreter.checkMethodVisibility(class, methodDefinition, env.(*Environment), stmt); err != nil {
+		return values.NewVoid(), err
+	}
+
+	return interpreter.callMethod(class, methodDefinition, object, stmt.Arguments, env.(*Environment))
+}
+
+// ProcessScopedCallExpr implements Visitor.
+func (interpreter *Interpreter) ProcessScopedCallExpr(stmt *ast.ScopedCallExpression, env any) (any, error) {
+	scopeClass, err := interpreter.resolveScope(stmt.Scope, env.(*Environment))
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	method := mustOrVoid(interpreter.memberNameToString(stmt.Member, env.(*Environment)))
+
+	methodDefinition, class, found := interpreter.lookupMethod(scopeClass, method)
+	if !found {
+		return values.NewVoid(), phpError.NewError("Uncaught Error: Call to undefined method %s::%s() in %s", scopeClass.Name, method, stmt.GetPosString())
+	}
+	if err := interpreter.checkMethodVisibility(class, methodDefinition, env.(*Environment), stmt); err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Spec: https://www.php.net/manual/en/language.oop5.paamayim-nekudotayim.php
+	// A non-static method called via "parent::", "self::" or the own class name is executed on the current object.
+	var object *values.Object = nil
+	if !slices.Contains(methodDefinition.Modifiers, "static") {
+		currentObject := env.(*Environment).CurrentObject
+		if currentObject == nil || !interpreter.isInstanceOf(currentObject.Class, class) {
+			return values.NewVoid(), phpError.NewError(
+				"Uncaught Error: Non-static method %s::%s() cannot be called statically in %s",
+				class.Name, methodDefinition.Name, stmt.GetPosString(),
+			)
+		}
+		object = currentObject
+	}
+
+	return interpreter.callMethod(class, methodDefinition, object, stmt.Arguments, env.(*Environment))
+}
+
+// ProcessClassConstantAccessExpr implements Visitor.
+func (interpreter *Interpreter) ProcessClassConstantAccessExpr(stmt *ast.ClassConstantAccessExpression, env any) (any, error) {
+	// Spec: https://www.php.net/manual/en/language.oop5.basic.php#language.oop5.basic.class.class
+	// The class keyword is also used for class name resolution.
+	if strings.ToLower(stmt.ConstantName) == "class" && stmt.Scope.GetKind() == ast.StringLiteralExpr {
+		scope := stmt.Scope.(*ast.StringLiteralExpression).Value
+		if !slices.Contains([]string{"self", "parent", "static"}, strings.ToLower(scope)) {
+			return values.NewStr(scope), nil
+		}
+	}
+
+	scopeClass, err := interpreter.resolveScope(stmt.Scope, env.(*Environment))
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	if strings.ToLower(stmt.ConstantName) == "class" {
+		return values.NewStr(scopeClass.Name), nil
+	}
+
+	if constant, class, found := interpreter.lookupClassConstant(scopeClass, stmt.ConstantName); found {
+		// The constant expression is evaluated in the scope of the declaring class
+		constantEnv, err := NewEnvironment(env.(*Environment), nil, interpreter)
+		if err != nil {
+			return values.NewVoid(), err
+		}
+		constantEnv.CurrentClass = class
+		return interpreter.processStmt(constant.Value, constantEnv)
+	}
+
+	return values.NewVoid(), phpError.NewError("Uncaught Error: Undefined constant %s::%s in %s", scopeClass.Name, stmt.ConstantName, stmt.GetPosString())
+}
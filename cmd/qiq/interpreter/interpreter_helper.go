@@ -4,11 +4,14 @@ import (
 	"QIQ/cmd/qiq/ast"
 	"QIQ/cmd/qiq/common"
 	"QIQ/cmd/qiq/common/os"
+	"QIQ/cmd/qiq/opcache"
 	"QIQ/cmd/qiq/phpError"
 	"QIQ/cmd/qiq/runtime"
 	"QIQ/cmd/qiq/runtime/stdlib/outputControl"
+	phpStrings "QIQ/cmd/qiq/runtime/stdlib/strings"
 	"QIQ/cmd/qiq/runtime/stdlib/variableHandling"
 	"QIQ/cmd/qiq/runtime/values"
+	"fmt"
 	"math"
 	GoOs "os"
 	"path/filepath"
@@ -34,7 +37,7 @@ func (interpreter *Interpreter) Println(str string) {
 }
 
 func (interpreter *Interpreter) WriteResult(str string) {
-	interpreter.result += str
+	interpreter.result.WriteString(str)
 }
 
 func (interpreter *Interpreter) flushOutputBuffers() {
@@ -58,6 +61,19 @@ func (interpreter *Interpreter) processCondition(expr ast.IExpression, env *Envi
 }
 
 func (interpreter *Interpreter) lookupVariable(expr ast.IExpression, env *Environment) (values.RuntimeValue, phpError.Error) {
+	// Variables resolved by the resolver are accessed without their name
+	if variable, ok := expr.(*ast.SimpleVariableExpression); ok {
+		if env.hasSlot(variable.Slot) {
+			if value := env.slots[variable.Slot]; value != nil {
+				return value, nil
+			}
+		} else if variable.IsSuperglobal {
+			if value, ok := env.global.predefinedVariables[variable.VariableName.(*ast.VariableNameExpression).VariableName]; ok {
+				return value, nil
+			}
+		}
+	}
+
 	variableName, err := interpreter.varExprToVarName(expr, env)
 	if err != nil {
 		return values.NewVoid(), err
@@ -70,24 +86,107 @@ func (interpreter *Interpreter) lookupVariable(expr ast.IExpression, env *Enviro
 	return runtimeValue, nil
 }
 
+// Spec: https://www.php.net/manual/en/language.oop5.decon.php#language.oop5.decon.destructor
+// The destructor method will be called as soon as there are no other references to a particular object,
+// or in any order during the shutdown sequence.
 func (interpreter *Interpreter) destructObject(object *values.Object, env *Environment) phpError.Error {
 	if object.IsDestructed {
 		return nil
 	}
-	_, found := object.GetMethod("__destruct")
-	if found {
-		_, err := interpreter.CallMethod(object, "__destruct", []ast.IExpression{}, env)
-		if err != nil {
-			return err
+	if _, found := object.GetMethod("__destruct"); !found {
+		return nil
+	}
+	// The destructor is only called once, even if the object is released again by the destructor itself
+	object.IsDestructed = true
+	_, err := interpreter.CallMethod(object, "__destruct", []ast.IExpression{}, env)
+	return err
+}
+
+// Destruct and free an object whose reference count dropped to zero
+func (interpreter *Interpreter) freeObject(object *values.Object, env *Environment) {
+	if object.RefCount > 0 || object.IsFreed {
+		return
+	}
+	if err := interpreter.destructObject(object, env); err != nil {
+		interpreter.PrintError(err)
+	}
+	// The destructor might have stored the object (e.g. in a global variable)
+	if object.RefCount == 0 {
+		object.Free()
+	}
+}
+
+// Free the objects that became unreferenced in the scope of the environment.
+// The cycle collector runs if enough possible roots of garbage cycles were buffered.
+func (interpreter *Interpreter) collectGarbage(env *Environment) {
+	gc := interpreter.gc
+	for gc.HasWork(env.garbageMark) {
+		for _, object := range gc.TakeCandidates(env.garbageMark) {
+			interpreter.freeObject(object, env)
+		}
+		if gc.ThresholdReached() && interpreter.ini.GetBool("zend.enable_gc") {
+			gc.AdjustThreshold(interpreter.CollectCycles())
 		}
-		object.IsDestructed = true
 	}
-	return nil
 }
 
-func (interpreter *Interpreter) destructAllObjects(env *Environment) {
-	objects := env.getAllObjects()
-	for _, object := range objects {
+// Spec: https://www.php.net/manual/en/function.gc-collect-cycles.php
+func (interpreter *Interpreter) CollectCycles() int {
+	return interpreter.gc.CollectCycles(func(object *values.Object) {
+		if err := interpreter.destructObject(object, interpreter.env); err != nil {
+			interpreter.PrintError(err)
+		}
+	})
+}
+
+func (interpreter *Interpreter) GetGarbageCollector() *values.GarbageCollector {
+	return interpreter.gc
+}
+
+// Release the variables of a function or method call. The return value is kept alive for the caller.
+func (interpreter *Interpreter) releaseEnvironment(env *Environment, returnValue values.RuntimeValue) {
+	values.Retain(returnValue)
+	env.releaseVariables()
+	interpreter.collectGarbage(env)
+	values.Release(returnValue)
+}
+
+// Discard the copies of the arguments of a native function after the call.
+// The result is kept, as a native function can return one of its arguments.
+func discardArguments(args []values.RuntimeValue, result values.RuntimeValue) {
+	for _, arg := range args {
+		if arg != result {
+			values.Discard(arg)
+		}
+	}
+}
+
+// Destruct the remaining objects at the end of the script (see PHP's shutdown_destructors)
+func (interpreter *Interpreter) destructObjectsOnShutdown() {
+	env := interpreter.env
+	interpreter.collectGarbage(env)
+
+	// Objects that are only held by a global variable are destructed in reverse order of the variable declarations
+	for {
+		released := false
+		for index := len(env.variableNames) - 1; index >= 0; index-- {
+			if index >= len(env.variableNames) {
+				continue
+			}
+			variableName := env.variableNames[index]
+			if object, ok := env.variables[variableName].(*values.Object); ok && object.RefCount == 1 {
+				env.unsetVariable(variableName)
+				interpreter.collectGarbage(env)
+				released = true
+			}
+		}
+		if !released {
+			break
+		}
+	}
+
+	// All other objects are destructed in the order of their creation
+	for _, object := range interpreter.gc.GetObjects() {
 		if err := interpreter.destructObject(object, env); err != nil {
 			interpreter.PrintError(err)
 		}
@@ -113,7 +212,7 @@ func (interpreter *Interpreter) varExprToVarName(expr ast.IExpression, env *Envi
 			if err != nil {
 				interpreter.PrintError(err)
 			}
-			valueStr, err := variableHandling.StrVal(runtimeValue)
+			valueStr, err := interpreter.strVal(runtimeValue)
 			if err != nil {
 				return "", err
 			}
@@ -124,7 +223,7 @@ func (interpreter *Interpreter) varExprToVarName(expr ast.IExpression, env *Envi
 		if err != nil {
 			return "", err
 		}
-		valueStr, err := variableHandling.StrVal(variableName)
+		valueStr, err := interpreter.strVal(variableName)
 		if err != nil {
 			return "", err
 		}
@@ -140,7 +239,8 @@ func (interpreter *Interpreter) ErrorToString(err phpError.Error) string {
 	if (err.GetErrorType() == phpError.WarningPhpError && interpreter.ini.GetInt("error_reporting")&phpError.E_WARNING == 0) ||
 		(err.GetErrorType() == phpError.ErrorPhpError && interpreter.ini.GetInt("error_reporting")&phpError.E_ERROR == 0) ||
 		(err.GetErrorType() == phpError.NoticePhpError && interpreter.ini.GetInt("error_reporting")&phpError.E_NOTICE == 0) ||
-		(err.GetErrorType() == phpError.ParsePhpError && interpreter.ini.GetInt("error_reporting")&phpError.E_PARSE == 0) {
+		(err.GetErrorType() == phpError.ParsePhpError && interpreter.ini.GetInt("error_reporting")&phpError.E_PARSE == 0) ||
+		(err.GetErrorType() == phpError.DeprecatedPhpError && interpreter.ini.GetInt("error_reporting")&phpError.E_DEPRECATED == 0) {
 		return ""
 	}
 	return err.GetMessage()
@@ -155,6 +255,14 @@ func (interpreter *Interpreter) PrintError(err phpError.Error) {
 	}
 }
 
+// Convert the runtime value to a string using the ini directive "precision" for floats
+func (interpreter *Interpreter) strVal(value values.RuntimeValue) (string, phpError.Error) {
+	if str, ok := value.(*values.Str); ok {
+		return str.Value, nil
+	}
+	return variableHandling.StrVal(value, interpreter.ini.GetInt("precision"))
+}
+
 // Scan and process program for function definitions on root level and in compound statements
 func (interpreter *Interpreter) scanForFunctionDefinition(statements []ast.IStatement, env *Environment) phpError.Error {
 	for _, stmt := range statements {
@@ -175,38 +283,6 @@ func (interpreter *Interpreter) scanForFunctionDefinition(statements []ast.IStat
 	return nil
 }
 
-var literalExprTypeRuntimeValue = map[ast.NodeType]string{
-	ast.ArrayLiteralExpr:   "array",
-	ast.IntegerLiteralExpr: "int",
-	ast.StringLiteralExpr:  "string",
-}
-
-func literalExprTypeToRuntimeValue(expr ast.IExpression) (string, phpError.Error) {
-	typeStr, found := literalExprTypeRuntimeValue[expr.GetKind()]
-	if !found {
-		return "", phpError.NewError("literalExprTypeToRuntimeValue: No mapping for type %s", expr.GetKind())
-	}
-	return typeStr, nil
-}
-
-func checkParameterTypes(runtimeValue values.RuntimeValue, expectedTypes []string) phpError.Error {
-	typeStr := values.ToPhpType(runtimeValue)
-	if typeStr == "" {
-		return phpError.NewError("checkParameterTypes: No mapping for type %s", runtimeValue.GetType())
-	}
-
-	for _, expectedType := range expectedTypes {
-		if expectedType == "mixed" {
-			return nil
-		}
-
-		if typeStr == expectedType {
-			return nil
-		}
-	}
-	return phpError.NewError("Types do not match")
-}
-
 func (interpreter *Interpreter) includeFile(filepathExpr ast.IExpression, env *Environment, include bool, once bool) (values.RuntimeValue, phpError.Error) {
 	runtimeValue, err := interpreter.processStmt(filepathExpr, env)
 	if err != nil {
@@ -216,7 +292,7 @@ func (interpreter *Interpreter) includeFile(filepathExpr ast.IExpression, env *E
 		return runtimeValue, phpError.NewError("Uncaught ValueError: Path cannot be empty in %s", filepathExpr.GetPosString())
 	}
 
-	filename, err := variableHandling.StrVal(runtimeValue)
+	filename, err := interpreter.strVal(runtimeValue)
 	if err != nil {
 		return runtimeValue, err
 	}
@@ -268,11 +344,10 @@ func (interpreter *Interpreter) includeFile(filepathExpr ast.IExpression, env *E
 		return getError()
 	}
 
-	content, fileErr := GoOs.ReadFile(absFilename)
+	program, parserErr, fileErr := interpreter.parseFile(absFilename)
 	if fileErr != nil {
 		return getError()
 	}
-	program, parserErr := interpreter.parser.ProduceAST(string(content), absFilename)
 
 	if !os.IS_WIN {
 		interpreter.includedFiles = append(interpreter.includedFiles, absFilename)
@@ -285,6 +360,32 @@ func (interpreter *Interpreter) includeFile(filepathExpr ast.IExpression, env *E
 	return interpreter.processProgram(program, env)
 }
 
+// Parse the given file.
+// If the opcache is enabled, the parsed program is cached and shared with other interpreters.
+func (interpreter *Interpreter) parseFile(filename string) (*ast.Program, phpError.Error, error) {
+	useOpcache := interpreter.ini.GetBool("opcache.enable")
+	if useOpcache {
+		program, found := opcache.Get(filename, interpreter.ini.GetBool("opcache.validate_timestamps"), interpreter.ini.GetInt("opcache.revalidate_freq"))
+		if found {
+			return program, nil, nil
+		}
+	}
+
+	info, fileErr := GoOs.Stat(filename)
+	if fileErr != nil {
+		return nil, nil, fileErr
+	}
+	content, fileErr := GoOs.ReadFile(filename)
+	if fileErr != nil {
+		return nil, nil, fileErr
+	}
+	program, parserErr := interpreter.parser.ProduceAST(string(content), filename)
+	if parserErr == nil && useOpcache {
+		opcache.Store(filename, program, info)
+	}
+	return program, parserErr, nil
+}
+
 func GetExecutableCreationDate() time.Time {
 	exePath, err := GoOs.Executable()
 	if err != nil {
@@ -321,6 +422,43 @@ func (interpreter *Interpreter) writeCache(stmt ast.IStatement, value values.Run
 
 // -------------------------------------- RuntimeValue -------------------------------------- MARK: RuntimeValue
 
+// Matches an escape sequence or a variable substitution of a double quoted or heredoc string.
+// Escape sequences are matched so that an escaped dollar sign (e.g. "\$a") is not substituted.
+var variableSubstitutionRegex = regexp.MustCompile(`(\\(?s:.))|({\$[A-Za-z_][A-Za-z0-9_]*['A-Za-z0-9\[\]]*[^}]*})|(\$[A-Za-z_][A-Za-z0-9_]*['A-Za-z0-9\[\]]*)`)
+
+// Part of a double quoted or heredoc string: Either text or a variable substitution (e.g. "$a" or "{$a}")
+type stringPart struct {
+	text         string
+	substitution string
+}
+
+// Split a double quoted or heredoc string into text and variable substitutions.
+// The escape sequences of the text are replaced.
+func splitStringLiteral(expr *ast.StringLiteralExpression) []stringPart {
+	replaceEscapeSequences := common.ReplaceDoubleQuoteControlChars
+	if expr.StringType == ast.HeredocString {
+		replaceEscapeSequences = common.ReplaceHeredocControlChars
+	}
+
+	parts := []stringPart{}
+	start := 0
+	for _, match := range variableSubstitutionRegex.FindAllStringSubmatchIndex(expr.RawValue, -1) {
+		// Escape sequences are part of the text
+		if match[2] != -1 {
+			continue
+		}
+		if match[0] > start {
+			parts = append(parts, stringPart{text: replaceEscapeSequences(expr.RawValue[start:match[0]])})
+		}
+		parts = append(parts, stringPart{substitution: expr.RawValue[match[0]:match[1]]})
+		start = match[1]
+	}
+	if start < len(expr.RawValue) {
+		parts = append(parts, stringPart{text: replaceEscapeSequences(expr.RawValue[start:])})
+	}
+	return parts
+}
+
 func (interpreter *Interpreter) exprToRuntimeValue(expr ast.IExpression, env *Environment) (values.RuntimeValue, phpError.Error) {
 	switch expr.GetKind() {
 	case ast.ArrayLiteralExpr:
@@ -348,63 +486,158 @@ func (interpreter *Interpreter) exprToRuntimeValue(expr ast.IExpression, env *En
 	case ast.FloatingLiteralExpr:
 		return values.NewFloat(expr.(*ast.FloatingLiteralExpression).Value), nil
 	case ast.StringLiteralExpr:
-		str := expr.(*ast.StringLiteralExpression).Value
-		strType := expr.(*ast.StringLiteralExpression).StringType
-		if strType == ast.DoubleQuotedString || strType == ast.HeredocString {
-			// Supported expression: variable substitution: `echo "{$a}";`
-			// variable substitution
-			// TODO improve variable substitution: Regex and replace will not work for every case here. A parser is required that searches for variables, subscriptExpr, ... and resolves them.
-			// TODO improve variable substitution to detect if a $ is escaped. E.g. "\$i"
-			// TODO improve variable substitution to accept nested arrays "$a[$b['c']][0]"
-			r, _ := regexp.Compile(`({\$[A-Za-z_][A-Za-z0-9_]*['A-Za-z0-9\[\]]*[^}]*})|(\$[A-Za-z_][A-Za-z0-9_]*['A-Za-z0-9\[\]]*)`)
-			matches := r.FindAllString(str, -1)
-			for _, match := range matches {
-				varExpr := match
-				if match[0] == '{' {
-					// Remove curly braces
-					varExpr = match[1 : len(match)-1]
-				}
-				// TODO Find better solution for code evaluation
-				exprStr := "<?= " + varExpr + ";"
-				interp, err := NewInterpreter(interpreter.ini, interpreter.request, "__file_name__")
-				if err != nil {
-					return values.NewVoid(), err
-				}
-				result, err := interp.process(exprStr, env, true)
-				if err != nil {
-					return values.NewVoid(), err
-				}
-				// TODO Improve bad fix so that the warning is above the possible string output
-				if strings.Contains(result, "Warning: Undefined variable") {
-					filenameRegex := regexp.MustCompile(`__file_name__:\d+:\d+`)
-					interpreter.PrintError(
-						phpError.NewWarning(
-							"%s", strings.TrimPrefix(
-								strings.TrimSpace(
-									filenameRegex.ReplaceAllString(result, expr.GetPosString())),
-								"Warning: ")))
-					result = ""
-				}
-				str = strings.Replace(str, match, result, 1)
+		strExpr := expr.(*ast.StringLiteralExpression)
+		if strExpr.StringType != ast.DoubleQuotedString && strExpr.StringType != ast.HeredocString {
+			return values.NewStr(strExpr.Value), nil
+		}
+
+		// Supported expression: variable substitution: `echo "{$a}";`
+		// TODO improve variable substitution: Regex will not work for every case here. A parser is required that searches for variables, subscriptExpr, ... and resolves them.
+		// TODO improve variable substitution to accept nested arrays "$a[$b['c']][0]"
+		var str strings.Builder
+		for _, part := range splitStringLiteral(strExpr) {
+			if part.substitution == "" {
+				str.WriteString(part.text)
+				continue
 			}
 
-			// unicode escape sequence
-			r, _ = regexp.Compile(`\\u\{[0-9a-fA-F]+\}`)
-			matches = r.FindAllString(str, -1)
-			for _, match := range matches {
-				unicodeChar, err := strconv.ParseInt(match[3:len(match)-1], 16, 32)
-				if err != nil {
-					return values.NewVoid(), phpError.NewError("%s", err.Error())
-				}
-				str = strings.Replace(str, match, string(rune(unicodeChar)), 1)
+			varExpr := part.substitution
+			if varExpr[0] == '{' {
+				// Remove curly braces
+				varExpr = varExpr[1 : len(varExpr)-1]
+			}
+			// TODO Find better solution for code evaluation
+			exprStr := "<?= " + varExpr + ";"
+			interp, err := NewInterpreter(interpreter.ini, interpreter.request, "__file_name__")
+			if err != nil {
+				return values.NewVoid(), err
+			}
+			result, err := interp.process(exprStr, env, true)
+			if err != nil {
+				return values.NewVoid(), err
 			}
+			// TODO Improve bad fix so that the warning is above the possible string output
+			if strings.Contains(result, "Warning: Undefined variable") {
+				filenameRegex := regexp.MustCompile(`__file_name__:\d+:\d+`)
+				interpreter.PrintError(
+					phpError.NewWarning(
+						"%s", strings.TrimPrefix(
+							strings.TrimSpace(
+								filenameRegex.ReplaceAllString(result, expr.GetPosString())),
+							"Warning: ")))
+				result = ""
+			}
+			str.WriteString(result)
 		}
-		return values.NewStr(str), nil
+		return values.NewStr(str.String()), nil
 	default:
 		return values.NewVoid(), phpError.NewError("exprToRuntimeValue: Unsupported expression: %s", expr)
 	}
 }
 
+// -------------------------------------- string-offset -------------------------------------- MARK: string-offset
+
+// Convert the key of a string offset (e.g. `$s[1]`, `$s["1"]` or `$s[-1]`) to an integer.
+// Strings are byte arrays, so the offset designates a byte and not a character.
+func (interpreter *Interpreter) stringOffsetKey(key values.RuntimeValue, expr *ast.SubscriptExpression, quiet bool) (int64, phpError.Error) {
+	switch key.GetType() {
+	case values.IntValue:
+		return key.(*values.Int).Value, nil
+	case values.StrValue:
+		if offset, err := strconv.ParseInt(key.(*values.Str).Value, 10, 64); err == nil {
+			return offset, nil
+		}
+	case values.BoolValue, values.FloatValue, values.NullValue:
+		if !quiet {
+			interpreter.PrintError(phpError.NewWarning("String offset cast occurred in %s", expr.Index.GetPosString()))
+		}
+		return variableHandling.IntVal(key, false)
+	}
+	return 0, phpError.NewError("Cannot access offset of type %s on string in %s", values.ToPhpType(key), expr.Index.GetPosString())
+}
+
+// Get the byte at the given offset. Negative offsets are counted from the end of the string.
+func (interpreter *Interpreter) readStringOffset(str string, key values.RuntimeValue, expr *ast.SubscriptExpression, quiet bool) (values.RuntimeValue, phpError.Error) {
+	offset, err := interpreter.stringOffsetKey(key, expr, quiet)
+	if err != nil {
+		if quiet {
+			return values.NewNull(), nil
+		}
+		return values.NewVoid(), err
+	}
+
+	pos := offset
+	if pos < 0 {
+		pos += int64(len(str))
+	}
+	if pos < 0 || pos >= int64(len(str)) {
+		if quiet {
+			return values.NewNull(), nil
+		}
+		interpreter.PrintError(phpError.NewWarning("Uninitialized string offset %d in %s", offset, expr.Index.GetPosString()))
+		return values.NewStr(""), nil
+	}
+	return values.NewStr(str[pos : pos+1]), nil
+}
+
+// Replace the byte at the given offset with the first byte of the value.
+// Strings are padded with spaces if the offset is behind the end of the string.
+func (interpreter *Interpreter) writeStringOffset(str string, key values.RuntimeValue, value values.RuntimeValue, expr *ast.SimpleAssignmentExpression) (string, phpError.Error) {
+	offset, err := interpreter.stringOffsetKey(key, expr.Variable.(*ast.SubscriptExpression), false)
+	if err != nil {
+		return str, err
+	}
+
+	valueStr, err := interpreter.strVal(value)
+	if err != nil {
+		return str, err
+	}
+	if valueStr == "" {
+		return str, phpError.NewError("Cannot assign an empty string to a string offset in %s", expr.Value.GetPosString())
+	}
+
+	pos := offset
+	if pos < 0 {
+		pos += int64(len(str))
+		if pos < 0 {
+			interpreter.PrintError(phpError.NewWarning("Illegal string offset %d in %s", offset, expr.Variable.GetPosString()))
+			return str, nil
+		}
+	}
+
+	if len(valueStr) > 1 {
+		interpreter.PrintError(phpError.NewWarning("Only the first byte will be assigned to the string offset in %s", expr.Value.GetPosString()))
+	}
+
+	str = common.ExtendWithSpaces(str, int(pos+1))
+	return common.ReplaceAtPos(str, valueStr, int(pos)), nil
+}
+
+// Assign the value to the element of the array that is designated by the keys (e.g. `$a[1][] = 2;`).
+// Missing nested arrays are created. A nil key appends a new element.
+func assignArrayElement(array *values.Array, keys []values.RuntimeValue, value values.RuntimeValue) phpError.Error {
+	for index, keyValue := range keys {
+		if index == len(keys)-1 {
+			return array.SetElement(keyValue, value)
+		}
+
+		if keyValue == nil || !array.Contains(keyValue) {
+			if err := array.SetElement(keyValue, values.NewArray()); err != nil {
+				return err
+			}
+			if keyValue == nil {
+				keyValue = array.GetKeys()[array.Count()-1]
+			}
+		}
+		element, _ := array.GetElementForWrite(keyValue)
+		if element.GetType() != values.ArrayValue {
+			return phpError.NewError("processSimpleAssignmentExpr: Unexpected currentValue type %s", element.GetType())
+		}
+		array = element.(*values.Array)
+	}
+	return nil
+}
+
 // -------------------------------------- inc-dec-calculation -------------------------------------- MARK: inc-dec-calculation
 
 func calculateIncDec(operator string, operand values.RuntimeValue) (values.RuntimeValue, phpError.Error) {
@@ -504,6 +737,15 @@ func calculateIncDecNull(operator string) (values.RuntimeValue, phpError.Error)
 }
 
 func calculateIncDecString(operator string, operand *values.Str) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://phplang.org/spec/10-expressions.html#prefix-increment-and-decrement-operators
+	// For a prefix -- or ++ operator used with a numeric string, the numeric string is treated as the corresponding int or float value.
+	if numericStr, ok := common.ParseNumericString(operand.Value); ok && !numericStr.TrailingData {
+		if numericStr.IsFloat {
+			return calculateIncDecFloating(operator, values.NewFloat(numericStr.Float))
+		}
+		return calculateIncDecInteger(operator, values.NewInt(numericStr.Int))
+	}
+
 	switch operator {
 	case "++":
 		// Spec: https://phplang.org/spec/10-expressions.html#prefix-increment-and-decrement-operators
@@ -513,7 +755,16 @@ func calculateIncDecString(operator string, operand *values.Str) (values.Runtime
 		if operand.Value == "" {
 			return values.NewStr("1"), nil
 		}
-		return values.NewVoid(), phpError.NewError("TODO calculateIncDecString")
+
+		// Spec: https://phplang.org/spec/10-expressions.html#prefix-increment-and-decrement-operators
+		// For a non-numeric string-valued operand that contains only alphanumeric characters, for a prefix ++ operator,
+		// the operand is considered to be a representation of a base-36 number (i.e., with digits 0–9 followed by A–Z or a–z)
+		// in which letter case is ignored for value purposes. The right-most digit is incremented by 1. [...]
+		// For a non-numeric string-valued operand that contains any non-alphanumeric characters, for a prefix ++ operator,
+		// all characters up to and including the right-most non-alphanumeric character is passed through to the resulting string, unchanged.
+		// Characters to the right of that right-most non-alphanumeric character are treated like a non-numeric string-valued operand
+		// that contains only alphanumeric characters, except that the resulting string will not be extended.
+		return values.NewStr(phpStrings.IncrementString(operand.Value)), nil
 
 	case "--":
 		// Spec: https://phplang.org/spec/10-expressions.html#prefix-increment-and-decrement-operators
@@ -523,25 +774,14 @@ func calculateIncDecString(operator string, operand *values.Str) (values.Runtime
 		if operand.Value == "" {
 			return values.NewInt(-1), nil
 		}
-		return values.NewVoid(), phpError.NewError("TODO calculateIncDecString")
+
+		// Spec: https://phplang.org/spec/10-expressions.html#prefix-increment-and-decrement-operators
+		// For a prefix -- operator used with a non-numeric string-valued operand, there is no side effect, and the result is the operand’s value.
+		return operand, nil
 
 	default:
-		return values.NewInt(0), phpError.NewError("calculateIncDecNull: Operator \"%s\" not implemented", operator)
+		return values.NewInt(0), phpError.NewError("calculateIncDecString: Operator \"%s\" not implemented", operator)
 	}
-
-	// TODO calculateIncDecString
-	// Spec: https://phplang.org/spec/10-expressions.html#prefix-increment-and-decrement-operators
-	/*
-		String Operands
-
-		For a prefix -- or ++ operator used with a numeric string, the numeric string is treated as the corresponding int or float value.
-
-		For a prefix -- operator used with a non-numeric string-valued operand, there is no side effect, and the result is the operand’s value.
-
-		For a non-numeric string-valued operand that contains only alphanumeric characters, for a prefix ++ operator, the operand is considered to be a representation of a base-36 number (i.e., with digits 0–9 followed by A–Z or a–z) in which letter case is ignored for value purposes. The right-most digit is incremented by 1. For the digits 0–8, that means going to 1–9. For the letters “A”–“Y” (or “a”–“y”), that means going to “B”–“Z” (or “b”–“z”). For the digit 9, the digit becomes 0, and the carry is added to the next left-most digit, and so on. For the digit “Z” (or “z”), the resulting string has an extra digit “A” (or “a”) appended. For example, when incrementing, “a” -> “b”, “Z” -> “AA”, “AA” -> “AB”, “F29” -> “F30”, “FZ9” -> “GA0”, and “ZZ9” -> “AAA0”. A digit position containing a number wraps modulo-10, while a digit position containing a letter wraps modulo-26.
-
-		For a non-numeric string-valued operand that contains any non-alphanumeric characters, for a prefix ++ operator, all characters up to and including the right-most non-alphanumeric character is passed through to the resulting string, unchanged. Characters to the right of that right-most non-alphanumeric character are treated like a non-numeric string-valued operand that contains only alphanumeric characters, except that the resulting string will not be extended. Instead, a digit position containing a number wraps modulo-10, while a digit position containing a letter wraps modulo-26.
-	*/
 }
 
 // -------------------------------------- unary-op-calculation -------------------------------------- MARK: unary-op-calculation
@@ -558,6 +798,17 @@ func calculateUnary(operator string, operand values.RuntimeValue) (values.Runtim
 		// Spec: https://phplang.org/spec/10-expressions.html#unary-arithmetic-operators
 		// For a unary + or unary - operator used with a NULL-valued operand, the value of the result is zero and the type is int.
 		return values.NewInt(0), nil
+	case values.StrValue:
+		if operator == "~" {
+			// Spec: https://phplang.org/spec/10-expressions.html#unary-arithmetic-operators
+			// For a unary ~ operator used with a string, the result is the string with each byte being bitwise complement of the corresponding byte of the source string.
+			str := []byte(operand.(*values.Str).Value)
+			for i := range str {
+				str[i] = ^str[i]
+			}
+			return values.NewStr(string(str)), nil
+		}
+		return values.NewVoid(), phpError.NewError("calculateUnary: Type \"%s\" not implemented", operand.GetType())
 	default:
 		return values.NewVoid(), phpError.NewError("calculateUnary: Type \"%s\" not implemented", operand.GetType())
 	}
@@ -565,7 +816,6 @@ func calculateUnary(operator string, operand values.RuntimeValue) (values.Runtim
 	// TODO calculateUnary - string
 	// Spec: https://phplang.org/spec/10-expressions.html#unary-arithmetic-operators
 	// For a unary + or - operator used with a numeric string or a leading-numeric string, the string is first converted to an int or float, as appropriate, after which it is handled as an arithmetic operand. The trailing non-numeric characters in leading-numeric strings are ignored. With a non-numeric string, the result has type int and value 0. If the string was leading-numeric or non-numeric, a non-fatal error MUST be produced.
-	// For a unary ~ operator used with a string, the result is the string with each byte being bitwise complement of the corresponding byte of the source string.
 
 	// TODO calculateUnary - object
 	// If the operand has an object type supporting the operation, then the object semantics defines the result. Otherwise, for ~ the fatal error is issued and for + and - the object is converted to int.
@@ -624,7 +874,7 @@ func calculateUnaryFloating(operator string, operand *values.Float) (values.Runt
 	}
 }
 
-func calculateUnaryInteger(operator string, operand *values.Int) (*values.Int, phpError.Error) {
+func calculateUnaryInteger(operator string, operand *values.Int) (values.RuntimeValue, phpError.Error) {
 	switch operator {
 	case "+":
 		// Spec: https://phplang.org/spec/10-expressions.html#unary-arithmetic-operators
@@ -636,6 +886,9 @@ func calculateUnaryInteger(operator string, operand *values.Int) (*values.Int, p
 		// For a unary - operator used with an arithmetic operand, the value of the result is the negated value of the operand.
 		// However, if an int operand’s original value is the smallest representable for that type,
 		// the operand is treated as if it were float and the result will be float.
+		if operand.Value == math.MinInt64 {
+			return values.NewFloat(-float64(operand.Value)), nil
+		}
 		return values.NewInt(-operand.Value), nil
 
 	case "~":
@@ -651,47 +904,90 @@ func calculateUnaryInteger(operator string, operand *values.Int) (*values.Int, p
 
 // -------------------------------------- binary-op-calculation -------------------------------------- MARK: binary-op-calculation
 
-func calculate(operand1 values.RuntimeValue, operator string, operand2 values.RuntimeValue) (values.RuntimeValue, phpError.Error) {
-	resultType := values.VoidValue
-	if slices.Contains([]string{"."}, operator) {
-		resultType = values.StrValue
-	} else if slices.Contains([]string{"&", "|", "^", "<<", ">>"}, operator) {
-		resultType = values.IntValue
-	} else {
-		resultType = values.IntValue
-		if operand1.GetType() == values.FloatValue || operand2.GetType() == values.FloatValue {
-			resultType = values.FloatValue
+func (interpreter *Interpreter) calculate(operand1 values.RuntimeValue, operator string, operand2 values.RuntimeValue, stmt ast.IStatement) (values.RuntimeValue, phpError.Error) {
+	if operator == "." {
+		str1, ok := operand1.(*values.Str)
+		if !ok {
+			str, err := interpreter.strVal(operand1)
+			if err != nil {
+				return values.NewVoid(), err
+			}
+			str1 = values.NewStr(str)
 		}
+		str2, err := interpreter.strVal(operand2)
+		if err != nil {
+			return values.NewVoid(), err
+		}
+		return calculateString(str1, operator, values.NewStr(str2))
+	}
+
+	if slices.Contains([]string{"&", "|", "^"}, operator) &&
+		operand1.GetType() == values.StrValue && operand2.GetType() == values.StrValue {
+		// Spec: https://phplang.org/spec/10-expressions.html#bitwise-and-operator
+		// If both operands are strings, the bitwise operation is applied to the bytes of the strings
+		return calculateString(operand1.(*values.Str), operator, operand2.(*values.Str))
 	}
 
-	var err phpError.Error
-	operand1, err = variableHandling.ToValueType(resultType, operand1, false)
+	// Spec: https://www.php.net/manual/en/language.types.numeric-strings.php
+	// Strings used in arithmetic contexts are converted to an int or float
+	numericOperand1, err := interpreter.numericOperand(operand1, operand1, operator, operand2, stmt)
 	if err != nil {
 		return values.NewVoid(), err
 	}
-	operand2, err = variableHandling.ToValueType(resultType, operand2, false)
+	numericOperand2, err := interpreter.numericOperand(operand2, operand1, operator, operand2, stmt)
 	if err != nil {
 		return values.NewVoid(), err
 	}
-	// TODO testing how PHP behavious: var_dump(1.0 + 2); var_dump(1 + 2.0); var_dump("1" + 2);
-	// var_dump("1" + "2"); => int
-	// var_dump("1" . 2); => str
-	// type order "string" - "int" - "float"
 
-	// Testen
-	//   true + 2
-	//   true && 3
+	resultType := values.IntValue
+	if !slices.Contains([]string{"&", "|", "^", "<<", ">>", "%"}, operator) &&
+		(numericOperand1.GetType() == values.FloatValue || numericOperand2.GetType() == values.FloatValue) {
+		resultType = values.FloatValue
+	}
 
-	switch resultType {
-	case values.IntValue:
-		return calculateInteger(operand1.(*values.Int), operator, operand2.(*values.Int))
-	case values.FloatValue:
-		return calculateFloating(operand1.(*values.Float), operator, operand2.(*values.Float))
-	case values.StrValue:
-		return calculateString(operand1.(*values.Str), operator, operand2.(*values.Str))
-	default:
-		return values.NewVoid(), phpError.NewError("calculate: Type \"%s\" not implemented", resultType)
+	numericOperand1, err = variableHandling.ToValueType(resultType, numericOperand1, false)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+	numericOperand2, err = variableHandling.ToValueType(resultType, numericOperand2, false)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	if resultType == values.FloatValue {
+		return calculateFloating(numericOperand1.(*values.Float), operator, numericOperand2.(*values.Float))
+	}
+	return calculateInteger(numericOperand1.(*values.Int), operator, numericOperand2.(*values.Int))
+}
+
+// Convert a string operand of an arithmetic operation to an int or float
+func (interpreter *Interpreter) numericOperand(
+	operand values.RuntimeValue, operand1 values.RuntimeValue, operator string, operand2 values.RuntimeValue, stmt ast.IStatement,
+) (values.RuntimeValue, phpError.Error) {
+	str, ok := operand.(*values.Str)
+	if !ok {
+		return operand, nil
+	}
+
+	// Spec: https://www.php.net/manual/en/language.types.numeric-strings.php
+	// If the string is numeric or leading numeric then it will resolve to the corresponding integer or float value,
+	// otherwise it is converted to zero (0).
+	// Leading-numeric strings produce an E_WARNING "A non-numeric value encountered" and
+	// non-numeric strings throw a TypeError.
+	numericStr, ok := common.ParseNumericString(str.Value)
+	if !ok {
+		return values.NewVoid(), phpError.NewError(
+			"Uncaught TypeError: Unsupported operand types: %s %s %s in %s",
+			getTypeErrorName(operand1), operator, getTypeErrorName(operand2), stmt.GetPosString(),
+		)
+	}
+	if numericStr.TrailingData && !interpreter.suppressWarning {
+		interpreter.PrintError(phpError.NewWarning("A non-numeric value encountered in %s", stmt.GetPosString()))
+	}
+	if numericStr.IsFloat {
+		return values.NewFloat(numericStr.Float), nil
 	}
+	return values.NewInt(numericStr.Int), nil
 }
 
 func calculateFloating(operand1 *values.Float, operator string, operand2 *values.Float) (values.RuntimeValue, phpError.Error) {
@@ -703,29 +999,32 @@ func calculateFloating(operand1 *values.Float, operator string, operand2 *values
 	case "*":
 		return values.NewFloat(operand1.Value * operand2.Value), nil
 	case "/":
+		if operand2.Value == 0 {
+			// TODO Add position in output
+			return values.NewFloat(0), phpError.NewError("Uncaught DivisionByZeroError: Division by zero")
+		}
 		return values.NewFloat(operand1.Value / operand2.Value), nil
 	case "**":
 		return values.NewFloat(math.Pow(operand1.Value, operand2.Value)), nil
-	case "%":
-		op1, err := variableHandling.IntVal(operand1, false)
-		if err != nil {
-			return values.NewFloat(0), err
-		}
-		op2, err := variableHandling.IntVal(operand2, false)
-		if err != nil {
-			return values.NewFloat(0), err
-		}
-		return values.NewInt(op1 % op2), nil
 	default:
 		return values.NewFloat(0), phpError.NewError("calculateFloating: Operator \"%s\" not implemented", operator)
 	}
 }
 
-func calculateInteger(operand1 *values.Int, operator string, operand2 *values.Int) (*values.Int, phpError.Error) {
+func calculateInteger(operand1 *values.Int, operator string, operand2 *values.Int) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/language.types.integer.php#language.types.integer.overflow
+	// If PHP encounters a number beyond the bounds of the int type, it will be interpreted as a float instead.
+	// Also, an operation which results in a number beyond the bounds of the int type will return a float instead.
 	switch operator {
-	case "<<":
-		return values.NewInt(operand1.Value << operand2.Value), nil
-	case ">>":
+	case "<<", ">>":
+		if operand2.Value < 0 {
+			// TODO Add position in output
+			return values.NewInt(0), phpError.NewError("Uncaught ArithmeticError: Bit shift by negative number")
+		}
+		// Shifting by 64 or more bits results in 0 (or -1 for a negative operand and ">>")
+		if operator == "<<" {
+			return values.NewInt(operand1.Value << operand2.Value), nil
+		}
 		return values.NewInt(operand1.Value >> operand2.Value), nil
 	case "^":
 		return values.NewInt(operand1.Value ^ operand2.Value), nil
@@ -734,30 +1033,123 @@ func calculateInteger(operand1 *values.Int, operator string, operand2 *values.In
 	case "&":
 		return values.NewInt(operand1.Value & operand2.Value), nil
 	case "+":
-		return values.NewInt(operand1.Value + operand2.Value), nil
+		result := operand1.Value + operand2.Value
+		// Overflow if both operands have the same sign and the result has a different sign
+		if (operand1.Value >= 0) == (operand2.Value >= 0) && (result >= 0) != (operand1.Value >= 0) {
+			return values.NewFloat(float64(operand1.Value) + float64(operand2.Value)), nil
+		}
+		return values.NewInt(result), nil
 	case "-":
-		return values.NewInt(operand1.Value - operand2.Value), nil
+		result := operand1.Value - operand2.Value
+		// Overflow if the operands have different signs and the result has not the sign of the first operand
+		if (operand1.Value >= 0) != (operand2.Value >= 0) && (result >= 0) != (operand1.Value >= 0) {
+			return values.NewFloat(float64(operand1.Value) - float64(operand2.Value)), nil
+		}
+		return values.NewInt(result), nil
 	case "*":
-		return values.NewInt(operand1.Value * operand2.Value), nil
+		if result, ok := multiplyInteger(operand1.Value, operand2.Value); ok {
+			return values.NewInt(result), nil
+		}
+		return values.NewFloat(float64(operand1.Value) * float64(operand2.Value)), nil
 	case "/":
 		if operand2.Value == 0 {
 			// TODO Add position in output: Fatal error: Uncaught DivisionByZeroError: Division by zero in /home/user/scripts/code.php:3
 			return values.NewInt(0), phpError.NewError("Uncaught DivisionByZeroError: Division by zero")
 		}
+		// Spec: https://www.php.net/manual/en/language.operators.arithmetic.php
+		// The division operator / returns a float value unless the two operands are int
+		// (or numeric strings which are type juggled to int) and the numerator is a multiple of the divisor, in which case an integer value will be returned.
+		if operand1.Value == math.MinInt64 && operand2.Value == -1 {
+			return values.NewFloat(-float64(math.MinInt64)), nil
+		}
+		if operand1.Value%operand2.Value != 0 {
+			return values.NewFloat(float64(operand1.Value) / float64(operand2.Value)), nil
+		}
 		return values.NewInt(operand1.Value / operand2.Value), nil
 	case "%":
+		if operand2.Value == 0 {
+			// TODO Add position in output
+			return values.NewInt(0), phpError.NewError("Uncaught DivisionByZeroError: Modulo by zero")
+		}
+		// Spec: https://www.php.net/manual/en/language.operators.arithmetic.php
+		// The result of the modulo operator % has the same sign as the dividend
 		return values.NewInt(operand1.Value % operand2.Value), nil
 	case "**":
-		return values.NewInt(int64(math.Pow(float64(operand1.Value), float64(operand2.Value)))), nil
+		// A negative exponent results in a float
+		if operand2.Value < 0 {
+			return values.NewFloat(math.Pow(float64(operand1.Value), float64(operand2.Value))), nil
+		}
+		if result, ok := powInteger(operand1.Value, operand2.Value); ok {
+			return values.NewInt(result), nil
+		}
+		return values.NewFloat(math.Pow(float64(operand1.Value), float64(operand2.Value))), nil
 	default:
 		return values.NewInt(0), phpError.NewError("calculateInteger: Operator \"%s\" not implemented", operator)
 	}
 }
 
+// Multiply two integers. Returns false if the result overflows.
+func multiplyInteger(a int64, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	result := a * b
+	if result/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, false
+	}
+	return result, true
+}
+
+// Calculate base ** exponent with exponentiation by squaring. Returns false if the result overflows.
+func powInteger(base int64, exponent int64) (int64, bool) {
+	var result int64 = 1
+	for exponent > 0 {
+		var ok bool
+		if exponent&1 == 1 {
+			if result, ok = multiplyInteger(result, base); !ok {
+				return 0, false
+			}
+		}
+		exponent >>= 1
+		if exponent > 0 {
+			if base, ok = multiplyInteger(base, base); !ok {
+				return 0, false
+			}
+		}
+	}
+	return result, true
+}
+
 func calculateString(operand1 *values.Str, operator string, operand2 *values.Str) (*values.Str, phpError.Error) {
 	switch operator {
 	case ".":
-		return values.NewStr(operand1.Value + operand2.Value), nil
+		return values.ConcatStr(operand1, operand2.Value), nil
+	case "&", "^":
+		// Spec: https://www.php.net/manual/en/language.operators.bitwise.php
+		// If both operands for the &, | and ^ operators are strings, then the operation will be performed on the ASCII values
+		// of the characters that make up the strings and the result will be a string.
+		// The result of & and ^ has the length of the shorter operand.
+		length := min(len(operand1.Value), len(operand2.Value))
+		result := make([]byte, length)
+		for i := range length {
+			if operator == "&" {
+				result[i] = operand1.Value[i] & operand2.Value[i]
+			} else {
+				result[i] = operand1.Value[i] ^ operand2.Value[i]
+			}
+		}
+		return values.NewStr(string(result)), nil
+	case "|":
+		// The result of | has the length of the longer operand. The shorter operand is padded with zero bytes.
+		longer, shorter := operand1.Value, operand2.Value
+		if len(shorter) > len(longer) {
+			longer, shorter = shorter, longer
+		}
+		result := []byte(longer)
+		for i := range len(shorter) {
+			result[i] |= shorter[i]
+		}
+		return values.NewStr(string(result)), nil
 	default:
 		return values.NewStr(""), phpError.NewError("calculateString: Operator \"%s\" not implemented", operator)
 	}
@@ -766,62 +1158,660 @@ func calculateString(operand1 *values.Str, operator string, operand2 *values.Str
 // -------------------------------------- class-object -------------------------------------- MARK: class-object
 
 func (interpreter *Interpreter) CallMethod(object *values.Object, method string, args []ast.IExpression, env *Environment) (values.RuntimeValue, phpError.Error) {
-	methodDefinition, found := object.GetMethod(method)
+	methodDefinition, class, found := interpreter.lookupMethod(object.Class, method)
 	if !found {
 		return values.NewNull(), phpError.NewError("Class %s does not have a function \"%s\"", object.Class.Name, method)
 	}
+	return interpreter.callMethod(class, methodDefinition, object, args, env)
+}
+
+// Create a new object with a unique object handle
+func (interpreter *Interpreter) NewObject(class *ast.ClassDeclarationStatement) *values.Object {
+	interpreter.lastObjectHandle++
+	return interpreter.gc.NewObject(class, interpreter.lastObjectHandle)
+}
+
+// Create a new resource with a unique resource id
+func (interpreter *Interpreter) NewResource(resourceType string, handle any) *values.Resource {
+	return interpreter.resources.NewResource(resourceType, handle)
+}
+
+func (interpreter *Interpreter) GetResourceRegistry() *values.ResourceRegistry {
+	return interpreter.resources
+}
+
+// Call the method of the given class. The object is nil for static method calls.
+func (interpreter *Interpreter) callMethod(
+	class *ast.ClassDeclarationStatement, methodDefinition *ast.MethodDefinitionStatement, object *values.Object,
+	args []ast.IExpression, env *Environment,
+) (values.RuntimeValue, phpError.Error) {
+	if _, found := interpreter.nativeMethods[methodDefinition]; !found && len(methodDefinition.Params) != len(args) {
+		return values.NewVoid(), phpError.NewError(
+			"Uncaught ArgumentCountError: %s::%s() expects exactly %d arguments, %d given",
+			class.Name, methodDefinition.Name, len(methodDefinition.Params), len(args),
+		)
+	}
+
+	runtimeArgs := make([]values.RuntimeValue, len(args))
+	argStmts := make([]ast.IStatement, len(args))
+	for index, arg := range args {
+		runtimeArgs[index] = must(interpreter.processStmt(arg, env))
+		argStmts[index] = arg
+	}
+	return interpreter.callMethodWithValues(class, methodDefinition, object, runtimeArgs, argStmts, env)
+}
+
+// Call the method of the given class with evaluated arguments.
+// The statements of the arguments are used for the positions in error messages.
+func (interpreter *Interpreter) callMethodWithValues(
+	class *ast.ClassDeclarationStatement, methodDefinition *ast.MethodDefinitionStatement, object *values.Object,
+	args []values.RuntimeValue, argStmts []ast.IStatement, env *Environment,
+) (values.RuntimeValue, phpError.Error) {
+	if nativeMethod, found := interpreter.nativeMethods[methodDefinition]; found {
+		runtimeArgs := make([]values.RuntimeValue, len(args))
+		for index, arg := range args {
+			runtimeArgs[index] = values.Copy(arg)
+		}
+		result, err := nativeMethod(object, runtimeArgs, env)
+		discardArguments(runtimeArgs, result)
+		return result, err
+	}
 
 	methodEnv, err := NewEnvironment(env, nil, interpreter)
 	if err != nil {
 		return values.NewVoid(), err
 	}
+	methodEnv.CurrentClass = class
 	methodEnv.CurrentObject = object
 	methodEnv.CurrentMethod = methodDefinition
-
-	if len(methodDefinition.Params) != len(args) {
-		return values.NewVoid(), phpError.NewError(
-			"Uncaught ArgumentCountError: %s::%s() expects exactly %d arguments, %d given",
-			object.Class.BaseClass, methodDefinition.Name, len(methodDefinition.Params), len(args),
-		)
-	}
+	methodEnv.useScope(methodDefinition.Scope)
 
 	for index, param := range methodDefinition.Params {
-		runtimeValue := must(interpreter.processStmt(args[index], env))
-
 		// Check if the parameter types match
-		err = checkParameterTypes(runtimeValue, param.Type)
-		if err != nil && err.GetMessage() == "Types do not match" {
-			givenType, err := variableHandling.GetType(runtimeValue)
-			if err != nil {
-				return values.NewVoid(), err
-			}
-			return values.NewVoid(), phpError.NewError(
-				"Uncaught TypeError: %s::%s(): Argument #%d (%s) must be of type %s, %s given",
-				object.Class.BaseClass, methodDefinition.Name, index+1, param.Name, strings.Join(param.Type, "|"), givenType,
-			)
+		runtimeValue, err := interpreter.checkArgumentType(
+			args[index], fmt.Sprintf("%s::%s", class.Name, methodDefinition.Name), index, param, methodEnv, argStmts[index],
+		)
+		if err != nil {
+			return values.NewVoid(), err
 		}
 		// Declare parameter in method environment
-		methodEnv.declareVariable(param.Name, values.DeepCopy(runtimeValue))
+		methodEnv.declareVariable(param.Name, runtimeValue)
+	}
+
+	// Spec: https://www.php.net/manual/en/language.oop5.basic.php#language.oop5.basic.class
+	// The pseudo-variable $this is available when a method is called from within an object context.
+	if object != nil {
+		methodEnv.declareVariable("$this", object)
 	}
 
-	runtimeValue, err := interpreter.processStmt(methodDefinition.Body, methodEnv)
+	runtimeValue, err := interpreter.processFunctionBody(methodDefinition, methodDefinition.Body, methodEnv)
 	if err != nil && !(err.GetErrorType() == phpError.EventError && err.GetMessage() == phpError.ReturnEvent) {
 		return runtimeValue, err
 	}
-	err = checkParameterTypes(runtimeValue, methodDefinition.ReturnType)
-	if err != nil && err.GetMessage() == "Types do not match" {
-		givenType, err := variableHandling.GetType(runtimeValue)
-		if runtimeValue.GetType() == values.VoidValue {
-			givenType = "void"
+	interpreter.releaseEnvironment(methodEnv, runtimeValue)
+	runtimeValue, err = interpreter.checkReturnType(
+		runtimeValue, methodDefinition.ReturnType, fmt.Sprintf("%s::%s", class.Name, methodDefinition.Name), methodEnv, methodDefinition,
+	)
+	if err != nil {
+		return runtimeValue, err
+	}
+
+	return runtimeValue, nil
+}
+
+// Find the method in the given class or in one of its parent classes.
+// Returns the method and the class that declares it.
+func (interpreter *Interpreter) lookupMethod(class *ast.ClassDeclarationStatement, method string) (*ast.MethodDefinitionStatement, *ast.ClassDeclarationStatement, bool) {
+	for class != nil {
+		// Spec: https://www.php.net/manual/en/functions.user-defined.php
+		// Function names are case-insensitive for the ASCII characters A to Z.
+		if methodDefinition, found := class.Methods[method]; found {
+			return methodDefinition, class, true
 		}
-		if err != nil {
-			return runtimeValue, err
+		for name, methodDefinition := range class.Methods {
+			if strings.EqualFold(name, method) {
+				return methodDefinition, class, true
+			}
+		}
+
+		if class.BaseClass == "" {
+			break
+		}
+		baseClass, found := interpreter.GetClass(class.BaseClass)
+		if !found {
+			break
+		}
+		class = baseClass
+	}
+	return nil, nil, false
+}
+
+// Find the property in the given class or in one of its parent classes.
+// Returns the property and the class that declares it.
+func (interpreter *Interpreter) lookupProperty(class *ast.ClassDeclarationStatement, property string) (*ast.PropertyDeclarationStatement, *ast.ClassDeclarationStatement, bool) {
+	for class != nil {
+		if propertyDeclaration, found := class.Properties[property]; found {
+			return propertyDeclaration, class, true
+		}
+
+		if class.BaseClass == "" {
+			break
+		}
+		baseClass, found := interpreter.GetClass(class.BaseClass)
+		if !found {
+			break
+		}
+		class = baseClass
+	}
+	return nil, nil, false
+}
+
+// Find the class constant in the given class or in one of its parent classes.
+// Returns the constant and the class that declares it.
+func (interpreter *Interpreter) lookupClassConstant(class *ast.ClassDeclarationStatement, constant string) (*ast.ClassConstDeclarationStatement, *ast.ClassDeclarationStatement, bool) {
+	for class != nil {
+		if constantDeclaration, found := class.Constants[constant]; found {
+			return constantDeclaration, class, true
+		}
+		class = interpreter.getBaseClass(class)
+	}
+	return nil, nil, false
+}
+
+// Returns the parent class or nil if the class has no (declared) parent class
+func (interpreter *Interpreter) getBaseClass(class *ast.ClassDeclarationStatement) *ast.ClassDeclarationStatement {
+	if class.BaseClass == "" {
+		return nil
+	}
+	baseClass, found := interpreter.GetClass(class.BaseClass)
+	if !found {
+		return nil
+	}
+	return baseClass
+}
+
+// Check if the class is the given class or one of its children
+func (interpreter *Interpreter) isInstanceOf(class *ast.ClassDeclarationStatement, parent *ast.ClassDeclarationStatement) bool {
+	for class != nil {
+		if class == parent {
+			return true
+		}
+		if class.BaseClass == "" {
+			return false
+		}
+		baseClass, found := interpreter.GetClass(class.BaseClass)
+		if !found {
+			return false
+		}
+		class = baseClass
+	}
+	return false
+}
+
+func (interpreter *Interpreter) checkMethodVisibility(
+	class *ast.ClassDeclarationStatement, methodDefinition *ast.MethodDefinitionStatement, env *Environment, stmt ast.IStatement,
+) phpError.Error {
+	visibility := getMethodVisibility(methodDefinition)
+	if interpreter.isMemberAccessible(class, visibility, env) {
+		return nil
+	}
+
+	return phpError.NewError(
+		"Uncaught Error: Call to %s method %s::%s() from %s in %s",
+		visibility, class.Name, methodDefinition.Name, getScopeName(env), stmt.GetPosString(),
+	)
+}
+
+func getMethodVisibility(methodDefinition *ast.MethodDefinitionStatement) string {
+	if len(methodDefinition.Modifiers) > 0 && common.IsVisibilitModifierKeyword(methodDefinition.Modifiers[0]) {
+		return methodDefinition.Modifiers[0]
+	}
+	return "public"
+}
+
+// Check if a member with the given visibility declared in the class can be accessed from the current scope
+func (interpreter *Interpreter) isMemberAccessible(class *ast.ClassDeclarationStatement, visibility string, env *Environment) bool {
+	// Spec: https://www.php.net/manual/en/language.oop5.visibility.php
+	// Class members declared public can be accessed everywhere.
+	// Members declared protected can be accessed only within the class itself and by inheriting and parent classes.
+	// Members declared as private may only be accessed by the class that defines the member.
+	if visibility == "public" {
+		return true
+	}
+
+	scope := env.CurrentClass
+	if visibility == "private" && scope == class {
+		return true
+	}
+	return visibility == "protected" && scope != nil && (interpreter.isInstanceOf(scope, class) || interpreter.isInstanceOf(class, scope))
+}
+
+func getScopeName(env *Environment) string {
+	if env.CurrentClass != nil {
+		return "scope " + env.CurrentClass.Name
+	}
+	return "global scope"
+}
+
+// Resolve the scope-resolution-qualifier of a scoped expression to a class
+func (interpreter *Interpreter) resolveScope(scope ast.IExpression, env *Environment) (*ast.ClassDeclarationStatement, phpError.Error) {
+	// Spec: https://phplang.org/spec/10-expressions.html#grammar-scope-resolution-qualifier
+
+	// relative-scope:
+	//    self
+	//    parent
+	//    static
+	if scope.GetKind() == ast.StringLiteralExpr {
+		switch strings.ToLower(scope.(*ast.StringLiteralExpression).Value) {
+		case "self":
+			if env.CurrentClass == nil {
+				return nil, phpError.NewError("Uncaught Error: Cannot use \"self\" when no class scope is active in %s", scope.GetPosString())
+			}
+			return env.CurrentClass, nil
+		case "parent":
+			if env.CurrentClass == nil {
+				return nil, phpError.NewError("Uncaught Error: Cannot use \"parent\" when current class scope has no parent in %s", scope.GetPosString())
+			}
+			if env.CurrentClass.BaseClass == "" {
+				return nil, phpError.NewError("Uncaught Error: Cannot use \"parent\" when current class scope has no parent in %s", scope.GetPosString())
+			}
+			class, found := interpreter.GetClass(env.CurrentClass.BaseClass)
+			if !found {
+				return nil, phpError.NewError("Uncaught Error: Class \"%s\" not found in %s", env.CurrentClass.BaseClass, scope.GetPosString())
+			}
+			return class, nil
+		case "static":
+			if env.CurrentObject != nil {
+				return env.CurrentObject.Class, nil
+			}
+			if env.CurrentClass == nil {
+				return nil, phpError.NewError("Uncaught Error: Cannot use \"static\" when no class scope is active in %s", scope.GetPosString())
+			}
+			return env.CurrentClass, nil
+		}
+	}
+
+	scopeValue, err := interpreter.processDereferencableExpr(scope, env)
+	if err != nil {
+		return nil, err
+	}
+
+	if scopeValue.GetType() == values.ObjectValue {
+		return scopeValue.(*values.Object).Class, nil
+	}
+
+	if scopeValue.GetType() == values.StrValue {
+		className := scopeValue.(*values.Str).Value
+		class, found := interpreter.GetClass(className)
+		if !found {
+			return nil, phpError.NewError("Uncaught Error: Class \"%s\" not found in %s", className, scope.GetPosString())
+		}
+		return class, nil
+	}
+
+	return nil, phpError.NewError("Uncaught Error: Cannot use value of type %s as class name in %s", values.ToPhpType(scopeValue), scope.GetPosString())
+}
+
+// Process the dereferencable expression of a member access, member call, subscript, ... expression
+func (interpreter *Interpreter) processDereferencableExpr(expr ast.IExpression, env *Environment) (values.RuntimeValue, phpError.Error) {
+	if expr.GetKind() == ast.SimpleVariableExpr {
+		return interpreter.lookupVariable(expr, env)
+	}
+	return interpreter.processChainStmt(expr, env)
+}
+
+// Convert a member name (name, simple-variable or { expression }) into a string
+func (interpreter *Interpreter) memberNameToString(member ast.IExpression, env *Environment) (string, phpError.Error) {
+	runtimeValue, err := interpreter.processStmt(member, env)
+	if err != nil {
+		return "", err
+	}
+	return interpreter.strVal(runtimeValue)
+}
+
+// -------------------------------------- type-declaration -------------------------------------- MARK: type-declaration
+
+// Check if the value matches the type declaration.
+// In coercive typing mode scalar values are converted to the declared type if possible.
+// Returns the (converted) value and false if the value does not match the type.
+func (interpreter *Interpreter) checkType(
+	value values.RuntimeValue, typeDecl *ast.TypeDeclaration, isStrict bool, env *Environment, stmt ast.IStatement,
+) (values.RuntimeValue, bool, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/language.types.declarations.php
+	if typeDecl == nil {
+		return value, true, nil
+	}
+
+	for _, memberType := range typeDecl.Alternatives() {
+		if interpreter.matchesType(value, memberType, env) {
+			return value, true, nil
 		}
-		return runtimeValue, phpError.NewError(
-			"Uncaught TypeError: %s::%s(): Return value must be of type %s, %s given",
-			object.Class.BaseClass, methodDefinition.Name, strings.Join(methodDefinition.ReturnType, "|"), givenType,
+	}
+
+	// Spec: https://www.php.net/manual/en/language.types.declarations.php#language.types.declarations.strict
+	// The only exception to this rule is that an int value will pass a float type declaration.
+	if value.GetType() == values.IntValue && typeDecl.Contains("float") {
+		return values.NewFloat(float64(value.(*values.Int).Value)), true, nil
+	}
+
+	if isStrict {
+		return value, false, nil
+	}
+
+	return interpreter.coerceType(value, typeDecl, env, stmt)
+}
+
+// Check the argument passed to the parameter at the given index of a function or method.
+// Returns the (converted) argument or a TypeError.
+func (interpreter *Interpreter) checkArgumentType(
+	value values.RuntimeValue, functionName string, index int, param ast.FunctionParameter, env *Environment, argument ast.IStatement,
+) (values.RuntimeValue, phpError.Error) {
+	value, isValid, err := interpreter.checkType(value, param.Type, isStrictType(argument), env, argument)
+	if err != nil {
+		return value, err
+	}
+	if !isValid {
+		return value, phpError.NewError(
+			"Uncaught TypeError: %s(): Argument #%d (%s) must be of type %s, %s given",
+			functionName, index+1, param.Name, param.Type, getTypeErrorName(value),
 		)
 	}
+	return value, nil
+}
 
-	return runtimeValue, nil
+// Check the return value of the function against the declared return type.
+// The strict typing mode of the file declaring the function is used.
+func (interpreter *Interpreter) checkReturnType(
+	value values.RuntimeValue, returnType *ast.TypeDeclaration, functionName string, env *Environment, function ast.IStatement,
+) (values.RuntimeValue, phpError.Error) {
+	if returnType == nil {
+		return value, nil
+	}
+
+	// Spec: https://www.php.net/manual/en/language.types.never.php
+	// never is a return-only type indicating the function does not terminate.
+	if returnType.Contains("never") {
+		return value, phpError.NewError("Uncaught TypeError: %s(): never-returning function must not implicitly return", functionName)
+	}
+
+	value, isValid, err := interpreter.checkType(value, returnType, isStrictType(function), env, function)
+	if err != nil {
+		return value, err
+	}
+	if !isValid {
+		if value.GetType() == values.VoidValue {
+			return value, phpError.NewError("Uncaught TypeError: %s(): Return value must be of type %s, none returned", functionName, returnType)
+		}
+		return value, phpError.NewError(
+			"Uncaught TypeError: %s(): Return value must be of type %s, %s returned", functionName, returnType, getTypeErrorName(value),
+		)
+	}
+	return value, nil
+}
+
+func (interpreter *Interpreter) matchesType(value values.RuntimeValue, typeDecl *ast.TypeDeclaration, env *Environment) bool {
+	if typeDecl.Kind == ast.IntersectionType {
+		for _, memberType := range typeDecl.Types {
+			if !interpreter.matchesType(value, memberType, env) {
+				return false
+			}
+		}
+		return true
+	}
+
+	switch typeDecl.Name {
+	case "mixed":
+		return value.GetType() != values.VoidValue
+	case "void":
+		return value.GetType() == values.VoidValue
+	case "never":
+		return false
+	case "null":
+		return value.GetType() == values.NullValue
+	case "array":
+		return value.GetType() == values.ArrayValue
+	case "bool":
+		return value.GetType() == values.BoolValue
+	case "false":
+		return value.GetType() == values.BoolValue && !value.(*values.Bool).Value
+	case "true":
+		return value.GetType() == values.BoolValue && value.(*values.Bool).Value
+	case "float":
+		return value.GetType() == values.FloatValue
+	case "int":
+		return value.GetType() == values.IntValue
+	case "string":
+		return value.GetType() == values.StrValue
+	case "object":
+		return value.GetType() == values.ObjectValue
+	case "iterable":
+		// Spec: https://www.php.net/manual/en/language.types.iterable.php
+		// Iterable is a built-in compile time type alias for array|Traversable.
+		return value.GetType() == values.ArrayValue ||
+			value.GetType() == values.ObjectValue && interpreter.IsSubclassOf(value.(*values.Object).Class, "Traversable")
+	case "callable":
+		return interpreter.isCallable(value, env)
+	}
+
+	// self, parent, static or class name
+	if value.GetType() != values.ObjectValue {
+		return false
+	}
+	className := typeDecl.Name
+	switch className {
+	case "self":
+		if env.CurrentClass == nil {
+			return false
+		}
+		className = env.CurrentClass.Name
+	case "parent":
+		if env.CurrentClass == nil {
+			return false
+		}
+		className = env.CurrentClass.BaseClass
+	case "static":
+		if env.CurrentObject != nil {
+			return interpreter.isInstanceOf(value.(*values.Object).Class, env.CurrentObject.Class)
+		}
+		if env.CurrentClass == nil {
+			return false
+		}
+		className = env.CurrentClass.Name
+	}
+	return interpreter.IsSubclassOf(value.(*values.Object).Class, className)
+}
+
+func (interpreter *Interpreter) coerceType(
+	value values.RuntimeValue, typeDecl *ast.TypeDeclaration, env *Environment, stmt ast.IStatement,
+) (values.RuntimeValue, bool, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/language.types.type-juggling.php#language.types.type-juggling.function
+	// If the type of the value is not part of the union, then the target type is chosen in the following order of preference:
+	// int, float, string, bool
+
+	// Spec: https://www.php.net/manual/en/language.types.string.php#language.types.string.casting
+	// Stringable objects are converted by calling the __toString() method.
+	if value.GetType() == values.ObjectValue && typeDecl.Contains("string") {
+		object := value.(*values.Object)
+		if _, _, found := interpreter.lookupMethod(object.Class, "__toString"); found {
+			str, err := interpreter.CallMethod(object, "__toString", []ast.IExpression{}, env)
+			return str, err == nil, err
+		}
+		return value, false, nil
+	}
+
+	if !variableHandling.IsScalar(value) {
+		return value, false, nil
+	}
+
+	if typeDecl.Contains("int") {
+		// A float with a fractional part is only converted to int (with a deprecation) if the union has no other
+		// scalar type to fall back to, e.g. 1.5 is passed as "1.5" to string|int and as true to int|bool
+		intOnly := !typeDecl.Contains("float") && !typeDecl.Contains("string") && !typeDecl.Contains("bool")
+
+		switch value.GetType() {
+		case values.BoolValue:
+			if value.(*values.Bool).Value {
+				return values.NewInt(1), true, nil
+			}
+			return values.NewInt(0), true, nil
+
+		case values.FloatValue:
+			floatValue := value.(*values.Float).Value
+			if intOnly || floatValue == math.Trunc(floatValue) {
+				if intValue, ok := interpreter.floatToIntType(floatValue, "float "+value.(*values.Float).ToPhpString(interpreter.ini.GetInt("serialize_precision")), stmt); ok {
+					return intValue, true, nil
+				}
+			}
+
+		case values.StrValue:
+			str := value.(*values.Str).Value
+			if common.IsIntegerNumericString(str) {
+				if intValue, err := common.NumericStringToInt64(str); err == nil {
+					return values.NewInt(intValue), true, nil
+				}
+			}
+			// For an int|float union type the numeric string is converted to float
+			if common.IsNumericString(str) && !typeDecl.Contains("float") {
+				floatValue, _ := common.NumericStringToFloat64(str)
+				if intOnly || floatValue == math.Trunc(floatValue) {
+					if intValue, ok := interpreter.floatToIntType(floatValue, fmt.Sprintf(`float-string "%s"`, str), stmt); ok {
+						return intValue, true, nil
+					}
+				}
+			}
+		}
+	}
+
+	if typeDecl.Contains("float") {
+		switch value.GetType() {
+		case values.BoolValue:
+			if value.(*values.Bool).Value {
+				return values.NewFloat(1), true, nil
+			}
+			return values.NewFloat(0), true, nil
+
+		case values.StrValue:
+			if common.IsNumericString(value.(*values.Str).Value) {
+				floatValue, _ := common.NumericStringToFloat64(value.(*values.Str).Value)
+				return values.NewFloat(floatValue), true, nil
+			}
+		}
+	}
+
+	if typeDecl.Contains("string") && value.GetType() != values.StrValue {
+		str, err := interpreter.strVal(value)
+		return values.NewStr(str), err == nil, err
+	}
+
+	if typeDecl.Contains("bool") {
+		boolean, err := variableHandling.BoolVal(value)
+		return values.NewBool(boolean), err == nil, err
+	}
+
+	return value, false, nil
+}
+
+// Convert the float to int if it is in the range of int.
+// A deprecation is raised if the float has a fractional part.
+func (interpreter *Interpreter) floatToIntType(value float64, valueStr string, stmt ast.IStatement) (values.RuntimeValue, bool) {
+	if math.IsNaN(value) || math.IsInf(value, 0) || value < math.MinInt64 || value >= math.MaxInt64 {
+		return values.NewVoid(), false
+	}
+	if value != math.Trunc(value) {
+		interpreter.PrintError(phpError.NewDeprecated("Implicit conversion from %s to int loses precision in %s", valueStr, stmt.GetPosString()))
+	}
+	return values.NewInt(int64(value)), true
+}
+
+// Check if the class is the given class, extends it or implements it
+func (interpreter *Interpreter) IsSubclassOf(class *ast.ClassDeclarationStatement, className string) bool {
+	// Spec: https://www.php.net/manual/en/language.oop5.basic.php
+	// Class names are case-insensitive.
+	for class != nil {
+		if strings.EqualFold(class.Name, className) {
+			return true
+		}
+		if slices.ContainsFunc(class.Interfaces, func(name string) bool { return strings.EqualFold(name, className) }) {
+			return true
+		}
+		if class.BaseClass == "" {
+			return false
+		}
+		baseClass, found := interpreter.GetClass(class.BaseClass)
+		if !found {
+			return false
+		}
+		class = baseClass
+	}
+	return false
+}
+
+func (interpreter *Interpreter) isCallable(value values.RuntimeValue, env *Environment) bool {
+	// Spec: https://www.php.net/manual/en/language.types.callable.php
+	switch value.GetType() {
+	case values.StrValue:
+		name := value.(*values.Str).Value
+		if className, methodName, found := strings.Cut(name, "::"); found {
+			class, found := interpreter.GetClass(className)
+			if !found {
+				return false
+			}
+			_, _, found = interpreter.lookupMethod(class, methodName)
+			return found
+		}
+		if _, err := env.lookupNativeFunction(name); err == nil {
+			return true
+		}
+		_, err := env.lookupUserFunction(name)
+		return err == nil
+
+	case values.ArrayValue:
+		array := value.(*values.Array)
+		if array.Count() != 2 {
+			return false
+		}
+		target, found := array.GetElement(values.NewInt(0))
+		if !found {
+			return false
+		}
+		method, found := array.GetElement(values.NewInt(1))
+		if !found || method.GetType() != values.StrValue {
+			return false
+		}
+		var class *ast.ClassDeclarationStatement
+		switch target.GetType() {
+		case values.ObjectValue:
+			class = target.(*values.Object).Class
+		case values.StrValue:
+			class, found = interpreter.GetClass(target.(*values.Str).Value)
+			if !found {
+				return false
+			}
+		default:
+			return false
+		}
+		_, _, found = interpreter.lookupMethod(class, method.(*values.Str).Value)
+		return found
+
+	case values.ObjectValue:
+		_, _, found := interpreter.lookupMethod(value.(*values.Object).Class, "__invoke")
+		return found
+
+	default:
+		return false
+	}
+}
+
+// Returns the type of the value as used in TypeError messages
+func getTypeErrorName(value values.RuntimeValue) string {
+	if value.GetType() == values.ObjectValue {
+		return value.(*values.Object).Class.Name
+	}
+	return strings.ToLower(values.ToPhpType(value))
+}
+
+// Spec: https://www.php.net/manual/en/language.types.declarations.php#language.types.declarations.strict
+// Strict typing applies to function calls made from within the file with strict typing enabled.
+func isStrictType(stmt ast.IStatement) bool {
+	return stmt.GetPosition().File != nil && stmt.GetPosition().File.IsStrictType
 }
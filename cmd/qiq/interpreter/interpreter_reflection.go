@@ -0,0 +1,754 @@
+package interpreter
+
+import (
+	"QIQ/cmd/qiq/ast"
+	"QIQ/cmd/qiq/phpError"
+	"QIQ/cmd/qiq/runtime/funcParamValidator"
+	"QIQ/cmd/qiq/runtime/stdlib/variableHandling"
+	"QIQ/cmd/qiq/runtime/values"
+	"cmp"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// -------------------------------------- Native classes -------------------------------------- MARK: Native classes
+
+// Implementation of a method of a built-in class. The arguments are already evaluated.
+type nativeMethod func(object *values.Object, args []values.RuntimeValue, env *Environment) (values.RuntimeValue, phpError.Error)
+
+func (interpreter *Interpreter) addNativeClass(class *ast.ClassDeclarationStatement, methods map[string]nativeMethod) {
+	for name, method := range methods {
+		methodDefinition := ast.NewMethodDefinitionStmt(0, nil, name, []string{"public"}, []ast.FunctionParameter{}, ast.NewCompoundStmt(0, []ast.IStatement{}), nil)
+		class.AddMethod(methodDefinition)
+		interpreter.nativeMethods[methodDefinition] = method
+	}
+	interpreter.classDeclarations[class.Name] = class
+}
+
+func (interpreter *Interpreter) newNativeObject(className string, internal any, env *Environment) *values.Object {
+	class, _ := interpreter.GetClass(className)
+	object := interpreter.NewObject(class)
+	object.Internal = internal
+	return object
+}
+
+// -------------------------------------- Attribute -------------------------------------- MARK: Attribute
+
+// Spec: https://www.php.net/manual/en/class.attribute.php
+const (
+	attributeTargetClass         int64 = 1
+	attributeTargetFunction      int64 = 2
+	attributeTargetMethod        int64 = 4
+	attributeTargetProperty      int64 = 8
+	attributeTargetClassConstant int64 = 16
+	attributeTargetParameter     int64 = 32
+	attributeTargetAll           int64 = 63
+	attributeIsRepeatable        int64 = 64
+)
+
+var attributeTargetNames = []struct {
+	target int64
+	name   string
+}{
+	{attributeTargetClass, "class"},
+	{attributeTargetFunction, "function"},
+	{attributeTargetMethod, "method"},
+	{attributeTargetProperty, "property"},
+	{attributeTargetClassConstant, "class constant"},
+	{attributeTargetParameter, "parameter"},
+}
+
+// Spec: https://www.php.net/manual/en/class.reflectionattribute.php
+const reflectionAttributeIsInstanceOf int64 = 2
+
+type reflectionAttribute struct {
+	attribute *ast.Attribute
+	// Class in whose scope the arguments are evaluated (nil for functions)
+	scope      *ast.ClassDeclarationStatement
+	target     int64
+	isRepeated bool
+}
+
+// Spec: https://www.php.net/manual/en/language.attributes.classes.php
+// The flags of the attribute class restrict the targets and whether the attribute can be repeated.
+// Returns false if the class is not declared as an attribute.
+func (interpreter *Interpreter) getAttributeFlags(class *ast.ClassDeclarationStatement, env *Environment) (int64, bool, phpError.Error) {
+	for _, attribute := range class.Attributes {
+		if !strings.EqualFold(attribute.Name, "Attribute") {
+			continue
+		}
+		if len(attribute.Arguments) == 0 {
+			return attributeTargetAll, true, nil
+		}
+		flagsEnv, err := interpreter.newAttributeEnv(class, env)
+		if err != nil {
+			return 0, true, err
+		}
+		flags, err := interpreter.processStmt(attribute.Arguments[0].Value, flagsEnv)
+		if err != nil {
+			return 0, true, err
+		}
+		flagsInt, err := variableHandling.IntVal(flags, false)
+		return flagsInt, true, err
+	}
+	return 0, false, nil
+}
+
+// Attribute arguments are evaluated in the scope of the declaring class
+func (interpreter *Interpreter) newAttributeEnv(scope *ast.ClassDeclarationStatement, env *Environment) (*Environment, phpError.Error) {
+	attributeEnv, err := NewEnvironment(env, nil, interpreter)
+	if err != nil {
+		return nil, err
+	}
+	attributeEnv.CurrentClass = scope
+	return attributeEnv, nil
+}
+
+// Map the positional and named arguments of the attribute to the parameters of the constructor
+func (interpreter *Interpreter) getAttributeConstructorArgs(class *ast.ClassDeclarationStatement, attribute *ast.Attribute) ([]ast.IExpression, phpError.Error) {
+	constructor, _, found := interpreter.lookupMethod(class, "__construct")
+	if !found {
+		if len(attribute.Arguments) > 0 {
+			return nil, phpError.NewError("Uncaught Error: Attribute class %s does not have a constructor, cannot pass arguments", class.Name)
+		}
+		return []ast.IExpression{}, nil
+	}
+
+	args := []ast.IExpression{}
+	for _, argument := range attribute.Arguments {
+		if argument.Name == "" {
+			args = append(args, argument.Value)
+			continue
+		}
+		// Built-in constructors only support positional arguments
+		if _, isNative := interpreter.nativeMethods[constructor]; isNative {
+			args = append(args, argument.Value)
+			continue
+		}
+
+		index := slices.IndexFunc(constructor.Params, func(param ast.FunctionParameter) bool { return param.Name == "$"+argument.Name })
+		if index == -1 {
+			return nil, phpError.NewError("Uncaught Error: Unknown named parameter $%s", argument.Name)
+		}
+		for len(args) <= index {
+			args = append(args, nil)
+		}
+		if args[index] != nil {
+			return nil, phpError.NewError("Uncaught Error: Named parameter $%s overwrites previous argument", argument.Name)
+		}
+		args[index] = argument.Value
+	}
+
+	for index, arg := range args {
+		if arg == nil {
+			return nil, phpError.NewError(
+				"Uncaught ArgumentCountError: %s::__construct(): Argument #%d (%s) not passed",
+				class.Name, index+1, constructor.Params[index].Name,
+			)
+		}
+	}
+	return args, nil
+}
+
+// Create the ReflectionAttribute objects for the given attributes (see ReflectionClass::getAttributes())
+func (interpreter *Interpreter) getReflectionAttributes(
+	functionName string, attributes []*ast.Attribute, scope *ast.ClassDeclarationStatement, target int64,
+	args []values.RuntimeValue, env *Environment,
+) (values.RuntimeValue, phpError.Error) {
+	args, err := funcParamValidator.NewValidator(functionName).
+		AddParam("$name", []string{"string"}, values.NewNull()).
+		AddParam("$flags", []string{"int"}, values.NewInt(0)).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	flags := args[1].(*values.Int).Value
+	if flags&^reflectionAttributeIsInstanceOf != 0 {
+		return values.NewVoid(), phpError.NewError("Uncaught Error: %s(): Argument #2 ($flags) must be a valid attribute filter flag", functionName)
+	}
+
+	name := ""
+	if args[0].GetType() == values.StrValue {
+		name = args[0].(*values.Str).Value
+		if flags&reflectionAttributeIsInstanceOf != 0 {
+			if _, found := interpreter.GetClass(name); !found {
+				return values.NewVoid(), phpError.NewError("Uncaught Error: Class \"%s\" not found", name)
+			}
+		}
+	}
+
+	result := values.NewArray()
+	for _, attribute := range attributes {
+		if name != "" {
+			if flags&reflectionAttributeIsInstanceOf != 0 {
+				class, found := interpreter.GetClass(attribute.Name)
+				if !found || !interpreter.IsSubclassOf(class, name) {
+					continue
+				}
+			} else if !strings.EqualFold(attribute.Name, name) {
+				continue
+			}
+		}
+
+		isRepeated := slices.ContainsFunc(attributes, func(other *ast.Attribute) bool {
+			return other != attribute && strings.EqualFold(other.Name, attribute.Name)
+		})
+		object := interpreter.newNativeObject("ReflectionAttribute", &reflectionAttribute{
+			attribute: attribute, scope: scope, target: target, isRepeated: isRepeated,
+		}, env)
+		if err := result.SetElement(nil, object); err != nil {
+			return values.NewVoid(), err
+		}
+	}
+	return result, nil
+}
+
+// -------------------------------------- Reflection -------------------------------------- MARK: Reflection
+
+// Spec: https://www.php.net/manual/en/book.reflection.php
+
+// Internal state of ReflectionMethod, ReflectionProperty and ReflectionClassConstant
+type reflectionMember struct {
+	class       *ast.ClassDeclarationStatement
+	declaration ast.IStatement
+}
+
+// Internal state of ReflectionParameter
+type reflectionParameter struct {
+	param    ast.FunctionParameter
+	position int
+	scope    *ast.ClassDeclarationStatement
+}
+
+func (interpreter *Interpreter) registerReflectionClasses() {
+	// Attribute
+	attributeClass := ast.NewClassDeclarationStmt(0, nil, "Attribute", false, true)
+	attributeClass.Attributes = []*ast.Attribute{ast.NewAttribute(nil, "Attribute", []*ast.AttributeArgument{
+		ast.NewAttributeArgument("", ast.NewIntegerLiteralExpr(0, nil, attributeTargetClass)),
+	})}
+	for name, value := range map[string]int64{
+		"TARGET_CLASS": attributeTargetClass, "TARGET_FUNCTION": attributeTargetFunction, "TARGET_METHOD": attributeTargetMethod,
+		"TARGET_PROPERTY": attributeTargetProperty, "TARGET_CLASS_CONSTANT": attributeTargetClassConstant,
+		"TARGET_PARAMETER": attributeTargetParameter, "TARGET_ALL": attributeTargetAll, "IS_REPEATABLE": attributeIsRepeatable,
+	} {
+		attributeClass.AddConst(ast.NewClassConstDeclarationStmt(0, nil, name, ast.NewIntegerLiteralExpr(0, nil, value), "public"))
+	}
+	attributeClass.AddProperty(ast.NewPropertyDeclarationStmt(0, nil, "$flags", "public", false, ast.NewNamedType("int"), nil))
+	interpreter.addNativeClass(attributeClass, map[string]nativeMethod{
+		"__construct": func(object *values.Object, args []values.RuntimeValue, _ *Environment) (values.RuntimeValue, phpError.Error) {
+			args, err := funcParamValidator.NewValidator("Attribute::__construct").
+				AddParam("$flags", []string{"int"}, values.NewInt(attributeTargetAll)).
+				Validate(args)
+			if err != nil {
+				return values.NewVoid(), err
+			}
+			object.SetProperty("$flags", args[0])
+			return values.NewNull(), nil
+		},
+	})
+
+	// ReflectionAttribute
+	reflectionAttributeClass := ast.NewClassDeclarationStmt(0, nil, "ReflectionAttribute", false, false)
+	reflectionAttributeClass.AddConst(ast.NewClassConstDeclarationStmt(0, nil, "IS_INSTANCEOF", ast.NewIntegerLiteralExpr(0, nil, reflectionAttributeIsInstanceOf), "public"))
+	interpreter.addNativeClass(reflectionAttributeClass, map[string]nativeMethod{
+		"getName":      interpreter.reflectionAttributeGetName,
+		"getArguments": interpreter.reflectionAttributeGetArguments,
+		"getTarget":    interpreter.reflectionAttributeGetTarget,
+		"isRepeated":   interpreter.reflectionAttributeIsRepeated,
+		"newInstance":  interpreter.reflectionAttributeNewInstance,
+	})
+
+	// ReflectionClass
+	interpreter.addNativeClass(ast.NewClassDeclarationStmt(0, nil, "ReflectionClass", false, false), map[string]nativeMethod{
+		"__construct":            interpreter.reflectionClassConstruct,
+		"getName":                reflectionGetName,
+		"getAttributes":          interpreter.reflectionClassGetAttributes,
+		"getMethod":              interpreter.reflectionClassGetMethod,
+		"getMethods":             interpreter.reflectionClassGetMethods,
+		"getProperty":            interpreter.reflectionClassGetProperty,
+		"getProperties":          interpreter.reflectionClassGetProperties,
+		"getReflectionConstant":  interpreter.reflectionClassGetReflectionConstant,
+		"getReflectionConstants": interpreter.reflectionClassGetReflectionConstants,
+	})
+
+	// ReflectionFunction
+	interpreter.addNativeClass(ast.NewClassDeclarationStmt(0, nil, "ReflectionFunction", false, false), map[string]nativeMethod{
+		"__construct":   interpreter.reflectionFunctionConstruct,
+		"getName":       reflectionGetName,
+		"getAttributes": interpreter.reflectionFunctionGetAttributes,
+		"getParameters": interpreter.reflectionFunctionGetParameters,
+	})
+
+	// ReflectionMethod
+	interpreter.addNativeClass(ast.NewClassDeclarationStmt(0, nil, "ReflectionMethod", false, false), map[string]nativeMethod{
+		"__construct":   interpreter.reflectionMethodConstruct,
+		"getName":       reflectionGetName,
+		"getAttributes": interpreter.reflectionMethodGetAttributes,
+		"getParameters": interpreter.reflectionMethodGetParameters,
+	})
+
+	// ReflectionParameter
+	interpreter.addNativeClass(ast.NewClassDeclarationStmt(0, nil, "ReflectionParameter", false, false), map[string]nativeMethod{
+		"getName":       reflectionGetName,
+		"getPosition":   reflectionParameterGetPosition,
+		"getAttributes": interpreter.reflectionParameterGetAttributes,
+	})
+
+	// ReflectionProperty
+	interpreter.addNativeClass(ast.NewClassDeclarationStmt(0, nil, "ReflectionProperty", false, false), map[string]nativeMethod{
+		"__construct":   interpreter.reflectionPropertyConstruct,
+		"getName":       reflectionGetName,
+		"getAttributes": interpreter.reflectionPropertyGetAttributes,
+	})
+
+	// ReflectionClassConstant
+	interpreter.addNativeClass(ast.NewClassDeclarationStmt(0, nil, "ReflectionClassConstant", false, false), map[string]nativeMethod{
+		"__construct":   interpreter.reflectionClassConstantConstruct,
+		"getName":       reflectionGetName,
+		"getAttributes": interpreter.reflectionClassConstantGetAttributes,
+	})
+}
+
+// All reflection classes store the name of the reflected declaration in the public property "$name"
+func reflectionGetName(object *values.Object, _ []values.RuntimeValue, _ *Environment) (values.RuntimeValue, phpError.Error) {
+	name, _ := object.GetProperty("$name")
+	return name, nil
+}
+
+func (interpreter *Interpreter) reflectionLookupClass(objectOrClass values.RuntimeValue) (*ast.ClassDeclarationStatement, phpError.Error) {
+	if objectOrClass.GetType() == values.ObjectValue {
+		return objectOrClass.(*values.Object).Class, nil
+	}
+	className := objectOrClass.(*values.Str).Value
+	class, found := interpreter.GetClass(className)
+	if !found {
+		return nil, phpError.NewError("Uncaught ReflectionException: Class \"%s\" does not exist", className)
+	}
+	return class, nil
+}
+
+func (interpreter *Interpreter) newReflectionMethod(class *ast.ClassDeclarationStatement, method *ast.MethodDefinitionStatement, env *Environment) *values.Object {
+	object := interpreter.newNativeObject("ReflectionMethod", &reflectionMember{class: class, declaration: method}, env)
+	object.SetProperty("$name", values.NewStr(method.Name))
+	object.SetProperty("$class", values.NewStr(class.Name))
+	return object
+}
+
+func (interpreter *Interpreter) newReflectionProperty(class *ast.ClassDeclarationStatement, property *ast.PropertyDeclarationStatement, env *Environment) *values.Object {
+	object := interpreter.newNativeObject("ReflectionProperty", &reflectionMember{class: class, declaration: property}, env)
+	object.SetProperty("$name", values.NewStr(strings.TrimPrefix(property.Name, "$")))
+	object.SetProperty("$class", values.NewStr(class.Name))
+	return object
+}
+
+func (interpreter *Interpreter) newReflectionClassConstant(class *ast.ClassDeclarationStatement, constant *ast.ClassConstDeclarationStatement, env *Environment) *values.Object {
+	object := interpreter.newNativeObject("ReflectionClassConstant", &reflectionMember{class: class, declaration: constant}, env)
+	object.SetProperty("$name", values.NewStr(constant.Name))
+	object.SetProperty("$class", values.NewStr(class.Name))
+	return object
+}
+
+func (interpreter *Interpreter) newReflectionParameters(params []ast.FunctionParameter, scope *ast.ClassDeclarationStatement, env *Environment) (values.RuntimeValue, phpError.Error) {
+	result := values.NewArray()
+	for index, param := range params {
+		object := interpreter.newNativeObject("ReflectionParameter", &reflectionParameter{param: param, position: index, scope: scope}, env)
+		object.SetProperty("$name", values.NewStr(strings.TrimPrefix(param.Name, "$")))
+		if err := result.SetElement(nil, object); err != nil {
+			return values.NewVoid(), err
+		}
+	}
+	return result, nil
+}
+
+// -------------------------------------- ReflectionAttribute -------------------------------------- MARK: ReflectionAttribute
+
+func (interpreter *Interpreter) reflectionAttributeGetName(object *values.Object, _ []values.RuntimeValue, _ *Environment) (values.RuntimeValue, phpError.Error) {
+	return values.NewStr(object.Internal.(*reflectionAttribute).attribute.Name), nil
+}
+
+func (interpreter *Interpreter) reflectionAttributeGetArguments(object *values.Object, args []values.RuntimeValue, env *Environment) (values.RuntimeValue, phpError.Error) {
+	reflection := object.Internal.(*reflectionAttribute)
+	attributeEnv, err := interpreter.newAttributeEnv(reflection.scope, env)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	result := values.NewArray()
+	for _, argument := range reflection.attribute.Arguments {
+		value, err := interpreter.processStmt(argument.Value, attributeEnv)
+		if err != nil {
+			return values.NewVoid(), err
+		}
+		var key values.RuntimeValue = nil
+		if argument.Name != "" {
+			key = values.NewStr(argument.Name)
+		}
+		if err := result.SetElement(key, value); err != nil {
+			return values.NewVoid(), err
+		}
+	}
+	return result, nil
+}
+
+func (interpreter *Interpreter) reflectionAttributeGetTarget(object *values.Object, _ []values.RuntimeValue, _ *Environment) (values.RuntimeValue, phpError.Error) {
+	return values.NewInt(object.Internal.(*reflectionAttribute).target), nil
+}
+
+func (interpreter *Interpreter) reflectionAttributeIsRepeated(object *values.Object, _ []values.RuntimeValue, _ *Environment) (values.RuntimeValue, phpError.Error) {
+	return values.NewBool(object.Internal.(*reflectionAttribute).isRepeated), nil
+}
+
+func (interpreter *Interpreter) reflectionAttributeNewInstance(object *values.Object, _ []values.RuntimeValue, env *Environment) (values.RuntimeValue, phpError.Error) {
+	reflection := object.Internal.(*reflectionAttribute)
+	attribute := reflection.attribute
+
+	class, found := interpreter.GetClass(attribute.Name)
+	if !found {
+		return values.NewVoid(), phpError.NewError("Uncaught Error: Attribute class \"%s\" not found", attribute.Name)
+	}
+
+	flags, isAttribute, err := interpreter.getAttributeFlags(class, env)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+	if !isAttribute {
+		return values.NewVoid(), phpError.NewError("Uncaught Error: Attempting to use non-attribute class \"%s\" as attribute", class.Name)
+	}
+	if flags&reflection.target == 0 {
+		targetName := ""
+		allowedTargets := []string{}
+		for _, target := range attributeTargetNames {
+			if target.target == reflection.target {
+				targetName = target.name
+			}
+			if flags&target.target != 0 {
+				allowedTargets = append(allowedTargets, target.name)
+			}
+		}
+		return values.NewVoid(), phpError.NewError(
+			"Uncaught Error: Attribute \"%s\" cannot target %s (allowed targets: %s)",
+			class.Name, targetName, strings.Join(allowedTargets, ", "),
+		)
+	}
+	if reflection.isRepeated && flags&attributeIsRepeatable == 0 {
+		return values.NewVoid(), phpError.NewError("Uncaught Error: Attribute \"%s\" must not be repeated", class.Name)
+	}
+
+	constructorArgs, err := interpreter.getAttributeConstructorArgs(class, attribute)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+	attributeEnv, err := interpreter.newAttributeEnv(reflection.scope, env)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	instance := interpreter.NewObject(class)
+	values.Retain(instance)
+	defer values.Release(instance)
+	if err := interpreter.initObject(instance, constructorArgs, attributeEnv); err != nil {
+		return values.NewVoid(), err
+	}
+	return instance, nil
+}
+
+// -------------------------------------- ReflectionClass -------------------------------------- MARK: ReflectionClass
+
+func (interpreter *Interpreter) reflectionClassConstruct(object *values.Object, args []values.RuntimeValue, _ *Environment) (values.RuntimeValue, phpError.Error) {
+	args, err := funcParamValidator.NewValidator("ReflectionClass::__construct").
+		AddParam("$objectOrClass", []string{"object", "string"}, nil).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	class, err := interpreter.reflectionLookupClass(args[0])
+	if err != nil {
+		return values.NewVoid(), err
+	}
+	object.Internal = class
+	object.SetProperty("$name", values.NewStr(class.Name))
+	return values.NewNull(), nil
+}
+
+func (interpreter *Interpreter) reflectionClassGetAttributes(object *values.Object, args []values.RuntimeValue, env *Environment) (values.RuntimeValue, phpError.Error) {
+	class := object.Internal.(*ast.ClassDeclarationStatement)
+	return interpreter.getReflectionAttributes("ReflectionClass::getAttributes", class.Attributes, class, attributeTargetClass, args, env)
+}
+
+func (interpreter *Interpreter) reflectionClassGetMethod(object *values.Object, args []values.RuntimeValue, env *Environment) (values.RuntimeValue, phpError.Error) {
+	args, err := funcParamValidator.NewValidator("ReflectionClass::getMethod").
+		AddParam("$name", []string{"string"}, nil).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	class := object.Internal.(*ast.ClassDeclarationStatement)
+	name := args[0].(*values.Str).Value
+	method, declaringClass, found := interpreter.lookupMethod(class, name)
+	if !found {
+		return values.NewVoid(), phpError.NewError("Uncaught ReflectionException: Method %s::%s() does not exist", class.Name, name)
+	}
+	return interpreter.newReflectionMethod(declaringClass, method, env), nil
+}
+
+func (interpreter *Interpreter) reflectionClassGetMethods(object *values.Object, _ []values.RuntimeValue, env *Environment) (values.RuntimeValue, phpError.Error) {
+	result := values.NewArray()
+	names := []string{}
+	class := object.Internal.(*ast.ClassDeclarationStatement)
+	for class != nil {
+		// Methods are returned in the order of declaration
+		methods := slices.SortedFunc(maps.Values(class.Methods), func(a, b *ast.MethodDefinitionStatement) int {
+			return cmp.Or(cmp.Compare(a.GetId(), b.GetId()), cmp.Compare(a.Name, b.Name))
+		})
+		for _, method := range methods {
+			if slices.Contains(names, strings.ToLower(method.Name)) {
+				continue
+			}
+			names = append(names, strings.ToLower(method.Name))
+			if err := result.SetElement(nil, interpreter.newReflectionMethod(class, method, env)); err != nil {
+				return values.NewVoid(), err
+			}
+		}
+		class = interpreter.getBaseClass(class)
+	}
+	return result, nil
+}
+
+func (interpreter *Interpreter) reflectionClassGetProperty(object *values.Object, args []values.RuntimeValue, env *Environment) (values.RuntimeValue, phpError.Error) {
+	args, err := funcParamValidator.NewValidator("ReflectionClass::getProperty").
+		AddParam("$name", []string{"string"}, nil).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	class := object.Internal.(*ast.ClassDeclarationStatement)
+	name := args[0].(*values.Str).Value
+	property, declaringClass, found := interpreter.lookupProperty(class, "$"+name)
+	if !found {
+		return values.NewVoid(), phpError.NewError("Uncaught ReflectionException: Property %s::$%s does not exist", class.Name, name)
+	}
+	return interpreter.newReflectionProperty(declaringClass, property, env), nil
+}
+
+func (interpreter *Interpreter) reflectionClassGetProperties(object *values.Object, _ []values.RuntimeValue, env *Environment) (values.RuntimeValue, phpError.Error) {
+	result := values.NewArray()
+	names := []string{}
+	class := object.Internal.(*ast.ClassDeclarationStatement)
+	for class != nil {
+		for _, name := range class.PropertieNames {
+			if slices.Contains(names, name) {
+				continue
+			}
+			names = append(names, name)
+			if err := result.SetElement(nil, interpreter.newReflectionProperty(class, class.Properties[name], env)); err != nil {
+				return values.NewVoid(), err
+			}
+		}
+		class = interpreter.getBaseClass(class)
+	}
+	return result, nil
+}
+
+func (interpreter *Interpreter) reflectionClassGetReflectionConstant(object *values.Object, args []values.RuntimeValue, env *Environment) (values.RuntimeValue, phpError.Error) {
+	args, err := funcParamValidator.NewValidator("ReflectionClass::getReflectionConstant").
+		AddParam("$name", []string{"string"}, nil).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	constant, declaringClass, found := interpreter.lookupClassConstant(object.Internal.(*ast.ClassDeclarationStatement), args[0].(*values.Str).Value)
+	if !found {
+		return values.NewBool(false), nil
+	}
+	return interpreter.newReflectionClassConstant(declaringClass, constant, env), nil
+}
+
+func (interpreter *Interpreter) reflectionClassGetReflectionConstants(object *values.Object, _ []values.RuntimeValue, env *Environment) (values.RuntimeValue, phpError.Error) {
+	result := values.NewArray()
+	names := []string{}
+	class := object.Internal.(*ast.ClassDeclarationStatement)
+	for class != nil {
+		// Constants are returned in the order of declaration
+		constants := slices.SortedFunc(maps.Values(class.Constants), func(a, b *ast.ClassConstDeclarationStatement) int {
+			return cmp.Or(cmp.Compare(a.GetId(), b.GetId()), cmp.Compare(a.Name, b.Name))
+		})
+		for _, constant := range constants {
+			if slices.Contains(names, constant.Name) {
+				continue
+			}
+			names = append(names, constant.Name)
+			if err := result.SetElement(nil, interpreter.newReflectionClassConstant(class, constant, env)); err != nil {
+				return values.NewVoid(), err
+			}
+		}
+		class = interpreter.getBaseClass(class)
+	}
+	return result, nil
+}
+
+// -------------------------------------- ReflectionFunction -------------------------------------- MARK: ReflectionFunction
+
+func (interpreter *Interpreter) reflectionFunctionConstruct(object *values.Object, args []values.RuntimeValue, env *Environment) (values.RuntimeValue, phpError.Error) {
+	args, err := funcParamValidator.NewValidator("ReflectionFunction::__construct").
+		AddParam("$function", []string{"string"}, nil).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	functionName := args[0].(*values.Str).Value
+	function, err := env.lookupUserFunction(functionName)
+	if err != nil {
+		if !env.FunctionExists(functionName) {
+			return values.NewVoid(), phpError.NewError("Uncaught ReflectionException: Function %s() does not exist", functionName)
+		}
+		// Native functions have neither attributes nor declared parameters
+		function = ast.NewFunctionDefinitionStmt(0, nil, functionName, []ast.FunctionParameter{}, nil, nil)
+	}
+	object.Internal = function
+	object.SetProperty("$name", values.NewStr(function.FunctionName))
+	return values.NewNull(), nil
+}
+
+func (interpreter *Interpreter) reflectionFunctionGetAttributes(object *values.Object, args []values.RuntimeValue, env *Environment) (values.RuntimeValue, phpError.Error) {
+	function := object.Internal.(*ast.FunctionDefinitionStatement)
+	return interpreter.getReflectionAttributes("ReflectionFunctionAbstract::getAttributes", function.Attributes, nil, attributeTargetFunction, args, env)
+}
+
+func (interpreter *Interpreter) reflectionFunctionGetParameters(object *values.Object, _ []values.RuntimeValue, env *Environment) (values.RuntimeValue, phpError.Error) {
+	return interpreter.newReflectionParameters(object.Internal.(*ast.FunctionDefinitionStatement).Params, nil, env)
+}
+
+// -------------------------------------- ReflectionMethod -------------------------------------- MARK: ReflectionMethod
+
+func (interpreter *Interpreter) reflectionMethodConstruct(object *values.Object, args []values.RuntimeValue, _ *Environment) (values.RuntimeValue, phpError.Error) {
+	args, err := funcParamValidator.NewValidator("ReflectionMethod::__construct").
+		AddParam("$objectOrMethod", []string{"object", "string"}, nil).
+		AddParam("$method", []string{"string"}, values.NewNull()).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Spec: https://www.php.net/manual/en/reflectionmethod.construct.php
+	// The method can be given as one string in the format "Class::method".
+	objectOrClass := args[0]
+	var methodName string
+	if args[1].GetType() == values.NullValue {
+		if objectOrClass.GetType() != values.StrValue || !strings.Contains(objectOrClass.(*values.Str).Value, "::") {
+			return values.NewVoid(), phpError.NewError(
+				"Uncaught ReflectionException: ReflectionMethod::__construct(): Argument #1 ($objectOrMethod) must be a valid method name",
+			)
+		}
+		className, name, _ := strings.Cut(objectOrClass.(*values.Str).Value, "::")
+		objectOrClass = values.NewStr(className)
+		methodName = name
+	} else {
+		methodName = args[1].(*values.Str).Value
+	}
+
+	class, err := interpreter.reflectionLookupClass(objectOrClass)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+	method, declaringClass, found := interpreter.lookupMethod(class, methodName)
+	if !found {
+		return values.NewVoid(), phpError.NewError("Uncaught ReflectionException: Method %s::%s() does not exist", class.Name, methodName)
+	}
+	object.Internal = &reflectionMember{class: declaringClass, declaration: method}
+	object.SetProperty("$name", values.NewStr(method.Name))
+	object.SetProperty("$class", values.NewStr(declaringClass.Name))
+	return values.NewNull(), nil
+}
+
+func (interpreter *Interpreter) reflectionMethodGetAttributes(object *values.Object, args []values.RuntimeValue, env *Environment) (values.RuntimeValue, phpError.Error) {
+	member := object.Internal.(*reflectionMember)
+	method := member.declaration.(*ast.MethodDefinitionStatement)
+	return interpreter.getReflectionAttributes("ReflectionFunctionAbstract::getAttributes", method.Attributes, member.class, attributeTargetMethod, args, env)
+}
+
+func (interpreter *Interpreter) reflectionMethodGetParameters(object *values.Object, _ []values.RuntimeValue, env *Environment) (values.RuntimeValue, phpError.Error) {
+	member := object.Internal.(*reflectionMember)
+	return interpreter.newReflectionParameters(member.declaration.(*ast.MethodDefinitionStatement).Params, member.class, env)
+}
+
+// -------------------------------------- ReflectionParameter -------------------------------------- MARK: ReflectionParameter
+
+func reflectionParameterGetPosition(object *values.Object, _ []values.RuntimeValue, _ *Environment) (values.RuntimeValue, phpError.Error) {
+	return values.NewInt(int64(object.Internal.(*reflectionParameter).position)), nil
+}
+
+func (interpreter *Interpreter) reflectionParameterGetAttributes(object *values.Object, args []values.RuntimeValue, env *Environment) (values.RuntimeValue, phpError.Error) {
+	parameter := object.Internal.(*reflectionParameter)
+	return interpreter.getReflectionAttributes("ReflectionParameter::getAttributes", parameter.param.Attributes, parameter.scope, attributeTargetParameter, args, env)
+}
+
+// -------------------------------------- ReflectionProperty -------------------------------------- MARK: ReflectionProperty
+
+func (interpreter *Interpreter) reflectionPropertyConstruct(object *values.Object, args []values.RuntimeValue, _ *Environment) (values.RuntimeValue, phpError.Error) {
+	args, err := funcParamValidator.NewValidator("ReflectionProperty::__construct").
+		AddParam("$class", []string{"object", "string"}, nil).
+		AddParam("$property", []string{"string"}, nil).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	class, err := interpreter.reflectionLookupClass(args[0])
+	if err != nil {
+		return values.NewVoid(), err
+	}
+	name := args[1].(*values.Str).Value
+	property, declaringClass, found := interpreter.lookupProperty(class, "$"+name)
+	if !found {
+		return values.NewVoid(), phpError.NewError("Uncaught ReflectionException: Property %s::$%s does not exist", class.Name, name)
+	}
+	object.Internal = &reflectionMember{class: declaringClass, declaration: property}
+	object.SetProperty("$name", values.NewStr(name))
+	object.SetProperty("$class", values.NewStr(declaringClass.Name))
+	return values.NewNull(), nil
+}
+
+func (interpreter *Interpreter) reflectionPropertyGetAttributes(object *values.Object, args []values.RuntimeValue, env *Environment) (values.RuntimeValue, phpError.Error) {
+	member := object.Internal.(*reflectionMember)
+	property := member.declaration.(*ast.PropertyDeclarationStatement)
+	return interpreter.getReflectionAttributes("ReflectionProperty::getAttributes", property.Attributes, member.class, attributeTargetProperty, args, env)
+}
+
+// -------------------------------------- ReflectionClassConstant -------------------------------------- MARK: ReflectionClassConstant
+
+func (interpreter *Interpreter) reflectionClassConstantConstruct(object *values.Object, args []values.RuntimeValue, _ *Environment) (values.RuntimeValue, phpError.Error) {
+	args, err := funcParamValidator.NewValidator("ReflectionClassConstant::__construct").
+		AddParam("$class", []string{"object", "string"}, nil).
+		AddParam("$constant", []string{"string"}, nil).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	class, err := interpreter.reflectionLookupClass(args[0])
+	if err != nil {
+		return values.NewVoid(), err
+	}
+	name := args[1].(*values.Str).Value
+	constant, declaringClass, found := interpreter.lookupClassConstant(class, name)
+	if !found {
+		return values.NewVoid(), phpError.NewError("Uncaught ReflectionException: Constant %s::%s does not exist", class.Name, name)
+	}
+	object.Internal = &reflectionMember{class: declaringClass, declaration: constant}
+	object.SetProperty("$name", values.NewStr(name))
+	object.SetProperty("$class", values.NewStr(declaringClass.Name))
+	return values.NewNull(), nil
+}
+
+func (interpreter *Interpreter) reflectionClassConstantGetAttributes(object *values.Object, args []values.RuntimeValue, env *Environment) (values.RuntimeValue, phpError.Error) {
+	member := object.Internal.(*reflectionMember)
+	constant := member.declaration.(*ast.ClassConstDeclarationStatement)
+	return interpreter.getReflectionAttributes("ReflectionClassConstant::getAttributes", constant.Attributes, member.class, attributeTargetClassConstant, args, env)
+}
@@ -37,7 +37,7 @@ func (interpreter *Interpreter) ProcessEchoStmt(stmt *ast.EchoStatement, env any
 	for _, expr := range stmt.Expressions {
 		runtimeValue := must(interpreter.processStmt(expr, env))
 
-		str := mustOrVoid(variableHandling.StrVal(runtimeValue))
+		str := mustOrVoid(interpreter.strVal(runtimeValue))
 		interpreter.Print(str)
 	}
 	return values.NewVoid(), nil
@@ -139,27 +139,29 @@ func (interpreter *Interpreter) ProcessForStmt(stmt *ast.ForStatement, env any)
 			return nil
 		}
 
+		// Spec: https://phplang.org/spec/11-statements.html#grammar-for-statement
+		// Once the right-most expression in for-control is FALSE, control transfers to the point immediately following the end of the for statement.
+		if !condition {
+			break
+		}
+
 		// Spec: https://phplang.org/spec/11-statements.html#grammar-for-statement
 		// If the result is TRUE, statement is executed, ...
-		if condition {
-			_, err := interpreter.processStmt(stmt.Block, env)
-			if err != nil {
-				if err.GetErrorType() == phpError.EventError && err.GetMessage() == "break" {
-					breakoutLevel := err.(*phpError.ContinueEventError).GetBreakoutLevel()
-					if breakoutLevel == 1 {
-						break
-					}
-					return values.NewVoid(), phpError.NewBreakEvent(breakoutLevel - 1)
+		_, err := interpreter.processStmt(stmt.Block, env)
+		if err != nil {
+			if err.GetErrorType() == phpError.EventError && err.GetMessage() == "break" {
+				breakoutLevel := err.(*phpError.ContinueEventError).GetBreakoutLevel()
+				if breakoutLevel == 1 {
+					break
 				}
-				if err.GetErrorType() == phpError.EventError && err.GetMessage() == "continue" {
-					breakoutLevel := err.(*phpError.ContinueEventError).GetBreakoutLevel()
-					if breakoutLevel == 1 {
-						// Execute end-of-loop logic
-						mustOrVoid(0, executeEndOfLoop())
-						continue
-					}
+				return values.NewVoid(), phpError.NewBreakEvent(breakoutLevel - 1)
+			}
+			if err.GetErrorType() == phpError.EventError && err.GetMessage() == "continue" {
+				breakoutLevel := err.(*phpError.ContinueEventError).GetBreakoutLevel()
+				if breakoutLevel != 1 {
 					return values.NewVoid(), phpError.NewContinueEvent(breakoutLevel - 1)
 				}
+			} else {
 				return values.NewVoid(), err
 			}
 		}
@@ -167,12 +169,6 @@ func (interpreter *Interpreter) ProcessForStmt(stmt *ast.ForStatement, env any)
 		// Spec: https://phplang.org/spec/11-statements.html#grammar-for-statement
 		// ... and the group of expressions in for-end-of-loop is evaluated left-to-right, for their side effects only.
 		mustOrVoid(0, executeEndOfLoop())
-
-		// Spec: https://phplang.org/spec/11-statements.html#grammar-for-statement
-		// Once the right-most expression in for-control is FALSE, control transfers to the point immediately following the end of the for statement.
-		if !condition {
-			break
-		}
 	}
 
 	return values.NewVoid(), nil
@@ -254,14 +250,16 @@ func (interpreter *Interpreter) ProcessDoStmt(stmt *ast.DoStatement, env any) (a
 			}
 			if err.GetErrorType() == phpError.EventError && err.GetMessage() == "continue" {
 				breakoutLevel := err.(*phpError.ContinueEventError).GetBreakoutLevel()
-				if breakoutLevel == 1 {
-					continue
+				if breakoutLevel != 1 {
+					return values.NewVoid(), phpError.NewContinueEvent(breakoutLevel - 1)
 				}
-				return values.NewVoid(), phpError.NewContinueEvent(breakoutLevel - 1)
+			} else {
+				return runtimeValue, err
 			}
-			return runtimeValue, err
 		}
 
+		// Spec: https://www.php.net/manual/en/control-structures.continue.php
+		// continue skips the rest of the current loop iteration and continues execution at the condition evaluation.
 		conditionRuntimeValue := must(interpreter.processStmt(stmt.Condition, env))
 		condition = mustOrVoid(variableHandling.BoolVal(conditionRuntimeValue))
 		if !condition {
@@ -291,11 +289,7 @@ func (interpreter *Interpreter) ProcessThrowStmt(stmt *ast.ThrowStatement, env a
 // ProcessDeclareStmt implements Visitor.
 func (interpreter *Interpreter) ProcessDeclareStmt(stmt *ast.DeclareStatement, env any) (any, error) {
 	if stmt.Directive == "strict_types" {
-		if stmt.Literal.(*ast.IntegerLiteralExpression).Value == 1 {
-			stmt.GetPosition().File.IsStrictType = true
-		} else {
-			stmt.GetPosition().File.IsStrictType = false
-		}
+		// The strict mode of the file is set by the parser
 		return values.NewVoid(), nil
 	}
 
@@ -313,14 +307,17 @@ func (interpreter *Interpreter) ProcessForeachStmt(stmt *ast.ForeachStatement, e
 
 	// Array
 	if runtimeValue.GetType() == values.ArrayValue {
-		runtimeArray := runtimeValue.(*values.Array)
-		for _, keyValue := range runtimeArray.Keys {
+		// Iterate over a copy so that modifications in the body do not affect the iteration
+		runtimeArray := runtimeValue.(*values.Array).Copy()
+		// The loop holds the iterated array, so that its objects are not freed during the iteration
+		values.Retain(runtimeArray)
+		defer values.Release(runtimeArray)
+		for keyValue, value := range runtimeArray.Iterate() {
 			// Set key and value variable
 			if stmt.Key != nil {
 				keyName := mustOrVoid(interpreter.varExprToVarName(stmt.Key, environment))
 				environment.declareVariable(keyName, keyValue)
 			}
-			value, _ := runtimeArray.GetElement(keyValue)
 			valueName := mustOrVoid(interpreter.varExprToVarName(stmt.Value, environment))
 			environment.declareVariable(valueName, value)
 
@@ -350,9 +347,16 @@ func (interpreter *Interpreter) ProcessForeachStmt(stmt *ast.ForeachStatement, e
 	// Object
 	if runtimeValue.GetType() == values.ObjectValue {
 		runtimeObject := runtimeValue.(*values.Object)
+		values.Retain(runtimeObject)
+		defer values.Release(runtimeObject)
 
 		for _, propertyName := range runtimeObject.PropertyNames {
-			if runtimeObject.Class.Properties[propertyName].Visibility != "public" {
+			// Dynamic properties are always public
+			if property, found := runtimeObject.Class.Properties[propertyName]; found && property.Visibility != "public" {
+				continue
+			}
+			// Uninitialized typed properties are skipped
+			if _, found := runtimeObject.Properties[propertyName]; !found {
 				continue
 			}
 
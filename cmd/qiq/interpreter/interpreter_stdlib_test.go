@@ -1,7 +1,11 @@
 package interpreter
 
 import (
+	"QIQ/cmd/qiq/common/os"
 	"QIQ/cmd/qiq/phpError"
+	"fmt"
+	GoOs "os"
+	"path/filepath"
 	"testing"
 )
 
@@ -12,6 +16,7 @@ func TestLibMath(t *testing.T) {
 	testInputOutput(t, `<?php var_dump(abs(-4.2));`, "float(4.2)\n")
 	testInputOutput(t, `<?php var_dump(abs(5));`, "int(5)\n")
 	testInputOutput(t, `<?php var_dump(abs(-5));`, "int(5)\n")
+	testInputOutput(t, `<?php var_dump(is_float(abs(PHP_INT_MIN)), abs(-PHP_INT_MAX));`, "bool(true)\nint(9223372036854775807)\n")
 
 	// acos
 	testInputOutput(t, `<?php var_dump(acos(1.0));`, "float(0)\n")
@@ -26,10 +31,345 @@ func TestLibMath(t *testing.T) {
 	// asinh
 	testInputOutput(t, `<?php var_dump(asinh(0.0));`, "float(0)\n")
 
+	// intdiv
+	testInputOutput(t, `<?php var_dump(intdiv(7, 2), intdiv(-7, 2), intdiv(PHP_INT_MIN, 1));`, "int(3)\nint(-3)\nint(-9223372036854775808)\n")
+	testForError(t, `<?php intdiv(1, 0);`, phpError.NewError("Uncaught DivisionByZeroError: Division by zero"))
+	testForError(t, `<?php intdiv(PHP_INT_MIN, -1);`, phpError.NewError("Uncaught ArithmeticError: Division of PHP_INT_MIN by -1 is not an integer"))
+
 	// pi
 	testInputOutput(t, `<?php var_dump(M_PI === pi());`, "bool(true)\n")
 }
 
+// -------------------------------------- array -------------------------------------- MARK: array
+
+func TestLibArray(t *testing.T) {
+	// array_all, array_any, array_find, array_find_key
+	testInputOutput(t,
+		`<?php function isEven($v) { return $v % 2 == 0; } $a = ["a" => 1, "b" => 2, "c" => 4];
+		var_dump(array_all($a, "isEven"), array_any($a, "isEven"), array_find($a, "isEven"), array_find_key($a, "isEven"), array_find([1], "isEven"));`,
+		"bool(false)\nbool(true)\nint(2)\nstring(1) \"b\"\nNULL\n",
+	)
+	testForError(t, `<?php array_all([], "unknownFn");`,
+		phpError.NewError(`Uncaught TypeError: array_all(): Argument #2 ($callback) must be a valid callback, function "unknownFn" not found or invalid function name`),
+	)
+
+	// array_change_key_case, array_chunk, array_column, array_combine, array_count_values
+	testInputOutput(t, `<?php echo json_encode(array_change_key_case(["Ab" => 1, 2, "cD" => 3], CASE_UPPER));`, `{"AB":1,"0":2,"CD":3}`)
+	testInputOutput(t, `<?php echo json_encode(array_chunk([1, 2, 3], 2)), json_encode(array_chunk(["a" => 1, "b" => 2], 1, true));`, `[[1,2],[3]][{"a":1},{"b":2}]`)
+	testForError(t, `<?php array_chunk([1], 0);`, phpError.NewError("Uncaught ValueError: array_chunk(): Argument #2 ($length) must be greater than 0"))
+	testInputOutput(t,
+		`<?php $rows = [["id" => 3, "name" => "a"], ["id" => 5, "name" => "b"], ["id" => 7]];
+		echo json_encode(array_column($rows, "name")), json_encode(array_column($rows, "name", "id")), json_encode(array_column($rows, null, "id")[7]);`,
+		`["a","b"]{"3":"a","5":"b"}{"id":7}`,
+	)
+	testInputOutput(t, `<?php echo json_encode(array_combine(["a", "b"], [1, 2]));`, `{"a":1,"b":2}`)
+	testForError(t, `<?php array_combine(["a"], []);`,
+		phpError.NewError("Uncaught ValueError: array_combine(): Argument #1 ($keys) and argument #2 ($values) must have the same number of elements"),
+	)
+	testInputOutput(t,
+		`<?php echo json_encode(array_count_values([1, "a", 1, "1", 1.5]));`,
+		fmt.Sprintf("\nWarning: array_count_values(): Can only count string and integer values, entry skipped in %s:1:24\n{\"1\":3,\"a\":1}", TEST_FILE_NAME),
+	)
+
+	// array_diff, array_intersect and their variants
+	testInputOutput(t, `<?php echo json_encode(array_diff([1, "2", 3, "a", 4], ["1", 3], [4]));`, `{"1":"2","3":"a"}`)
+	testInputOutput(t,
+		`<?php echo json_encode(array_diff_assoc(["a" => "green", "b" => "brown", "c" => "blue", "red"], ["a" => "green", "yellow", "red"]));`,
+		`{"b":"brown","c":"blue","0":"red"}`,
+	)
+	testInputOutput(t, `<?php echo json_encode(array_diff_key(["a" => 1, "b" => 2, 0 => 3], ["a" => 9, "0" => 9]));`, `{"b":2}`)
+	testInputOutput(t, `<?php echo json_encode(array_intersect([1, "2", 3, "a"], ["1", 3, "a"], [3, 1]));`, `{"0":1,"2":3}`)
+	testInputOutput(t, `<?php echo json_encode(array_intersect_assoc(["a" => "g", "b" => "r", "c" => "b"], ["a" => "g", "b" => "x"]));`, `{"a":"g"}`)
+	testInputOutput(t, `<?php echo json_encode(array_intersect_key(["a" => 1, "b" => 2, "c" => 3], ["a" => 0, "c" => 0]));`, `{"a":1,"c":3}`)
+	testInputOutput(t,
+		`<?php function cmp($a, $b) { return strtolower($a) <=> strtolower($b); }
+		echo json_encode(array_udiff(["A", "b", "c"], ["a", "C"], "cmp")), json_encode(array_uintersect(["A", "b", "c"], ["a", "C"], "cmp")),
+			json_encode(array_diff_ukey(["A" => 1, "b" => 2], ["a" => 1], "cmp")), json_encode(array_intersect_ukey(["A" => 1, "b" => 2], ["a" => 1], "cmp")),
+			json_encode(array_udiff_uassoc(["A" => "x", "b" => "y"], ["a" => "X", "b" => "z"], "cmp", "cmp")),
+			json_encode(array_uintersect_assoc(["a" => "x", "b" => "y"], ["a" => "X", "B" => "y"], "cmp"));`,
+		`{"1":"b"}{"0":"A","2":"c"}{"b":2}{"A":1}{"b":"y"}{"a":"x"}`,
+	)
+	testForError(t, `<?php array_diff([], 1);`, phpError.NewError("Uncaught TypeError: array_diff(): Argument #2 must be of type array, int given"))
+	testForError(t, `<?php array_udiff([], []);`,
+		phpError.NewError("Uncaught TypeError: array_udiff(): Argument #2 must be a valid callback, array callback must have exactly two members"),
+	)
+
+	// array_fill, array_fill_keys, array_filter
+	testInputOutput(t, `<?php echo json_encode(array_fill(5, 2, "v")), json_encode(array_fill(-3, 2, 0)), json_encode(array_fill_keys(["a", 5], 0));`,
+		`{"5":"v","6":"v"}{"-3":0,"-2":0}{"a":0,"5":0}`,
+	)
+	testForError(t, `<?php array_fill(0, -1, 0);`, phpError.NewError("Uncaught ValueError: array_fill(): Argument #2 ($count) must be greater than or equal to 0"))
+	testInputOutput(t,
+		`<?php function isOdd($v) { return $v % 2; } function isKeyB($k) { return $k == "b"; } function both($v, $k) { return $v > 1 && $k != "c"; }
+		echo json_encode(array_filter([1, 2, 3, 4, 5], "isOdd")), json_encode(array_filter([0, 1, "", null, "a", "0", []])),
+			json_encode(array_filter(["a" => 1, "b" => 2, "c" => 3], "isKeyB", ARRAY_FILTER_USE_KEY)),
+			json_encode(array_filter(["a" => 1, "b" => 2, "c" => 3], "both", ARRAY_FILTER_USE_BOTH));`,
+		`{"0":1,"2":3,"4":5}{"1":1,"4":"a"}{"b":2}{"b":2}`,
+	)
+
+	// array_flip, array_is_list, array_keys, array_values
+	testInputOutput(t,
+		`<?php echo json_encode(array_flip(["a", "b", "a", true]));`,
+		fmt.Sprintf("\nWarning: array_flip(): Can only flip string and integer values, entry skipped in %s:1:24\n{\"a\":2,\"b\":1}", TEST_FILE_NAME),
+	)
+	testInputOutput(t, `<?php var_dump(array_is_list([]), array_is_list([1, 2]), array_is_list([1 => 1]), array_is_list(["a" => 1]));`,
+		"bool(true)\nbool(true)\nbool(false)\nbool(false)\n",
+	)
+	testInputOutput(t, `<?php echo json_encode(array_keys(["a" => 1, "b" => "1", 5 => 2])), json_encode(array_keys([1, "1", 2, 1.0], 1)), json_encode(array_keys([1, "1"], "1", true));`,
+		`["a","b",5][0,1,3][1]`,
+	)
+	testInputOutput(t, `<?php echo json_encode(array_values(["a" => 1, 5 => 2]));`, `[1,2]`)
+
+	// array_map
+	testInputOutput(t,
+		`<?php function twice($v) { return $v * 2; } function join2($a, $b) { return $a . $b; }
+		echo json_encode(array_map("twice", ["x" => 1, "y" => 2])), json_encode(array_map("join2", ["a", "b"], ["c"])),
+			json_encode(array_map(null, [1, 2], ["a", "b"])), json_encode(array_map(null, ["x" => 1]));`,
+		`{"x":2,"y":4}["ac","b"][[1,"a"],[2,"b"]]{"x":1}`,
+	)
+	testForError(t, `<?php array_map("strlen", [], 1);`, phpError.NewError("Uncaught TypeError: array_map(): Argument #3 must be of type array, int given"))
+
+	// array_merge, array_merge_recursive, array_replace, array_replace_recursive
+	testInputOutput(t, `<?php echo json_encode(array_merge(["a" => 1, 5 => 2], ["a" => 3, 9 => 4])), json_encode(array_merge());`, `{"a":3,"0":2,"1":4}[]`)
+	testInputOutput(t,
+		`<?php echo json_encode(array_merge_recursive(["a" => [1], "b" => 2, 5], ["a" => [3], "b" => 4, 6]));`,
+		`{"a":[1,3],"b":[2,4],"0":5,"1":6}`,
+	)
+	testInputOutput(t, `<?php echo json_encode(array_replace([1, 2, 3], [1 => "b"], [3 => "d"]));`, `[1,"b",3,"d"]`)
+	testInputOutput(t,
+		`<?php echo json_encode(array_replace_recursive(["a" => ["x" => 1, "y" => 2], "b" => 1], ["a" => ["y" => 3], "b" => [2]]));`,
+		`{"a":{"x":1,"y":3},"b":[2]}`,
+	)
+
+	// array_pad, array_reverse, array_slice
+	testInputOutput(t, `<?php echo json_encode(array_pad([1, 2], 4, 0)), json_encode(array_pad([1, 2], -4, 0)), json_encode(array_pad([1, 2], 1, 0));`,
+		`[1,2,0,0][0,0,1,2][1,2]`,
+	)
+	testInputOutput(t, `<?php echo json_encode(array_reverse(["x", "y", "k" => "z"])), json_encode(array_reverse(["x", "y"], true));`,
+		`{"k":"z","0":"y","1":"x"}{"1":"y","0":"x"}`,
+	)
+	testInputOutput(t,
+		`<?php $a = ["a", "b", "c", "d", "e"];
+		echo json_encode(array_slice($a, 2)), json_encode(array_slice($a, -2, 1)), json_encode(array_slice($a, 0, -3)),
+			json_encode(array_slice($a, 2, -1, true)), json_encode(array_slice(["x" => 1, 5 => 2], 0)), json_encode(array_slice($a, 9));`,
+		`["c","d","e"]["d"]["a","b"]{"2":"c","3":"d"}{"x":1,"0":2}[]`,
+	)
+
+	// array_pop, array_push, array_shift, array_unshift, array_splice
+	testInputOutput(t, `<?php $a = [1, 2]; echo array_push($a, 3, 4), json_encode($a);`, `4[1,2,3,4]`)
+	testInputOutput(t, `<?php $a = [1, 2, 3]; echo array_pop($a), json_encode($a); $a[] = 4; echo json_encode($a); $e = []; var_dump(array_pop($e));`,
+		"3[1,2][1,2,4]NULL\n",
+	)
+	testInputOutput(t, `<?php $a = [5 => 1, "x" => 2, 9 => 3]; echo array_shift($a), json_encode($a);`, `1{"x":2,"0":3}`)
+	testInputOutput(t, `<?php $a = [5 => 1, "x" => 2]; echo array_unshift($a, "a", "b"), json_encode($a);`, `4{"0":"a","1":"b","2":1,"x":2}`)
+	testInputOutput(t,
+		`<?php $a = ["red", "green", "blue", "yellow"]; echo json_encode(array_splice($a, 1, -1, "orange")), json_encode($a);
+		$a = ["a", "b"]; array_splice($a, 1, 0, ["x", "y"]); echo json_encode($a);
+		$a = ["a", "k" => "b", "c"]; echo json_encode(array_splice($a, -2)), json_encode($a);`,
+		`["green","blue"]["red","orange","yellow"]["a","x","y","b"]{"k":"b","0":"c"}["a"]`,
+	)
+
+	// array_product, array_sum
+	testInputOutput(t, `<?php var_dump(array_sum([1, 2.5, "3"]), array_sum([]), array_product([2, "3"]), array_product([]), is_float(array_sum([PHP_INT_MAX, 1])));`,
+		"float(6.5)\nint(0)\nint(6)\nint(1)\nbool(true)\n",
+	)
+	testInputOutput(t,
+		`<?php var_dump(array_sum([1, [2]]));`,
+		fmt.Sprintf("\nWarning: array_sum(): Addition is not supported on type array in %s:1:16\nint(1)\n", TEST_FILE_NAME),
+	)
+
+	// array_rand
+	testInputOutput(t, `<?php var_dump(array_rand(["a" => 1]), count(array_rand([1, 2, 3], 2)), in_array(array_rand([5 => 1, 7 => 2]), [5, 7]));`,
+		"string(1) \"a\"\nint(2)\nbool(true)\n",
+	)
+	testForError(t, `<?php array_rand([]);`, phpError.NewError("Uncaught ValueError: array_rand(): Argument #1 ($array) cannot be empty"))
+	testForError(t, `<?php array_rand([1], 2);`,
+		phpError.NewError("Uncaught ValueError: array_rand(): Argument #2 ($num) must be between 1 and the number of elements in argument #1 ($array)"),
+	)
+
+	// array_reduce, array_walk, array_walk_recursive
+	testInputOutput(t, `<?php function sum($carry, $v) { return $carry + $v; } var_dump(array_reduce([1, 2, 3], "sum"), array_reduce([1, 2, 3], "sum", 10), array_reduce([], "sum"));`,
+		"int(6)\nint(16)\nNULL\n",
+	)
+	testInputOutput(t,
+		`<?php function show($v, $k, $p) { echo "$p$k=$v "; } function show2($v, $k) { echo "$k=$v "; }
+		array_walk(["a" => 1, "b" => 2], "show", "-"); array_walk_recursive([1, [2, 3]], "show2");`,
+		"-a=1 -b=2 0=1 0=2 1=3 ",
+	)
+
+	// array_search, in_array
+	testInputOutput(t,
+		`<?php var_dump(array_search("1", [0, 1, 2]), array_search("1", [0, 1], true), array_search("x", ["a" => "x"]), in_array("abc", [0]), in_array(null, [0]), in_array(null, [0], true));`,
+		"int(1)\nbool(false)\nstring(1) \"a\"\nbool(false)\nbool(true)\nbool(false)\n",
+	)
+
+	// array_unique
+	testInputOutput(t,
+		`<?php echo json_encode(array_unique([4, "4", "3", 4, 3, "3"])), json_encode(array_unique(["a" => "green", "red", "b" => "green", "blue", "red"])),
+			json_encode(array_unique([1, "1.0", true], SORT_REGULAR)), json_encode(array_unique(["1e1", "10", 10.0], SORT_NUMERIC));`,
+		`{"0":4,"2":"3"}{"a":"green","0":"red","1":"blue"}[1]["1e1"]`,
+	)
+
+	// count, sizeof
+	testInputOutput(t, `<?php echo count([1, [2, 3]]), count([1, [2, [3]]], COUNT_RECURSIVE), sizeof([]);`, "250")
+	testInputOutput(t, `<?php class C implements Countable { public function count(): int { return 42; } } echo count(new C());`, "42")
+	testForError(t, `<?php count(null);`, phpError.NewError("Uncaught TypeError: count(): Argument #1 ($value) must be of type Countable|array, null given"))
+	testForError(t, `<?php count([], 2);`, phpError.NewError("Uncaught ValueError: count(): Argument #2 ($mode) must be either COUNT_NORMAL or COUNT_RECURSIVE"))
+
+	// current, end, key, next, pos, prev, reset
+	testInputOutput(t,
+		`<?php $a = ["a" => 1, "b" => 2, "c" => 3];
+		var_dump(current($a), next($a), key($a), next($a), next($a), key($a), current($a), prev($a), reset($a), pos($a), end($a), prev($a), key($a));
+		$e = []; var_dump(current($e), reset($e), end($e), key($e));`,
+		"int(1)\nint(2)\nstring(1) \"b\"\nint(3)\nbool(false)\nNULL\nbool(false)\nbool(false)\nint(1)\nint(1)\nint(3)\nint(2)\nstring(1) \"b\"\n"+
+			"bool(false)\nbool(false)\nbool(false)\nNULL\n",
+	)
+
+	// range
+	testInputOutput(t,
+		`<?php echo json_encode(range(1, 4)), json_encode(range(5, 1, 2)), json_encode(range(0, 1, 0.25)), json_encode(range("a", "e", 2)),
+			json_encode(range("z", "x")), json_encode(range("1", "3")), json_encode(range(1, 2, 1.0)), json_encode(range(3, 3));`,
+		`[1,2,3,4][5,3,1][0,0.25,0.5,0.75,1]["a","c","e"]["z","y","x"][1,2,3][1,2][3]`,
+	)
+	testForError(t, `<?php range(1, 2, 0);`, phpError.NewError("Uncaught ValueError: range(): Argument #3 ($step) cannot be 0"))
+	testForError(t, `<?php range(1, 2, -1);`, phpError.NewError("Uncaught ValueError: range(): Argument #3 ($step) must be greater than 0 for increasing ranges"))
+	testForError(t, `<?php range(1, 2, 5);`, phpError.NewError("Uncaught ValueError: range(): Argument #3 ($step) must not exceed the specified range"))
+}
+
+// -------------------------------------- array sorting -------------------------------------- MARK: array sorting
+
+func TestLibArraySort(t *testing.T) {
+	// sort, rsort
+	testInputOutput(t, `<?php $a = [3, "10", 1, "9", 2.5]; var_dump(sort($a)); echo json_encode($a); rsort($a); echo json_encode($a);`,
+		"bool(true)\n[1,2.5,3,\"9\",\"10\"][\"10\",\"9\",3,2.5,1]",
+	)
+	testInputOutput(t, `<?php $a = ["x" => "b", "y" => "a"]; sort($a); echo json_encode($a);`, `["a","b"]`)
+	testInputOutput(t, `<?php $a = ["10 ", "9", "1e1"]; sort($a); echo json_encode($a);`, `["9","10 ","1e1"]`)
+	// Array elements as by-reference arguments
+	testInputOutput(t, `<?php $data = ["items" => [3, 1, 2], "n" => 1]; $copy = $data; sort($data["items"]); echo json_encode($data), json_encode($copy);`,
+		`{"items":[1,2,3],"n":1}{"items":[3,1,2],"n":1}`,
+	)
+	testInputOutput(t, `<?php function byLength($a, $b) { return strlen($a) - strlen($b); } $cfg = ["a" => ["b" => ["bb", "a", "ccc"]]]; usort($cfg["a"]["b"], "byLength"); echo json_encode($cfg);`,
+		`{"a":{"b":["a","bb","ccc"]}}`,
+	)
+	testInputOutput(t, `<?php $i = 0; $a = [[2, 1], [4, 3]]; sort($a[$i + 1]); echo json_encode($a); preg_match("/(b)/", "abc", $m["x"]); echo json_encode($m);`,
+		`[[2,1],[3,4]]{"x":["b","b"]}`,
+	)
+	testInputOutput(t, `<?php $a = ["10", 9, "1e1", 2]; sort($a, SORT_STRING); echo json_encode($a); sort($a, SORT_NUMERIC); echo json_encode($a);`,
+		`["10","1e1",2,9][2,9,"10","1e1"]`,
+	)
+	testInputOutput(t, `<?php $a = ["b", "A", "c", "B"]; sort($a); echo json_encode($a); sort($a, SORT_STRING | SORT_FLAG_CASE); echo json_encode($a);`,
+		`["A","B","b","c"]["A","B","b","c"]`,
+	)
+	testInputOutput(t, `<?php $a = ["b", "A", "c", "B"]; sort($a, SORT_STRING | SORT_FLAG_CASE); echo json_encode($a);`, `["A","b","B","c"]`)
+	testInputOutput(t, `<?php $a = ["img12", "img10", "img2", "IMG1"]; sort($a, SORT_NATURAL); echo json_encode($a); sort($a, SORT_NATURAL | SORT_FLAG_CASE); echo json_encode($a);`,
+		`["IMG1","img2","img10","img12"]["IMG1","img2","img10","img12"]`,
+	)
+
+	// asort, arsort, ksort, krsort
+	testInputOutput(t,
+		`<?php $a = ["b" => 2, "a" => 3, "c" => 1, "d" => 2]; asort($a); echo json_encode($a); arsort($a); echo json_encode($a);
+		ksort($a); echo json_encode($a); krsort($a); echo json_encode($a);`,
+		`{"c":1,"b":2,"d":2,"a":3}{"a":3,"b":2,"d":2,"c":1}{"a":3,"b":2,"c":1,"d":2}{"d":2,"c":1,"b":2,"a":3}`,
+	)
+	testInputOutput(t, `<?php $a = [10 => "a", 9 => "b", "x" => "c", 1 => "d"]; ksort($a); echo json_encode($a);`, `{"1":"d","9":"b","10":"a","x":"c"}`)
+
+	// natsort, natcasesort
+	testInputOutput(t,
+		`<?php $a = ["img12.png", "img10.png", "IMG2.png", "img1.png"]; natsort($a); echo json_encode($a); natcasesort($a); echo json_encode($a);`,
+		`{"2":"IMG2.png","3":"img1.png","1":"img10.png","0":"img12.png"}{"3":"img1.png","2":"IMG2.png","1":"img10.png","0":"img12.png"}`,
+	)
+
+	// usort, uasort, uksort
+	testInputOutput(t,
+		`<?php function byLength($a, $b) { return strlen($a) - strlen($b); } function reverse($a, $b) { return $b <=> $a; }
+		$a = ["x" => "ccc", "y" => "a", "z" => "bb", "w" => "d"]; $b = $a; $c = $a;
+		usort($a, "byLength"); uasort($b, "byLength"); uksort($c, "reverse"); echo json_encode($a), json_encode($b), json_encode($c);`,
+		`["a","d","bb","ccc"]{"y":"a","w":"d","z":"bb","x":"ccc"}{"z":"bb","y":"a","x":"ccc","w":"d"}`,
+	)
+	testForError(t, `<?php $a = []; usort($a, "unknownFn");`,
+		phpError.NewError(`Uncaught TypeError: usort(): Argument #2 ($callback) must be a valid callback, function "unknownFn" not found or invalid function name`),
+	)
+	// Static method callables
+	testInputOutput(t,
+		`<?php class Base { public static function cmp($a, $b) { return $a <=> $b; } } class C extends Base { static function rev($a, $b) { return $b <=> $a; } }
+		$a = [3, 1, 2]; usort($a, "C::cmp"); echo json_encode($a); usort($a, ["C", "rev"]); echo json_encode($a); usort($a, ["C", "CMP"]); echo json_encode($a);`,
+		"[1,2,3][3,2,1][1,2,3]",
+	)
+	testInputOutput(t,
+		`<?php class C { static function twice($x) { return $x * 2; } static function isOdd($x) { return $x % 2 == 1; } }
+		echo json_encode(array_map("C::twice", [1, 2])), json_encode(array_map(["C", "twice"], [3])), json_encode(array_filter([1, 2, 3], "C::isOdd"));`,
+		"[2,4][6]{\"0\":1,\"2\":3}",
+	)
+	testForError(t, `<?php $a = []; usort($a, "X::cmp");`,
+		phpError.NewError(`Uncaught TypeError: usort(): Argument #2 ($callback) must be a valid callback, class "X" not found`),
+	)
+	testForError(t, `<?php class C {} $a = []; usort($a, ["C", "cmp"]);`,
+		phpError.NewError(`Uncaught TypeError: usort(): Argument #2 ($callback) must be a valid callback, class C does not have a method "cmp"`),
+	)
+	testForError(t, `<?php class C { function cmp($a, $b) {} } $a = []; usort($a, "C::cmp");`,
+		phpError.NewError(`Uncaught TypeError: usort(): Argument #2 ($callback) must be a valid callback, non-static method C::cmp() cannot be called statically`),
+	)
+
+	// array_multisort
+	testInputOutput(t, `<?php $a = [3, 1, 3, 2]; $b = ["c", "a", "b", "d"]; var_dump(array_multisort($a, $b)); echo json_encode($a), json_encode($b);`,
+		"bool(true)\n[1,2,3,3][\"a\",\"d\",\"b\",\"c\"]",
+	)
+	testInputOutput(t,
+		`<?php $a = [3, 1, 3, 2]; $b = ["c", "a", "b", "d"]; array_multisort($a, SORT_DESC, SORT_NUMERIC, $b, SORT_ASC); echo json_encode($a), json_encode($b);`,
+		`[3,3,2,1]["b","c","d","a"]`,
+	)
+	testInputOutput(t, `<?php $a = ["x" => 2, 5 => 1]; array_multisort($a); echo json_encode($a);`, `{"0":1,"x":2}`)
+	testForError(t, `<?php $a = [1]; $b = [1, 2]; array_multisort($a, $b);`, phpError.NewError("Uncaught ValueError: Array sizes are inconsistent"))
+	testForError(t, `<?php $a = [1]; array_multisort($a, SORT_ASC, SORT_DESC);`,
+		phpError.NewError("Uncaught ValueError: array_multisort(): Argument #3 must be an array or a sort flag that has not already been specified"),
+	)
+	testForError(t, `<?php $a = [1]; array_multisort($a, 99);`, phpError.NewError("Uncaught ValueError: array_multisort(): Argument #2 must be a valid sort flag"))
+	testForError(t, `<?php $a = [1]; array_multisort($a, "x");`, phpError.NewError("Uncaught TypeError: array_multisort(): Argument #2 must be an array or a sort flag"))
+
+	// shuffle
+	testInputOutput(t, `<?php $a = ["x" => 1, "y" => 2, "z" => 3]; var_dump(shuffle($a)); var_dump(array_is_list($a)); sort($a); echo json_encode($a);`,
+		"bool(true)\nbool(true)\n[1,2,3]",
+	)
+}
+
+// -------------------------------------- compact -------------------------------------- MARK: compact
+
+func TestLibCompact(t *testing.T) {
+	testInputOutput(t,
+		`<?php function f() { $city = "San Francisco"; $state = "CA"; $event = "SIGGRAPH"; $location = ["city", "state"];
+		print_r(compact("event", $location)); } f();`,
+		"Array\n(\n    [event] => SIGGRAPH\n    [city] => San Francisco\n    [state] => CA\n)\n",
+	)
+	testInputOutput(t,
+		`<?php $a = 1; print_r(compact("a", ["b"]));`,
+		fmt.Sprintf("\nWarning: compact(): Undefined variable $b in %s:1:23\nArray\n(\n    [a] => 1\n)\n", TEST_FILE_NAME),
+	)
+}
+
+// -------------------------------------- extract -------------------------------------- MARK: extract
+
+func TestLibExtract(t *testing.T) {
+	testInputOutput(t,
+		`<?php function f() { $size = "large"; $vars = ["color" => "blue", "size" => "medium", "shape" => "sphere"];
+		echo extract($vars, EXTR_PREFIX_SAME, "wddx"), " $color, $size, $shape, $wddx_size"; } f();`,
+		"3 blue, large, sphere, medium",
+	)
+	testInputOutput(t, `<?php function f() { $a = 1; echo extract(["a" => 2, "b" => 3]), $a, $b; } f();`, "223")
+	testInputOutput(t, `<?php $a = 1; echo extract(["a" => 2, "b" => 3], EXTR_SKIP), $a, $b;`, "113")
+	testInputOutput(t, `<?php $a = 1; echo extract(["a" => 2, "b" => 3], EXTR_IF_EXISTS), $a;`, "12")
+	testInputOutput(t, `<?php $a = 1; echo extract(["a" => 2, "b" => 3], EXTR_PREFIX_IF_EXISTS, "p"), $a, $p_a;`, "112")
+	testInputOutput(t, `<?php echo extract(["a" => 1, 0 => 2, "1x" => 3], EXTR_PREFIX_ALL, "p"), $p_a, $p_0, $p_1x;`, "3123")
+	testInputOutput(t, `<?php echo extract(["a" => 1, 0 => 2, "1x" => 3], EXTR_PREFIX_INVALID, "p"), $a, $p_0, $p_1x;`, "3123")
+	testInputOutput(t, `<?php echo extract(["a b" => 1, 0 => 2, "this" => 3]);`, "0")
+	testForError(t, `<?php extract(["a" => 1], 7);`,
+		phpError.NewError("Uncaught ValueError: extract(): Argument #2 ($flags) must be a valid extract type"),
+	)
+	testForError(t, `<?php extract(["a" => 1], EXTR_PREFIX_ALL);`,
+		phpError.NewError("Uncaught ValueError: extract(): Argument #3 ($prefix) is required when using this extract type"),
+	)
+}
+
 // -------------------------------------- constant -------------------------------------- MARK: constant
 
 func TestLibConstant(t *testing.T) {
@@ -142,6 +482,14 @@ func TestLibDate(t *testing.T) {
 // -------------------------------------- strings -------------------------------------- MARK: strings
 
 func TestLibStrings(t *testing.T) {
+	// addcslashes
+	testInputOutput(t, `<?php var_dump(addcslashes('foo[bar]', 'A..Z'));`, "string(8) \"foo[bar]\"\n")
+	testInputOutput(t, `<?php var_dump(addcslashes("zoo['.']", 'z..A'));`, fmt.Sprintf("\nWarning: addcslashes(): Invalid '..'-range, '..'-range needs to be incrementing in %s:1:16\n", TEST_FILE_NAME)+`string(10) "\zoo['\.']"`+"\n")
+	testInputOutput(t, `<?php var_dump(addcslashes("a\nb", "\n"));`, `string(4) "a\nb"`+"\n")
+
+	// addslashes
+	testInputOutput(t, `<?php var_dump(addslashes("O'Reilly \"a\\b\""));`, `string(18) "O\'Reilly \"a\\b\""`+"\n")
+
 	// bin2hex
 	testInputOutput(t, `<?php var_dump(bin2hex('Hello world!'));`, "string(24) \"48656c6c6f20776f726c6421\"\n")
 	testInputOutput(t, `<?php var_dump(bin2hex('Äàßê'));`, "string(16) \"c384c3a0c39fc3aa\"\n")
@@ -151,6 +499,24 @@ func TestLibStrings(t *testing.T) {
 	testInputOutput(t, `<?php var_dump(chr(60));`, "string(1) \"<\"\n")
 	testInputOutput(t, `<?php var_dump(chr(60-256));`, "string(1) \"<\"\n")
 	testInputOutput(t, `<?php var_dump(chr(60+256));`, "string(1) \"<\"\n")
+	testInputOutput(t, `<?php var_dump(bin2hex(chr(200)), strlen(chr(255)));`, "string(2) \"c8\"\nint(1)\n")
+
+	// explode
+	testInputOutput(t, `<?php var_dump(explode(',', 'a,b,c'));`, "array(3) {\n  [0]=>\n  string(1) \"a\"\n  [1]=>\n  string(1) \"b\"\n  [2]=>\n  string(1) \"c\"\n}\n")
+	testInputOutput(t, `<?php var_dump(explode(',', 'a,b,c', 2));`, "array(2) {\n  [0]=>\n  string(1) \"a\"\n  [1]=>\n  string(3) \"b,c\"\n}\n")
+	testInputOutput(t, `<?php var_dump(explode(',', 'a,b,c', -1));`, "array(2) {\n  [0]=>\n  string(1) \"a\"\n  [1]=>\n  string(1) \"b\"\n}\n")
+	testInputOutput(t, `<?php var_dump(explode(',', '', -1));`, "array(0) {\n}\n")
+	testForError(t, `<?php explode('', 'abc');`, phpError.NewError("Uncaught ValueError: explode(): Argument #1 ($separator) cannot be empty"))
+
+	// htmlspecialchars
+	testInputOutput(t, `<?php echo htmlspecialchars("<a href='x'>T&amp;C</a>");`, "&lt;a href=&#039;x&#039;&gt;T&amp;amp;C&lt;/a&gt;")
+	testInputOutput(t, `<?php echo htmlspecialchars("<a href='x'>T&amp;C</a>", ENT_NOQUOTES, 'UTF-8', false);`, "&lt;a href='x'&gt;T&amp;C&lt;/a&gt;")
+	testInputOutput(t, `<?php echo htmlspecialchars_decode("&lt;p&gt;&#039;x&#039; &amp;amp;&lt;/p&gt;");`, "<p>'x' &amp;</p>")
+
+	// html_entity_decode / htmlentities
+	testInputOutput(t, `<?php echo htmlentities("café €");`, "caf&eacute; &euro;")
+	testInputOutput(t, `<?php echo html_entity_decode("caf&eacute; &euro; &apos; &#39;");`, "café € &apos; '")
+	testInputOutput(t, `<?php echo html_entity_decode("&apos;", ENT_QUOTES | ENT_HTML5);`, "'")
 
 	// lcfirst
 	testInputOutput(t, `<?php var_dump(lcfirst('ABC'));`, "string(3) \"aBC\"\n")
@@ -158,11 +524,34 @@ func TestLibStrings(t *testing.T) {
 	testInputOutput(t, `<?php var_dump(lcfirst('abc'));`, "string(3) \"abc\"\n")
 	testInputOutput(t, `<?php var_dump(lcfirst(''));`, "string(0) \"\"\n")
 
+	// levenshtein
+	testInputOutput(t, `<?php var_dump(levenshtein('kitten', 'sitting'));`, "int(3)\n")
+	testInputOutput(t, `<?php var_dump(levenshtein('', 'abc'));`, "int(3)\n")
+
+	// nl2br
+	testInputOutput(t, `<?php echo nl2br("a\r\nb\nc");`, "a<br />\r\nb<br />\nc")
+	testInputOutput(t, `<?php echo nl2br("a\nb", false);`, "a<br>\nb")
+
+	// parse_str
+	testInputOutput(t, `<?php parse_str('a=1&b[]=2&b[]=3&c%20d=x+y&e[f=5', $r); var_dump($r);`,
+		"array(4) {\n  [\"a\"]=>\n  string(1) \"1\"\n  [\"b\"]=>\n  array(2) {\n    [0]=>\n    string(1) \"2\"\n    [1]=>\n    string(1) \"3\"\n  }\n"+
+			"  [\"c_d\"]=>\n  string(3) \"x y\"\n  [\"e_f\"]=>\n  string(1) \"5\"\n}\n")
+
 	// quotemeta
 	testInputOutput(t, `<?php var_dump(quotemeta('. \ + * ? [ ^ ] ( $ )'));`, `string(31) "\. \\ \+ \* \? \[ \^ ] \( \$ \)"`+"\n")
 	testInputOutput(t, `<?php var_dump(quotemeta('Hello. (can you hear me?)'));`, `string(29) "Hello\. \(can you hear me\?\)"`+"\n")
 	testInputOutput(t, `<?php var_dump(quotemeta(''));`, "bool(false)\n")
 
+	// similar_text
+	testInputOutput(t, `<?php var_dump(similar_text('World', 'Word', $p), $p);`, "int(4)\nfloat(88.88888888888889)\n")
+
+	// soundex / metaphone
+	testInputOutput(t, `<?php var_dump(soundex('Robert'), soundex('Tymczak'), soundex(''));`, "string(4) \"R163\"\nstring(4) \"T522\"\nstring(0) \"\"\n")
+	testInputOutput(t, `<?php var_dump(metaphone('Thumb'));`, "string(2) \"0M\"\n")
+
+	// str_getcsv
+	testInputOutput(t, `<?php var_dump(str_getcsv('a,"b ""c""",d'));`, "array(3) {\n  [0]=>\n  string(1) \"a\"\n  [1]=>\n  string(5) \"b \"c\"\"\n  [2]=>\n  string(1) \"d\"\n}\n")
+
 	// str_contains
 	testInputOutput(t, `<?php var_dump(str_contains('abc', ''));`, "bool(true)\n")
 	testInputOutput(t, `<?php var_dump(str_contains('The lazy fox', 'lazy'));`, "bool(true)\n")
@@ -173,37 +562,529 @@ func TestLibStrings(t *testing.T) {
 	testInputOutput(t, `<?php var_dump(str_ends_with('The lazy fox', 'fox'));`, "bool(true)\n")
 	testInputOutput(t, `<?php var_dump(str_ends_with('The lazy fox', 'Fox'));`, "bool(false)\n")
 
+	// str_increment / str_decrement
+	testInputOutput(t, `<?php var_dump(str_increment('Az'), str_increment('zz'), str_decrement('Ba'), str_decrement('aaa'));`, "string(2) \"Ba\"\nstring(3) \"aaa\"\nstring(2) \"Az\"\nstring(2) \"zz\"\n")
+	testForError(t, `<?php str_increment('');`, phpError.NewError("Uncaught ValueError: str_increment(): Argument #1 ($string) cannot be empty"))
+	testForError(t, `<?php str_decrement('a');`, phpError.NewError("Uncaught ValueError: str_decrement(): Argument #1 ($string) \"a\" is out of decrement range"))
+
+	// str_pad
+	testInputOutput(t, `<?php var_dump(str_pad('ab', 7, 'xy', STR_PAD_BOTH), str_pad('5', 3, '0', STR_PAD_LEFT), str_pad('abc', 2));`, "string(7) \"xyabxyx\"\nstring(3) \"005\"\nstring(3) \"abc\"\n")
+
 	// str_repeat
 	testInputOutput(t, `<?php var_dump(str_repeat('abc', 0));`, "string(0) \"\"\n")
 	testInputOutput(t, `<?php var_dump(str_repeat('abc', 1));`, "string(3) \"abc\"\n")
 	testInputOutput(t, `<?php var_dump(str_repeat('abc', 2));`, "string(6) \"abcabc\"\n")
 	testForError(t, `<?php var_dump(str_repeat('abc', -1));`, phpError.NewError("Uncaught ValueError: str_repeat(): Argument #2 ($times) must be greater than or equal to 0"))
 
+	// str_replace / str_ireplace
+	testInputOutput(t, `<?php var_dump(str_replace(['a', 'b'], ['b', 'c'], 'ab', $count), $count);`, "string(2) \"cc\"\nint(3)\n")
+	testInputOutput(t, `<?php var_dump(str_replace(['a', 'b'], 'x', ['ab', 'k' => 'cb']));`, "array(2) {\n  [0]=>\n  string(2) \"xx\"\n  [\"k\"]=>\n  string(2) \"cx\"\n}\n")
+	testInputOutput(t, `<?php var_dump(str_ireplace('L', 'x', 'Hello'));`, "string(5) \"Hexxo\"\n")
+
+	// str_split
+	testInputOutput(t, `<?php var_dump(str_split('abcde', 2));`, "array(3) {\n  [0]=>\n  string(2) \"ab\"\n  [1]=>\n  string(2) \"cd\"\n  [2]=>\n  string(1) \"e\"\n}\n")
+
 	// str_starts_with
 	testInputOutput(t, `<?php var_dump(str_starts_with('abc', ''));`, "bool(true)\n")
 	testInputOutput(t, `<?php var_dump(str_starts_with('The lazy fox', 'The'));`, "bool(true)\n")
 	testInputOutput(t, `<?php var_dump(str_starts_with('The lazy fox', 'the'));`, "bool(false)\n")
 
+	// strip_tags
+	testInputOutput(t, `<?php echo strip_tags('<p>Hello <b>World</b><br/></p><!-- c -->', '<b>');`, "Hello <b>World</b>")
+
+	// stripcslashes / stripslashes
+	testInputOutput(t, `<?php var_dump(stripcslashes('a\tb\x41\101'));`, "string(5) \"a\tbAA\"\n")
+	testInputOutput(t, `<?php var_dump(stripslashes('O\\\'Re\\\\illy'));`, `string(9) "O'Re\illy"`+"\n")
+
 	// strlen
 	testInputOutput(t, `<?php var_dump(strlen('abcdef'));`, "int(6)\n")
 	testInputOutput(t, `<?php var_dump(strlen(' ab cd '));`, "int(7)\n")
 	testInputOutput(t, `<?php var_dump(strlen(' äb ćd '));`, "int(9)\n")
 
+	// strpos / stripos / strrpos / strripos
+	testInputOutput(t, `<?php var_dump(strpos('hello', 'l'), strpos('hello', 'l', -2), strpos('hello', 'x'));`, "int(2)\nint(3)\nbool(false)\n")
+	testInputOutput(t, `<?php var_dump(stripos('HeLLo', 'll'), strrpos('hello', 'l'), strrpos('hello', 'l', -3), strripos('HELLO', 'l'));`, "int(2)\nint(3)\nint(2)\nint(3)\n")
+	testForError(t, `<?php strpos('abc', 'a', 4);`, phpError.NewError("Uncaught ValueError: strpos(): Argument #3 ($offset) must be contained in argument #1 ($haystack)"))
+
+	// strstr / strrchr
+	testInputOutput(t, `<?php var_dump(strstr('user@example.com', '@'), strstr('user@example.com', '@', true), strrchr('a/b/c', '/'));`, "string(12) \"@example.com\"\nstring(4) \"user\"\nstring(2) \"/c\"\n")
+
+	// strtok
+	testInputOutput(t, `<?php $t = strtok('a b  c', ' '); while ($t !== false) { echo $t, '|'; $t = strtok(' '); }`, "a|b|c|")
+
 	// strtolower
 	testInputOutput(t, `<?php var_dump(strtolower('Mary Had A Little Lamb and She LOVED It So'));`, "string(42) \"mary had a little lamb and she loved it so\"\n")
 	testInputOutput(t, `<?php var_dump(strtolower(''));`, "string(0) \"\"\n")
 	testInputOutput(t, `<?php var_dump(strtolower('AÄOÖUÜSß'));`, "string(12) \"aÄoÖuÜsß\"\n")
 
+	// strtr
+	testInputOutput(t, `<?php var_dump(strtr('Hi all', ['Hi' => 'Hello', 'Hi all' => 'Hey', 'a' => 'A']));`, "string(3) \"Hey\"\n")
+	testInputOutput(t, `<?php var_dump(strtr('Hi all', ['Hi' => 'Hello', 'all' => 'All']));`, "string(9) \"Hello All\"\n")
+	testInputOutput(t, `<?php var_dump(strtr('abc', 'ab', 'xyz'));`, "string(3) \"xyc\"\n")
+
+	// substr
+	testInputOutput(t, `<?php var_dump(substr('abcdef', -1), substr('abcdef', 1, -2), substr('abc', 5), substr('abc', 1, -5));`, "string(1) \"f\"\nstring(3) \"bcd\"\nstring(0) \"\"\nstring(0) \"\"\n")
+
+	// substr_count
+	testInputOutput(t, `<?php var_dump(substr_count('aaa', 'aa'), substr_count('hello hello', 'll', 3));`, "int(1)\nint(1)\n")
+	testForError(t, `<?php substr_count('abc', 'a', 5);`, phpError.NewError("Uncaught ValueError: substr_count(): Argument #3 ($offset) must be contained in argument #1 ($haystack)"))
+
+	// substr_replace
+	testInputOutput(t, `<?php var_dump(substr_replace('Hello', 'J', 0, 1), substr_replace('Hello', '!', -1, 0));`, "string(5) \"Jello\"\nstring(6) \"Hell!o\"\n")
+
+	// trim / ltrim / rtrim
+	testInputOutput(t, `<?php var_dump(trim("  a b \n"), ltrim('xxay', 'x'), rtrim('xxay', 'a..z'));`, "string(3) \"a b\"\nstring(2) \"ay\"\nstring(0) \"\"\n")
+
 	// strtoupper
 	testInputOutput(t, `<?php var_dump(strtoupper('Mary Had A Little Lamb and She LOVED It So'));`, "string(42) \"MARY HAD A LITTLE LAMB AND SHE LOVED IT SO\"\n")
 	testInputOutput(t, `<?php var_dump(strtoupper(''));`, "string(0) \"\"\n")
 	testInputOutput(t, `<?php var_dump(strtoupper('aäoöuüsß'));`, "string(12) \"AäOöUüSß\"\n")
 
+	// ucwords
+	testInputOutput(t, `<?php var_dump(ucwords('hello world-foo'), ucwords('hello world-foo', '-'));`, "string(15) \"Hello World-foo\"\nstring(15) \"Hello world-Foo\"\n")
+
 	// ucfirst
 	testInputOutput(t, `<?php var_dump(ucfirst('ABC'));`, "string(3) \"ABC\"\n")
 	testInputOutput(t, `<?php var_dump(ucfirst('abc'));`, "string(3) \"Abc\"\n")
 	testInputOutput(t, `<?php var_dump(ucfirst('Abc'));`, "string(3) \"Abc\"\n")
 	testInputOutput(t, `<?php var_dump(ucfirst(''));`, "string(0) \"\"\n")
+
+	// wordwrap
+	testInputOutput(t, `<?php echo wordwrap('The quick brown fox', 10, "\n", true);`, "The quick\nbrown fox")
+	testForError(t, `<?php wordwrap('abc', 0, "\n", true);`, phpError.NewError("Uncaught ValueError: wordwrap(): Argument #4 ($cut_long_words) cannot be true when argument #2 ($width) is 0"))
+}
+
+func TestLibPrintf(t *testing.T) {
+	// sprintf
+	testInputOutput(t, `<?php echo sprintf("[%5d] [%-5d] [%05d] [%+d] [%+05d] [%u]", 42, 42, -42, 5, 5, -1);`, "[   42] [42   ] [-0042] [+5] [+0005] [18446744073709551615]")
+	testInputOutput(t, `<?php echo sprintf("[%'*8s] [%-8s] [%.3s] [%5.1s] [%-'x4d]", "ab", "ab", "abcdef", "abc", 7);`, "[******ab] [ab      ] [abc] [    a] [7xxx]")
+	testInputOutput(t, `<?php echo sprintf('%2$s %1$s %%', "a", "b");`, "b a %")
+	testInputOutput(t, `<?php echo sprintf("%b %o %x %X %c", 10, 8, 255, 255, 65);`, "1010 10 ff FF A")
+	testInputOutput(t, `<?php echo sprintf("%f %.2f %.0f %.1f %F", 3.14159, 1.005, 2.5, -0.25, 1);`, "3.141590 1.01 3 -0.3 1.000000")
+	testInputOutput(t, `<?php echo sprintf("%e %.2E %.0e %e", 1234.5, 0.000123, 5, 0);`, "1.234500e+3 1.23E-4 5e+0 0.000000e+0")
+	testInputOutput(t, `<?php echo sprintf("%g %G %g %g %.3g %g", 0.00001234, 1e20, 100000, 1000000, 3.14159, -0.5);`, "1.234e-5 1.0E+20 100000 1.0e+6 3.14 -0.5")
+	testInputOutput(t, `<?php echo sprintf("[%10.3f] [%-10.2f] [%010.2f] [%+.1f] [%+g]", -3.14159, 2.5, -2.5, 3, 2);`, "[    -3.142] [2.50      ] [-000002.50] [+3.0] [+2]")
+	testInputOutput(t, `<?php echo sprintf("%f %5f %+f %f", NAN, INF, INF, -INF);`, "NaN Inf +Inf -Inf")
+	testInputOutput(t, `<?php echo sprintf("%s %s %s %d %d", true, null, 1.5, "12abc", 3.99);`, "1  1.5 12 3")
+	testInputOutput(t, `<?php echo sprintf("%*d|%-*d|%.*f", 5, 1, 4, 2, 2, 3.14159);`, "    1|2   |3.14")
+	testInputOutput(t, `<?php echo sprintf("%.*g", -1, 0.1 + 0.2);`, "0.30000000000000004")
+	testInputOutput(t, `<?php echo sprintf("%ld %5%", 1, 2);`, "1 %")
+	testInputOutput(t, `<?php echo sprintf("%.2x", 255);`, "")
+	testInputOutput(t, `<?php echo sprintf("%.60f", 1);`,
+		"\nNotice: Requested precision of 60 digits was truncated to PHP maximum of 53 digits in /home/admin/test.php:1:12\n"+
+			"1.00000000000000000000000000000000000000000000000000000",
+	)
+	testForError(t, `<?php sprintf("%d %d", 1);`, phpError.NewError("Uncaught ArgumentCountError: 3 arguments are required, 2 given"))
+	testForError(t, `<?php sprintf('%3$s', 1);`, phpError.NewError("Uncaught ArgumentCountError: 4 arguments are required, 2 given"))
+	testForError(t, `<?php sprintf('%0$s', 1);`, phpError.NewError("Uncaught ValueError: Argument number specifier must be greater than zero and less than 2147483647"))
+	testForError(t, `<?php sprintf("%y", 1);`, phpError.NewError("Uncaught ValueError: Unknown format specifier \"y\""))
+	testForError(t, `<?php sprintf("%5", 1);`, phpError.NewError("Uncaught ValueError: Missing format specifier at end of string"))
+	testForError(t, `<?php sprintf("%'", 1);`, phpError.NewError("Uncaught ValueError: Missing padding character"))
+	testForError(t, `<?php sprintf("%*d", "5", 1);`, phpError.NewError("Uncaught ValueError: Width must be an integer"))
+	testForError(t, `<?php sprintf("%*d", -1, 1);`, phpError.NewError("Uncaught ValueError: Width must be greater than or equal to zero and less than 2147483647"))
+	testForError(t, `<?php sprintf("%.*f", -2, 1);`, phpError.NewError("Uncaught ValueError: Precision must be between -1 and 2147483647"))
+	testForError(t, `<?php sprintf("%.*f", -1, 1);`, phpError.NewError("Uncaught ValueError: Precision -1 is only supported for %%g, %%G, %%h and %%H"))
+
+	// printf
+	testInputOutput(t, `<?php $length = printf("%s=%d\n", "x", 3); var_dump($length);`, "x=3\nint(4)\n")
+
+	// vsprintf and vprintf
+	testInputOutput(t, `<?php echo vsprintf("%04d-%02d-%02d", ["y" => 2024, "m" => 1, "d" => 5]);`, "2024-01-05")
+	testInputOutput(t, `<?php var_dump(vprintf("%s %s|", ["a", "b"]));`, "a b|int(4)\n")
+	testForError(t, `<?php vsprintf("%s %s", ["a"]);`, phpError.NewError("Uncaught ValueError: The arguments array must contain 2 items, 1 given"))
+
+	// number_format
+	testInputOutput(t, `<?php echo number_format(1234567.891), " ", number_format(1234567.891, 2), " ", number_format(1234.5678, 2, ",", ".");`, "1,234,568 1,234,567.89 1.234,57")
+	testInputOutput(t, `<?php echo number_format(-0.4), " ", number_format(1.005, 2), " ", number_format(1234.5, -2), " ", number_format(-1234.567, 1, null, " ");`, "0 1.01 1,200 -1 234.6")
+	testInputOutput(t, `<?php echo number_format(0.5), " ", number_format(1000, 2, "", ""), " ", number_format(12345, 0, ".", "");`, "1 100000 12345")
+
+	// sscanf
+	testInputOutput(t, `<?php var_dump(sscanf("age: 25 name: Bob", "age: %d name: %s"));`, "array(2) {\n  [0]=>\n  int(25)\n  [1]=>\n  string(3) \"Bob\"\n}\n")
+	testInputOutput(t, `<?php $n = sscanf("12 apples", "%d %s", $count, $fruit); var_dump($n, $count, $fruit);`, "int(2)\nint(12)\nstring(6) \"apples\"\n")
+	testInputOutput(t, `<?php var_dump(sscanf("ab-ff", "%[a-z]-%x"), sscanf("a", "%c%n"), sscanf("x", "%d"));`,
+		"array(2) {\n  [0]=>\n  string(2) \"ab\"\n  [1]=>\n  int(255)\n}\narray(2) {\n  [0]=>\n  string(1) \"a\"\n  [1]=>\n  int(1)\n}\narray(1) {\n  [0]=>\n  NULL\n}\n",
+	)
+	testInputOutput(t, `<?php var_dump(sscanf("", "%d"), sscanf("", "%d", $a), sscanf("1.5e3 -7", "%f %*d"));`, "NULL\nint(-1)\narray(1) {\n  [0]=>\n  float(1500)\n}\n")
+	testInputOutput(t, `<?php sscanf("2024-01-05", '%3$d-%2$d-%1$d', $day, $month, $year); echo $day, ".", $month, ".", $year;`, "5.1.2024")
+	testForError(t, `<?php sscanf("1 2", "%d %d", $a);`, phpError.NewError("Uncaught ValueError: Different numbers of variable names and field specifiers"))
+	testForError(t, `<?php sscanf("1", "%y");`, phpError.NewError("Uncaught ValueError: Bad scan conversion character \"y\""))
+
+	// fprintf
+	if os.OS == "Windows" {
+		t.Skip("The test commands require a POSIX shell")
+	}
+	testInputOutput(t, `<?php
+		$process = proc_open("cat", [0 => ["pipe", "r"], 1 => ["pipe", "w"]], $pipes);
+		var_dump(fprintf($pipes[0], "%05.1f|", 3.14159));
+		vfprintf($pipes[0], "%s-%s", ["a", "b"]);
+		fclose($pipes[0]);
+		echo stream_get_contents($pipes[1]);
+		proc_close($process);`,
+		"int(6)\n003.1|a-b",
+	)
+}
+
+// -------------------------------------- mbstring -------------------------------------- MARK: mbstring
+
+func TestLibMbstring(t *testing.T) {
+	// mb_check_encoding
+	testInputOutput(t, `<?php var_dump(mb_check_encoding('ä'), mb_check_encoding("\xE4"), mb_check_encoding("\xE4", 'ISO-8859-1'));`, "bool(true)\nbool(false)\nbool(true)\n")
+	testInputOutput(t, `<?php var_dump(mb_check_encoding(['a' => 'ä', "\xFF" => 'b']));`, "bool(false)\n")
+
+	// mb_convert_case
+	testInputOutput(t, `<?php var_dump(mb_convert_case("hello wORLD it's o'neil", MB_CASE_TITLE));`, "string(23) \"Hello World It's O'neil\"\n")
+	testInputOutput(t, `<?php var_dump(mb_convert_case('Straße', MB_CASE_FOLD), mb_convert_case('Straße', MB_CASE_FOLD_SIMPLE), mb_convert_case('Straße', MB_CASE_UPPER_SIMPLE));`,
+		"string(7) \"strasse\"\nstring(7) \"straße\"\nstring(7) \"STRAßE\"\n")
+	testForError(t, `<?php mb_convert_case('a', 8);`, phpError.NewError("Uncaught ValueError: mb_convert_case(): Argument #2 ($mode) must be one of the MB_CASE_* constants"))
+
+	// mb_convert_encoding
+	testInputOutput(t, `<?php var_dump(bin2hex(mb_convert_encoding('äb€', 'ISO-8859-1', 'UTF-8')), bin2hex(mb_convert_encoding('äb€', 'Windows-1252')));`, "string(6) \"e4623f\"\nstring(6) \"e46280\"\n")
+	testInputOutput(t, `<?php var_dump(bin2hex(mb_convert_encoding('aä', 'UTF-16')), bin2hex(mb_convert_encoding('aä', 'UTF-16LE')));`, "string(8) \"006100e4\"\nstring(8) \"6100e400\"\n")
+	testInputOutput(t, `<?php var_dump(mb_convert_encoding("\xFF\xFEa\x00", 'UTF-8', 'UTF-16'), mb_convert_encoding("\xE4\x80", 'UTF-8', 'ISO-8859-1, Windows-1252'));`, "string(1) \"a\"\nstring(5) \"ä€\"\n")
+	testInputOutput(t, `<?php var_dump(mb_convert_encoding("\xE4", 'UTF-8', ['ASCII', 'ISO-8859-1']));`, "string(2) \"ä\"\n")
+	testForError(t, `<?php mb_convert_encoding('a', 'EBCDIC');`, phpError.NewError("Uncaught ValueError: mb_convert_encoding(): Argument #2 ($to_encoding) must be a valid encoding, \"EBCDIC\" given"))
+	testForError(t, `<?php mb_convert_encoding('a', 'UTF-8', 'ASCII, EBCDIC');`, phpError.NewError("Uncaught ValueError: mb_convert_encoding(): Argument #3 ($from_encoding) contains invalid encoding \"EBCDIC\""))
+
+	// mb_detect_encoding
+	testInputOutput(t, `<?php var_dump(mb_detect_encoding('abc'), mb_detect_encoding('äbc'), mb_detect_encoding('äbc', 'ISO-8859-1, UTF-8'));`, "string(5) \"ASCII\"\nstring(5) \"UTF-8\"\nstring(5) \"UTF-8\"\n")
+	testInputOutput(t, `<?php var_dump(mb_detect_encoding("\xE4bc", 'ASCII, UTF-8, ISO-8859-1'), mb_detect_encoding("\xE4bc", ['ASCII', 'UTF-8'], true));`, "string(10) \"ISO-8859-1\"\nbool(false)\n")
+	testForError(t, `<?php mb_detect_encoding('a', []);`, phpError.NewError("Uncaught ValueError: mb_detect_encoding(): Argument #2 ($encodings) must specify at least one encoding"))
+
+	// mb_detect_order
+	testInputOutput(t, `<?php var_dump(mb_detect_order('UTF-8, ISO-8859-1'), mb_detect_order(), mb_detect_encoding("\xE4"));`,
+		"bool(true)\narray(2) {\n  [0]=>\n  string(5) \"UTF-8\"\n  [1]=>\n  string(10) \"ISO-8859-1\"\n}\nstring(10) \"ISO-8859-1\"\n")
+	testInputOutputWithIni(t, []string{"mbstring.detect_order=ISO-8859-1"}, `<?php var_dump(mb_detect_encoding('abc'));`, "string(10) \"ISO-8859-1\"\n")
+
+	// mb_internal_encoding
+	testInputOutput(t, `<?php var_dump(mb_internal_encoding(), mb_internal_encoding('latin1'), mb_internal_encoding(), mb_strlen('ä'));`, "string(5) \"UTF-8\"\nbool(true)\nstring(10) \"ISO-8859-1\"\nint(2)\n")
+	testInputOutputWithIni(t, []string{"internal_encoding=ISO-8859-1"}, `<?php var_dump(mb_internal_encoding(), mb_strlen('ä'));`, "string(10) \"ISO-8859-1\"\nint(2)\n")
+	testForError(t, `<?php mb_internal_encoding('EBCDIC');`, phpError.NewError("Uncaught ValueError: mb_internal_encoding(): Argument #1 ($encoding) must be a valid encoding, \"EBCDIC\" given"))
+
+	// mb_str_pad
+	testInputOutput(t, `<?php var_dump(mb_str_pad('ñ', 5, 'äö', STR_PAD_BOTH), mb_str_pad('ñ', 4, '-', STR_PAD_LEFT), mb_str_pad('ñö', 1));`, "string(10) \"äöñäö\"\nstring(5) \"---ñ\"\nstring(4) \"ñö\"\n")
+	testForError(t, `<?php mb_str_pad('a', 5, '');`, phpError.NewError("Uncaught ValueError: mb_str_pad(): Argument #3 ($pad_string) must be a non-empty string"))
+	testForError(t, `<?php mb_str_pad('a', 5, ' ', 3);`, phpError.NewError("Uncaught ValueError: mb_str_pad(): Argument #4 ($pad_type) must be STR_PAD_LEFT, STR_PAD_RIGHT, or STR_PAD_BOTH"))
+
+	// mb_str_split
+	testInputOutput(t, `<?php var_dump(mb_str_split('añbç', 3), mb_str_split(''));`, "array(2) {\n  [0]=>\n  string(4) \"añb\"\n  [1]=>\n  string(2) \"ç\"\n}\narray(0) {\n}\n")
+	testForError(t, `<?php mb_str_split('a', 0);`, phpError.NewError("Uncaught ValueError: mb_str_split(): Argument #2 ($length) must be greater than 0"))
+
+	// mb_strimwidth / mb_strwidth
+	testInputOutput(t, `<?php var_dump(mb_strwidth('日本語abc'), mb_strimwidth('Hello World', 0, 10, '...'), mb_strimwidth('日本語テキスト', 0, 8, '…'), mb_strimwidth('Hello', -3, 5));`,
+		"int(9)\nstring(10) \"Hello W...\"\nstring(12) \"日本語…\"\nstring(3) \"llo\"\n")
+	testForError(t, `<?php mb_strimwidth('Hello', 6, 5);`, phpError.NewError("Uncaught ValueError: mb_strimwidth(): Argument #2 ($start) is out of range"))
+
+	// mb_strlen
+	testInputOutput(t, `<?php var_dump(mb_strlen('Hällo wörld'), mb_strlen('Hällo', 'ISO-8859-1'), mb_strlen("a\x00b\x00", 'UTF-16LE'));`, "int(11)\nint(6)\nint(2)\n")
+	testForError(t, `<?php mb_strlen('a', 'EBCDIC');`, phpError.NewError("Uncaught ValueError: mb_strlen(): Argument #2 ($encoding) must be a valid encoding, \"EBCDIC\" given"))
+
+	// mb_strpos family
+	testInputOutput(t, `<?php var_dump(mb_strpos('añbñ', 'ñ'), mb_strrpos('añbñ', 'ñ'), mb_stripos('AÑBÑ', 'ñ', 2), mb_strripos('añbñ', 'Ñ', -2), mb_strpos('añb', 'x'));`, "int(1)\nint(3)\nint(3)\nint(1)\nbool(false)\n")
+	testForError(t, `<?php mb_strpos('añb', 'a', 4);`, phpError.NewError("Uncaught ValueError: mb_strpos(): Argument #3 ($offset) must be contained in argument #1 ($haystack)"))
+
+	// mb_strstr family
+	testInputOutput(t, `<?php var_dump(mb_strstr('user@exämple', '@'), mb_strrchr('a/ä/c', '/'), mb_stristr('HÄLLO', 'ä', true), mb_strrichr('aÄbä', 'ä'));`, "string(9) \"@exämple\"\nstring(2) \"/c\"\nstring(1) \"H\"\nstring(2) \"ä\"\n")
+
+	// mb_strtolower / mb_strtoupper
+	testInputOutput(t, `<?php var_dump(mb_strtoupper('straße ǆ'), mb_strtolower('ΣΑΣ ΟΔΟΣ ÄÖÜ'));`, "string(10) \"STRASSE Ǆ\"\nstring(22) \"σας οδος äöü\"\n")
+
+	// mb_substr
+	testInputOutput(t, `<?php var_dump(mb_substr('Hällo wörld', 1, 4), mb_substr('Hällo', -3), mb_substr('Hällo', 1, -1), mb_substr('Hällo', 7), mb_substr('Hällo', 3, -4));`,
+		"string(5) \"ällo\"\nstring(3) \"llo\"\nstring(4) \"äll\"\nstring(0) \"\"\nstring(0) \"\"\n")
+
+	// mb_substr_count
+	testInputOutput(t, `<?php var_dump(mb_substr_count('ääää', 'ää'), mb_substr_count('añbñ', 'ñ'));`, "int(2)\nint(2)\n")
+	testForError(t, `<?php mb_substr_count('a', '');`, phpError.NewError("Uncaught ValueError: mb_substr_count(): Argument #2 ($needle) must not be empty"))
+}
+
+// -------------------------------------- hash -------------------------------------- MARK: hash
+
+func TestLibHash(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "data.txt")
+	if err := GoOs.WriteFile(filename, []byte("The quick brown fox jumps over the lazy dog"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// crc32 / md5 / sha1
+	testInputOutput(t, `<?php var_dump(crc32('The quick brown fox jumped over the lazy dog.'), md5(''), sha1('abc'));`,
+		"int(2191738434)\nstring(32) \"d41d8cd98f00b204e9800998ecf8427e\"\nstring(40) \"a9993e364706816aba3e25717850c26c9cd0d89d\"\n")
+	testInputOutput(t, `<?php var_dump(bin2hex(md5('abc', true)), strlen(sha1('abc', true)));`, "string(32) \"900150983cd24fb0d6963f7d28e17f72\"\nint(20)\n")
+	testInputOutput(t, fmt.Sprintf(`<?php echo md5_file("%s"), " ", sha1_file("%s");`, filename, filename),
+		"9e107d9d372bb6826bd81d3542a419d6 2fd4e1c67a2d28fced849ee1bb76e7391b93eb12")
+	testInputOutput(t, `<?php var_dump(md5_file('/does/not/exist'));`,
+		fmt.Sprintf("\nWarning: md5_file(/does/not/exist): Failed to open stream: No such file or directory in %s:1:16\nbool(false)\n", TEST_FILE_NAME))
+
+	// hash
+	testInputOutput(t, `<?php $s = 'The quick brown fox jumps over the lazy dog'; echo hash('sha256', $s), " ", hash('SHA3-256', $s), " ", hash('crc32b', $s), " ", hash('fnv1a64', $s);`,
+		"d7a8fbb307d7809469ca9abcb0082e4f8d5651e46d3cdb762d02d0bf37c9e592 69070dda01975c8c120c3aada1b282394e7f032fa9cf32f4cb2259a0897dfc04 414fa339 f3f9b7f5e7e47110")
+	testInputOutput(t, `<?php echo hash('crc32', 'a'), " ", hash('xxh3', ''), " ", hash('xxh128', 'abc'), " ", hash('xxh32', 'abc');`,
+		"6b9b9319 2d06800538d394c2 06b05ab6733a618578af5f94892f3950 32d153ff")
+	testInputOutput(t, `<?php echo hash('xxh64', 'abc', false, ['seed' => 0]) === hash('xxh64', 'abc') ? 'same' : 'different', hash('xxh64', 'abc', false, ['seed' => 1]) === hash('xxh64', 'abc') ? ' same' : ' different';`,
+		"same different")
+	testForError(t, `<?php hash('md4', 'a');`, phpError.NewError("Uncaught ValueError: hash(): Argument #1 ($algo) must be a valid hashing algorithm"))
+	testForError(t, `<?php hash('xxh3', 'a', false, ['secret' => 'short']);`, phpError.NewError("Uncaught Error: xxh3: Secret length must be >= 136 bytes, 5 bytes passed"))
+	testForError(t, `<?php hash('xxh128', 'a', false, ['seed' => 1, 'secret' => str_repeat('a', 136)]);`,
+		phpError.NewError("Uncaught Error: xxh128: Only one of seed or secret is to be passed for initialization"))
+
+	// hash_algos / hash_hmac_algos
+	testInputOutput(t, `<?php var_dump(count(hash_algos()), in_array('xxh128', hash_algos()), in_array('xxh128', hash_hmac_algos()), in_array('sha512/256', hash_hmac_algos()));`,
+		"int(25)\nbool(true)\nbool(false)\nbool(true)\n")
+
+	// hash_equals
+	testInputOutput(t, `<?php var_dump(hash_equals('abc', 'abc'), hash_equals('abc', 'abd'), hash_equals('abc', 'ab'));`, "bool(true)\nbool(false)\nbool(false)\n")
+	testForError(t, `<?php hash_equals(1, 'a');`, phpError.NewError("Uncaught TypeError: hash_equals(): Argument #1 ($known_string) must be of type string, int given"))
+
+	// hash_file / hash_hmac_file
+	testInputOutput(t, fmt.Sprintf(`<?php echo hash_file('sha1', "%s"), " ", hash_hmac_file('md5', "%s", 'key');`, filename, filename),
+		"2fd4e1c67a2d28fced849ee1bb76e7391b93eb12 80070713463e7749b90c2dc24911e275")
+	testInputOutput(t, `<?php var_dump(hash_file('md5', '/does/not/exist'));`,
+		fmt.Sprintf("\nWarning: hash_file(/does/not/exist): Failed to open stream: No such file or directory in %s:1:16\nbool(false)\n", TEST_FILE_NAME))
+
+	// hash_hmac
+	testInputOutput(t, `<?php echo hash_hmac('sha256', 'The quick brown fox jumps over the lazy dog', 'key'), " ", hash_hmac('md5', '', '');`,
+		"f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8 74e6f7298a9c2d168935f58c001bad88")
+	testForError(t, `<?php hash_hmac('crc32b', 'a', 'key');`, phpError.NewError("Uncaught ValueError: hash_hmac(): Argument #1 ($algo) must be a valid cryptographic hashing algorithm"))
+
+	// hash_init / hash_update / hash_copy / hash_final
+	testInputOutput(t, `<?php $c = hash_init('md5'); hash_update($c, 'The quick brown fox '); $d = hash_copy($c); $e = clone $c; hash_update($c, 'jumps over the lazy dog');
+		echo hash_final($c), " ", get_class($e), " ", hash_final($d) === md5('The quick brown fox ') && hash_final($e) === md5('The quick brown fox ') ? 'ok' : 'fail';`,
+		"9e107d9d372bb6826bd81d3542a419d6 HashContext ok")
+	testInputOutput(t, `<?php $c = hash_init('sha256', HASH_HMAC, 'key'); hash_update($c, 'The quick brown fox '); hash_update($c, 'jumps over the lazy dog'); echo hash_final($c);`,
+		"f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8")
+	testInputOutput(t, `<?php $c = hash_init('xxh32', 0, '', ['seed' => 42]); hash_update($c, 'abc'); echo hash_final($c) === hash('xxh32', 'abc', false, ['seed' => 42]) ? 'ok' : 'fail';`, "ok")
+	testForError(t, `<?php hash_init('crc32', HASH_HMAC, 'key');`,
+		phpError.NewError("Uncaught ValueError: hash_init(): Argument #1 ($algo) must be a cryptographic hashing algorithm if HMAC is requested"))
+	testForError(t, `<?php hash_init('md5', HASH_HMAC);`, phpError.NewError("Uncaught ValueError: hash_init(): Argument #3 ($key) cannot be empty when HMAC is requested"))
+	testForError(t, `<?php $c = hash_init('md5'); hash_final($c); hash_update($c, 'a');`,
+		phpError.NewError("Uncaught TypeError: hash_update(): Argument #1 ($context) must be a valid, non-finalized HashContext"))
+	testForError(t, `<?php hash_final(new stdClass());`, phpError.NewError("Uncaught TypeError: hash_final(): Argument #1 ($context) must be of type HashContext, stdClass given"))
+
+	// hash_hkdf
+	testInputOutput(t, `<?php var_dump(bin2hex(hash_hkdf('sha256', "\x0b\x0b\x0b\x0b\x0b\x0b\x0b\x0b\x0b\x0b\x0b\x0b\x0b\x0b\x0b\x0b\x0b\x0b\x0b\x0b\x0b\x0b", 42, "\xf0\xf1\xf2\xf3\xf4\xf5\xf6\xf7\xf8\xf9", "\x00\x01\x02\x03\x04\x05\x06\x07\x08\x09\x0a\x0b\x0c")), strlen(hash_hkdf('sha1', 'key')));`,
+		"string(84) \"3cb25f25faacd57a90434f64d0362f2a2d2d0a90cf1a5a4c5db02d56ecc4c5bf34007208d5b887185865\"\nint(20)\n")
+	testForError(t, `<?php hash_hkdf('sha256', '');`, phpError.NewError("Uncaught ValueError: hash_hkdf(): Argument #2 ($key) cannot be empty"))
+
+	// hash_pbkdf2
+	testInputOutput(t, `<?php var_dump(hash_pbkdf2('sha1', 'password', 'salt', 2, 20), bin2hex(hash_pbkdf2('sha1', 'password', 'salt', 2, 20, true)), hash_pbkdf2('sha256', 'password', 'salt', 1));`,
+		"string(20) \"ea6c014dc72d6f8ccd1e\"\nstring(40) \"ea6c014dc72d6f8ccd1ed92ace1d41f0d8de8957\"\nstring(64) \"120fb6cffcf8b32c43e7225256c4f837a86548c92ccc35480805987cb70be17b\"\n")
+	testForError(t, `<?php hash_pbkdf2('sha1', 'password', 'salt', 0);`, phpError.NewError("Uncaught ValueError: hash_pbkdf2(): Argument #4 ($iterations) must be greater than 0"))
+	testForError(t, `<?php hash_pbkdf2('sha1', 'password', 'salt', 1, -1);`, phpError.NewError("Uncaught ValueError: hash_pbkdf2(): Argument #5 ($length) must be greater than or equal to 0"))
+}
+
+// -------------------------------------- password -------------------------------------- MARK: password
+
+func TestLibPassword(t *testing.T) {
+	// crypt
+	testInputOutput(t, `<?php echo crypt('rasmuslerdorf', '$1$rasmusle$'), " ", crypt('rasmuslerdorf', '$2y$07$usesomesillystringforsalt$');`,
+		"$1$rasmusle$rISCgZzpwk3UhDidwXvin0 $2y$07$usesomesillystringfore2uDLvp1Ii2e./U9C8sBjqp8I90dH6hi")
+	testInputOutput(t, `<?php echo crypt('rasmuslerdorf', '$5$rounds=5000$usesomesillystringforsalt$');`,
+		"$5$rounds=5000$usesomesillystri$KqJWpanXZHKq2BOB43TSaYhEWsQ1Lr5QNyPCDH/Tp.6")
+	testInputOutput(t, `<?php echo crypt('rasmuslerdorf', '$2y$99$usesomesillystringforsalt$'), " ", crypt('rasmuslerdorf', '*0');`, "*0 *1")
+	testInputOutput(t, `<?php echo crypt('rasmuslerdorf', 'rl'), " ", crypt('rasmuslerdorf', '_J9..rasm'), " ", CRYPT_STD_DES, CRYPT_EXT_DES;`,
+		"rl.3StKT.4T8M _J9..rasmBYk8r9AiWNc 11")
+
+	// password_algos
+	testInputOutput(t, `<?php echo implode(',', password_algos());`, "2y,argon2i,argon2id")
+
+	// password_get_info
+	testInputOutput(t, `<?php var_dump(password_get_info('$2y$10$.vGA1O9wmRjrwAVXD98HNOgsNpDczlqm3Jq7KnEd1rVAGv3Fykk1a'));`,
+		"array(3) {\n  [\"algo\"]=>\n  string(2) \"2y\"\n  [\"algoName\"]=>\n  string(6) \"bcrypt\"\n  [\"options\"]=>\n  array(1) {\n    [\"cost\"]=>\n    int(10)\n  }\n}\n")
+	testInputOutput(t, `<?php var_dump(password_get_info('$argon2id$v=19$m=1024,t=2,p=1$c29tZXNhbHQ$aGFzaA'));`,
+		"array(3) {\n  [\"algo\"]=>\n  string(8) \"argon2id\"\n  [\"algoName\"]=>\n  string(8) \"argon2id\"\n  [\"options\"]=>\n  array(3) {\n    [\"memory_cost\"]=>\n    int(1024)\n    [\"time_cost\"]=>\n    int(2)\n    [\"threads\"]=>\n    int(1)\n  }\n}\n")
+	testInputOutput(t, `<?php var_dump(password_get_info('$1$rasmusle$rISCgZzpwk3UhDidwXvin0'));`,
+		"array(3) {\n  [\"algo\"]=>\n  NULL\n  [\"algoName\"]=>\n  string(7) \"unknown\"\n  [\"options\"]=>\n  array(0) {\n  }\n}\n")
+
+	// password_hash / password_verify
+	testInputOutput(t, `<?php $h = password_hash('secret', PASSWORD_BCRYPT, ['cost' => 4]); var_dump(substr($h, 0, 7), strlen($h), password_verify('secret', $h), password_verify('Secret', $h));`,
+		"string(7) \"$2y$04$\"\nint(60)\nbool(true)\nbool(false)\n")
+	testInputOutput(t, `<?php $h = password_hash('secret', PASSWORD_ARGON2ID, ['memory_cost' => 256, 'time_cost' => 1]); var_dump(substr($h, 0, 29), password_verify('secret', $h), password_verify('Secret', $h));`,
+		"string(29) \"$argon2id$v=19$m=256,t=1,p=1$\"\nbool(true)\nbool(false)\n")
+	testInputOutput(t, `<?php var_dump(password_verify('rasmuslerdorf', '$2y$10$.vGA1O9wmRjrwAVXD98HNOgsNpDczlqm3Jq7KnEd1rVAGv3Fykk1a'), password_verify('rasmuslerdorf', '$1$rasmusle$rISCgZzpwk3UhDidwXvin0'));`,
+		"bool(true)\nbool(true)\n")
+	testInputOutput(t, `<?php var_dump(password_verify('rasmuslerdorf', 'rl.3StKT.4T8M'), password_verify('rasmuslerdorf', '_J9..rasmBYk8r9AiWNc'));`, "bool(true)\nbool(true)\n")
+	testInputOutput(t, `<?php var_dump(password_verify('password', '$argon2i$v=19$m=65536,t=2,p=4$c29tZXNhbHQ$RdescudvJCsgt3ub+b+dWRWJTmaaJObG'));`, "bool(true)\n")
+	testInputOutput(t, `<?php $h = password_hash('a', PASSWORD_BCRYPT, ['cost' => 4, 'salt' => 'abcdefghijklmnopqrstuv']);`,
+		fmt.Sprintf("\nWarning: password_hash(): The \"salt\" option has been ignored, since providing a custom salt is no longer supported in %s:1:12\n", TEST_FILE_NAME))
+	testForError(t, `<?php password_hash('a', 'md5');`, phpError.NewError("Uncaught ValueError: password_hash(): Argument #2 ($algo) must be a valid password hashing algorithm"))
+	testForError(t, `<?php password_hash('a', PASSWORD_BCRYPT, ['cost' => 3]);`, phpError.NewError("Uncaught ValueError: Invalid bcrypt cost parameter specified: 3"))
+	testForError(t, `<?php password_hash('a', PASSWORD_ARGON2ID, ['threads' => 0]);`, phpError.NewError("Uncaught ValueError: Invalid number of threads"))
+
+	// password_needs_rehash
+	testInputOutput(t, `<?php $h = '$2y$10$.vGA1O9wmRjrwAVXD98HNOgsNpDczlqm3Jq7KnEd1rVAGv3Fykk1a';
+		var_dump(password_needs_rehash($h, PASSWORD_DEFAULT), password_needs_rehash($h, PASSWORD_BCRYPT, ['cost' => 12]), password_needs_rehash($h, PASSWORD_ARGON2ID), password_needs_rehash($h, 'md5'));`,
+		"bool(false)\nbool(true)\nbool(true)\nbool(false)\n")
+	testInputOutput(t, `<?php $h = '$argon2id$v=19$m=65536,t=4,p=1$c29tZXNhbHQ$aGFzaA';
+		var_dump(password_needs_rehash($h, PASSWORD_ARGON2ID), password_needs_rehash($h, PASSWORD_ARGON2ID, ['time_cost' => 3]));`,
+		"bool(false)\nbool(true)\n")
+}
+
+// -------------------------------------- random -------------------------------------- MARK: random
+
+func TestLibRandom(t *testing.T) {
+	// random_bytes
+	testInputOutput(t, `<?php var_dump(strlen(random_bytes(16)), random_bytes(32) === random_bytes(32));`, "int(16)\nbool(false)\n")
+	testForError(t, `<?php random_bytes(0);`, phpError.NewError("Uncaught ValueError: random_bytes(): Argument #1 ($length) must be greater than 0"))
+
+	// random_int
+	testInputOutput(t, `<?php $i = random_int(-3, 3); var_dump(random_int(5, 5), $i >= -3 && $i <= 3, is_int(random_int(PHP_INT_MIN, PHP_INT_MAX)));`, "int(5)\nbool(true)\nbool(true)\n")
+	testForError(t, `<?php random_int(2, 1);`, phpError.NewError("Uncaught ValueError: random_int(): Argument #1 ($min) must be less than or equal to argument #2 ($max)"))
+}
+
+// -------------------------------------- JSON -------------------------------------- MARK: JSON
+
+func TestLibJson(t *testing.T) {
+	// json_encode
+	testInputOutput(t, `<?php echo json_encode([1, 2.5, "a/b", "ä", true, null, 10.0]);`, `[1,2.5,"a\/b","\u00e4",true,null,10]`)
+	testInputOutput(t, `<?php echo json_encode([1 => 'a', 2 => 'b']), json_encode([]), json_encode([3 => 'x'], JSON_FORCE_OBJECT), json_encode([], JSON_FORCE_OBJECT);`,
+		`{"1":"a","2":"b"}[]{"3":"x"}{}`,
+	)
+	testInputOutput(t, `<?php echo json_encode(["a" => 1, "b" => [1, 2], "c" => []], JSON_PRETTY_PRINT);`,
+		"{\n    \"a\": 1,\n    \"b\": [\n        1,\n        2\n    ],\n    \"c\": []\n}",
+	)
+	testInputOutput(t, `<?php echo json_encode("ä😀/", JSON_UNESCAPED_UNICODE | JSON_UNESCAPED_SLASHES), json_encode("😀\n\x01");`, `"ä😀/""\ud83d\ude00\n\u0001"`)
+	testInputOutput(t, `<?php echo json_encode("<>'&\"", JSON_HEX_TAG | JSON_HEX_APOS | JSON_HEX_AMP | JSON_HEX_QUOT);`, `"\u003C\u003E\u0027\u0026\u0022"`)
+	testInputOutput(t, `<?php echo json_encode(10.0, JSON_PRESERVE_ZERO_FRACTION), ' ', json_encode(0.1 + 0.2), ' ', json_encode(1e25);`, "10.0 0.30000000000000004 1.0e+25")
+	testInputOutput(t,
+		`<?php class P implements JsonSerializable { public $a = 1; function jsonSerialize(): mixed { return ['x' => $this->a]; } }
+		class Q { public $a = 1; protected $b = 2; private $c = 3; }
+		class R implements JsonSerializable { public $r = 1; function jsonSerialize(): mixed { return $this; } }
+		$q = new Q(); $q->d = "e";
+		echo json_encode([new P(), $q, new R(), new stdClass()]);`,
+		`[{"x":1},{"a":1,"d":"e"},{"r":1},{}]`,
+	)
+	testInputOutput(t, `<?php var_dump(json_encode(NAN), json_last_error(), json_last_error_msg(), json_encode(INF, JSON_PARTIAL_OUTPUT_ON_ERROR));`,
+		"bool(false)\nint(7)\nstring(34) \"Inf and NaN cannot be JSON encoded\"\nstring(1) \"0\"\n",
+	)
+	testInputOutput(t, `<?php var_dump(json_encode("\xff"), json_last_error_msg(), json_encode("a\xffb", JSON_INVALID_UTF8_SUBSTITUTE), json_encode("a\xffb", JSON_INVALID_UTF8_IGNORE));`,
+		"bool(false)\nstring(56) \"Malformed UTF-8 characters, possibly incorrectly encoded\"\nstring(10) \"\"a\\ufffdb\"\"\nstring(4) \"\"ab\"\"\n",
+	)
+	testInputOutput(t, `<?php var_dump(json_encode([[1]], 0, 1), json_last_error_msg(), json_encode([1], 0, 1));`,
+		"bool(false)\nstring(28) \"Maximum stack depth exceeded\"\nstring(3) \"[1]\"\n",
+	)
+	testInputOutput(t, `<?php $o = new stdClass(); $o->self = $o; var_dump(json_encode($o), json_last_error_msg(), json_encode($o, JSON_PARTIAL_OUTPUT_ON_ERROR));`,
+		"bool(false)\nstring(18) \"Recursion detected\"\nstring(13) \"{\"self\":null}\"\n",
+	)
+
+	// json_decode
+	testInputOutput(t, `<?php $o = json_decode('{"a":{"b":[1,2,{"c":null}]},"d":1.0,"e":"ä😀"}'); var_dump($o->a->b[2]->c, $o->d, $o->e); echo get_class($o);`,
+		"NULL\nfloat(1)\nstring(6) \"ä😀\"\nstdClass",
+	)
+	testInputOutput(t, `<?php var_dump(json_decode('{"a":{"b":[1,2]},"0":3}', true));`,
+		"array(2) {\n  [\"a\"]=>\n  array(1) {\n    [\"b\"]=>\n    array(2) {\n      [0]=>\n      int(1)\n      [1]=>\n      int(2)\n    }\n  }\n  [0]=>\n  int(3)\n}\n",
+	)
+	testInputOutput(t, `<?php var_dump(json_decode(' "a\/b\t" '), json_decode('-0'), json_decode('-1.5e2'), json_decode('true'), json_decode('null'));`,
+		"string(4) \"a/b\t\"\nint(0)\nfloat(-150)\nbool(true)\nNULL\n",
+	)
+	testInputOutput(t, `<?php var_dump(json_decode('12345678901234567890'), json_decode('12345678901234567890', false, 512, JSON_BIGINT_AS_STRING));`,
+		"float(1.2345678901234567E+19)\nstring(20) \"12345678901234567890\"\n",
+	)
+	testInputOutput(t, `<?php var_dump(json_decode('{"a":1}', null, 512, JSON_OBJECT_AS_ARRAY)["a"], json_decode('{"a":1}', false, 512, JSON_OBJECT_AS_ARRAY)->a);`, "int(1)\nint(1)\n")
+	testInputOutput(t, `<?php var_dump(json_decode('[1,]'), json_last_error(), json_last_error_msg()); json_decode('[1]'); var_dump(json_last_error());`,
+		"NULL\nint(4)\nstring(12) \"Syntax error\"\nint(0)\n",
+	)
+	testInputOutput(t, `<?php foreach (['', '[[1]]', '"\ud800"', "\"\x01\"", "\"\xff\"", '{"\u0000a":1}', "[1]\x00"] as $json) { json_decode($json, false, 1); echo json_last_error(), ' '; }`,
+		"4 1 10 3 5 9 3 ",
+	)
+	testForError(t, `<?php json_decode('{', false, 512, JSON_THROW_ON_ERROR);`, phpError.NewError("Uncaught JsonException: Syntax error"))
+	testForError(t, `<?php json_decode('1', false, 0);`, phpError.NewError("Uncaught ValueError: json_decode(): Argument #3 ($depth) must be greater than 0"))
+
+	// json_validate
+	testInputOutput(t, `<?php var_dump(json_validate('{"a":[1, 2]}'), json_validate('{a:1}'), json_last_error_msg(), json_validate('[[1]]', 1));`,
+		"bool(true)\nbool(false)\nstring(12) \"Syntax error\"\nbool(false)\n",
+	)
+	testForError(t, `<?php json_validate('1', 512, JSON_BIGINT_AS_STRING);`,
+		phpError.NewError("Uncaught ValueError: json_validate(): Argument #3 ($flags) must be a valid flag (allowed flags: JSON_INVALID_UTF8_IGNORE)"),
+	)
+}
+
+// -------------------------------------- PCRE -------------------------------------- MARK: PCRE
+
+func TestLibPcre(t *testing.T) {
+	// preg_match
+	testInputOutput(t, `<?php var_dump(preg_match('/(\d+)-(\d+)/', 'tel: 030-1234', $m)); print_r($m);`,
+		"int(1)\nArray\n(\n    [0] => 030-1234\n    [1] => 030\n    [2] => 1234\n)\n",
+	)
+	testInputOutput(t, `<?php var_dump(preg_match('/xyz/', 'abc', $m), $m);`, "int(0)\narray(0) {\n}\n")
+	testInputOutput(t, `<?php preg_match('/(?<year>\d{4})-(?<month>\d\d)/', '2024-05', $m); print_r($m);`,
+		"Array\n(\n    [0] => 2024-05\n    [year] => 2024\n    [1] => 2024\n    [month] => 05\n    [2] => 05\n)\n",
+	)
+	testInputOutput(t, `<?php preg_match('/(a)(b)?(c)?/', 'a', $m); var_dump(count($m)); preg_match('/(a)(b)?/', 'a', $m, PREG_UNMATCHED_AS_NULL); var_dump($m[2]);`,
+		"int(2)\nNULL\n",
+	)
+	testInputOutput(t, `<?php preg_match('/b+/', 'abbc', $m, PREG_OFFSET_CAPTURE); echo $m[0][0], ' ', $m[0][1];`, "bb 1")
+	testInputOutput(t, `<?php echo preg_match('/a/', 'aba', $m, 0, 1), preg_match('/^a/', 'ba', $m, 0, -1), preg_match('/ABC/i', 'xabc');`, "101")
+	testInputOutput(t, `<?php echo preg_match('/^\w+$/u', 'größe'), preg_match('/^.$/u', 'ä'), preg_match('/^.$/', 'ä');`, "110")
+	testForError(t, `<?php preg_match('/a/', 'a', $m, 1);`, phpError.NewError("Uncaught ValueError: preg_match(): Argument #4 ($flags) must be a PREG_* constant"))
+
+	// Invalid patterns
+	testInputOutput(t, `<?php var_dump(preg_match('/abc', 'abc'));`,
+		"\nWarning: preg_match(): No ending delimiter '/' found in /home/admin/test.php:1:16\nbool(false)\n",
+	)
+	testInputOutput(t, `<?php var_dump(preg_match('abc', 'abc'));`,
+		"\nWarning: preg_match(): Delimiter must not be alphanumeric, backslash, or NUL byte in /home/admin/test.php:1:16\nbool(false)\n",
+	)
+	testInputOutput(t, `<?php var_dump(preg_match('/abc/k', 'abc'));`,
+		"\nWarning: preg_match(): Unknown modifier 'k' in /home/admin/test.php:1:16\nbool(false)\n",
+	)
+	testInputOutput(t, `<?php var_dump(preg_match('/(abc/', 'abc'));`,
+		"\nWarning: preg_match(): Compilation failed: missing closing parenthesis at offset 4 in /home/admin/test.php:1:16\nbool(false)\n",
+	)
+	testInputOutput(t, `<?php echo preg_match('{a{2}}', 'aa'), preg_match('(a(b)c)', 'abc'), preg_match('#a/b#', 'a/b');`, "111")
+
+	// preg_match_all
+	testInputOutput(t, `<?php var_dump(preg_match_all('/\d/', 'a1b2c3', $m)); echo implode(',', $m[0]);`, "int(3)\n1,2,3")
+	testInputOutput(t, `<?php preg_match_all('/(?<l>\w)(\d)/', 'a1 b2', $m); echo implode(',', $m[0]), ' ', implode(',', $m['l']), ' ', implode(',', $m[2]);`, "a1,b2 a,b 1,2")
+	testInputOutput(t, `<?php preg_match_all('/(\w)(\d)/', 'a1 b2', $m, PREG_SET_ORDER); echo $m[0][0], $m[1][1], $m[1][2];`, "a1b2")
+	testInputOutput(t, `<?php echo preg_match_all('/a*/', 'baa', $m), ' ', implode('|', $m[0]);`, "3 |aa|")
+	testInputOutput(t, `<?php echo preg_match_all('/x/', 'abc', $m), count($m), count($m[0]);`, "010")
+
+	// preg_replace
+	testInputOutput(t, `<?php echo preg_replace('/(\w+) (\w+)/', '$2 ${1}x \2', 'hello world');`, "world hellox world")
+	testInputOutput(t, `<?php echo preg_replace('/a/', 'b', 'aaaa', 2, $count), $count;`, "bbaa2")
+	testInputOutput(t, `<?php echo preg_replace(['/a/', '/b/'], ['b', 'c'], 'ab');`, "cc")
+	testInputOutput(t, `<?php echo preg_replace(['/a/', '/b/'], ['x'], 'ab');`, "x")
+	testInputOutput(t, `<?php print_r(preg_replace('/\d/', '#', ['x' => 'a1', 'y' => '22']));`, "Array\n(\n    [x] => a#\n    [y] => ##\n)\n")
+	testInputOutput(t, `<?php echo preg_replace('/x*/', '-', 'abc');`, "-a-b-c-")
+	testInputOutput(t, `<?php echo preg_replace('/a/', '\\\\$0 \$0', 'a');`, `\a $0`)
+	testForError(t, `<?php preg_replace('/a/', ['b'], 'a');`,
+		phpError.NewError("Uncaught TypeError: preg_replace(): Argument #1 ($pattern) must be of type array when argument #2 ($replacement) is an array, string given"),
+	)
+
+	// preg_replace_callback
+	testInputOutput(t, `<?php function up($m) { return strtoupper($m[1]); } echo preg_replace_callback('/-(\w)/', 'up', 'foo-bar-baz', -1, $count), $count;`, "fooBarBaz2")
+	testInputOutput(t, `<?php function twice($m) { return $m[0] * 2; } echo preg_replace_callback('/\d+/', 'twice', 'a 1 b 21', 1);`, "a 2 b 21")
+	testInputOutput(t, `<?php class C { static function up($m) { return strtoupper($m[0]); } } echo preg_replace_callback('/b+/', 'C::up', 'abbc'), preg_replace_callback('/a/', ['C', 'up'], 'abc');`, "aBBcAbc")
+	testForError(t, `<?php preg_replace_callback('/a/', 'unknown', 'a');`,
+		phpError.NewError("Uncaught TypeError: preg_replace_callback(): Argument #2 ($callback) must be a valid callback, function \"unknown\" not found or invalid function name"),
+	)
+
+	// preg_split
+	testInputOutput(t, `<?php echo implode('|', preg_split('/[\s,]+/', "a, b  c,d"));`, "a|b|c|d")
+	testInputOutput(t, `<?php echo implode('|', preg_split('//', 'abc', -1, PREG_SPLIT_NO_EMPTY));`, "a|b|c")
+	testInputOutput(t, `<?php echo implode('|', preg_split('/(-)/', 'a-b-c', 2, PREG_SPLIT_DELIM_CAPTURE));`, "a|-|b-c")
+	testInputOutput(t, `<?php echo implode('|', preg_split('/,/', ',a,,b,'));`, "|a||b|")
+	testInputOutput(t, `<?php $p = preg_split('/ /', 'ab cd', -1, PREG_SPLIT_OFFSET_CAPTURE); echo $p[1][0], $p[1][1];`, "cd3")
+
+	// preg_quote
+	testInputOutput(t, `<?php echo preg_quote('Hello.World?(1+1=2)'), ' ', preg_quote('a/b#c', '/');`, `Hello\.World\?\(1\+1\=2\) a\/b\#c`)
+
+	// preg_grep
+	testInputOutput(t, `<?php print_r(preg_grep('/^\d+$/', ['1', 'a', 5 => '23']));`, "Array\n(\n    [0] => 1\n    [5] => 23\n)\n")
+	testInputOutput(t, `<?php print_r(preg_grep('/^\d+$/', ['1', 'a'], PREG_GREP_INVERT));`, "Array\n(\n    [1] => a\n)\n")
+
+	// preg_last_error
+	testInputOutput(t, `<?php var_dump(preg_match('/(a+)+b/', str_repeat('a', 30))); echo preg_last_error(), ' ', preg_last_error_msg();`,
+		"bool(false)\n2 Backtrack limit exhausted",
+	)
+	testInputOutputWithIni(t, []string{"pcre.backtrack_limit=10"}, `<?php var_dump(preg_match('/a+b/', 'aaaaaaaaaaaaaaaaaaaaaaaaa')); echo preg_last_error_msg();`,
+		"bool(false)\nBacktrack limit exhausted",
+	)
+	testInputOutput(t, `<?php var_dump(preg_match('/./u', "\xff")); echo preg_last_error(), ' ', preg_last_error_msg(); preg_match('/a/', 'a'); echo ' ', preg_last_error();`,
+		"bool(false)\n4 Malformed UTF-8 characters, possibly incorrectly encoded 0",
+	)
+	testInputOutput(t, `<?php var_dump(preg_match('/./u', "ä", $m, 0, 1)); echo preg_last_error_msg();`,
+		"bool(false)\nThe offset did not correspond to the beginning of a valid UTF-8 code point",
+	)
 }
 
 // -------------------------------------- get_debug_type -------------------------------------- MARK: get_debug_type
@@ -222,6 +1103,25 @@ func TestLibGetDebugType(t *testing.T) {
 	testInputOutput(t, `<?php echo get_debug_type([]);`, "array")
 	testInputOutput(t, `<?php echo get_debug_type([42]);`, "array")
 	testInputOutput(t, `<?php echo get_debug_type(null);`, "null")
+	testInputOutput(t, `<?php $p = proc_open("true", [], $pipes); echo get_debug_type($p);`, "resource (process)")
+	testInputOutput(t, `<?php $p = proc_open("true", [], $pipes); proc_close($p); echo get_debug_type($p);`, "resource (closed)")
+}
+
+// -------------------------------------- get_defined_vars -------------------------------------- MARK: get_defined_vars
+
+func TestLibGetDefinedVars(t *testing.T) {
+	testInputOutput(t,
+		`<?php function f($a) { $b = 2; $$a = 3; foreach (get_defined_vars() as $k => $v) { echo $k, "=", $v, " "; } } f("c");`,
+		"a=c b=2 c=3 ",
+	)
+	testInputOutput(t,
+		`<?php class C { function m($p) { $q = $p * 2; return get_defined_vars(); } } print_r((new C())->m(21));`,
+		"Array\n(\n    [p] => 21\n    [q] => 42\n)\n",
+	)
+	testInputOutput(t,
+		`<?php $a = 1; $b = 2; unset($a); foreach (get_defined_vars() as $k => $v) { echo $k, " "; }`,
+		"_GET _POST _COOKIE _FILES _ENV _REQUEST _SERVER b ",
+	)
 }
 
 // -------------------------------------- gettype -------------------------------------- MARK: gettype
@@ -240,6 +1140,23 @@ func TestLibGettype(t *testing.T) {
 	testInputOutput(t, `<?php echo gettype([]);`, "array")
 	testInputOutput(t, `<?php echo gettype([42]);`, "array")
 	testInputOutput(t, `<?php echo gettype(null);`, "NULL")
+	testInputOutput(t, `<?php $p = proc_open("true", [], $pipes); echo gettype($p);`, "resource")
+	testInputOutput(t, `<?php $p = proc_open("true", [], $pipes); proc_close($p); echo gettype($p);`, "resource (closed)")
+}
+
+// -------------------------------------- get_resource_XXX -------------------------------------- MARK: get_resource_XXX
+
+func TestLibGetResource(t *testing.T) {
+	// get_resource_id
+	testInputOutput(t, `<?php $p = proc_open("true", [1 => ["pipe", "w"]], $pipes); echo get_resource_id($p), " ", get_resource_id($pipes[1]);`, "2 1")
+
+	// get_resource_type
+	testInputOutput(t, `<?php $p = proc_open("true", [1 => ["pipe", "w"]], $pipes); echo get_resource_type($p), " ", get_resource_type($pipes[1]);`, "process stream")
+	testInputOutput(t, `<?php $p = proc_open("true", [], $pipes); proc_close($p); echo get_resource_type($p);`, "Unknown")
+
+	// Conversions
+	testInputOutput(t, `<?php $p = proc_open("true", [], $pipes); var_dump((int)$p, (string)$p, (bool)$p, (float)$p);`, "int(1)\nstring(14) \"Resource id #1\"\nbool(true)\nfloat(1)\n")
+	testInputOutput(t, `<?php $p = proc_open("true", [], $pipes); print_r((array)$p);`, "Array\n(\n    [0] => Resource id #1\n)\n")
 }
 
 // -------------------------------------- is_XXX -------------------------------------- MARK: is_XXX
@@ -265,6 +1182,11 @@ func TestLibIsType(t *testing.T) {
 	testInputOutput(t, `<?php $a = null; var_dump(is_null($a));`, "bool(true)\n")
 	testInputOutput(t, `<?php $a = 42; var_dump(is_null($a));`, "bool(false)\n")
 
+	// is_resource
+	testInputOutput(t, `<?php $a = proc_open("true", [], $pipes); var_dump(is_resource($a));`, "bool(true)\n")
+	testInputOutput(t, `<?php $a = proc_open("true", [], $pipes); proc_close($a); var_dump(is_resource($a));`, "bool(false)\n")
+	testInputOutput(t, `<?php $a = 42; var_dump(is_resource($a));`, "bool(false)\n")
+
 	// is_scalar
 	testInputOutput(t, `<?php $a = true; var_dump(is_scalar($a));`, "bool(true)\n")
 	testInputOutput(t, `<?php $a = false; var_dump(is_scalar($a));`, "bool(true)\n")
@@ -293,6 +1215,85 @@ func TestLibPrintR(t *testing.T) {
 	testInputOutput(t, `<?php print_r([1, [1]]);`, "Array\n(\n    [0] => 1\n    [1] => Array\n        (\n            [0] => 1\n        )\n\n)\n")
 }
 
+// -------------------------------------- serialize -------------------------------------- MARK: serialize
+
+func TestLibSerialize(t *testing.T) {
+	// Scalars and arrays
+	testInputOutput(t, `<?php echo serialize(null), serialize(true), serialize(false), serialize(42), serialize(-1.5), serialize("abc");`,
+		`N;b:1;b:0;i:42;d:-1.5;s:3:"abc";`)
+	testInputOutput(t, `<?php echo serialize(INF), serialize(-INF), serialize(NAN), serialize(0.1);`, "d:INF;d:-INF;d:NAN;d:0.1;")
+	testInputOutput(t, `<?php echo serialize([1, "a" => [true]]);`, `a:2:{i:0;i:1;s:1:"a";a:1:{i:0;b:1;}}`)
+	testInputOutput(t, `<?php echo serialize("hé");`, `s:3:"hé";`)
+
+	// Objects
+	testInputOutput(t, `<?php class A { public $a = 1; protected $b = 2; private $c = 3; } echo serialize(new A());`,
+		"O:1:\"A\":3:{s:1:\"a\";i:1;s:4:\"\x00*\x00b\";i:2;s:4:\"\x00A\x00c\";i:3;}")
+	testInputOutput(t, `<?php class A { public $a = 1; } $a = new A(); echo serialize([$a, $a]);`,
+		`a:2:{i:0;O:1:"A":1:{s:1:"a";i:1;}i:1;r:2;}`)
+	testInputOutput(t, `<?php class A { public $a = 1; public $b = 2; public function __sleep() { return ["b"]; } } echo serialize(new A());`,
+		`O:1:"A":1:{s:1:"b";i:2;}`)
+	testInputOutput(t, `<?php class A { public $a = 1; public function __sleep() { return ["x"]; } } echo serialize(new A());`,
+		"\nWarning: serialize(): \"x\" returned as member variable from __sleep() but does not exist in /home/admin/test.php:1:83\n"+`O:1:"A":1:{s:1:"x";N;}`)
+	testInputOutput(t, `<?php class A { public function __sleep() { return 1; } } echo serialize(new A());`,
+		"\nWarning: serialize(): __sleep should return an array only containing the names of instance-variables to serialize in /home/admin/test.php:1:64\nN;")
+	testInputOutput(t, `<?php class A { public $a = 1; public function __serialize() { return ["x" => 2]; } } echo serialize(new A());`,
+		`O:1:"A":1:{s:1:"x";i:2;}`)
+	testForError(t, `<?php class A { public function __serialize() { return 1; } } serialize(new A());`,
+		phpError.NewError("Uncaught TypeError: A::__serialize() must return an array"))
+	testInputOutput(t, `<?php class A implements Serializable { public function serialize() { return "abc"; } public function unserialize($data) { echo $data; } }
+		echo serialize(new A()), "\n"; unserialize(serialize(new A()));`,
+		"C:1:\"A\":3:{abc}\nabc")
+
+	// unserialize
+	testInputOutput(t, `<?php var_dump(unserialize('N;'), unserialize('b:1;'), unserialize('i:-5;'), unserialize('i:+5;'), unserialize('d:0.5;'), unserialize('s:3:"abc";'));`,
+		"NULL\nbool(true)\nint(-5)\nint(5)\nfloat(0.5)\nstring(3) \"abc\"\n")
+	testInputOutput(t, `<?php var_dump(unserialize('a:2:{i:0;i:1;s:1:"a";a:1:{i:0;b:1;}}'));`,
+		"array(2) {\n  [0]=>\n  int(1)\n  [\"a\"]=>\n  array(1) {\n    [0]=>\n    bool(true)\n  }\n}\n")
+	testInputOutput(t, `<?php var_dump(unserialize('a:2:{i:0;s:1:"x";i:1;r:2;}'));`,
+		"array(2) {\n  [0]=>\n  string(1) \"x\"\n  [1]=>\n  string(1) \"x\"\n}\n")
+	testInputOutput(t, `<?php class A { public $a = 1; protected $b = 2; private $c = 3; public function get() { return $this->a . $this->b . $this->c; } }
+		echo unserialize(serialize(new A()))->get(), "\n"; echo unserialize('O:1:"A":1:{s:4:"`+"\x00A\x00"+`c";i:9;}')->get();`,
+		"123\n129")
+	testInputOutput(t, `<?php class A { public $a = 1; public function __construct() { echo "ctor"; } public function __wakeup() { echo "wakeup "; } }
+		echo unserialize('O:1:"A":1:{s:1:"a";i:5;}')->a;`,
+		"wakeup 5")
+	testInputOutput(t, `<?php class A { public $a = 1; public function __unserialize($data) { echo "unserialize ", $data["x"], " "; $this->a = $data["x"]; } }
+		echo unserialize('O:1:"A":1:{s:1:"x";i:7;}')->a;`,
+		"unserialize 7 7")
+	testInputOutput(t, `<?php $a = unserialize('O:3:"Foo":1:{s:1:"a";i:1;}'); echo get_class($a), " ", serialize($a);`,
+		`__PHP_Incomplete_Class O:3:"Foo":1:{s:1:"a";i:1;}`)
+	testInputOutput(t, `<?php class A { public $a = 1; } echo get_class(unserialize(serialize(new A()), ["allowed_classes" => false]));`,
+		"__PHP_Incomplete_Class")
+	testInputOutput(t, `<?php class A { public $a = 1; } echo get_class(unserialize(serialize(new A()), ["allowed_classes" => ["a"]]));`, "A")
+	testInputOutput(t, `<?php class A { public $a = 1; } echo get_class(unserialize(serialize(new A()), ["allowed_classes" => ["B"]]));`,
+		"__PHP_Incomplete_Class")
+	testForError(t, `<?php unserialize('N;', ["allowed_classes" => 1]);`,
+		phpError.NewError(`Uncaught TypeError: unserialize(): Option "allowed_classes" must be an array or of type bool, int given`))
+	testForError(t, `<?php unserialize('N;', ["allowed_classes" => [1]]);`,
+		phpError.NewError(`Uncaught TypeError: unserialize(): Option "allowed_classes" must be an array of class names, int given`))
+	testForError(t, `<?php unserialize('N;', ["max_depth" => "1"]);`,
+		phpError.NewError(`Uncaught TypeError: unserialize(): Option "max_depth" must be of type int, string given`))
+	testForError(t, `<?php unserialize('N;', ["max_depth" => -1]);`,
+		phpError.NewError(`Uncaught ValueError: unserialize(): Option "max_depth" must be greater than or equal to 0`))
+
+	// Invalid data
+	testInputOutput(t, `<?php var_dump(unserialize('a:1:{i:0;i:1;'));`,
+		"\nWarning: unserialize(): Error at offset 13 of 13 bytes in /home/admin/test.php:1:16\nbool(false)\n")
+	testInputOutput(t, `<?php var_dump(unserialize('a:1:{i:0;s:5:"abc";}'));`,
+		"\nWarning: unserialize(): Error at offset 19 of 20 bytes in /home/admin/test.php:1:16\nbool(false)\n")
+	testInputOutput(t, `<?php var_dump(unserialize('i:1;xyz'));`,
+		"\nWarning: unserialize(): Extra data starting at offset 4 of 7 bytes in /home/admin/test.php:1:16\nint(1)\n")
+	testInputOutput(t, `<?php var_dump(unserialize('a:1:{i:0;a:1:{i:0;i:1;}}', ["max_depth" => 1]));`,
+		"\nWarning: unserialize(): Maximum depth of 1 exceeded. The depth limit can be changed using the max_depth unserialize() option "+
+			"or the unserialize_max_depth ini setting in /home/admin/test.php:1:16\n"+
+			"\nWarning: unserialize(): Error at offset 14 of 24 bytes in /home/admin/test.php:1:16\nbool(false)\n")
+	testInputOutput(t, `<?php var_dump(unserialize('a:1:{i:0;a:0:{}}', ["max_depth" => 1]));`, "array(1) {\n  [0]=>\n  array(0) {\n  }\n}\n")
+	testInputOutputWithIni(t, []string{"unserialize_max_depth=1"}, `<?php var_dump(unserialize('a:1:{i:0;a:1:{i:0;i:1;}}'));`,
+		"\nWarning: unserialize(): Maximum depth of 1 exceeded. The depth limit can be changed using the max_depth unserialize() option "+
+			"or the unserialize_max_depth ini setting in /home/admin/test.php:1:16\n"+
+			"\nWarning: unserialize(): Error at offset 14 of 24 bytes in /home/admin/test.php:1:16\nbool(false)\n")
+}
+
 // -------------------------------------- var_dump -------------------------------------- MARK: var_dump
 
 func TestLibVarDump(t *testing.T) {
@@ -301,6 +1302,7 @@ func TestLibVarDump(t *testing.T) {
 	testInputOutput(t, `<?php var_dump([]);`, "array(0) {\n}\n")
 	testInputOutput(t, `<?php var_dump([1,2]);`, "array(2) {\n  [0]=>\n  int(1)\n  [1]=>\n  int(2)\n}\n")
 	testInputOutput(t, `<?php var_dump([1, [1]]);`, "array(2) {\n  [0]=>\n  int(1)\n  [1]=>\n  array(1) {\n    [0]=>\n    int(1)\n  }\n}\n")
+	testInputOutput(t, `<?php $p = proc_open("true", [], $pipes); var_dump($p); proc_close($p); var_dump($p);`, "resource(1) of type (process)\nresource(1) of type (Unknown)\n")
 }
 
 // -------------------------------------- var_export -------------------------------------- MARK: var_export
@@ -331,6 +1333,52 @@ func TestLibOptionInfo(t *testing.T) {
 	testInputOutput(t, `<?php var_dump(ini_set('error_reporting', E_ERROR)); var_dump(ini_set('error_reporting', E_ERROR));`, "string(5) \"32767\"\nstring(1) \"1\"\n")
 }
 
+// -------------------------------------- opcache -------------------------------------- MARK: opcache
+
+func TestLibOpcache(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "include.php")
+	if err := GoOs.WriteFile(filename, []byte(`<?php declare(strict_types=1); function twice(int $a): int { return $a * 2; }
+function twiceStr(string $a) { return twice($a); }`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every interpreter (one per engine) shares the cached program of the include
+	testInputOutput(t,
+		fmt.Sprintf(`<?php opcache_reset(); function first() { return 1; } require "%s"; function last() { return 3; }
+		echo first(), twice(1), last(), opcache_is_script_cached("%s") ? "Y" : "N";`, filename, filename),
+		"123Y",
+	)
+	testInputOutput(t,
+		fmt.Sprintf(`<?php require "%s"; function last() { return 3; } echo twice(21), last(); $s = opcache_get_status();
+		echo " ", $s["opcache_statistics"]["num_cached_scripts"], " ", $s["scripts"]["%s"]["hits"] > 0 ? "hit" : "miss";`, filename, filename),
+		"423 1 hit",
+	)
+	// The strict mode of the cached file is kept
+	testForError(t,
+		fmt.Sprintf(`<?php require "%s"; twiceStr("1");`, filename),
+		phpError.NewError(`Uncaught TypeError: twice(): Argument #1 ($a) must be of type int, string given`),
+	)
+
+	testInputOutput(t, fmt.Sprintf(`<?php var_dump(opcache_invalidate("%s", true), opcache_is_script_cached("%s"));`, filename, filename), "bool(true)\nbool(false)\n")
+	testInputOutput(t, `<?php var_dump(opcache_reset(), opcache_get_status(false)["opcache_statistics"]["num_cached_scripts"]);`, "bool(true)\nint(0)\n")
+	// Disabled opcache
+	testInputOutputWithIni(t, []string{"opcache.enable=0"},
+		fmt.Sprintf(`<?php require "%s"; var_dump(opcache_get_status(), opcache_reset(), opcache_is_script_cached("%s"));`, filename, filename),
+		"bool(false)\nbool(false)\nbool(false)\n",
+	)
+}
+
+// -------------------------------------- spl_object_id -------------------------------------- MARK: spl_object_id
+
+func TestLibSplObject(t *testing.T) {
+	testInputOutput(t, `<?php $a = new stdClass(); $b = new stdClass(); $c = clone $a; var_dump(spl_object_id($a), spl_object_id($b), spl_object_id($c));`,
+		"int(1)\nint(2)\nint(3)\n",
+	)
+	testInputOutput(t, `<?php $a = new stdClass(); $b = $a; var_dump(spl_object_id($a) === spl_object_id($b), spl_object_hash($a));`,
+		"bool(true)\nstring(32) \"00000000000000010000000000000000\"\n",
+	)
+}
+
 // -------------------------------------- error_reporting -------------------------------------- MARK: error_reporting
 
 func TestLibErrorReporting(t *testing.T) {
@@ -349,3 +1397,69 @@ func TestLibErrorReporting(t *testing.T) {
 	// Report all PHP errors
 	testInputOutput(t, `<?php error_reporting(-1); echo error_reporting();`, "32767")
 }
+
+// -------------------------------------- program execution -------------------------------------- MARK: program execution
+
+func TestLibProgramExecution(t *testing.T) {
+	if os.OS == "Windows" {
+		t.Skip("The test commands require a POSIX shell")
+	}
+
+	// Shell command expression
+	testInputOutput(t, "<?php $name = 'World'; echo `echo Hello $name`;", "Hello World\n")
+	testInputOutput(t, "<?php var_dump(`true`);", "NULL\n")
+
+	// escapeshellarg
+	testInputOutput(t, `<?php echo escapeshellarg("It's");`, `'It'\''s'`)
+
+	// escapeshellcmd
+	testInputOutput(t, `<?php echo escapeshellcmd("ls 'a b' \"c\" d'e; rm & *");`, `ls 'a b' "c" d\'e\; rm \& \*`)
+
+	// exec
+	testInputOutput(t, `<?php echo exec("printf 'a  \nb\nc'", $output, $code); var_dump($output, $code);`,
+		"c"+`array(3) {
+  [0]=>
+  string(1) "a"
+  [1]=>
+  string(1) "b"
+  [2]=>
+  string(1) "c"
+}
+int(0)
+`)
+	testInputOutput(t, `<?php $output = ["x"]; exec("echo y", $output); echo implode(",", $output);`, "x,y")
+	testInputOutput(t, `<?php exec("exit 3", $output, $code); echo $code;`, "3")
+	testForError(t, `<?php exec("");`, phpError.NewError("Uncaught ValueError: exec(): Argument #1 ($command) cannot be empty"))
+
+	// passthru
+	testInputOutput(t, `<?php var_dump(passthru("printf raw", $code)); echo $code;`, "rawNULL\n0")
+
+	// shell_exec
+	testInputOutput(t, `<?php echo shell_exec("echo a; echo b");`, "a\nb\n")
+
+	// system
+	testInputOutput(t, `<?php $last = system("echo a; echo b; exit 2", $code); echo "[", $last, "]", $code;`, "a\nb\n[b]2")
+
+	// proc_open and proc_close
+	testInputOutput(t, `<?php
+		$process = proc_open("tr a-z A-Z; echo error >&2; exit 4", [0 => ["pipe", "r"], 1 => ["pipe", "w"], 2 => ["pipe", "w"]], $pipes);
+		fwrite($pipes[0], "hello");
+		fclose($pipes[0]);
+		echo stream_get_contents($pipes[1]), "|", fgets($pipes[2]);
+		echo proc_close($process);`,
+		"HELLO|error\n4",
+	)
+	testInputOutput(t, `<?php $process = proc_open(["printf", "%s-%s", "a b", "c"], [1 => ["pipe", "w"]], $pipes); echo fread($pipes[1], 100); proc_close($process);`, "a b-c")
+	testInputOutput(t, `<?php $process = proc_open("pwd", [1 => ["pipe", "w"]], $pipes, "/"); echo stream_get_contents($pipes[1]); proc_close($process);`, "/\n")
+	testInputOutput(t, `<?php $process = proc_open('echo $FOO', [1 => ["pipe", "w"]], $pipes, null, ["FOO" => "bar"]); echo stream_get_contents($pipes[1]); proc_close($process);`, "bar\n")
+	testForError(t, `<?php $process = proc_open("true", [["socket"]], $pipes);`, phpError.NewError("Uncaught ValueError: proc_open(): socket is not a valid descriptor spec/mode"))
+	testForError(t, `<?php $process = proc_open("true", [], $pipes); proc_close($process); proc_close($process);`,
+		phpError.NewError("Uncaught TypeError: proc_close(): supplied resource is not a valid process resource"),
+	)
+
+	// disable_functions
+	testInputOutputWithIni(t, []string{"disable_functions=exec, system"}, `<?php var_dump(function_exists("exec"), function_exists("system"), function_exists("passthru"));`,
+		"bool(false)\nbool(false)\nbool(true)\n",
+	)
+	testForErrorWithIni(t, []string{"disable_functions=shell_exec"}, "<?php `ls`;", phpError.NewError("Call to undefined function shell_exec()"))
+}
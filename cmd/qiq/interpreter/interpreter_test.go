@@ -12,6 +12,8 @@ import (
 )
 
 var TEST_FILE_NAME string = getTestFileName()
+
+var testEngines = []string{"ast", "vm"}
 var TEST_FILE_PATH string = getTestFilePath()
 
 func getTestFileName() string {
@@ -99,32 +101,57 @@ func TestVariableExprToVariableName(t *testing.T) {
 // -------------------------------------- input output tests -------------------------------------- MARK: input output tests
 
 func testForError(t *testing.T, php string, expected phpError.Error) {
-	interpreter, err := NewInterpreter(ini.NewDevIni(), &request.Request{}, TEST_FILE_NAME)
-	if err != nil {
-		t.Errorf("\nCode: \"%s\"\nUnexpected error: \"%s\"", php, err)
-		return
-	}
-	_, err = interpreter.Process(php)
-	if err.GetErrorType() != expected.GetErrorType() || err.GetMessage() != expected.GetMessage() {
-		t.Errorf("\nCode: \"%s\"\nExpected: %s\nGot:      %s", php, expected, err)
+	testForErrorWithIni(t, []string{}, php, expected)
+}
+
+func testForErrorWithIni(t *testing.T, iniEntries []string, php string, expected phpError.Error) {
+	// Both engines must produce the same result
+	for _, engine := range testEngines {
+		testIni, err := ini.NewDevIniFromArray(append([]string{"qiq.engine=" + engine}, iniEntries...))
+		if err != nil {
+			t.Errorf("\nCode: \"%s\"\nUnexpected error: \"%s\"", php, err)
+			return
+		}
+		interpreter, err := NewInterpreter(testIni, &request.Request{}, TEST_FILE_NAME)
+		if err != nil {
+			t.Errorf("\nCode: \"%s\"\nUnexpected error: \"%s\"", php, err)
+			return
+		}
+		_, err = interpreter.Process(php)
+		if err == nil || err.GetErrorType() != expected.GetErrorType() || err.GetMessage() != expected.GetMessage() {
+			t.Errorf("\nEngine: %s\nCode: \"%s\"\nExpected: %s\nGot:      %s", engine, php, expected, err)
+		}
 	}
 }
 
 func testInputOutput(t *testing.T, php string, output string) *Interpreter {
+	return testInputOutputWithIni(t, []string{}, php, output)
+}
+
+func testInputOutputWithIni(t *testing.T, iniEntries []string, php string, output string) *Interpreter {
 	// Always use "\n" for tests so that they also pass on Windows
 	os.EOL = "\n"
-	interpreter, err := NewInterpreter(ini.NewDevIni(), &request.Request{}, TEST_FILE_NAME)
-	if err != nil {
-		t.Errorf("\nCode: \"%s\"\nUnexpected error: \"%s\"", php, err)
-		return interpreter
-	}
-	actual, err := interpreter.Process(php)
-	if err != nil {
-		t.Errorf("\nCode: \"%s\"\nUnexpected error: \"%s\"", php, err)
-		return interpreter
-	}
-	if actual != output {
-		t.Errorf("\nCode: \"%s\"\nExpected: \"%s\",\nGot:      \"%s\"", php, output, actual)
+	var interpreter *Interpreter
+	// Both engines must produce the same output
+	for _, engine := range testEngines {
+		testIni, err := ini.NewDevIniFromArray(append([]string{"qiq.engine=" + engine}, iniEntries...))
+		if err != nil {
+			t.Errorf("\nCode: \"%s\"\nUnexpected error: \"%s\"", php, err)
+			return nil
+		}
+		interpreter, err = NewInterpreter(testIni, &request.Request{}, TEST_FILE_NAME)
+		if err != nil {
+			t.Errorf("\nCode: \"%s\"\nUnexpected error: \"%s\"", php, err)
+			return interpreter
+		}
+		actual, err := interpreter.Process(php)
+		if err != nil {
+			t.Errorf("\nEngine: %s\nCode: \"%s\"\nUnexpected error: \"%s\"", engine, php, err)
+			return interpreter
+		}
+		if actual != output {
+			t.Errorf("\nEngine: %s\nCode: \"%s\"\nExpected: \"%s\",\nGot:      \"%s\"", engine, php, output, actual)
+		}
 	}
 	return interpreter
 }
@@ -236,6 +263,14 @@ func TestVariable(t *testing.T) {
 			f();
 			var_dump($foo);`,
 		"string(3) \"abc\"\nint(42)\nint(12)\n")
+	testInputOutput(t, `<?php function g() { global $x; $x = 5; } function f() { g(); } f(); echo $x;`, "5")
+
+	// Variables of functions are accessed by name
+	testInputOutput(t,
+		`<?php function f($a) { $b = 1; $name = "b"; $$name += $a; echo $b; $name = "c"; $$name = 3; echo $c; unset($$name); echo isset($c) ? "set" : "unset"; } f(2);`,
+		"33unset",
+	)
+	testInputOutput(t, `<?php function f($a) { eval('$a++; $b = 2;'); echo $a, $b; } f(1);`, "22")
 }
 
 func TestConditionals(t *testing.T) {
@@ -275,6 +310,8 @@ func TestConditionals(t *testing.T) {
 	testInputOutput(t, `<?php $a = 42; do { echo "1"; $a++; } while ($a < 42);`, "1")
 	testInputOutput(t, `<?php $a = 0; do { echo "1"; $a++; if ($a == 5) { break; }} while (true);`, "11111")
 	testInputOutput(t, `<?php $a = 0; do { echo "1"; while (true) { echo "2"; break 2; }} while (true);`, "12")
+	// Continue evaluates the condition
+	testInputOutput(t, `<?php $a = 0; do { $a++; if ($a < 3) { continue; } echo $a; } while ($a < 2); echo "|" . $a;`, "|2")
 
 	// For statement
 	testInputOutput(t,
@@ -309,6 +346,9 @@ func TestConditionals(t *testing.T) {
 		}`,
 		"2 100\n4 90\n6 80\n8 70\n10 60\n",
 	)
+	// End-of-loop is not executed after the condition is false
+	testInputOutput(t, `<?php for ($i = 0; $i < 3; $i++) { echo $i; } echo "|" . $i;`, "012|3")
+	testInputOutput(t, `<?php for ($i = 0; $i < 4; $i++) { if ($i % 2 == 0) { continue; } echo $i; } echo "|" . $i;`, "13|4")
 
 	// Foreach statment
 	testInputOutput(t,
@@ -479,7 +519,83 @@ func TestUserFunctions(t *testing.T) {
 	testInputOutput(t, "<?php function myUserFunc() {} var_dump(function_exists('myUserFunc'));", "bool(true)\n")
 }
 
+func TestTypeDeclarations(t *testing.T) {
+	// Coercive typing mode
+	testInputOutput(t, `<?php function f(int $a) { var_dump($a); } f("5"); f(5.0); f(true); f(" 7 ");`, "int(5)\nint(5)\nint(1)\nint(7)\n")
+	testInputOutput(t, `<?php function f(int|float $a) { var_dump($a); } f("4.5"); f("4");`, "float(4.5)\nint(4)\n")
+	testInputOutput(t, `<?php function f(int|float|bool $a) { var_dump($a); } f("45X"); f("");`, "bool(true)\nbool(false)\n")
+	// Floats with a fractional part are only converted to int if the union has no other scalar type
+	testInputOutput(t, `<?php function f(string|int $a) { var_dump($a); } f(1.5); f(2.0); f(1e100);`, "string(3) \"1.5\"\nint(2)\nstring(8) \"1.0E+100\"\n")
+	testInputOutput(t, `<?php function f(int|bool $a) { var_dump($a); } f(1.5); f(3.0); f("1.5"); f("2.0");`, "bool(true)\nint(3)\nbool(true)\nint(2)\n")
+	testInputOutput(t, `<?php function f(?int $a) { var_dump($a); } f(2.5);`,
+		fmt.Sprintf("\nDeprecated: Implicit conversion from float 2.5 to int loses precision in %s:1:47\nint(2)\n", TEST_FILE_NAME),
+	)
+	testInputOutput(t, `<?php function f(?string $a): string { return $a ?? 42; } var_dump(f(null)); var_dump(f(12));`, "string(2) \"42\"\nstring(2) \"12\"\n")
+	testInputOutput(t, `<?php function f(int $a) { var_dump($a); } f(1.5);`,
+		fmt.Sprintf("\nDeprecated: Implicit conversion from float 1.5 to int loses precision in %s:1:46\nint(1)\n", TEST_FILE_NAME),
+	)
+	testInputOutput(t, `<?php class A { function __toString() { return "A"; } } function f(string $a) { var_dump($a); } f(new A());`, "string(1) \"A\"\n")
+	testForError(t, `<?php function f(int $a) {} f("abc");`, phpError.NewError("Uncaught TypeError: f(): Argument #1 ($a) must be of type int, string given"))
+	testForError(t, `<?php function f(int $a) {} f(null);`, phpError.NewError("Uncaught TypeError: f(): Argument #1 ($a) must be of type int, null given"))
+
+	// Strict typing mode
+	testInputOutput(t, `<?php declare(strict_types=1); function f(float $a) { var_dump($a); } f(5);`, "float(5)\n")
+	testForError(t, `<?php declare(strict_types=1); function f(int $a) {} f("5");`, phpError.NewError("Uncaught TypeError: f(): Argument #1 ($a) must be of type int, string given"))
+	testForError(t, `<?php declare(strict_types=1); function f(): string { return 5; } f();`, phpError.NewError("Uncaught TypeError: f(): Return value must be of type string, int returned"))
+
+	// Return types
+	testForError(t, `<?php function f(): int {} f();`, phpError.NewError("Uncaught TypeError: f(): Return value must be of type int, none returned"))
+	testForError(t, `<?php function f(): never {} f();`, phpError.NewError("Uncaught TypeError: f(): never-returning function must not implicitly return"))
+	testForError(t, `<?php function f(): false|int { return "x"; } f();`, phpError.NewError("Uncaught TypeError: f(): Return value must be of type int|false, string returned"))
+
+	// Class types
+	testInputOutput(t, `<?php class A {} class B extends A {} function f(A $a): A { return $a; } echo get_class(f(new B()));`, "B")
+	testInputOutput(t, `<?php class A { function get(): static { return $this; } function set(self $a): ?self { return null; } } echo get_class((new A())->get());`, "A")
+	testForError(t, `<?php class A {} class B {} function f(A $a) {} f(new B());`, phpError.NewError("Uncaught TypeError: f(): Argument #1 ($a) must be of type A, B given"))
+	testForError(t, `<?php function f((A&B)|null $a) {} f(1);`, phpError.NewError("Uncaught TypeError: f(): Argument #1 ($a) must be of type (A&B)|null, int given"))
+	testInputOutput(t, `<?php function f(iterable $i, callable $c, object $o, mixed $m) { echo "ok"; } f([1], 'strlen', new stdClass(), null);`, "ok")
+
+	// Typed properties
+	testInputOutput(t, `<?php class A { public int $i = 1; public ?A $a = null; } $a = new A(); $a->i = "12"; var_dump($a->i);`, "int(12)\n")
+	testForError(t, `<?php class A { public int $i; } $a = new A(); $a->i = "x";`,
+		phpError.NewError("Uncaught TypeError: Cannot assign string to property A::$i of type int in %s:1:50", TEST_FILE_NAME),
+	)
+	testForError(t, `<?php class A { public int $i; } $a = new A(); echo $a->i;`,
+		phpError.NewError("Uncaught Error: Typed property A::$i must not be accessed before initialization in %s:1:57", TEST_FILE_NAME),
+	)
+	testForError(t, `<?php class A { public int $i = "x"; } $a = new A();`,
+		phpError.NewError("Cannot use string as default value for property A::$i of type int in %s:1:28", TEST_FILE_NAME),
+	)
+
+	// Invalid type declarations
+	testForError(t, `<?php function f(int|INT $a) {}`, phpError.NewError("Duplicate type int is redundant in %s:1:18", TEST_FILE_NAME))
+	testForError(t, `<?php function f(?mixed $a) {}`, phpError.NewError("mixed cannot be marked as nullable in %s:1:18", TEST_FILE_NAME))
+	testForError(t, `<?php function f(void $a) {}`, phpError.NewError("void cannot be used as a parameter type in %s:1:23", TEST_FILE_NAME))
+	testForError(t, `<?php class A { public callable $a; }`, phpError.NewError("Property A::$a cannot have type callable in %s:1:33", TEST_FILE_NAME))
+}
+
 func TestString(t *testing.T) {
+	// Escape sequences are byte-exact
+	testInputOutput(t, `<?php var_dump(bin2hex("\xFF\x0a\X41\x7"));`, "string(8) \"ff0a4107\"\n")
+	testInputOutput(t, `<?php var_dump(bin2hex("\101\0\400\18"));`, "string(10) \"4100000138\"\n")
+	testInputOutput(t, `<?php var_dump(bin2hex("\u{41}\u{e4}\u{1F600}\u{D800}"));`, "string(20) \"41c3a4f09f9880eda080\"\n")
+	testInputOutput(t, `<?php var_dump(bin2hex("\e\f\v\r\n\t"));`, "string(12) \"1b0c0b0d0a09\"\n")
+	testInputOutput(t, `<?php var_dump("\\x41\\u{41}\q\u");`, `string(14) "\x41\u{41}\q\u"`+"\n")
+	testInputOutput(t, `<?php var_dump('\x41\101');`, `string(8) "\x41\101"`+"\n")
+	testInputOutput(t, "<?php $s = <<<EOT\n\\xFF\\u{e4}\\101\nEOT; var_dump(bin2hex($s));", "string(8) \"ffc3a441\"\n")
+	testInputOutput(t, "<?php var_dump(bin2hex(\"\xFF\xFE\"));", "string(4) \"fffe\"\n")
+	testInputOutput(t, `<?php $a = 1; echo "\$a \\$a \x24a \44a \u{24}a";`, `$a \1 $a $a $a`)
+	testInputOutput(t, `<?php $a = '$b'; $b = 2; echo "$a {$b}\n";`, "$b 2\n")
+	testInputOutput(t, "<?php $a = 1; $s = <<<EOT\n\\$a $a \\x24a \"$a\"\nEOT; echo $s;", `$a 1 $a "1"`)
+	testForError(t, `<?php echo "\u{110000}";`, phpError.NewParseError("Invalid UTF-8 codepoint escape sequence: Codepoint too large in %s:1:12", TEST_FILE_NAME))
+
+	testInputOutput(t, "<?php $\u20ac = \"\xFF\x80\"; var_dump(strlen($\u20ac), bin2hex($\u20ac));", "int(2)\nstring(4) \"ff80\"\n")
+
+	// Bitwise operators on strings
+	testInputOutput(t, `<?php var_dump(bin2hex("\x0F\xF0" & "\xFF"), bin2hex("\x0F" | "\xF0\x01"), bin2hex("ab" ^ "  c"), bin2hex(~"\x00\xFF"));`,
+		"string(2) \"0f\"\nstring(4) \"ff01\"\nstring(4) \"4142\"\nstring(4) \"ff00\"\n")
+	testInputOutput(t, `<?php var_dump("12" & "3", 12 & "3");`, "string(1) \"1\"\nint(0)\n")
+
 	// Heredoc string
 	testInputOutput(t, "<?php $v = 123; $s = <<< ID\n"+`S'o'me "\"t e\txt; v = $v"`+"\nSome more text\nID; echo \">$s<\";", `>S'o'me "\"t e`+"\t"+`xt; v = 123"`+"\nSome more text<")
 
@@ -487,19 +603,53 @@ func TestString(t *testing.T) {
 	testInputOutput(t, `<?php $s = 'abc'; var_dump($s[0]);`, "string(1) \"a\"\n")
 	testForError(t, `<?php $s = 'abc'; var_dump($s[""]);`, phpError.NewError("Cannot access offset of type string on string in %s:1:31", TEST_FILE_NAME))
 	testForError(t, `<?php $s = 'abc'; var_dump($s[]);`, phpError.NewError("Cannot use [] for reading in %s:1:28", TEST_FILE_NAME))
-	testForError(t, `<?php $s = 'abc'; var_dump($s[3]);`, phpError.NewError("Uninitialized string offset 3 in %s:1:31", TEST_FILE_NAME))
+	testInputOutput(t, `<?php $s = 'abc'; var_dump($s[3]);`, fmt.Sprintf("\nWarning: Uninitialized string offset 3 in %s:1:31\nstring(0) \"\"\n", TEST_FILE_NAME))
+	testInputOutput(t, `<?php $s = 'abc'; var_dump($s[-1], $s["1"], $s[1 + 1]);`, "string(1) \"c\"\nstring(1) \"b\"\nstring(1) \"c\"\n")
+	testInputOutput(t, `<?php $s = "\xC3\xA4b"; var_dump($s[0] === "\xC3", $s[1] === "\xA4", $s[2]);`, "bool(true)\nbool(true)\nstring(1) \"b\"\n")
+	testInputOutput(t, `<?php $s = 'abc'; var_dump($s[true]);`, fmt.Sprintf("\nWarning: String offset cast occurred in %s:1:31\nstring(1) \"b\"\n", TEST_FILE_NAME))
+	testInputOutput(t, `<?php $s = 'abc'; var_dump(isset($s[3]), isset($s[-3]), isset($s[-4]));`, "bool(false)\nbool(true)\nbool(false)\n")
+	testInputOutput(t, `<?php $s = 'abc'; var_dump($s[-4]);`, fmt.Sprintf("\nWarning: Uninitialized string offset -4 in %s:1:31\nstring(0) \"\"\n", TEST_FILE_NAME))
+	testInputOutput(t, `<?php $s = 'abc'; var_dump($s[3] ?? 'x', $s[-4] ?? 'y', $s[2] ?? 'z');`, "string(1) \"x\"\nstring(1) \"y\"\nstring(1) \"c\"\n")
+	testInputOutput(t, `<?php $s = 'abc'; $i = 2; var_dump($s[$i + 1] ?? 'x', $s[$i] ?? 'y');`, "string(1) \"x\"\nstring(1) \"c\"\n")
+	testInputOutput(t, `<?php $a = ['s' => 'abc']; var_dump($a['s'][5] ?? 'x');`, "string(1) \"x\"\n")
 
 	// Write string index
 	testInputOutput(t, `<?php $s = '123'; $s[0] = '*'; var_dump($s);`, "string(3) \"*23\"\n")
-	testInputOutput(t, `<?php $s = '123'; $s[0] = '**'; var_dump($s);`, "string(3) \"*23\"\n")
-	testInputOutput(t, `<?php $s = '123'; $s[3] = '**'; var_dump($s);`, "string(4) \"123*\"\n")
+	testInputOutput(t, `<?php $s = '123'; $s[0] = '**'; var_dump($s);`,
+		fmt.Sprintf("\nWarning: Only the first byte will be assigned to the string offset in %s:1:27\nstring(3) \"*23\"\n", TEST_FILE_NAME))
+	testInputOutput(t, `<?php $s = '123'; $s[3] = '**'; var_dump($s);`,
+		fmt.Sprintf("\nWarning: Only the first byte will be assigned to the string offset in %s:1:27\nstring(4) \"123*\"\n", TEST_FILE_NAME))
 	testInputOutput(t, `<?php $s = '123'; $s[5] = '*'; var_dump($s);`, "string(6) \"123  *\"\n")
 	testInputOutput(t, `<?php $s = '12345'; $s[1] = $s[1] = $s[3]; var_dump($s);`, "string(5) \"14345\"\n")
 	testInputOutput(t, `<?php $s = '12345'; $s[1] = $s[3] = '*'; var_dump($s);`, "string(5) \"1*3*5\"\n")
+	testInputOutput(t, `<?php $s = '123'; $s[-1] = '*'; $s["0"] = '*'; var_dump($s);`, "string(3) \"*2*\"\n")
+	testInputOutput(t, `<?php $s = '123'; $s[-4] = '*'; var_dump($s);`, fmt.Sprintf("\nWarning: Illegal string offset -4 in %s:1:19\nstring(3) \"123\"\n", TEST_FILE_NAME))
+	testInputOutput(t, `<?php $s = "\xC3\xA4"; $s[1] = "\xFF"; var_dump(bin2hex($s));`, "string(4) \"c3ff\"\n")
 	testForError(t, `<?php $s = '123'; $s[] = '*';`, phpError.NewError("[] operator not supported for strings in %s:1:19", TEST_FILE_NAME))
 	testForError(t, `<?php $s = '123'; $s["abc"] = '*';`, phpError.NewError("Cannot access offset of type string on string in %s:1:22", TEST_FILE_NAME))
 }
 
+func FuzzStringOffset(f *testing.F) {
+	f.Add([]byte("abc"), int8(1))
+	f.Add([]byte{0xc3, 0xa4, 0xff}, int8(-2))
+	f.Fuzz(func(t *testing.T, input []byte, offset int8) {
+		if len(input) == 0 {
+			return
+		}
+		pos := int(offset) % len(input)
+		// The byte at the offset is read and written without interpreting the string as UTF-8
+		expected := input[(pos+len(input))%len(input)]
+		escaped := ""
+		for _, b := range input {
+			escaped += fmt.Sprintf(`\x%02x`, b)
+		}
+		testInputOutput(t,
+			fmt.Sprintf(`<?php $s = "%s"; echo bin2hex($s[%d]); $s[%d] = "\x%02x"; echo " ", $s === "%s" ? "same" : "changed";`, escaped, pos, pos, expected, escaped),
+			fmt.Sprintf("%02x same", expected),
+		)
+	})
+}
+
 func TestArray(t *testing.T) {
 	testInputOutput(t, `<?php $a = [0, 1, 2]; echo $a[0] === null ? "y" : "n";`, "n")
 	testInputOutput(t, `<?php $a = [0, 1, 2]; echo $a[3] === null ? "y" : "n";`, "y")
@@ -507,6 +657,7 @@ func TestArray(t *testing.T) {
 	testInputOutput(t, `<?php $a = []; $a[] = 1; echo $a[0];`, "1")
 	testInputOutput(t, `<?php $a = []; $a[][] = "42"; echo $a[0][0];`, "42")
 	testInputOutput(t, `<?php $a = []; $a[][123] = "42"; echo $a[0][123];`, "42")
+	testInputOutput(t, `<?php $a = [1]; $a = [2]; echo $a[0];`, "2")
 	testInputOutput(t, `<?php $a = []; $a["a"]["b"]["c"]=1; echo $a["a"]["b"]["c"];`, "1")
 
 	// Implicit declaration
@@ -535,6 +686,50 @@ func TestArray(t *testing.T) {
 		`<?php $b = [42]; $a = $b; var_dump($a[0], $b[0]); $b[0] = 43; var_dump($a[0], $b[0]);`,
 		"int(42)\nint(42)\nint(42)\nint(43)\n",
 	)
+	testInputOutput(t,
+		`<?php $a = [1, [2, 3]]; $b = $a; $b[1][0] = 9; $b[] = 4; echo count($a), $a[1][0], count($b), $b[1][0];`,
+		"2239",
+	)
+	testInputOutput(t,
+		`<?php $a = []; $a[0][0][0] = 1; $b = $a; $b[0][0][1] = 2; $c = $b; $c[][1] = 3; echo count($a[0][0]), count($b[0][0]), count($b), count($c);`,
+		"1212",
+	)
+	testInputOutput(t,
+		`<?php function f($x) { $x[1][1] = 7; $x[] = 8; return $x; } $a = [1, [2, 3]]; $b = f($a); echo count($a), $a[1][1], count($b), $b[1][1];`,
+		"2337",
+	)
+	// Copies that are released (e.g. parameters after the call) must not share their storage with later writes
+	testInputOutput(t,
+		`<?php function f($x) { return $x; } $a = [1, 2]; $b = f($a); $a[0] = 9; $c = f(f($a)); $a[0] = 8; $d = array_fill(0, 2, $a); $a[0] = 7;
+		echo $b[0], $c[0], $d[1][0], $a[0], count($a);`,
+		"19872",
+	)
+	testInputOutput(t,
+		`<?php $a = [1, 2]; foreach ($a as $v) { $a[] = $v; } echo count($a);`,
+		"4",
+	)
+
+	// Order after removing and overwriting elements
+	testInputOutput(t,
+		`<?php $a = [1, 2, 3]; unset($a[1]); $a[] = 4; $a[0] = 5; foreach ($a as $k => $v) { echo "$k:$v,"; }`,
+		"0:5,2:3,3:4,",
+	)
+	testInputOutput(t,
+		`<?php $a = [1, 2, 3]; unset($a[2]); $a[] = 4; foreach ($a as $k => $v) { echo "$k:$v,"; }`,
+		"0:1,1:2,3:4,",
+	)
+	testInputOutput(t,
+		`<?php unset($x[1]); $a = [1, [2, 3, 4]]; $b = $a; unset($b[1][0], $b[5]); echo count($a[1]), count($b[1]);`,
+		"32",
+	)
+	testInputOutput(t,
+		`<?php $a = ["a" => 1, 5 => 2]; $a[5] = 3; $a[] = 4; foreach ($a as $k => $v) { echo "$k:$v,"; }`,
+		"a:1,5:3,6:4,",
+	)
+	testInputOutput(t,
+		`<?php $a = ["-5" => 1, "05" => 2, "-0" => 3]; var_dump($a);`,
+		"array(3) {\n  [-5]=>\n  int(1)\n  [\"05\"]=>\n  int(2)\n  [\"-0\"]=>\n  int(3)\n}\n",
+	)
 
 	// Key value
 	testInputOutput(t,
@@ -581,7 +776,7 @@ func TestArray(t *testing.T) {
 	)
 
 	// Implode
-	testInputOutput(t, `<?php $a = [1, 2, 3]; var_dump(implode($a));`, "string(5) \"1 2 3\"\n")
+	testInputOutput(t, `<?php $a = [1, 2, 3]; var_dump(implode($a));`, "string(3) \"123\"\n")
 	testInputOutput(t, `<?php $a = [1, 2, 3]; var_dump(implode(' ', $a));`, "string(5) \"1 2 3\"\n")
 	testInputOutput(t, `<?php $a = ["1", 2, 3.4]; var_dump(implode('-', $a));`, "string(7) \"1-2-3.4\"\n")
 }
@@ -638,7 +833,7 @@ func TestNumbers(t *testing.T) {
 
 	// Float
 	testInputOutput(t, `<?php var_dump(107_925_284.88);`, "float(107925284.88)\n")
-	testInputOutput(t, `<?php var_dump(6.674_083e-11);`, "float(0.00000000006674083)\n")
+	testInputOutput(t, `<?php var_dump(6.674_083e-11);`, "float(6.674083E-11)\n")
 	// Invalid
 	testForError(t, `<?php var_dump(1_.0);`, phpError.NewParseError("Unsupported number format detected at %s:1:16", TEST_FILE_NAME))
 	testForError(t, `<?php var_dump(1._0);`, phpError.NewError("Undefined constant \"_0\""))
@@ -670,6 +865,45 @@ func TestNumbers(t *testing.T) {
 	testInputOutput(t, `<?php var_dump(boolval('9.9'));`, "bool(true)\n")
 	testInputOutput(t, `<?php var_dump(boolval('9.9.9'));`, "bool(true)\n")
 	testInputOutput(t, `<?php var_dump(boolval('9X'));`, "bool(true)\n")
+
+	// Float to string
+	testInputOutput(t, `<?php echo 1e25;`, "1.0E+25")
+	testInputOutput(t, `<?php echo 1.0, " ", -1.5, " ", 0.1 + 0.2, " ", 1/3;`, "1 -1.5 0.3 0.33333333333333")
+	testInputOutput(t, `<?php echo 0.0001, " ", 0.00001, " ", 1e14, " ", 123456789012345.0;`, "0.0001 1.0E-5 1.0E+14 1.2345678901234E+14")
+	testInputOutput(t, `<?php echo PHP_INT_MAX + 1;`, "9.2233720368548E+18")
+	testInputOutput(t, `<?php echo -0.0, " ", INF, " ", -INF, " ", NAN;`, "-0 INF -INF NAN")
+	testInputOutput(t, `<?php echo (string)0.1 . "|" . strval(1e100) . "|" . 2.5;`, "0.1|1.0E+100|2.5")
+	testInputOutput(t, `<?php $a = 1.5; echo "$a {$a}";`, "1.5 1.5")
+	testInputOutput(t, `<?php var_dump(0.1 + 0.2, 1/3, 1e15, 1e25, -0.0, 1.0);`,
+		"float(0.30000000000000004)\nfloat(0.3333333333333333)\nfloat(1000000000000000)\nfloat(1.0E+25)\nfloat(-0)\nfloat(1)\n",
+	)
+	testInputOutput(t, `<?php var_export([1.0, 0.1, -0.0, 1e25, INF, NAN]);`,
+		"array (\n  0 => 1.0,\n  1 => 0.1,\n  2 => -0.0,\n  3 => 1.0E+25,\n  4 => INF,\n  5 => NAN,\n)",
+	)
+	testInputOutput(t, `<?php print_r([0.1 + 0.2]);`, "Array\n(\n    [0] => 0.3\n)\n")
+	testInputOutput(t, `<?php ini_set("precision", "17"); echo 0.1 + 0.2;`, "0.30000000000000004")
+	testInputOutput(t, `<?php ini_set("precision", "-1"); echo 0.1 + 0.2, " ", 1/3;`, "0.30000000000000004 0.3333333333333333")
+	testInputOutput(t, `<?php ini_set("precision", "3"); echo 3.14159, " ", 1234.5;`, "3.14 1.23E+3")
+	testInputOutput(t, `<?php ini_set("serialize_precision", "5"); var_dump(1/3); var_export(2.0);`, "float(0.33333)\n2.0")
+
+	// Numeric strings
+	testInputOutput(t, `<?php var_dump(" 12" + 1, "12 " + 1, "\t1.5\n" + 1, "1e3" * 1, ".5" + 0);`,
+		"int(13)\nint(13)\nfloat(2.5)\nfloat(1000)\nfloat(0.5)\n",
+	)
+	testInputOutput(t, `<?php var_dump("99999999999999999999" + 0);`, "float(1.0E+20)\n")
+	testInputOutput(t, `<?php var_dump((int)" 12 ", (float)" 1.5abc", (int)"1e3", (int)"1e100", (int)"0x1A", (int)"1_000");`,
+		"int(12)\nfloat(1.5)\nint(1000)\nint(9223372036854775807)\nint(0)\nint(1)\n",
+	)
+	testInputOutput(t, `<?php var_dump("12abc" + 1);`, "\nWarning: A non-numeric value encountered in /home/admin/test.php:1:16\nint(13)\n")
+	testInputOutput(t, `<?php $a = "1.5 apples"; $a *= 2; var_dump($a);`,
+		"\nWarning: A non-numeric value encountered in /home/admin/test.php:1:26\nfloat(3)\n",
+	)
+	testForError(t, `<?php var_dump("abc" + 1);`,
+		phpError.NewError("Uncaught TypeError: Unsupported operand types: string + int in %s:1:16", TEST_FILE_NAME),
+	)
+	testForError(t, `<?php var_dump(1.5 * "");`,
+		phpError.NewError("Uncaught TypeError: Unsupported operand types: float * string in %s:1:16", TEST_FILE_NAME),
+	)
 }
 
 func TestOperators(t *testing.T) {
@@ -752,6 +986,19 @@ func TestOperators(t *testing.T) {
 	// String
 	testInputOutput(t, `<?php $a = ""; var_dump($a++); var_dump($a);`, `string(0) ""`+"\n"+`string(1) "1"`+"\n")
 	testInputOutput(t, `<?php $a = ""; var_dump($a--); var_dump($a);`, `string(0) ""`+"\nint(-1)\n")
+	testInputOutput(t, `<?php $a = "5"; $a++; var_dump($a);`, "int(6)\n")
+	testInputOutput(t, `<?php $a = " 5 "; $a--; var_dump($a);`, "int(4)\n")
+	testInputOutput(t, `<?php $a = "5.5"; $a++; var_dump($a);`, "float(6.5)\n")
+	testInputOutput(t, `<?php $a = "1e2"; $a--; var_dump($a);`, "float(99)\n")
+	testInputOutput(t, `<?php $a = (string)PHP_INT_MAX; $a++; var_dump($a);`, "float(9.223372036854776E+18)\n")
+	testInputOutput(t, `<?php $a = "a"; $a++; var_dump($a);`, `string(1) "b"`+"\n")
+	testInputOutput(t, `<?php $a = "a9"; $a++; var_dump($a);`, `string(2) "b0"`+"\n")
+	testInputOutput(t, `<?php $a = "Az"; $a++; var_dump($a);`, `string(2) "Ba"`+"\n")
+	testInputOutput(t, `<?php $a = "zz"; $a++; var_dump($a);`, `string(3) "aaa"`+"\n")
+	testInputOutput(t, `<?php $a = "Zz"; $a++; var_dump($a);`, `string(3) "AAa"`+"\n")
+	testInputOutput(t, `<?php $a = "5a"; $a++; var_dump($a);`, `string(2) "5b"`+"\n")
+	testInputOutput(t, `<?php $a = "a-z"; $a++; var_dump($a);`, `string(3) "a-a"`+"\n")
+	testInputOutput(t, `<?php $a = "abc"; $a--; var_dump($a);`, `string(3) "abc"`+"\n")
 
 	// Base operators
 	// Integer
@@ -780,6 +1027,23 @@ func TestOperators(t *testing.T) {
 	testInputOutput(t, `<?php echo 2 ** 4;`, "16")
 	testInputOutput(t, `<?php echo 2 ** 2 ** 2;`, "16")
 	testInputOutput(t, `<?php $a = 2; echo $a **= 4;`, "16")
+	testInputOutput(t, `<?php var_dump(7 / 2);`, "float(3.5)\n")
+	testInputOutput(t, `<?php var_dump(-7 % 3, 7 % -3);`, "int(-1)\nint(1)\n")
+	testInputOutput(t, `<?php var_dump(7.5 % 2);`, "int(1)\n")
+	testInputOutput(t, `<?php var_dump(2 ** -1, 2 ** -1 ** 2);`, "float(0.5)\nfloat(0.5)\n")
+	testInputOutput(t, `<?php var_dump(2 ** 62, (-2) ** 63);`, "int(4611686018427387904)\nint(-9223372036854775808)\n")
+	testInputOutput(t, `<?php var_dump(1 << 64, -8 >> 64, -8 >> 1);`, "int(0)\nint(-1)\nint(-4)\n")
+	testForError(t, `<?php echo 1 % 0;`, phpError.NewError("Uncaught DivisionByZeroError: Modulo by zero"))
+	testForError(t, `<?php echo 1 / 0;`, phpError.NewError("Uncaught DivisionByZeroError: Division by zero"))
+	testForError(t, `<?php echo 1.5 / 0;`, phpError.NewError("Uncaught DivisionByZeroError: Division by zero"))
+	testForError(t, `<?php echo 1 << -1;`, phpError.NewError("Uncaught ArithmeticError: Bit shift by negative number"))
+	testForError(t, `<?php echo 1 >> -1;`, phpError.NewError("Uncaught ArithmeticError: Bit shift by negative number"))
+	// Integer overflow
+	testInputOutput(t, `<?php var_dump(is_float(PHP_INT_MAX + 1), is_float(PHP_INT_MIN - 1), is_float(PHP_INT_MAX * 2));`, "bool(true)\nbool(true)\nbool(true)\n")
+	testInputOutput(t, `<?php var_dump(is_float(2 ** 63), is_float(-PHP_INT_MIN), is_float(PHP_INT_MIN / -1));`, "bool(true)\nbool(true)\nbool(true)\n")
+	testInputOutput(t, `<?php var_dump(PHP_INT_MAX + 1 == 2 ** 63, PHP_INT_MIN % -1, PHP_INT_MAX - 1 + 1);`, "bool(true)\nint(0)\nint(9223372036854775807)\n")
+	testInputOutput(t, `<?php $a = PHP_INT_MAX; $a++; $b = PHP_INT_MIN; $b--; var_dump(is_float($a), is_float($b));`, "bool(true)\nbool(true)\n")
+	testInputOutput(t, `<?php $a = PHP_INT_MAX; $a -= 1; $a++; var_dump($a);`, "int(9223372036854775807)\n")
 	// Floating
 	testInputOutput(t, `<?php echo 42.0 + 1.5;`, "43.5")
 	testInputOutput(t, `<?php $a = 42.0; echo $a += 1.5;`, "43.5")
@@ -903,6 +1167,10 @@ func TestStrictComparison(t *testing.T) {
 	testInputOutput(t, `<?php class c {} $c1 = new c; var_dump($c1 === $c1);`, "bool(true)\n")
 	testInputOutput(t, `<?php class c {} $c1 = new c; var_dump($c1 !== $c1);`, "bool(false)\n")
 	testInputOutput(t, `<?php class c {} $c1 = new c; var_dump($c1 === null);`, "bool(false)\n")
+
+	// Resource
+	testInputOutput(t, `<?php $a = proc_open("true", [], $p); $b = proc_open("true", [], $p); var_dump($a === $a, $a === $b, $a === 1);`, "bool(true)\nbool(false)\nbool(false)\n")
+	testInputOutput(t, `<?php $a = proc_open("true", [], $p); $b = proc_open("true", [], $p); var_dump(in_array($b, [$a, $b], true));`, "bool(true)\n")
 }
 
 func TestLooseComparison(t *testing.T) {
@@ -1011,11 +1279,20 @@ func TestLooseComparison(t *testing.T) {
 	testInputOutput(t, `<?php var_dump("1e1" == "10");`, "bool(true)\n")
 	testInputOutput(t, `<?php var_dump(100 == "1e2");`, "bool(true)\n")
 	testInputOutput(t, `<?php var_dump(1e2 == "100");`, "bool(true)\n")
+	// Numeric strings with whitespaces
+	testInputOutput(t, `<?php var_dump("1 " == 1, 1 == "1 ", " 1" == 1.0, "\n1\t" == 1);`, "bool(true)\nbool(true)\nbool(true)\nbool(true)\n")
+	testInputOutput(t, `<?php var_dump("1 " == "1", " 1" == "1 ", "100" == "1e2 ", "1 a" == "1");`, "bool(true)\nbool(true)\nbool(true)\nbool(false)\n")
+	testInputOutput(t, `<?php var_dump(1 == "1.5", "1abc" == 1, "abc" == 0, null == "0");`, "bool(false)\nbool(false)\nbool(false)\nbool(false)\n")
 
 	// Object
 	testInputOutput(t, `<?php class c {} $c1 = new c; var_dump($c1 == $c1);`, "bool(true)\n")
 	testInputOutput(t, `<?php class c {} $c1 = new c; var_dump($c1 != $c1);`, "bool(false)\n")
 	testInputOutput(t, `<?php class c {} $c1 = new c; var_dump($c1 == null);`, "bool(false)\n")
+
+	// Resource
+	testInputOutput(t, `<?php $a = proc_open("true", [], $p); $b = proc_open("true", [], $p); var_dump($a == $a, $a == $b, $a != $b);`, "bool(true)\nbool(false)\nbool(true)\n")
+	testInputOutput(t, `<?php $a = proc_open("true", [], $p); var_dump($a == 1, $a == "1", $a == true, $a == null);`, "bool(true)\nbool(true)\nbool(true)\nbool(false)\n")
+	testInputOutput(t, `<?php $a = proc_open("true", [], $p); $b = proc_open("true", [], $p); var_dump(in_array($b, [$a, $b]), array_search($b, [$a, $b]));`, "bool(true)\nint(1)\n")
 }
 
 func TestCompareRelation(t *testing.T) {
@@ -1302,6 +1579,17 @@ func TestCompareRelation(t *testing.T) {
 	testInputOutput(t, `<?php class c {} $c1 = new c; var_dump($c1 < NULL);`, "bool(false)\n")
 	testInputOutput(t, `<?php class c {} $c1 = new c; var_dump($c1 <= NULL);`, "bool(false)\n")
 	testInputOutput(t, `<?php class c {} $c1 = new c; var_dump($c1 <=> null);`, "int(1)\n")
+
+	// Numeric strings with whitespaces
+	testInputOutput(t, `<?php var_dump("1 " <=> 1, " 1" <=> "1 ", "1 " <=> "2", "1e1" <=> "9 ", " 10" <=> "9");`, "int(0)\nint(0)\nint(-1)\nint(1)\nint(1)\n")
+	testInputOutput(t, `<?php var_dump(1 < "1.5", "1.5 " > 1, 1.5 <=> " 1.5");`, "bool(true)\nbool(true)\nint(0)\n")
+	// Numbers and non-numeric strings are compared as strings
+	testInputOutput(t, `<?php var_dump(1 <=> "a", "a" <=> 1, 10 <=> "1a", 1.5 <=> "1.5a", "" <=> 0);`, "int(-1)\nint(1)\nint(-1)\nint(-1)\nint(-1)\n")
+
+	// Resource
+	testInputOutput(t, `<?php $a = proc_open("true", [], $p); $b = proc_open("true", [], $p); var_dump($a < $b, $b <= $a, $b <=> $a);`, "bool(true)\nbool(false)\nint(1)\n")
+	testInputOutput(t, `<?php $a = proc_open("true", [], $p); var_dump($a <=> 1, 2 > $a, $a > null, $a < []);`, "int(0)\nbool(true)\nbool(true)\nbool(true)\n")
+	testInputOutput(t, `<?php $a = proc_open("true", [], $p); $b = proc_open("true", [], $p); $arr = [$b, $a]; sort($arr); var_dump($arr[0] === $a);`, "bool(true)\n")
 }
 
 // -------------------------------------- classes and objects -------------------------------------- MARK: classes and objects
@@ -1316,6 +1604,48 @@ func TestClasses(t *testing.T) {
 	testInputOutput(t, `<?php class c { public int $i = 42; } $c = new c; var_dump($c->i);`, "int(42)\n")
 	// TODO testInputOutput(t, `<?php class c { private int $i = 42; } $c = new c; var_dump($c->i);`, "int(42)\n")
 	testForError(t, `<?php class c { } $c = new c; $c->prop;`, phpError.NewError("Undefined property: c::$prop in %s:1:35", TEST_FILE_NAME))
+	testInputOutput(t, `<?php class c { public $i = 1; } $c = new c; $c->i = 42; $c->i += 1; var_dump($c->i);`, "int(43)\n")
+	testInputOutput(t, `<?php class c { } $c = new c; $name = "prop"; $c->$name = "a"; $c->{"pr" . "op"} .= "b"; var_dump($c->prop);`, `string(2) "ab"`+"\n")
+
+	// Method call
+	testInputOutput(t, `<?php class c { public $i = 42; function get() { return $this->i; } } $c = new c; var_dump($c->get());`, "int(42)\n")
+	testInputOutput(t, `<?php class p { function hi() { return __CLASS__ . "::hi"; } } class c extends p { } $c = new c; echo $c->hi();`, "p::hi")
+	testInputOutput(t, `<?php class p { function hi() { return "p"; } } class c extends p { function hi() { return parent::hi() . "c"; } } echo (new c)->hi();`, "pc")
+	testInputOutput(t, `<?php class c { static function create() { return new c; } function hi() { return "hi"; } } echo c::create()->hi();`, "hi")
+	testForError(t, `<?php class c { } $c = new c; $c->hi();`, phpError.NewError("Uncaught Error: Call to undefined method c::hi() in %s:1:33", TEST_FILE_NAME))
+	testForError(t, `<?php class c { private function hi() { } } $c = new c; $c->hi();`, phpError.NewError("Uncaught Error: Call to private method c::hi() from global scope in %s:1:59", TEST_FILE_NAME))
+	testForError(t, `<?php $c = null; $c->hi();`, phpError.NewError("Uncaught Error: Call to a member function hi() on null in %s:1:20", TEST_FILE_NAME))
+
+	// Class constant access
+	testInputOutput(t, `<?php class p { const A = 1; } class c extends p { const B = self::A + 1; } var_dump(c::A, c::B, c::class);`, "int(1)\nint(2)\n"+`string(1) "c"`+"\n")
+
+	// Chained dereferencing
+	testInputOutput(t, `<?php
+		class Foo {
+			public $child = null;
+			function bar() { return ["x" => $this->child]; }
+			function baz() { return [1, 2, 3]; }
+		}
+		$foo = new Foo;
+		$foo->child = new Foo;
+		var_dump((new Foo)->baz()[1]);
+		var_dump($foo->bar()['x']->baz()[2]);
+		function f() { return [[1, 2], [3, 4]]; }
+		var_dump(f()[1][0]);
+		var_dump([10, 20][1]);
+		var_dump("abc"[2]);
+		$fn = ["strtoupper"];
+		var_dump($fn[0]("a"));`,
+		"int(2)\nint(3)\nint(3)\nint(20)\n"+`string(1) "c"`+"\n"+`string(1) "A"`+"\n",
+	)
+
+	// Nullsafe operator
+	testInputOutput(t, `<?php $a = null; var_dump($a?->b);`, "NULL\n")
+	testInputOutput(t, `<?php $a = null; var_dump($a?->b()->c['d']->e());`, "NULL\n")
+	testInputOutput(t, `<?php class Foo { public $child = null; function bar() { return ['x' => $this->child]; } } var_dump((new Foo)->bar()['x']?->baz);`, "NULL\n")
+	testInputOutput(t, `<?php class Foo { public $v = 42; function bar() { return ['x' => $this]; } } var_dump((new Foo)->bar()['x']?->v);`, "int(42)\n")
+	testInputOutput(t, `<?php function f() { echo "called"; return 1; } $a = null; var_dump($a?->b(f()));`, "NULL\n")
+	testInputOutput(t, `<?php $a = null; var_dump(isset($a?->b), $a?->b ?? "default");`, "bool(false)\n"+`string(7) "default"`+"\n")
 
 	// Destructor
 	testInputOutput(t, `<?php class c { function __destruct() { echo __METHOD__; } } $c = new c; echo "Done\n";`, "Done\nc::__destruct")
@@ -1340,6 +1670,94 @@ func TestClasses(t *testing.T) {
 		func1();`,
 		"1\nC::__construct\nC::__destruct\n2\n3\nC::__construct\n4\nC::__destruct\n",
 	)
+	testInputOutput(t, `<?php
+		class C {
+			public $other;
+			public $name; function __construct($name) { $this->name = $name; }
+			function __destruct() { echo "destruct {$this->name}\n"; }
+		}
+		$a = new C("a");
+		$a = new C("b");
+		echo "reassigned\n";
+		unset($a);
+		echo "unset\n";
+		$array = [new C("element")];
+		$array[0] = null;
+		echo "element\n";
+		$object = new C("outer");
+		$object->other = new C("inner");
+		$object = null;
+		echo "property\n";
+		function create() { return new C("returned"); }
+		create();
+		echo "discarded\n";
+		$kept = create();
+		foreach ([new C("iterated")] as $value) { echo "loop\n"; }
+		unset($value);
+		echo "foreach\n";
+		$first = new C("first");
+		$second = new C("second");
+		echo "end\n";`,
+		"destruct a\nreassigned\ndestruct b\nunset\ndestruct element\nelement\ndestruct outer\ndestruct inner\nproperty\n"+
+			"destruct returned\ndiscarded\nloop\ndestruct iterated\nforeach\nend\ndestruct second\ndestruct first\ndestruct returned\n",
+	)
+	testInputOutput(t, `<?php
+		class C { function __destruct() { echo "destruct\n"; } }
+		$a = ["x" => ["y" => new C]];
+		$b = $a;
+		$a["x"]["y"] = 1;
+		echo "copy\n";
+		unset($b);
+		echo "done\n";`,
+		"copy\ndestruct\ndone\n",
+	)
+	testInputOutput(t, `<?php
+		class C { function __destruct() { global $saved; echo "destruct\n"; $saved = $this; } }
+		$c = new C;
+		$c = null;
+		echo get_class($saved), "\n";
+		$saved = null;
+		echo "done\n";`,
+		"destruct\nC\ndone\n",
+	)
+
+	testInputOutput(t, `<?php
+		class C { function __destruct() { echo "destruct\n"; } }
+		foreach ([new C] as $value) { $value = null; echo "loop\n"; }
+		echo "done\n";`,
+		"loop\ndestruct\ndone\n",
+	)
+
+	// Garbage collection
+	testInputOutput(t, `<?php
+		class C { public $other; public $name; function __construct($name) { $this->name = $name; } function __destruct() { echo "destruct {$this->name}\n"; } }
+		$a = new C("a");
+		$b = new C("b");
+		$a->other = $b;
+		$b->other = $a;
+		unset($a, $b);
+		echo "unset\n";
+		var_dump(gc_collect_cycles());
+		var_dump(gc_collect_cycles());`,
+		"unset\ndestruct a\ndestruct b\nint(2)\nint(0)\n",
+	)
+	testInputOutput(t, `<?php
+		class C { public $self; }
+		function cycle() { $c = new C; $c->self = $c; }
+		for ($i = 0; $i < 10001; $i++) { cycle(); }
+		$status = gc_status();
+		echo $status["runs"], " ", $status["collected"], " ", $status["roots"], "\n";
+		gc_disable();
+		var_dump(gc_enabled());
+		for ($i = 0; $i < 10001; $i++) { cycle(); }
+		echo gc_status()["runs"], " ", gc_collect_cycles(), "\n";
+		gc_enable();
+		var_dump(gc_enabled(), ini_get("zend.enable_gc"));`,
+		"1 10001 0\nbool(false)\n1 10001\nbool(true)\nstring(1) \"1\"\n",
+	)
+	testInputOutput(t, `<?php foreach (gc_status() as $key => $value) { echo $key, " "; }`,
+		"running protected full runs collected threshold buffer_size roots application_time collector_time destructor_time free_time ",
+	)
 
 	// Class with namespace
 	testInputOutput(t, `<?php
@@ -1348,4 +1766,106 @@ func TestClasses(t *testing.T) {
 	new C;`,
 		`string(14) "My\Namespace\C"`+"\n",
 	)
+
+	// Clone
+	testInputOutput(t, `<?php
+		class Money {
+			public $amount = 0;
+			public $currency;
+			function __construct() { $this->currency = new stdClass(); }
+			function withAmount($amount) { $clone = clone $this; $clone->amount = $amount; return $clone; }
+			function __clone() { echo "__clone\n"; }
+		}
+		$a = new Money();
+		$b = $a->withAmount(5);
+		echo $a->amount, $b->amount, "\n";
+		var_dump($a == $b, $a === $b, $a->currency === $b->currency);
+		$c = clone $a;
+		var_dump($a == $c, $a === $c);`,
+		"__clone\n05\nbool(false)\nbool(false)\nbool(true)\n__clone\nbool(true)\nbool(false)\n",
+	)
+	testInputOutput(t, `<?php class C { public $a = [1]; } $a = new C(); $b = clone $a; $b->a = [1, 2]; echo count($a->a), count($b->a);`, "12")
+	testInputOutput(t, `<?php class C { private function __clone() {} static function copy($c) { return clone $c; } } var_dump(C::copy(new C()) == new C());`, "bool(true)\n")
+	testForError(t, `<?php class C { private function __clone() {} } $c = clone new C();`,
+		phpError.NewError("Uncaught Error: Call to private C::__clone() from global scope in %s:1:54", TEST_FILE_NAME),
+	)
+	testForError(t, `<?php $a = 42; $b = clone $a;`, phpError.NewError("Uncaught Error: __clone method called on non-object in %s:1:21", TEST_FILE_NAME))
+
+	// Object comparison
+	testInputOutput(t, `<?php class A { public $a = 1; } class B { public $a = 1; } var_dump(new A() == new A(), new A() == new B(), new A() === new A());`,
+		"bool(true)\nbool(false)\nbool(false)\n",
+	)
+	testInputOutput(t, `<?php class A { public $a = 1; } $a = new A(); $b = new A(); $b->a = 2; var_dump($a == $b, $a < $b, $a <=> $b);`,
+		"bool(false)\nbool(true)\nint(-1)\n",
+	)
+	// Objects that refer to themselves
+	testInputOutput(t, `<?php $a = new stdClass(); $a->x = $a; $c = new stdClass(); $b = new stdClass(); $b->x = $c; $b->y = $c; $d = clone $b; var_dump($a == $a, $b == $d);`,
+		"bool(true)\nbool(true)\n",
+	)
+	for _, expr := range []string{"$a == $b", "$a < $b", "in_array($a, [$b])", "sort($c)"} {
+		testForError(t, `<?php $a = new stdClass(); $b = new stdClass(); $a->x = $a; $b->x = $b; $c = [$a, $b]; `+expr+`;`,
+			phpError.NewError("Nesting level too deep - recursive dependency?"),
+		)
+	}
+	testForError(t, `<?php $a = new stdClass(); $b = new stdClass(); $a->x = [$a]; $b->x = [$b]; $a == $b;`,
+		phpError.NewError("Nesting level too deep - recursive dependency?"),
+	)
+}
+
+func TestAttributes(t *testing.T) {
+	// Attributes of classes, methods, parameters, properties and class constants
+	testInputOutput(t, `<?php
+		#[Attribute(Attribute::TARGET_METHOD | Attribute::IS_REPEATABLE)]
+		class Route {
+			public function __construct(string $path, string $method) { $this->path = $path; $this->method = $method; }
+		}
+		#[Attribute]
+		class Entity {}
+		#[Entity]
+		class HomeController {
+			const PREFIX = "/app";
+			#[Route(self::PREFIX . "/home", method: "GET")]
+			#[Route(method: "POST", path: "/home")]
+			public function home(#[SensitiveParameter] string $password) {}
+			public function other() {}
+			#[Column("id")] public int $id;
+			#[Deprecated] const A = 1;
+		}
+		$class = new ReflectionClass(HomeController::class);
+		foreach ($class->getAttributes() as $attribute) { echo $attribute->getName(), $attribute->getTarget(), get_class($attribute->newInstance()), "\n"; }
+		foreach ($class->getMethods() as $method) {
+			echo $method->getName(), count($method->getAttributes(Route::class)), "\n";
+			foreach ($method->getAttributes(Route::class) as $attribute) {
+				$route = $attribute->newInstance();
+				echo $route->method, " ", $route->path, "\n";
+			}
+		}
+		$parameter = (new ReflectionMethod("HomeController::home"))->getParameters()[0];
+		echo $parameter->getName(), $parameter->getPosition(), $parameter->getAttributes()[0]->getName(), "\n";
+		var_dump($class->getProperty("id")->getAttributes()[0]->getArguments());
+		echo (new ReflectionClassConstant("HomeController", "A"))->getAttributes()[0]->getName(), "\n";
+		echo count((new ReflectionClass("Route"))->getAttributes(Attribute::class, ReflectionAttribute::IS_INSTANCEOF));`,
+		"Entity1Entity\nhome2\nGET /app/home\nPOST /home\nother0\npassword0SensitiveParameter\narray(1) {\n  [0]=>\n  string(2) \"id\"\n}\nDeprecated\n1",
+	)
+	testInputOutput(t, `<?php #[Attribute] class A { public function __construct(int $a, int $b) {} } #[A(b: 2, a: 1)] function f() {}
+		$attribute = (new ReflectionFunction("f"))->getAttributes()[0];
+		var_dump($attribute->getArguments(), $attribute->isRepeated());`,
+		"array(2) {\n  [\"b\"]=>\n  int(2)\n  [\"a\"]=>\n  int(1)\n}\nbool(false)\n",
+	)
+
+	// Validation in newInstance
+	testForError(t, `<?php #[Attribute(Attribute::TARGET_CLASS)] class A {} #[A] function f() {} (new ReflectionFunction("f"))->getAttributes()[0]->newInstance();`,
+		phpError.NewError(`Uncaught Error: Attribute "A" cannot target function (allowed targets: class)`),
+	)
+	testForError(t, `<?php class A {} #[A] function f() {} (new ReflectionFunction("f"))->getAttributes()[0]->newInstance();`,
+		phpError.NewError(`Uncaught Error: Attempting to use non-attribute class "A" as attribute`),
+	)
+	testForError(t, `<?php #[Attribute] class A {} #[A, A] function f() {} (new ReflectionFunction("f"))->getAttributes()[0]->newInstance();`,
+		phpError.NewError(`Uncaught Error: Attribute "A" must not be repeated`),
+	)
+	testForError(t, `<?php #[Attribute] class A { public function __construct(int $a) {} } #[A(b: 1)] function f() {} (new ReflectionFunction("f"))->getAttributes()[0]->newInstance();`,
+		phpError.NewError(`Uncaught Error: Unknown named parameter $b`),
+	)
+	testForError(t, `<?php #[A(a: 1, 2)] function f() {}`, phpError.NewError("Cannot use positional argument after named argument in %s:1:17", TEST_FILE_NAME))
+	testForError(t, `<?php new ReflectionClass("A");`, phpError.NewError(`Uncaught ReflectionException: Class "A" does not exist`))
 }
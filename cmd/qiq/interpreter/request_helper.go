@@ -26,7 +26,7 @@ func parseCookies(cookies string, interpreter runtime.Interpreter) *values.Array
 	result := values.NewArray()
 
 	for cookies != "" {
-		if int64(len(result.Keys)) >= interpreter.GetIni().GetInt("max_input_vars") {
+		if int64(result.Count()) >= interpreter.GetIni().GetInt("max_input_vars") {
 			break
 		}
 
@@ -236,7 +236,7 @@ func parseQuery(query string, interpreter runtime.Interpreter) (*values.Array, e
 	result := values.NewArray()
 
 	for query != "" {
-		if int64(len(result.Keys)) >= interpreter.GetIni().GetInt("max_input_vars") {
+		if int64(result.Count()) >= interpreter.GetIni().GetInt("max_input_vars") {
 			break
 		}
 
@@ -0,0 +1,816 @@
+package interpreter
+
+import (
+	"QIQ/cmd/qiq/ast"
+	"QIQ/cmd/qiq/ini"
+	"QIQ/cmd/qiq/phpError"
+	"QIQ/cmd/qiq/runtime"
+	"QIQ/cmd/qiq/runtime/stdlib/variableHandling"
+	"QIQ/cmd/qiq/runtime/values"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// The VM executes the bytecode produced by the compiler (see compiler.go).
+// It is the default engine. The tree-walking interpreter is used with the ini directive "qiq.engine=ast".
+
+const (
+	engineAST = "ast"
+	engineVM  = "vm"
+)
+
+type vmIterator struct {
+	// Arrays are iterated over a copy so that modifications in the body do not affect the iteration
+	array    *values.Array
+	object   *values.Object
+	names    []string
+	position int
+}
+
+type vmFrame struct {
+	unit   *compiledUnit
+	env    *Environment
+	locals []values.RuntimeValue
+	stack  []values.RuntimeValue
+	// Iterators of the active foreach loops
+	iterators []*vmIterator
+	// Error reporting values that were active before opSuppressErrors
+	savedErrorReporting []string
+}
+
+func newFrame(unit *compiledUnit, env *Environment) *vmFrame {
+	// Locals and stack share one allocation. The stack grows if the compiler underestimated its depth.
+	// The first locals are the slots of the environment, so that the tree-walking interpreter accesses the same variables.
+	locals := make([]values.RuntimeValue, len(unit.variables), len(unit.variables)+unit.maxStackDepth)
+	if unit.slots > 0 {
+		copy(locals, env.slots)
+		env.slots = locals[:unit.slots:unit.slots]
+	}
+	return &vmFrame{unit: unit, env: env, locals: locals, stack: locals[len(locals):]}
+}
+
+// The iterated array or object is held by the iterator until the loop is left
+func (frame *vmFrame) pushIterator(iterator *vmIterator) {
+	if iterator.array != nil {
+		values.Retain(iterator.array)
+	} else {
+		values.Retain(iterator.object)
+	}
+	frame.iterators = append(frame.iterators, iterator)
+}
+
+// Remove the iterators after the given count. All iterators are removed if a function is left by return or an error.
+func (frame *vmFrame) popIterators(count int) {
+	for _, iterator := range frame.iterators[count:] {
+		if iterator.array != nil {
+			values.Release(iterator.array)
+		} else {
+			values.Release(iterator.object)
+		}
+	}
+	frame.iterators = frame.iterators[:count]
+}
+
+// -------------------------------------- Entry points -------------------------------------- MARK: Entry points
+
+// Process a program compiled to bytecode
+func (interpreter *Interpreter) processCompiledProgram(program *ast.Program, env *Environment) (values.RuntimeValue, phpError.Error) {
+	frame := newFrame(interpreter.compileProgram(program.GetStatements()), env)
+	runtimeValue, returned, err := interpreter.run(frame)
+	frame.popIterators(0)
+	if err == nil && returned {
+		return runtimeValue, phpError.NewEvent(phpError.ReturnEvent)
+	}
+	return runtimeValue, err
+}
+
+// Process the body of a function or method whose parameters are declared in the given environment
+func (interpreter *Interpreter) processFunctionBody(function ast.IStatement, body *ast.CompoundStatement, env *Environment) (values.RuntimeValue, phpError.Error) {
+	if interpreter.engine == engineAST {
+		env.garbageMark = interpreter.gc.Mark()
+		return interpreter.processStmt(body, env)
+	}
+
+	frame := newFrame(interpreter.compileFunctionBody(function, env.scope, body), env)
+	env.garbageMark = interpreter.gc.Mark()
+	runtimeValue, returned, err := interpreter.run(frame)
+	frame.popIterators(0)
+	if err == nil && returned {
+		return runtimeValue, phpError.NewEvent(phpError.ReturnEvent)
+	}
+	return runtimeValue, err
+}
+
+// Call a user function with the evaluated arguments
+func (interpreter *Interpreter) callCompiledFunction(
+	env *Environment, site *callSite, args []values.RuntimeValue,
+) (values.RuntimeValue, phpError.Error) {
+	function := site.function
+	functionEnv, err := NewEnvironment(env, nil, interpreter)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+	functionEnv.CurrentFunction = function
+	functionEnv.scope = function.Scope
+
+	if site.unit == nil {
+		site.unit = interpreter.compileFunctionBody(function, function.Scope, function.Body)
+	}
+	frame := newFrame(site.unit, functionEnv)
+	for index, param := range function.Params {
+		// Check if the parameter types match
+		runtimeValue, err := interpreter.checkArgumentType(args[index], function.FunctionName, index, param, functionEnv, site.expr.Arguments[index])
+		if err != nil {
+			return values.NewVoid(), err
+		}
+		if function.Scope == nil {
+			functionEnv.declareVariable(param.Name, runtimeValue)
+			continue
+		}
+		functionEnv.setSlot(function.Scope.Params[index], runtimeValue)
+	}
+
+	functionEnv.garbageMark = interpreter.gc.Mark()
+	runtimeValue, _, err := interpreter.run(frame)
+	frame.popIterators(0)
+	if err != nil {
+		return runtimeValue, err
+	}
+	interpreter.releaseEnvironment(functionEnv, runtimeValue)
+	return interpreter.checkReturnType(runtimeValue, function.ReturnType, function.FunctionName, functionEnv, function)
+}
+
+// -------------------------------------- Execution -------------------------------------- MARK: Execution
+
+// Execute the bytecode of the frame. Returns true if a return statement was executed.
+func (interpreter *Interpreter) run(frame *vmFrame) (result values.RuntimeValue, returned bool, err phpError.Error) {
+	unit := frame.unit
+	if unit.suppressesErrors {
+		defer func() {
+			// Restore the error reporting if an error occurred in the condition of "??"
+			if len(frame.savedErrorReporting) > 0 {
+				interpreter.ini.Set("error_reporting", frame.savedErrorReporting[0], ini.INI_ALL)
+				frame.savedErrorReporting = nil
+			}
+		}()
+	}
+
+	code := unit.code
+	locals := frame.locals
+	stack := frame.stack
+	pc := 0
+
+	for {
+		instr := &code[pc]
+		pc++
+
+		switch instr.op {
+		case opConst:
+			stack = append(stack, unit.constants[instr.arg])
+
+		case opConstant:
+			value, err := frame.env.LookupConstant(unit.names[instr.arg])
+			if err != nil {
+				return value, false, err
+			}
+			stack = append(stack, value)
+
+		case opPop:
+			stack = stack[:len(stack)-1]
+
+		case opDup:
+			stack = append(stack, stack[len(stack)-1])
+
+		case opLoad:
+			if value := locals[instr.arg]; value != nil {
+				stack = append(stack, value)
+				continue
+			}
+			stack = append(stack, interpreter.loadVariable(frame, instr.arg, instr.arg2 == 1, instr.node))
+
+		case opStore, opAssign:
+			value := stack[len(stack)-1]
+			if instr.op == opStore {
+				stack = stack[:len(stack)-1]
+			}
+			interpreter.storeVariable(frame, instr.arg, value)
+			if instr.arg2 == 1 && interpreter.gc.HasWork(frame.env.garbageMark) {
+				interpreter.collectGarbage(frame.env)
+			}
+
+		case opUnset:
+			if variable := unit.variables[instr.arg]; variable.named {
+				frame.env.unsetVariable(variable.name)
+			} else {
+				values.Release(locals[instr.arg])
+				locals[instr.arg] = nil
+			}
+
+		case opIncDec:
+			previous := locals[instr.arg]
+			if previous == nil {
+				previous = interpreter.loadVariable(frame, instr.arg, false, instr.node)
+			}
+			var newValue values.RuntimeValue
+			if integer, ok := previous.(*values.Int); ok && integer.Value > math.MinInt64 && integer.Value < math.MaxInt64 {
+				// Fast path for integers that do not overflow
+				if instr.arg2&incDecDecrement != 0 {
+					newValue = values.NewInt(integer.Value - 1)
+				} else {
+					newValue = values.NewInt(integer.Value + 1)
+				}
+			} else {
+				operator := "++"
+				if instr.arg2&incDecDecrement != 0 {
+					operator = "--"
+				}
+				var err phpError.Error
+				newValue, err = calculateIncDec(operator, previous)
+				if err != nil {
+					return newValue, false, err
+				}
+			}
+			interpreter.storeVariable(frame, instr.arg, newValue)
+			if instr.arg2&incDecDiscard != 0 {
+				continue
+			}
+			if instr.arg2&incDecPrefix != 0 {
+				stack = append(stack, newValue)
+			} else {
+				stack = append(stack, previous)
+			}
+
+		case opInitDim:
+			switch interpreter.loadVariable(frame, instr.arg, true, instr.node).GetType() {
+			case values.ArrayValue:
+			case values.NullValue:
+				interpreter.storeVariable(frame, instr.arg, values.NewArray())
+			default:
+				// Assignments to string offsets and other values are processed by the tree-walking interpreter
+				value, err := interpreter.fallback(frame, instr.node)
+				if err != nil {
+					return value, false, err
+				}
+				stack = append(stack, value)
+				pc = instr.arg2
+			}
+
+		case opAssignDim:
+			value := stack[len(stack)-1]
+			keys := stack[len(stack)-1-instr.arg2 : len(stack)-1]
+			stack = stack[:len(stack)-1-instr.arg2]
+			current := interpreter.loadVariable(frame, instr.arg, true, instr.node)
+			if current.GetType() != values.ArrayValue {
+				return values.NewVoid(), false, phpError.NewError("processSimpleAssignmentExpr: Unexpected currentValue type %s", current.GetType())
+			}
+			if err := assignArrayElement(current.(*values.Array), keys, value); err != nil {
+				return values.NewVoid(), false, err
+			}
+			stack = append(stack, value)
+
+		case opFetchDim:
+			key := stack[len(stack)-1]
+			container := stack[len(stack)-2]
+			stack = stack[:len(stack)-2]
+			if array, ok := container.(*values.Array); ok {
+				if element, found := array.GetElement(key); found {
+					stack = append(stack, element)
+				} else {
+					stack = append(stack, values.NewNull())
+				}
+				continue
+			}
+			value, err := interpreter.fetchDim(container, key, instr.node.(*ast.SubscriptExpression), instr.arg2 == 1)
+			if err != nil {
+				return value, false, err
+			}
+			stack = append(stack, value)
+
+		case opAdd, opSub, opMul, opMod, opConcat, opBinary,
+			opLess, opLessEqual, opGreater, opGreaterEqual, opRelational,
+			opEqual, opNotEqual, opIdentical, opNotIdentical, opEquality:
+			if instr.op == opAdd {
+				// Fast path for the most frequent operation
+				if left, ok := stack[len(stack)-2].(*values.Int); ok {
+					if right, ok := stack[len(stack)-1].(*values.Int); ok {
+						if sum := left.Value + right.Value; (sum > left.Value) == (right.Value > 0) {
+							stack = stack[:len(stack)-1]
+							stack[len(stack)-1] = values.NewInt(sum)
+							continue
+						}
+					}
+				}
+			}
+			value, err := interpreter.binaryOperation(instr.op, stack[len(stack)-2], stack[len(stack)-1], unit.names, instr.arg, instr.node)
+			if err != nil {
+				return value, false, err
+			}
+			stack = stack[:len(stack)-1]
+			stack[len(stack)-1] = value
+
+		case opUnary:
+			value, err := calculateUnary(unit.names[instr.arg], stack[len(stack)-1])
+			if err != nil {
+				return value, false, err
+			}
+			stack[len(stack)-1] = value
+
+		case opNot, opToBool:
+			boolean, err := toBool(stack[len(stack)-1])
+			if err != nil {
+				return values.NewVoid(), false, err
+			}
+			stack[len(stack)-1] = values.NewBool(boolean == (instr.op == opToBool))
+
+		case opCast:
+			value, err := interpreter.castValue(unit.names[instr.arg], stack[len(stack)-1])
+			if err != nil {
+				return value, false, err
+			}
+			stack[len(stack)-1] = value
+
+		case opJump:
+			pc = instr.arg
+
+		case opJumpIfFalse, opJumpIfTrue:
+			boolean, err := toBool(stack[len(stack)-1])
+			if err != nil {
+				return values.NewVoid(), false, err
+			}
+			stack = stack[:len(stack)-1]
+			if boolean == (instr.op == opJumpIfTrue) {
+				pc = instr.arg
+			}
+
+		case opCompareJump:
+			lhs, rhs := stack[len(stack)-2], stack[len(stack)-1]
+			stack = stack[:len(stack)-2]
+			if left, ok := lhs.(*values.Int); ok {
+				if right, ok := rhs.(*values.Int); ok {
+					if !compareIntegers(opcode(instr.arg2), left.Value, right.Value) {
+						pc = instr.arg
+					}
+					continue
+				}
+			}
+			value, err := interpreter.binaryOperation(opcode(instr.arg2), lhs, rhs, unit.names, 0, instr.node)
+			if err != nil {
+				return value, false, err
+			}
+			boolean, err := toBool(value)
+			if err != nil {
+				return values.NewVoid(), false, err
+			}
+			if !boolean {
+				pc = instr.arg
+			}
+
+		case opJumpIfNotNull:
+			if stack[len(stack)-1].GetType() != values.NullValue {
+				pc = instr.arg
+				continue
+			}
+			stack = stack[:len(stack)-1]
+
+		case opJumpIfNull:
+			value := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if value.GetType() == values.NullValue {
+				pc = instr.arg
+			}
+
+		case opEcho:
+			value := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if str, ok := value.(*values.Str); ok {
+				interpreter.Print(str.Value)
+				continue
+			}
+			str, err := interpreter.strVal(value)
+			if err != nil {
+				return values.NewVoid(), false, err
+			}
+			interpreter.Print(str)
+
+		case opPrint:
+			str, err := interpreter.strVal(stack[len(stack)-1])
+			stack[len(stack)-1] = values.NewInt(1)
+			if err != nil {
+				return values.NewInt(1), false, err
+			}
+			interpreter.Print(str)
+
+		case opReturn:
+			return stack[len(stack)-1], true, nil
+
+		case opReturnVoid:
+			return values.NewVoid(), true, nil
+
+		case opEnd:
+			return values.NewVoid(), false, nil
+
+		case opNewArray:
+			stack = append(stack, values.NewArray())
+
+		case opArrayAppend:
+			value := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if err := stack[len(stack)-1].(*values.Array).SetElement(nil, value); err != nil {
+				return values.NewVoid(), false, err
+			}
+
+		case opArrayInsert:
+			key := stack[len(stack)-2]
+			value := stack[len(stack)-1]
+			stack = stack[:len(stack)-2]
+			if err := stack[len(stack)-1].(*values.Array).SetElement(key, value); err != nil {
+				return values.NewVoid(), false, err
+			}
+
+		case opInterpolate:
+			value, err := interpreter.interpolate(frame, unit.interpolations[instr.arg])
+			if err != nil {
+				return value, false, err
+			}
+			stack = append(stack, value)
+
+		case opCheckCall:
+			if err := resolveCallSite(frame.env, unit.callSites[instr.arg]); err != nil {
+				return values.NewVoid(), false, err
+			}
+
+		case opCall:
+			site := unit.callSites[instr.arg]
+			args := stack[len(stack)-instr.arg2:]
+			var value values.RuntimeValue
+			var err phpError.Error
+			if site.native != nil {
+				functionArguments := make([]values.RuntimeValue, len(args))
+				for index, arg := range args {
+					functionArguments[index] = values.Copy(arg)
+				}
+				value, err = site.native(functionArguments, runtime.NewContext(interpreter, frame.env, site.expr))
+				discardArguments(functionArguments, value)
+			} else {
+				value, err = interpreter.callCompiledFunction(frame.env, site, args)
+			}
+			if err != nil {
+				return value, false, err
+			}
+			stack = append(stack[:len(stack)-instr.arg2], value)
+
+		case opIterInit:
+			value := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			switch value.GetType() {
+			case values.ArrayValue:
+				frame.pushIterator(&vmIterator{array: value.(*values.Array).Copy()})
+			case values.ObjectValue:
+				object := value.(*values.Object)
+				frame.pushIterator(&vmIterator{object: object, names: object.PropertyNames})
+			default:
+				interpreter.PrintError(phpError.NewWarning(
+					"foreach() argument must be of type array|object, %s given in %s", getForeachTypeName(value), instr.node.GetPosString(),
+				))
+				pc = instr.arg
+			}
+
+		case opIterNext:
+			iterator := frame.iterators[len(frame.iterators)-1]
+			key, value, found := iterator.next()
+			if !found {
+				frame.popIterators(len(frame.iterators) - 1)
+				pc = instr.arg
+				continue
+			}
+			stack = append(stack, value)
+			if instr.arg2 == 1 {
+				stack = append(stack, key)
+			}
+
+		case opIterPop:
+			frame.popIterators(len(frame.iterators) - 1)
+
+		case opFreeObjects:
+			interpreter.collectGarbage(frame.env)
+
+		case opSuppressErrors:
+			errorReporting, _ := interpreter.ini.Get("error_reporting")
+			frame.savedErrorReporting = append(frame.savedErrorReporting, errorReporting)
+			interpreter.ini.Set("error_reporting", "0", ini.INI_ALL)
+
+		case opRestoreErrors:
+			errorReporting := frame.savedErrorReporting[len(frame.savedErrorReporting)-1]
+			frame.savedErrorReporting = frame.savedErrorReporting[:len(frame.savedErrorReporting)-1]
+			interpreter.ini.Set("error_reporting", errorReporting, ini.INI_ALL)
+
+		case opFallback:
+			value, err := interpreter.fallback(frame, instr.node)
+			if err != nil {
+				if err.GetErrorType() != phpError.EventError {
+					return value, false, err
+				}
+				switch err.GetMessage() {
+				case phpError.ReturnEvent:
+					return value, true, nil
+				case phpError.BreakEvent, phpError.ContinueEvent:
+					level := int(err.(*phpError.ContinueEventError).GetBreakoutLevel())
+					if instr.arg == -1 || level < 1 {
+						return value, false, err
+					}
+					table := unit.jumpTables[instr.arg]
+					if level > len(table) {
+						if err.GetMessage() == phpError.BreakEvent {
+							return value, false, phpError.NewBreakEvent(int64(level - len(table)))
+						}
+						return value, false, phpError.NewContinueEvent(int64(level - len(table)))
+					}
+					// Statements start with an empty stack
+					stack = stack[:0]
+					target := table[level-1]
+					if err.GetMessage() == phpError.BreakEvent {
+						frame.popIterators(target.outerIterators)
+						pc = target.breakTarget
+					} else {
+						frame.popIterators(target.innerIterators)
+						pc = target.continueTarget
+					}
+					continue
+				default:
+					return value, false, err
+				}
+			}
+			if instr.arg2 == 1 {
+				stack = append(stack, value)
+			}
+
+		default:
+			return values.NewVoid(), false, phpError.NewError("VM: Unsupported opcode %d", instr.op)
+		}
+	}
+}
+
+// Process a node with the tree-walking interpreter.
+// It accesses the variables stored in slots through the slots of the environment.
+func (interpreter *Interpreter) fallback(frame *vmFrame, node ast.IStatement) (values.RuntimeValue, phpError.Error) {
+	return interpreter.processStmt(node, frame.env)
+}
+
+// -------------------------------------- Variables -------------------------------------- MARK: Variables
+
+func (interpreter *Interpreter) loadVariable(frame *vmFrame, index int, quiet bool, node ast.IStatement) values.RuntimeValue {
+	variable := frame.unit.variables[index]
+	if !variable.named {
+		if value := frame.locals[index]; value != nil {
+			return value
+		}
+		if !quiet && !interpreter.suppressWarning {
+			interpreter.PrintError(phpError.NewWarning("Undefined variable %s in %s", variable.name, node.GetPosString()))
+		}
+		return values.NewNull()
+	}
+
+	runtimeValue, err := frame.env.LookupVariable(variable.name)
+	if err != nil && !quiet && !interpreter.suppressWarning {
+		interpreter.PrintError(phpError.NewWarning("%s in %s", strings.TrimPrefix(err.Error(), "Warning: "), node.GetPosString()))
+	}
+	return runtimeValue
+}
+
+func (interpreter *Interpreter) storeVariable(frame *vmFrame, index int, value values.RuntimeValue) {
+	if variable := &frame.unit.variables[index]; variable.named {
+		frame.env.declareVariable(variable.name, value)
+		return
+	}
+	// Other values than arrays and objects are neither copied nor reference counted (see setSlot)
+	if !isRefCounted(frame.locals[index]) && !isRefCounted(value) {
+		frame.locals[index] = value
+		return
+	}
+	frame.env.setSlot(index, value)
+}
+
+func isRefCounted(value values.RuntimeValue) bool {
+	switch value.(type) {
+	case *values.Array, *values.Object:
+		return true
+	default:
+		return false
+	}
+}
+
+// -------------------------------------- Operations -------------------------------------- MARK: Operations
+
+func toBool(value values.RuntimeValue) (bool, phpError.Error) {
+	if boolean, ok := value.(*values.Bool); ok {
+		return boolean.Value, nil
+	}
+	return variableHandling.BoolVal(value)
+}
+
+var binaryOperators = map[opcode]string{
+	opAdd: "+", opSub: "-", opMul: "*", opMod: "%", opConcat: ".",
+	opLess: "<", opLessEqual: "<=", opGreater: ">", opGreaterEqual: ">=",
+	opEqual: "==", opNotEqual: "!=", opIdentical: "===", opNotIdentical: "!==",
+}
+
+func (interpreter *Interpreter) binaryOperation(
+	op opcode, lhs values.RuntimeValue, rhs values.RuntimeValue, names []string, name int, node ast.IStatement,
+) (values.RuntimeValue, phpError.Error) {
+	// Fast paths for integers and strings
+	if left, ok := lhs.(*values.Int); ok {
+		if right, ok := rhs.(*values.Int); ok {
+			switch op {
+			case opAdd:
+				if sum := left.Value + right.Value; (sum > left.Value) == (right.Value > 0) {
+					return values.NewInt(sum), nil
+				}
+			case opSub:
+				if difference := left.Value - right.Value; (difference < left.Value) == (right.Value > 0) {
+					return values.NewInt(difference), nil
+				}
+			case opMul:
+				if product, ok := multiplyInteger(left.Value, right.Value); ok {
+					return values.NewInt(product), nil
+				}
+			case opMod:
+				if right.Value != 0 {
+					return values.NewInt(left.Value % right.Value), nil
+				}
+			case opLess, opLessEqual, opGreater, opGreaterEqual, opEqual, opIdentical, opNotEqual, opNotIdentical:
+				return values.NewBool(compareIntegers(op, left.Value, right.Value)), nil
+			}
+		}
+	}
+	if op == opConcat {
+		if left, ok := lhs.(*values.Str); ok {
+			switch right := rhs.(type) {
+			case *values.Str:
+				return values.ConcatStr(left, right.Value), nil
+			case *values.Int:
+				return values.ConcatStr(left, strconv.FormatInt(right.Value, 10)), nil
+			}
+		}
+	}
+
+	switch op {
+	case opBinary:
+		return interpreter.calculate(lhs, names[name], rhs, node)
+	case opRelational:
+		return variableHandling.CompareRelation(lhs, names[name], rhs, true)
+	case opEquality:
+		return variableHandling.Compare(lhs, names[name], rhs)
+	case opLess, opLessEqual, opGreater, opGreaterEqual:
+		return variableHandling.CompareRelation(lhs, binaryOperators[op], rhs, true)
+	case opEqual, opNotEqual, opIdentical, opNotIdentical:
+		return variableHandling.Compare(lhs, binaryOperators[op], rhs)
+	default:
+		return interpreter.calculate(lhs, binaryOperators[op], rhs, node)
+	}
+}
+
+// Compare two integers with a comparison opcode (e.g. opLess)
+func compareIntegers(op opcode, left int64, right int64) bool {
+	switch op {
+	case opLess:
+		return left < right
+	case opLessEqual:
+		return left <= right
+	case opGreater:
+		return left > right
+	case opGreaterEqual:
+		return left >= right
+	case opEqual, opIdentical:
+		return left == right
+	default:
+		return left != right
+	}
+}
+
+// Get the element of a container that is not an array (see ProcessSubscriptExpr)
+func (interpreter *Interpreter) fetchDim(container values.RuntimeValue, key values.RuntimeValue, expr *ast.SubscriptExpression, quiet bool) (values.RuntimeValue, phpError.Error) {
+	switch container.GetType() {
+	case values.StrValue:
+		return interpreter.readStringOffset(container.(*values.Str).Value, key, expr, quiet)
+
+	case values.NullValue:
+		if !quiet && !interpreter.suppressWarning {
+			interpreter.PrintError(phpError.NewWarning("Trying to access array offset on value of type null in %s", expr.Variable.GetPosString()))
+		}
+		return values.NewNull(), nil
+
+	default:
+		if quiet {
+			return values.NewNull(), nil
+		}
+		return values.NewVoid(), phpError.NewError("Unsupported subscript expression: %s", ast.ToString(expr))
+	}
+}
+
+func (iterator *vmIterator) next() (key values.RuntimeValue, value values.RuntimeValue, found bool) {
+	if iterator.array != nil {
+		key, value, iterator.position, found = iterator.array.NextElement(iterator.position)
+		return key, value, found
+	}
+
+	for iterator.position < len(iterator.names) {
+		propertyName := iterator.names[iterator.position]
+		iterator.position++
+		// Dynamic properties are always public
+		if property, found := iterator.object.Class.Properties[propertyName]; found && property.Visibility != "public" {
+			continue
+		}
+		// Uninitialized typed properties are skipped
+		value, found := iterator.object.Properties[propertyName]
+		if !found {
+			continue
+		}
+		return values.NewStr(propertyName[1:]), value, true
+	}
+	return nil, nil, false
+}
+
+func getForeachTypeName(value values.RuntimeValue) string {
+	switch value.GetType() {
+	case values.BoolValue:
+		if value.(*values.Bool).Value {
+			return "true"
+		}
+		return "false"
+	case values.NullValue:
+		return "null"
+	default:
+		return values.ToPhpType(value)
+	}
+}
+
+// Substitute the variables of a double quoted string (see exprToRuntimeValue)
+func (interpreter *Interpreter) interpolate(frame *vmFrame, interpolation *interpolation) (values.RuntimeValue, phpError.Error) {
+	substitutions := make([]values.RuntimeValue, len(interpolation.variables))
+	for index, variable := range interpolation.variables {
+		runtimeValue, isDefined := lookupFrameVariable(frame, variable)
+		if !isDefined {
+			continue
+		}
+		switch runtimeValue.GetType() {
+		case values.BoolValue, values.FloatValue, values.IntValue, values.NullValue, values.StrValue:
+			substitutions[index] = runtimeValue
+		default:
+			// Other values are converted by the tree-walking interpreter
+			return interpreter.fallback(frame, interpolation.expr)
+		}
+	}
+
+	var str strings.Builder
+	index := 0
+	for _, part := range interpolation.parts {
+		if part.substitution == "" {
+			str.WriteString(part.text)
+			continue
+		}
+		if substitutions[index] == nil {
+			interpreter.PrintError(phpError.NewWarning(
+				"Undefined variable %s in %s", frame.unit.variables[interpolation.variables[index]].name, interpolation.expr.GetPosString(),
+			))
+		} else {
+			result, _ := interpreter.strVal(substitutions[index])
+			str.WriteString(result)
+		}
+		index++
+	}
+
+	return values.NewStr(str.String()), nil
+}
+
+func lookupFrameVariable(frame *vmFrame, index int) (values.RuntimeValue, bool) {
+	variable := frame.unit.variables[index]
+	if !variable.named {
+		return frame.locals[index], frame.locals[index] != nil
+	}
+	runtimeValue, err := frame.env.LookupVariable(variable.name)
+	return runtimeValue, err == nil
+}
+
+// Resolve the function of the call site and check the number of arguments
+func resolveCallSite(env *Environment, site *callSite) phpError.Error {
+	if site.native == nil && site.function == nil {
+		if nativeFunction, err := env.lookupNativeFunction(site.name); err == nil {
+			site.native = nativeFunction
+		} else {
+			userFunction, err := env.lookupUserFunction(site.name)
+			if err != nil {
+				return err
+			}
+			site.function = userFunction
+		}
+	}
+
+	if site.function != nil && len(site.function.Params) != len(site.expr.Arguments) {
+		return phpError.NewError(
+			"Uncaught ArgumentCountError: %s() expects exactly %d arguments, %d given",
+			site.function.FunctionName, len(site.function.Params), len(site.expr.Arguments),
+		)
+	}
+	return nil
+}
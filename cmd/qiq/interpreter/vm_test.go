@@ -0,0 +1,74 @@
+package interpreter
+
+import (
+	"QIQ/cmd/qiq/ini"
+	"QIQ/cmd/qiq/phpError"
+	"QIQ/cmd/qiq/request"
+	"testing"
+)
+
+func TestVm(t *testing.T) {
+	// Break and continue in statements processed by the tree-walking interpreter
+	testInputOutput(t,
+		`<?php for ($i = 0; $i < 3; $i++) { foreach ([1, 2, 3] as $j) { if ($j == 2) { $k = [$j]; continue 2; } echo $i . $j . " "; } }`,
+		"01 11 21 ",
+	)
+	testInputOutput(t,
+		`<?php $i = 0; while (true) { $i++; foreach ([1] as $j) { if ($i == 3) { $k = [$j]; break 2; } } } echo $i;`,
+		"3",
+	)
+	// Variables stored in slots are visible to the tree-walking interpreter
+	testInputOutput(t,
+		`<?php function f($a) { $b = $a * 2; $c = ["x" => $b]; return "{$c['x']} $b"; } echo f(21);`,
+		"42 42",
+	)
+	// Foreach over objects only iterates public properties
+	testInputOutput(t,
+		`<?php class C { public $a = 1; protected $b = 2; private $c = 3; public $d = 4; }
+		foreach (new C() as $k => $v) { echo $k . "=" . $v . " "; }`,
+		"a=1 d=4 ",
+	)
+	testInputOutput(t,
+		`<?php foreach (null as $v) { echo $v; } echo "end";`,
+		"\nWarning: foreach() argument must be of type array|object, null given in /home/admin/test.php:1:16\nend",
+	)
+	// Nested array assignment
+	testInputOutput(t, `<?php $a[1][] = 2; $a[1][] = 3; $a["x"]["y"] = "z"; echo count($a), count($a[1]), $a["x"]["y"];`, "22z")
+	testForError(t,
+		`<?php function f($a, $b) {} f(1);`,
+		phpError.NewError("Uncaught ArgumentCountError: f() expects exactly 2 arguments, 1 given"),
+	)
+}
+
+func benchmarkEngine(b *testing.B, engine string, php string) {
+	testIni, err := ini.NewDevIniFromArray([]string{"qiq.engine=" + engine})
+	if err != nil {
+		b.Fatal(err)
+	}
+	for b.Loop() {
+		interpreter, err := NewInterpreter(testIni, &request.Request{}, TEST_FILE_NAME)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := interpreter.Process(php); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+const benchmarkLoop = `<?php function loop($n) { $sum = 0; for ($i = 0; $i < $n; $i++) { $sum += $i % 7; } return $sum; } echo loop(100000);`
+const benchmarkFib = `<?php function fib($n) { if ($n < 2) { return $n; } return fib($n - 1) + fib($n - 2); } echo fib(18);`
+
+// Writing to an array after passing it to a function must not copy the array
+const benchmarkArrayArgument = `<?php function first($a) { return $a[0]; } $a = range(1, 100000); for ($i = 0; $i < 1000; $i++) { first($a); count($a); $a[0] = $i; } echo $a[0];`
+const benchmarkConcat = `<?php function concat($n) { $s = ""; for ($i = 0; $i < $n; $i++) { $s .= "item " . $i . ","; } return strlen($s); } echo concat(100000);`
+
+func BenchmarkLoopAst(b *testing.B)   { benchmarkEngine(b, engineAST, benchmarkLoop) }
+func BenchmarkLoopVm(b *testing.B)    { benchmarkEngine(b, engineVM, benchmarkLoop) }
+func BenchmarkFibAst(b *testing.B)    { benchmarkEngine(b, engineAST, benchmarkFib) }
+func BenchmarkFibVm(b *testing.B)     { benchmarkEngine(b, engineVM, benchmarkFib) }
+func BenchmarkConcatAst(b *testing.B) { benchmarkEngine(b, engineAST, benchmarkConcat) }
+func BenchmarkConcatVm(b *testing.B)  { benchmarkEngine(b, engineVM, benchmarkConcat) }
+
+func BenchmarkArrayArgumentAst(b *testing.B) { benchmarkEngine(b, engineAST, benchmarkArrayArgument) }
+func BenchmarkArrayArgumentVm(b *testing.B)  { benchmarkEngine(b, engineVM, benchmarkArrayArgument) }
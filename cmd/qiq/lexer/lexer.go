@@ -152,7 +152,8 @@ func (lexer *Lexer) tokenizeInputFile() error {
 		}
 
 		// comment
-		if lexer.at() == "#" || lexer.nextN(2) == "//" || lexer.nextN(2) == "/*" {
+		// Spec-Fix: Since PHP 8.0 "#[" starts an attribute and not a comment
+		if (lexer.at() == "#" && lexer.nextN(2) != "#[") || lexer.nextN(2) == "//" || lexer.nextN(2) == "/*" {
 			if err := lexer.tokenizeComment(); err != nil {
 				return err
 			}
@@ -269,6 +270,33 @@ func (lexer *Lexer) tokenizeToken() error {
 			return nil
 		}
 
+		// shell-command-expression
+		if lexer.at() == "`" {
+			// Spec: https://phplang.org/spec/10-expressions.html#grammar-shell-command-expression
+
+			// shell-command-expression:
+			//    `   dq-char-sequence(opt)   `
+
+			str := lexer.eat()
+			for !lexer.isEof() {
+				if lexer.at() == "`" {
+					str += lexer.eat()
+					break
+				}
+				if lexer.at() == `\` && lexer.next(0) != "" {
+					str += lexer.eatN(2)
+					continue
+				}
+				str += lexer.eat()
+			}
+
+			if !common.IsShellCommandLiteral(str) {
+				return phpError.NewError("Invalid shell command literal detected at %s:%d:%d", lexer.file.Filename, lexer.currPos.CurrLine, lexer.currPos.CurrPos)
+			}
+			lexer.pushToken(StringLiteralToken, str)
+			return nil
+		}
+
 		// variable-name
 		if lexer.at() == "$" {
 			// Spec: https://phplang.org/spec/09-lexical-structure.html#grammar-variable-name
@@ -731,6 +759,10 @@ func (lexer *Lexer) getStringLiteral(eat bool) (string, phpError.Error) {
 		}
 
 		if common.IsDoubleQuotedStringLiteral(strValue) {
+			if err := lexer.checkUnicodeEscapes(strValue); err != nil {
+				lexer.popSnapShot(true)
+				return "", err
+			}
 			lexer.popSnapShot(!eat)
 			return strValue, nil
 		}
@@ -848,6 +880,10 @@ func (lexer *Lexer) getStringLiteral(eat bool) (string, phpError.Error) {
 		}
 
 		if common.IsHeredocStringLiteral(strValue) {
+			if err := lexer.checkUnicodeEscapes(strValue); err != nil {
+				lexer.popSnapShot(true)
+				return "", err
+			}
 			lexer.popSnapShot(!eat)
 			return strValue, nil
 		}
@@ -861,6 +897,17 @@ func (lexer *Lexer) getStringLiteral(eat bool) (string, phpError.Error) {
 	return "", phpError.NewError("Unsupported string literal detected at %s:%d:%d", lexer.file.Filename, lexer.currPos.CurrLine, lexer.currPos.CurrPos)
 }
 
+func (lexer *Lexer) checkUnicodeEscapes(str string) phpError.Error {
+	// Spec: https://www.php.net/manual/en/language.types.string.php#language.types.string.syntax.double
+	// The unicode escape sequence "\u{[0-9A-Fa-f]+}" represents the UTF-8 representation of the codepoint.
+	// Codepoints above U+10FFFF cannot be represented and are a parse error.
+	if common.HasInvalidUnicodeEscape(str) {
+		return phpError.NewParseError("Invalid UTF-8 codepoint escape sequence: Codepoint too large in %s:%d:%d",
+			lexer.file.Filename, lexer.currPos.CurrTokenLine, lexer.currPos.CurrTokenCol)
+	}
+	return nil
+}
+
 func (lexer *Lexer) getOperatorOrPunctuator(eat bool) string {
 	// Spec: https://phplang.org/spec/09-lexical-structure.html#operators-and-punctuators
 
@@ -869,9 +916,9 @@ func (lexer *Lexer) getOperatorOrPunctuator(eat bool) string {
 	//    $   /   %   <<   >>   <   >   <=   >=   ==   ===   !=   !==   ^   |
 	//    &   &&   ||   ?   :   ;   =   **=   *=   /=   %=   +=   -=   .=   <<=
 	//    >>=   &=   ^=   |=   ,   ??   <=>   ...   \
-	// Spec-Fix: =>   @   <<<
+	// Spec-Fix: =>   @   <<<   ::   ?->   #[
 
-	if op := lexer.nextN(3); slices.Contains([]string{"===", "!==", "**=", "<<=", ">>=", "<=>", "...", "<<<"}, op) {
+	if op := lexer.nextN(3); slices.Contains([]string{"===", "!==", "**=", "<<=", ">>=", "<=>", "...", "<<<", "?->"}, op) {
 		if eat {
 			lexer.eatN(3)
 		}
@@ -880,7 +927,7 @@ func (lexer *Lexer) getOperatorOrPunctuator(eat bool) string {
 	if op := lexer.nextN(2); slices.Contains([]string{
 		"->", "++", "--", "**", "<<", ">>", "<=", ">=", "==", "!=", "&&",
 		"||", "*=", "/=", "%=", "+=", "-=", ".=", "&=", "^=", "|=", "??",
-		"=>",
+		"=>", "::", "#[",
 	}, op) {
 		if eat {
 			lexer.eatN(2)
@@ -188,6 +188,13 @@ func TestStringLiteral(t *testing.T) {
 		NewToken(StringLiteralToken, "<<<ID\nID", position.NewPosition(testFile, 1, 7)),
 		NewToken(OpOrPuncToken, ";", position.NewPosition(testFile, 2, 3)),
 	})
+
+	// Shell command
+	testTokenize(t, "<?php `ls \\`-la\\` $dir`;", []*Token{
+		NewToken(StartTagToken, "", position.NewPosition(testFile, 1, 1)),
+		NewToken(StringLiteralToken, "`ls \\`-la\\` $dir`", position.NewPosition(testFile, 1, 7)),
+		NewToken(OpOrPuncToken, ";", position.NewPosition(testFile, 1, 24)),
+	})
 }
 
 func TestOperatorOrPunctuator(t *testing.T) {
@@ -214,6 +221,14 @@ func TestOperatorOrPunctuator(t *testing.T) {
 		NewToken(NameToken, "a", position.NewPosition(testFile, 1, 7)),
 		NewToken(OpOrPuncToken, "**", position.NewPosition(testFile, 1, 9)),
 	})
+
+	// Attribute
+	testTokenize(t, "<?php #[Attr] # comment\n;", []*Token{
+		NewToken(StartTagToken, "", position.NewPosition(testFile, 1, 1)),
+		NewToken(OpOrPuncToken, "#[", position.NewPosition(testFile, 1, 7)),
+		NewToken(NameToken, "Attr", position.NewPosition(testFile, 1, 9)),
+		NewToken(OpOrPuncToken, "]", position.NewPosition(testFile, 1, 13)),
+	})
 }
 
 func TestVariableVarname(t *testing.T) {
@@ -237,6 +252,19 @@ func TestVariableVarname(t *testing.T) {
 		})
 }
 
+func TestBinarySource(t *testing.T) {
+	// Names may contain any byte in the range 0x80-0xff and string literals may contain invalid UTF-8
+	testTokenize(t, "<?php $\u20ac = \"\xff\x80\"; \xe4\xff();",
+		[]*Token{
+			NewToken(StartTagToken, "", position.NewPosition(testFile, 1, 1)),
+			NewToken(VariableNameToken, "$\u20ac", position.NewPosition(testFile, 1, 7)),
+			NewToken(OpOrPuncToken, "=", position.NewPosition(testFile, 1, 12)),
+			NewToken(StringLiteralToken, "\"\xff\x80\"", position.NewPosition(testFile, 1, 14)),
+			NewToken(OpOrPuncToken, ";", position.NewPosition(testFile, 1, 18)),
+			NewToken(NameToken, "\xe4\xff", position.NewPosition(testFile, 1, 20)),
+		})
+}
+
 func TestHtmlAndPhp(t *testing.T) {
 	testTokenize(t, "<body>\n"+`    <?php $heading = "My Heading"; ?>`+"\n    <h1><?= $heading ?></h1>",
 		[]*Token{
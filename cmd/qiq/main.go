@@ -5,6 +5,7 @@ import (
 	"QIQ/cmd/qiq/config"
 	"QIQ/cmd/qiq/ini"
 	"QIQ/cmd/qiq/interpreter"
+	"QIQ/cmd/qiq/phpError"
 	"QIQ/cmd/qiq/request"
 	"QIQ/cmd/qiq/stats"
 	"bufio"
@@ -19,10 +20,24 @@ import (
 
 var serverAddr string
 var documentRoot string
+var iniEntries iniEntryFlag
+
+// List of ini entries given with "-d foo=bar"
+type iniEntryFlag []string
+
+func (entries *iniEntryFlag) String() string {
+	return strings.Join(*entries, ", ")
+}
+
+func (entries *iniEntryFlag) Set(value string) error {
+	*entries = append(*entries, value)
+	return nil
+}
 
 func main() {
 	file := flag.String("f", "", "Parse and execute <file>.")
 	isDev := flag.Bool("dev", false, "Run in developer mode.")
+	flag.Var(&iniEntries, "d", "Define INI entry foo with value 'bar'. <foo[=bar]>")
 	// Developer tools
 	showStats := flag.Bool("stats", false, "Show statistics.")
 	debugMode := flag.Bool("debug", false, "Enable debug mode.")
@@ -173,14 +188,7 @@ func requestHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	content, err := os.ReadFile(absFilePath)
-	if err != nil {
-		fmt.Println("Error:", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-
-	output, exitCode := processContent(w, r, string(content), absFilePath)
+	output, exitCode := processScript(w, r, absFilePath)
 	if exitCode == 500 {
 		w.WriteHeader(http.StatusInternalServerError)
 		fmt.Printf("%d %s\n", 500, absFilePath)
@@ -193,6 +201,19 @@ func requestHandler(w http.ResponseWriter, r *http.Request) {
 // -------------------------------------- core logic -------------------------------------- MARK: core logic
 
 func processContent(w http.ResponseWriter, r *http.Request, content string, filename string) (output string, exitCode int) {
+	return execute(w, r, filename, func(interpreter *interpreter.Interpreter) (string, phpError.Error) {
+		return interpreter.Process(content)
+	})
+}
+
+// Process the given file. The parsed program is taken from the opcache if possible.
+func processScript(w http.ResponseWriter, r *http.Request, filename string) (output string, exitCode int) {
+	return execute(w, r, filename, (*interpreter.Interpreter).ProcessFile)
+}
+
+func execute(
+	w http.ResponseWriter, r *http.Request, filename string, process func(*interpreter.Interpreter) (string, phpError.Error),
+) (output string, exitCode int) {
 	stat := stats.Start()
 	defer stats.StopAndPrint(stat, "Total")
 
@@ -202,6 +223,15 @@ func processContent(w http.ResponseWriter, r *http.Request, content string, file
 	} else {
 		initIni = ini.NewDefaultIni()
 	}
+	for _, entry := range iniEntries {
+		directive, value, found := strings.Cut(entry, "=")
+		if !found {
+			value = "1"
+		}
+		if err := initIni.Set(strings.TrimSpace(directive), strings.TrimSpace(value), ini.INI_ALL); err != nil {
+			fmt.Println("Error:", err)
+		}
+	}
 
 	request := request.NewRequestFromGoRequest(r, documentRoot, serverAddr, filename)
 	interpreter, err := interpreter.NewInterpreter(initIni, request, filename)
@@ -217,7 +247,7 @@ func processContent(w http.ResponseWriter, r *http.Request, content string, file
 	if err != nil {
 		return interpreter.ErrorToString(err), 500
 	}
-	result, err := interpreter.Process(content)
+	result, err := process(interpreter)
 	if err != nil {
 		result += interpreter.ErrorToString(err)
 		return result, 500
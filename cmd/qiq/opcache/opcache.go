@@ -0,0 +1,145 @@
+package opcache
+
+import (
+	"QIQ/cmd/qiq/ast"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// The opcache stores the parsed programs of script files so that they are not lexed and parsed again for every
+// request or include. The programs are shared between interpreters and must therefore not be modified.
+
+type script struct {
+	program *ast.Program
+	// Modification time and size of the file when it was parsed
+	modTime time.Time
+	size    int64
+	// Last time the modification time and size were checked
+	lastValidated time.Time
+	lastUsed      time.Time
+	hits          int64
+}
+
+type ScriptStatus struct {
+	FullPath  string
+	Hits      int64
+	LastUsed  time.Time
+	Timestamp time.Time
+	Size      int64
+}
+
+type Status struct {
+	StartTime       time.Time
+	LastRestartTime time.Time
+	Hits            int64
+	Misses          int64
+	Scripts         []ScriptStatus
+}
+
+var (
+	mutex           sync.Mutex
+	scripts         = map[string]*script{}
+	hits            int64
+	misses          int64
+	startTime       = time.Now()
+	lastRestartTime time.Time
+)
+
+// Get the cached program of the file.
+// If validateTimestamps is set, the modification time and size of the file are checked at most every
+// revalidateFreq seconds. A changed file is removed from the cache.
+func Get(filename string, validateTimestamps bool, revalidateFreq int64) (*ast.Program, bool) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	cached, found := scripts[filename]
+	if !found {
+		misses++
+		return nil, false
+	}
+
+	now := time.Now()
+	if validateTimestamps && now.Sub(cached.lastValidated) >= time.Duration(revalidateFreq)*time.Second {
+		info, err := os.Stat(filename)
+		if err != nil || !info.ModTime().Equal(cached.modTime) || info.Size() != cached.size {
+			delete(scripts, filename)
+			misses++
+			return nil, false
+		}
+		cached.lastValidated = now
+	}
+
+	cached.hits++
+	cached.lastUsed = now
+	hits++
+	return cached.program, true
+}
+
+// Store the program of the file. The file info must be read before the file content so that
+// a file changed in the meantime is detected by the next validation.
+func Store(filename string, program *ast.Program, info os.FileInfo) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	now := time.Now()
+	scripts[filename] = &script{
+		program: program, modTime: info.ModTime(), size: info.Size(), lastValidated: now, lastUsed: now,
+	}
+}
+
+// Remove the file from the cache.
+// Without force, the file is only removed if it was modified since it was cached.
+func Invalidate(filename string, force bool) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	cached, found := scripts[filename]
+	if !found {
+		return
+	}
+	if !force {
+		info, err := os.Stat(filename)
+		if err == nil && !info.ModTime().After(cached.modTime) {
+			return
+		}
+	}
+	delete(scripts, filename)
+}
+
+// Remove all files from the cache
+func Reset() {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	scripts = map[string]*script{}
+	hits = 0
+	misses = 0
+	lastRestartTime = time.Now()
+}
+
+func IsCached(filename string) bool {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	_, found := scripts[filename]
+	return found
+}
+
+func GetStatus() Status {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	status := Status{
+		StartTime: startTime, LastRestartTime: lastRestartTime, Hits: hits, Misses: misses,
+		Scripts: make([]ScriptStatus, 0, len(scripts)),
+	}
+	for filename, cached := range scripts {
+		status.Scripts = append(status.Scripts, ScriptStatus{
+			FullPath: filename, Hits: cached.hits, LastUsed: cached.lastUsed, Timestamp: cached.modTime, Size: cached.size,
+		})
+	}
+	sort.Slice(status.Scripts, func(i, j int) bool { return status.Scripts[i].FullPath < status.Scripts[j].FullPath })
+	return status
+}
@@ -0,0 +1,75 @@
+package opcache
+
+import (
+	"QIQ/cmd/qiq/ast"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func storeFile(t *testing.T, filename string, content string) *ast.Program {
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	program := ast.NewProgram()
+	Store(filename, program, info)
+	return program
+}
+
+func TestOpcache(t *testing.T) {
+	Reset()
+	filename := filepath.Join(t.TempDir(), "test.php")
+	program := storeFile(t, filename, "<?php echo 1;")
+
+	if cached, found := Get(filename, true, 0); !found || cached != program {
+		t.Errorf("Expected cached program")
+	}
+	if !IsCached(filename) {
+		t.Errorf("Expected file to be cached")
+	}
+
+	// A changed file is detected with validate_timestamps
+	if err := os.WriteFile(filename, []byte("<?php echo 12;"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, found := Get(filename, false, 0); !found {
+		t.Errorf("Expected cached program without validation")
+	}
+	if _, found := Get(filename, true, 60); !found {
+		t.Errorf("Expected cached program before revalidate_freq has passed")
+	}
+	if _, found := Get(filename, true, 0); found {
+		t.Errorf("Expected changed file to be invalidated")
+	}
+	if IsCached(filename) {
+		t.Errorf("Expected changed file to be removed")
+	}
+
+	// Invalidate
+	storeFile(t, filename, "<?php echo 1;")
+	Invalidate(filename, false)
+	if !IsCached(filename) {
+		t.Errorf("Expected unmodified file to stay cached")
+	}
+	Invalidate(filename, true)
+	if IsCached(filename) {
+		t.Errorf("Expected file to be invalidated with force")
+	}
+
+	// Status and reset
+	storeFile(t, filename, "<?php echo 1;")
+	Get(filename, true, 0)
+	status := GetStatus()
+	if status.Hits != 4 || status.Misses != 1 || len(status.Scripts) != 1 || status.Scripts[0].Hits != 1 {
+		t.Errorf("Unexpected status: %+v", status)
+	}
+	Reset()
+	status = GetStatus()
+	if status.Hits != 0 || status.Misses != 0 || len(status.Scripts) != 0 || status.LastRestartTime.IsZero() {
+		t.Errorf("Unexpected status after reset: %+v", status)
+	}
+}
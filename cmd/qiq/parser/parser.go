@@ -7,9 +7,11 @@ import (
 	"QIQ/cmd/qiq/lexer"
 	"QIQ/cmd/qiq/phpError"
 	"QIQ/cmd/qiq/position"
+	"QIQ/cmd/qiq/resolver"
 	"QIQ/cmd/qiq/stats"
 	"slices"
 	"strings"
+	"sync/atomic"
 )
 
 type Parser struct {
@@ -18,9 +20,11 @@ type Parser struct {
 	lexer   *lexer.Lexer
 	tokens  []*lexer.Token
 	currPos int
-	id      int64
 }
 
+// Node ids are unique across all parsers because parsed programs are shared between interpreters (see opcache)
+var lastId atomic.Int64
+
 func NewParser(ini *ini.Ini) *Parser {
 	return &Parser{ini: ini}
 }
@@ -32,8 +36,7 @@ func (parser *Parser) init() {
 }
 
 func (parser *Parser) nextId() int64 {
-	parser.id++
-	return parser.id
+	return lastId.Add(1)
 }
 
 func (parser *Parser) ProduceAST(sourceCode string, filename string) (*ast.Program, phpError.Error) {
@@ -42,6 +45,9 @@ func (parser *Parser) ProduceAST(sourceCode string, filename string) (*ast.Progr
 	var lexerErr error
 	parser.tokens, lexerErr = parser.lexer.Tokenize(sourceCode, filename)
 	if lexerErr != nil {
+		if err, ok := lexerErr.(phpError.Error); ok && err.GetErrorType() == phpError.ParsePhpError {
+			return parser.program, err
+		}
 		return parser.program, phpError.NewParseError("%s", lexerErr.Error())
 	}
 
@@ -65,6 +71,8 @@ func (parser *Parser) ProduceAST(sourceCode string, filename string) (*ast.Progr
 		}
 	}
 
+	resolver.Resolve(parser.program)
+
 	return parser.program, nil
 }
 
@@ -220,6 +228,11 @@ func (parser *Parser) parseStmt() (ast.IStatement, phpError.Error) {
 			if literal.(*ast.IntegerLiteralExpression).Value < 0 || literal.(*ast.IntegerLiteralExpression).Value > 1 {
 				return ast.NewEmptyStmt(), phpError.NewParseError("Only 0 and 1 allowed as values for the declare directive 'strict_types' at %s", parser.at().GetPosString())
 			}
+			// The strict mode is a property of the file and is set while parsing,
+			// so that the interpreter does not modify the (possibly shared) program
+			if pos.File != nil {
+				pos.File.IsStrictType = literal.(*ast.IntegerLiteralExpression).Value == 1
+			}
 		}
 
 		// )
@@ -328,6 +341,28 @@ func (parser *Parser) parseStmt() (ast.IStatement, phpError.Error) {
 		return parser.parseConstDeclaration()
 	}
 
+	// attributes
+	if parser.isToken(lexer.OpOrPuncToken, "#[", false) {
+		attributes, err := parser.parseAttributes()
+		if err != nil {
+			return ast.NewEmptyStmt(), err
+		}
+		pos := parser.at().GetPosString()
+		stmt, err := parser.parseStmt()
+		if err != nil {
+			return ast.NewEmptyStmt(), err
+		}
+		switch stmt := stmt.(type) {
+		case *ast.FunctionDefinitionStatement:
+			stmt.Attributes = attributes
+		case *ast.ClassDeclarationStatement:
+			stmt.Attributes = attributes
+		default:
+			return ast.NewEmptyStmt(), phpError.NewParseError("Attributes can only be applied to classes and functions at %s", pos)
+		}
+		return stmt, nil
+	}
+
 	// function-definition
 	if parser.isToken(lexer.KeywordToken, "function", false) {
 		return parser.parseFunctionDefinition()
@@ -1213,19 +1248,13 @@ func (parser *Parser) parseFunctionDefinition() (ast.IStatement, phpError.Error)
 		return ast.NewEmptyStmt(), NewExpectedError(")", parser.at())
 	}
 
-	returnTypes := []string{}
+	var returnType *ast.TypeDeclaration = nil
 	if parser.isToken(lexer.OpOrPuncToken, ":", true) {
-		for parser.at().TokenType == lexer.KeywordToken && common.IsReturnTypeKeyword(parser.at().Value) {
-			returnTypes = append(returnTypes, strings.ToLower(parser.eat().Value))
-			if parser.isToken(lexer.OpOrPuncToken, "|", true) {
-				continue
-			}
-			break
+		returnType, err = parser.getType(true)
+		if err != nil {
+			return ast.NewEmptyStmt(), err
 		}
 	}
-	if len(returnTypes) == 0 {
-		returnTypes = append(returnTypes, "mixed")
-	}
 
 	body, err := parser.parseStmt()
 	if err != nil {
@@ -1235,7 +1264,7 @@ func (parser *Parser) parseFunctionDefinition() (ast.IStatement, phpError.Error)
 		return ast.NewEmptyStmt(), phpError.NewParseError("Expected compound statement. Got %s", body.GetKind())
 	}
 
-	return ast.NewFunctionDefinitionStmt(parser.nextId(), pos, functionName, parameters, body.(*ast.CompoundStatement), returnTypes), nil
+	return ast.NewFunctionDefinitionStmt(parser.nextId(), pos, functionName, parameters, body.(*ast.CompoundStatement), returnType), nil
 }
 
 func (parser *Parser) parseFunctionParameters() ([]ast.FunctionParameter, phpError.Error) {
@@ -1247,14 +1276,22 @@ func (parser *Parser) parseFunctionParameters() ([]ast.FunctionParameter, phpErr
 				break
 			}
 
+			// attributes
+			attributes, err := parser.parseAttributes()
+			if err != nil {
+				return parameters, err
+			}
+
 			// type-declaration
-			paramTypes := []string{"mixed"}
+			var paramType *ast.TypeDeclaration = nil
 			if parser.isPhpType(parser.at()) {
-				var err phpError.Error
-				paramTypes, err = parser.getTypes(true)
+				paramType, err = parser.getType(true)
 				if err != nil {
 					return parameters, err
 				}
+				if paramType.Kind == ast.NamedType && (paramType.Name == "void" || paramType.Name == "never") {
+					return parameters, phpError.NewError("%s cannot be used as a parameter type in %s", paramType.Name, parser.at().GetPosString())
+				}
 			}
 
 			// TODO function-definition - parameter-declaration - &(opt)
@@ -1262,7 +1299,7 @@ func (parser *Parser) parseFunctionParameters() ([]ast.FunctionParameter, phpErr
 			if parser.at().TokenType != lexer.VariableNameToken {
 				return parameters, phpError.NewParseError("Expected variable. Got \"%s\" (%s) at %s", parser.at().Value, parser.at().TokenType, parser.at().GetPosString())
 			}
-			parameters = append(parameters, ast.FunctionParameter{Name: parser.eat().Value, Type: paramTypes})
+			parameters = append(parameters, ast.FunctionParameter{Name: parser.eat().Value, Type: paramType, Attributes: attributes})
 
 			if parser.isToken(lexer.OpOrPuncToken, ",", true) {
 				continue
@@ -1977,7 +2014,8 @@ func (parser *Parser) parseExponentiationExpr() (ast.IExpression, phpError.Error
 	// Supported expression: exponentiation expression: `$var ** 42;`
 	if parser.isToken(lexer.OpOrPuncToken, "**", true) {
 		PrintParserCallstack("exponentiation-expression", parser)
-		rhs, err := parser.parseExponentiationExpr()
+		// PHP also allows an unary expression on the right side (e.g. `2 ** -1`)
+		rhs, err := parser.parseUnaryExpr()
 		if err != nil {
 			return ast.NewEmptyExpr(), err
 		}
@@ -1993,7 +2031,17 @@ func (parser *Parser) parseCloneExpr() (ast.IExpression, phpError.Error) {
 	//	primary-expression
 	//	clone   primary-expression
 
-	// TODO clone-expression
+	// Supported expression: clone expression: `clone $obj;`
+	if parser.isToken(lexer.KeywordToken, "clone", false) {
+		PrintParserCallstack("clone-expression", parser)
+		pos := parser.eat().Position
+		expr, err := parser.parsePrimaryExpr()
+		if err != nil {
+			return ast.NewEmptyExpr(), err
+		}
+		return ast.NewCloneExpr(parser.nextId(), pos, expr), nil
+	}
+
 	return parser.parsePrimaryExpr()
 }
 
@@ -2028,115 +2076,14 @@ func (parser *Parser) parsePrimaryExpr() (ast.IExpression, phpError.Error) {
 	//    scoped-property-access-expression
 	//    member-access-expression
 
-	var variable ast.IExpression
-
-	// -------------------------------------- callable-variable -------------------------------------- MARK: callable-variable
-
-	// Spec: https://phplang.org/spec/10-expressions.html#grammar-callable-variable
-
-	// callable-variable:
-	//    simple-variable
-	//    subscript-expression
-	//    member-call-expression
-	//    scoped-call-expression
-	//    function-call-expression
-
-	// -------------------------------------- simple-variable -------------------------------------- MARK: simple-variable
-
-	// Spec: https://phplang.org/spec/10-expressions.html#simple-variable
-
-	// simple-variable:
-	//    variable-name
-
-	if parser.isTokenType(lexer.VariableNameToken, false) {
-		PrintParserCallstack("simple-variable", parser)
-		variable = ast.NewSimpleVariableExpr(parser.nextId(), ast.NewVariableNameExpr(parser.nextId(), parser.at().Position, parser.eat().Value))
-	}
-
-	// Spec: https://phplang.org/spec/10-expressions.html#simple-variable
-
-	// simple-variable:
-	//    $   {   expression   }
-
-	// Supported expression: variable access: `echo $v;`
-	if parser.isToken(lexer.OpOrPuncToken, "$", false) &&
-		parser.next(0).TokenType == lexer.OpOrPuncToken && parser.next(0).Value == "{" {
-		PrintParserCallstack("simple-variable", parser)
-		parser.eatN(2)
-		// Get expression
-		expr, err := parser.parseExpr()
-		if err != nil {
-			return ast.NewEmptyExpr(), err
-		}
-
-		if parser.at().Value == "}" {
-			parser.eat()
-			variable = ast.NewSimpleVariableExpr(parser.nextId(), expr)
-		} else {
-			return ast.NewEmptyExpr(), phpError.NewParseError("End of simple variable expression not detected at %s", parser.at().GetPosString())
-		}
-	}
-
-	// Spec: https://phplang.org/spec/10-expressions.html#simple-variable
-
-	// simple-variable:
-	//    $   simple-variable
-
-	if parser.isToken(lexer.OpOrPuncToken, "$", true) {
-		PrintParserCallstack("simple-variable", parser)
-		if expr, err := parser.parsePrimaryExpr(); err != nil {
-			return ast.NewEmptyExpr(), err
-		} else {
-			variable = ast.NewSimpleVariableExpr(parser.nextId(), expr)
-		}
-	}
-
-	if ast.IsVariableExpr(variable) &&
-		!parser.isToken(lexer.OpOrPuncToken, "(", false) && !parser.isToken(lexer.OpOrPuncToken, "[", false) &&
-		!parser.isToken(lexer.OpOrPuncToken, "{", false) && !parser.isToken(lexer.OpOrPuncToken, "++", false) &&
-		!parser.isToken(lexer.OpOrPuncToken, "--", false) && !parser.isToken(lexer.OpOrPuncToken, "->", false) {
-		return variable, nil
+	variable, err := parser.parseSimpleVariable()
+	if err != nil {
+		return ast.NewEmptyExpr(), err
 	}
-
-	// TODO The following expressions can occur multiple times: $a[0]()["abc"]()()...
-
-	// -------------------------------------- subscript-expression -------------------------------------- MARK: subscript-expression
-
-	// Spec: https://phplang.org/spec/10-expressions.html#grammar-subscript-expression
-
-	// subscript-expression:
-	//    dereferencable-expression   [   expression(opt)   ]
-	//    dereferencable-expression   {   expression   }   <b>[Deprecated form]</b>
-
-	// dereferencable-expression:
-	//    variable
-	//    (   expression   )
-	//    array-creation-expression
-	//    string-literal
-
-	// Supported expression: subscript expression: `$a[1];`
-	if ast.IsVariableExpr(variable) && parser.isToken(lexer.OpOrPuncToken, "[", false) {
-		PrintParserCallstack("subscript-expression", parser)
-		for ast.IsVariableExpr(variable) && parser.isToken(lexer.OpOrPuncToken, "[", true) {
-			var err phpError.Error
-			var index ast.IExpression
-			if !parser.isToken(lexer.OpOrPuncToken, "]", false) {
-				index, err = parser.parseExpr()
-				if err != nil {
-					return ast.NewEmptyExpr(), err
-				}
-			}
-			if !parser.isToken(lexer.OpOrPuncToken, "]", true) {
-				return ast.NewEmptyExpr(), NewExpectedError("]", parser.at())
-			}
-			variable = ast.NewSubscriptExpr(parser.nextId(), variable, index)
-		}
-		return variable, nil
+	if variable != nil {
+		return parser.parsePostfixExpr(variable)
 	}
 
-	// TODO member-call-expression
-	// TODO scoped-call-expression
-
 	// -------------------------------------- function-call-expression -------------------------------------- MARK: function-call-expression
 
 	// Spec: https://phplang.org/spec/10-expressions.html#grammar-function-call-expression
@@ -2147,89 +2094,38 @@ func (parser *Parser) parsePrimaryExpr() (ast.IExpression, phpError.Error) {
 	//    callable-expression   (   argument-expression-list(opt)   )
 	//    callable-expression   (   argument-expression-list   ,   )
 
-	// argument-expression-list:
-	//    argument-expression
-	//    argument-expression-list   ,   argument-expression
-
-	// argument-expression:
-	//    variadic-unpacking
-	//    expression
-
-	// variadic-unpacking:
-	//    ...   expression
-
-	// Supported expression: function call expression: `func(42);`
-	if (parser.isTokenType(lexer.NameToken, false) && parser.next(0).TokenType == lexer.OpOrPuncToken && parser.next(0).Value == "(") ||
-		(ast.IsVariableExpr(variable) && parser.isToken(lexer.OpOrPuncToken, "(", false)) {
+	// Supported expression: function call expression: `func(42); $func(42); $a[0]()();`
+	if parser.isTokenType(lexer.NameToken, false) && parser.next(0).TokenType == lexer.OpOrPuncToken && parser.next(0).Value == "(" {
 		PrintParserCallstack("function-call-expression", parser)
-
-		var pos *position.Position
-		var functionName ast.IExpression
-
-		if parser.isTokenType(lexer.NameToken, false) && parser.next(0).TokenType == lexer.OpOrPuncToken && parser.next(0).Value == "(" {
-			pos = parser.at().Position
-			functionName = ast.NewStringLiteralExpr(parser.nextId(), pos, parser.eat().Value, ast.SingleQuotedString)
-		} else {
-			pos = variable.GetPosition()
-			functionName = variable
-		}
-
-		args := []ast.IExpression{}
-		// Eat opening parentheses
-		parser.eat()
-		for {
-			if parser.isToken(lexer.OpOrPuncToken, ")", true) {
-				break
-			}
-
-			arg, err := parser.parseExpr()
-			if err != nil {
-				return ast.NewEmptyExpr(), err
-			}
-			args = append(args, arg)
-
-			if parser.isToken(lexer.OpOrPuncToken, ",", true) || parser.isToken(lexer.OpOrPuncToken, ")", false) {
-				continue
-			}
-			return ast.NewEmptyExpr(), phpError.NewParseError("Expected \",\" or \")\". Got: %s", parser.at())
+		pos := parser.at().Position
+		functionName := ast.NewStringLiteralExpr(parser.nextId(), pos, parser.eat().Value, ast.SingleQuotedString)
+		args, err := parser.parseArgumentExpressionList()
+		if err != nil {
+			return ast.NewEmptyExpr(), err
 		}
-		return ast.NewFunctionCallExpr(parser.nextId(), pos, functionName, args), nil
+		return parser.parsePostfixExpr(ast.NewFunctionCallExpr(parser.nextId(), pos, functionName, args))
 	}
 
-	// TODO scoped-property-access-expression
+	// -------------------------------------- scoped-access -------------------------------------- MARK: scoped-access
 
-	// -------------------------------------- member-access-expression -------------------------------------- MARK: member-access-expression
+	// Spec: https://phplang.org/spec/10-expressions.html#grammar-scope-resolution-qualifier
 
-	// Spec: https://phplang.org/spec/10-expressions.html#member-access-operator
-
-	// member-access-expression:
-	//    dereferencable-expression   ->   member-name
-
-	// member-name:
-	//    name
-	//    simple-variable
-	//    {   expression   }
-
-	// TODO member-access-expression - check if it is a "dereferencable-expression"
-
-	// Supported expression: member access expression: `$obj->member`
-	if ast.IsVariableExpr(variable) && parser.isToken(lexer.OpOrPuncToken, "->", false) {
-		PrintParserCallstack("member-access-expression", parser)
-
-		pos := parser.eat().Position
-
-		member, err := parser.parseExpr()
-		if err != nil {
-			return ast.NewEmptyExpr(), err
-		}
+	// scope-resolution-qualifier:
+	//    relative-scope
+	//    qualified-name
+	//    dereferencable-expression
 
-		// TODO member-access-expression - check member name type
+	// relative-scope:
+	//    self
+	//    parent
+	//    static
 
-		return ast.NewMemberAccessExpr(parser.nextId(), pos, variable, member), nil
+	if (parser.isTokenType(lexer.NameToken, false) || parser.isToken(lexer.KeywordToken, "static", false)) &&
+		parser.next(0).TokenType == lexer.OpOrPuncToken && parser.next(0).Value == "::" {
+		pos := parser.at().Position
+		return parser.parsePostfixExpr(ast.NewStringLiteralExpr(parser.nextId(), pos, parser.eat().Value, ast.SingleQuotedString))
 	}
 
-	// TODO class-constant-access-expression
-
 	// -------------------------------------- constant-access-expression -------------------------------------- MARK: constant-access-expression
 
 	// Spec: https://phplang.org/spec/10-expressions.html#grammar-constant-access-expression
@@ -2254,20 +2150,30 @@ func (parser *Parser) parsePrimaryExpr() (ast.IExpression, phpError.Error) {
 		if common.IsCorePredefinedConstant(constantName) || common.IsContextDependentConstant(constantName) {
 			constantName = strings.ToUpper(constantName)
 		}
-		return ast.NewConstantAccessExpr(parser.nextId(), parser.eat().Position, constantName), nil
+		return parser.parsePostfixExpr(ast.NewConstantAccessExpr(parser.nextId(), parser.eat().Position, constantName))
 	}
 
 	// literal
-	if parser.isTokenType(lexer.IntegerLiteralToken, false) || parser.isTokenType(lexer.FloatingLiteralToken, false) ||
-		parser.isTokenType(lexer.StringLiteralToken, false) {
+	if parser.isTokenType(lexer.IntegerLiteralToken, false) || parser.isTokenType(lexer.FloatingLiteralToken, false) {
 		return parser.parseLiteral()
 	}
+	if parser.isTokenType(lexer.StringLiteralToken, false) && !common.IsShellCommandLiteral(parser.at().Value) {
+		literal, err := parser.parseLiteral()
+		if err != nil {
+			return ast.NewEmptyExpr(), err
+		}
+		return parser.parsePostfixExpr(literal)
+	}
 
 	// array-creation-expression
 	if (parser.isToken(lexer.KeywordToken, "array", false) &&
 		parser.next(0).TokenType == lexer.OpOrPuncToken && parser.next(0).Value == "(") ||
 		parser.isToken(lexer.OpOrPuncToken, "[", false) {
-		return parser.parseArrayCreationExpr()
+		array, err := parser.parseArrayCreationExpr()
+		if err != nil {
+			return ast.NewEmptyExpr(), err
+		}
+		return parser.parsePostfixExpr(array)
 	}
 
 	// intrinsic
@@ -2300,25 +2206,6 @@ func (parser *Parser) parsePrimaryExpr() (ast.IExpression, phpError.Error) {
 
 	// TODO anonymous-function-creation-expression
 
-	// -------------------------------------- postfix-increment-expression -------------------------------------- MARK: postfix-increment-expression
-
-	// Spec: https://phplang.org/spec/10-expressions.html#grammar-postfix-increment-expression
-
-	// postfix-increment-expression:
-	//    variable   ++
-
-	// Spec: https://phplang.org/spec/10-expressions.html#grammar-postfix-decrement-expression
-
-	// postfix-decrement-expression:
-	//    variable   --
-
-	// Supported expression: postfix (in/de)crease expression: `$var++; $var--;`
-	if ast.IsVariableExpr(variable) && (parser.isToken(lexer.OpOrPuncToken, "++", false) ||
-		parser.isToken(lexer.OpOrPuncToken, "--", false)) {
-		PrintParserCallstack("postfix-decrement-expression", parser)
-		return ast.NewPostfixIncExpr(parser.nextId(), parser.at().Position, variable, parser.eat().Value), nil
-	}
-
 	// -------------------------------------- prefix-increment-expression -------------------------------------- MARK: prefix-increment-expression
 
 	// Spec: https://phplang.org/spec/10-expressions.html#grammar-prefix-increment-expression
@@ -2347,7 +2234,24 @@ func (parser *Parser) parsePrimaryExpr() (ast.IExpression, phpError.Error) {
 	}
 
 	// TODO byref-assignment-expression
-	// TODO shell-command-expression
+
+	// -------------------------------------- shell-command-expression -------------------------------------- MARK: shell-command-expression
+
+	// Spec: https://phplang.org/spec/10-expressions.html#grammar-shell-command-expression
+
+	// shell-command-expression:
+	//    `   dq-char-sequence(opt)   `
+
+	// Supported expression: shell command expression: `` $files = `ls -la $dir`; ``
+	if parser.isTokenType(lexer.StringLiteralToken, false) && common.IsShellCommandLiteral(parser.at().Value) {
+		PrintParserCallstack("shell-command-expression", parser)
+		pos := parser.at().Position
+		literal := parser.eat().Value
+		command := ast.NewInterpolatedStringLiteralExpr(parser.nextId(), pos,
+			common.ShellCommandLiteralToString(literal), common.ShellCommandLiteralContent(literal), ast.DoubleQuotedString,
+		)
+		return ast.NewShellCommandExpr(parser.nextId(), pos, command), nil
+	}
 
 	// -------------------------------------- (   expression   ) -------------------------------------- MARK: (   expression   )
 
@@ -2360,7 +2264,7 @@ func (parser *Parser) parsePrimaryExpr() (ast.IExpression, phpError.Error) {
 			return ast.NewEmptyExpr(), err
 		}
 		if parser.isToken(lexer.OpOrPuncToken, ")", true) {
-			return ast.NewParenthesizedExpr(parser.nextId(), pos, expr), nil
+			return parser.parsePostfixExpr(ast.NewParenthesizedExpr(parser.nextId(), pos, expr))
 		} else {
 			return ast.NewEmptyExpr(), NewExpectedError(")", parser.at())
 		}
@@ -2378,6 +2282,308 @@ func (parser *Parser) parsePrimaryExpr() (ast.IExpression, phpError.Error) {
 	return ast.NewEmptyExpr(), phpError.NewParseError("Unsupported expression type '%s', value: '%s' at %s", parser.at().TokenType, parser.at().Value, parser.at().GetPosString())
 }
 
+func (parser *Parser) parseSimpleVariable() (ast.IExpression, phpError.Error) {
+	// -------------------------------------- simple-variable -------------------------------------- MARK: simple-variable
+
+	// Spec: https://phplang.org/spec/10-expressions.html#simple-variable
+
+	// simple-variable:
+	//    variable-name
+
+	if parser.isTokenType(lexer.VariableNameToken, false) {
+		PrintParserCallstack("simple-variable", parser)
+		return ast.NewSimpleVariableExpr(parser.nextId(), ast.NewVariableNameExpr(parser.nextId(), parser.at().Position, parser.eat().Value)), nil
+	}
+
+	// Spec: https://phplang.org/spec/10-expressions.html#simple-variable
+
+	// simple-variable:
+	//    $   {   expression   }
+
+	// Supported expression: variable access: `echo $v;`
+	if parser.isToken(lexer.OpOrPuncToken, "$", false) &&
+		parser.next(0).TokenType == lexer.OpOrPuncToken && parser.next(0).Value == "{" {
+		PrintParserCallstack("simple-variable", parser)
+		parser.eatN(2)
+		// Get expression
+		expr, err := parser.parseExpr()
+		if err != nil {
+			return ast.NewEmptyExpr(), err
+		}
+
+		if parser.at().Value == "}" {
+			parser.eat()
+			return ast.NewSimpleVariableExpr(parser.nextId(), expr), nil
+		} else {
+			return ast.NewEmptyExpr(), phpError.NewParseError("End of simple variable expression not detected at %s", parser.at().GetPosString())
+		}
+	}
+
+	// Spec: https://phplang.org/spec/10-expressions.html#simple-variable
+
+	// simple-variable:
+	//    $   simple-variable
+
+	if parser.isToken(lexer.OpOrPuncToken, "$", true) {
+		PrintParserCallstack("simple-variable", parser)
+		expr, err := parser.parseSimpleVariable()
+		if err != nil {
+			return ast.NewEmptyExpr(), err
+		}
+		if expr == nil {
+			return ast.NewEmptyExpr(), NewExpectedError("variable", parser.at())
+		}
+		return ast.NewSimpleVariableExpr(parser.nextId(), expr), nil
+	}
+
+	return nil, nil
+}
+
+func (parser *Parser) parsePostfixExpr(expr ast.IExpression) (ast.IExpression, phpError.Error) {
+	// Spec: https://phplang.org/spec/10-expressions.html#grammar-dereferencable-expression
+
+	// dereferencable-expression:
+	//    variable
+	//    (   expression   )
+	//    array-creation-expression
+	//    string-literal
+
+	// callable-expression:
+	//    callable-variable
+	//    (   expression   )
+	//    array-creation-expression
+	//    string-literal
+
+	// The following expressions can occur multiple times: $a[0]()["abc"]()()->b?->c()::d()...
+	for {
+		// -------------------------------------- subscript-expression -------------------------------------- MARK: subscript-expression
+
+		// Spec: https://phplang.org/spec/10-expressions.html#grammar-subscript-expression
+
+		// subscript-expression:
+		//    dereferencable-expression   [   expression(opt)   ]
+		//    dereferencable-expression   {   expression   }   <b>[Deprecated form]</b>
+
+		// Supported expression: subscript expression: `$a[1];`
+		if parser.isToken(lexer.OpOrPuncToken, "[", true) {
+			PrintParserCallstack("subscript-expression", parser)
+			var err phpError.Error
+			var index ast.IExpression
+			if !parser.isToken(lexer.OpOrPuncToken, "]", false) {
+				index, err = parser.parseExpr()
+				if err != nil {
+					return ast.NewEmptyExpr(), err
+				}
+			}
+			if !parser.isToken(lexer.OpOrPuncToken, "]", true) {
+				return ast.NewEmptyExpr(), NewExpectedError("]", parser.at())
+			}
+			expr = ast.NewSubscriptExpr(parser.nextId(), expr, index)
+			continue
+		}
+
+		// -------------------------------------- function-call-expression -------------------------------------- MARK: function-call-expression
+
+		// Spec: https://phplang.org/spec/10-expressions.html#grammar-function-call-expression
+
+		// function-call-expression:
+		//    callable-expression   (   argument-expression-list(opt)   )
+		//    callable-expression   (   argument-expression-list   ,   )
+
+		if parser.isToken(lexer.OpOrPuncToken, "(", false) {
+			PrintParserCallstack("function-call-expression", parser)
+			args, err := parser.parseArgumentExpressionList()
+			if err != nil {
+				return ast.NewEmptyExpr(), err
+			}
+			expr = ast.NewFunctionCallExpr(parser.nextId(), expr.GetPosition(), expr, args)
+			continue
+		}
+
+		// -------------------------------------- member-access-expression -------------------------------------- MARK: member-access-expression
+
+		// Spec: https://phplang.org/spec/10-expressions.html#member-access-operator
+
+		// member-access-expression:
+		//    dereferencable-expression   ->   member-name
+		// Spec-Fix: dereferencable-expression   ?->   member-name
+
+		// -------------------------------------- member-call-expression -------------------------------------- MARK: member-call-expression
+
+		// Spec: https://phplang.org/spec/10-expressions.html#grammar-member-call-expression
+
+		// member-call-expression:
+		//    dereferencable-expression   ->   member-name   (   argument-expression-list(opt)   )
+		//    dereferencable-expression   ->   member-name   (   argument-expression-list   ,   )
+		// Spec-Fix: dereferencable-expression   ?->   member-name   (   argument-expression-list(opt)   )
+
+		// Supported expression: member access expression: `$obj->member; $obj?->member;`
+		// Supported expression: member call expression: `$obj->method(); $obj?->method();`
+		if parser.isToken(lexer.OpOrPuncToken, "->", false) || parser.isToken(lexer.OpOrPuncToken, "?->", false) {
+			isNullsafe := parser.at().Value == "?->"
+			pos := parser.eat().Position
+
+			member, err := parser.parseMemberName()
+			if err != nil {
+				return ast.NewEmptyExpr(), err
+			}
+
+			if parser.isToken(lexer.OpOrPuncToken, "(", false) {
+				PrintParserCallstack("member-call-expression", parser)
+				args, err := parser.parseArgumentExpressionList()
+				if err != nil {
+					return ast.NewEmptyExpr(), err
+				}
+				expr = ast.NewMemberCallExpr(parser.nextId(), pos, expr, member, args, isNullsafe)
+				continue
+			}
+
+			PrintParserCallstack("member-access-expression", parser)
+			expr = ast.NewMemberAccessExpr(parser.nextId(), pos, expr, member, isNullsafe)
+			continue
+		}
+
+		// -------------------------------------- scoped-call-expression -------------------------------------- MARK: scoped-call-expression
+
+		// Spec: https://phplang.org/spec/10-expressions.html#grammar-scoped-call-expression
+
+		// scoped-call-expression:
+		//    scope-resolution-qualifier   ::   member-name   (   argument-expression-list(opt)   )
+		//    scope-resolution-qualifier   ::   member-name   (   argument-expression-list,(opt)   )
+
+		// -------------------------------------- class-constant-access-expression -------------------------------------- MARK: class-constant-access-expression
+
+		// Spec: https://phplang.org/spec/10-expressions.html#grammar-class-constant-access-expression
+
+		// class-constant-access-expression:
+		//    scope-resolution-qualifier   ::   name
+
+		// Supported expression: scoped call expression: `MyClass::method(); parent::method();`
+		// Supported expression: class constant access expression: `MyClass::CONSTANT; MyClass::class;`
+		if parser.isToken(lexer.OpOrPuncToken, "::", false) {
+			pos := parser.eat().Position
+
+			// TODO scoped-property-access-expression
+			if parser.isTokenType(lexer.VariableNameToken, false) {
+				return ast.NewEmptyExpr(), phpError.NewParseError("Unsupported scoped property access at %s", parser.at().GetPosString())
+			}
+
+			if !parser.isTokenType(lexer.NameToken, false) && !parser.isTokenType(lexer.KeywordToken, false) {
+				return ast.NewEmptyExpr(), NewExpectedError("member name", parser.at())
+			}
+
+			if parser.next(0).TokenType == lexer.OpOrPuncToken && parser.next(0).Value == "(" {
+				PrintParserCallstack("scoped-call-expression", parser)
+				member := ast.NewStringLiteralExpr(parser.nextId(), parser.at().Position, parser.eat().Value, ast.SingleQuotedString)
+				args, err := parser.parseArgumentExpressionList()
+				if err != nil {
+					return ast.NewEmptyExpr(), err
+				}
+				expr = ast.NewScopedCallExpr(parser.nextId(), pos, expr, member, args)
+				continue
+			}
+
+			PrintParserCallstack("class-constant-access-expression", parser)
+			expr = ast.NewClassConstantAccessExpr(parser.nextId(), pos, expr, parser.eat().Value)
+			continue
+		}
+
+		break
+	}
+
+	// -------------------------------------- postfix-increment-expression -------------------------------------- MARK: postfix-increment-expression
+
+	// Spec: https://phplang.org/spec/10-expressions.html#grammar-postfix-increment-expression
+
+	// postfix-increment-expression:
+	//    variable   ++
+
+	// Spec: https://phplang.org/spec/10-expressions.html#grammar-postfix-decrement-expression
+
+	// postfix-decrement-expression:
+	//    variable   --
+
+	// Supported expression: postfix (in/de)crease expression: `$var++; $var--;`
+	if ast.IsVariableExpr(expr) && (parser.isToken(lexer.OpOrPuncToken, "++", false) ||
+		parser.isToken(lexer.OpOrPuncToken, "--", false)) {
+		PrintParserCallstack("postfix-decrement-expression", parser)
+		return ast.NewPostfixIncExpr(parser.nextId(), parser.at().Position, expr, parser.eat().Value), nil
+	}
+
+	return expr, nil
+}
+
+func (parser *Parser) parseMemberName() (ast.IExpression, phpError.Error) {
+	// Spec: https://phplang.org/spec/10-expressions.html#grammar-member-name
+
+	// member-name:
+	//    name
+	//    simple-variable
+	//    {   expression   }
+
+	if parser.isTokenType(lexer.NameToken, false) || parser.isTokenType(lexer.KeywordToken, false) {
+		return ast.NewStringLiteralExpr(parser.nextId(), parser.at().Position, parser.eat().Value, ast.SingleQuotedString), nil
+	}
+
+	if parser.isToken(lexer.OpOrPuncToken, "{", true) {
+		expr, err := parser.parseExpr()
+		if err != nil {
+			return ast.NewEmptyExpr(), err
+		}
+		if !parser.isToken(lexer.OpOrPuncToken, "}", true) {
+			return ast.NewEmptyExpr(), NewExpectedError("}", parser.at())
+		}
+		return expr, nil
+	}
+
+	variable, err := parser.parseSimpleVariable()
+	if err != nil {
+		return ast.NewEmptyExpr(), err
+	}
+	if variable == nil {
+		return ast.NewEmptyExpr(), NewExpectedError("member name", parser.at())
+	}
+	return variable, nil
+}
+
+func (parser *Parser) parseArgumentExpressionList() ([]ast.IExpression, phpError.Error) {
+	// Spec: https://phplang.org/spec/10-expressions.html#grammar-argument-expression-list
+
+	// argument-expression-list:
+	//    argument-expression
+	//    argument-expression-list   ,   argument-expression
+
+	// argument-expression:
+	//    variadic-unpacking
+	//    expression
+
+	// variadic-unpacking:
+	//    ...   expression
+
+	if !parser.isToken(lexer.OpOrPuncToken, "(", true) {
+		return []ast.IExpression{}, NewExpectedError("(", parser.at())
+	}
+
+	args := []ast.IExpression{}
+	for {
+		if parser.isToken(lexer.OpOrPuncToken, ")", true) {
+			break
+		}
+
+		arg, err := parser.parseExpr()
+		if err != nil {
+			return args, err
+		}
+		args = append(args, arg)
+
+		if parser.isToken(lexer.OpOrPuncToken, ",", true) || parser.isToken(lexer.OpOrPuncToken, ")", false) {
+			continue
+		}
+		return args, phpError.NewParseError("Expected \",\" or \")\". Got: %s", parser.at())
+	}
+	return args, nil
+}
+
 func (parser *Parser) parseLiteral() (ast.IExpression, phpError.Error) {
 	// -------------------------------------- literal -------------------------------------- MARK: literal
 
@@ -2423,15 +2629,19 @@ func (parser *Parser) parseLiteral() (ast.IExpression, phpError.Error) {
 
 		// double-quoted-string-literal
 		if common.IsDoubleQuotedStringLiteral(parser.at().Value) {
-			return ast.NewStringLiteralExpr(
-					parser.nextId(), parser.at().Position, common.DoubleQuotedStringLiteralToString(parser.eat().Value), ast.DoubleQuotedString),
+			pos := parser.at().Position
+			literal := parser.eat().Value
+			return ast.NewInterpolatedStringLiteralExpr(parser.nextId(), pos,
+					common.DoubleQuotedStringLiteralToString(literal), common.DoubleQuotedStringLiteralContent(literal), ast.DoubleQuotedString),
 				nil
 		}
 
 		// heredoc-string-literal
 		if common.IsHeredocStringLiteral(parser.at().Value) {
-			return ast.NewStringLiteralExpr(
-					parser.nextId(), parser.at().Position, common.HeredocStringLiteralToString(parser.eat().Value), ast.HeredocString),
+			pos := parser.at().Position
+			literal := parser.eat().Value
+			return ast.NewInterpolatedStringLiteralExpr(parser.nextId(), pos,
+					common.HeredocStringLiteralToString(literal), common.HeredocStringLiteralContent(literal), ast.HeredocString),
 				nil
 		}
 
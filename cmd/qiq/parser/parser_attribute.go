@@ -0,0 +1,104 @@
+package parser
+
+import (
+	"QIQ/cmd/qiq/ast"
+	"QIQ/cmd/qiq/common"
+	"QIQ/cmd/qiq/lexer"
+	"QIQ/cmd/qiq/phpError"
+)
+
+func (parser *Parser) parseAttributes() ([]*ast.Attribute, phpError.Error) {
+	// -------------------------------------- attributes -------------------------------------- MARK: attributes
+
+	// Spec: https://www.php.net/manual/en/language.attributes.syntax.php
+
+	// attribute-groups:
+	//    attribute-group
+	//    attribute-groups   attribute-group
+
+	// attribute-group:
+	//    #[   attribute-list   ,(opt)   ]
+
+	// attribute-list:
+	//    attribute
+	//    attribute-list   ,   attribute
+
+	// attribute:
+	//    qualified-name   attribute-arguments(opt)
+
+	// attribute-arguments:
+	//    (   attribute-argument-list(opt)   )
+
+	// attribute-argument:
+	//    constant-expression
+	//    name   :   constant-expression
+
+	// Supported statement: attributes: `#[Route("/home", methods: ["GET"])] function home() {}`
+	var attributes []*ast.Attribute
+	for parser.isToken(lexer.OpOrPuncToken, "#[", true) {
+		PrintParserCallstack("attribute-group", parser)
+		for {
+			// Allow trailing comma
+			if parser.isToken(lexer.OpOrPuncToken, "]", true) {
+				break
+			}
+
+			pos := parser.at().Position
+			name := parser.at().Value
+			if parser.at().TokenType != lexer.NameToken || !common.IsQualifiedName(name) {
+				return attributes, phpError.NewParseError("\"%s\" is not a valid attribute name at %s", name, parser.at().GetPosString())
+			}
+			parser.eat()
+
+			arguments := []*ast.AttributeArgument{}
+			if parser.isToken(lexer.OpOrPuncToken, "(", true) {
+				var err phpError.Error
+				arguments, err = parser.parseAttributeArguments()
+				if err != nil {
+					return attributes, err
+				}
+			}
+			attributes = append(attributes, ast.NewAttribute(pos, name, arguments))
+
+			if parser.isToken(lexer.OpOrPuncToken, ",", true) {
+				continue
+			}
+			if parser.isToken(lexer.OpOrPuncToken, "]", true) {
+				break
+			}
+			return attributes, phpError.NewParseError("Expected \",\" or \"]\". Got: %s", parser.at())
+		}
+	}
+	return attributes, nil
+}
+
+func (parser *Parser) parseAttributeArguments() ([]*ast.AttributeArgument, phpError.Error) {
+	arguments := []*ast.AttributeArgument{}
+	for {
+		if parser.isToken(lexer.OpOrPuncToken, ")", true) {
+			return arguments, nil
+		}
+
+		// Named argument
+		name := ""
+		if (parser.at().TokenType == lexer.NameToken || parser.at().TokenType == lexer.KeywordToken) &&
+			parser.next(0).TokenType == lexer.OpOrPuncToken && parser.next(0).Value == ":" {
+			name = parser.at().Value
+			parser.eatN(2)
+		} else if len(arguments) > 0 && arguments[len(arguments)-1].Name != "" {
+			return arguments, phpError.NewError("Cannot use positional argument after named argument in %s", parser.at().GetPosString())
+		}
+
+		// TODO parse constant-expression
+		value, err := parser.parseExpr()
+		if err != nil {
+			return arguments, err
+		}
+		arguments = append(arguments, ast.NewAttributeArgument(name, value))
+
+		if parser.isToken(lexer.OpOrPuncToken, ",", true) || parser.isToken(lexer.OpOrPuncToken, ")", false) {
+			continue
+		}
+		return arguments, phpError.NewParseError("Expected \",\" or \")\". Got: %s", parser.at())
+	}
+}
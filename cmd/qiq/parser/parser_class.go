@@ -6,6 +6,7 @@ import (
 	"QIQ/cmd/qiq/lexer"
 	"QIQ/cmd/qiq/phpError"
 	"QIQ/cmd/qiq/position"
+	"slices"
 )
 
 func (parser *Parser) parseObjectCreationExpression() (ast.IExpression, phpError.Error) {
@@ -183,8 +184,17 @@ func (parser *Parser) parseClassMemberDeclaration(class *ast.ClassDeclarationSta
 			return nil
 		}
 
+		// attributes
+		attributes, err := parser.parseAttributes()
+		if err != nil {
+			return err
+		}
+
 		// trait-use-clause
 		if parser.isToken(lexer.KeywordToken, "use", false) {
+			if len(attributes) > 0 {
+				return phpError.NewParseError("Attributes cannot be applied to a trait use clause at %s", parser.at().GetPosString())
+			}
 			if err := parser.parserTraitUseClause(class); err != nil {
 				return err
 			}
@@ -195,14 +205,14 @@ func (parser *Parser) parseClassMemberDeclaration(class *ast.ClassDeclarationSta
 		if (parser.isTokenType(lexer.KeywordToken, false) && common.IsVisibilitModifierKeyword(parser.at().Value) &&
 			parser.next(0).TokenType == lexer.KeywordToken && parser.next(0).Value == "const") ||
 			parser.isToken(lexer.KeywordToken, "const", false) {
-			if err := parser.parseClassConstDeclaration(class); err != nil {
+			if err := parser.parseClassConstDeclaration(class, attributes); err != nil {
 				return err
 			}
 			continue
 		}
 
 		// constructor-declaration
-		isConstructorDeclaration, err := parser.parseClassConstrutorDeclaration(class)
+		isConstructorDeclaration, err := parser.parseClassConstrutorDeclaration(class, attributes)
 		if isConstructorDeclaration && err != nil {
 			return err
 		}
@@ -211,7 +221,7 @@ func (parser *Parser) parseClassMemberDeclaration(class *ast.ClassDeclarationSta
 		}
 
 		// destructor-declaration
-		isDestructorDeclaration, err := parser.parseClassDestrutorDeclaration(class)
+		isDestructorDeclaration, err := parser.parseClassDestrutorDeclaration(class, attributes)
 		if isDestructorDeclaration && err != nil {
 			return err
 		}
@@ -220,7 +230,7 @@ func (parser *Parser) parseClassMemberDeclaration(class *ast.ClassDeclarationSta
 		}
 
 		// method-declaration
-		isMethodDeclaration, err := parser.parseClassMethodDeclaration(class)
+		isMethodDeclaration, err := parser.parseClassMethodDeclaration(class, attributes)
 		if isMethodDeclaration && err != nil {
 			return err
 		}
@@ -229,7 +239,7 @@ func (parser *Parser) parseClassMemberDeclaration(class *ast.ClassDeclarationSta
 		}
 
 		// property-declaration
-		isPropertyDeclaration, err := parser.parseClassPropertyDeclaration(class)
+		isPropertyDeclaration, err := parser.parseClassPropertyDeclaration(class, attributes)
 		if isPropertyDeclaration && err != nil {
 			return err
 		}
@@ -241,7 +251,7 @@ func (parser *Parser) parseClassMemberDeclaration(class *ast.ClassDeclarationSta
 	}
 }
 
-func (parser *Parser) parseClassConstDeclaration(class *ast.ClassDeclarationStatement) phpError.Error {
+func (parser *Parser) parseClassConstDeclaration(class *ast.ClassDeclarationStatement, attributes []*ast.Attribute) phpError.Error {
 	// -------------------------------------- class-const-declaration -------------------------------------- MARK: class-const-declaration
 
 	// Spec: https://phplang.org/spec/14-classes.html#grammar-class-const-declaration
@@ -280,7 +290,9 @@ func (parser *Parser) parseClassConstDeclaration(class *ast.ClassDeclarationStat
 			return err
 		}
 
-		class.AddConst(ast.NewClassConstDeclarationStmt(parser.nextId(), pos, name, value, visibility))
+		constant := ast.NewClassConstDeclarationStmt(parser.nextId(), pos, name, value, visibility)
+		constant.Attributes = attributes
+		class.AddConst(constant)
 		if parser.isToken(lexer.OpOrPuncToken, ",", true) {
 			continue
 		}
@@ -349,7 +361,7 @@ func (parser *Parser) parserTraitUseClause(class *ast.ClassDeclarationStatement)
 	}
 }
 
-func (parser *Parser) parseClassConstrutorDeclaration(class *ast.ClassDeclarationStatement) (bool, phpError.Error) {
+func (parser *Parser) parseClassConstrutorDeclaration(class *ast.ClassDeclarationStatement, attributes []*ast.Attribute) (bool, phpError.Error) {
 	// -------------------------------------- constructor-declaration -------------------------------------- MARK: constructor-declaration
 
 	// Spec: https://phplang.org/spec/14-classes.html#grammar-constructor-declaration
@@ -414,15 +426,17 @@ func (parser *Parser) parseClassConstrutorDeclaration(class *ast.ClassDeclaratio
 		return isConstructor, phpError.NewParseError("Expected compound statement. Got %s", body.GetKind())
 	}
 
-	class.AddMethod(ast.NewMethodDefinitionStmt(
+	method := ast.NewMethodDefinitionStmt(
 		parser.nextId(), pos,
-		"__construct", modifiers, parameters, body.(*ast.CompoundStatement), []string{"void"},
-	))
+		"__construct", modifiers, parameters, body.(*ast.CompoundStatement), nil,
+	)
+	method.Attributes = attributes
+	class.AddMethod(method)
 
 	return isConstructor, nil
 }
 
-func (parser *Parser) parseClassDestrutorDeclaration(class *ast.ClassDeclarationStatement) (bool, phpError.Error) {
+func (parser *Parser) parseClassDestrutorDeclaration(class *ast.ClassDeclarationStatement, attributes []*ast.Attribute) (bool, phpError.Error) {
 	// -------------------------------------- destructor-declaration -------------------------------------- MARK: destructor-declaration
 
 	// Spec: https://phplang.org/spec/14-classes.html#grammar-destructor-declaration
@@ -482,15 +496,17 @@ func (parser *Parser) parseClassDestrutorDeclaration(class *ast.ClassDeclaration
 		return isDestructor, phpError.NewParseError("Expected compound statement. Got %s", body.GetKind())
 	}
 
-	class.AddMethod(ast.NewMethodDefinitionStmt(
+	method := ast.NewMethodDefinitionStmt(
 		parser.nextId(), pos,
-		"__destruct", modifiers, []ast.FunctionParameter{}, body.(*ast.CompoundStatement), []string{"void"},
-	))
+		"__destruct", modifiers, []ast.FunctionParameter{}, body.(*ast.CompoundStatement), nil,
+	)
+	method.Attributes = attributes
+	class.AddMethod(method)
 
 	return isDestructor, nil
 }
 
-func (parser *Parser) parseClassMethodDeclaration(class *ast.ClassDeclarationStatement) (bool, phpError.Error) {
+func (parser *Parser) parseClassMethodDeclaration(class *ast.ClassDeclarationStatement, attributes []*ast.Attribute) (bool, phpError.Error) {
 	// -------------------------------------- method-declaration -------------------------------------- MARK: method-declaration
 
 	// Spec: https://phplang.org/spec/14-classes.html#grammar-method-declaration
@@ -559,9 +575,9 @@ func (parser *Parser) parseClassMethodDeclaration(class *ast.ClassDeclarationSta
 	}
 
 	// return-type
-	var returnTypes []string = []string{"mixed"}
+	var returnType *ast.TypeDeclaration = nil
 	if parser.isToken(lexer.OpOrPuncToken, ":", true) {
-		returnTypes, err = parser.getTypes(true)
+		returnType, err = parser.getType(true)
 		if err != nil {
 			return isMethod, err
 		}
@@ -576,15 +592,17 @@ func (parser *Parser) parseClassMethodDeclaration(class *ast.ClassDeclarationSta
 		return isMethod, phpError.NewParseError("Expected compound statement. Got %s", body.GetKind())
 	}
 
-	class.AddMethod(ast.NewMethodDefinitionStmt(
+	method := ast.NewMethodDefinitionStmt(
 		parser.nextId(), pos,
-		name, modifiers, parameters, body.(*ast.CompoundStatement), returnTypes,
-	))
+		name, modifiers, parameters, body.(*ast.CompoundStatement), returnType,
+	)
+	method.Attributes = attributes
+	class.AddMethod(method)
 
 	return isMethod, nil
 }
 
-func (parser *Parser) parseClassPropertyDeclaration(class *ast.ClassDeclarationStatement) (bool, phpError.Error) {
+func (parser *Parser) parseClassPropertyDeclaration(class *ast.ClassDeclarationStatement, attributes []*ast.Attribute) (bool, phpError.Error) {
 	// -------------------------------------- property-declaration -------------------------------------- MARK: property-declaration
 
 	// Spec: https://phplang.org/spec/14-classes.html#grammar-property-declaration
@@ -611,7 +629,7 @@ func (parser *Parser) parseClassPropertyDeclaration(class *ast.ClassDeclarationS
 	offset := -1
 	visibilityModifierKeyword := ""
 	staticModifierKeyword := ""
-	propertyType := []string{"mixed"}
+	var propertyType *ast.TypeDeclaration = nil
 
 	token := func() *lexer.Token {
 		return parser.next(offset)
@@ -638,7 +656,7 @@ func (parser *Parser) parseClassPropertyDeclaration(class *ast.ClassDeclarationS
 		// Property type
 		if step == "modifier" && parser.isPhpType(token()) {
 			var err phpError.Error
-			propertyType, offset, err = parser.getTypesWithOffset(false, offset)
+			propertyType, offset, err = parser.getTypeWithOffset(false, offset)
 			if err != nil {
 				return isProperty, err
 			}
@@ -668,6 +686,12 @@ func (parser *Parser) parseClassPropertyDeclaration(class *ast.ClassDeclarationS
 	pos := parser.at().Position
 	name := parser.eat().Value
 
+	// Spec: https://www.php.net/manual/en/language.oop5.properties.php#language.oop5.properties.typed-properties
+	// Typed properties do not support the types void, never and callable.
+	if propertyType != nil && propertyType.Kind == ast.NamedType && slices.Contains([]string{"void", "never", "callable"}, propertyType.Name) {
+		return isProperty, phpError.NewError("Property %s::%s cannot have type %s in %s", class.Name, name, propertyType.Name, pos.ToPosString())
+	}
+
 	// property-initializer
 	var initialValue ast.IExpression = nil
 	if parser.isToken(lexer.OpOrPuncToken, "=", true) {
@@ -688,7 +712,9 @@ func (parser *Parser) parseClassPropertyDeclaration(class *ast.ClassDeclarationS
 		return isProperty, NewExpectedError(";", parser.at())
 	}
 
-	class.AddProperty(ast.NewPropertyDeclarationStmt(parser.nextId(), pos, name, visibilityModifierKeyword, staticModifierKeyword != "", propertyType, initialValue))
+	property := ast.NewPropertyDeclarationStmt(parser.nextId(), pos, name, visibilityModifierKeyword, staticModifierKeyword != "", propertyType, initialValue)
+	property.Attributes = attributes
+	class.AddProperty(property)
 
 	return isProperty, nil
 }
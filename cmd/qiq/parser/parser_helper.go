@@ -1,12 +1,14 @@
 package parser
 
 import (
+	"QIQ/cmd/qiq/ast"
 	"QIQ/cmd/qiq/common"
 	"QIQ/cmd/qiq/config"
 	"QIQ/cmd/qiq/lexer"
 	"QIQ/cmd/qiq/phpError"
 	"QIQ/cmd/qiq/position"
 	"fmt"
+	"slices"
 	"strings"
 )
 
@@ -123,50 +125,140 @@ func (parser *Parser) isTextExpression(eat bool) bool {
 }
 
 func (parser *Parser) isPhpType(token *lexer.Token) bool {
-	return token.TokenType == lexer.OpOrPuncToken && token.Value == "?" ||
-		token.TokenType == lexer.KeywordToken && common.IsReturnTypeKeyword(token.Value)
+	return token.TokenType == lexer.OpOrPuncToken && (token.Value == "?" || token.Value == "(") || parser.isPhpTypeName(token)
 }
 
-func (parser *Parser) getTypes(eat bool) ([]string, phpError.Error) {
-	types, _, err := parser.getTypesWithOffset(eat, -1)
-	return types, err
+func (parser *Parser) isPhpTypeName(token *lexer.Token) bool {
+	return token.TokenType == lexer.KeywordToken && common.IsReturnTypeKeyword(token.Value) ||
+		token.TokenType == lexer.NameToken
 }
 
-func (parser *Parser) getTypesWithOffset(eat bool, offset int) ([]string, int, phpError.Error) {
-	types := []string{}
+func (parser *Parser) getType(eat bool) (*ast.TypeDeclaration, phpError.Error) {
+	typeDecl, _, err := parser.getTypeWithOffset(eat, -1)
+	return typeDecl, err
+}
+
+func (parser *Parser) getTypeWithOffset(eat bool, offset int) (*ast.TypeDeclaration, int, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/language.types.declarations.php
+
+	// type-declaration:
+	//    ?   type-name
+	//    union-type-member
+	//    union-type-member   |   union-type-member ...
+
+	// union-type-member:
+	//    type-name
+	//    type-name   &   type-name ...
+	//    (   type-name   &   type-name ...   )
 
 	token := func() *lexer.Token {
 		return parser.next(offset)
 	}
+	pos := token().GetPosString()
 
-	if token().TokenType == lexer.OpOrPuncToken && token().Value == "?" {
-		types = append(types, "null")
+	getTypeName := func() (*ast.TypeDeclaration, phpError.Error) {
+		if !parser.isPhpTypeName(token()) {
+			return nil, phpError.NewParseError("Expected a type. Got \"%s\" at %s", token().Value, token().GetPosString())
+		}
+		typeDecl := ast.NewNamedType(token().Value)
 		offset++
+		return typeDecl, nil
 	}
 
-	if !parser.isPhpType(token()) {
-		return types, offset, phpError.NewParseError("Expected a type. Got \"%s\"s at %s", token().Value, token().GetPosString())
+	getIntersectionType := func(first *ast.TypeDeclaration) (*ast.TypeDeclaration, phpError.Error) {
+		types := []*ast.TypeDeclaration{first}
+		// Do not confuse the intersection with a by-reference parameter "A &$a"
+		for token().TokenType == lexer.OpOrPuncToken && token().Value == "&" && parser.isPhpTypeName(parser.next(offset+1)) {
+			offset++
+			typeDecl, err := getTypeName()
+			if err != nil {
+				return nil, err
+			}
+			types = append(types, typeDecl)
+		}
+		if len(types) == 1 {
+			return first, nil
+		}
+		for _, typeDecl := range types {
+			if typeDecl.IsBuiltin() {
+				return nil, phpError.NewError("Type %s cannot be part of an intersection type in %s", typeDecl.Name, pos)
+			}
+		}
+		return ast.NewIntersectionType(types...), nil
 	}
 
-	for parser.isPhpType(token()) {
-		types = append(types, strings.ToLower(token().Value))
+	var typeDecl *ast.TypeDeclaration
+	if token().TokenType == lexer.OpOrPuncToken && token().Value == "?" {
 		offset++
-
-		if token().TokenType == lexer.OpOrPuncToken && token().Value == "|" {
-			offset++
-			continue
+		namedType, err := getTypeName()
+		if err != nil {
+			return nil, offset, err
+		}
+		if slices.Contains([]string{"mixed", "null", "void", "never"}, namedType.Name) {
+			return nil, offset, phpError.NewError("%s cannot be marked as nullable in %s", namedType.Name, pos)
+		}
+		typeDecl = ast.NewNullableType(namedType)
+	} else {
+		types := []*ast.TypeDeclaration{}
+		for {
+			if token().TokenType == lexer.OpOrPuncToken && token().Value == "(" {
+				offset++
+				first, err := getTypeName()
+				if err != nil {
+					return nil, offset, err
+				}
+				intersectionType, err := getIntersectionType(first)
+				if err != nil {
+					return nil, offset, err
+				}
+				if intersectionType.Kind != ast.IntersectionType {
+					return nil, offset, NewExpectedError("&", token())
+				}
+				if token().TokenType != lexer.OpOrPuncToken || token().Value != ")" {
+					return nil, offset, NewExpectedError(")", token())
+				}
+				offset++
+				types = append(types, intersectionType)
+			} else {
+				first, err := getTypeName()
+				if err != nil {
+					return nil, offset, err
+				}
+				memberType, err := getIntersectionType(first)
+				if err != nil {
+					return nil, offset, err
+				}
+				types = append(types, memberType)
+			}
+
+			if token().TokenType == lexer.OpOrPuncToken && token().Value == "|" {
+				offset++
+				continue
+			}
+			break
 		}
 
-		break
+		if len(types) == 1 {
+			typeDecl = types[0]
+		} else {
+			names := []string{}
+			for _, memberType := range types {
+				if memberType.Kind == ast.NamedType && slices.Contains([]string{"mixed", "void", "never"}, memberType.Name) {
+					return nil, offset, phpError.NewError("Type %s can only be used as a standalone type in %s", memberType.Name, pos)
+				}
+				name := strings.ToLower(memberType.String())
+				if slices.Contains(names, name) {
+					return nil, offset, phpError.NewError("Duplicate type %s is redundant in %s", memberType, pos)
+				}
+				names = append(names, name)
+			}
+			typeDecl = ast.NewUnionType(types...)
+		}
 	}
 
 	if eat {
 		parser.eatN(offset + 1)
 	}
 
-	if len(types) == 0 {
-		types = append(types, "mixed")
-	}
-
-	return types, offset, nil
+	return typeDecl, offset, nil
 }
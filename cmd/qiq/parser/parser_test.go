@@ -66,6 +66,11 @@ func TestVariable(t *testing.T) {
 	})
 }
 
+func TestShellCommand(t *testing.T) {
+	testExpr(t, "<?php `ls -la $dir`;", ast.NewShellCommandExpr(0, nil, ast.NewStringLiteralExpr(0, nil, "ls -la $dir", ast.DoubleQuotedString)))
+	testExpr(t, "<?php `echo \\`date\\``;", ast.NewShellCommandExpr(0, nil, ast.NewStringLiteralExpr(0, nil, "echo `date`", ast.DoubleQuotedString)))
+}
+
 func TestArray(t *testing.T) {
 	// Subscript
 	testExpr(t, "<?php $myVar[];", ast.NewSubscriptExpr(0, ast.NewSimpleVariableExpr(0, ast.NewVariableNameExpr(0, nil, "$myVar")), nil))
@@ -79,6 +84,35 @@ func TestFunctionCall(t *testing.T) {
 	testExpr(t, "<?php func();", ast.NewFunctionCallExpr(0, nil, ast.NewStringLiteralExpr(0, nil, "func", ast.SingleQuotedString), []ast.IExpression{}))
 	// With argument
 	testExpr(t, "<?php func(42);", ast.NewFunctionCallExpr(0, nil, ast.NewStringLiteralExpr(0, nil, "func", ast.SingleQuotedString), []ast.IExpression{ast.NewIntegerLiteralExpr(0, nil, 42)}))
+	// Chained
+	testExpr(t, `<?php $a[0]()["abc"];`, ast.NewSubscriptExpr(0,
+		ast.NewFunctionCallExpr(0, nil,
+			ast.NewSubscriptExpr(0, ast.NewSimpleVariableExpr(0, ast.NewVariableNameExpr(0, nil, "$a")), ast.NewIntegerLiteralExpr(0, nil, 0)),
+			[]ast.IExpression{},
+		),
+		ast.NewStringLiteralExpr(0, nil, "abc", ast.DoubleQuotedString),
+	))
+}
+
+func TestMemberAccess(t *testing.T) {
+	obj := ast.NewSimpleVariableExpr(0, ast.NewVariableNameExpr(0, nil, "$obj"))
+	testExpr(t, `<?php $obj->prop;`, ast.NewMemberAccessExpr(0, nil, obj, ast.NewStringLiteralExpr(0, nil, "prop", ast.SingleQuotedString), false))
+	testExpr(t, `<?php $obj?->prop;`, ast.NewMemberAccessExpr(0, nil, obj, ast.NewStringLiteralExpr(0, nil, "prop", ast.SingleQuotedString), true))
+	testExpr(t, `<?php $obj->method(42);`, ast.NewMemberCallExpr(0, nil, obj,
+		ast.NewStringLiteralExpr(0, nil, "method", ast.SingleQuotedString), []ast.IExpression{ast.NewIntegerLiteralExpr(0, nil, 42)}, false,
+	))
+	testExpr(t, `<?php $obj?->a()->b;`, ast.NewMemberAccessExpr(0, nil,
+		ast.NewMemberCallExpr(0, nil, obj, ast.NewStringLiteralExpr(0, nil, "a", ast.SingleQuotedString), []ast.IExpression{}, true),
+		ast.NewStringLiteralExpr(0, nil, "b", ast.SingleQuotedString), false,
+	))
+	testExpr(t, `<?php MyClass::create()->prop;`, ast.NewMemberAccessExpr(0, nil,
+		ast.NewScopedCallExpr(0, nil,
+			ast.NewStringLiteralExpr(0, nil, "MyClass", ast.SingleQuotedString),
+			ast.NewStringLiteralExpr(0, nil, "create", ast.SingleQuotedString), []ast.IExpression{},
+		),
+		ast.NewStringLiteralExpr(0, nil, "prop", ast.SingleQuotedString), false,
+	))
+	testExpr(t, `<?php MyClass::CONSTANT;`, ast.NewClassConstantAccessExpr(0, nil, ast.NewStringLiteralExpr(0, nil, "MyClass", ast.SingleQuotedString), "CONSTANT"))
 }
 
 func TestLiteral(t *testing.T) {
@@ -279,6 +313,10 @@ func TestIntrinsic(t *testing.T) {
 	)
 }
 
+func TestCloneExpression(t *testing.T) {
+	testExpr(t, `<?php clone $a;`, ast.NewCloneExpr(0, nil, ast.NewSimpleVariableExpr(0, ast.NewVariableNameExpr(0, nil, "$a"))))
+}
+
 func TestErrorControlExpression(t *testing.T) {
 	testExpr(t, `<?php @func();`,
 		ast.NewErrorControlExpr(0, nil, ast.NewFunctionCallExpr(0, nil, ast.NewStringLiteralExpr(0, nil, "func", ast.SingleQuotedString), []ast.IExpression{})),
@@ -437,83 +475,163 @@ func TestClassDeclaration(t *testing.T) {
 
 	// Simple class with constructor
 	class = ast.NewClassDeclarationStmt(0, nil, "c", false, false)
-	class.AddMethod(ast.NewMethodDefinitionStmt(0, nil, "__construct", []string{"public"}, []ast.FunctionParameter{}, ast.NewCompoundStmt(0, []ast.IStatement{}), []string{"void"}))
+	class.AddMethod(ast.NewMethodDefinitionStmt(0, nil, "__construct", []string{"public"}, []ast.FunctionParameter{}, ast.NewCompoundStmt(0, []ast.IStatement{}), nil))
 	testStmt(t, `<?php class c { function __construct() {} }`, class)
 
 	// Simple class with private constructor
 	class = ast.NewClassDeclarationStmt(0, nil, "c", false, false)
-	class.AddMethod(ast.NewMethodDefinitionStmt(0, nil, "__construct", []string{"private"}, []ast.FunctionParameter{}, ast.NewCompoundStmt(0, []ast.IStatement{}), []string{"void"}))
+	class.AddMethod(ast.NewMethodDefinitionStmt(0, nil, "__construct", []string{"private"}, []ast.FunctionParameter{}, ast.NewCompoundStmt(0, []ast.IStatement{}), nil))
 	testStmt(t, `<?php class c { private function __construct() {} }`, class)
 
 	// Simple class with final private constructor
 	class = ast.NewClassDeclarationStmt(0, nil, "c", false, false)
-	class.AddMethod(ast.NewMethodDefinitionStmt(0, nil, "__construct", []string{"private", "final"}, []ast.FunctionParameter{}, ast.NewCompoundStmt(0, []ast.IStatement{}), []string{"void"}))
+	class.AddMethod(ast.NewMethodDefinitionStmt(0, nil, "__construct", []string{"private", "final"}, []ast.FunctionParameter{}, ast.NewCompoundStmt(0, []ast.IStatement{}), nil))
 	testStmt(t, `<?php class c { final private function __construct() {} }`, class)
 
 	// Simple class with constructor with parameters
 	class = ast.NewClassDeclarationStmt(0, nil, "c", false, false)
-	class.AddMethod(ast.NewMethodDefinitionStmt(0, nil, "__construct", []string{"public"}, []ast.FunctionParameter{{Name: "$name", Type: []string{"string"}}}, ast.NewCompoundStmt(0, []ast.IStatement{}), []string{"void"}))
+	class.AddMethod(ast.NewMethodDefinitionStmt(0, nil, "__construct", []string{"public"}, []ast.FunctionParameter{{Name: "$name", Type: ast.NewNamedType("string")}}, ast.NewCompoundStmt(0, []ast.IStatement{}), nil))
 	testStmt(t, `<?php class c { function __construct(string $name) {} }`, class)
 
 	// Simple class with constructor with body
 	class = ast.NewClassDeclarationStmt(0, nil, "c", false, false)
-	class.AddMethod(ast.NewMethodDefinitionStmt(0, nil, "__construct", []string{"public"}, []ast.FunctionParameter{}, ast.NewCompoundStmt(0, []ast.IStatement{ast.NewExpressionStmt(0, ast.NewExitIntrinsic(0, nil, nil))}), []string{"void"}))
+	class.AddMethod(ast.NewMethodDefinitionStmt(0, nil, "__construct", []string{"public"}, []ast.FunctionParameter{}, ast.NewCompoundStmt(0, []ast.IStatement{ast.NewExpressionStmt(0, ast.NewExitIntrinsic(0, nil, nil))}), nil))
 	testStmt(t, `<?php class c { function __construct() { exit(); } }`, class)
 
 	// Simple class with destructor
 	class = ast.NewClassDeclarationStmt(0, nil, "c", false, false)
-	class.AddMethod(ast.NewMethodDefinitionStmt(0, nil, "__destruct", []string{"public"}, []ast.FunctionParameter{}, ast.NewCompoundStmt(0, []ast.IStatement{}), []string{"void"}))
+	class.AddMethod(ast.NewMethodDefinitionStmt(0, nil, "__destruct", []string{"public"}, []ast.FunctionParameter{}, ast.NewCompoundStmt(0, []ast.IStatement{}), nil))
 	testStmt(t, `<?php class c { function __destruct() {} }`, class)
 
 	// Simple class with private destructor
 	class = ast.NewClassDeclarationStmt(0, nil, "c", false, false)
-	class.AddMethod(ast.NewMethodDefinitionStmt(0, nil, "__destruct", []string{"private"}, []ast.FunctionParameter{}, ast.NewCompoundStmt(0, []ast.IStatement{}), []string{"void"}))
+	class.AddMethod(ast.NewMethodDefinitionStmt(0, nil, "__destruct", []string{"private"}, []ast.FunctionParameter{}, ast.NewCompoundStmt(0, []ast.IStatement{}), nil))
 	testStmt(t, `<?php class c { private function __destruct() {} }`, class)
 
 	// Simple class with final private destructor
 	class = ast.NewClassDeclarationStmt(0, nil, "c", false, false)
-	class.AddMethod(ast.NewMethodDefinitionStmt(0, nil, "__destruct", []string{"private", "final"}, []ast.FunctionParameter{}, ast.NewCompoundStmt(0, []ast.IStatement{}), []string{"void"}))
+	class.AddMethod(ast.NewMethodDefinitionStmt(0, nil, "__destruct", []string{"private", "final"}, []ast.FunctionParameter{}, ast.NewCompoundStmt(0, []ast.IStatement{}), nil))
 	testStmt(t, `<?php class c { final private function __destruct() {} }`, class)
 
 	// Simple class with destructor with body
 	class = ast.NewClassDeclarationStmt(0, nil, "c", false, false)
-	class.AddMethod(ast.NewMethodDefinitionStmt(0, nil, "__destruct", []string{"public"}, []ast.FunctionParameter{}, ast.NewCompoundStmt(0, []ast.IStatement{ast.NewExpressionStmt(0, ast.NewExitIntrinsic(0, nil, nil))}), []string{"void"}))
+	class.AddMethod(ast.NewMethodDefinitionStmt(0, nil, "__destruct", []string{"public"}, []ast.FunctionParameter{}, ast.NewCompoundStmt(0, []ast.IStatement{ast.NewExpressionStmt(0, ast.NewExitIntrinsic(0, nil, nil))}), nil))
 	testStmt(t, `<?php class c { function __destruct() { exit(); } }`, class)
 
 	// Simple class with private static method
 	class = ast.NewClassDeclarationStmt(0, nil, "c", false, false)
-	class.AddMethod(ast.NewMethodDefinitionStmt(0, nil, "myFunction", []string{"private", "static"}, []ast.FunctionParameter{}, ast.NewCompoundStmt(0, []ast.IStatement{}), []string{"mixed"}))
+	class.AddMethod(ast.NewMethodDefinitionStmt(0, nil, "myFunction", []string{"private", "static"}, []ast.FunctionParameter{}, ast.NewCompoundStmt(0, []ast.IStatement{}), nil))
 	testStmt(t, `<?php class c { private static function myFunction() {} }`, class)
 
 	// Simple class with method with parameters
 	class = ast.NewClassDeclarationStmt(0, nil, "c", false, false)
-	class.AddMethod(ast.NewMethodDefinitionStmt(0, nil, "myFunction", []string{"public"}, []ast.FunctionParameter{{Name: "$name", Type: []string{"string"}}}, ast.NewCompoundStmt(0, []ast.IStatement{}), []string{"void"}))
+	class.AddMethod(ast.NewMethodDefinitionStmt(0, nil, "myFunction", []string{"public"}, []ast.FunctionParameter{{Name: "$name", Type: ast.NewNamedType("string")}}, ast.NewCompoundStmt(0, []ast.IStatement{}), ast.NewNamedType("void")))
 	testStmt(t, `<?php class c { function myFunction(string $name): void {} }`, class)
 
 	// Simple class with method with return type
 	class = ast.NewClassDeclarationStmt(0, nil, "c", false, false)
-	class.AddMethod(ast.NewMethodDefinitionStmt(0, nil, "myFunction", []string{"public"}, []ast.FunctionParameter{}, ast.NewCompoundStmt(0, []ast.IStatement{ast.NewExpressionStmt(0, ast.NewExitIntrinsic(0, nil, nil))}), []string{"null", "int"}))
+	class.AddMethod(ast.NewMethodDefinitionStmt(0, nil, "myFunction", []string{"public"}, []ast.FunctionParameter{}, ast.NewCompoundStmt(0, []ast.IStatement{ast.NewExpressionStmt(0, ast.NewExitIntrinsic(0, nil, nil))}), ast.NewNullableType(ast.NewNamedType("int"))))
 	testStmt(t, `<?php class c { function myFunction(): ?int { exit(); } }`, class)
 
 	// Simple class with method with body
 	class = ast.NewClassDeclarationStmt(0, nil, "c", false, false)
-	class.AddMethod(ast.NewMethodDefinitionStmt(0, nil, "myFunction", []string{"public"}, []ast.FunctionParameter{}, ast.NewCompoundStmt(0, []ast.IStatement{ast.NewExpressionStmt(0, ast.NewExitIntrinsic(0, nil, nil))}), []string{"int", "float"}))
+	class.AddMethod(ast.NewMethodDefinitionStmt(0, nil, "myFunction", []string{"public"}, []ast.FunctionParameter{}, ast.NewCompoundStmt(0, []ast.IStatement{ast.NewExpressionStmt(0, ast.NewExitIntrinsic(0, nil, nil))}), ast.NewUnionType(ast.NewNamedType("int"), ast.NewNamedType("float"))))
 	testStmt(t, `<?php class c { function myFunction(): int|float { exit(); } }`, class)
 
 	// Simple class with property
 	class = ast.NewClassDeclarationStmt(0, nil, "c", false, false)
-	class.AddProperty(ast.NewPropertyDeclarationStmt(0, nil, "$member", "public", false, []string{"mixed"}, nil))
+	class.AddProperty(ast.NewPropertyDeclarationStmt(0, nil, "$member", "public", false, nil, nil))
 	testStmt(t, `<?php class c { public $member; }`, class)
 
 	// Simple class with protected property and initial value
 	class = ast.NewClassDeclarationStmt(0, nil, "c", false, false)
-	class.AddProperty(ast.NewPropertyDeclarationStmt(0, nil, "$member", "protected", false, []string{"mixed"}, ast.NewIntegerLiteralExpr(0, nil, 42)))
+	class.AddProperty(ast.NewPropertyDeclarationStmt(0, nil, "$member", "protected", false, nil, ast.NewIntegerLiteralExpr(0, nil, 42)))
 	testStmt(t, `<?php class c { protected $member = 42; }`, class)
 
 	// Simple class with multiple properties
 	class = ast.NewClassDeclarationStmt(0, nil, "c", false, false)
-	class.AddProperty(ast.NewPropertyDeclarationStmt(0, nil, "$a", "private", false, []string{"mixed"}, nil))
-	class.AddProperty(ast.NewPropertyDeclarationStmt(0, nil, "$b", "protected", false, []string{"mixed"}, nil))
-	class.AddProperty(ast.NewPropertyDeclarationStmt(0, nil, "$c", "public", false, []string{"null", "int"}, ast.NewIntegerLiteralExpr(0, nil, 42)))
+	class.AddProperty(ast.NewPropertyDeclarationStmt(0, nil, "$a", "private", false, nil, nil))
+	class.AddProperty(ast.NewPropertyDeclarationStmt(0, nil, "$b", "protected", false, nil, nil))
+	class.AddProperty(ast.NewPropertyDeclarationStmt(0, nil, "$c", "public", false, ast.NewNullableType(ast.NewNamedType("int")), ast.NewIntegerLiteralExpr(0, nil, 42)))
 	testStmt(t, `<?php class c { private $a; protected $b; public ?int $c = 42; }`, class)
 }
+
+func TestTypeDeclaration(t *testing.T) {
+	intType := ast.NewNamedType("int")
+	body := ast.NewCompoundStmt(0, []ast.IStatement{})
+
+	// Nullable and union types
+	testStmt(t, `<?php function f(?int $a): int|string|null {}`, ast.NewFunctionDefinitionStmt(0, nil, "f",
+		[]ast.FunctionParameter{{Name: "$a", Type: ast.NewNullableType(intType)}}, body,
+		ast.NewUnionType(intType, ast.NewNamedType("string"), ast.NewNamedType("null")),
+	))
+
+	// Class names, intersection and DNF types
+	testStmt(t, `<?php function f(A&B $a, (A&B)|null $b): static {}`, ast.NewFunctionDefinitionStmt(0, nil, "f",
+		[]ast.FunctionParameter{
+			{Name: "$a", Type: ast.NewIntersectionType(ast.NewNamedType("A"), ast.NewNamedType("B"))},
+			{Name: "$b", Type: ast.NewUnionType(ast.NewIntersectionType(ast.NewNamedType("A"), ast.NewNamedType("B")), ast.NewNamedType("null"))},
+		},
+		body, ast.NewNamedType("static"),
+	))
+
+	// Built-in types are case-insensitive
+	testStmt(t, `<?php function f(INT|False $a): Never {}`, ast.NewFunctionDefinitionStmt(0, nil, "f",
+		[]ast.FunctionParameter{{Name: "$a", Type: ast.NewUnionType(intType, ast.NewNamedType("false"))}}, body,
+		ast.NewNamedType("never"),
+	))
+}
+
+func TestAttributes(t *testing.T) {
+	body := ast.NewCompoundStmt(0, []ast.IStatement{})
+
+	// Function with attribute groups, arguments and named arguments
+	function := ast.NewFunctionDefinitionStmt(0, nil, "f",
+		[]ast.FunctionParameter{{Name: "$a", Type: ast.NewNamedType("int"), Attributes: []*ast.Attribute{ast.NewAttribute(nil, "SensitiveParameter", nil)}}},
+		body, nil,
+	)
+	function.Attributes = []*ast.Attribute{
+		ast.NewAttribute(nil, "Route", []*ast.AttributeArgument{
+			ast.NewAttributeArgument("", ast.NewStringLiteralExpr(0, nil, "/home", ast.SingleQuotedString)),
+			ast.NewAttributeArgument("priority", ast.NewIntegerLiteralExpr(0, nil, 1)),
+		}),
+		ast.NewAttribute(nil, "Deprecated", nil),
+		ast.NewAttribute(nil, "Cached", nil),
+	}
+	testStmt(t, `<?php #[Route('/home', priority: 1), Deprecated] #[Cached()] function f(#[SensitiveParameter] int $a) {}`, function)
+
+	// Class and class members
+	class := ast.NewClassDeclarationStmt(0, nil, "C", false, true)
+	class.Attributes = []*ast.Attribute{ast.NewAttribute(nil, "Entity", nil)}
+	constant := ast.NewClassConstDeclarationStmt(0, nil, "A", ast.NewIntegerLiteralExpr(0, nil, 1), "public")
+	constant.Attributes = []*ast.Attribute{ast.NewAttribute(nil, "Constant", nil)}
+	class.AddConst(constant)
+	property := ast.NewPropertyDeclarationStmt(0, nil, "$id", "public", false, ast.NewNamedType("int"), nil)
+	property.Attributes = []*ast.Attribute{ast.NewAttribute(nil, "Column", []*ast.AttributeArgument{
+		ast.NewAttributeArgument("name", ast.NewStringLiteralExpr(0, nil, "id", ast.DoubleQuotedString)),
+	})}
+	class.AddProperty(property)
+	method := ast.NewMethodDefinitionStmt(0, nil, "get", []string{"public"}, []ast.FunctionParameter{}, body, nil)
+	method.Attributes = []*ast.Attribute{ast.NewAttribute(nil, "Get", nil)}
+	class.AddMethod(method)
+	testStmt(t, `<?php #[Entity] final class C {
+		#[Constant] const A = 1;
+		#[Column(name: "id")] public int $id;
+		#[Get] public function get() {}
+	}`, class)
+}
+
+func TestNodeIdsAcrossParsers(t *testing.T) {
+	// Parsed programs are shared between interpreters, so the node ids must be unique across all parsers
+	first, err := NewParser(ini.NewDevIni()).ProduceAST(`<?php function a() {}`, "a.php")
+	if err != nil {
+		t.Fatalf("Unexpected error: \"%s\"", err)
+	}
+	second, err := NewParser(ini.NewDevIni()).ProduceAST(`<?php function a() {}`, "b.php")
+	if err != nil {
+		t.Fatalf("Unexpected error: \"%s\"", err)
+	}
+	if first.GetStatements()[0].GetId() == second.GetStatements()[0].GetId() {
+		t.Errorf("Expected different node ids, got %d", first.GetStatements()[0].GetId())
+	}
+}
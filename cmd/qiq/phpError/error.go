@@ -48,6 +48,7 @@ const (
 	ReturnEvent   string = "return"
 	ContinueEvent string = "continue"
 	BreakEvent    string = "break"
+	NullsafeEvent string = "nullsafe"
 )
 
 type Error interface {
@@ -81,6 +82,8 @@ func (err *PhpError) String() string {
 		return "Fatal error: " + err.message
 	case ParsePhpError:
 		return "Parse error: " + err.message
+	case DeprecatedPhpError:
+		return "Deprecated: " + err.message
 	default:
 		return err.message
 	}
@@ -106,6 +109,10 @@ func NewNotice(format string, a ...any) Error {
 	return &PhpError{errorType: NoticePhpError, message: fmt.Sprintf(format, a...)}
 }
 
+func NewDeprecated(format string, a ...any) Error {
+	return &PhpError{errorType: DeprecatedPhpError, message: fmt.Sprintf(format, a...)}
+}
+
 func NewEvent(event string) Error {
 	return &PhpError{errorType: EventError, message: event}
 }
@@ -0,0 +1,497 @@
+package resolver
+
+import (
+	"QIQ/cmd/qiq/ast"
+	"slices"
+)
+
+// The resolver runs after the parser. It assigns the variables of each function and method body to fixed slots
+// and marks the superglobals, so that the interpreter does not have to look up the variables by name.
+//
+// Variables whose name is only known at runtime (e.g. `$$name`, `extract()`, `compact()`) are looked up by name.
+// The environment maps these names to the slots of the scope, so that both ways access the same variable.
+
+// Superglobals are only stored in the global environment (see registerPredefinedVariables)
+var Superglobals = []string{"$_GET", "$_POST", "$_COOKIE", "$_FILES", "$_ENV", "$_REQUEST", "$_SERVER"}
+
+var _ ast.Visitor = &Resolver{}
+
+type functionScope struct {
+	variables []*ast.SimpleVariableExpression
+	// Variables declared with `global $name;`
+	globals []string
+}
+
+type Resolver struct {
+	// Scope of the resolved function or method body (nil in the global scope)
+	function *functionScope
+}
+
+func Resolve(program *ast.Program) {
+	resolver := &Resolver{}
+	for _, stmt := range program.GetStatements() {
+		resolver.resolve(stmt)
+	}
+}
+
+func (resolver *Resolver) resolve(stmt ast.IStatement) {
+	if stmt == nil {
+		return
+	}
+	stmt.Process(resolver, nil)
+}
+
+func (resolver *Resolver) resolveAll(expressions []ast.IExpression) {
+	for _, expr := range expressions {
+		resolver.resolve(expr)
+	}
+}
+
+// Resolve the body of a function or method and assign its variables to slots. The parameters come first.
+func (resolver *Resolver) resolveFunction(params []ast.FunctionParameter, body *ast.CompoundStatement) *ast.Scope {
+	outerFunction := resolver.function
+	function := &functionScope{}
+	resolver.function = function
+	if body != nil {
+		resolver.resolve(body)
+	}
+	resolver.function = outerFunction
+
+	scope := ast.NewScope()
+	for _, param := range params {
+		scope.Params = append(scope.Params, scope.Add(param.Name))
+	}
+	for _, variable := range function.variables {
+		variableName := variable.VariableName.(*ast.VariableNameExpression).VariableName
+		// Global variables are stored in the global environment
+		if slices.Contains(function.globals, variableName) {
+			continue
+		}
+		variable.Slot = scope.Add(variableName)
+	}
+	return scope
+}
+
+// -------------------------------------- Statements -------------------------------------- MARK: Statements
+
+// ProcessBreakStmt implements Visitor.
+func (resolver *Resolver) ProcessBreakStmt(stmt *ast.BreakStatement, _ any) (any, error) {
+	resolver.resolve(stmt.Expr)
+	return nil, nil
+}
+
+// ProcessClassDeclarationStmt implements Visitor.
+func (resolver *Resolver) ProcessClassDeclarationStmt(stmt *ast.ClassDeclarationStatement, _ any) (any, error) {
+	// Constants and properties are not part of the surrounding function
+	outerFunction := resolver.function
+	resolver.function = nil
+	for _, constant := range stmt.Constants {
+		resolver.resolve(constant.Value)
+	}
+	for _, property := range stmt.Properties {
+		resolver.resolve(property.InitialValue)
+	}
+	resolver.function = outerFunction
+
+	for _, method := range stmt.Methods {
+		method.Scope = resolver.resolveFunction(method.Params, method.Body)
+	}
+	return nil, nil
+}
+
+// ProcessCompoundStmt implements Visitor.
+func (resolver *Resolver) ProcessCompoundStmt(stmt *ast.CompoundStatement, _ any) (any, error) {
+	for _, statement := range stmt.Statements {
+		resolver.resolve(statement)
+	}
+	return nil, nil
+}
+
+// ProcessConstDeclarationStmt implements Visitor.
+func (resolver *Resolver) ProcessConstDeclarationStmt(stmt *ast.ConstDeclarationStatement, _ any) (any, error) {
+	resolver.resolve(stmt.Value)
+	return nil, nil
+}
+
+// ProcessContinueStmt implements Visitor.
+func (resolver *Resolver) ProcessContinueStmt(stmt *ast.ContinueStatement, _ any) (any, error) {
+	resolver.resolve(stmt.Expr)
+	return nil, nil
+}
+
+// ProcessDeclareStmt implements Visitor.
+func (resolver *Resolver) ProcessDeclareStmt(stmt *ast.DeclareStatement, _ any) (any, error) {
+	return nil, nil
+}
+
+// ProcessDoStmt implements Visitor.
+func (resolver *Resolver) ProcessDoStmt(stmt *ast.DoStatement, _ any) (any, error) {
+	resolver.resolve(stmt.Block)
+	resolver.resolve(stmt.Condition)
+	return nil, nil
+}
+
+// ProcessEchoStmt implements Visitor.
+func (resolver *Resolver) ProcessEchoStmt(stmt *ast.EchoStatement, _ any) (any, error) {
+	resolver.resolveAll(stmt.Expressions)
+	return nil, nil
+}
+
+// ProcessExpressionStmt implements Visitor.
+func (resolver *Resolver) ProcessExpressionStmt(stmt *ast.ExpressionStatement, _ any) (any, error) {
+	resolver.resolve(stmt.Expr)
+	return nil, nil
+}
+
+// ProcessForStmt implements Visitor.
+func (resolver *Resolver) ProcessForStmt(stmt *ast.ForStatement, _ any) (any, error) {
+	for _, statements := range []*ast.CompoundStatement{stmt.Initializer, stmt.Control, stmt.EndOfLoop} {
+		if statements != nil {
+			resolver.resolve(statements)
+		}
+	}
+	resolver.resolve(stmt.Block)
+	return nil, nil
+}
+
+// ProcessForeachStmt implements Visitor.
+func (resolver *Resolver) ProcessForeachStmt(stmt *ast.ForeachStatement, _ any) (any, error) {
+	resolver.resolve(stmt.Collection)
+	resolver.resolve(stmt.Key)
+	resolver.resolve(stmt.Value)
+	resolver.resolve(stmt.Block)
+	return nil, nil
+}
+
+// ProcessFunctionDefinitionStmt implements Visitor.
+func (resolver *Resolver) ProcessFunctionDefinitionStmt(stmt *ast.FunctionDefinitionStatement, _ any) (any, error) {
+	stmt.Scope = resolver.resolveFunction(stmt.Params, stmt.Body)
+	return nil, nil
+}
+
+// ProcessGlobalDeclarationStmt implements Visitor.
+func (resolver *Resolver) ProcessGlobalDeclarationStmt(stmt *ast.GlobalDeclarationStatement, _ any) (any, error) {
+	for _, variable := range stmt.Variables {
+		variableExpr, ok := variable.(*ast.SimpleVariableExpression)
+		if !ok {
+			resolver.resolve(variable)
+			continue
+		}
+		variableName, ok := variableExpr.VariableName.(*ast.VariableNameExpression)
+		if !ok {
+			resolver.resolve(variableExpr.VariableName)
+			continue
+		}
+		if resolver.function != nil {
+			resolver.function.globals = append(resolver.function.globals, variableName.VariableName)
+		}
+	}
+	return nil, nil
+}
+
+// ProcessIfStmt implements Visitor.
+func (resolver *Resolver) ProcessIfStmt(stmt *ast.IfStatement, _ any) (any, error) {
+	resolver.resolve(stmt.Condition)
+	resolver.resolve(stmt.IfBlock)
+	for _, elseIf := range stmt.ElseIf {
+		resolver.resolve(elseIf)
+	}
+	resolver.resolve(stmt.ElseBlock)
+	return nil, nil
+}
+
+// ProcessReturnStmt implements Visitor.
+func (resolver *Resolver) ProcessReturnStmt(stmt *ast.ReturnStatement, _ any) (any, error) {
+	resolver.resolve(stmt.Expr)
+	return nil, nil
+}
+
+// ProcessStmt implements Visitor.
+func (resolver *Resolver) ProcessStmt(stmt *ast.Statement, _ any) (any, error) {
+	return nil, nil
+}
+
+// ProcessThrowStmt implements Visitor.
+func (resolver *Resolver) ProcessThrowStmt(stmt *ast.ThrowStatement, _ any) (any, error) {
+	resolver.resolve(stmt.Expr)
+	return nil, nil
+}
+
+// ProcessWhileStmt implements Visitor.
+func (resolver *Resolver) ProcessWhileStmt(stmt *ast.WhileStatement, _ any) (any, error) {
+	resolver.resolve(stmt.Condition)
+	resolver.resolve(stmt.Block)
+	return nil, nil
+}
+
+// -------------------------------------- Expressions -------------------------------------- MARK: Expressions
+
+// ProcessArrayLiteralExpr implements Visitor.
+func (resolver *Resolver) ProcessArrayLiteralExpr(stmt *ast.ArrayLiteralExpression, _ any) (any, error) {
+	for _, key := range stmt.Keys {
+		resolver.resolve(key)
+		resolver.resolve(stmt.Elements[key])
+	}
+	return nil, nil
+}
+
+// ProcessArrayNextKeyExpr implements Visitor.
+func (resolver *Resolver) ProcessArrayNextKeyExpr(stmt *ast.ArrayNextKeyExpression, _ any) (any, error) {
+	return nil, nil
+}
+
+// ProcessBinaryOpExpr implements Visitor.
+func (resolver *Resolver) ProcessBinaryOpExpr(stmt *ast.BinaryOpExpression, _ any) (any, error) {
+	resolver.resolve(stmt.Lhs)
+	resolver.resolve(stmt.Rhs)
+	return nil, nil
+}
+
+// ProcessCastExpr implements Visitor.
+func (resolver *Resolver) ProcessCastExpr(stmt *ast.CastExpression, _ any) (any, error) {
+	resolver.resolve(stmt.Expr)
+	return nil, nil
+}
+
+// ProcessClassConstantAccessExpr implements Visitor.
+func (resolver *Resolver) ProcessClassConstantAccessExpr(stmt *ast.ClassConstantAccessExpression, _ any) (any, error) {
+	resolver.resolve(stmt.Scope)
+	return nil, nil
+}
+
+// ProcessCloneExpr implements Visitor.
+func (resolver *Resolver) ProcessCloneExpr(stmt *ast.CloneExpression, _ any) (any, error) {
+	resolver.resolve(stmt.Expr)
+	return nil, nil
+}
+
+// ProcessCoalesceExpr implements Visitor.
+func (resolver *Resolver) ProcessCoalesceExpr(stmt *ast.CoalesceExpression, _ any) (any, error) {
+	resolver.resolve(stmt.Cond)
+	resolver.resolve(stmt.ElseExpr)
+	return nil, nil
+}
+
+// ProcessCompoundAssignmentExpr implements Visitor.
+func (resolver *Resolver) ProcessCompoundAssignmentExpr(stmt *ast.CompoundAssignmentExpression, _ any) (any, error) {
+	resolver.resolve(stmt.Variable)
+	resolver.resolve(stmt.Value)
+	return nil, nil
+}
+
+// ProcessConditionalExpr implements Visitor.
+func (resolver *Resolver) ProcessConditionalExpr(stmt *ast.ConditionalExpression, _ any) (any, error) {
+	resolver.resolve(stmt.Cond)
+	resolver.resolve(stmt.IfExpr)
+	resolver.resolve(stmt.ElseExpr)
+	return nil, nil
+}
+
+// ProcessConstantAccessExpr implements Visitor.
+func (resolver *Resolver) ProcessConstantAccessExpr(stmt *ast.ConstantAccessExpression, _ any) (any, error) {
+	return nil, nil
+}
+
+// ProcessEmptyIntrinsicExpr implements Visitor.
+func (resolver *Resolver) ProcessEmptyIntrinsicExpr(stmt *ast.EmptyIntrinsicExpression, _ any) (any, error) {
+	return resolver.ProcessFunctionCallExpr(stmt.FunctionCallExpression, nil)
+}
+
+// ProcessEqualityExpr implements Visitor.
+func (resolver *Resolver) ProcessEqualityExpr(stmt *ast.EqualityExpression, _ any) (any, error) {
+	return resolver.ProcessBinaryOpExpr(stmt.BinaryOpExpression, nil)
+}
+
+// ProcessErrorControlExpr implements Visitor.
+func (resolver *Resolver) ProcessErrorControlExpr(stmt *ast.ErrorControlExpression, _ any) (any, error) {
+	resolver.resolve(stmt.Expr)
+	return nil, nil
+}
+
+// ProcessEvalIntrinsicExpr implements Visitor.
+func (resolver *Resolver) ProcessEvalIntrinsicExpr(stmt *ast.EvalIntrinsicExpression, _ any) (any, error) {
+	return resolver.ProcessFunctionCallExpr(stmt.FunctionCallExpression, nil)
+}
+
+// ProcessExitIntrinsicExpr implements Visitor.
+func (resolver *Resolver) ProcessExitIntrinsicExpr(stmt *ast.ExitIntrinsicExpression, _ any) (any, error) {
+	return resolver.ProcessFunctionCallExpr(stmt.FunctionCallExpression, nil)
+}
+
+// ProcessExpr implements Visitor.
+func (resolver *Resolver) ProcessExpr(stmt *ast.Expression, _ any) (any, error) {
+	return nil, nil
+}
+
+// ProcessFloatingLiteralExpr implements Visitor.
+func (resolver *Resolver) ProcessFloatingLiteralExpr(stmt *ast.FloatingLiteralExpression, _ any) (any, error) {
+	return nil, nil
+}
+
+// ProcessFunctionCallExpr implements Visitor.
+func (resolver *Resolver) ProcessFunctionCallExpr(stmt *ast.FunctionCallExpression, _ any) (any, error) {
+	resolver.resolve(stmt.FunctionName)
+	resolver.resolveAll(stmt.Arguments)
+	return nil, nil
+}
+
+// ProcessIncludeExpr implements Visitor.
+func (resolver *Resolver) ProcessIncludeExpr(stmt *ast.IncludeExpression, _ any) (any, error) {
+	resolver.resolve(stmt.Expr)
+	return nil, nil
+}
+
+// ProcessIncludeOnceExpr implements Visitor.
+func (resolver *Resolver) ProcessIncludeOnceExpr(stmt *ast.IncludeOnceExpression, _ any) (any, error) {
+	return resolver.ProcessIncludeExpr(stmt.IncludeExpression, nil)
+}
+
+// ProcessIntegerLiteralExpr implements Visitor.
+func (resolver *Resolver) ProcessIntegerLiteralExpr(stmt *ast.IntegerLiteralExpression, _ any) (any, error) {
+	return nil, nil
+}
+
+// ProcessIssetIntrinsicExpr implements Visitor.
+func (resolver *Resolver) ProcessIssetIntrinsicExpr(stmt *ast.IssetIntrinsicExpression, _ any) (any, error) {
+	return resolver.ProcessFunctionCallExpr(stmt.FunctionCallExpression, nil)
+}
+
+// ProcessLogicalExpr implements Visitor.
+func (resolver *Resolver) ProcessLogicalExpr(stmt *ast.LogicalExpression, _ any) (any, error) {
+	return resolver.ProcessBinaryOpExpr(stmt.BinaryOpExpression, nil)
+}
+
+// ProcessLogicalNotExpr implements Visitor.
+func (resolver *Resolver) ProcessLogicalNotExpr(stmt *ast.LogicalNotExpression, _ any) (any, error) {
+	return resolver.ProcessUnaryExpr(stmt.UnaryOpExpression, nil)
+}
+
+// ProcessMemberAccessExpr implements Visitor.
+func (resolver *Resolver) ProcessMemberAccessExpr(stmt *ast.MemberAccessExpression, _ any) (any, error) {
+	resolver.resolve(stmt.Object)
+	resolver.resolve(stmt.Member)
+	return nil, nil
+}
+
+// ProcessMemberCallExpr implements Visitor.
+func (resolver *Resolver) ProcessMemberCallExpr(stmt *ast.MemberCallExpression, _ any) (any, error) {
+	resolver.resolve(stmt.Object)
+	resolver.resolve(stmt.Member)
+	resolver.resolveAll(stmt.Arguments)
+	return nil, nil
+}
+
+// ProcessObjectCreationExpr implements Visitor.
+func (resolver *Resolver) ProcessObjectCreationExpr(stmt *ast.ObjectCreationExpression, _ any) (any, error) {
+	resolver.resolveAll(stmt.Args)
+	return nil, nil
+}
+
+// ProcessParenthesizedExpr implements Visitor.
+func (resolver *Resolver) ProcessParenthesizedExpr(stmt *ast.ParenthesizedExpression, _ any) (any, error) {
+	resolver.resolve(stmt.Expr)
+	return nil, nil
+}
+
+// ProcessPostfixIncExpr implements Visitor.
+func (resolver *Resolver) ProcessPostfixIncExpr(stmt *ast.PostfixIncExpression, _ any) (any, error) {
+	return resolver.ProcessUnaryExpr(stmt.UnaryOpExpression, nil)
+}
+
+// ProcessPrefixIncExpr implements Visitor.
+func (resolver *Resolver) ProcessPrefixIncExpr(stmt *ast.PrefixIncExpression, _ any) (any, error) {
+	return resolver.ProcessUnaryExpr(stmt.UnaryOpExpression, nil)
+}
+
+// ProcessPrintExpr implements Visitor.
+func (resolver *Resolver) ProcessPrintExpr(stmt *ast.PrintExpression, _ any) (any, error) {
+	return resolver.ProcessParenthesizedExpr(stmt.ParenthesizedExpression, nil)
+}
+
+// ProcessRelationalExpr implements Visitor.
+func (resolver *Resolver) ProcessRelationalExpr(stmt *ast.RelationalExpression, _ any) (any, error) {
+	return resolver.ProcessBinaryOpExpr(stmt.BinaryOpExpression, nil)
+}
+
+// ProcessRequireExpr implements Visitor.
+func (resolver *Resolver) ProcessRequireExpr(stmt *ast.RequireExpression, _ any) (any, error) {
+	return resolver.ProcessIncludeExpr(stmt.IncludeExpression, nil)
+}
+
+// ProcessRequireOnceExpr implements Visitor.
+func (resolver *Resolver) ProcessRequireOnceExpr(stmt *ast.RequireOnceExpression, _ any) (any, error) {
+	return resolver.ProcessIncludeExpr(stmt.IncludeExpression, nil)
+}
+
+// ProcessScopedCallExpr implements Visitor.
+func (resolver *Resolver) ProcessScopedCallExpr(stmt *ast.ScopedCallExpression, _ any) (any, error) {
+	resolver.resolve(stmt.Scope)
+	resolver.resolve(stmt.Member)
+	resolver.resolveAll(stmt.Arguments)
+	return nil, nil
+}
+
+// ProcessShellCommandExpr implements Visitor.
+func (resolver *Resolver) ProcessShellCommandExpr(stmt *ast.ShellCommandExpression, _ any) (any, error) {
+	resolver.resolve(stmt.Command)
+	return nil, nil
+}
+
+// ProcessSimpleAssignmentExpr implements Visitor.
+func (resolver *Resolver) ProcessSimpleAssignmentExpr(stmt *ast.SimpleAssignmentExpression, _ any) (any, error) {
+	resolver.resolve(stmt.Variable)
+	resolver.resolve(stmt.Value)
+	return nil, nil
+}
+
+// ProcessSimpleVariableExpr implements Visitor.
+func (resolver *Resolver) ProcessSimpleVariableExpr(stmt *ast.SimpleVariableExpression, _ any) (any, error) {
+	variableName, ok := stmt.VariableName.(*ast.VariableNameExpression)
+	if !ok {
+		// Variable variables (e.g. `$$name`) are accessed by name
+		resolver.resolve(stmt.VariableName)
+		return nil, nil
+	}
+
+	if slices.Contains(Superglobals, variableName.VariableName) {
+		stmt.IsSuperglobal = true
+		return nil, nil
+	}
+	if resolver.function != nil {
+		resolver.function.variables = append(resolver.function.variables, stmt)
+	}
+	return nil, nil
+}
+
+// ProcessStringLiteralExpr implements Visitor.
+func (resolver *Resolver) ProcessStringLiteralExpr(stmt *ast.StringLiteralExpression, _ any) (any, error) {
+	return nil, nil
+}
+
+// ProcessSubscriptExpr implements Visitor.
+func (resolver *Resolver) ProcessSubscriptExpr(stmt *ast.SubscriptExpression, _ any) (any, error) {
+	resolver.resolve(stmt.Variable)
+	resolver.resolve(stmt.Index)
+	return nil, nil
+}
+
+// ProcessTextExpr implements Visitor.
+func (resolver *Resolver) ProcessTextExpr(stmt *ast.TextExpression, _ any) (any, error) {
+	return nil, nil
+}
+
+// ProcessUnaryExpr implements Visitor.
+func (resolver *Resolver) ProcessUnaryExpr(stmt *ast.UnaryOpExpression, _ any) (any, error) {
+	resolver.resolve(stmt.Expr)
+	return nil, nil
+}
+
+// ProcessUnsetIntrinsicExpr implements Visitor.
+func (resolver *Resolver) ProcessUnsetIntrinsicExpr(stmt *ast.UnsetIntrinsicExpression, _ any) (any, error) {
+	return resolver.ProcessFunctionCallExpr(stmt.FunctionCallExpression, nil)
+}
+
+// ProcessVariableNameExpr implements Visitor.
+func (resolver *Resolver) ProcessVariableNameExpr(stmt *ast.VariableNameExpression, _ any) (any, error) {
+	return nil, nil
+}
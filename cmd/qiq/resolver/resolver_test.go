@@ -0,0 +1,35 @@
+package resolver_test
+
+import (
+	"QIQ/cmd/qiq/ast"
+	"QIQ/cmd/qiq/ini"
+	"QIQ/cmd/qiq/parser"
+	"slices"
+	"testing"
+)
+
+func TestResolver(t *testing.T) {
+	program, err := parser.NewParser(ini.NewDevIni()).ProduceAST(
+		`<?php $g = 1; function f($a, $b) { global $g; $c = $a + $b + $g; $$c = $_GET; return $c; }`, "test.php",
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: \"%s\"", err)
+	}
+
+	function := program.GetStatements()[1].(*ast.FunctionDefinitionStatement)
+	if function.Scope == nil {
+		t.Fatalf("Expected scope for function")
+	}
+	if !slices.Equal(function.Scope.Variables, []string{"$a", "$b", "$c"}) {
+		t.Errorf("Unexpected variables: %v", function.Scope.Variables)
+	}
+	if !slices.Equal(function.Scope.Params, []int{0, 1}) {
+		t.Errorf("Unexpected params: %v", function.Scope.Params)
+	}
+
+	// Variables of the global scope are accessed by name
+	global := program.GetStatements()[0].(*ast.ExpressionStatement).Expr.(*ast.SimpleAssignmentExpression)
+	if slot := global.Variable.(*ast.SimpleVariableExpression).Slot; slot != -1 {
+		t.Errorf("Expected global variable without slot, got %d", slot)
+	}
+}
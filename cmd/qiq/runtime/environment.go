@@ -8,8 +8,12 @@ import (
 type Environment interface {
 	// Variables
 	LookupVariable(variableName string) (values.RuntimeValue, phpError.Error)
+	AddVariable(variableName string, value values.RuntimeValue)
+	// Get the names of the variables that are defined in the current scope
+	GetVariableNames() []string
 	// Functions
-	AddNativeFunction(functionName string, function NativeFunction)
+	// Add a native function. byRefParams are the indexes of parameters that are passed by reference.
+	AddNativeFunction(functionName string, function NativeFunction, byRefParams ...int)
 	FunctionExists(functionName string) bool
 	// Constants
 	LookupConstant(constantName string) (values.RuntimeValue, phpError.Error)
@@ -0,0 +1,449 @@
+package format
+
+import (
+	"QIQ/cmd/qiq/common"
+	"QIQ/cmd/qiq/phpError"
+	"QIQ/cmd/qiq/runtime"
+	"QIQ/cmd/qiq/runtime/stdlib/variableHandling"
+	"QIQ/cmd/qiq/runtime/values"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Spec: https://www.php.net/manual/en/function.sprintf.php
+
+const maxInt32 = math.MaxInt32
+
+// Default precision of the float specifiers
+const floatPrecision = 6
+
+// Maximum precision of the float specifiers
+const maxFloatPrecision = 53
+
+// The argument is not given as position but is the next one
+const argNumNext = -1
+
+// ArrayArguments is passed as argumentOffset to Sprintf if the arguments were given as array (e.g. vsprintf)
+const ArrayArguments = -1
+
+type alignment int
+
+const (
+	alignLeft alignment = iota
+	alignRight
+)
+
+type formatSpec struct {
+	padding      byte
+	alignment    alignment
+	alwaysSign   bool
+	width        int
+	precision    int
+	hasPrecision bool
+	// The precision was given explicitly (e.g. "%.2s" but not "%.s")
+	explicitPrecision bool
+}
+
+// Sprintf produces a string according to the format string like PHP's sprintf.
+// argumentOffset is the number of parameters of the calling function before the format arguments
+// (e.g. 1 for sprintf and 2 for fprintf). It is used for the error message if arguments are missing.
+func Sprintf(context runtime.Context, format string, args []values.RuntimeValue, argumentOffset int) (string, phpError.Error) {
+	var result strings.Builder
+	currentArg := 0
+	maxMissingArg := -1
+
+	pos := 0
+	// The character at the given position or '\0' at the end of the format string
+	charAt := func(pos int) byte {
+		if pos < len(format) {
+			return format[pos]
+		}
+		return 0
+	}
+
+	// Get the argument index given as position (e.g. "2$") or argNumNext
+	getArgNum := func() (int, phpError.Error) {
+		end := pos
+		for isDigit(charAt(end)) {
+			end++
+		}
+		if charAt(end) != '$' {
+			return argNumNext, nil
+		}
+		argNum, ok := getNumber(format, &pos)
+		if !ok || argNum <= 0 {
+			return 0, phpError.NewError("Uncaught ValueError: Argument number specifier must be greater than zero and less than %d", maxInt32)
+		}
+		// Skip the "$"
+		pos++
+		return argNum - 1, nil
+	}
+
+	for pos < len(format) {
+		percentPos := strings.IndexByte(format[pos:], '%')
+		if percentPos == -1 {
+			result.WriteString(format[pos:])
+			break
+		}
+		result.WriteString(format[pos : pos+percentPos])
+		pos += percentPos + 1
+
+		if charAt(pos) == '%' {
+			result.WriteByte('%')
+			pos++
+			continue
+		}
+
+		// Start of a new format specifier
+		spec := formatSpec{padding: ' ', alignment: alignRight}
+		var argNum int
+		if char := charAt(pos); char < 128 && !isAlpha(char) {
+			var err phpError.Error
+			if argNum, err = getArgNum(); err != nil {
+				return "", err
+			}
+
+			// Flags
+		flags:
+			for ; ; pos++ {
+				switch charAt(pos) {
+				case ' ', '0':
+					spec.padding = charAt(pos)
+				case '-':
+					spec.alignment = alignLeft
+				case '+':
+					spec.alwaysSign = true
+				case '\'':
+					if pos+1 >= len(format) {
+						return "", phpError.NewError("Uncaught ValueError: Missing padding character")
+					}
+					pos++
+					spec.padding = charAt(pos)
+				default:
+					break flags
+				}
+			}
+
+			// Width
+			if charAt(pos) == '*' {
+				pos++
+				widthArgNum, err := getArgNum()
+				if err != nil {
+					return "", err
+				}
+				if widthArgNum == argNumNext {
+					widthArgNum = currentArg
+					currentArg++
+				}
+				if widthArgNum >= len(args) {
+					maxMissingArg = max(maxMissingArg, widthArgNum)
+					continue
+				}
+				width, ok := args[widthArgNum].(*values.Int)
+				if !ok {
+					return "", phpError.NewError("Uncaught ValueError: Width must be an integer")
+				}
+				if width.Value < 0 || width.Value > maxInt32 {
+					return "", phpError.NewError("Uncaught ValueError: Width must be greater than or equal to zero and less than %d", maxInt32)
+				}
+				spec.width = int(width.Value)
+			} else if isDigit(charAt(pos)) {
+				width, ok := getNumber(format, &pos)
+				if !ok {
+					return "", phpError.NewError("Uncaught ValueError: Width must be greater than zero and less than %d", maxInt32)
+				}
+				spec.width = width
+			}
+
+			// Precision
+			if charAt(pos) == '.' {
+				pos++
+				spec.hasPrecision = true
+				if charAt(pos) == '*' {
+					pos++
+					precisionArgNum, err := getArgNum()
+					if err != nil {
+						return "", err
+					}
+					if precisionArgNum == argNumNext {
+						precisionArgNum = currentArg
+						currentArg++
+					}
+					if precisionArgNum >= len(args) {
+						maxMissingArg = max(maxMissingArg, precisionArgNum)
+						continue
+					}
+					precision, ok := args[precisionArgNum].(*values.Int)
+					if !ok {
+						return "", phpError.NewError("Uncaught ValueError: Precision must be an integer")
+					}
+					if precision.Value < -1 || precision.Value > maxInt32 {
+						return "", phpError.NewError("Uncaught ValueError: Precision must be between -1 and %d", maxInt32)
+					}
+					spec.precision = int(precision.Value)
+					spec.explicitPrecision = true
+				} else if isDigit(charAt(pos)) {
+					precision, ok := getNumber(format, &pos)
+					if !ok {
+						return "", phpError.NewError("Uncaught ValueError: Precision must be greater than zero and less than %d", maxInt32)
+					}
+					spec.precision = precision
+					spec.explicitPrecision = true
+				}
+			}
+		} else {
+			argNum = argNumNext
+		}
+
+		// The argument of the value follows the arguments of width and precision
+		if argNum == argNumNext {
+			argNum = currentArg
+			currentArg++
+		}
+
+		// The length modifier "l" is ignored
+		if charAt(pos) == 'l' {
+			pos++
+		}
+
+		if argNum >= len(args) {
+			maxMissingArg = max(maxMissingArg, argNum)
+			continue
+		}
+
+		specifier := charAt(pos)
+		if spec.explicitPrecision && spec.precision == -1 && !strings.ContainsRune("gGhH", rune(specifier)) {
+			return "", phpError.NewError("Uncaught ValueError: Precision -1 is only supported for %%g, %%G, %%h and %%H")
+		}
+
+		arg := args[argNum]
+		switch specifier {
+		case 's':
+			str, err := variableHandling.StrVal(arg, context.Interpreter.GetIni().GetInt("precision"))
+			if err != nil {
+				return "", err
+			}
+			spec.alwaysSign = false
+			appendString(&result, str, spec, false)
+		case 'd':
+			number, err := variableHandling.IntVal(arg, true)
+			if err != nil {
+				return "", err
+			}
+			appendInt(&result, number, spec)
+		case 'u':
+			number, err := variableHandling.IntVal(arg, true)
+			if err != nil {
+				return "", err
+			}
+			appendUint(&result, uint64(number), spec)
+		case 'e', 'E', 'f', 'F', 'g', 'G', 'h', 'H':
+			number, err := variableHandling.FloatVal(arg, true)
+			if err != nil {
+				return "", err
+			}
+			appendFloat(context, &result, number, spec, specifier)
+		case 'c':
+			number, err := variableHandling.IntVal(arg, true)
+			if err != nil {
+				return "", err
+			}
+			result.WriteByte(byte(number))
+		case 'o', 'x', 'X', 'b':
+			number, err := variableHandling.IntVal(arg, true)
+			if err != nil {
+				return "", err
+			}
+			appendBase2N(&result, uint64(number), spec, specifier)
+		case '%':
+			result.WriteByte('%')
+		case 0:
+			if pos >= len(format) {
+				return "", phpError.NewError("Uncaught ValueError: Missing format specifier at end of string")
+			}
+			fallthrough
+		default:
+			return "", phpError.NewError("Uncaught ValueError: Unknown format specifier \"%c\"", specifier)
+		}
+		pos++
+	}
+
+	if maxMissingArg >= 0 {
+		if argumentOffset == ArrayArguments {
+			return "", phpError.NewError("Uncaught ValueError: The arguments array must contain %d items, %d given", maxMissingArg+1, len(args))
+		}
+		return "", phpError.NewError(
+			"Uncaught ArgumentCountError: %d arguments are required, %d given", maxMissingArg+argumentOffset+1, len(args)+argumentOffset,
+		)
+	}
+
+	return result.String(), nil
+}
+
+func isDigit(char byte) bool {
+	return char >= '0' && char <= '9'
+}
+
+func isAlpha(char byte) bool {
+	return (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z')
+}
+
+// Read the number at the given position. It returns false if the number is not less than the maximum int32.
+func getNumber(format string, pos *int) (int, bool) {
+	start := *pos
+	for *pos < len(format) && isDigit(format[*pos]) {
+		*pos++
+	}
+	number, err := strconv.ParseInt(format[start:*pos], 10, 64)
+	if err != nil || number >= maxInt32 {
+		return 0, false
+	}
+	return int(number), true
+}
+
+// Append the string with the padding of the format specifier.
+// The string is truncated to the precision if the precision was given explicitly.
+// A sign at the beginning of the string is placed before the padding if the string is padded with zeros.
+func appendString(result *strings.Builder, str string, spec formatSpec, isNegative bool) {
+	copyLen := len(str)
+	if spec.explicitPrecision {
+		copyLen = min(spec.precision, copyLen)
+	}
+	padLen := max(spec.width-copyLen, 0)
+
+	if spec.alignment == alignRight {
+		if (isNegative || spec.alwaysSign) && spec.padding == '0' {
+			if isNegative {
+				result.WriteByte('-')
+			} else {
+				result.WriteByte('+')
+			}
+			str = str[1:]
+			copyLen--
+		}
+		result.WriteString(strings.Repeat(string(spec.padding), padLen))
+	}
+	result.WriteString(str[:copyLen])
+	if spec.alignment == alignLeft {
+		result.WriteString(strings.Repeat(string(spec.padding), padLen))
+	}
+}
+
+func appendInt(result *strings.Builder, number int64, spec formatSpec) {
+	// Integers cannot be right-padded with zeros
+	if spec.alignment == alignLeft && spec.padding == '0' {
+		spec.padding = ' '
+	}
+	str := strconv.FormatInt(number, 10)
+	if number >= 0 && spec.alwaysSign {
+		str = "+" + str
+	}
+	spec.explicitPrecision = false
+	appendString(result, str, spec, number < 0)
+}
+
+func appendUint(result *strings.Builder, number uint64, spec formatSpec) {
+	// Integers cannot be right-padded with zeros
+	if spec.alignment == alignLeft && spec.padding == '0' {
+		spec.padding = ' '
+	}
+	spec.alwaysSign = false
+	spec.explicitPrecision = false
+	appendString(result, strconv.FormatUint(number, 10), spec, false)
+}
+
+func appendBase2N(result *strings.Builder, number uint64, spec formatSpec, specifier byte) {
+	var str string
+	switch specifier {
+	case 'o':
+		str = strconv.FormatUint(number, 8)
+	case 'x':
+		str = strconv.FormatUint(number, 16)
+	case 'X':
+		str = strings.ToUpper(strconv.FormatUint(number, 16))
+	case 'b':
+		str = strconv.FormatUint(number, 2)
+	}
+	// Like in PHP, an explicit precision truncates the number completely
+	spec.precision = 0
+	spec.alwaysSign = false
+	appendString(result, str, spec, false)
+}
+
+func appendFloat(context runtime.Context, result *strings.Builder, number float64, spec formatSpec, specifier byte) {
+	precision := spec.precision
+	if !spec.hasPrecision {
+		precision = floatPrecision
+	} else if precision > maxFloatPrecision {
+		context.Interpreter.PrintError(phpError.NewNotice(
+			"Requested precision of %d digits was truncated to PHP maximum of %d digits in %s",
+			precision, maxFloatPrecision, context.Stmt.GetPosString(),
+		))
+		precision = maxFloatPrecision
+	}
+
+	// NaN and infinity ignore the width
+	if math.IsNaN(number) {
+		spec.width = 3
+		spec.explicitPrecision = false
+		appendString(result, "NaN", spec, false)
+		return
+	}
+	if math.IsInf(number, 0) {
+		str := "Inf"
+		if number < 0 {
+			str = "-Inf"
+		} else if spec.alwaysSign {
+			str = "+Inf"
+		}
+		spec.width = len(str)
+		spec.explicitPrecision = false
+		appendString(result, str, spec, number < 0)
+		return
+	}
+
+	var str string
+	isNegative := false
+	switch specifier {
+	case 'f', 'F':
+		isNegative = number < 0
+		str = strconv.FormatFloat(common.RoundHalfUp(math.Abs(number), precision), 'f', precision, 64)
+	case 'e', 'E':
+		isNegative = number < 0
+		// Round half up to the number of significant digits
+		_, exponentStr, _ := strings.Cut(strconv.FormatFloat(math.Abs(number), 'e', -1, 64), "e")
+		exponent, _ := strconv.Atoi(exponentStr)
+		rounded := common.RoundHalfUp(math.Abs(number), precision-exponent)
+		mantissa, exponentStr, _ := strings.Cut(strconv.FormatFloat(rounded, 'e', precision, 64), "e")
+		// The exponent has no leading zeros (e.g. "1.5e+3")
+		exponent, _ = strconv.Atoi(exponentStr)
+		str = mantissa + string(specifier)
+		if exponent < 0 {
+			str += "-" + strconv.Itoa(-exponent)
+		} else {
+			str += "+" + strconv.Itoa(exponent)
+		}
+	case 'g', 'G', 'h', 'H':
+		if precision == 0 {
+			precision = 1
+		}
+		exponentChar := byte('e')
+		if specifier == 'G' || specifier == 'H' {
+			exponentChar = 'E'
+		}
+		str = common.FormatFloatWithExponentChar(number, int64(precision), exponentChar)
+		if strings.HasPrefix(str, "-") {
+			isNegative = true
+			str = str[1:]
+		}
+	}
+
+	if isNegative {
+		str = "-" + str
+	} else if spec.alwaysSign {
+		str = "+" + str
+	}
+	spec.explicitPrecision = false
+	appendString(result, str, spec, isNegative)
+}
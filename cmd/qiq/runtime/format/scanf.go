@@ -0,0 +1,417 @@
+package format
+
+import (
+	"QIQ/cmd/qiq/phpError"
+	"QIQ/cmd/qiq/runtime/values"
+	"strconv"
+	"strings"
+)
+
+// Spec: https://www.php.net/manual/en/function.sscanf.php
+
+type scanConversion struct {
+	suppress bool
+	// Index of the variable the value is assigned to (-1 if not given as "%n$")
+	position  int
+	width     int
+	specifier byte
+	// Characters of a "[...]" conversion
+	charSet string
+	negated bool
+}
+
+// Sscanf parses the string according to the format string like PHP's sscanf.
+// numVars is the number of variables passed to sscanf.
+// It returns the parsed values and the number of performed conversions.
+// The returned count is -1 if the end of the string was reached before the first conversion.
+func Sscanf(str string, format string, numVars int) ([]values.RuntimeValue, int, phpError.Error) {
+	totalVars, err := validateScanFormat(format, numVars)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	result := make([]values.RuntimeValue, totalVars)
+	for i := range result {
+		result[i] = values.NewNull()
+	}
+
+	conversions := 0
+	underflow := false
+	varIndex := 0
+	strPos := 0
+	formatPos := 0
+
+	assign := func(conversion scanConversion, value values.RuntimeValue) {
+		if conversion.suppress {
+			return
+		}
+		if conversion.position >= 0 {
+			result[conversion.position] = value
+			return
+		}
+		result[varIndex] = value
+		varIndex++
+	}
+
+scan:
+	for formatPos < len(format) {
+		char := format[formatPos]
+		formatPos++
+
+		// Whitespace in the format skips whitespace in the string
+		if isSpace(char) {
+			for strPos < len(str) && isSpace(str[strPos]) {
+				strPos++
+			}
+			continue
+		}
+
+		isLiteral := char != '%'
+		if !isLiteral && formatPos < len(format) && format[formatPos] == '%' {
+			formatPos++
+			isLiteral = true
+		}
+		if isLiteral {
+			if strPos >= len(str) {
+				underflow = true
+				break
+			}
+			if str[strPos] != char {
+				break
+			}
+			strPos++
+			continue
+		}
+
+		conversion := parseScanConversion(format, &formatPos)
+
+		if conversion.specifier == 'n' {
+			assign(conversion, values.NewInt(int64(strPos)))
+			conversions++
+			continue
+		}
+
+		if strPos >= len(str) {
+			underflow = true
+			break
+		}
+
+		// Skip leading whitespace
+		if conversion.specifier != 'c' && conversion.specifier != '[' {
+			for strPos < len(str) && isSpace(str[strPos]) {
+				strPos++
+			}
+			if strPos >= len(str) {
+				underflow = true
+				break
+			}
+		}
+
+		width := conversion.width
+		if width == 0 {
+			width = len(str)
+		}
+		end := min(strPos+width, len(str))
+
+		switch conversion.specifier {
+		case 'c':
+			assign(conversion, values.NewStr(str[strPos:strPos+1]))
+			strPos++
+
+		case 's':
+			start := strPos
+			for strPos < end && !isSpace(str[strPos]) {
+				strPos++
+			}
+			assign(conversion, values.NewStr(str[start:strPos]))
+
+		case '[':
+			start := strPos
+			for strPos < end && (strings.IndexByte(conversion.charSet, str[strPos]) >= 0) != conversion.negated {
+				strPos++
+			}
+			if start == strPos {
+				break scan
+			}
+			assign(conversion, values.NewStr(str[start:strPos]))
+
+		case 'd', 'D', 'i', 'o', 'x', 'X', 'u':
+			number, length, ok := scanInt(str[strPos:end], conversion.specifier)
+			if !ok {
+				if strPos+length >= len(str) {
+					underflow = true
+				}
+				break scan
+			}
+			strPos += length
+			if conversion.specifier == 'u' && number < 0 {
+				assign(conversion, values.NewStr(strconv.FormatUint(uint64(number), 10)))
+			} else {
+				assign(conversion, values.NewInt(number))
+			}
+
+		case 'f', 'e', 'E', 'g':
+			number, length, ok := scanFloat(str[strPos:end])
+			if !ok {
+				if strPos+length >= len(str) {
+					underflow = true
+				}
+				break scan
+			}
+			strPos += length
+			assign(conversion, values.NewFloat(number))
+		}
+		conversions++
+	}
+
+	if underflow && conversions == 0 {
+		return result, -1, nil
+	}
+	return result, conversions, nil
+}
+
+// Parse the conversion after the "%". The position points to the character after the conversion afterwards.
+func parseScanConversion(format string, pos *int) scanConversion {
+	conversion := scanConversion{position: -1}
+	charAt := func(pos int) byte {
+		if pos < len(format) {
+			return format[pos]
+		}
+		return 0
+	}
+
+	// Assignment suppression ("*") or position of the variable ("%n$")
+	if charAt(*pos) == '*' {
+		conversion.suppress = true
+		*pos++
+	} else if isDigit(charAt(*pos)) {
+		end := *pos
+		for isDigit(charAt(end)) {
+			end++
+		}
+		if charAt(end) == '$' {
+			position, _ := strconv.Atoi(format[*pos:end])
+			conversion.position = position - 1
+			*pos = end + 1
+		}
+	}
+
+	// Width
+	start := *pos
+	for isDigit(charAt(*pos)) {
+		*pos++
+	}
+	conversion.width, _ = strconv.Atoi(format[start:*pos])
+
+	// The size modifiers are ignored
+	if strings.IndexByte("hlL", charAt(*pos)) >= 0 {
+		*pos++
+	}
+
+	conversion.specifier = charAt(*pos)
+	*pos++
+	if conversion.specifier != '[' {
+		return conversion
+	}
+
+	// Character set (e.g. "[a-z]" or "[^,]")
+	if charAt(*pos) == '^' {
+		conversion.negated = true
+		*pos++
+	}
+	var charSet strings.Builder
+	// A "]" at the beginning is part of the set
+	if charAt(*pos) == ']' {
+		charSet.WriteByte(']')
+		*pos++
+	}
+	for *pos < len(format) && format[*pos] != ']' {
+		from := format[*pos]
+		if charAt(*pos+1) == '-' && charAt(*pos+2) != ']' && *pos+2 < len(format) {
+			to := format[*pos+2]
+			if from > to {
+				from, to = to, from
+			}
+			for char := int(from); char <= int(to); char++ {
+				charSet.WriteByte(byte(char))
+			}
+			*pos += 3
+			continue
+		}
+		charSet.WriteByte(from)
+		*pos++
+	}
+	// Skip the "]"
+	*pos++
+	conversion.charSet = charSet.String()
+	return conversion
+}
+
+// Validate the format string and return the number of variables it assigns
+func validateScanFormat(format string, numVars int) (int, phpError.Error) {
+	totalVars := 0
+	maxPosition := 0
+	gotSequential := false
+	gotPositional := false
+
+	pos := 0
+	for pos < len(format) {
+		if format[pos] != '%' {
+			pos++
+			continue
+		}
+		pos++
+		if pos < len(format) && format[pos] == '%' {
+			pos++
+			continue
+		}
+
+		conversion := parseScanConversion(format, &pos)
+		switch conversion.specifier {
+		case 'c':
+			if conversion.width != 0 {
+				return 0, phpError.NewError("Uncaught ValueError: Field width may not be specified in %%c conversion")
+			}
+		case '[':
+			if pos > len(format) {
+				return 0, phpError.NewError("Uncaught ValueError: Unmatched [ in format string")
+			}
+		case 'n', 'd', 'D', 'i', 'o', 'x', 'X', 'u', 'f', 'e', 'E', 'g', 's':
+		default:
+			return 0, phpError.NewError("Uncaught ValueError: Bad scan conversion character \"%c\"", conversion.specifier)
+		}
+
+		if conversion.suppress {
+			continue
+		}
+		if conversion.position >= 0 {
+			gotPositional = true
+			if numVars > 0 && conversion.position >= numVars {
+				return 0, phpError.NewError("Uncaught ValueError: \"%%n$\" argument index out of range")
+			}
+			maxPosition = max(maxPosition, conversion.position+1)
+		} else {
+			gotSequential = true
+			totalVars++
+		}
+		if gotPositional && gotSequential {
+			return 0, phpError.NewError("Uncaught ValueError: cannot mix \"%%\" and \"%%n$\" conversion specifiers")
+		}
+	}
+
+	if gotPositional {
+		totalVars = maxPosition
+	}
+	if numVars > 0 && numVars != totalVars {
+		return 0, phpError.NewError("Uncaught ValueError: Different numbers of variable names and field specifiers")
+	}
+	return totalVars, nil
+}
+
+func isSpace(char byte) bool {
+	return strings.IndexByte(" \t\n\v\f\r", char) >= 0
+}
+
+// Scan an integer at the beginning of the string.
+// It returns the number of consumed bytes and false if no digits were found.
+func scanInt(str string, specifier byte) (int64, int, bool) {
+	pos := 0
+	negative := false
+	if pos < len(str) && (str[pos] == '+' || str[pos] == '-') {
+		negative = str[pos] == '-'
+		pos++
+	}
+
+	base := 10
+	switch specifier {
+	case 'o':
+		base = 8
+	case 'x', 'X':
+		base = 16
+	case 'i':
+		base = 0
+	}
+	// Prefixes of octal and hexadecimal numbers
+	if (base == 0 || base == 16) && pos+1 < len(str) && str[pos] == '0' && (str[pos+1] == 'x' || str[pos+1] == 'X') &&
+		pos+2 < len(str) && isDigitOfBase(str[pos+2], 16) {
+		base = 16
+		pos += 2
+	} else if base == 0 && pos < len(str) && str[pos] == '0' {
+		base = 8
+	} else if base == 0 {
+		base = 10
+	}
+
+	start := pos
+	for pos < len(str) && isDigitOfBase(str[pos], base) {
+		pos++
+	}
+	if start == pos {
+		return 0, pos, false
+	}
+
+	number, err := strconv.ParseUint(str[start:pos], base, 64)
+	if err != nil {
+		number = 1 << 63
+		if !negative {
+			number--
+		}
+	}
+	if negative {
+		return -int64(number), pos, true
+	}
+	return int64(number), pos, true
+}
+
+func isDigitOfBase(char byte, base int) bool {
+	switch {
+	case char >= '0' && char <= '9':
+		return int(char-'0') < base
+	case char >= 'a' && char <= 'z':
+		return int(char-'a')+10 < base
+	case char >= 'A' && char <= 'Z':
+		return int(char-'A')+10 < base
+	}
+	return false
+}
+
+// Scan a floating-point number at the beginning of the string.
+// It returns the number of consumed bytes and false if no digits were found.
+func scanFloat(str string) (float64, int, bool) {
+	pos := 0
+	if pos < len(str) && (str[pos] == '+' || str[pos] == '-') {
+		pos++
+	}
+	digits := 0
+	for pos < len(str) && isDigit(str[pos]) {
+		pos++
+		digits++
+	}
+	if pos < len(str) && str[pos] == '.' {
+		pos++
+		for pos < len(str) && isDigit(str[pos]) {
+			pos++
+			digits++
+		}
+	}
+	if digits == 0 {
+		return 0, pos, false
+	}
+
+	// Exponent
+	if pos < len(str) && (str[pos] == 'e' || str[pos] == 'E') {
+		end := pos + 1
+		if end < len(str) && (str[end] == '+' || str[end] == '-') {
+			end++
+		}
+		if end < len(str) && isDigit(str[end]) {
+			for end < len(str) && isDigit(str[end]) {
+				end++
+			}
+			pos = end
+		}
+	}
+
+	number, _ := strconv.ParseFloat(str[:pos], 64)
+	return number, pos, true
+}
@@ -28,8 +28,8 @@ func (validator *Validator) AddParam(name string, paramType []string, defaultVal
 	// Add type of default value to allowed types
 	// e.g. function test(int $a = null) => Allowed types: int|null
 	if defaultValue != nil {
-		defaultValueType := values.ToPhpType(defaultValue)
-		if defaultValueType == "" && !slices.Contains(paramType, defaultValueType) && !slices.Contains(paramType, "mixed") {
+		defaultValueType := strings.ToLower(values.ToPhpType(defaultValue))
+		if defaultValueType != "" && !slices.Contains(paramType, defaultValueType) && !slices.Contains(paramType, "mixed") {
 			paramType = append(paramType, defaultValueType)
 		}
 	}
@@ -50,7 +50,7 @@ func (validator *Validator) AddVariableLenParam(name string, paramType []string)
 func (validator *Validator) Validate(args []values.RuntimeValue) ([]values.RuntimeValue, phpError.Error) {
 
 	typeMatches := func(param funcParam, arg values.RuntimeValue) bool {
-		typeStr := values.ToPhpType(arg)
+		typeStr := strings.ToLower(values.ToPhpType(arg))
 		if typeStr == "" {
 			return false
 		}
@@ -113,3 +113,20 @@ func TestCorrectParamType(t *testing.T) {
 		t.Errorf("\nExpected: nil\nGot: \"%s\"", err)
 	}
 }
+
+func TestNullableParam(t *testing.T) {
+	validator := NewValidator("testFn").AddParam("paramA", []string{"int"}, values.NewNull())
+	args, err := validator.Validate([]values.RuntimeValue{values.NewNull()})
+	if err != nil {
+		t.Errorf("Unexpected error: \"%s\"", err)
+	}
+	if len(args) != 1 || args[0].GetType() != values.NullValue {
+		t.Errorf("Expected null argument, got: %v", args)
+	}
+
+	_, err = validator.Validate([]values.RuntimeValue{values.NewStr("abc")})
+	expectedErr := phpError.NewError("Uncaught TypeError: testFn(): Argument #1 (paramA) must be of type int|null, string given")
+	if err.GetMessage() != expectedErr.GetMessage() {
+		t.Errorf("\nExpected: \"%s\"\nGot: \"%s\"", expectedErr, err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"QIQ/cmd/qiq/phpError"
 	"QIQ/cmd/qiq/request"
 	"QIQ/cmd/qiq/runtime/outputBuffer"
+	"QIQ/cmd/qiq/runtime/values"
 )
 
 type Interpreter interface {
@@ -14,6 +15,24 @@ type Interpreter interface {
 	GetIni() *ini.Ini
 	GetOutputBufferStack() *outputBuffer.Stack
 	GetClass(class string) (*ast.ClassDeclarationStatement, bool)
+	// Check if the class is the given class, extends it or implements it
+	IsSubclassOf(class *ast.ClassDeclarationStatement, className string) bool
+	// Create a new object with a unique object handle
+	NewObject(class *ast.ClassDeclarationStatement) *values.Object
+	// Create an object with the default property values without calling the constructor (e.g. for unserialize)
+	NewObjectWithoutConstructor(context Context, class *ast.ClassDeclarationStatement) (*values.Object, phpError.Error)
+	NewResource(resourceType string, handle any) *values.Resource
+	GetResourceRegistry() *values.ResourceRegistry
+	// Collect the garbage cycles and return the number of freed objects and arrays
+	CollectCycles() int
+	GetGarbageCollector() *values.GarbageCollector
+	// Assign a value to the variable that was passed to a by-reference parameter of a native function
+	SetByRefArgument(context Context, index int, value values.RuntimeValue) phpError.Error
+	// Call the function designated by the callable (e.g. the name of a function or [$object, "method"]) with the given arguments
+	CallFunction(context Context, callable values.RuntimeValue, args []values.RuntimeValue) (values.RuntimeValue, phpError.Error)
+	// Get the request-scoped state of a stdlib extension (e.g. the last error of the PCRE functions).
+	// The state is created with newState if it does not exist yet.
+	GetExtensionState(name string, newState func() any) any
 	Print(str string)
 	Println(str string)
 	PrintError(err phpError.Error)
@@ -3,6 +3,20 @@ package runtime
 import (
 	"QIQ/cmd/qiq/phpError"
 	"QIQ/cmd/qiq/runtime/values"
+	"slices"
 )
 
 type NativeFunction func([]values.RuntimeValue, Context) (values.RuntimeValue, phpError.Error)
+
+// VariadicByRef can be passed as last by-reference index to AddNativeFunction.
+// All parameters after the preceding index are then passed by reference (e.g. `&...$vars` of sscanf).
+const VariadicByRef = -1
+
+// IsByRefParam returns if the parameter with the given index is passed by reference
+func IsByRefParam(byRefParams []int, index int) bool {
+	if slices.Contains(byRefParams, index) {
+		return true
+	}
+	count := len(byRefParams)
+	return count >= 2 && byRefParams[count-1] == VariadicByRef && index > byRefParams[count-2]
+}
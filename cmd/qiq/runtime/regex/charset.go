@@ -0,0 +1,263 @@
+package regex
+
+import (
+	"strings"
+	"sync"
+	"unicode"
+)
+
+type charRange struct {
+	from rune
+	to   rune
+}
+
+type charClass struct {
+	contains func(r rune) bool
+	negated  bool
+}
+
+// Set of characters of a character class (e.g. "[a-z\d]") or an escape sequence (e.g. "\w")
+type charSet struct {
+	negated bool
+	ranges  []charRange
+	classes []charClass
+	// The ranges match case-insensitively
+	fold bool
+	utf  bool
+}
+
+func (set *charSet) matches(r rune) bool {
+	return set.contains(r) != set.negated
+}
+
+func (set *charSet) contains(r rune) bool {
+	for _, class := range set.classes {
+		if class.contains(r) != class.negated {
+			return true
+		}
+	}
+	if set.containsRange(r) {
+		return true
+	}
+	if set.fold {
+		for other := otherCase(r, set.utf); other != r; other = otherCase(other, set.utf) {
+			if set.containsRange(other) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (set *charSet) containsRange(r rune) bool {
+	for _, charRange := range set.ranges {
+		if r >= charRange.from && r <= charRange.to {
+			return true
+		}
+	}
+	return false
+}
+
+func (set *charSet) addChar(r rune) {
+	set.ranges = append(set.ranges, charRange{from: r, to: r})
+}
+
+func (set *charSet) addClass(contains func(r rune) bool, negated bool) {
+	set.classes = append(set.classes, charClass{contains: contains, negated: negated})
+}
+
+// -------------------------------------- Case folding -------------------------------------- MARK: Case folding
+
+// Return the next character of the case folding orbit of the character.
+// Without UTF-8 only ASCII letters have other cases.
+func otherCase(r rune, utf bool) rune {
+	if utf {
+		return unicode.SimpleFold(r)
+	}
+	switch {
+	case r >= 'a' && r <= 'z':
+		return r - 'a' + 'A'
+	case r >= 'A' && r <= 'Z':
+		return r - 'A' + 'a'
+	}
+	return r
+}
+
+func hasOtherCase(r rune, utf bool) bool {
+	return otherCase(r, utf) != r
+}
+
+func equalFold(a rune, b rune, utf bool) bool {
+	if a == b {
+		return true
+	}
+	for other := otherCase(a, utf); other != a; other = otherCase(other, utf) {
+		if other == b {
+			return true
+		}
+	}
+	return false
+}
+
+// -------------------------------------- Predefined classes -------------------------------------- MARK: Predefined classes
+
+// In UTF-8 mode the classes use Unicode properties (PHP compiles patterns with the "u" modifier with PCRE2_UCP)
+
+func isDigitChar(r rune, ucp bool) bool {
+	if ucp {
+		return unicode.Is(unicode.Nd, r)
+	}
+	return r >= '0' && r <= '9'
+}
+
+func isWordChar(r rune, ucp bool) bool {
+	if ucp {
+		return r == '_' || unicode.IsLetter(r) || unicode.IsNumber(r)
+	}
+	return r == '_' || (r >= '0' && r <= '9') || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+func isSpaceChar(r rune, ucp bool) bool {
+	if r == ' ' || (r >= '\t' && r <= '\r') {
+		return true
+	}
+	return ucp && (unicode.Is(unicode.Z, r) || isHorizontalSpace(r, true) || isVerticalSpace(r, true))
+}
+
+func isHorizontalSpace(r rune, utf bool) bool {
+	switch r {
+	case '\t', ' ', 0xA0:
+		return true
+	case 0x1680, 0x180E, 0x202F, 0x205F, 0x3000:
+		return utf
+	}
+	return utf && r >= 0x2000 && r <= 0x200A
+}
+
+func isVerticalSpace(r rune, utf bool) bool {
+	switch r {
+	case '\n', '\v', '\f', '\r', 0x85:
+		return true
+	case 0x2028, 0x2029:
+		return utf
+	}
+	return false
+}
+
+// Return the class of the escape sequence (e.g. "\d")
+func escapeClass(char byte, ucp bool) (func(r rune) bool, bool) {
+	switch char {
+	case 'd', 'D':
+		return func(r rune) bool { return isDigitChar(r, ucp) }, char == 'D'
+	case 'w', 'W':
+		return func(r rune) bool { return isWordChar(r, ucp) }, char == 'W'
+	case 's', 'S':
+		return func(r rune) bool { return isSpaceChar(r, ucp) }, char == 'S'
+	case 'h', 'H':
+		return func(r rune) bool { return isHorizontalSpace(r, ucp) }, char == 'H'
+	case 'v', 'V':
+		return func(r rune) bool { return isVerticalSpace(r, ucp) }, char == 'V'
+	}
+	return nil, false
+}
+
+// Spec: https://www.pcre.org/current/doc/html/pcre2pattern.html#SEC10
+func posixClass(name string, ucp bool) func(r rune) bool {
+	isASCII := func(r rune) bool { return r < 0x80 }
+	switch name {
+	case "alnum":
+		if ucp {
+			return func(r rune) bool { return unicode.IsLetter(r) || unicode.IsNumber(r) }
+		}
+		return func(r rune) bool { return isASCII(r) && (unicode.IsLetter(r) || unicode.IsDigit(r)) }
+	case "alpha":
+		if ucp {
+			return unicode.IsLetter
+		}
+		return func(r rune) bool { return isASCII(r) && unicode.IsLetter(r) }
+	case "ascii":
+		return isASCII
+	case "blank":
+		return func(r rune) bool { return isHorizontalSpace(r, ucp) && (ucp || r != 0xA0) }
+	case "cntrl":
+		return func(r rune) bool { return r < 0x20 || r == 0x7F || (ucp && unicode.Is(unicode.Cc, r)) }
+	case "digit":
+		return func(r rune) bool { return isDigitChar(r, ucp) }
+	case "graph":
+		if ucp {
+			return func(r rune) bool { return unicode.IsGraphic(r) && !unicode.IsSpace(r) }
+		}
+		return func(r rune) bool { return r > 0x20 && r < 0x7F }
+	case "lower":
+		if ucp {
+			return unicode.IsLower
+		}
+		return func(r rune) bool { return r >= 'a' && r <= 'z' }
+	case "print":
+		if ucp {
+			return func(r rune) bool { return unicode.IsPrint(r) || r == ' ' }
+		}
+		return func(r rune) bool { return r >= 0x20 && r < 0x7F }
+	case "punct":
+		if ucp {
+			return func(r rune) bool { return unicode.IsPunct(r) || (r < 0x80 && unicode.IsSymbol(r)) }
+		}
+		return func(r rune) bool { return isASCII(r) && (unicode.IsPunct(r) || unicode.IsSymbol(r)) }
+	case "space":
+		return func(r rune) bool { return isSpaceChar(r, ucp) }
+	case "upper":
+		if ucp {
+			return unicode.IsUpper
+		}
+		return func(r rune) bool { return r >= 'A' && r <= 'Z' }
+	case "word":
+		return func(r rune) bool { return isWordChar(r, ucp) }
+	case "xdigit":
+		return func(r rune) bool { return strings.ContainsRune("0123456789abcdefABCDEF", r) }
+	}
+	return nil
+}
+
+// -------------------------------------- Unicode properties -------------------------------------- MARK: Unicode properties
+
+var (
+	// Scripts by their normalized name
+	unicodeScripts     map[string]*unicode.RangeTable
+	unicodeScriptsOnce sync.Once
+)
+
+// Normalize the name of a property ("Greek", "greek" and "GREEK" are the same property)
+func normalizePropertyName(name string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", " ", "", "-", "").Replace(name))
+}
+
+// Return the class of a Unicode property (e.g. "L", "Lu", "Greek" or "Xan") used with "\p{...}"
+func unicodeProperty(name string) func(r rune) bool {
+	switch name {
+	case "Any":
+		return func(r rune) bool { return true }
+	case "L&", "LC":
+		return func(r rune) bool { return unicode.In(r, unicode.Lu, unicode.Ll, unicode.Lt) }
+	case "Xan":
+		return func(r rune) bool { return unicode.IsLetter(r) || unicode.IsNumber(r) }
+	case "Xsp", "Xps":
+		return func(r rune) bool { return isSpaceChar(r, true) }
+	case "Xwd":
+		return func(r rune) bool { return isWordChar(r, true) }
+	}
+
+	// General categories are case-sensitive ("Lu"), scripts are not ("greek")
+	if table, found := unicode.Categories[name]; found {
+		return func(r rune) bool { return unicode.Is(table, r) }
+	}
+	unicodeScriptsOnce.Do(func() {
+		unicodeScripts = map[string]*unicode.RangeTable{}
+		for script, table := range unicode.Scripts {
+			unicodeScripts[normalizePropertyName(script)] = table
+		}
+	})
+	if table, found := unicodeScripts[normalizePropertyName(name)]; found {
+		return func(r rune) bool { return unicode.Is(table, r) }
+	}
+	return nil
+}
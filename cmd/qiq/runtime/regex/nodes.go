@@ -0,0 +1,811 @@
+package regex
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The matcher uses continuation-passing style: Each node matches at a position and calls the continuation
+// with the position after the node. If the continuation fails, the node tries its next alternative
+// (e.g. one character less for a greedy quantifier). This implements backtracking without an explicit stack.
+
+type continuation func(pos int) bool
+
+type node interface {
+	match(m *matcher, pos int, k continuation) bool
+	// Minimum and maximum number of characters the node matches (maximum -1 = unbounded)
+	length() (int, int)
+}
+
+// Node that matches deterministically without backtracking (e.g. a literal)
+type fixedNode interface {
+	node
+	// Return the position after the node or -1 if the node does not match
+	advance(m *matcher, pos int) int
+}
+
+type matcher struct {
+	regex   *Regex
+	subject string
+	// Start and end offsets of the capture groups
+	caps []int
+	// Start offset of the search (for "\G")
+	searchStart int
+	// Position the current match attempt started at
+	matchStart int
+	// Start of the reported match set by "\K" (-1 if not set)
+	keep int
+	// Numbers of the groups that are currently called as subroutines
+	recursions     []int
+	steps          int
+	backtrackLimit int
+	depth          int
+	recursionLimit int
+	err            error
+}
+
+// Count a matching step. It returns false if the backtrack limit is exhausted.
+func (m *matcher) step() bool {
+	if m.err != nil {
+		return false
+	}
+	m.steps++
+	if m.backtrackLimit > 0 && m.steps > m.backtrackLimit {
+		m.err = ErrBacktrackLimit
+		return false
+	}
+	return true
+}
+
+// Enter a nested group or repetition. It returns false if the recursion limit is exhausted.
+func (m *matcher) enter() bool {
+	m.depth++
+	if m.recursionLimit > 0 && m.depth > m.recursionLimit {
+		m.err = ErrRecursionLimit
+		return false
+	}
+	return true
+}
+
+func (m *matcher) leave() {
+	m.depth--
+}
+
+// Decode the character at the position
+func (m *matcher) charAt(pos int) (rune, int) {
+	if m.regex.flags.UTF8 {
+		return utf8.DecodeRuneInString(m.subject[pos:])
+	}
+	return rune(m.subject[pos]), 1
+}
+
+// Return the character before the position or -1 at the start of the subject
+func (m *matcher) charBefore(pos int) rune {
+	if pos == 0 {
+		return -1
+	}
+	if m.regex.flags.UTF8 {
+		r, _ := utf8.DecodeLastRuneInString(m.subject[:pos])
+		return r
+	}
+	return rune(m.subject[pos-1])
+}
+
+func (m *matcher) charLen(pos int) int {
+	if pos >= len(m.subject) {
+		return 1
+	}
+	_, size := m.charAt(pos)
+	return size
+}
+
+func (m *matcher) prevCharStart(pos int) int {
+	if m.regex.flags.UTF8 {
+		_, size := utf8.DecodeLastRuneInString(m.subject[:pos])
+		return pos - size
+	}
+	return pos - 1
+}
+
+func (m *matcher) saveCaps() []int {
+	return slices.Clone(m.caps)
+}
+
+func (m *matcher) restoreCaps(caps []int, keep int) {
+	copy(m.caps, caps)
+	m.keep = keep
+}
+
+func (m *matcher) matchSequence(nodes []node, pos int, k continuation) bool {
+	// Deterministic nodes are matched in a loop to keep the call depth small
+	for len(nodes) > 0 {
+		fixed, ok := nodes[0].(fixedNode)
+		if !ok {
+			break
+		}
+		if !m.step() {
+			return false
+		}
+		pos = fixed.advance(m, pos)
+		if pos < 0 {
+			return false
+		}
+		nodes = nodes[1:]
+	}
+
+	switch len(nodes) {
+	case 0:
+		return k(pos)
+	case 1:
+		return nodes[0].match(m, pos, k)
+	}
+	return nodes[0].match(m, pos, func(pos int) bool { return m.matchSequence(nodes[1:], pos, k) })
+}
+
+func matchFixed(n fixedNode, m *matcher, pos int, k continuation) bool {
+	if !m.step() {
+		return false
+	}
+	pos = n.advance(m, pos)
+	return pos >= 0 && k(pos)
+}
+
+// -------------------------------------- Empty -------------------------------------- MARK: Empty
+
+type emptyNode struct{}
+
+func (n *emptyNode) match(m *matcher, pos int, k continuation) bool { return k(pos) }
+func (n *emptyNode) advance(m *matcher, pos int) int                { return pos }
+func (n *emptyNode) length() (int, int)                             { return 0, 0 }
+
+// -------------------------------------- Literal -------------------------------------- MARK: Literal
+
+type literalNode struct {
+	// Literal in the encoding of the subject
+	str string
+	// Number of characters
+	chars int
+	fold  bool
+}
+
+func (n *literalNode) match(m *matcher, pos int, k continuation) bool {
+	return matchFixed(n, m, pos, k)
+}
+func (n *literalNode) length() (int, int) { return n.chars, n.chars }
+
+func (n *literalNode) advance(m *matcher, pos int) int {
+	if !n.fold {
+		if strings.HasPrefix(m.subject[pos:], n.str) {
+			return pos + len(n.str)
+		}
+		return -1
+	}
+
+	utf := m.regex.flags.UTF8
+	for i := 0; i < len(n.str); {
+		if pos >= len(m.subject) {
+			return -1
+		}
+		var expected rune
+		var size int
+		if utf {
+			expected, size = utf8.DecodeRuneInString(n.str[i:])
+		} else {
+			expected, size = rune(n.str[i]), 1
+		}
+		i += size
+		actual, actualSize := m.charAt(pos)
+		if !equalFold(expected, actual, utf) {
+			return -1
+		}
+		pos += actualSize
+	}
+	return pos
+}
+
+// -------------------------------------- Character class -------------------------------------- MARK: Character class
+
+type classNode struct {
+	set *charSet
+}
+
+func (n *classNode) match(m *matcher, pos int, k continuation) bool { return matchFixed(n, m, pos, k) }
+func (n *classNode) length() (int, int)                             { return 1, 1 }
+
+func (n *classNode) advance(m *matcher, pos int) int {
+	if pos >= len(m.subject) {
+		return -1
+	}
+	r, size := m.charAt(pos)
+	if !n.set.matches(r) {
+		return -1
+	}
+	return pos + size
+}
+
+// "." matches any character except a newline (without the "s" modifier)
+type anyNode struct {
+	dotAll bool
+}
+
+func (n *anyNode) match(m *matcher, pos int, k continuation) bool { return matchFixed(n, m, pos, k) }
+func (n *anyNode) length() (int, int)                             { return 1, 1 }
+
+func (n *anyNode) advance(m *matcher, pos int) int {
+	if pos >= len(m.subject) || (!n.dotAll && m.subject[pos] == '\n') {
+		return -1
+	}
+	return pos + m.charLen(pos)
+}
+
+// Check if the node always matches exactly one character
+func isSingleChar(n node) bool {
+	switch n := n.(type) {
+	case *literalNode:
+		return n.chars == 1
+	case *classNode, *anyNode:
+		return true
+	}
+	return false
+}
+
+// -------------------------------------- Assertion -------------------------------------- MARK: Assertion
+
+type assertionKind int
+
+const (
+	// ^
+	assertStartOfLine assertionKind = iota
+	// $
+	assertEndOfLine
+	// \A
+	assertStartOfSubject
+	// \z
+	assertEndOfSubject
+	// \Z
+	assertEndOfSubjectOrNewline
+	// \G
+	assertStartOfMatch
+	// \b
+	assertWordBoundary
+	// \B
+	assertNotWordBoundary
+)
+
+type assertionNode struct {
+	kind          assertionKind
+	multiline     bool
+	dollarEndOnly bool
+}
+
+func (n *assertionNode) match(m *matcher, pos int, k continuation) bool {
+	return matchFixed(n, m, pos, k)
+}
+func (n *assertionNode) length() (int, int) { return 0, 0 }
+
+func (n *assertionNode) advance(m *matcher, pos int) int {
+	if n.matches(m, pos) {
+		return pos
+	}
+	return -1
+}
+
+func (n *assertionNode) matches(m *matcher, pos int) bool {
+	subject := m.subject
+	switch n.kind {
+	case assertStartOfLine:
+		return pos == 0 || (n.multiline && pos < len(subject) && subject[pos-1] == '\n')
+	case assertEndOfLine:
+		if n.multiline {
+			return pos == len(subject) || subject[pos] == '\n'
+		}
+		return pos == len(subject) || (!n.dollarEndOnly && pos == len(subject)-1 && subject[pos] == '\n')
+	case assertStartOfSubject:
+		return pos == 0
+	case assertEndOfSubject:
+		return pos == len(subject)
+	case assertEndOfSubjectOrNewline:
+		return pos == len(subject) || (pos == len(subject)-1 && subject[pos] == '\n')
+	case assertStartOfMatch:
+		return pos == m.searchStart
+	case assertWordBoundary, assertNotWordBoundary:
+		ucp := m.regex.flags.UTF8
+		before := m.charBefore(pos)
+		isBoundary := before >= 0 && isWordChar(before, ucp)
+		if pos < len(subject) {
+			after, _ := m.charAt(pos)
+			isBoundary = isBoundary != isWordChar(after, ucp)
+		}
+		return isBoundary == (n.kind == assertWordBoundary)
+	}
+	return false
+}
+
+// "\K" sets the start of the reported match
+type keepNode struct{}
+
+func (n *keepNode) length() (int, int) { return 0, 0 }
+
+func (n *keepNode) match(m *matcher, pos int, k continuation) bool {
+	keep := m.keep
+	m.keep = pos
+	if k(pos) {
+		return true
+	}
+	m.keep = keep
+	return false
+}
+
+// -------------------------------------- Sequence and alternation -------------------------------------- MARK: Sequence and alternation
+
+type sequenceNode struct {
+	nodes []node
+}
+
+func (n *sequenceNode) match(m *matcher, pos int, k continuation) bool {
+	return m.matchSequence(n.nodes, pos, k)
+}
+
+func (n *sequenceNode) length() (int, int) {
+	minLen, maxLen := 0, 0
+	for _, child := range n.nodes {
+		childMin, childMax := child.length()
+		minLen += childMin
+		if maxLen >= 0 {
+			maxLen = addMaxLength(maxLen, childMax)
+		}
+	}
+	return minLen, maxLen
+}
+
+func addMaxLength(a int, b int) int {
+	if a < 0 || b < 0 {
+		return -1
+	}
+	return a + b
+}
+
+type alternationNode struct {
+	alternatives []node
+}
+
+func (n *alternationNode) match(m *matcher, pos int, k continuation) bool {
+	for _, alternative := range n.alternatives {
+		if !m.step() {
+			return false
+		}
+		if alternative.match(m, pos, k) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *alternationNode) length() (int, int) {
+	minLen, maxLen := n.alternatives[0].length()
+	for _, alternative := range n.alternatives[1:] {
+		childMin, childMax := alternative.length()
+		minLen = min(minLen, childMin)
+		if maxLen >= 0 && (childMax < 0 || childMax > maxLen) {
+			maxLen = childMax
+		}
+	}
+	return minLen, maxLen
+}
+
+// -------------------------------------- Groups -------------------------------------- MARK: Groups
+
+type groupNode struct {
+	// Number of the capture group
+	index int
+	child node
+}
+
+func (n *groupNode) length() (int, int) { return n.child.length() }
+
+func (n *groupNode) match(m *matcher, pos int, k continuation) bool {
+	if !m.step() {
+		return false
+	}
+	start := pos
+	i := 2 * n.index
+	return n.child.match(m, pos, func(pos int) bool {
+		oldStart, oldEnd := m.caps[i], m.caps[i+1]
+		m.caps[i], m.caps[i+1] = start, pos
+		if k(pos) {
+			return true
+		}
+		m.caps[i], m.caps[i+1] = oldStart, oldEnd
+		return false
+	})
+}
+
+// Atomic group "(?>...)": Once the group matched, it is not backtracked into
+type atomicNode struct {
+	child node
+}
+
+func (n *atomicNode) length() (int, int) { return n.child.length() }
+
+func (n *atomicNode) match(m *matcher, pos int, k continuation) bool {
+	caps, keep := m.saveCaps(), m.keep
+	end := -1
+	if !n.child.match(m, pos, func(pos int) bool {
+		end = pos
+		return true
+	}) {
+		return false
+	}
+	if k(end) {
+		return true
+	}
+	m.restoreCaps(caps, keep)
+	return false
+}
+
+// Lookahead "(?=...)", "(?!...)" and lookbehind "(?<=...)", "(?<!...)" assertions
+type lookaroundNode struct {
+	child    node
+	behind   bool
+	negated  bool
+	minChars int
+	maxChars int
+}
+
+func (n *lookaroundNode) length() (int, int) { return 0, 0 }
+
+func (n *lookaroundNode) match(m *matcher, pos int, k continuation) bool {
+	if !m.step() {
+		return false
+	}
+	caps, keep := m.saveCaps(), m.keep
+	matched := n.matches(m, pos)
+	if m.err != nil {
+		return false
+	}
+	// Captures of negative assertions are never kept
+	if n.negated {
+		m.restoreCaps(caps, keep)
+		return !matched && k(pos)
+	}
+	if matched && k(pos) {
+		return true
+	}
+	m.restoreCaps(caps, keep)
+	return false
+}
+
+func (n *lookaroundNode) matches(m *matcher, pos int) bool {
+	keep := m.keep
+	defer func() { m.keep = keep }()
+
+	if !n.behind {
+		return n.child.match(m, pos, func(int) bool { return true })
+	}
+
+	start := pos
+	for chars := 0; chars <= n.maxChars; chars++ {
+		if chars >= n.minChars && n.child.match(m, start, func(end int) bool { return end == pos }) {
+			return true
+		}
+		if start == 0 || m.err != nil {
+			break
+		}
+		start = m.prevCharStart(start)
+	}
+	return false
+}
+
+// -------------------------------------- Repetition -------------------------------------- MARK: Repetition
+
+type repeatNode struct {
+	child node
+	min   int
+	// Maximum number of repetitions (-1 = unbounded)
+	max    int
+	greedy bool
+}
+
+func (n *repeatNode) length() (int, int) {
+	childMin, childMax := n.child.length()
+	if n.max == 0 || childMax == 0 {
+		return n.min * childMin, 0
+	}
+	if n.max < 0 || childMax < 0 {
+		return n.min * childMin, -1
+	}
+	return n.min * childMin, n.max * childMax
+}
+
+func (n *repeatNode) match(m *matcher, pos int, k continuation) bool {
+	if !m.step() {
+		return false
+	}
+	if isSingleChar(n.child) {
+		return n.matchChars(m, n.child.(fixedNode), pos, k)
+	}
+	return n.matchIteration(m, pos, 0, k)
+}
+
+// Repetition of a single character: The characters are matched in a loop
+// and the backtracking goes back character by character.
+func (n *repeatNode) matchChars(m *matcher, child fixedNode, pos int, k continuation) bool {
+	count := 0
+	if n.greedy {
+		for n.max < 0 || count < n.max {
+			next := child.advance(m, pos)
+			if next < 0 {
+				break
+			}
+			pos = next
+			count++
+		}
+		if count < n.min {
+			return false
+		}
+		for {
+			if k(pos) {
+				return true
+			}
+			if count == n.min || !m.step() {
+				return false
+			}
+			pos = m.prevCharStart(pos)
+			count--
+		}
+	}
+
+	for count < n.min {
+		pos = child.advance(m, pos)
+		if pos < 0 {
+			return false
+		}
+		count++
+	}
+	for {
+		if k(pos) {
+			return true
+		}
+		if (n.max >= 0 && count >= n.max) || !m.step() {
+			return false
+		}
+		pos = child.advance(m, pos)
+		if pos < 0 {
+			return false
+		}
+		count++
+	}
+}
+
+func (n *repeatNode) matchIteration(m *matcher, pos int, count int, k continuation) bool {
+	if !m.enter() {
+		return false
+	}
+	defer m.leave()
+
+	if n.greedy {
+		if n.max < 0 || count < n.max {
+			if n.child.match(m, pos, func(next int) bool {
+				// An empty iteration ends the repetition
+				if next == pos && count+1 >= n.min {
+					return k(next)
+				}
+				return n.matchIteration(m, next, count+1, k)
+			}) {
+				return true
+			}
+			if m.err != nil {
+				return false
+			}
+		}
+		return count >= n.min && k(pos)
+	}
+
+	if count >= n.min {
+		if k(pos) {
+			return true
+		}
+		if m.err != nil {
+			return false
+		}
+	}
+	if n.max >= 0 && count >= n.max {
+		return false
+	}
+	return n.child.match(m, pos, func(next int) bool {
+		if next == pos && count >= n.min {
+			return false
+		}
+		return n.matchIteration(m, next, count+1, k)
+	})
+}
+
+// -------------------------------------- Backreference -------------------------------------- MARK: Backreference
+
+type backrefNode struct {
+	// Groups the reference refers to (several for duplicate names)
+	indexes []int
+	fold    bool
+	// Name or relative number to resolve after parsing
+	name   string
+	offset int
+}
+
+func (n *backrefNode) length() (int, int) { return 0, -1 }
+
+func (n *backrefNode) match(m *matcher, pos int, k continuation) bool {
+	if !m.step() {
+		return false
+	}
+	for _, index := range n.indexes {
+		start, end := m.caps[2*index], m.caps[2*index+1]
+		if start < 0 {
+			continue
+		}
+		captured := m.subject[start:end]
+		if !n.fold {
+			return strings.HasPrefix(m.subject[pos:], captured) && k(pos+len(captured))
+		}
+		literal := &literalNode{str: captured, fold: true}
+		pos = literal.advance(m, pos)
+		return pos >= 0 && k(pos)
+	}
+	// A reference to an unset group never matches
+	return false
+}
+
+// -------------------------------------- Subroutine call -------------------------------------- MARK: Subroutine call
+
+// Recursion "(?R)" and subroutine calls "(?1)" or "(?&name)"
+type subroutineNode struct {
+	index  int
+	name   string
+	offset int
+}
+
+func (n *subroutineNode) length() (int, int) { return 0, -1 }
+
+func (n *subroutineNode) match(m *matcher, pos int, k continuation) bool {
+	if !m.step() || !m.enter() {
+		return false
+	}
+	defer m.leave()
+
+	// Captures set during the call are reset afterwards
+	caps := m.saveCaps()
+	m.recursions = append(m.recursions, n.index)
+	depth := len(m.recursions)
+	if m.regex.groups[n.index].match(m, pos, func(end int) bool {
+		innerCaps := m.saveCaps()
+		copy(m.caps, caps)
+		m.recursions = m.recursions[:depth-1]
+		if k(end) {
+			return true
+		}
+		m.recursions = append(m.recursions[:depth-1], n.index)
+		copy(m.caps, innerCaps)
+		return false
+	}) {
+		return true
+	}
+	m.recursions = m.recursions[:depth-1]
+	copy(m.caps, caps)
+	return false
+}
+
+// -------------------------------------- Conditional -------------------------------------- MARK: Conditional
+
+type conditionKind int
+
+const (
+	// (?(1)...) or (?(<name>)...)
+	conditionGroup conditionKind = iota
+	// (?(R)...), (?(R1)...) or (?(R&name)...)
+	conditionRecursion
+	// (?(?=...)...)
+	conditionAssertion
+	// (?(DEFINE)...)
+	conditionDefine
+)
+
+type conditionalNode struct {
+	kind conditionKind
+	// Groups of the condition (-1 for "(?(R)...)")
+	indexes   []int
+	assertion *lookaroundNode
+	yes       node
+	no        node
+	// Name to resolve after parsing
+	name   string
+	offset int
+}
+
+func (n *conditionalNode) length() (int, int) {
+	yesMin, yesMax := n.yes.length()
+	noMin, noMax := n.no.length()
+	if yesMax < 0 || noMax < 0 {
+		return min(yesMin, noMin), -1
+	}
+	return min(yesMin, noMin), max(yesMax, noMax)
+}
+
+func (n *conditionalNode) match(m *matcher, pos int, k continuation) bool {
+	if !m.step() {
+		return false
+	}
+	switch n.kind {
+	case conditionGroup:
+		for _, index := range n.indexes {
+			if m.caps[2*index] >= 0 {
+				return n.yes.match(m, pos, k)
+			}
+		}
+	case conditionRecursion:
+		if len(m.recursions) > 0 && (n.indexes[0] < 0 || m.recursions[len(m.recursions)-1] == n.indexes[0]) {
+			return n.yes.match(m, pos, k)
+		}
+	case conditionAssertion:
+		asserted := false
+		matched := n.assertion.match(m, pos, func(pos int) bool {
+			asserted = true
+			return n.yes.match(m, pos, k)
+		})
+		if asserted || m.err != nil {
+			return matched
+		}
+	}
+	return n.no.match(m, pos, k)
+}
+
+// -------------------------------------- Newline sequence -------------------------------------- MARK: Newline sequence
+
+// "\R" matches any Unicode newline sequence (atomically)
+type newlineNode struct{}
+
+func (n *newlineNode) match(m *matcher, pos int, k continuation) bool {
+	return matchFixed(n, m, pos, k)
+}
+func (n *newlineNode) length() (int, int) { return 1, 2 }
+
+func (n *newlineNode) advance(m *matcher, pos int) int {
+	if strings.HasPrefix(m.subject[pos:], "\r\n") {
+		return pos + 2
+	}
+	if pos >= len(m.subject) {
+		return -1
+	}
+	r, size := m.charAt(pos)
+	if isVerticalSpace(r, m.regex.flags.UTF8) {
+		return pos + size
+	}
+	return -1
+}
+
+// "\X" matches an extended grapheme cluster (a character followed by combining marks)
+type graphemeNode struct{}
+
+func (n *graphemeNode) match(m *matcher, pos int, k continuation) bool {
+	return matchFixed(n, m, pos, k)
+}
+func (n *graphemeNode) length() (int, int) { return 1, -1 }
+
+func (n *graphemeNode) advance(m *matcher, pos int) int {
+	if pos >= len(m.subject) {
+		return -1
+	}
+	if strings.HasPrefix(m.subject[pos:], "\r\n") {
+		return pos + 2
+	}
+	_, size := m.charAt(pos)
+	pos += size
+	for pos < len(m.subject) {
+		r, size := m.charAt(pos)
+		if !unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc) && r != 0x200D {
+			break
+		}
+		pos += size
+	}
+	return pos
+}
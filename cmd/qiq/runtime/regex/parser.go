@@ -0,0 +1,1208 @@
+package regex
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type parser struct {
+	pattern string
+	pos     int
+	// Flags at the current position (changed by inline options like "(?i)")
+	flags Flags
+	// Capture groups by their number (index 0 is the whole pattern)
+	groups      []*groupNode
+	groupNames  []string
+	namedGroups map[string][]int
+	// Number of the last opened capture group
+	groupCount int
+	// Number of capture groups of the whole pattern (decides if "\10" is a backreference or an octal escape)
+	totalGroups  int
+	backrefs     []*backrefNode
+	subroutines  []*subroutineNode
+	conditionals []*conditionalNode
+}
+
+// Maximum length of a group name
+const maxNameLength = 32
+
+func newParser(pattern string, flags Flags) *parser {
+	return &parser{
+		pattern:     pattern,
+		flags:       flags,
+		groups:      []*groupNode{nil},
+		groupNames:  []string{""},
+		namedGroups: map[string][]int{},
+		totalGroups: countGroups(pattern, flags.NoAutoCapture),
+	}
+}
+
+func (p *parser) errorAt(message string, offset int) error {
+	return &CompileError{Message: message, Offset: offset}
+}
+
+func (p *parser) parse() (node, error) {
+	root, err := p.parseAlternation(false)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.pattern) {
+		return nil, p.errorAt("unmatched closing parenthesis", p.pos)
+	}
+	if err := p.resolveReferences(); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// Resolve the names of backreferences, subroutine calls and conditions (they can refer to later groups)
+func (p *parser) resolveReferences() error {
+	resolve := func(name string, indexes []int, offset int) ([]int, error) {
+		if name != "" {
+			indexes = p.namedGroups[name]
+		}
+		if len(indexes) == 0 {
+			return nil, p.errorAt("reference to non-existent subpattern", offset)
+		}
+		for _, index := range indexes {
+			if index > p.groupCount {
+				return nil, p.errorAt("reference to non-existent subpattern", offset)
+			}
+		}
+		return indexes, nil
+	}
+
+	for _, backref := range p.backrefs {
+		indexes, err := resolve(backref.name, backref.indexes, backref.offset)
+		if err != nil {
+			return err
+		}
+		backref.indexes = indexes
+	}
+	for _, subroutine := range p.subroutines {
+		indexes, err := resolve(subroutine.name, []int{subroutine.index}, subroutine.offset)
+		if err != nil {
+			return err
+		}
+		subroutine.index = indexes[0]
+	}
+	for _, conditional := range p.conditionals {
+		if conditional.kind == conditionRecursion && conditional.name == "" {
+			continue
+		}
+		indexes, err := resolve(conditional.name, conditional.indexes, conditional.offset)
+		if err != nil {
+			return err
+		}
+		conditional.indexes = indexes
+	}
+	return nil
+}
+
+// Count the capture groups of the pattern
+func countGroups(pattern string, noAutoCapture bool) int {
+	count := 0
+	inClass := false
+	for i := 0; i < len(pattern); i++ {
+		switch char := pattern[i]; {
+		case char == '\\':
+			i++
+		case inClass:
+			inClass = char != ']'
+		case char == '[':
+			inClass = true
+			if strings.HasPrefix(pattern[i+1:], "^") {
+				i++
+			}
+			if strings.HasPrefix(pattern[i+1:], "]") {
+				i++
+			}
+		case char == '(':
+			rest := pattern[i+1:]
+			if strings.HasPrefix(rest, "?<") && !strings.HasPrefix(rest, "?<=") && !strings.HasPrefix(rest, "?<!") ||
+				strings.HasPrefix(rest, "?'") || strings.HasPrefix(rest, "?P<") {
+				count++
+			} else if !noAutoCapture && !strings.HasPrefix(rest, "?") && !strings.HasPrefix(rest, "*") {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// -------------------------------------- Characters -------------------------------------- MARK: Characters
+
+func (p *parser) atEnd() bool {
+	return p.pos >= len(p.pattern)
+}
+
+func (p *parser) peek() byte {
+	if p.atEnd() {
+		return 0
+	}
+	return p.pattern[p.pos]
+}
+
+func (p *parser) hasPrefix(prefix string) bool {
+	return strings.HasPrefix(p.pattern[p.pos:], prefix)
+}
+
+// Read the next character of the pattern
+func (p *parser) nextChar() rune {
+	if p.flags.UTF8 {
+		r, size := utf8.DecodeRuneInString(p.pattern[p.pos:])
+		p.pos += size
+		return r
+	}
+	p.pos++
+	return rune(p.pattern[p.pos-1])
+}
+
+func (p *parser) literal(r rune) *literalNode {
+	var str string
+	if p.flags.UTF8 {
+		str = string(r)
+	} else {
+		str = string([]byte{byte(r)})
+	}
+	return &literalNode{str: str, chars: 1, fold: p.flags.CaseInsensitive && hasOtherCase(r, p.flags.UTF8)}
+}
+
+// Skip whitespace and comments in extended mode ("x" modifier)
+func (p *parser) skipExtended() {
+	if !p.flags.Extended {
+		return
+	}
+	for !p.atEnd() {
+		switch p.peek() {
+		case ' ', '\t', '\n', '\v', '\f', '\r':
+			p.pos++
+		case '#':
+			for !p.atEnd() && p.peek() != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// -------------------------------------- Structure -------------------------------------- MARK: Structure
+
+func (p *parser) parseAlternation(branchReset bool) (node, error) {
+	alternatives, err := p.parseAlternatives(branchReset)
+	if err != nil {
+		return nil, err
+	}
+	if len(alternatives) == 1 {
+		return alternatives[0], nil
+	}
+	return &alternationNode{alternatives: alternatives}, nil
+}
+
+func (p *parser) parseAlternatives(branchReset bool) ([]node, error) {
+	alternatives := []node{}
+	groupCount := p.groupCount
+	maxGroupCount := p.groupCount
+	for {
+		// In a branch reset group "(?|...)" each alternative starts with the same group number
+		if branchReset {
+			p.groupCount = groupCount
+		}
+		sequence, err := p.parseSequence()
+		if err != nil {
+			return nil, err
+		}
+		alternatives = append(alternatives, sequence)
+		maxGroupCount = max(maxGroupCount, p.groupCount)
+		if p.peek() != '|' {
+			break
+		}
+		p.pos++
+	}
+	p.groupCount = maxGroupCount
+	return alternatives, nil
+}
+
+func (p *parser) parseSequence() (node, error) {
+	items := []node{}
+	for {
+		p.skipExtended()
+		if p.atEnd() || p.peek() == '|' || p.peek() == ')' {
+			break
+		}
+
+		// Quoted literal "\Q...\E"
+		if p.hasPrefix(`\Q`) {
+			p.pos += 2
+			end := strings.Index(p.pattern[p.pos:], `\E`)
+			if end < 0 {
+				end = len(p.pattern) - p.pos
+			}
+			quoteEnd := p.pos + end
+			quoted := 0
+			for p.pos < quoteEnd {
+				items = append(items, p.literal(p.nextChar()))
+				quoted++
+			}
+			p.pos = min(quoteEnd+2, len(p.pattern))
+			// A quantifier applies to the last quoted character
+			if quoted > 0 {
+				item, err := p.parseQuantifier(items[len(items)-1])
+				if err != nil {
+					return nil, err
+				}
+				items[len(items)-1] = item
+			}
+			continue
+		}
+
+		atom, err := p.parseAtom()
+		if err != nil {
+			return nil, err
+		}
+		if atom == nil {
+			continue
+		}
+		atom, err = p.parseQuantifier(atom)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, atom)
+	}
+	return newSequence(items), nil
+}
+
+// Create a sequence and merge adjacent literals
+func newSequence(items []node) node {
+	merged := []node{}
+	for _, item := range items {
+		if literal, ok := item.(*literalNode); ok && len(merged) > 0 {
+			if previous, ok := merged[len(merged)-1].(*literalNode); ok && previous.fold == literal.fold {
+				merged[len(merged)-1] = &literalNode{str: previous.str + literal.str, chars: previous.chars + literal.chars, fold: literal.fold}
+				continue
+			}
+		}
+		merged = append(merged, item)
+	}
+
+	switch len(merged) {
+	case 0:
+		return &emptyNode{}
+	case 1:
+		return merged[0]
+	}
+	return &sequenceNode{nodes: merged}
+}
+
+func (p *parser) parseAtom() (node, error) {
+	switch p.peek() {
+	case '(':
+		return p.parseGroup()
+	case '[':
+		return p.parseClass()
+	case '.':
+		p.pos++
+		return &anyNode{dotAll: p.flags.DotAll}, nil
+	case '^':
+		p.pos++
+		return &assertionNode{kind: assertStartOfLine, multiline: p.flags.Multiline}, nil
+	case '$':
+		p.pos++
+		return &assertionNode{kind: assertEndOfLine, multiline: p.flags.Multiline, dollarEndOnly: p.flags.DollarEndOnly}, nil
+	case '\\':
+		return p.parseEscapeNode()
+	case '*', '+', '?':
+		return nil, p.errorAt("quantifier does not follow a repeatable item", p.pos)
+	case '{':
+		if _, _, _, ok, _ := p.parseBraces(); ok {
+			return nil, p.errorAt("quantifier does not follow a repeatable item", p.pos)
+		}
+	}
+	return p.literal(p.nextChar()), nil
+}
+
+// -------------------------------------- Quantifiers -------------------------------------- MARK: Quantifiers
+
+func (p *parser) parseQuantifier(atom node) (node, error) {
+	p.skipExtended()
+	start := p.pos
+	var minCount, maxCount int
+	switch p.peek() {
+	case '*':
+		minCount, maxCount = 0, -1
+		p.pos++
+	case '+':
+		minCount, maxCount = 1, -1
+		p.pos++
+	case '?':
+		minCount, maxCount = 0, 1
+		p.pos++
+	case '{':
+		var end int
+		var ok bool
+		var err error
+		minCount, maxCount, end, ok, err = p.parseBraces()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return atom, nil
+		}
+		p.pos = end
+	default:
+		return atom, nil
+	}
+
+	switch atom.(type) {
+	case *assertionNode, *keepNode:
+		return nil, p.errorAt("quantifier does not follow a repeatable item", start)
+	}
+
+	greedy := !p.flags.Ungreedy
+	possessive := false
+	switch p.peek() {
+	case '?':
+		greedy = !greedy
+		p.pos++
+	case '+':
+		possessive = true
+		greedy = true
+		p.pos++
+	}
+
+	if minCount == 1 && maxCount == 1 {
+		return atom, nil
+	}
+	var result node = &repeatNode{child: atom, min: minCount, max: maxCount, greedy: greedy}
+	if possessive {
+		result = &atomicNode{child: result}
+	}
+	return result, nil
+}
+
+// Parse a quantifier like "{2}", "{2,}" or "{2,5}" at the current position.
+// It returns false if the braces are no quantifier (they are a literal then).
+func (p *parser) parseBraces() (int, int, int, bool, error) {
+	pos := p.pos + 1
+	readNumber := func() (int, bool, error) {
+		start := pos
+		for pos < len(p.pattern) && p.pattern[pos] >= '0' && p.pattern[pos] <= '9' {
+			pos++
+		}
+		if start == pos {
+			return 0, false, nil
+		}
+		number, err := strconv.Atoi(p.pattern[start:pos])
+		if err != nil || number > 65535 {
+			return 0, false, p.errorAt("number too big in {} quantifier", pos)
+		}
+		return number, true, nil
+	}
+
+	minCount, ok, err := readNumber()
+	if !ok || err != nil {
+		return 0, 0, 0, false, err
+	}
+	maxCount := minCount
+	if pos < len(p.pattern) && p.pattern[pos] == ',' {
+		pos++
+		maxCount, ok, err = readNumber()
+		if err != nil {
+			return 0, 0, 0, false, err
+		}
+		if !ok {
+			maxCount = -1
+		}
+	}
+	if pos >= len(p.pattern) || p.pattern[pos] != '}' {
+		return 0, 0, 0, false, nil
+	}
+	if maxCount >= 0 && maxCount < minCount {
+		return 0, 0, 0, false, p.errorAt("numbers out of order in {} quantifier", pos)
+	}
+	return minCount, maxCount, pos + 1, true, nil
+}
+
+// -------------------------------------- Groups -------------------------------------- MARK: Groups
+
+func (p *parser) parseGroup() (node, error) {
+	start := p.pos
+	p.pos++
+	if p.peek() == '*' {
+		return nil, p.errorAt("(*VERB) not recognized or malformed", p.pos)
+	}
+	if p.peek() != '?' {
+		if p.flags.NoAutoCapture {
+			return p.parseGroupBody(false)
+		}
+		return p.parseCaptureGroup("", start)
+	}
+
+	p.pos++
+	switch {
+	case p.hasPrefix("#"):
+		end := strings.IndexByte(p.pattern[p.pos:], ')')
+		if end < 0 {
+			return nil, p.errorAt("missing ) after (?# comment", len(p.pattern))
+		}
+		p.pos += end + 1
+		return nil, nil
+	case p.hasPrefix(":"):
+		p.pos++
+		return p.parseGroupBody(false)
+	case p.hasPrefix("|"):
+		p.pos++
+		return p.parseGroupBody(true)
+	case p.hasPrefix(">"):
+		p.pos++
+		child, err := p.parseGroupBody(false)
+		if err != nil {
+			return nil, err
+		}
+		return &atomicNode{child: child}, nil
+	case p.hasPrefix("="), p.hasPrefix("!"):
+		negated := p.peek() == '!'
+		p.pos++
+		return p.parseLookaround(false, negated, start)
+	case p.hasPrefix("<="), p.hasPrefix("<!"):
+		negated := p.hasPrefix("<!")
+		p.pos += 2
+		return p.parseLookaround(true, negated, start)
+	case p.hasPrefix("<"), p.hasPrefix("'"), p.hasPrefix("P<"):
+		if p.peek() == 'P' {
+			p.pos++
+		}
+		terminator := byte('>')
+		if p.peek() == '\'' {
+			terminator = '\''
+		}
+		p.pos++
+		name, err := p.parseName(terminator)
+		if err != nil {
+			return nil, err
+		}
+		return p.parseCaptureGroup(name, start)
+	case p.hasPrefix("P="):
+		p.pos += 2
+		offset := p.pos
+		name, err := p.parseName(')')
+		if err != nil {
+			return nil, err
+		}
+		return p.newBackref(0, name, offset), nil
+	case p.hasPrefix("P>"), p.hasPrefix("&"):
+		if p.peek() == 'P' {
+			p.pos++
+		}
+		p.pos++
+		offset := p.pos
+		name, err := p.parseName(')')
+		if err != nil {
+			return nil, err
+		}
+		return p.newSubroutine(0, name, offset), nil
+	case p.hasPrefix("R)"):
+		p.pos += 2
+		return p.newSubroutine(0, "", start), nil
+	case p.hasPrefix("("):
+		return p.parseConditional(start)
+	}
+
+	// Subroutine call by number: "(?1)", "(?-1)" or "(?+1)"
+	if number, relative, ok := p.parseGroupNumber(); ok {
+		if p.peek() != ')' {
+			return nil, p.errorAt("missing closing parenthesis", p.pos)
+		}
+		p.pos++
+		index, err := p.resolveGroupNumber(number, relative, start)
+		if err != nil {
+			return nil, err
+		}
+		return p.newSubroutine(index, "", start), nil
+	}
+
+	return p.parseInlineOptions()
+}
+
+// Parse a (possibly relative) group number like "1", "-1" or "+1"
+func (p *parser) parseGroupNumber() (int, byte, bool) {
+	pos := p.pos
+	relative := byte(0)
+	if pos < len(p.pattern) && (p.pattern[pos] == '-' || p.pattern[pos] == '+') {
+		relative = p.pattern[pos]
+		pos++
+	}
+	start := pos
+	for pos < len(p.pattern) && p.pattern[pos] >= '0' && p.pattern[pos] <= '9' {
+		pos++
+	}
+	if start == pos {
+		return 0, 0, false
+	}
+	number, err := strconv.Atoi(p.pattern[start:pos])
+	if err != nil {
+		number = 1 << 30
+	}
+	p.pos = pos
+	return number, relative, true
+}
+
+func (p *parser) resolveGroupNumber(number int, relative byte, offset int) (int, error) {
+	switch relative {
+	case '-':
+		number = p.groupCount - number + 1
+		if number <= 0 {
+			return 0, p.errorAt("reference to non-existent subpattern", offset)
+		}
+	case '+':
+		if number == 0 {
+			return 0, p.errorAt("a relative value of zero is not allowed", offset)
+		}
+		number += p.groupCount
+	}
+	return number, nil
+}
+
+func (p *parser) parseGroupBody(branchReset bool) (node, error) {
+	flags := p.flags
+	child, err := p.parseAlternation(branchReset)
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() != ')' {
+		return nil, p.errorAt("missing closing parenthesis", len(p.pattern))
+	}
+	p.pos++
+	p.flags = flags
+	return child, nil
+}
+
+func (p *parser) parseCaptureGroup(name string, offset int) (node, error) {
+	p.groupCount++
+	group := &groupNode{index: p.groupCount}
+	if group.index < len(p.groups) {
+		// Group number of a branch reset group that is used a second time
+		group = p.groups[group.index]
+	} else {
+		p.groups = append(p.groups, group)
+		p.groupNames = append(p.groupNames, name)
+	}
+
+	if name != "" {
+		if indexes, found := p.namedGroups[name]; found && !p.flags.DupNames && indexes[0] != group.index {
+			return nil, p.errorAt("two named subpatterns have the same name (PCRE2_DUPNAMES not set)", offset)
+		}
+		p.namedGroups[name] = append(p.namedGroups[name], group.index)
+		p.groupNames[group.index] = name
+	}
+
+	child, err := p.parseGroupBody(false)
+	if err != nil {
+		return nil, err
+	}
+	if group.child == nil {
+		group.child = child
+		return group, nil
+	}
+	return &groupNode{index: group.index, child: child}, nil
+}
+
+func (p *parser) parseLookaround(behind bool, negated bool, offset int) (node, error) {
+	alternatives, err := p.parseAlternativesInGroup()
+	if err != nil {
+		return nil, err
+	}
+	lookaround := &lookaroundNode{behind: behind, negated: negated, child: alternatives[0]}
+	if len(alternatives) > 1 {
+		lookaround.child = &alternationNode{alternatives: alternatives}
+	}
+	if behind {
+		// Each alternative of a lookbehind assertion must have a fixed length
+		for _, alternative := range alternatives {
+			minChars, maxChars := alternative.length()
+			if minChars != maxChars {
+				return nil, p.errorAt("lookbehind assertion is not fixed length", offset)
+			}
+		}
+		lookaround.minChars, lookaround.maxChars = lookaround.child.length()
+	}
+	return lookaround, nil
+}
+
+// Parse the alternatives of a group up to the closing parenthesis
+func (p *parser) parseAlternativesInGroup() ([]node, error) {
+	flags := p.flags
+	alternatives, err := p.parseAlternatives(false)
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() != ')' {
+		return nil, p.errorAt("missing closing parenthesis", len(p.pattern))
+	}
+	p.pos++
+	p.flags = flags
+	return alternatives, nil
+}
+
+// Parse inline options like "(?i)" or "(?i-s:...)"
+func (p *parser) parseInlineOptions() (node, error) {
+	flags := p.flags
+	negate := false
+	for !p.atEnd() {
+		char := p.peek()
+		p.pos++
+		switch char {
+		case '-':
+			negate = true
+		case 'i':
+			flags.CaseInsensitive = !negate
+		case 'm':
+			flags.Multiline = !negate
+		case 's':
+			flags.DotAll = !negate
+		case 'x':
+			flags.Extended = !negate
+		case 'n':
+			flags.NoAutoCapture = !negate
+		case 'U':
+			flags.Ungreedy = !negate
+		case 'J':
+			flags.DupNames = !negate
+		case ')':
+			// The options apply to the rest of the enclosing group
+			p.flags = flags
+			return nil, nil
+		case ':':
+			outerFlags := p.flags
+			p.flags = flags
+			child, err := p.parseGroupBody(false)
+			p.flags = outerFlags
+			return child, err
+		default:
+			return nil, p.errorAt("unrecognized character after (? or (?-", p.pos-1)
+		}
+	}
+	return nil, p.errorAt("missing closing parenthesis", len(p.pattern))
+}
+
+// Parse a group name up to the terminator
+func (p *parser) parseName(terminator byte) (string, error) {
+	start := p.pos
+	for !p.atEnd() {
+		r, size := utf8.DecodeRuneInString(p.pattern[p.pos:])
+		if !(r == '_' || (r >= '0' && r <= '9') || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (p.flags.UTF8 && isWordChar(r, true))) {
+			break
+		}
+		p.pos += size
+	}
+	name := p.pattern[start:p.pos]
+	switch {
+	case name == "":
+		return "", p.errorAt("subpattern name expected", p.pos)
+	case name[0] >= '0' && name[0] <= '9':
+		return "", p.errorAt("subpattern name must start with a non-digit", start)
+	case len(name) > maxNameLength:
+		return "", p.errorAt("subpattern name is too long (maximum 32 code units)", p.pos)
+	case p.peek() != terminator:
+		return "", p.errorAt("syntax error in subpattern name (missing terminator?)", p.pos)
+	}
+	p.pos++
+	return name, nil
+}
+
+func (p *parser) newBackref(index int, name string, offset int) *backrefNode {
+	backref := &backrefNode{indexes: []int{index}, name: name, offset: offset, fold: p.flags.CaseInsensitive}
+	p.backrefs = append(p.backrefs, backref)
+	return backref
+}
+
+func (p *parser) newSubroutine(index int, name string, offset int) *subroutineNode {
+	subroutine := &subroutineNode{index: index, name: name, offset: offset}
+	p.subroutines = append(p.subroutines, subroutine)
+	return subroutine
+}
+
+// Parse a conditional group like "(?(1)yes|no)", "(?(<name>)yes|no)", "(?(R)yes|no)" or "(?(?=...)yes|no)"
+func (p *parser) parseConditional(start int) (node, error) {
+	conditional := &conditionalNode{offset: p.pos}
+	p.pos++ // (
+
+	switch {
+	case p.hasPrefix("?=") || p.hasPrefix("?!") || p.hasPrefix("?<=") || p.hasPrefix("?<!"):
+		p.pos--
+		assertion, err := p.parseGroup()
+		if err != nil {
+			return nil, err
+		}
+		conditional.kind = conditionAssertion
+		conditional.assertion = assertion.(*lookaroundNode)
+	case p.hasPrefix("DEFINE)"):
+		p.pos += len("DEFINE)")
+		conditional.kind = conditionDefine
+	case p.hasPrefix("R&"):
+		p.pos += 2
+		name, err := p.parseName(')')
+		if err != nil {
+			return nil, err
+		}
+		conditional.kind = conditionRecursion
+		conditional.name = name
+		p.conditionals = append(p.conditionals, conditional)
+	case p.hasPrefix("R"):
+		p.pos++
+		conditional.kind = conditionRecursion
+		conditional.indexes = []int{-1}
+		if number, _, ok := p.parseGroupNumber(); ok {
+			conditional.indexes = []int{number}
+		}
+		if p.peek() != ')' {
+			return nil, p.errorAt("malformed number or name after (?(", p.pos)
+		}
+		p.pos++
+	default:
+		conditional.kind = conditionGroup
+		if number, relative, ok := p.parseGroupNumber(); ok {
+			index, err := p.resolveGroupNumber(number, relative, start)
+			if err != nil {
+				return nil, err
+			}
+			conditional.indexes = []int{index}
+			if p.peek() != ')' {
+				return nil, p.errorAt("malformed number or name after (?(", p.pos)
+			}
+			p.pos++
+		} else {
+			terminator := byte(')')
+			if p.peek() == '<' || p.peek() == '\'' {
+				terminator = '>'
+				if p.peek() == '\'' {
+					terminator = '\''
+				}
+				p.pos++
+			}
+			name, err := p.parseName(terminator)
+			if err != nil {
+				return nil, err
+			}
+			if terminator != ')' {
+				if p.peek() != ')' {
+					return nil, p.errorAt("malformed number or name after (?(", p.pos)
+				}
+				p.pos++
+			}
+			conditional.name = name
+		}
+		p.conditionals = append(p.conditionals, conditional)
+	}
+
+	alternatives, err := p.parseAlternativesInGroup()
+	if err != nil {
+		return nil, err
+	}
+	if conditional.kind == conditionDefine {
+		if len(alternatives) > 1 {
+			return nil, p.errorAt("DEFINE subpattern contains more than one branch", start)
+		}
+		// The group only defines groups for subroutine calls
+		return &emptyNode{}, nil
+	}
+	if len(alternatives) > 2 {
+		return nil, p.errorAt("conditional subpattern contains more than two branches", start)
+	}
+	conditional.yes = alternatives[0]
+	conditional.no = &emptyNode{}
+	if len(alternatives) == 2 {
+		conditional.no = alternatives[1]
+	}
+	return conditional, nil
+}
+
+// -------------------------------------- Character classes -------------------------------------- MARK: Character classes
+
+func (p *parser) parseClass() (node, error) {
+	p.pos++ // [
+	set := &charSet{fold: p.flags.CaseInsensitive, utf: p.flags.UTF8}
+	if p.peek() == '^' {
+		set.negated = true
+		p.pos++
+	}
+
+	for first := true; ; first = false {
+		if p.atEnd() {
+			return nil, p.errorAt("missing terminating ] for character class", len(p.pattern))
+		}
+		if p.peek() == ']' && !first {
+			p.pos++
+			break
+		}
+
+		// POSIX class like "[:alpha:]"
+		if p.hasPrefix("[:") || p.hasPrefix("[.") || p.hasPrefix("[=") {
+			if end := strings.Index(p.pattern[p.pos+2:], string(p.pattern[p.pos+1])+"]"); end >= 0 {
+				if p.pattern[p.pos+1] != ':' {
+					return nil, p.errorAt("POSIX collating elements are not supported", p.pos)
+				}
+				name := p.pattern[p.pos+2 : p.pos+2+end]
+				negated := strings.HasPrefix(name, "^")
+				class := posixClass(strings.TrimPrefix(name, "^"), p.flags.UTF8)
+				if class == nil {
+					return nil, p.errorAt("unknown POSIX class name", p.pos)
+				}
+				set.addClass(class, negated)
+				p.pos += end + 4
+				continue
+			}
+		}
+
+		from, isChar, err := p.parseClassAtom(set)
+		if err != nil {
+			return nil, err
+		}
+		if !isChar {
+			continue
+		}
+
+		// Range like "a-z"
+		if p.peek() == '-' && p.pos+1 < len(p.pattern) && p.pattern[p.pos+1] != ']' {
+			p.pos++
+			to, isChar, err := p.parseClassAtom(set)
+			if err != nil {
+				return nil, err
+			}
+			if !isChar {
+				return nil, p.errorAt("invalid range in character class", p.pos-1)
+			}
+			if to < from {
+				return nil, p.errorAt("range out of order in character class", p.pos-1)
+			}
+			set.ranges = append(set.ranges, charRange{from: from, to: to})
+			continue
+		}
+		set.addChar(from)
+	}
+	return &classNode{set: set}, nil
+}
+
+// Parse a character or an escape sequence in a character class.
+// Classes like "\d" are added to the set and false is returned.
+func (p *parser) parseClassAtom(set *charSet) (rune, bool, error) {
+	if p.peek() != '\\' {
+		return p.nextChar(), true, nil
+	}
+	escape, err := p.parseEscape(true)
+	if err != nil {
+		return 0, false, err
+	}
+	if escape.class != nil {
+		set.addClass(escape.class, escape.negated)
+		return 0, false, nil
+	}
+	if escape.char < 0 {
+		// "\E" and "\Q" without content
+		return 0, false, nil
+	}
+	return escape.char, true, nil
+}
+
+// -------------------------------------- Escape sequences -------------------------------------- MARK: Escape sequences
+
+type escape struct {
+	// Character of the escape sequence (-1 if the escape sequence is no character)
+	char rune
+	// Class of the escape sequence (e.g. "\d")
+	class   func(r rune) bool
+	negated bool
+	// Other nodes (e.g. "\b" or "\1")
+	node node
+}
+
+func (p *parser) parseEscapeNode() (node, error) {
+	escape, err := p.parseEscape(false)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case escape.node != nil:
+		return escape.node, nil
+	case escape.class != nil:
+		set := &charSet{utf: p.flags.UTF8}
+		set.addClass(escape.class, escape.negated)
+		return &classNode{set: set}, nil
+	case escape.char >= 0:
+		return p.literal(escape.char), nil
+	}
+	return nil, nil
+}
+
+// Spec: https://www.pcre.org/current/doc/html/pcre2pattern.html#SEC5
+func (p *parser) parseEscape(inClass bool) (escape, error) {
+	p.pos++ // \
+	if p.atEnd() {
+		return escape{}, p.errorAt("\\ at end of pattern", p.pos)
+	}
+	charEscape := func(r rune) (escape, error) { return escape{char: r}, nil }
+	nodeEscape := func(n node) (escape, error) {
+		if inClass {
+			return escape{}, p.errorAt("escape sequence is invalid in character class", p.pos-1)
+		}
+		return escape{char: -1, node: n}, nil
+	}
+
+	char := p.peek()
+	p.pos++
+	switch char {
+	case 'a':
+		return charEscape(7)
+	case 'e':
+		return charEscape(27)
+	case 'f':
+		return charEscape('\f')
+	case 'n':
+		return charEscape('\n')
+	case 'r':
+		return charEscape('\r')
+	case 't':
+		return charEscape('\t')
+	case '0':
+		p.pos--
+		return p.parseOctal(p.pos, 3)
+	case '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		start := p.pos - 1
+		end := start
+		for end < len(p.pattern) && p.pattern[end] >= '0' && p.pattern[end] <= '9' {
+			end++
+		}
+		number, err := strconv.Atoi(p.pattern[start:end])
+		if !inClass && (err != nil || number < 10 || number <= p.totalGroups) {
+			p.pos = end
+			if err != nil {
+				return escape{}, p.errorAt("number is too big", end)
+			}
+			return nodeEscape(p.newBackref(number, "", end))
+		}
+		if char >= '8' {
+			return charEscape(rune(char))
+		}
+		p.pos = start
+		return p.parseOctal(start, 3)
+	case 'o':
+		if p.peek() != '{' {
+			return escape{}, p.errorAt("missing opening brace after \\o", p.pos)
+		}
+		end := strings.IndexByte(p.pattern[p.pos:], '}')
+		if end < 0 {
+			return escape{}, p.errorAt("missing closing brace after \\o{", len(p.pattern))
+		}
+		value, err := strconv.ParseUint(p.pattern[p.pos+1:p.pos+end], 8, 32)
+		if err != nil {
+			return escape{}, p.errorAt("non-octal character in \\o{} (closing brace missing?)", p.pos+1)
+		}
+		p.pos += end + 1
+		return p.codePoint(value)
+	case 'x':
+		if p.peek() == '{' {
+			end := strings.IndexByte(p.pattern[p.pos:], '}')
+			if end < 0 {
+				return escape{}, p.errorAt("missing closing brace after \\x{", len(p.pattern))
+			}
+			value, err := strconv.ParseUint(p.pattern[p.pos+1:p.pos+end], 16, 32)
+			if err != nil {
+				return escape{}, p.errorAt("non-hex character in \\x{} (closing brace missing?)", p.pos+1)
+			}
+			p.pos += end + 1
+			return p.codePoint(value)
+		}
+		value := 0
+		for i := 0; i < 2 && isHexDigit(p.peek()); i++ {
+			digit, _ := strconv.ParseUint(string(p.peek()), 16, 8)
+			value = value*16 + int(digit)
+			p.pos++
+		}
+		return charEscape(rune(value))
+	case 'c':
+		if p.atEnd() {
+			return escape{}, p.errorAt("\\c at end of pattern", p.pos)
+		}
+		control := p.peek()
+		if control < 0x20 || control > 0x7E {
+			return escape{}, p.errorAt("\\c must be followed by a printable ASCII character", p.pos)
+		}
+		p.pos++
+		if control >= 'a' && control <= 'z' {
+			control -= 'a' - 'A'
+		}
+		return charEscape(rune(control ^ 0x40))
+	case 'd', 'D', 'w', 'W', 's', 'S', 'h', 'H', 'v', 'V':
+		class, negated := escapeClass(char, p.flags.UTF8)
+		return escape{char: -1, class: class, negated: negated}, nil
+	case 'N':
+		if inClass {
+			return escape{}, p.errorAt("escape sequence is invalid in character class", p.pos-1)
+		}
+		return escape{char: -1, class: func(r rune) bool { return r == '\n' }, negated: true}, nil
+	case 'p', 'P':
+		return p.parseProperty(char == 'P')
+	case 'b':
+		if inClass {
+			return charEscape('\b')
+		}
+		return nodeEscape(&assertionNode{kind: assertWordBoundary})
+	case 'B':
+		return nodeEscape(&assertionNode{kind: assertNotWordBoundary})
+	case 'A':
+		return nodeEscape(&assertionNode{kind: assertStartOfSubject})
+	case 'z':
+		return nodeEscape(&assertionNode{kind: assertEndOfSubject})
+	case 'Z':
+		return nodeEscape(&assertionNode{kind: assertEndOfSubjectOrNewline})
+	case 'G':
+		return nodeEscape(&assertionNode{kind: assertStartOfMatch})
+	case 'K':
+		return nodeEscape(&keepNode{})
+	case 'R':
+		return nodeEscape(&newlineNode{})
+	case 'X':
+		return nodeEscape(&graphemeNode{})
+	case 'g':
+		if inClass {
+			return escape{}, p.errorAt("escape sequence is invalid in character class", p.pos-1)
+		}
+		return p.parseGReference()
+	case 'k':
+		if inClass {
+			return escape{}, p.errorAt("escape sequence is invalid in character class", p.pos-1)
+		}
+		terminator := map[byte]byte{'<': '>', '\'': '\'', '{': '}'}[p.peek()]
+		if terminator == 0 {
+			return escape{}, p.errorAt("\\k is not followed by a braced, angle-bracketed, or quoted name", p.pos)
+		}
+		p.pos++
+		offset := p.pos
+		name, err := p.parseName(terminator)
+		if err != nil {
+			return escape{}, err
+		}
+		return nodeEscape(p.newBackref(0, name, offset))
+	case 'E', 'Q':
+		// "\E" without "\Q" (or "\Q" in a character class) is ignored
+		return escape{char: -1}, nil
+	case 'L', 'l', 'U', 'u':
+		return escape{}, p.errorAt("PCRE2 does not support \\F, \\L, \\l, \\N{name}, \\U, or \\u", p.pos-1)
+	}
+
+	if (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') || (char >= '0' && char <= '9') {
+		return escape{}, p.errorAt("unrecognized character follows \\", p.pos-1)
+	}
+	// Any other character is taken literally (e.g. "\." or "\/")
+	p.pos--
+	return charEscape(p.nextChar())
+}
+
+func isHexDigit(char byte) bool {
+	return (char >= '0' && char <= '9') || (char >= 'a' && char <= 'f') || (char >= 'A' && char <= 'F')
+}
+
+// Parse an octal escape with up to maxDigits digits
+func (p *parser) parseOctal(start int, maxDigits int) (escape, error) {
+	p.pos = start
+	value := uint64(0)
+	for i := 0; i < maxDigits && p.peek() >= '0' && p.peek() <= '7'; i++ {
+		value = value*8 + uint64(p.peek()-'0')
+		p.pos++
+	}
+	if !p.flags.UTF8 && value > 0xFF {
+		return escape{}, p.errorAt("octal value is greater than \\377 in 8-bit non-UTF-8 mode", p.pos)
+	}
+	return escape{char: rune(value)}, nil
+}
+
+// Check the code point of "\x{...}" or "\o{...}"
+func (p *parser) codePoint(value uint64) (escape, error) {
+	if (!p.flags.UTF8 && value > 0xFF) || value > utf8.MaxRune {
+		return escape{}, p.errorAt("character code point value in \\x{} or \\o{} is too large", p.pos-1)
+	}
+	if p.flags.UTF8 && value >= 0xD800 && value <= 0xDFFF {
+		return escape{}, p.errorAt("disallowed Unicode code point (>= 0xd800 && <= 0xdfff)", p.pos-1)
+	}
+	return escape{char: rune(value)}, nil
+}
+
+// Parse a Unicode property like "\pL", "\p{Lu}" or "\P{^Greek}"
+func (p *parser) parseProperty(negated bool) (escape, error) {
+	var name string
+	if p.peek() == '{' {
+		end := strings.IndexByte(p.pattern[p.pos:], '}')
+		if end < 0 {
+			return escape{}, p.errorAt("malformed \\P or \\p sequence", p.pos)
+		}
+		name = p.pattern[p.pos+1 : p.pos+end]
+		p.pos += end + 1
+	} else if !p.atEnd() {
+		name = string(p.peek())
+		p.pos++
+	}
+	if strings.HasPrefix(name, "^") {
+		negated = !negated
+		name = name[1:]
+	}
+	class := unicodeProperty(name)
+	if class == nil {
+		return escape{}, p.errorAt("unknown property after \\P or \\p", p.pos)
+	}
+	return escape{char: -1, class: class, negated: negated}, nil
+}
+
+// Parse "\g" references: Backreferences "\g1", "\g{-1}", "\g{name}" and subroutine calls "\g<1>", "\g'name'"
+func (p *parser) parseGReference() (escape, error) {
+	offset := p.pos
+	var terminator byte
+	switch p.peek() {
+	case '{':
+		terminator = '}'
+	case '<':
+		terminator = '>'
+	case '\'':
+		terminator = '\''
+	}
+	if terminator != 0 {
+		p.pos++
+	}
+
+	var result node
+	if number, relative, ok := p.parseGroupNumber(); ok {
+		if relative == '+' && terminator == '}' || relative == 0 && number == 0 {
+			return escape{}, p.errorAt("a numbered reference must not be zero", p.pos)
+		}
+		index, err := p.resolveGroupNumber(number, relative, offset)
+		if err != nil {
+			return escape{}, err
+		}
+		if terminator != 0 {
+			if p.peek() != terminator {
+				return escape{}, p.errorAt("\\g is not followed by a braced, angle-bracketed, or quoted name/number or by a plain number", p.pos)
+			}
+			p.pos++
+		}
+		if terminator == '>' || terminator == '\'' {
+			result = p.newSubroutine(index, "", offset)
+		} else {
+			result = p.newBackref(index, "", offset)
+		}
+	} else {
+		if terminator == 0 {
+			return escape{}, p.errorAt("\\g is not followed by a braced, angle-bracketed, or quoted name/number or by a plain number", p.pos)
+		}
+		name, err := p.parseName(terminator)
+		if err != nil {
+			return escape{}, err
+		}
+		if terminator == '}' {
+			result = p.newBackref(0, name, offset)
+		} else {
+			result = p.newSubroutine(0, name, offset)
+		}
+	}
+	return escape{char: -1, node: result}, nil
+}
@@ -0,0 +1,196 @@
+// Package regex implements a backtracking regular expression engine with the syntax and semantics of PCRE2.
+// Unlike Go's regexp package it supports backreferences, lookaround assertions, atomic groups,
+// possessive quantifiers, recursion and conditional groups as used by PHP's preg_* functions.
+package regex
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Spec: https://www.pcre.org/current/doc/html/pcre2pattern.html
+
+var (
+	ErrBacktrackLimit = errors.New("backtrack limit exhausted")
+	ErrRecursionLimit = errors.New("recursion limit exhausted")
+)
+
+type Flags struct {
+	// i: Letters match both upper and lower case letters
+	CaseInsensitive bool
+	// m: "^" and "$" match at internal newlines
+	Multiline bool
+	// s: "." matches newlines
+	DotAll bool
+	// x: Whitespace and "#" comments in the pattern are ignored
+	Extended bool
+	// u: Pattern and subject are treated as UTF-8 (including Unicode properties for \d, \w, ...)
+	UTF8 bool
+	// U: Quantifiers are lazy by default
+	Ungreedy bool
+	// D: "$" only matches at the very end of the subject
+	DollarEndOnly bool
+	// A: The pattern only matches at the start offset
+	Anchored bool
+	// J: Names of capture groups do not need to be unique
+	DupNames bool
+	// n: Plain parentheses do not capture
+	NoAutoCapture bool
+}
+
+type CompileError struct {
+	Message string
+	Offset  int
+}
+
+func (err *CompileError) Error() string {
+	return fmt.Sprintf("%s at offset %d", err.Message, err.Offset)
+}
+
+type Regex struct {
+	root  node
+	flags Flags
+	// Capture groups by their number (index 0 is the whole pattern)
+	groups     []*groupNode
+	groupNames []string
+	// Literal every match starts with (used to skip impossible start positions)
+	prefix string
+	// The pattern can only match at the start offset (e.g. "^abc" or "\Gabc")
+	anchored bool
+}
+
+type MatchOptions struct {
+	// An empty string at the start offset is not a valid match
+	NotEmptyAtStart bool
+	// The pattern only matches at the start offset
+	Anchored bool
+	// Maximum number of backtracking steps (0 = unlimited)
+	BacktrackLimit int
+	// Maximum nesting depth of groups and repetitions (0 = unlimited)
+	RecursionLimit int
+}
+
+// Compile parses the pattern (without delimiters and modifiers).
+func Compile(pattern string, flags Flags) (*Regex, error) {
+	if flags.UTF8 && !utf8.ValidString(pattern) {
+		offset := 0
+		for offset < len(pattern) {
+			r, size := utf8.DecodeRuneInString(pattern[offset:])
+			if r == utf8.RuneError && size <= 1 {
+				break
+			}
+			offset += size
+		}
+		message := "UTF-8 error: byte 2 top bits not 0x80"
+		if pattern[offset] >= 0xFE {
+			message = "UTF-8 error: illegal byte (0xfe or 0xff)"
+		} else if pattern[offset]&0xC0 == 0x80 {
+			message = "UTF-8 error: isolated byte with 0x80 bit set"
+		} else if !utf8.FullRuneInString(pattern[offset:]) {
+			message = "UTF-8 error: 1 byte missing at end"
+		}
+		return nil, &CompileError{Message: message, Offset: offset}
+	}
+
+	p := newParser(pattern, flags)
+	root, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+
+	regex := &Regex{root: root, flags: flags, groups: p.groups, groupNames: p.groupNames}
+	regex.groups[0] = &groupNode{index: 0, child: root}
+	regex.prefix, regex.anchored = analyzeStart(root)
+	return regex, nil
+}
+
+// GroupCount returns the number of capture groups.
+func (regex *Regex) GroupCount() int {
+	return len(regex.groups) - 1
+}
+
+// GroupNames returns the name of each capture group by its number ("" for unnamed groups).
+func (regex *Regex) GroupNames() []string {
+	return regex.groupNames
+}
+
+// IsUTF8 returns if the pattern was compiled with the "u" modifier.
+func (regex *Regex) IsUTF8() bool {
+	return regex.flags.UTF8
+}
+
+// Match searches the subject starting at the offset.
+// It returns the start and end offsets of the match and of each capture group (-1 for unset groups)
+// or nil if the pattern does not match.
+// In UTF-8 mode the subject must be valid UTF-8 and the offset must be at the start of a character.
+func (regex *Regex) Match(subject string, offset int, options MatchOptions) ([]int, error) {
+	m := &matcher{
+		regex:          regex,
+		subject:        subject,
+		caps:           make([]int, 2*len(regex.groups)),
+		searchStart:    offset,
+		backtrackLimit: options.BacktrackLimit,
+		recursionLimit: options.RecursionLimit,
+	}
+	anchored := options.Anchored || regex.flags.Anchored || regex.anchored
+
+	for start := offset; start <= len(subject); {
+		if regex.prefix != "" && !anchored {
+			index := strings.Index(subject[start:], regex.prefix)
+			if index < 0 {
+				return nil, nil
+			}
+			start += index
+		}
+
+		for i := range m.caps {
+			m.caps[i] = -1
+		}
+		m.keep = -1
+		m.matchStart = start
+		matched := regex.root.match(m, start, func(pos int) bool {
+			reportedStart := start
+			if m.keep >= 0 {
+				reportedStart = m.keep
+			}
+			if options.NotEmptyAtStart && pos == offset && reportedStart == offset {
+				return false
+			}
+			m.caps[0], m.caps[1] = reportedStart, pos
+			return true
+		})
+		if m.err != nil {
+			return nil, m.err
+		}
+		if matched {
+			return m.caps, nil
+		}
+		if anchored || start >= len(subject) {
+			break
+		}
+		start += m.charLen(start)
+	}
+	return nil, nil
+}
+
+// Determine the literal prefix of all matches and if the pattern is anchored at the start offset
+func analyzeStart(root node) (string, bool) {
+	first := root
+	if seq, ok := root.(*sequenceNode); ok && len(seq.nodes) > 0 {
+		first = seq.nodes[0]
+	}
+
+	switch n := first.(type) {
+	case *assertionNode:
+		if n.kind == assertStartOfSubject || n.kind == assertStartOfMatch || (n.kind == assertStartOfLine && !n.multiline) {
+			return "", true
+		}
+	case *literalNode:
+		if !n.fold {
+			return n.str, false
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,185 @@
+package regex
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func parseFlags(modifiers string) Flags {
+	return Flags{
+		CaseInsensitive: strings.Contains(modifiers, "i"),
+		Multiline:       strings.Contains(modifiers, "m"),
+		DotAll:          strings.Contains(modifiers, "s"),
+		Extended:        strings.Contains(modifiers, "x"),
+		UTF8:            strings.Contains(modifiers, "u"),
+		Ungreedy:        strings.Contains(modifiers, "U"),
+		DollarEndOnly:   strings.Contains(modifiers, "D"),
+		Anchored:        strings.Contains(modifiers, "A"),
+		DupNames:        strings.Contains(modifiers, "J"),
+		NoAutoCapture:   strings.Contains(modifiers, "n"),
+	}
+}
+
+func TestMatch(t *testing.T) {
+	// Expected are the matched strings of the whole pattern and of the groups ("<unset>" for unset groups)
+	doTest := func(t *testing.T, pattern string, modifiers string, subject string, expected ...string) {
+		regex, err := Compile(pattern, parseFlags(modifiers))
+		if err != nil {
+			t.Errorf("\nPattern: %s\nUnexpected error: %s", pattern, err)
+			return
+		}
+		offsets, err := regex.Match(subject, 0, MatchOptions{})
+		if err != nil {
+			t.Errorf("\nPattern: %s\nUnexpected error: %s", pattern, err)
+			return
+		}
+		got := []string{}
+		for i := 0; i < len(offsets); i += 2 {
+			if offsets[i] < 0 {
+				got = append(got, "<unset>")
+			} else {
+				got = append(got, subject[offsets[i]:offsets[i+1]])
+			}
+		}
+		if !slices.Equal(got, expected) {
+			t.Errorf("\nPattern: %s\nSubject: %q\nExpected: %q\nGot: %q", pattern, subject, expected, got)
+		}
+	}
+
+	// Literals and classes
+	doTest(t, `abc`, "", "xxabcxx", "abc")
+	doTest(t, `abc`, "", "ab")
+	doTest(t, `a.c`, "", "a\nc abc", "abc")
+	doTest(t, `a.c`, "s", "a\nc", "a\nc")
+	doTest(t, `[a-c]+`, "", "xxbcaz", "bca")
+	doTest(t, `[^a-c\d]+`, "", "ab12xyz", "xyz")
+	doTest(t, `[]a]+`, "", "x]a]", "]a]")
+	doTest(t, `[[:alpha:]]+`, "", "12ab34", "ab")
+	doTest(t, `\d+\s\w+`, "", "abc 123 def", "123 def")
+	doTest(t, `ABC`, "i", "xabcx", "abc")
+	doTest(t, `[a-z]+`, "i", "ABC", "ABC")
+	doTest(t, `\x41\101\x{42}`, "", "AAB", "AAB")
+	doTest(t, `a\Q.*\E+`, "", "a.***", "a.***")
+
+	// Quantifiers
+	doTest(t, `a{2,3}`, "", "aaaa", "aaa")
+	doTest(t, `a{2,}?`, "", "aaaa", "aa")
+	doTest(t, `a{,2}`, "", "a{,2}", "a{,2}")
+	doTest(t, `<.+>`, "", "<a><b>", "<a><b>")
+	doTest(t, `<.+?>`, "", "<a><b>", "<a>")
+	doTest(t, `<.+>`, "U", "<a><b>", "<a>")
+	doTest(t, `a++a`, "", "aaa")
+	doTest(t, `(?:ab)*c`, "", "ababc", "ababc")
+	doTest(t, `(a|)*b`, "", "aab", "aab", "")
+
+	// Groups and alternation
+	doTest(t, `(\w+)@(\w+)\.com`, "", "mail: user@example.com", "user@example.com", "user", "example")
+	doTest(t, `(a)|(b)`, "", "b", "b", "<unset>", "b")
+	doTest(t, `(?<year>\d{4})-(?<month>\d{2})`, "", "2024-05", "2024-05", "2024", "05")
+	doTest(t, `(?|(a)|(b))c`, "", "bc", "bc", "b")
+	doTest(t, `(a)(?:b)(c)`, "", "abc", "abc", "a", "c")
+	doTest(t, `(a)(b)`, "n", "ab", "ab")
+	doTest(t, `(?i)abc|DEF`, "", "def", "def")
+	doTest(t, `a(?i:b)c`, "", "aBc aBC", "aBc")
+
+	// Anchors and assertions
+	doTest(t, `^b`, "", "a\nb")
+	doTest(t, `^b`, "m", "a\nb", "b")
+	doTest(t, `a$`, "", "a\n", "a")
+	doTest(t, `a$`, "D", "a\n")
+	doTest(t, `\bcat\b`, "", "concat cat", "cat")
+	doTest(t, `\Bcat`, "", "cat concat", "cat")
+	doTest(t, `foo(?=bar)`, "", "foobaz foobar", "foo")
+	doTest(t, `foo(?!bar)`, "", "foobar foobaz", "foo")
+	doTest(t, `(?<=\$)\d+`, "", "EUR 5, $10", "10")
+	doTest(t, `(?<!\$)\b\d+`, "", "$10 20", "20")
+	doTest(t, `(?<=ab|c)d`, "", "abd", "d")
+	doTest(t, `foo\Kbar`, "", "foobar", "bar")
+
+	// Backreferences
+	doTest(t, `(\w)\1`, "", "abccd", "cc", "c")
+	doTest(t, `(?<q>["']).*?\k<q>`, "", `say "hi" 'x'`, `"hi"`, `"`)
+	doTest(t, `(a)\g{-1}`, "", "aa", "aa", "a")
+	doTest(t, `(?i)(a)\1`, "", "aA", "aA", "a")
+	doTest(t, `(?P<x>a)(?P=x)`, "", "aa", "aa", "a")
+
+	// Atomic groups, recursion and conditionals
+	doTest(t, `(?>a+)b`, "", "aaab", "aaab")
+	doTest(t, `(?>a+)a`, "", "aaaa")
+	doTest(t, `\((?:[^()]++|(?R))*\)`, "", "x(a(b)c)y", "(a(b)c)")
+	doTest(t, `(\d)(?1)`, "", "12", "12", "1")
+	doTest(t, `(<)?a(?(1)>)`, "", "<a>", "<a>", "<")
+	doTest(t, `(?(?=\d)\d+|[a-z]+)`, "", "abc", "abc")
+	doTest(t, `(?(DEFINE)(?<byte>\d{1,3}))(?&byte)\.(?&byte)`, "", "10.20", "10.20", "<unset>")
+
+	// UTF-8
+	doTest(t, `.`, "u", "äb", "ä")
+	doTest(t, `.`, "", "äb", "\xc3")
+	doTest(t, `\w+`, "u", "größe", "größe")
+	doTest(t, `\w+`, "", "größe", "gr")
+	doTest(t, `Ä`, "iu", "ä", "ä")
+	doTest(t, `\p{Greek}+`, "u", "abc αβγ", "αβγ")
+	doTest(t, `\p{Lu}`, "u", "abcÄ", "Ä")
+	doTest(t, `[ä-ü]+`, "u", "aöb", "ö")
+
+	// Extended mode
+	doTest(t, "a b # comment\n c", "x", "abc", "abc")
+}
+
+func TestCompileError(t *testing.T) {
+	doTest := func(t *testing.T, pattern string, modifiers string, expected string) {
+		_, err := Compile(pattern, parseFlags(modifiers))
+		if err == nil {
+			t.Errorf("\nPattern: %s\nExpected error: %s", pattern, expected)
+			return
+		}
+		if err.Error() != expected {
+			t.Errorf("\nPattern: %s\nExpected: %s\nGot: %s", pattern, expected, err)
+		}
+	}
+
+	doTest(t, `(abc`, "", "missing closing parenthesis at offset 4")
+	doTest(t, `abc)`, "", "unmatched closing parenthesis at offset 3")
+	doTest(t, `*a`, "", "quantifier does not follow a repeatable item at offset 0")
+	doTest(t, `[abc`, "", "missing terminating ] for character class at offset 4")
+	doTest(t, `[z-a]`, "", "range out of order in character class at offset 3")
+	doTest(t, `a{3,1}`, "", "numbers out of order in {} quantifier at offset 5")
+	doTest(t, `(?<=a+)b`, "", "lookbehind assertion is not fixed length at offset 0")
+	doTest(t, `(a)\2`, "", "reference to non-existent subpattern at offset 5")
+	doTest(t, `\i`, "", "unrecognized character follows \\ at offset 1")
+	doTest(t, `(?<n>a)(?<n>b)`, "", "two named subpatterns have the same name (PCRE2_DUPNAMES not set) at offset 7")
+	doTest(t, `[[:foo:]]`, "", "unknown POSIX class name at offset 1")
+	doTest(t, `\p{Foo}`, "u", "unknown property after \\P or \\p at offset 7")
+	doTest(t, "a\x80", "u", "UTF-8 error: isolated byte with 0x80 bit set at offset 1")
+	doTest(t, "\xff", "u", "UTF-8 error: illegal byte (0xfe or 0xff) at offset 0")
+}
+
+func TestMatchOptions(t *testing.T) {
+	regex, _ := Compile(`a*`, Flags{})
+	if offsets, _ := regex.Match("baa", 0, MatchOptions{NotEmptyAtStart: true, Anchored: true}); offsets != nil {
+		t.Errorf("Expected no match, got %v", offsets)
+	}
+	if offsets, _ := regex.Match("baa", 1, MatchOptions{}); !slices.Equal(offsets, []int{1, 3}) {
+		t.Errorf("Expected [1 3], got %v", offsets)
+	}
+
+	regex, _ = Compile(`\Ga`, Flags{})
+	if offsets, _ := regex.Match("aab", 1, MatchOptions{}); !slices.Equal(offsets, []int{1, 2}) {
+		t.Errorf("Expected [1 2], got %v", offsets)
+	}
+	if offsets, _ := regex.Match("aba", 1, MatchOptions{}); offsets != nil {
+		t.Errorf("Expected no match, got %v", offsets)
+	}
+
+	// Catastrophic backtracking
+	regex, _ = Compile(`(a+)+b`, Flags{})
+	if _, err := regex.Match(strings.Repeat("a", 30), 0, MatchOptions{BacktrackLimit: 1000000}); err != ErrBacktrackLimit {
+		t.Errorf("Expected backtrack limit error, got %v", err)
+	}
+
+	regex, _ = Compile(`(?:a|b)*c`, Flags{})
+	if _, err := regex.Match(strings.Repeat("a", 1000), 0, MatchOptions{RecursionLimit: 100}); err != ErrRecursionLimit {
+		t.Errorf("Expected recursion limit error, got %v", err)
+	}
+}
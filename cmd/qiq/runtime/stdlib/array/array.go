@@ -1,278 +1,2416 @@
 package array
 
 import (
+	"QIQ/cmd/qiq/common"
 	"QIQ/cmd/qiq/phpError"
 	"QIQ/cmd/qiq/runtime"
 	"QIQ/cmd/qiq/runtime/funcParamValidator"
+	"QIQ/cmd/qiq/runtime/stdlib/variableHandling"
 	"QIQ/cmd/qiq/runtime/values"
+	"math"
+	"math/rand/v2"
 	"slices"
+	"strconv"
 )
 
 func Register(environment runtime.Environment) {
 	// Category: Array Functions
+	environment.AddNativeFunction("array_all", nativeFn_array_all)
+	environment.AddNativeFunction("array_any", nativeFn_array_any)
+	environment.AddNativeFunction("array_change_key_case", nativeFn_array_change_key_case)
+	environment.AddNativeFunction("array_chunk", nativeFn_array_chunk)
+	environment.AddNativeFunction("array_column", nativeFn_array_column)
+	environment.AddNativeFunction("array_combine", nativeFn_array_combine)
+	environment.AddNativeFunction("array_count_values", nativeFn_array_count_values)
+	environment.AddNativeFunction("array_diff", nativeFn_array_diff)
+	environment.AddNativeFunction("array_diff_assoc", nativeFn_array_diff_assoc)
+	environment.AddNativeFunction("array_diff_key", nativeFn_array_diff_key)
+	environment.AddNativeFunction("array_diff_uassoc", nativeFn_array_diff_uassoc)
+	environment.AddNativeFunction("array_diff_ukey", nativeFn_array_diff_ukey)
+	environment.AddNativeFunction("array_fill", nativeFn_array_fill)
+	environment.AddNativeFunction("array_fill_keys", nativeFn_array_fill_keys)
+	environment.AddNativeFunction("array_filter", nativeFn_array_filter)
+	environment.AddNativeFunction("array_find", nativeFn_array_find)
+	environment.AddNativeFunction("array_find_key", nativeFn_array_find_key)
 	environment.AddNativeFunction("array_first", nativeFn_array_first)
+	environment.AddNativeFunction("array_flip", nativeFn_array_flip)
+	environment.AddNativeFunction("array_intersect", nativeFn_array_intersect)
+	environment.AddNativeFunction("array_intersect_assoc", nativeFn_array_intersect_assoc)
+	environment.AddNativeFunction("array_intersect_key", nativeFn_array_intersect_key)
+	environment.AddNativeFunction("array_intersect_uassoc", nativeFn_array_intersect_uassoc)
+	environment.AddNativeFunction("array_intersect_ukey", nativeFn_array_intersect_ukey)
+	environment.AddNativeFunction("array_is_list", nativeFn_array_is_list)
 	environment.AddNativeFunction("array_key_exists", nativeFn_array_key_exists)
 	environment.AddNativeFunction("array_key_first", nativeFn_array_key_first)
 	environment.AddNativeFunction("array_key_last", nativeFn_array_key_last)
+	environment.AddNativeFunction("array_keys", nativeFn_array_keys)
 	environment.AddNativeFunction("array_last", nativeFn_array_last)
-	environment.AddNativeFunction("array_pop", nativeFn_array_pop)
-	environment.AddNativeFunction("array_push", nativeFn_array_push)
+	environment.AddNativeFunction("array_map", nativeFn_array_map)
+	environment.AddNativeFunction("array_merge", nativeFn_array_merge)
+	environment.AddNativeFunction("array_merge_recursive", nativeFn_array_merge_recursive)
+	environment.AddNativeFunction("array_multisort", nativeFn_array_multisort, 0, runtime.VariadicByRef)
+	environment.AddNativeFunction("array_pad", nativeFn_array_pad)
+	environment.AddNativeFunction("array_pop", nativeFn_array_pop, 0)
+	environment.AddNativeFunction("array_product", nativeFn_array_product)
+	environment.AddNativeFunction("array_push", nativeFn_array_push, 0)
+	environment.AddNativeFunction("array_rand", nativeFn_array_rand)
+	environment.AddNativeFunction("array_reduce", nativeFn_array_reduce)
+	environment.AddNativeFunction("array_replace", nativeFn_array_replace)
+	environment.AddNativeFunction("array_replace_recursive", nativeFn_array_replace_recursive)
+	environment.AddNativeFunction("array_reverse", nativeFn_array_reverse)
+	environment.AddNativeFunction("array_search", nativeFn_array_search)
+	environment.AddNativeFunction("array_shift", nativeFn_array_shift, 0)
+	environment.AddNativeFunction("array_slice", nativeFn_array_slice)
+	environment.AddNativeFunction("array_splice", nativeFn_array_splice, 0)
+	environment.AddNativeFunction("array_sum", nativeFn_array_sum)
+	environment.AddNativeFunction("array_udiff", nativeFn_array_udiff)
+	environment.AddNativeFunction("array_udiff_assoc", nativeFn_array_udiff_assoc)
+	environment.AddNativeFunction("array_udiff_uassoc", nativeFn_array_udiff_uassoc)
+	environment.AddNativeFunction("array_uintersect", nativeFn_array_uintersect)
+	environment.AddNativeFunction("array_uintersect_assoc", nativeFn_array_uintersect_assoc)
+	environment.AddNativeFunction("array_uintersect_uassoc", nativeFn_array_uintersect_uassoc)
+	environment.AddNativeFunction("array_unique", nativeFn_array_unique)
+	environment.AddNativeFunction("array_unshift", nativeFn_array_unshift, 0)
+	environment.AddNativeFunction("array_values", nativeFn_array_values)
+	environment.AddNativeFunction("array_walk", nativeFn_array_walk)
+	environment.AddNativeFunction("array_walk_recursive", nativeFn_array_walk_recursive)
+	environment.AddNativeFunction("arsort", nativeFn_arsort, 0)
+	environment.AddNativeFunction("asort", nativeFn_asort, 0)
+	environment.AddNativeFunction("compact", nativeFn_compact)
 	environment.AddNativeFunction("count", nativeFn_count)
+	environment.AddNativeFunction("current", nativeFn_current)
+	environment.AddNativeFunction("end", nativeFn_end, 0)
+	environment.AddNativeFunction("extract", nativeFn_extract)
+	environment.AddNativeFunction("in_array", nativeFn_in_array)
+	environment.AddNativeFunction("key", nativeFn_key)
+	environment.AddNativeFunction("krsort", nativeFn_krsort, 0)
+	environment.AddNativeFunction("ksort", nativeFn_ksort, 0)
+	environment.AddNativeFunction("natcasesort", nativeFn_natcasesort, 0)
+	environment.AddNativeFunction("natsort", nativeFn_natsort, 0)
 	environment.AddNativeFunction("key_exists", nativeFn_array_key_exists)
+	environment.AddNativeFunction("next", nativeFn_next, 0)
+	environment.AddNativeFunction("pos", nativeFn_current)
+	environment.AddNativeFunction("prev", nativeFn_prev, 0)
+	environment.AddNativeFunction("range", nativeFn_range)
+	environment.AddNativeFunction("reset", nativeFn_reset, 0)
+	environment.AddNativeFunction("rsort", nativeFn_rsort, 0)
+	environment.AddNativeFunction("shuffle", nativeFn_shuffle, 0)
+	environment.AddNativeFunction("sizeof", nativeFn_count)
+	environment.AddNativeFunction("sort", nativeFn_sort, 0)
+	environment.AddNativeFunction("uasort", nativeFn_uasort, 0)
+	environment.AddNativeFunction("uksort", nativeFn_uksort, 0)
+	environment.AddNativeFunction("usort", nativeFn_usort, 0)
+
+	// Const Category: Array Constants
+	// Spec: https://www.php.net/manual/en/array.constants.php
+	environment.AddPredefinedConstant("ARRAY_FILTER_USE_BOTH", values.NewInt(ARRAY_FILTER_USE_BOTH))
+	environment.AddPredefinedConstant("ARRAY_FILTER_USE_KEY", values.NewInt(ARRAY_FILTER_USE_KEY))
+	environment.AddPredefinedConstant("CASE_LOWER", values.NewInt(CASE_LOWER))
+	environment.AddPredefinedConstant("CASE_UPPER", values.NewInt(CASE_UPPER))
+	environment.AddPredefinedConstant("COUNT_NORMAL", values.NewInt(COUNT_NORMAL))
+	environment.AddPredefinedConstant("COUNT_RECURSIVE", values.NewInt(COUNT_RECURSIVE))
+	environment.AddPredefinedConstant("EXTR_OVERWRITE", values.NewInt(EXTR_OVERWRITE))
+	environment.AddPredefinedConstant("EXTR_SKIP", values.NewInt(EXTR_SKIP))
+	environment.AddPredefinedConstant("EXTR_PREFIX_SAME", values.NewInt(EXTR_PREFIX_SAME))
+	environment.AddPredefinedConstant("EXTR_PREFIX_ALL", values.NewInt(EXTR_PREFIX_ALL))
+	environment.AddPredefinedConstant("EXTR_PREFIX_INVALID", values.NewInt(EXTR_PREFIX_INVALID))
+	environment.AddPredefinedConstant("EXTR_PREFIX_IF_EXISTS", values.NewInt(EXTR_PREFIX_IF_EXISTS))
+	environment.AddPredefinedConstant("EXTR_IF_EXISTS", values.NewInt(EXTR_IF_EXISTS))
+	environment.AddPredefinedConstant("EXTR_REFS", values.NewInt(EXTR_REFS))
+	environment.AddPredefinedConstant("SORT_ASC", values.NewInt(SORT_ASC))
+	environment.AddPredefinedConstant("SORT_DESC", values.NewInt(SORT_DESC))
+	environment.AddPredefinedConstant("SORT_REGULAR", values.NewInt(SORT_REGULAR))
+	environment.AddPredefinedConstant("SORT_NUMERIC", values.NewInt(SORT_NUMERIC))
+	environment.AddPredefinedConstant("SORT_STRING", values.NewInt(SORT_STRING))
+	environment.AddPredefinedConstant("SORT_LOCALE_STRING", values.NewInt(SORT_LOCALE_STRING))
+	environment.AddPredefinedConstant("SORT_NATURAL", values.NewInt(SORT_NATURAL))
+	environment.AddPredefinedConstant("SORT_FLAG_CASE", values.NewInt(SORT_FLAG_CASE))
+}
+
+const (
+	ARRAY_FILTER_USE_BOTH int64 = 1
+	ARRAY_FILTER_USE_KEY  int64 = 2
+	CASE_LOWER            int64 = 0
+	CASE_UPPER            int64 = 1
+	COUNT_NORMAL          int64 = 0
+	COUNT_RECURSIVE       int64 = 1
+	EXTR_OVERWRITE        int64 = 0
+	EXTR_SKIP             int64 = 1
+	EXTR_PREFIX_SAME      int64 = 2
+	EXTR_PREFIX_ALL       int64 = 3
+	EXTR_PREFIX_INVALID   int64 = 4
+	EXTR_PREFIX_IF_EXISTS int64 = 5
+	EXTR_IF_EXISTS        int64 = 6
+	EXTR_REFS             int64 = 256
+	SORT_ASC              int64 = 4
+	SORT_DESC             int64 = 3
+	SORT_REGULAR          int64 = 0
+	SORT_NUMERIC          int64 = 1
+	SORT_STRING           int64 = 2
+	SORT_LOCALE_STRING    int64 = 5
+	SORT_NATURAL          int64 = 6
+	SORT_FLAG_CASE        int64 = 8
+)
+
+// -------------------------------------- array_all -------------------------------------- MARK: array_all
+
+func nativeFn_array_all(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.array-all.php
+	args, err := funcParamValidator.NewValidator("array_all").
+		AddParam("$array", []string{"array"}, nil).
+		AddParam("$callback", []string{"mixed"}, nil).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+	if err := variableHandling.ValidateCallback("array_all", 2, "$callback", args[1], false, context); err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Returns true, if callback returns true for all elements. Otherwise false.
+	key, _, err := find(args[0].(*values.Array), args[1], true, context)
+	return values.NewBool(key == nil), err
+}
+
+// Find the first element for which the callback returns true (or false if negate is set)
+func find(array *values.Array, callback values.RuntimeValue, negate bool, context runtime.Context) (values.RuntimeValue, values.RuntimeValue, phpError.Error) {
+	for key, value := range array.Iterate() {
+		result, err := callPredicate(callback, []values.RuntimeValue{value, key}, context)
+		if err != nil {
+			return nil, nil, err
+		}
+		if result != negate {
+			return key, value, nil
+		}
+	}
+	return nil, nil, nil
+}
+
+// -------------------------------------- array_any -------------------------------------- MARK: array_any
+
+func nativeFn_array_any(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.array-any.php
+	args, err := funcParamValidator.NewValidator("array_any").
+		AddParam("$array", []string{"array"}, nil).
+		AddParam("$callback", []string{"mixed"}, nil).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+	if err := variableHandling.ValidateCallback("array_any", 2, "$callback", args[1], false, context); err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Returns true if there is at least one element for which callback returns true. Otherwise returns false.
+	key, _, err := find(args[0].(*values.Array), args[1], false, context)
+	return values.NewBool(key != nil), err
+}
+
+// -------------------------------------- array_change_key_case -------------------------------------- MARK: array_change_key_case
+
+func nativeFn_array_change_key_case(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.array-change-key-case.php
+	args, err := funcParamValidator.NewValidator("array_change_key_case").
+		AddParam("$array", []string{"array"}, nil).
+		AddParam("$case", []string{"int"}, values.NewInt(CASE_LOWER)).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Returns an array with all keys from array lowercased or uppercased. Numbered indices are left as is.
+	upper := args[1].(*values.Int).Value != CASE_LOWER
+	result := values.NewArray()
+	for key, value := range args[0].(*values.Array).Iterate() {
+		if key.GetType() == values.StrValue {
+			key = values.NewStr(changeAsciiCase(key.(*values.Str).Value, upper))
+		}
+		result.SetElement(key, value)
+	}
+	return result, nil
+}
+
+// -------------------------------------- array_chunk -------------------------------------- MARK: array_chunk
+
+func nativeFn_array_chunk(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.array-chunk.php
+	args, err := funcParamValidator.NewValidator("array_chunk").
+		AddParam("$array", []string{"array"}, nil).
+		AddParam("$length", []string{"int"}, nil).
+		AddParam("$preserve_keys", []string{"bool"}, values.NewBool(false)).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Chunks an array into arrays with length elements. The last chunk may contain less than length elements.
+	length := args[1].(*values.Int).Value
+	if length < 1 {
+		return values.NewVoid(), phpError.NewError("Uncaught ValueError: array_chunk(): Argument #2 ($length) must be greater than 0")
+	}
+	preserveKeys := args[2].(*values.Bool).Value
+
+	result := values.NewArray()
+	chunk := values.NewArray()
+	for key, value := range args[0].(*values.Array).Iterate() {
+		if !preserveKeys {
+			key = nil
+		}
+		chunk.SetElement(key, value)
+		if int64(chunk.Count()) == length {
+			result.SetElement(nil, chunk)
+			chunk = values.NewArray()
+		}
+	}
+	if !chunk.IsEmpty() {
+		result.SetElement(nil, chunk)
+	}
+	return result, nil
+}
+
+// -------------------------------------- array_column -------------------------------------- MARK: array_column
+
+func nativeFn_array_column(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.array-column.php
+	args, err := funcParamValidator.NewValidator("array_column").
+		AddParam("$array", []string{"array"}, nil).
+		AddParam("$column_key", []string{"int", "string", "null"}, nil).
+		AddParam("$index_key", []string{"int", "string", "null"}, values.NewNull()).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Returns the values from a single column of the array, identified by the column_key.
+	// Optionally, an index_key may be provided to index the values in the returned array
+	// by the values from the index_key column of the input array.
+	columnKey := args[1]
+	indexKey := args[2]
+	result := values.NewArray()
+	for _, row := range args[0].(*values.Array).Iterate() {
+		// The column to return. It can also be null to return complete arrays or objects.
+		value, found := row, true
+		if columnKey.GetType() != values.NullValue {
+			value, found = getColumn(row, columnKey, context)
+		}
+		if !found {
+			continue
+		}
+
+		var key values.RuntimeValue
+		if indexKey.GetType() != values.NullValue {
+			if index, found := getColumn(row, indexKey, context); found {
+				if key, err = toKey(index, context); err != nil {
+					return values.NewVoid(), err
+				}
+			}
+		}
+		if err := result.SetElement(key, value); err != nil {
+			return values.NewVoid(), err
+		}
+	}
+	return result, nil
+}
+
+// Get the element of an array or the public property of an object
+func getColumn(row values.RuntimeValue, column values.RuntimeValue, context runtime.Context) (values.RuntimeValue, bool) {
+	switch row.GetType() {
+	case values.ArrayValue:
+		return row.(*values.Array).GetElement(column)
+	case values.ObjectValue:
+		object := row.(*values.Object)
+		name, err := toString(column, context)
+		if err != nil {
+			return nil, false
+		}
+		if property, found := object.Class.Properties["$"+name]; found && property.Visibility != "public" {
+			return nil, false
+		}
+		return object.GetProperty("$" + name)
+	default:
+		return nil, false
+	}
+}
+
+// -------------------------------------- array_combine -------------------------------------- MARK: array_combine
+
+func nativeFn_array_combine(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.array-combine.php
+	args, err := funcParamValidator.NewValidator("array_combine").
+		AddParam("$keys", []string{"array"}, nil).
+		AddParam("$values", []string{"array"}, nil).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Creates an array by using the values from the keys array as keys and the values from the values array as the corresponding values.
+	keys := args[0].(*values.Array).GetValues()
+	arrayValues := args[1].(*values.Array).GetValues()
+	if len(keys) != len(arrayValues) {
+		return values.NewVoid(), phpError.NewError(
+			"Uncaught ValueError: array_combine(): Argument #1 ($keys) and argument #2 ($values) must have the same number of elements",
+		)
+	}
+	result := values.NewArray()
+	for index, key := range keys {
+		key, err := toKey(key, context)
+		if err != nil {
+			return values.NewVoid(), err
+		}
+		result.SetElement(key, arrayValues[index])
+	}
+	return result, nil
+}
+
+// -------------------------------------- array_count_values -------------------------------------- MARK: array_count_values
+
+func nativeFn_array_count_values(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.array-count-values.php
+	args, err := funcParamValidator.NewValidator("array_count_values").AddParam("$array", []string{"array"}, nil).Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Returns an associative array of values from array as keys and their count as value.
+	result := values.NewArray()
+	for _, value := range args[0].(*values.Array).Iterate() {
+		if value.GetType() != values.IntValue && value.GetType() != values.StrValue {
+			context.Interpreter.PrintError(phpError.NewWarning(
+				"array_count_values(): Can only count string and integer values, entry skipped in %s", context.Stmt.GetPosString(),
+			))
+			continue
+		}
+		count := int64(0)
+		if current, found := result.GetElement(value); found {
+			count = current.(*values.Int).Value
+		}
+		result.SetElement(value, values.NewInt(count+1))
+	}
+	return result, nil
+}
+
+// -------------------------------------- array_diff -------------------------------------- MARK: array_diff
+
+// Spec: https://www.php.net/manual/en/function.array-diff.php
+// Compares array against one or more other arrays and returns the values in array that are not present in any of the other arrays.
+// Two elements are considered equal if and only if (string) $elem1 === (string) $elem2.
+
+func nativeFn_array_diff(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	return setOperation(args, "array_diff", false, compareValuesAsString, compareNothing, 0, context)
+}
+
+// Spec: https://www.php.net/manual/en/function.array-diff-assoc.php
+// Compares array against arrays and returns the difference. Unlike array_diff() the array keys are also used in the comparison.
+
+func nativeFn_array_diff_assoc(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	return setOperation(args, "array_diff_assoc", false, compareValuesAsString, compareKeysIdentical, 0, context)
+}
+
+// Spec: https://www.php.net/manual/en/function.array-diff-key.php
+// Compares the keys from array against the keys from arrays and returns the difference.
+
+func nativeFn_array_diff_key(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	return setOperation(args, "array_diff_key", false, compareNothing, compareKeysIdentical, 0, context)
+}
+
+// Spec: https://www.php.net/manual/en/function.array-diff-uassoc.php
+// Compares array against arrays and returns the difference. The keys are compared by a user supplied callback function.
+
+func nativeFn_array_diff_uassoc(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	return setOperation(args, "array_diff_uassoc", false, compareValuesAsString, compareKeysWithCallback, 1, context)
+}
+
+// Spec: https://www.php.net/manual/en/function.array-diff-ukey.php
+// Compares the keys from array against the keys from arrays and returns the difference using a callback function.
+
+func nativeFn_array_diff_ukey(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	return setOperation(args, "array_diff_ukey", false, compareNothing, compareKeysWithCallback, 1, context)
+}
+
+// Spec: https://www.php.net/manual/en/function.array-udiff.php
+// Computes the difference of arrays by using a callback function for data comparison.
+
+func nativeFn_array_udiff(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	return setOperation(args, "array_udiff", false, compareValuesWithCallback, compareNothing, 1, context)
+}
+
+// Spec: https://www.php.net/manual/en/function.array-udiff-assoc.php
+// Computes the difference of arrays with additional index check, compares data by a callback function.
+
+func nativeFn_array_udiff_assoc(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	return setOperation(args, "array_udiff_assoc", false, compareValuesWithCallback, compareKeysIdentical, 1, context)
+}
+
+// Spec: https://www.php.net/manual/en/function.array-udiff-uassoc.php
+// Computes the difference of arrays with additional index check, compares data and indexes by a callback function.
+
+func nativeFn_array_udiff_uassoc(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	return setOperation(args, "array_udiff_uassoc", false, compareValuesWithCallback, compareKeysWithCallback, 2, context)
+}
+
+// How the values or keys of the arrays are compared by setOperation
+type setComparison int
+
+const (
+	compareNothing setComparison = iota
+	compareValuesAsString
+	compareValuesWithCallback
+	compareKeysIdentical
+	compareKeysWithCallback
+)
+
+// Compute the difference or the intersection of the first array with the other arrays.
+// The arguments are the arrays followed by the given number of callbacks (value callback before key callback).
+func setOperation(
+	args []values.RuntimeValue, funcName string, intersect bool,
+	valueComparison setComparison, keyComparison setComparison, callbackCount int, context runtime.Context,
+) (values.RuntimeValue, phpError.Error) {
+	if len(args) < 1+callbackCount {
+		return values.NewVoid(), phpError.NewError(
+			"Uncaught ArgumentCountError: Too few arguments to function %s(), %d passed and at least %d expected", funcName, len(args), 1+callbackCount,
+		)
+	}
+	callbacks := args[len(args)-callbackCount:]
+	arrays := []*values.Array{}
+	for index, arg := range args[:len(args)-callbackCount] {
+		if arg.GetType() != values.ArrayValue {
+			paramName := " ($array)"
+			if index > 0 {
+				paramName = ""
+			}
+			return values.NewVoid(), phpError.NewError(
+				"Uncaught TypeError: %s(): Argument #%d%s must be of type array, %s given", funcName, index+1, paramName, typeName(arg),
+			)
+		}
+		arrays = append(arrays, arg.(*values.Array))
+	}
+	for index, callback := range callbacks {
+		if err := variableHandling.ValidateCallback(funcName, len(args)-callbackCount+index+1, "", callback, false, context); err != nil {
+			return values.NewVoid(), err
+		}
+	}
+
+	// The value callback is passed before the key callback
+	var valueCallback, keyCallback values.RuntimeValue
+	if valueComparison == compareValuesWithCallback {
+		valueCallback = callbacks[0]
+	}
+	if keyComparison == compareKeysWithCallback {
+		keyCallback = callbacks[len(callbacks)-1]
+	}
+
+	isEqual := func(lhsKey, lhsValue, rhsKey, rhsValue values.RuntimeValue) (bool, phpError.Error) {
+		switch keyComparison {
+		case compareKeysIdentical:
+			if equal, err := equals(lhsKey, rhsKey, true); err != nil || !equal {
+				return false, err
+			}
+		case compareKeysWithCallback:
+			if result, err := callComparator(keyCallback, lhsKey, rhsKey, context); err != nil || result != 0 {
+				return false, err
+			}
+		}
+		switch valueComparison {
+		case compareValuesAsString:
+			return stringEquals(lhsValue, rhsValue, context)
+		case compareValuesWithCallback:
+			result, err := callComparator(valueCallback, lhsValue, rhsValue, context)
+			return result == 0, err
+		}
+		return true, nil
+	}
+
+	isContained := func(key, value values.RuntimeValue, array *values.Array) (bool, phpError.Error) {
+		// Identical keys can be looked up directly
+		if keyComparison == compareKeysIdentical {
+			other, found := array.GetElement(key)
+			if !found {
+				return false, nil
+			}
+			return isEqual(key, value, key, other)
+		}
+		for otherKey, otherValue := range array.Iterate() {
+			if equal, err := isEqual(key, value, otherKey, otherValue); err != nil || equal {
+				return equal, err
+			}
+		}
+		return false, nil
+	}
+
+	result := values.NewArray()
+	for key, value := range arrays[0].Iterate() {
+		// Difference: Keep the elements that are not present in any of the other arrays.
+		// Intersection: Keep the elements that are present in all of the other arrays.
+		keep := true
+		for _, other := range arrays[1:] {
+			contained, err := isContained(key, value, other)
+			if err != nil {
+				return values.NewVoid(), err
+			}
+			if contained != intersect {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			result.SetElement(key, value)
+		}
+	}
+	return result, nil
+}
+
+// -------------------------------------- array_fill -------------------------------------- MARK: array_fill
+
+func nativeFn_array_fill(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.array-fill.php
+	args, err := funcParamValidator.NewValidator("array_fill").
+		AddParam("$start_index", []string{"int"}, nil).
+		AddParam("$count", []string{"int"}, nil).
+		AddParam("$value", []string{"mixed"}, nil).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Fills an array with count entries of the value of the value parameter, keys starting at the start_index parameter.
+	startIndex := args[0].(*values.Int).Value
+	count := args[1].(*values.Int).Value
+	if count < 0 {
+		return values.NewVoid(), phpError.NewError("Uncaught ValueError: array_fill(): Argument #2 ($count) must be greater than or equal to 0")
+	}
+	result := values.NewArray()
+	for index := range count {
+		result.SetElement(values.NewInt(startIndex+index), args[2])
+	}
+	return result, nil
+}
+
+// -------------------------------------- array_fill_keys -------------------------------------- MARK: array_fill_keys
+
+func nativeFn_array_fill_keys(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.array-fill-keys.php
+	args, err := funcParamValidator.NewValidator("array_fill_keys").
+		AddParam("$keys", []string{"array"}, nil).
+		AddParam("$value", []string{"mixed"}, nil).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Fills an array with the value of the value parameter, using the values of the keys array as keys.
+	result := values.NewArray()
+	for _, key := range args[0].(*values.Array).Iterate() {
+		key, err := toKey(key, context)
+		if err != nil {
+			return values.NewVoid(), err
+		}
+		result.SetElement(key, args[1])
+	}
+	return result, nil
+}
+
+// -------------------------------------- array_filter -------------------------------------- MARK: array_filter
+
+func nativeFn_array_filter(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.array-filter.php
+	args, err := funcParamValidator.NewValidator("array_filter").
+		AddParam("$array", []string{"array"}, nil).
+		AddParam("$callback", []string{"mixed"}, values.NewNull()).
+		AddParam("$mode", []string{"int"}, values.NewInt(0)).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	callback := args[1]
+	if callback.GetType() != values.NullValue {
+		if err := variableHandling.ValidateCallback("array_filter", 2, "$callback", callback, true, context); err != nil {
+			return values.NewVoid(), err
+		}
+	}
+	mode := args[2].(*values.Int).Value
+
+	// Iterates over each value in the array passing them to the callback function.
+	// If the callback function returns true, the current value from array is returned into the result array.
+	// Array keys are preserved.
+	// If no callback is supplied, all empty entries of array will be removed.
+	result := values.NewArray()
+	for key, value := range args[0].(*values.Array).Iterate() {
+		var keep bool
+		var err phpError.Error
+		switch {
+		case callback.GetType() == values.NullValue:
+			keep, err = variableHandling.BoolVal(value)
+		case mode == ARRAY_FILTER_USE_KEY:
+			// Pass key as the only argument to callback instead of the value
+			keep, err = callPredicate(callback, []values.RuntimeValue{key}, context)
+		case mode == ARRAY_FILTER_USE_BOTH:
+			// Pass both value and key as arguments to callback instead of the value
+			keep, err = callPredicate(callback, []values.RuntimeValue{value, key}, context)
+		default:
+			keep, err = callPredicate(callback, []values.RuntimeValue{value}, context)
+		}
+		if err != nil {
+			return values.NewVoid(), err
+		}
+		if keep {
+			result.SetElement(key, value)
+		}
+	}
+	return result, nil
+}
+
+// -------------------------------------- array_find -------------------------------------- MARK: array_find
+
+func nativeFn_array_find(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.array-find.php
+	args, err := funcParamValidator.NewValidator("array_find").
+		AddParam("$array", []string{"array"}, nil).
+		AddParam("$callback", []string{"mixed"}, nil).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+	if err := variableHandling.ValidateCallback("array_find", 2, "$callback", args[1], false, context); err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Returns the value of the first element for which the callback returns true. If no matching element is found the function returns null.
+	key, value, err := find(args[0].(*values.Array), args[1], false, context)
+	if err != nil || key == nil {
+		return values.NewNull(), err
+	}
+	return value, nil
+}
+
+// -------------------------------------- array_find_key -------------------------------------- MARK: array_find_key
+
+func nativeFn_array_find_key(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.array-find-key.php
+	args, err := funcParamValidator.NewValidator("array_find_key").
+		AddParam("$array", []string{"array"}, nil).
+		AddParam("$callback", []string{"mixed"}, nil).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+	if err := variableHandling.ValidateCallback("array_find_key", 2, "$callback", args[1], false, context); err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Returns the key of the first element for which the callback returns true. If no matching element is found the function returns null.
+	key, _, err := find(args[0].(*values.Array), args[1], false, context)
+	if err != nil || key == nil {
+		return values.NewNull(), err
+	}
+	return key, nil
+}
+
+// -------------------------------------- array_first -------------------------------------- MARK: array_first
+
+func nativeFn_array_first(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://php.watch/versions/8.5/array_first-array_last
+	args, err := funcParamValidator.NewValidator("array_first").
+		AddParam("$array", []string{"array"}, nil).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	array := args[0].(*values.Array)
+	if array.IsEmpty() {
+		return values.NewNull(), nil
+	}
+
+	value, _ := array.GetElement(FirstKey(array))
+	return value, nil
+}
+
+// -------------------------------------- array_flip -------------------------------------- MARK: array_flip
+
+func nativeFn_array_flip(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.array-flip.php
+	args, err := funcParamValidator.NewValidator("array_flip").AddParam("$array", []string{"array"}, nil).Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Returns an array in flip order, i.e. keys from array become values and values from array become keys.
+	// Note that the values of array need to be valid keys, i.e. they need to be either int or string.
+	// A warning will be emitted if a value has the wrong type, and the key/value pair in question will not be included in the result.
+	// If a value has several occurrences, the latest key will be used as its value, and all others will be lost.
+	result := values.NewArray()
+	for key, value := range args[0].(*values.Array).Iterate() {
+		if value.GetType() != values.IntValue && value.GetType() != values.StrValue {
+			context.Interpreter.PrintError(phpError.NewWarning(
+				"array_flip(): Can only flip string and integer values, entry skipped in %s", context.Stmt.GetPosString(),
+			))
+			continue
+		}
+		result.SetElement(value, key)
+	}
+	return result, nil
+}
+
+// -------------------------------------- array_intersect -------------------------------------- MARK: array_intersect
+
+// Spec: https://www.php.net/manual/en/function.array-intersect.php
+// Returns an array containing all the values of array that are present in all the arguments. Note that keys are preserved.
+// Two elements are considered equal if and only if (string) $elem1 === (string) $elem2.
+
+func nativeFn_array_intersect(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	return setOperation(args, "array_intersect", true, compareValuesAsString, compareNothing, 0, context)
+}
+
+// Spec: https://www.php.net/manual/en/function.array-intersect-assoc.php
+// Returns an array containing all the values of array that are present in all the arguments.
+// Note that the keys are also used in the comparison unlike in array_intersect().
+
+func nativeFn_array_intersect_assoc(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	return setOperation(args, "array_intersect_assoc", true, compareValuesAsString, compareKeysIdentical, 0, context)
+}
+
+// Spec: https://www.php.net/manual/en/function.array-intersect-key.php
+// Returns an array containing all the entries of array which have keys that are present in all the arguments.
+
+func nativeFn_array_intersect_key(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	return setOperation(args, "array_intersect_key", true, compareNothing, compareKeysIdentical, 0, context)
+}
+
+// Spec: https://www.php.net/manual/en/function.array-intersect-uassoc.php
+// Computes the intersection of arrays with additional index check, compares indexes by a callback function.
+
+func nativeFn_array_intersect_uassoc(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	return setOperation(args, "array_intersect_uassoc", true, compareValuesAsString, compareKeysWithCallback, 1, context)
+}
+
+// Spec: https://www.php.net/manual/en/function.array-intersect-ukey.php
+// Computes the intersection of arrays using a callback function on the keys for comparison.
+
+func nativeFn_array_intersect_ukey(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	return setOperation(args, "array_intersect_ukey", true, compareNothing, compareKeysWithCallback, 1, context)
+}
+
+// Spec: https://www.php.net/manual/en/function.array-uintersect.php
+// Computes the intersection of arrays, compares data by a callback function.
+
+func nativeFn_array_uintersect(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	return setOperation(args, "array_uintersect", true, compareValuesWithCallback, compareNothing, 1, context)
+}
+
+// Spec: https://www.php.net/manual/en/function.array-uintersect-assoc.php
+// Computes the intersection of arrays with additional index check, compares data by a callback function.
+
+func nativeFn_array_uintersect_assoc(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	return setOperation(args, "array_uintersect_assoc", true, compareValuesWithCallback, compareKeysIdentical, 1, context)
+}
+
+// Spec: https://www.php.net/manual/en/function.array-uintersect-uassoc.php
+// Computes the intersection of arrays with additional index check, compares data and indexes by separate callback functions.
+
+func nativeFn_array_uintersect_uassoc(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	return setOperation(args, "array_uintersect_uassoc", true, compareValuesWithCallback, compareKeysWithCallback, 2, context)
+}
+
+// -------------------------------------- array_is_list -------------------------------------- MARK: array_is_list
+
+func nativeFn_array_is_list(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.array-is-list.php
+	args, err := funcParamValidator.NewValidator("array_is_list").AddParam("$array", []string{"array"}, nil).Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Determines if the given array is a list. An array is considered a list if its keys consist of consecutive numbers from 0 to count($array)-1.
+	return values.NewBool(IsList(args[0].(*values.Array))), nil
+}
+
+func IsList(array *values.Array) bool {
+	index := int64(0)
+	for key := range array.Iterate() {
+		if key.GetType() != values.IntValue || key.(*values.Int).Value != index {
+			return false
+		}
+		index++
+	}
+	return true
+}
+
+// -------------------------------------- array_key_exists -------------------------------------- MARK: array_key_exists
+
+func nativeFn_array_key_exists(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	args, err := funcParamValidator.NewValidator("array_key_exists").
+		AddParam("$key", []string{"string", "int", "float", "bool", "resource", "null"}, nil).
+		AddParam("$array", []string{"array"}, nil).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	boolean, err := lib_array_key_exists(args[0], args[1].(*values.Array))
+	return values.NewBool(boolean), err
+}
+
+func lib_array_key_exists(key values.RuntimeValue, array *values.Array) (bool, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.array-key-exists.php
+
+	// TODO lib_array_key_exists - allowedKeyTypes - resource
+	allowedKeyTypes := []values.ValueType{values.StrValue, values.IntValue, values.FloatValue, values.BoolValue, values.NullValue}
+
+	if !slices.Contains(allowedKeyTypes, key.GetType()) {
+		return false, phpError.NewError("Values of type %s are not allowed as array key", key.GetType())
+	}
+
+	return array.Contains(key), nil
+}
+
+// -------------------------------------- array_key_first -------------------------------------- MARK: array_key_first
+
+func nativeFn_array_key_first(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.array-key-first.php
+	args, err := funcParamValidator.NewValidator("array_key_first").
+		AddParam("$array", []string{"array"}, nil).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	return FirstKey(args[0].(*values.Array)), nil
+}
+
+func FirstKey(array *values.Array) values.RuntimeValue {
+	// Spec: https://www.php.net/manual/en/function.array-key-first.php
+	if array.IsEmpty() {
+		return values.NewNull()
+	}
+	return array.GetKeys()[0]
+}
+
+// -------------------------------------- array_key_last -------------------------------------- MARK: array_key_last
+
+func nativeFn_array_key_last(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.array-key-last.php
+	args, err := funcParamValidator.NewValidator("array_key_last").
+		AddParam("$array", []string{"array"}, nil).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	return LastKey(args[0].(*values.Array)), nil
+}
+
+func LastKey(array *values.Array) values.RuntimeValue {
+	// Spec: https://www.php.net/manual/en/function.array-key-last.php
+	if array.IsEmpty() {
+		return values.NewNull()
+	}
+	return array.GetKeys()[array.Count()-1]
+}
+
+// -------------------------------------- array_keys -------------------------------------- MARK: array_keys
+
+func nativeFn_array_keys(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.array-keys.php
+	argCount := len(args)
+	args, err := funcParamValidator.NewValidator("array_keys").
+		AddParam("$array", []string{"array"}, nil).
+		AddParam("$filter_value", []string{"mixed"}, values.NewNull()).
+		AddParam("$strict", []string{"bool"}, values.NewBool(false)).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// array_keys() returns the keys, numeric and string, from the array.
+	// If a filter_value is specified, then only the keys for that value are returned. Otherwise, all the keys from the array are returned.
+	result := values.NewArray()
+	for key, value := range args[0].(*values.Array).Iterate() {
+		if argCount >= 2 {
+			equal, err := equals(value, args[1], args[2].(*values.Bool).Value)
+			if err != nil {
+				return values.NewVoid(), err
+			}
+			if !equal {
+				continue
+			}
+		}
+		result.SetElement(nil, key)
+	}
+	return result, nil
+}
+
+// -------------------------------------- array_last -------------------------------------- MARK: array_last
+
+func nativeFn_array_last(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://php.watch/versions/8.5/array_first-array_last
+	args, err := funcParamValidator.NewValidator("array_last").
+		AddParam("$array", []string{"array"}, nil).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	array := args[0].(*values.Array)
+	if array.IsEmpty() {
+		return values.NewNull(), nil
+	}
+
+	value, _ := array.GetElement(LastKey(array))
+	return value, nil
+}
+
+// -------------------------------------- array_map -------------------------------------- MARK: array_map
+
+func nativeFn_array_map(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.array-map.php
+	args, err := funcParamValidator.NewValidator("array_map").
+		AddParam("$callback", []string{"mixed"}, nil).
+		AddParam("$array", []string{"array"}, nil).
+		AddVariableLenParam("$arrays", []string{"mixed"}).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	callback := args[0]
+	if callback.GetType() != values.NullValue {
+		if err := variableHandling.ValidateCallback("array_map", 1, "$callback", callback, true, context); err != nil {
+			return values.NewVoid(), err
+		}
+	}
+	arrays := []*values.Array{args[1].(*values.Array)}
+	for index, array := range args[2].(*values.Array).GetValues() {
+		if array.GetType() != values.ArrayValue {
+			return values.NewVoid(), phpError.NewError(
+				"Uncaught TypeError: array_map(): Argument #%d must be of type array, %s given", index+3, typeName(array),
+			)
+		}
+		arrays = append(arrays, array.(*values.Array))
+	}
+
+	// Returns an array containing the results of applying the callback to the corresponding value of array
+	// (and arrays if more arrays are provided) used as arguments for the callback.
+	// The returned array will preserve the keys of the array argument if and only if exactly one array is passed.
+	if len(arrays) == 1 {
+		if callback.GetType() == values.NullValue {
+			return arrays[0], nil
+		}
+		result := values.NewArray()
+		for key, value := range arrays[0].Iterate() {
+			mapped, err := context.Interpreter.CallFunction(context, callback, []values.RuntimeValue{value})
+			if err != nil {
+				return values.NewVoid(), err
+			}
+			result.SetElement(key, mapped)
+		}
+		return result, nil
+	}
+
+	// If the arrays are of unequal length, shorter ones will be extended with empty elements to match the length of the longest.
+	// null can be passed as a value to callback to perform a zip operation on multiple arrays.
+	length := 0
+	arrayValues := make([][]values.RuntimeValue, len(arrays))
+	for index, array := range arrays {
+		arrayValues[index] = array.GetValues()
+		length = max(length, array.Count())
+	}
+	result := values.NewArray()
+	for position := range length {
+		callbackArgs := make([]values.RuntimeValue, len(arrays))
+		for index := range arrays {
+			if position < len(arrayValues[index]) {
+				callbackArgs[index] = arrayValues[index][position]
+			} else {
+				callbackArgs[index] = values.NewNull()
+			}
+		}
+		if callback.GetType() == values.NullValue {
+			tuple := values.NewArray()
+			for _, value := range callbackArgs {
+				tuple.SetElement(nil, value)
+			}
+			result.SetElement(nil, tuple)
+			continue
+		}
+		mapped, err := context.Interpreter.CallFunction(context, callback, callbackArgs)
+		if err != nil {
+			return values.NewVoid(), err
+		}
+		result.SetElement(nil, mapped)
+	}
+	return result, nil
+}
+
+// -------------------------------------- array_merge -------------------------------------- MARK: array_merge
+
+func nativeFn_array_merge(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.array-merge.php
+	args, err := funcParamValidator.NewValidator("array_merge").AddVariableLenParam("$arrays", []string{"array"}).Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Merges the elements of one or more arrays together so that the values of one are appended to the end of the previous one.
+	// If the input arrays have the same string keys, then the later value for that key will overwrite the previous one.
+	// If, however, the arrays contain numeric keys, the later value will not overwrite the original value, but will be appended.
+	// Values in the input arrays with numeric keys will be renumbered with incrementing keys starting from zero in the result array.
+	result := values.NewArray()
+	for _, array := range args[0].(*values.Array).Iterate() {
+		for key, value := range array.(*values.Array).Iterate() {
+			if key.GetType() == values.IntValue {
+				key = nil
+			}
+			result.SetElement(key, value)
+		}
+	}
+	return result, nil
+}
+
+// -------------------------------------- array_merge_recursive -------------------------------------- MARK: array_merge_recursive
+
+func nativeFn_array_merge_recursive(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.array-merge-recursive.php
+	args, err := funcParamValidator.NewValidator("array_merge_recursive").AddVariableLenParam("$arrays", []string{"array"}).Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	result := values.NewArray()
+	for _, array := range args[0].(*values.Array).Iterate() {
+		mergeRecursive(result, array.(*values.Array))
+	}
+	return result, nil
+}
+
+// Merge the source into the destination like array_merge.
+// If the arrays have the same string keys, then the values for these keys are merged together into an array,
+// and this is done recursively, so that if one of the values is an array itself, the function will merge it with
+// a corresponding entry in another array too.
+func mergeRecursive(destination *values.Array, source *values.Array) {
+	for key, value := range source.Iterate() {
+		if key.GetType() == values.IntValue {
+			destination.SetElement(nil, value)
+			continue
+		}
+		existing, found := destination.GetElement(key)
+		if !found {
+			destination.SetElement(key, value)
+			continue
+		}
+		merged := values.NewArray()
+		if existing.GetType() == values.ArrayValue {
+			merged = existing.(*values.Array).Copy()
+		} else {
+			merged.SetElement(nil, existing)
+		}
+		if value.GetType() == values.ArrayValue {
+			mergeRecursive(merged, value.(*values.Array))
+		} else {
+			merged.SetElement(nil, value)
+		}
+		destination.SetElement(key, merged)
+	}
+}
+
+// -------------------------------------- array_multisort -------------------------------------- MARK: array_multisort
+
+func nativeFn_array_multisort(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.array-multisort.php
+	if len(args) == 0 {
+		return values.NewVoid(), phpError.NewError("Uncaught ArgumentCountError: array_multisort() expects at least 1 argument, 0 given")
+	}
+
+	// array_multisort() can be used to sort several arrays at once, or a multi-dimensional array by one or more dimensions.
+	// Each array can be followed by its sort order (SORT_ASC or SORT_DESC) and its sort flags.
+	type column struct {
+		argIndex   int
+		array      *values.Array
+		descending bool
+		flags      int64
+	}
+	columns := []*column{}
+	hasOrder, hasFlags := true, true
+	for index, arg := range args {
+		paramName := ""
+		if index == 0 {
+			paramName = " ($array)"
+		}
+		switch arg.GetType() {
+		case values.ArrayValue:
+			columns = append(columns, &column{argIndex: index, array: arg.(*values.Array), flags: SORT_REGULAR})
+			hasOrder, hasFlags = false, false
+		case values.IntValue:
+			value := arg.(*values.Int).Value
+			switch value &^ SORT_FLAG_CASE {
+			case SORT_ASC, SORT_DESC:
+				if hasOrder {
+					return values.NewVoid(), phpError.NewError(
+						"Uncaught ValueError: array_multisort(): Argument #%d%s must be an array or a sort flag that has not already been specified", index+1, paramName,
+					)
+				}
+				columns[len(columns)-1].descending = value == SORT_DESC
+				hasOrder = true
+			case SORT_REGULAR, SORT_NUMERIC, SORT_STRING, SORT_LOCALE_STRING, SORT_NATURAL:
+				if hasFlags {
+					return values.NewVoid(), phpError.NewError(
+						"Uncaught ValueError: array_multisort(): Argument #%d%s must be an array or a sort flag that has not already been specified", index+1, paramName,
+					)
+				}
+				columns[len(columns)-1].flags = value
+				hasFlags = true
+			default:
+				return values.NewVoid(), phpError.NewError("Uncaught ValueError: array_multisort(): Argument #%d%s must be a valid sort flag", index+1, paramName)
+			}
+		default:
+			return values.NewVoid(), phpError.NewError("Uncaught TypeError: array_multisort(): Argument #%d%s must be an array or a sort flag", index+1, paramName)
+		}
+	}
+
+	// Make sure the arrays are of the same size
+	count := columns[0].array.Count()
+	for _, column := range columns {
+		if column.array.Count() != count {
+			return values.NewVoid(), phpError.NewError("Uncaught ValueError: Array sizes are inconsistent")
+		}
+	}
+
+	// The rows are sorted by the values of the first array, equal rows by the values of the next array and so on.
+	keys := make([][]values.RuntimeValue, len(columns))
+	arrayValues := make([][]values.RuntimeValue, len(columns))
+	compareFuncs := make([]compareFunc, len(columns))
+	for index, column := range columns {
+		keys[index] = column.array.GetKeys()
+		arrayValues[index] = column.array.GetValues()
+		compareFuncs[index] = getCompareFunc(column.flags, context)
+	}
+	order := make([]int, count)
+	for index := range order {
+		order[index] = index
+	}
+	var err phpError.Error
+	slices.SortStableFunc(order, func(lhs, rhs int) int {
+		for index, column := range columns {
+			if err != nil {
+				return 0
+			}
+			var result int
+			result, err = compareFuncs[index](arrayValues[index][lhs], arrayValues[index][rhs])
+			if column.descending {
+				result = -result
+			}
+			if result != 0 {
+				return result
+			}
+		}
+		return 0
+	})
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Associative (string) keys will be maintained, but numeric keys will be re-indexed.
+	for index, column := range columns {
+		result := values.NewArray()
+		for _, row := range order {
+			key := keys[index][row]
+			if key.GetType() == values.IntValue {
+				key = nil
+			}
+			result.SetElement(key, arrayValues[index][row])
+		}
+		if err := context.Interpreter.SetByRefArgument(context, column.argIndex, result); err != nil {
+			return values.NewVoid(), err
+		}
+	}
+	return values.NewBool(true), nil
+}
+
+// -------------------------------------- array_pad -------------------------------------- MARK: array_pad
+
+func nativeFn_array_pad(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.array-pad.php
+	args, err := funcParamValidator.NewValidator("array_pad").
+		AddParam("$array", []string{"array"}, nil).
+		AddParam("$length", []string{"int"}, nil).
+		AddParam("$value", []string{"mixed"}, nil).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Returns a copy of the array padded to size specified by length with value value.
+	// If length is positive then the array is padded on the right, if it's negative then on the left.
+	// If the absolute value of length is less than or equal to the length of the array then no padding takes place.
+	array := args[0].(*values.Array)
+	length := args[1].(*values.Int).Value
+	padding := max(length, -length) - int64(array.Count())
+	if padding <= 0 {
+		return array, nil
+	}
+
+	result := values.NewArray()
+	addPadding := func() {
+		for range padding {
+			result.SetElement(nil, args[2])
+		}
+	}
+	if length < 0 {
+		addPadding()
+	}
+	for key, value := range array.Iterate() {
+		if key.GetType() == values.IntValue {
+			key = nil
+		}
+		result.SetElement(key, value)
+	}
+	if length > 0 {
+		addPadding()
+	}
+	return result, nil
+}
+
+// -------------------------------------- array_pop -------------------------------------- MARK: array_pop
+
+func nativeFn_array_pop(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.array-pop.php
+	args, err := funcParamValidator.NewValidator("array_pop").
+		AddParam("$array", []string{"array"}, nil).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	array := args[0].(*values.Array)
+	value, err := lib_array_pop(array)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+	return value, context.Interpreter.SetByRefArgument(context, 0, array)
+}
+
+func lib_array_pop(array *values.Array) (values.RuntimeValue, phpError.Error) {
+	// array_pop() pops and returns the value of the last element of array, shortening the array by one element.
+	// This function will reset() the array pointer of the input array after use.
+	if array.IsEmpty() {
+		return values.NewNull(), nil
+	}
+
+	lastKey := LastKey(array)
+	value, _ := array.GetElement(lastKey)
+	if err := array.RemoveLastElement(lastKey); err != nil {
+		return values.NewVoid(), err
+	}
+	array.SetPointer(0)
+	return value, nil
+}
+
+// -------------------------------------- array_product -------------------------------------- MARK: array_product
+
+func nativeFn_array_product(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.array-product.php
+	args, err := funcParamValidator.NewValidator("array_product").AddParam("$array", []string{"array"}, nil).Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Returns the product as an integer or float.
+	return calculateArray(args[0].(*values.Array), '*', context), nil
+}
+
+// Calculate the sum or product of the values of the array
+func calculateArray(array *values.Array, operator byte, context runtime.Context) values.RuntimeValue {
+	funcName, operation, result := "array_sum", "Addition", values.RuntimeValue(values.NewInt(0))
+	if operator == '*' {
+		funcName, operation, result = "array_product", "Multiplication", values.NewInt(1)
+	}
+	for _, value := range array.Iterate() {
+		if value.GetType() == values.ArrayValue || value.GetType() == values.ObjectValue {
+			context.Interpreter.PrintError(phpError.NewWarning(
+				"%s(): %s is not supported on type %s in %s", funcName, operation, typeName(value), context.Stmt.GetPosString(),
+			))
+			continue
+		}
+		result = calculate(result, operator, toNumber(value))
+	}
+	return result
+}
+
+// -------------------------------------- array_push -------------------------------------- MARK: array_push
+
+func nativeFn_array_push(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.array-push.php
+	args, err := funcParamValidator.NewValidator("array_push").
+		AddParam("$array", []string{"array"}, nil).
+		AddVariableLenParam("$values", []string{"mixed"}).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	array := args[0].(*values.Array)
+	count, err := lib_array_push(array, args[1].(*values.Array).GetValues()...)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+	return count, context.Interpreter.SetByRefArgument(context, 0, array)
+}
+
+func lib_array_push(array *values.Array, arrayValues ...values.RuntimeValue) (values.RuntimeValue, phpError.Error) {
+	// array_push() treats array as a stack, and pushes the passed variables onto the end of array.
+	for _, value := range arrayValues {
+		if err := array.SetElement(nil, value); err != nil {
+			return values.NewVoid(), err
+		}
+	}
+	// Returns the new number of elements in the array.
+	return values.NewInt(int64(array.Count())), nil
+}
+
+// -------------------------------------- array_rand -------------------------------------- MARK: array_rand
+
+func nativeFn_array_rand(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.array-rand.php
+	args, err := funcParamValidator.NewValidator("array_rand").
+		AddParam("$array", []string{"array"}, nil).
+		AddParam("$num", []string{"int"}, values.NewInt(1)).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Picks one or more random entries out of an array, and returns the key (or keys) of the random entries.
+	array := args[0].(*values.Array)
+	num := args[1].(*values.Int).Value
+	if array.IsEmpty() {
+		return values.NewVoid(), phpError.NewError("Uncaught ValueError: array_rand(): Argument #1 ($array) cannot be empty")
+	}
+	if num < 1 || num > int64(array.Count()) {
+		return values.NewVoid(), phpError.NewError(
+			"Uncaught ValueError: array_rand(): Argument #2 ($num) must be between 1 and the number of elements in argument #1 ($array)",
+		)
+	}
+
+	keys := array.GetKeys()
+	if num == 1 {
+		return keys[rand.IntN(len(keys))], nil
+	}
+	// When picking multiple entries, the keys are returned in the order they were present in the original array.
+	picked := rand.Perm(len(keys))[:num]
+	slices.Sort(picked)
+	result := values.NewArray()
+	for _, index := range picked {
+		result.SetElement(nil, keys[index])
+	}
+	return result, nil
+}
+
+// -------------------------------------- array_reduce -------------------------------------- MARK: array_reduce
+
+func nativeFn_array_reduce(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.array-reduce.php
+	args, err := funcParamValidator.NewValidator("array_reduce").
+		AddParam("$array", []string{"array"}, nil).
+		AddParam("$callback", []string{"mixed"}, nil).
+		AddParam("$initial", []string{"mixed"}, values.NewNull()).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+	if err := variableHandling.ValidateCallback("array_reduce", 2, "$callback", args[1], false, context); err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Iteratively reduce the array to a single value using a callback function.
+	// The callback gets the return value of the previous iteration (carry) and the value of the current iteration.
+	carry := args[2]
+	for _, value := range args[0].(*values.Array).Iterate() {
+		carry, err = context.Interpreter.CallFunction(context, args[1], []values.RuntimeValue{carry, value})
+		if err != nil {
+			return values.NewVoid(), err
+		}
+	}
+	return carry, nil
+}
+
+// -------------------------------------- array_replace -------------------------------------- MARK: array_replace
+
+func nativeFn_array_replace(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.array-replace.php
+	args, err := funcParamValidator.NewValidator("array_replace").
+		AddParam("$array", []string{"array"}, nil).
+		AddVariableLenParam("$replacements", []string{"array"}).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// array_replace() replaces the values of array with values having the same keys in each of the following arrays.
+	// If a key from the first array exists in the second array, its value will be replaced by the value from the second array.
+	// If the key exists in the second array, and not the first, it will be created in the first array.
+	result := args[0].(*values.Array)
+	for _, replacement := range args[1].(*values.Array).Iterate() {
+		for key, value := range replacement.(*values.Array).Iterate() {
+			result.SetElement(key, value)
+		}
+	}
+	return result, nil
+}
+
+// -------------------------------------- array_replace_recursive -------------------------------------- MARK: array_replace_recursive
+
+func nativeFn_array_replace_recursive(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.array-replace-recursive.php
+	args, err := funcParamValidator.NewValidator("array_replace_recursive").
+		AddParam("$array", []string{"array"}, nil).
+		AddVariableLenParam("$replacements", []string{"array"}).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	result := args[0].(*values.Array)
+	for _, replacement := range args[1].(*values.Array).Iterate() {
+		replaceRecursive(result, replacement.(*values.Array))
+	}
+	return result, nil
+}
+
+// Replace the values like array_replace.
+// If a value in the destination and the replacement are both arrays, the replacement is done recursively.
+func replaceRecursive(destination *values.Array, replacement *values.Array) {
+	for key, value := range replacement.Iterate() {
+		existing, found := destination.GetElement(key)
+		if found && existing.GetType() == values.ArrayValue && value.GetType() == values.ArrayValue {
+			replaced := existing.(*values.Array).Copy()
+			replaceRecursive(replaced, value.(*values.Array))
+			value = replaced
+		}
+		destination.SetElement(key, value)
+	}
+}
+
+// -------------------------------------- array_reverse -------------------------------------- MARK: array_reverse
+
+func nativeFn_array_reverse(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.array-reverse.php
+	args, err := funcParamValidator.NewValidator("array_reverse").
+		AddParam("$array", []string{"array"}, nil).
+		AddParam("$preserve_keys", []string{"bool"}, values.NewBool(false)).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Takes an input array and returns a new array with the order of the elements reversed.
+	// If preserve_keys is set to true numeric keys are preserved. Non-numeric keys are not affected by this setting and will always be preserved.
+	array := args[0].(*values.Array)
+	preserveKeys := args[1].(*values.Bool).Value
+	keys := array.GetKeys()
+	result := values.NewArray()
+	for index := len(keys) - 1; index >= 0; index-- {
+		value, _ := array.GetElement(keys[index])
+		key := keys[index]
+		if key.GetType() == values.IntValue && !preserveKeys {
+			key = nil
+		}
+		result.SetElement(key, value)
+	}
+	return result, nil
+}
+
+// -------------------------------------- array_search -------------------------------------- MARK: array_search
+
+func nativeFn_array_search(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.array-search.php
+	args, err := funcParamValidator.NewValidator("array_search").
+		AddParam("$needle", []string{"mixed"}, nil).
+		AddParam("$haystack", []string{"array"}, nil).
+		AddParam("$strict", []string{"bool"}, values.NewBool(false)).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Returns the key for needle if it is found in the array, false otherwise.
+	key, err := search(args[0], args[1].(*values.Array), args[2].(*values.Bool).Value)
+	if err != nil || key == nil {
+		return values.NewBool(false), err
+	}
+	return key, nil
+}
+
+// Find the key of the first element that is equal (or identical if strict) to the needle
+func search(needle values.RuntimeValue, haystack *values.Array, strict bool) (values.RuntimeValue, phpError.Error) {
+	for key, value := range haystack.Iterate() {
+		equal, err := equals(value, needle, strict)
+		if err != nil {
+			return nil, err
+		}
+		if equal {
+			return key, nil
+		}
+	}
+	return nil, nil
+}
+
+// -------------------------------------- array_shift -------------------------------------- MARK: array_shift
+
+func nativeFn_array_shift(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.array-shift.php
+	args, err := funcParamValidator.NewValidator("array_shift").AddParam("$array", []string{"array"}, nil).Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// array_shift() shifts the first value of the array off and returns it, shortening the array by one element and moving everything down.
+	// All numerical array keys will be modified to start counting from zero while literal keys won't be affected.
+	array := args[0].(*values.Array)
+	if array.IsEmpty() {
+		return values.NewNull(), nil
+	}
+	value, _ := array.GetElement(FirstKey(array))
+	result := values.NewArray()
+	for index, key := range array.GetKeys() {
+		if index == 0 {
+			continue
+		}
+		element, _ := array.GetElement(key)
+		if key.GetType() == values.IntValue {
+			key = nil
+		}
+		result.SetElement(key, element)
+	}
+	return value, context.Interpreter.SetByRefArgument(context, 0, result)
 }
 
-// -------------------------------------- array_first -------------------------------------- MARK: array_first
+// -------------------------------------- array_slice -------------------------------------- MARK: array_slice
 
-func nativeFn_array_first(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
-	// Spec: https://php.watch/versions/8.5/array_first-array_last
-	args, err := funcParamValidator.NewValidator("array_first").
+func nativeFn_array_slice(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.array-slice.php
+	args, err := funcParamValidator.NewValidator("array_slice").
 		AddParam("$array", []string{"array"}, nil).
+		AddParam("$offset", []string{"int"}, nil).
+		AddParam("$length", []string{"int", "null"}, values.NewNull()).
+		AddParam("$preserve_keys", []string{"bool"}, values.NewBool(false)).
 		Validate(args)
 	if err != nil {
 		return values.NewVoid(), err
 	}
 
+	// Returns the sequence of elements from the array array as specified by the offset and length parameters.
+	// Note that array_slice() will reorder and reset the integer array indices by default.
+	// This behaviour can be changed by setting preserve_keys to true. String keys are always preserved, regardless of this parameter.
 	array := args[0].(*values.Array)
-	if array.IsEmpty() {
-		return values.NewNull(), nil
+	start, end := sliceBounds(array.Count(), args[1].(*values.Int).Value, args[2])
+	preserveKeys := args[3].(*values.Bool).Value
+	result := values.NewArray()
+	for _, key := range array.GetKeys()[start:end] {
+		value, _ := array.GetElement(key)
+		if key.GetType() == values.IntValue && !preserveKeys {
+			key = nil
+		}
+		result.SetElement(key, value)
+	}
+	return result, nil
+}
+
+// Get the start and end index of a slice.
+// If offset is non-negative, the sequence will start at that offset in the array.
+// If offset is negative, the sequence will start that far from the end of the array.
+// If length is given and is positive, then the sequence will have up to that many elements in it.
+// If length is given and is negative then the sequence will stop that many elements from the end of the array.
+// If it is omitted (null), then the sequence will have everything from offset up until the end of the array.
+func sliceBounds(count int, offset int64, length values.RuntimeValue) (int, int) {
+	if offset < 0 {
+		offset = max(int64(count)+offset, 0)
 	}
+	offset = min(offset, int64(count))
 
-	value, _ := array.GetElement(FirstKey(array))
-	return value, nil
+	end := int64(count)
+	if length.GetType() == values.IntValue {
+		if length := length.(*values.Int).Value; length < 0 {
+			end = int64(count) + length
+		} else if length < int64(count)-offset {
+			end = offset + length
+		}
+	}
+	end = max(end, offset)
+	return int(offset), int(end)
 }
 
-// -------------------------------------- array_key_exists -------------------------------------- MARK: array_key_exists
+// -------------------------------------- array_splice -------------------------------------- MARK: array_splice
 
-func nativeFn_array_key_exists(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
-	args, err := funcParamValidator.NewValidator("array_key_exists").
-		AddParam("$key", []string{"string", "int", "float", "bool", "resource", "null"}, nil).
+func nativeFn_array_splice(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.array-splice.php
+	args, err := funcParamValidator.NewValidator("array_splice").
 		AddParam("$array", []string{"array"}, nil).
+		AddParam("$offset", []string{"int"}, nil).
+		AddParam("$length", []string{"int", "null"}, values.NewNull()).
+		AddParam("$replacement", []string{"mixed"}, values.NewArray()).
 		Validate(args)
 	if err != nil {
 		return values.NewVoid(), err
 	}
 
-	boolean, err := lib_array_key_exists(args[0], args[1].(*values.Array))
-	return values.NewBool(boolean), err
+	// Removes the elements designated by offset and length from the array array, and replaces them with the elements of the replacement array, if supplied.
+	// Numerical keys in array are not preserved. If replacement is not an array, it will be typecast to one (i.e. (array) $replacement).
+	// Returns an array consisting of the extracted elements.
+	array := args[0].(*values.Array)
+	start, end := sliceBounds(array.Count(), args[1].(*values.Int).Value, args[2])
+	replacement := args[3]
+	if replacement.GetType() != values.ArrayValue {
+		replacementArray := values.NewArray()
+		if replacement.GetType() != values.NullValue {
+			replacementArray.SetElement(nil, replacement)
+		}
+		replacement = replacementArray
+	}
+
+	result := values.NewArray()
+	removed := values.NewArray()
+	for index, key := range array.GetKeys() {
+		if index == start {
+			for _, value := range replacement.(*values.Array).Iterate() {
+				result.SetElement(nil, value)
+			}
+		}
+		value, _ := array.GetElement(key)
+		newKey := key
+		if key.GetType() == values.IntValue {
+			newKey = nil
+		}
+		if index >= start && index < end {
+			removed.SetElement(newKey, value)
+		} else {
+			result.SetElement(newKey, value)
+		}
+	}
+	if start >= array.Count() {
+		for _, value := range replacement.(*values.Array).Iterate() {
+			result.SetElement(nil, value)
+		}
+	}
+	return removed, context.Interpreter.SetByRefArgument(context, 0, result)
 }
 
-func lib_array_key_exists(key values.RuntimeValue, array *values.Array) (bool, phpError.Error) {
-	// Spec: https://www.php.net/manual/en/function.array-key-exists.php
+// -------------------------------------- array_sum -------------------------------------- MARK: array_sum
 
-	// TODO lib_array_key_exists - allowedKeyTypes - resource
-	allowedKeyTypes := []values.ValueType{values.StrValue, values.IntValue, values.FloatValue, values.BoolValue, values.NullValue}
+func nativeFn_array_sum(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.array-sum.php
+	args, err := funcParamValidator.NewValidator("array_sum").AddParam("$array", []string{"array"}, nil).Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
 
-	if !slices.Contains(allowedKeyTypes, key.GetType()) {
-		return false, phpError.NewError("Values of type %s are not allowed as array key", key.GetType())
+	// Returns the sum of values as an integer or float; 0 if the array is empty.
+	return calculateArray(args[0].(*values.Array), '+', context), nil
+}
+
+// -------------------------------------- array_unique -------------------------------------- MARK: array_unique
+
+func nativeFn_array_unique(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.array-unique.php
+	args, err := funcParamValidator.NewValidator("array_unique").
+		AddParam("$array", []string{"array"}, nil).
+		AddParam("$flags", []string{"int"}, values.NewInt(SORT_STRING)).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
 	}
 
-	return array.Contains(key), nil
+	// Takes an input array and returns a new array without duplicate values.
+	// Note that keys are preserved. If multiple elements compare equal under the given flags,
+	// then the key and value of the first equal element will be retained.
+	flags := args[1].(*values.Int).Value
+	result := values.NewArray()
+	switch flags {
+	case SORT_REGULAR, SORT_NUMERIC:
+		// SORT_REGULAR: compare items normally (don't change types)
+		// SORT_NUMERIC: compare items numerically
+		kept := []values.RuntimeValue{}
+		for key, value := range args[0].(*values.Array).Iterate() {
+			isDuplicate := false
+			for _, keptValue := range kept {
+				var equal bool
+				var err phpError.Error
+				if flags == SORT_NUMERIC {
+					equal = toFloat(toNumber(value)) == toFloat(toNumber(keptValue))
+				} else {
+					equal, err = equals(value, keptValue, false)
+				}
+				if err != nil {
+					return values.NewVoid(), err
+				}
+				if equal {
+					isDuplicate = true
+					break
+				}
+			}
+			if !isDuplicate {
+				kept = append(kept, value)
+				result.SetElement(key, value)
+			}
+		}
+	default:
+		// SORT_STRING: compare items as strings
+		kept := map[string]bool{}
+		for key, value := range args[0].(*values.Array).Iterate() {
+			str, err := toString(value, context)
+			if err != nil {
+				return values.NewVoid(), err
+			}
+			if !kept[str] {
+				kept[str] = true
+				result.SetElement(key, value)
+			}
+		}
+	}
+	return result, nil
 }
 
-// -------------------------------------- array_key_first -------------------------------------- MARK: array_key_first
+// -------------------------------------- array_unshift -------------------------------------- MARK: array_unshift
 
-func nativeFn_array_key_first(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
-	// Spec: https://www.php.net/manual/en/function.array-key-first.php
-	args, err := funcParamValidator.NewValidator("array_key_first").
+func nativeFn_array_unshift(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.array-unshift.php
+	args, err := funcParamValidator.NewValidator("array_unshift").
 		AddParam("$array", []string{"array"}, nil).
+		AddVariableLenParam("$values", []string{"mixed"}).
 		Validate(args)
 	if err != nil {
 		return values.NewVoid(), err
 	}
 
-	return FirstKey(args[0].(*values.Array)), nil
+	// array_unshift() prepends passed elements to the front of the array.
+	// All numerical array keys will be modified to start counting from zero while literal keys won't be changed.
+	result := values.NewArray()
+	for _, value := range args[1].(*values.Array).Iterate() {
+		result.SetElement(nil, value)
+	}
+	for key, value := range args[0].(*values.Array).Iterate() {
+		if key.GetType() == values.IntValue {
+			key = nil
+		}
+		result.SetElement(key, value)
+	}
+	return values.NewInt(int64(result.Count())), context.Interpreter.SetByRefArgument(context, 0, result)
 }
 
-func FirstKey(array *values.Array) values.RuntimeValue {
-	// Spec: https://www.php.net/manual/en/function.array-key-first.php
-	if array.IsEmpty() {
-		return values.NewNull()
+// -------------------------------------- array_values -------------------------------------- MARK: array_values
+
+func nativeFn_array_values(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.array-values.php
+	args, err := funcParamValidator.NewValidator("array_values").AddParam("$array", []string{"array"}, nil).Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// array_values() returns all the values from the array and indexes the array numerically.
+	result := values.NewArray()
+	for _, value := range args[0].(*values.Array).Iterate() {
+		result.SetElement(nil, value)
 	}
-	return array.Keys[0]
+	return result, nil
 }
 
-// -------------------------------------- array_key_last -------------------------------------- MARK: array_key_last
+// -------------------------------------- array_walk -------------------------------------- MARK: array_walk
 
-func nativeFn_array_key_last(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
-	// Spec: https://www.php.net/manual/en/function.array-key-last.php
-	args, err := funcParamValidator.NewValidator("array_key_last").
+func nativeFn_array_walk(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	return walk("array_walk", args, false, context)
+}
+
+// -------------------------------------- array_walk_recursive -------------------------------------- MARK: array_walk_recursive
+
+func nativeFn_array_walk_recursive(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	return walk("array_walk_recursive", args, true, context)
+}
+
+func walk(funcName string, args []values.RuntimeValue, recursive bool, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.array-walk.php
+	// Spec: https://www.php.net/manual/en/function.array-walk-recursive.php
+	argCount := len(args)
+	args, err := funcParamValidator.NewValidator(funcName).
 		AddParam("$array", []string{"array"}, nil).
+		AddParam("$callback", []string{"mixed"}, nil).
+		AddParam("$arg", []string{"mixed"}, values.NewNull()).
 		Validate(args)
 	if err != nil {
 		return values.NewVoid(), err
 	}
+	if err := variableHandling.ValidateCallback(funcName, 2, "$callback", args[1], false, context); err != nil {
+		return values.NewVoid(), err
+	}
 
-	return LastKey(args[0].(*values.Array)), nil
+	// Applies the user-defined callback function to each element of the array.
+	// Typically, callback takes on two parameters. The array parameter's value being the first, and the key/index second.
+	// If the optional arg parameter is supplied, it will be passed as the third parameter to the callback.
+	// TODO Pass the value by reference once references are supported so that the callback can change the elements
+	var walkArray func(array *values.Array) phpError.Error
+	walkArray = func(array *values.Array) phpError.Error {
+		for key, value := range array.Iterate() {
+			// array_walk_recursive: Recurse into deeper arrays
+			if recursive && value.GetType() == values.ArrayValue {
+				if err := walkArray(value.(*values.Array)); err != nil {
+					return err
+				}
+				continue
+			}
+			callbackArgs := []values.RuntimeValue{value, key}
+			if argCount >= 3 {
+				callbackArgs = append(callbackArgs, args[2])
+			}
+			if _, err := context.Interpreter.CallFunction(context, args[1], callbackArgs); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walkArray(args[0].(*values.Array)); err != nil {
+		return values.NewVoid(), err
+	}
+	return values.NewBool(true), nil
 }
 
-func LastKey(array *values.Array) values.RuntimeValue {
-	// Spec: https://www.php.net/manual/en/function.array-key-last.php
-	if array.IsEmpty() {
-		return values.NewNull()
-	}
-	return array.Keys[len(array.Keys)-1]
+// -------------------------------------- arsort -------------------------------------- MARK: arsort
+
+func nativeFn_arsort(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.arsort.php
+	// Sorts array in place in descending order, such that its keys maintain their correlation with the values they are associated with.
+	return sortByRef("arsort", args, true, SORT_REGULAR, false, true, true, context)
 }
 
-// -------------------------------------- array_last -------------------------------------- MARK: array_last
+// -------------------------------------- asort -------------------------------------- MARK: asort
 
-func nativeFn_array_last(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
-	// Spec: https://php.watch/versions/8.5/array_first-array_last
-	args, err := funcParamValidator.NewValidator("array_last").
-		AddParam("$array", []string{"array"}, nil).
+func nativeFn_asort(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.asort.php
+	// Sorts array in place in ascending order, such that its keys maintain their correlation with the values they are associated with.
+	return sortByRef("asort", args, true, SORT_REGULAR, false, false, true, context)
+}
+
+// -------------------------------------- compact -------------------------------------- MARK: compact
+
+func nativeFn_compact(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.compact.php
+	args, err := funcParamValidator.NewValidator("compact").
+		AddParam("$var_name", []string{"mixed"}, nil).
+		AddVariableLenParam("$var_names", []string{"mixed"}).
 		Validate(args)
 	if err != nil {
 		return values.NewVoid(), err
 	}
 
-	array := args[0].(*values.Array)
-	if array.IsEmpty() {
-		return values.NewNull(), nil
+	// Creates an array containing variables and their values.
+	// For each of these, compact() looks for a variable with that name in the current symbol table and adds it
+	// to the output array such that the variable name becomes the key and the contents of the variable become
+	// the value for that key.
+	result := values.NewArray()
+	if err := compactVariables(result, args[0], 1, context); err != nil {
+		return values.NewVoid(), err
+	}
+	varNames := args[1].(*values.Array)
+	for index, key := range varNames.GetKeys() {
+		varName, _ := varNames.GetElement(key)
+		if err := compactVariables(result, varName, index+2, context); err != nil {
+			return values.NewVoid(), err
+		}
 	}
+	return result, nil
+}
 
-	value, _ := array.GetElement(LastKey(array))
-	return value, nil
+func compactVariables(result *values.Array, varName values.RuntimeValue, argument int, context runtime.Context) phpError.Error {
+	switch varName.GetType() {
+	case values.StrValue:
+		// Any strings that are not set will simply be skipped.
+		name := varName.(*values.Str).Value
+		value, err := context.Env.LookupVariable("$" + name)
+		if err != nil {
+			context.Interpreter.PrintError(phpError.NewWarning("compact(): Undefined variable $%s in %s", name, context.Stmt.GetPosString()))
+			return nil
+		}
+		return result.SetElement(values.NewStr(name), values.Copy(value))
+
+	case values.ArrayValue:
+		// Each parameter can be either a string containing the name of the variable, or an array of variable names.
+		// The array can contain other arrays of variable names inside it; compact() handles it recursively.
+		array := varName.(*values.Array)
+		for _, key := range array.GetKeys() {
+			element, _ := array.GetElement(key)
+			if err := compactVariables(result, element, argument, context); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	default:
+		context.Interpreter.PrintError(phpError.NewWarning(
+			"compact(): Argument #%d must be string or array of strings, %s given in %s",
+			argument, values.ToPhpType(varName), context.Stmt.GetPosString(),
+		))
+		return nil
+	}
 }
 
-// -------------------------------------- array_pop -------------------------------------- MARK: array_pop
+// -------------------------------------- count -------------------------------------- MARK: count
 
-func nativeFn_array_pop(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
-	// Spec: https://www.php.net/manual/en/function.array-pop.php
-	args, err := funcParamValidator.NewValidator("array_pop").
-		AddParam("$array", []string{"array"}, nil).
+func nativeFn_count(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.count.php
+	args, err := funcParamValidator.NewValidator("count").
+		AddParam("$value", []string{"mixed"}, nil).
+		AddParam("$mode", []string{"int"}, values.NewInt(COUNT_NORMAL)).
 		Validate(args)
 	if err != nil {
 		return values.NewVoid(), err
 	}
 
-	array := args[0].(*values.Array)
-	if array.IsEmpty() {
-		return values.NewNull(), nil
+	mode := args[1].(*values.Int).Value
+	if mode != COUNT_NORMAL && mode != COUNT_RECURSIVE {
+		return values.NewVoid(), phpError.NewError("Uncaught ValueError: count(): Argument #2 ($mode) must be either COUNT_NORMAL or COUNT_RECURSIVE")
 	}
 
-	lastKey := LastKey(array)
-	value, _ := array.GetElement(lastKey)
-	if err := RemoveByKey(array, lastKey); err != nil {
+	switch value := args[0]; value.GetType() {
+	case values.ArrayValue:
+		// If the optional mode parameter is set to COUNT_RECURSIVE (or 1), count() will recursively count the array.
+		// This is particularly useful for counting all the elements of a multidimensional array.
+		return values.NewInt(countElements(value.(*values.Array), mode == COUNT_RECURSIVE)), nil
+	case values.ObjectValue:
+		// Spec: https://www.php.net/manual/en/countable.count.php
+		// This method is executed when using the count() function on an object implementing Countable.
+		if context.Interpreter.IsSubclassOf(value.(*values.Object).Class, "Countable") {
+			callable := values.NewArray()
+			callable.SetElement(nil, value)
+			callable.SetElement(nil, values.NewStr("count"))
+			result, err := context.Interpreter.CallFunction(context, callable, []values.RuntimeValue{})
+			if err != nil {
+				return values.NewVoid(), err
+			}
+			count, err := variableHandling.IntVal(result, false)
+			return values.NewInt(count), err
+		}
+	}
+	return values.NewVoid(), phpError.NewError(
+		"Uncaught TypeError: count(): Argument #1 ($value) must be of type Countable|array, %s given", typeName(args[0]),
+	)
+}
+
+func countElements(array *values.Array, recursive bool) int64 {
+	count := int64(array.Count())
+	if recursive {
+		for _, value := range array.Iterate() {
+			if value.GetType() == values.ArrayValue {
+				count += countElements(value.(*values.Array), true)
+			}
+		}
+	}
+	return count
+}
+
+// -------------------------------------- current -------------------------------------- MARK: current
+
+func nativeFn_current(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.current.php
+	args, err := funcParamValidator.NewValidator("current").AddParam("$array", []string{"array"}, nil).Validate(args)
+	if err != nil {
 		return values.NewVoid(), err
 	}
 
-	return value, nil
+	// The current() function simply returns the value of the array element that's currently being pointed to by the internal pointer.
+	// If the internal pointer points beyond the end of the elements list or the array is empty, current() returns false.
+	return currentElement(args[0].(*values.Array)), nil
 }
 
-// -------------------------------------- array_push -------------------------------------- MARK: array_push
+// Get the value of the element the internal pointer points to (false if it points beyond the elements)
+func currentElement(array *values.Array) values.RuntimeValue {
+	pointer := array.GetPointer()
+	if pointer < 0 || pointer >= array.Count() {
+		return values.NewBool(false)
+	}
+	return array.GetValues()[pointer]
+}
 
-func nativeFn_array_push(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
-	// Spec: https://www.php.net/manual/en/function.array-push.php
-	args, err := funcParamValidator.NewValidator("array_push").
+// Move the internal pointer and return the value of the element it points to afterwards
+func movePointer(funcName string, args []values.RuntimeValue, move func(array *values.Array), context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	args, err := funcParamValidator.NewValidator(funcName).AddParam("$array", []string{"array"}, nil).Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	array := args[0].(*values.Array)
+	move(array)
+	return currentElement(array), context.Interpreter.SetByRefArgument(context, 0, array)
+}
+
+// -------------------------------------- end -------------------------------------- MARK: end
+
+func nativeFn_end(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.end.php
+	// end() advances array's internal pointer to the last element, and returns its value.
+	// Returns the value of the last element or false for empty array.
+	return movePointer("end", args, func(array *values.Array) { array.SetPointer(max(array.Count()-1, 0)) }, context)
+}
+
+// -------------------------------------- extract -------------------------------------- MARK: extract
+
+func nativeFn_extract(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.extract.php
+	argCount := len(args)
+	args, err := funcParamValidator.NewValidator("extract").
 		AddParam("$array", []string{"array"}, nil).
-		AddVariableLenParam("$values", []string{"mixed"}).
+		AddParam("$flags", []string{"int"}, values.NewInt(EXTR_OVERWRITE)).
+		AddParam("$prefix", []string{"string"}, values.NewStr("")).
 		Validate(args)
 	if err != nil {
 		return values.NewVoid(), err
 	}
 
 	array := args[0].(*values.Array)
-	arrayValues := args[1].(*values.Array)
-	for _, key := range arrayValues.Keys {
-		argValue, _ := arrayValues.GetElement(key)
-		if err := array.SetElement(nil, argValue); err != nil {
-			return values.NewVoid(), err
+	// TODO extract - EXTR_REFS: The variables are extracted as copies
+	flags := args[1].(*values.Int).Value &^ EXTR_REFS
+	prefix := args[2].(*values.Str).Value
+
+	if flags < EXTR_OVERWRITE || flags > EXTR_IF_EXISTS {
+		return values.NewVoid(), phpError.NewError("Uncaught ValueError: extract(): Argument #2 ($flags) must be a valid extract type")
+	}
+	// Note that prefix is only required if flags is EXTR_PREFIX_SAME, EXTR_PREFIX_ALL, EXTR_PREFIX_INVALID or EXTR_PREFIX_IF_EXISTS.
+	if flags >= EXTR_PREFIX_SAME && flags <= EXTR_PREFIX_IF_EXISTS && argCount < 3 {
+		return values.NewVoid(), phpError.NewError("Uncaught ValueError: extract(): Argument #3 ($prefix) is required when using this extract type")
+	}
+	if prefix != "" && !common.IsName(prefix) {
+		return values.NewVoid(), phpError.NewError("Uncaught ValueError: extract(): Argument #3 ($prefix) must be a valid identifier")
+	}
+
+	// Import variables from an array into the current symbol table.
+	// Checks each key to see whether it has a valid variable name.
+	// It also checks for collisions with existing variables in the symbol table.
+	count := 0
+	for _, key := range array.GetKeys() {
+		value, _ := array.GetElement(key)
+
+		name := ""
+		isNumeric := key.GetType() == values.IntValue
+		if isNumeric {
+			// Numeric keys are only imported with a prefix
+			if flags != EXTR_PREFIX_ALL && flags != EXTR_PREFIX_INVALID {
+				continue
+			}
+			name = strconv.FormatInt(key.(*values.Int).Value, 10)
+		} else {
+			name = key.(*values.Str).Value
 		}
+		_, lookupErr := context.Env.LookupVariable("$" + name)
+		exists := !isNumeric && lookupErr == nil
+
+		switch flags {
+		case EXTR_SKIP:
+			// If there is a collision, don't overwrite the existing variable.
+			if exists {
+				continue
+			}
+		case EXTR_PREFIX_SAME:
+			// If there is a collision, prefix the variable name with prefix.
+			if exists || name == "this" {
+				name = prefix + "_" + name
+			}
+		case EXTR_PREFIX_ALL:
+			// Prefix all variable names with prefix.
+			name = prefix + "_" + name
+		case EXTR_PREFIX_INVALID:
+			// Only prefix invalid/numeric variable names with prefix.
+			if isNumeric || !common.IsName(name) || name == "this" {
+				name = prefix + "_" + name
+			}
+		case EXTR_IF_EXISTS:
+			// Only overwrite the variable if it already exists in the current symbol table, otherwise do nothing.
+			if !exists {
+				continue
+			}
+		case EXTR_PREFIX_IF_EXISTS:
+			// Only create prefixed variable names if the non-prefixed version of the same variable exists in the current symbol table.
+			if !exists {
+				continue
+			}
+			name = prefix + "_" + name
+		}
+
+		if !common.IsName(name) || name == "this" {
+			continue
+		}
+		context.Env.AddVariable("$"+name, values.Copy(value))
+		count++
 	}
 
-	return values.NewInt(int64(len(array.Keys))), nil
+	// Returns the number of variables successfully imported into the symbol table.
+	return values.NewInt(int64(count)), nil
 }
 
-// -------------------------------------- count -------------------------------------- MARK: count
+// -------------------------------------- in_array -------------------------------------- MARK: in_array
 
-func nativeFn_count(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
-	// Spec: https://www.php.net/manual/en/function.count.php
-	args, err := funcParamValidator.NewValidator("count").
-		AddParam("$array", []string{"Countable", "array"}, nil).
+func nativeFn_in_array(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.in-array.php
+	args, err := funcParamValidator.NewValidator("in_array").
+		AddParam("$needle", []string{"mixed"}, nil).
+		AddParam("$haystack", []string{"array"}, nil).
+		AddParam("$strict", []string{"bool"}, values.NewBool(false)).
 		Validate(args)
-		// TOOD count param mode
 	if err != nil {
 		return values.NewVoid(), err
 	}
 
+	// Searches for needle in haystack using loose comparison unless strict is set.
+	// If the third parameter strict is set to true then the in_array() function will also check the types of the needle in the haystack.
+	key, err := search(args[0], args[1].(*values.Array), args[2].(*values.Bool).Value)
+	return values.NewBool(key != nil), err
+}
+
+// -------------------------------------- key -------------------------------------- MARK: key
+
+func nativeFn_key(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.key.php
+	args, err := funcParamValidator.NewValidator("key").AddParam("$array", []string{"array"}, nil).Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// The key() function simply returns the key of the array element that's currently being pointed to by the internal pointer.
+	// If the internal pointer points beyond the end of the elements list or the array is empty, key() returns null.
 	array := args[0].(*values.Array)
-	return values.NewInt(int64(len(array.Elements))), nil
+	pointer := array.GetPointer()
+	if pointer < 0 || pointer >= array.Count() {
+		return values.NewNull(), nil
+	}
+	return array.GetKeys()[pointer], nil
+}
+
+// -------------------------------------- krsort -------------------------------------- MARK: krsort
+
+func nativeFn_krsort(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.krsort.php
+	// Sorts array in place by keys in descending order.
+	return sortByRef("krsort", args, true, SORT_REGULAR, true, true, true, context)
+}
+
+// -------------------------------------- ksort -------------------------------------- MARK: ksort
+
+func nativeFn_ksort(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.ksort.php
+	// Sorts array in place by keys in ascending order.
+	return sortByRef("ksort", args, true, SORT_REGULAR, true, false, true, context)
+}
+
+// -------------------------------------- natcasesort -------------------------------------- MARK: natcasesort
+
+func nativeFn_natcasesort(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.natcasesort.php
+	// natcasesort() is a case insensitive version of natsort().
+	return sortByRef("natcasesort", args, false, SORT_NATURAL|SORT_FLAG_CASE, false, false, true, context)
+}
+
+// -------------------------------------- natsort -------------------------------------- MARK: natsort
+
+func nativeFn_natsort(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.natsort.php
+	// This function implements a sort algorithm that orders alphanumeric strings in the way a human being would
+	// while maintaining key/value associations. This is described as a "natural ordering".
+	return sortByRef("natsort", args, false, SORT_NATURAL, false, false, true, context)
+}
+
+// -------------------------------------- next -------------------------------------- MARK: next
+
+func nativeFn_next(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.next.php
+	// next() behaves like current(), with one difference. It advances the internal array pointer one place forward before returning the element value.
+	// Returns the array value in the next place that's pointed to by the internal array pointer, or false if there are no more elements.
+	return movePointer("next", args, func(array *values.Array) {
+		if pointer := array.GetPointer(); pointer >= 0 && pointer < array.Count() {
+			array.SetPointer(pointer + 1)
+		}
+	}, context)
+}
+
+// -------------------------------------- prev -------------------------------------- MARK: prev
+
+func nativeFn_prev(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.prev.php
+	// Returns the array value in the previous place that's pointed to by the internal array pointer, or false if there are no more elements.
+	return movePointer("prev", args, func(array *values.Array) {
+		if pointer := array.GetPointer(); pointer >= 0 && pointer < array.Count() {
+			array.SetPointer(pointer - 1)
+		}
+	}, context)
+}
+
+// -------------------------------------- range -------------------------------------- MARK: range
+
+func nativeFn_range(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.range.php
+	args, err := funcParamValidator.NewValidator("range").
+		AddParam("$start", []string{"string", "int", "float"}, nil).
+		AddParam("$end", []string{"string", "int", "float"}, nil).
+		AddParam("$step", []string{"int", "float"}, values.NewInt(1)).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Create an array containing a range of elements.
+	step := toFloat(args[2])
+	if math.IsInf(step, 0) || math.IsNaN(step) {
+		return values.NewVoid(), phpError.NewError("Uncaught ValueError: range(): Argument #3 ($step) must be a finite number, %s provided", common.FormatFloat(step, 17))
+	}
+	if step == 0 {
+		return values.NewVoid(), phpError.NewError("Uncaught ValueError: range(): Argument #3 ($step) cannot be 0")
+	}
+	// If a step value is given, it will be used as the increment (or decrement) between elements in the sequence.
+	// step must not equal 0 and must not exceed the specified range. If not specified, step will default to 1.
+	isStepNegative := step < 0
+	step = math.Abs(step)
+	// A float step that can be interpreted as an int is used as int
+	isFloatStep := step != math.Trunc(step)
+
+	// If both start and end are strings, and step is int the produced array will be a sequence of bytes.
+	start, end := args[0], args[1]
+	if start.GetType() == values.StrValue && end.GetType() == values.StrValue {
+		startStr, endStr := start.(*values.Str).Value, end.(*values.Str).Value
+		if startStr != "" && endStr != "" && (!common.IsNumericString(startStr) || !common.IsNumericString(endStr)) && !isFloatStep {
+			for index, str := range []string{startStr, endStr} {
+				if len(str) > 1 {
+					context.Interpreter.PrintError(phpError.NewWarning(
+						"range(): Argument #%d ($%s) must be a single byte, subsequent bytes are ignored in %s",
+						index+1, []string{"start", "end"}[index], context.Stmt.GetPosString(),
+					))
+				}
+			}
+			low, high := int64(startStr[0]), int64(endStr[0])
+			return rangeOfNumbers(float64(low), float64(high), step, isStepNegative, func(value float64) values.RuntimeValue {
+				return values.NewStr(string([]byte{byte(value)}))
+			})
+		}
+	}
+
+	// Otherwise, the range is a sequence of numbers
+	isFloat := isFloatStep
+	numbers := make([]float64, 2)
+	for index, value := range []values.RuntimeValue{start, end} {
+		if value.GetType() == values.StrValue && value.(*values.Str).Value == "" {
+			context.Interpreter.PrintError(phpError.NewWarning(
+				"range(): Argument #%d ($%s) must not be empty, casted to 0 in %s", index+1, []string{"start", "end"}[index], context.Stmt.GetPosString(),
+			))
+		}
+		number := toNumber(value)
+		if number.GetType() == values.FloatValue {
+			if floatValue := number.(*values.Float).Value; math.IsInf(floatValue, 0) || math.IsNaN(floatValue) {
+				return values.NewVoid(), phpError.NewError(
+					"Uncaught ValueError: range(): Argument #%d ($%s) must be a finite number, %s provided",
+					index+1, []string{"start", "end"}[index], common.FormatFloat(floatValue, 17),
+				)
+			}
+			isFloat = true
+		}
+		numbers[index] = toFloat(number)
+	}
+	return rangeOfNumbers(numbers[0], numbers[1], step, isStepNegative, func(value float64) values.RuntimeValue {
+		if isFloat {
+			return values.NewFloat(value)
+		}
+		return values.NewInt(int64(value))
+	})
+}
+
+func rangeOfNumbers(start float64, end float64, step float64, isStepNegative bool, toValue func(float64) values.RuntimeValue) (values.RuntimeValue, phpError.Error) {
+	result := values.NewArray()
+	if start == end {
+		result.SetElement(nil, toValue(start))
+		return result, nil
+	}
+	if start < end && isStepNegative {
+		return values.NewVoid(), phpError.NewError("Uncaught ValueError: range(): Argument #3 ($step) must be greater than 0 for increasing ranges")
+	}
+	if math.Abs(end-start) < step {
+		return values.NewVoid(), phpError.NewError("Uncaught ValueError: range(): Argument #3 ($step) must not exceed the specified range")
+	}
+
+	// The elements are calculated from the start to avoid accumulating rounding errors
+	direction := 1.0
+	if start > end {
+		direction = -1.0
+	}
+	count := int64(math.Floor(math.Abs(end-start)/step + 1e-9))
+	for index := range count + 1 {
+		result.SetElement(nil, toValue(start+direction*float64(index)*step))
+	}
+	return result, nil
+}
+
+// -------------------------------------- reset -------------------------------------- MARK: reset
+
+func nativeFn_reset(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.reset.php
+	// reset() rewinds array's internal pointer to the first element and returns the value of the first array element.
+	// Returns the value of the first array element, or false if the array is empty.
+	return movePointer("reset", args, func(array *values.Array) { array.SetPointer(0) }, context)
+}
+
+// -------------------------------------- rsort -------------------------------------- MARK: rsort
+
+func nativeFn_rsort(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.rsort.php
+	// Sorts array in place by values in descending order.
+	return sortByRef("rsort", args, true, SORT_REGULAR, false, true, false, context)
+}
+
+// -------------------------------------- shuffle -------------------------------------- MARK: shuffle
+
+func nativeFn_shuffle(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.shuffle.php
+	args, err := funcParamValidator.NewValidator("shuffle").AddParam("$array", []string{"array"}, nil).Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// This function shuffles (randomizes the order of the elements in) an array.
+	// It assigns new keys to the elements in array. It will remove any existing keys that may have been assigned, rather than just reordering the keys.
+	arrayValues := args[0].(*values.Array).GetValues()
+	rand.Shuffle(len(arrayValues), func(i, j int) { arrayValues[i], arrayValues[j] = arrayValues[j], arrayValues[i] })
+	result := values.NewArray()
+	for _, value := range arrayValues {
+		result.SetElement(nil, value)
+	}
+	return values.NewBool(true), context.Interpreter.SetByRefArgument(context, 0, result)
+}
+
+// -------------------------------------- sort -------------------------------------- MARK: sort
+
+func nativeFn_sort(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.sort.php
+	// Sorts array in place by values in ascending order.
+	// This function assigns new keys to the elements in array. It will remove any existing keys that may have been assigned, rather than just reordering the keys.
+	return sortByRef("sort", args, true, SORT_REGULAR, false, false, false, context)
+}
+
+// -------------------------------------- uasort -------------------------------------- MARK: uasort
+
+func nativeFn_uasort(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.uasort.php
+	// Sorts array in place such that its keys maintain their correlation with the values they are associated with, using a user-defined comparison function.
+	return userSortByRef("uasort", args, false, true, context)
+}
+
+// -------------------------------------- uksort -------------------------------------- MARK: uksort
+
+func nativeFn_uksort(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.uksort.php
+	// Sorts array in place by keys using a user-supplied comparison function to determine the order.
+	return userSortByRef("uksort", args, true, true, context)
+}
+
+// -------------------------------------- usort -------------------------------------- MARK: usort
+
+func nativeFn_usort(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.usort.php
+	// Sorts array in place by values using a user-supplied comparison function to determine the order.
+	// This function assigns new keys to the elements in array.
+	return userSortByRef("usort", args, false, false, context)
 }
 
 // TODO array
-// TODO array_all
-// TODO array_any
-// TODO array_change_key_case
-// TODO array_chunk
-// TODO array_column
-// TODO array_combine
-// TODO array_count_values
-// TODO array_diff
-// TODO array_diff_assoc
-// TODO array_diff_key
-// TODO array_diff_uassoc
-// TODO array_diff_ukey
-// TODO array_fill
-// TODO array_fill_keys
-// TODO array_filter
-// TODO array_find
-// TODO array_find_key
-// TODO array_flip
-// TODO array_intersect
-// TODO array_intersect_assoc
-// TODO array_intersect_key
-// TODO array_intersect_uassoc
-// TODO array_intersect_ukey
-// TODO array_is_list
-// TODO array_keys
-// TODO array_map
-// TODO array_merge
-// TODO array_merge_recursive
-// TODO array_multisort
-// TODO array_pad
-// TODO array_product
-// TODO array_rand
-// TODO array_reduce
-// TODO array_replace
-// TODO array_replace_recursive
-// TODO array_reverse
-// TODO array_search
-// TODO array_shift
-// TODO array_slice
-// TODO array_splice
-// TODO array_sum
-// TODO array_udiff
-// TODO array_udiff_assoc
-// TODO array_udiff_uassoc
-// TODO array_uintersect
-// TODO array_uintersect_assoc
-// TODO array_uintersect_uassoc
-// TODO array_unique
-// TODO array_unshift
-// TODO array_values
-// TODO array_walk
-// TODO array_walk_recursive
-// TODO arsort
-// TODO asort
-// TODO compact
-// TODO current
-// TODO end
-// TODO extract
-// TODO in_array
-// TODO key
-// TODO krsort
-// TODO ksort
 // TODO list
-// TODO natcasesort
-// TODO natsort
-// TODO next
-// TODO pos
-// TODO prev
-// TODO range
-// TODO reset
-// TODO rsort
-// TODO shuffle
-// TODO sizeof
-// TODO sort
-// TODO uasort
-// TODO uksort
-// TODO usort
@@ -140,10 +140,8 @@ func TestArrayLast(t *testing.T) {
 // -------------------------------------- array_pop -------------------------------------- MARK: array_pop
 
 func TestArrayPop(t *testing.T) {
-	context := runtime.NewContext(nil, nil, nil)
-
 	array := values.NewArray()
-	actual, err := nativeFn_array_pop([]values.RuntimeValue{array}, context)
+	actual, err := lib_array_pop(array)
 	if err != nil {
 		t.Errorf("Unexpected error: %s", err)
 	} else if actual.GetType() != values.NullValue {
@@ -151,7 +149,7 @@ func TestArrayPop(t *testing.T) {
 	}
 
 	array.SetElement(nil, values.NewInt(42))
-	actual, err = nativeFn_array_pop([]values.RuntimeValue{array}, context)
+	actual, err = lib_array_pop(array)
 	if err != nil {
 		t.Errorf("Unexpected error: %s", err)
 	} else if actual.(*values.Int).Value != 42 {
@@ -164,13 +162,13 @@ func TestArrayPop(t *testing.T) {
 	array = values.NewArray()
 	array.SetElement(nil, values.NewInt(42))
 	array.SetElement(nil, values.NewInt(43))
-	actual, err = nativeFn_array_pop([]values.RuntimeValue{array}, context)
+	actual, err = lib_array_pop(array)
 	if err != nil {
 		t.Errorf("Unexpected error: %s", err)
 	} else if actual.(*values.Int).Value != 43 {
 		t.Errorf("Expected: 43, Got %d", actual.(*values.Int).Value)
 	}
-	if len(array.Keys) != 1 || array.Keys[0].(*values.Int).Value != 0 {
+	if array.Count() != 1 || array.GetKeys()[0].(*values.Int).Value != 0 {
 		t.Error("Expected array to contain one element with key 0 after pop")
 	}
 }
@@ -178,10 +176,8 @@ func TestArrayPop(t *testing.T) {
 // -------------------------------------- array_push -------------------------------------- MARK: array_push
 
 func TestArrayPush(t *testing.T) {
-	context := runtime.NewContext(nil, nil, nil)
-
 	array := values.NewArray()
-	actual, err := nativeFn_array_push([]values.RuntimeValue{array, values.NewInt(42)}, context)
+	actual, err := lib_array_push(array, values.NewInt(42))
 	if err != nil {
 		t.Errorf("Unexpected error: %s", err)
 	} else if actual.(*values.Int).Value != 1 {
@@ -190,28 +186,28 @@ func TestArrayPush(t *testing.T) {
 	if array.IsEmpty() {
 		t.Error("Expected array not to be empty after push")
 	}
-	if len(array.Keys) != 1 || array.Keys[0].(*values.Int).Value != 0 {
+	if array.Count() != 1 || array.GetKeys()[0].(*values.Int).Value != 0 {
 		t.Error("Expected array to contain one element with key 0 after push")
 	}
 
-	actual, err = nativeFn_array_push([]values.RuntimeValue{array, values.NewInt(43)}, context)
+	actual, err = lib_array_push(array, values.NewInt(43))
 	if err != nil {
 		t.Errorf("Unexpected error: %s", err)
 	} else if actual.(*values.Int).Value != 2 {
 		t.Errorf("Expected: 2, Got %d", actual.(*values.Int).Value)
 	}
-	if len(array.Keys) != 2 || array.Keys[1].(*values.Int).Value != 1 {
+	if array.Count() != 2 || array.GetKeys()[1].(*values.Int).Value != 1 {
 		t.Error("Expected array to contain one element with key 0 after push")
 	}
 
 	array = values.NewArray()
-	actual, err = nativeFn_array_push([]values.RuntimeValue{array, values.NewInt(42), values.NewInt(43)}, context)
+	actual, err = lib_array_push(array, values.NewInt(42), values.NewInt(43))
 	if err != nil {
 		t.Errorf("Unexpected error: %s", err)
 	} else if actual.(*values.Int).Value != 2 {
 		t.Errorf("Expected: 2, Got %d", actual.(*values.Int).Value)
 	}
-	if len(array.Keys) != 2 || array.Keys[0].(*values.Int).Value != 0 || array.Keys[1].(*values.Int).Value != 1 {
+	if array.Count() != 2 || array.GetKeys()[0].(*values.Int).Value != 0 || array.GetKeys()[1].(*values.Int).Value != 1 {
 		t.Error("Expected array to contain two elements with keys 0 and 1 after push")
 	}
 }
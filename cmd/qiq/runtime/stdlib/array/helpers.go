@@ -1,28 +1,164 @@
 package array
 
 import (
+	"QIQ/cmd/qiq/common"
 	"QIQ/cmd/qiq/phpError"
+	"QIQ/cmd/qiq/runtime"
 	"QIQ/cmd/qiq/runtime/stdlib/variableHandling"
 	"QIQ/cmd/qiq/runtime/values"
-	"slices"
+	"math"
+	"strings"
 )
 
 func RemoveByKey(array *values.Array, key values.RuntimeValue) phpError.Error {
 	if key == nil {
 		return phpError.NewError("Array.RemoveByKey: Key can not be nil")
 	}
-	mapKey, found, err := array.GetMapKey(key, true)
+	return array.RemoveElement(key)
+}
+
+// -------------------------------------- Callbacks -------------------------------------- MARK: Callbacks
+
+// Call the callback and convert the result to bool
+func callPredicate(callback values.RuntimeValue, args []values.RuntimeValue, context runtime.Context) (bool, phpError.Error) {
+	result, err := context.Interpreter.CallFunction(context, callback, args)
 	if err != nil {
-		return err
+		return false, err
 	}
-	if !found {
-		return phpError.NewError("Array.RemoveByKey: Key %s not found", values.ToPhpType(key))
+	return variableHandling.BoolVal(result)
+}
+
+// Call the comparison callback and convert the result to int.
+// The result is less than, equal to, or greater than zero if the first argument is considered to be
+// respectively less than, equal to, or greater than the second.
+func callComparator(callback values.RuntimeValue, lhs values.RuntimeValue, rhs values.RuntimeValue, context runtime.Context) (int64, phpError.Error) {
+	result, err := context.Interpreter.CallFunction(context, callback, []values.RuntimeValue{lhs, rhs})
+	if err != nil {
+		return 0, err
 	}
+	return variableHandling.IntVal(result, true)
+}
+
+// -------------------------------------- Comparison -------------------------------------- MARK: Comparison
+
+// Compare the values with "==" or (if strict) with "==="
+func equals(lhs values.RuntimeValue, rhs values.RuntimeValue, strict bool) (bool, phpError.Error) {
+	operator := "=="
+	if strict {
+		operator = "==="
+	}
+	result, err := variableHandling.Compare(lhs, operator, rhs)
+	if err != nil {
+		return false, err
+	}
+	return result.Value, nil
+}
+
+// Compare the string representations of the values (`(string) $lhs === (string) $rhs`)
+func stringEquals(lhs values.RuntimeValue, rhs values.RuntimeValue, context runtime.Context) (bool, phpError.Error) {
+	lhsStr, err := toString(lhs, context)
+	if err != nil {
+		return false, err
+	}
+	rhsStr, err := toString(rhs, context)
+	if err != nil {
+		return false, err
+	}
+	return lhsStr == rhsStr, nil
+}
+
+func toString(value values.RuntimeValue, context runtime.Context) (string, phpError.Error) {
+	return variableHandling.StrVal(value, context.Interpreter.GetIni().GetInt("precision"))
+}
 
-	delete(array.Elements, mapKey)
-	array.Keys = slices.DeleteFunc(array.Keys, func(k values.RuntimeValue) bool {
-		match, _ := variableHandling.Compare(k, "===", key)
-		return match.Value
-	})
-	return nil
+// Convert a value to an array key. Keys that are neither int nor string are converted to string
+// (e.g. the float 1.5 to "1.5"). Numeric strings are converted to int by SetElement.
+func toKey(value values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	if value.GetType() == values.IntValue || value.GetType() == values.StrValue {
+		return value, nil
+	}
+	str, err := toString(value, context)
+	if err != nil {
+		return nil, err
+	}
+	return values.NewStr(str), nil
+}
+
+// Convert the ASCII letters of the string to lowercase or uppercase
+func changeAsciiCase(str string, upper bool) string {
+	bytes := []byte(str)
+	for index, char := range bytes {
+		if upper && char >= 'a' && char <= 'z' {
+			bytes[index] = char - 32
+		} else if !upper && char >= 'A' && char <= 'Z' {
+			bytes[index] = char + 32
+		}
+	}
+	return string(bytes)
+}
+
+// -------------------------------------- Arithmetic -------------------------------------- MARK: Arithmetic
+
+// Convert a scalar value to int or float (e.g. for array_sum).
+// Non-numeric strings are converted to 0 and leading-numeric strings to their leading number.
+func toNumber(value values.RuntimeValue) values.RuntimeValue {
+	switch value.GetType() {
+	case values.IntValue, values.FloatValue:
+		return value
+	case values.BoolValue:
+		if value.(*values.Bool).Value {
+			return values.NewInt(1)
+		}
+		return values.NewInt(0)
+	case values.StrValue:
+		number, isNumeric := common.ParseNumericString(value.(*values.Str).Value)
+		if !isNumeric {
+			return values.NewInt(0)
+		}
+		if number.IsFloat {
+			return values.NewFloat(number.Float)
+		}
+		return values.NewInt(number.Int)
+	case values.ResourceValue:
+		return values.NewInt(value.(*values.Resource).Id)
+	default:
+		return values.NewInt(0)
+	}
+}
+
+func toFloat(value values.RuntimeValue) float64 {
+	if value.GetType() == values.IntValue {
+		return float64(value.(*values.Int).Value)
+	}
+	return value.(*values.Float).Value
+}
+
+// Add or multiply two numbers. Integers that overflow are converted to float.
+func calculate(lhs values.RuntimeValue, operator byte, rhs values.RuntimeValue) values.RuntimeValue {
+	if lhs.GetType() == values.IntValue && rhs.GetType() == values.IntValue {
+		a, b := lhs.(*values.Int).Value, rhs.(*values.Int).Value
+		switch operator {
+		case '+':
+			if !((b > 0 && a > math.MaxInt64-b) || (b < 0 && a < math.MinInt64-b)) {
+				return values.NewInt(a + b)
+			}
+		case '*':
+			product := a * b
+			if a == 0 || (product/a == b && !(a == -1 && b == math.MinInt64) && !(b == -1 && a == math.MinInt64)) {
+				return values.NewInt(product)
+			}
+		}
+	}
+	if operator == '+' {
+		return values.NewFloat(toFloat(lhs) + toFloat(rhs))
+	}
+	return values.NewFloat(toFloat(lhs) * toFloat(rhs))
+}
+
+// Get the type name of a value used in error messages (class name for objects)
+func typeName(value values.RuntimeValue) string {
+	if value.GetType() == values.ObjectValue {
+		return value.(*values.Object).Class.Name
+	}
+	return strings.ToLower(values.ToPhpType(value))
 }
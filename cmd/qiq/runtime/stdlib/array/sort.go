@@ -0,0 +1,171 @@
+package array
+
+import (
+	"QIQ/cmd/qiq/common"
+	"QIQ/cmd/qiq/phpError"
+	"QIQ/cmd/qiq/runtime"
+	"QIQ/cmd/qiq/runtime/funcParamValidator"
+	"QIQ/cmd/qiq/runtime/stdlib/variableHandling"
+	"QIQ/cmd/qiq/runtime/values"
+	"slices"
+	"strings"
+)
+
+// Compare two values and return a negative number, zero or a positive number
+// if lhs is less than, equal to, or greater than rhs.
+type compareFunc func(lhs values.RuntimeValue, rhs values.RuntimeValue) (int, phpError.Error)
+
+// Get the compare function for the given sort flags
+func getCompareFunc(flags int64, context runtime.Context) compareFunc {
+	// Spec: https://www.php.net/manual/en/function.sort.php
+	ignoreCase := flags&SORT_FLAG_CASE != 0
+	switch flags &^ SORT_FLAG_CASE {
+	case SORT_NUMERIC:
+		// SORT_NUMERIC - compare items numerically
+		return func(lhs, rhs values.RuntimeValue) (int, phpError.Error) {
+			lhsFloat, rhsFloat := toFloat(toNumber(lhs)), toFloat(toNumber(rhs))
+			switch {
+			case lhsFloat < rhsFloat:
+				return -1, nil
+			case lhsFloat > rhsFloat:
+				return 1, nil
+			default:
+				return 0, nil
+			}
+		}
+	case SORT_STRING, SORT_LOCALE_STRING:
+		// SORT_STRING - compare items as strings
+		// SORT_LOCALE_STRING - compare items as strings, based on the current locale
+		// TODO SORT_LOCALE_STRING - Use the current locale (setlocale)
+		return compareStrings(context, func(lhs, rhs string) int {
+			if ignoreCase {
+				lhs, rhs = changeAsciiCase(lhs, false), changeAsciiCase(rhs, false)
+			}
+			return strings.Compare(lhs, rhs)
+		})
+	case SORT_NATURAL:
+		// SORT_NATURAL - compare items as strings using "natural ordering" like natsort()
+		return compareStrings(context, func(lhs, rhs string) int { return common.NaturalCompare(lhs, rhs, ignoreCase) })
+	default:
+		// SORT_REGULAR - compare items normally (don't change types)
+		return compareRegular
+	}
+}
+
+// Compare the values with the spaceship operator "<=>"
+func compareRegular(lhs values.RuntimeValue, rhs values.RuntimeValue) (int, phpError.Error) {
+	result, err := variableHandling.CompareRelation(lhs, "<=>", rhs, false)
+	if err != nil {
+		return 0, err
+	}
+	return int(result.(*values.Int).Value), nil
+}
+
+// Create a compare function that compares the string representations of the values
+func compareStrings(context runtime.Context, compare func(lhs, rhs string) int) compareFunc {
+	return func(lhs, rhs values.RuntimeValue) (int, phpError.Error) {
+		lhsStr, err := toString(lhs, context)
+		if err != nil {
+			return 0, err
+		}
+		rhsStr, err := toString(rhs, context)
+		if err != nil {
+			return 0, err
+		}
+		return compare(lhsStr, rhsStr), nil
+	}
+}
+
+// Create a compare function that calls the user-defined comparison callback
+func compareWithCallback(callback values.RuntimeValue, context runtime.Context) compareFunc {
+	return func(lhs, rhs values.RuntimeValue) (int, phpError.Error) {
+		result, err := callComparator(callback, lhs, rhs, context)
+		return int(max(min(result, 1), -1)), err
+	}
+}
+
+// Sort the elements of the array by value or (if byKey) by key.
+// The sort is stable: Elements that compare as equal retain their original order.
+// Integer keys are renumbered unless keepKeys is set. String keys are only kept if keepKeys is set.
+func sortArray(array *values.Array, compare compareFunc, byKey bool, reverse bool, keepKeys bool) (*values.Array, phpError.Error) {
+	keys := array.GetKeys()
+	arrayValues := array.GetValues()
+	order := make([]int, len(keys))
+	for index := range order {
+		order[index] = index
+	}
+
+	var err phpError.Error
+	slices.SortStableFunc(order, func(lhs, rhs int) int {
+		if err != nil {
+			return 0
+		}
+		if reverse {
+			lhs, rhs = rhs, lhs
+		}
+		var result int
+		if byKey {
+			result, err = compare(keys[lhs], keys[rhs])
+		} else {
+			result, err = compare(arrayValues[lhs], arrayValues[rhs])
+		}
+		return result
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := values.NewArray()
+	for _, index := range order {
+		var key values.RuntimeValue
+		if keepKeys {
+			key = keys[index]
+		}
+		if err := result.SetElement(key, arrayValues[index]); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// Sort the array passed by reference (sort, rsort, asort, arsort, ksort, krsort, natsort and natcasesort)
+func sortByRef(funcName string, args []values.RuntimeValue, hasFlags bool, defaultFlags int64, byKey bool, reverse bool, keepKeys bool, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	validator := funcParamValidator.NewValidator(funcName).AddParam("$array", []string{"array"}, nil)
+	if hasFlags {
+		validator.AddParam("$flags", []string{"int"}, values.NewInt(defaultFlags))
+	}
+	args, err := validator.Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	flags := defaultFlags
+	if hasFlags {
+		flags = args[1].(*values.Int).Value
+	}
+	result, err := sortArray(args[0].(*values.Array), getCompareFunc(flags, context), byKey, reverse, keepKeys)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+	return values.NewBool(true), context.Interpreter.SetByRefArgument(context, 0, result)
+}
+
+// Sort the array passed by reference with a user-defined comparison function (usort, uasort and uksort)
+func userSortByRef(funcName string, args []values.RuntimeValue, byKey bool, keepKeys bool, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	args, err := funcParamValidator.NewValidator(funcName).
+		AddParam("$array", []string{"array"}, nil).
+		AddParam("$callback", []string{"mixed"}, nil).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+	if err := variableHandling.ValidateCallback(funcName, 2, "$callback", args[1], false, context); err != nil {
+		return values.NewVoid(), err
+	}
+
+	result, err := sortArray(args[0].(*values.Array), compareWithCallback(args[1], context), byKey, false, keepKeys)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+	return values.NewBool(true), context.Interpreter.SetByRefArgument(context, 0, result)
+}
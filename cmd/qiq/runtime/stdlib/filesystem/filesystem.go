@@ -5,6 +5,7 @@ import (
 	"QIQ/cmd/qiq/phpError"
 	"QIQ/cmd/qiq/runtime"
 	"QIQ/cmd/qiq/runtime/funcParamValidator"
+	"QIQ/cmd/qiq/runtime/stream"
 	"QIQ/cmd/qiq/runtime/values"
 	"os"
 	"slices"
@@ -18,6 +19,14 @@ func Register(environment runtime.Environment) {
 	environment.AddNativeFunction("is_uploaded_file", nativeFn_is_uploaded_file)
 	environment.AddNativeFunction("file_exists", nativeFn_file_exists)
 	environment.AddNativeFunction("rename", nativeFn_rename)
+	environment.AddNativeFunction("fclose", nativeFn_fclose)
+	environment.AddNativeFunction("feof", nativeFn_feof)
+	environment.AddNativeFunction("fgets", nativeFn_fgets)
+	environment.AddNativeFunction("fread", nativeFn_fread)
+	environment.AddNativeFunction("fwrite", nativeFn_fwrite)
+
+	// Category: Stream Functions
+	environment.AddNativeFunction("stream_get_contents", nativeFn_stream_get_contents)
 }
 
 // -------------------------------------- file_get_contents -------------------------------------- MARK: file_get_contents
@@ -136,6 +145,193 @@ func nativeFn_rename(args []values.RuntimeValue, _ runtime.Context) (values.Runt
 	return values.NewBool(goErr == nil), nil
 }
 
+// -------------------------------------- fclose -------------------------------------- MARK: fclose
+
+func nativeFn_fclose(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.fclose.php
+
+	args, err := funcParamValidator.NewValidator("fclose").AddParam("$stream", []string{"resource"}, nil).Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	stream, err := GetStream("fclose", args[0])
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	args[0].(*values.Resource).Close()
+	return values.NewBool(stream.Close() == nil), nil
+}
+
+// -------------------------------------- feof -------------------------------------- MARK: feof
+
+func nativeFn_feof(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.feof.php
+
+	args, err := funcParamValidator.NewValidator("feof").AddParam("$stream", []string{"resource"}, nil).Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	stream, err := GetStream("feof", args[0])
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	return values.NewBool(stream.Eof()), nil
+}
+
+// -------------------------------------- fgets -------------------------------------- MARK: fgets
+
+func nativeFn_fgets(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.fgets.php
+
+	args, err := funcParamValidator.NewValidator("fgets").
+		AddParam("$stream", []string{"resource"}, nil).
+		AddParam("$length", []string{"int"}, values.NewNull()).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	stream, err := GetStream("fgets", args[0])
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	length := 0
+	if args[1].GetType() == values.IntValue {
+		length = int(args[1].(*values.Int).Value)
+		if length <= 0 {
+			return values.NewVoid(), phpError.NewError("Uncaught ValueError: fgets(): Argument #2 ($length) must be greater than 0")
+		}
+	}
+
+	line, goErr := stream.ReadLine(length)
+	if goErr != nil {
+		context.Interpreter.PrintError(phpError.NewNotice("fgets(): Read failed: %s in %s", goErr, context.Stmt.GetPosString()))
+		return values.NewBool(false), nil
+	}
+	// If there is no more data to read in the file pointer, then false is returned.
+	if line == "" {
+		return values.NewBool(false), nil
+	}
+	return values.NewStr(line), nil
+}
+
+// -------------------------------------- fread -------------------------------------- MARK: fread
+
+func nativeFn_fread(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.fread.php
+
+	args, err := funcParamValidator.NewValidator("fread").
+		AddParam("$stream", []string{"resource"}, nil).
+		AddParam("$length", []string{"int"}, nil).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	stream, err := GetStream("fread", args[0])
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	length := int(args[1].(*values.Int).Value)
+	if length <= 0 {
+		return values.NewVoid(), phpError.NewError("Uncaught ValueError: fread(): Argument #2 ($length) must be greater than 0")
+	}
+
+	data, goErr := stream.Read(length)
+	if goErr != nil {
+		context.Interpreter.PrintError(phpError.NewNotice("fread(): Read failed: %s in %s", goErr, context.Stmt.GetPosString()))
+		return values.NewBool(false), nil
+	}
+	return values.NewStr(data), nil
+}
+
+// -------------------------------------- fwrite -------------------------------------- MARK: fwrite
+
+func nativeFn_fwrite(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.fwrite.php
+
+	args, err := funcParamValidator.NewValidator("fwrite").
+		AddParam("$stream", []string{"resource"}, nil).
+		AddParam("$data", []string{"string"}, nil).
+		AddParam("$length", []string{"int"}, values.NewNull()).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	stream, err := GetStream("fwrite", args[0])
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// If length is an int, writing will stop after length bytes have been written or the end of data is reached, whichever comes first.
+	data := args[1].(*values.Str).Value
+	if args[2].GetType() == values.IntValue {
+		length := max(int(args[2].(*values.Int).Value), 0)
+		if length < len(data) {
+			data = data[:length]
+		}
+	}
+
+	written, goErr := stream.Write(data)
+	if goErr != nil {
+		context.Interpreter.PrintError(phpError.NewNotice("fwrite(): Write of %d bytes failed: %s in %s", len(data), goErr, context.Stmt.GetPosString()))
+		return values.NewBool(false), nil
+	}
+	return values.NewInt(int64(written)), nil
+}
+
+// -------------------------------------- stream_get_contents -------------------------------------- MARK: stream_get_contents
+
+func nativeFn_stream_get_contents(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.stream-get-contents.php
+
+	args, err := funcParamValidator.NewValidator("stream_get_contents").
+		AddParam("$stream", []string{"resource"}, nil).
+		AddParam("$length", []string{"int"}, values.NewNull()).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// TODO stream_get_contents: Add support for param "$offset"
+
+	stream, err := GetStream("stream_get_contents", args[0])
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	length := -1
+	if args[1].GetType() == values.IntValue {
+		length = int(args[1].(*values.Int).Value)
+		if length < -1 {
+			return values.NewVoid(), phpError.NewError("Uncaught ValueError: stream_get_contents(): Argument #2 ($length) must be greater than or equal to -1")
+		}
+	}
+
+	content, goErr := stream.ReadAll(length)
+	if goErr != nil {
+		context.Interpreter.PrintError(phpError.NewNotice("stream_get_contents(): Read failed: %s in %s", goErr, context.Stmt.GetPosString()))
+		return values.NewBool(false), nil
+	}
+	return values.NewStr(content), nil
+}
+
+// Get the stream of a resource. A TypeError is returned if the resource is not an open stream.
+func GetStream(functionName string, resource values.RuntimeValue) (*stream.Stream, phpError.Error) {
+	stream, ok := resource.(*values.Resource).Handle.(*stream.Stream)
+	if !ok || resource.(*values.Resource).IsClosed || stream.IsClosed() {
+		return nil, phpError.NewError("Uncaught TypeError: %s(): supplied resource is not a valid stream resource", functionName)
+	}
+	return stream, nil
+}
+
 // TODO basename
 // TODO chgrp
 // TODO chmod
@@ -147,13 +343,10 @@ func nativeFn_rename(args []values.RuntimeValue, _ runtime.Context) (values.Runt
 // TODO disk_​free_​space
 // TODO disk_​total_​space
 // TODO diskfreespace
-// TODO fclose
 // TODO fdatasync
-// TODO feof
 // TODO fflush
 // TODO fgetc
 // TODO fgetcsv
-// TODO fgets
 // TODO fgetss
 // TODO file
 // TODO file_​get_​contents
@@ -173,14 +366,12 @@ func nativeFn_rename(args []values.RuntimeValue, _ runtime.Context) (values.Runt
 // TODO fpassthru
 // TODO fputcsv
 // TODO fputs
-// TODO fread
 // TODO fscanf
 // TODO fseek
 // TODO fstat
 // TODO fsync
 // TODO ftell
 // TODO ftruncate
-// TODO fwrite
 // TODO glob
 // TODO is_​executable
 // TODO is_​link
@@ -0,0 +1,236 @@
+package hash
+
+import (
+	"QIQ/cmd/qiq/phpError"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha3"
+	"crypto/sha512"
+	"encoding"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"hash/adler32"
+	"hash/crc32"
+	"hash/fnv"
+	"strings"
+)
+
+// Options of the hash algorithms passed to hash(), hash_file() and hash_init() (e.g. the seed of xxh32)
+type hashOptions struct {
+	seed   uint64
+	secret []byte
+}
+
+type algorithm struct {
+	name string
+	// Only cryptographic algorithms can be used for HMAC and key derivation
+	isCrypto bool
+	newHash  func(options hashOptions) hash.Hash
+}
+
+// Supported algorithms in the order of hash_algos()
+var algorithms = []*algorithm{
+	{name: "md5", isCrypto: true, newHash: func(hashOptions) hash.Hash { return md5.New() }},
+	{name: "sha1", isCrypto: true, newHash: func(hashOptions) hash.Hash { return sha1.New() }},
+	{name: "sha224", isCrypto: true, newHash: func(hashOptions) hash.Hash { return sha256.New224() }},
+	{name: "sha256", isCrypto: true, newHash: func(hashOptions) hash.Hash { return sha256.New() }},
+	{name: "sha384", isCrypto: true, newHash: func(hashOptions) hash.Hash { return sha512.New384() }},
+	{name: "sha512/224", isCrypto: true, newHash: func(hashOptions) hash.Hash { return sha512.New512_224() }},
+	{name: "sha512/256", isCrypto: true, newHash: func(hashOptions) hash.Hash { return sha512.New512_256() }},
+	{name: "sha512", isCrypto: true, newHash: func(hashOptions) hash.Hash { return sha512.New() }},
+	{name: "sha3-224", isCrypto: true, newHash: func(hashOptions) hash.Hash { return sha3.New224() }},
+	{name: "sha3-256", isCrypto: true, newHash: func(hashOptions) hash.Hash { return sha3.New256() }},
+	{name: "sha3-384", isCrypto: true, newHash: func(hashOptions) hash.Hash { return sha3.New384() }},
+	{name: "sha3-512", isCrypto: true, newHash: func(hashOptions) hash.Hash { return sha3.New512() }},
+	{name: "adler32", newHash: func(hashOptions) hash.Hash { return adler32.New() }},
+	{name: "crc32", newHash: func(hashOptions) hash.Hash { return newCrc32Bzip2() }},
+	{name: "crc32b", newHash: func(hashOptions) hash.Hash { return crc32.NewIEEE() }},
+	{name: "crc32c", newHash: func(hashOptions) hash.Hash { return crc32.New(crc32.MakeTable(crc32.Castagnoli)) }},
+	{name: "fnv132", newHash: func(hashOptions) hash.Hash { return fnv.New32() }},
+	{name: "fnv1a32", newHash: func(hashOptions) hash.Hash { return fnv.New32a() }},
+	{name: "fnv164", newHash: func(hashOptions) hash.Hash { return fnv.New64() }},
+	{name: "fnv1a64", newHash: func(hashOptions) hash.Hash { return fnv.New64a() }},
+	{name: "joaat", newHash: func(hashOptions) hash.Hash { return &joaat{} }},
+	{name: "xxh32", newHash: func(options hashOptions) hash.Hash { return newXxh32(uint32(options.seed)) }},
+	{name: "xxh64", newHash: func(options hashOptions) hash.Hash { return newXxh64(options.seed) }},
+	{name: "xxh3", newHash: func(options hashOptions) hash.Hash { return newXxh3(options.seed, options.secret, false) }},
+	{name: "xxh128", newHash: func(options hashOptions) hash.Hash { return newXxh3(options.seed, options.secret, true) }},
+}
+
+// Get the algorithm with the given name (case-insensitive)
+func getAlgorithm(name string) (*algorithm, bool) {
+	name = strings.ToLower(name)
+	for _, algorithm := range algorithms {
+		if algorithm.name == name {
+			return algorithm, true
+		}
+	}
+	return nil, false
+}
+
+// Calculate the digest of the data
+func (algorithm *algorithm) sum(data []byte, options hashOptions) []byte {
+	hash := algorithm.newHash(options)
+	hash.Write(data)
+	return hash.Sum(nil)
+}
+
+// -------------------------------------- Hash context -------------------------------------- MARK: Hash context
+
+// Internal state of a HashContext object
+type hashContext struct {
+	algorithm *algorithm
+	options   hashOptions
+	hash      hash.Hash
+	// Key padded to the block size of the algorithm, nil if HMAC is not used
+	hmacKey     []byte
+	isFinalized bool
+}
+
+// Spec: https://www.rfc-editor.org/rfc/rfc2104
+func newHashContext(algorithm *algorithm, options hashOptions, hmacKey []byte) *hashContext {
+	context := &hashContext{algorithm: algorithm, options: options, hash: algorithm.newHash(options)}
+	if hmacKey != nil {
+		blockSize := context.hash.BlockSize()
+		if len(hmacKey) > blockSize {
+			hmacKey = algorithm.sum(hmacKey, options)
+		}
+		context.hmacKey = make([]byte, blockSize)
+		copy(context.hmacKey, hmacKey)
+		context.hash.Write(xorKey(context.hmacKey, 0x36))
+	}
+	return context
+}
+
+func xorKey(key []byte, pad byte) []byte {
+	result := make([]byte, len(key))
+	for i, char := range key {
+		result[i] = char ^ pad
+	}
+	return result
+}
+
+// Finalize the context and return the digest
+func (context *hashContext) final() []byte {
+	context.isFinalized = true
+	digest := context.hash.Sum(nil)
+	if context.hmacKey == nil {
+		return digest
+	}
+	outer := context.algorithm.newHash(context.options)
+	outer.Write(xorKey(context.hmacKey, 0x5c))
+	outer.Write(digest)
+	return outer.Sum(nil)
+}
+
+// Copy the context including the state of the hash algorithm (used by hash_copy and when cloning a HashContext object)
+func (context *hashContext) CloneInternal() (any, phpError.Error) {
+	clone := *context
+	clone.hash = context.algorithm.newHash(context.options)
+	state, err := context.hash.(encoding.BinaryMarshaler).MarshalBinary()
+	if err == nil {
+		err = clone.hash.(encoding.BinaryUnmarshaler).UnmarshalBinary(state)
+	}
+	if err != nil {
+		return nil, phpError.NewError("Cannot copy the state of %s: %s", context.algorithm.name, err)
+	}
+	return &clone, nil
+}
+
+// -------------------------------------- CRC32 -------------------------------------- MARK: CRC32
+
+// CRC-32 as used by bzip2 (same polynomial as crc32b but not reflected).
+// PHP outputs the checksum of the algorithm "crc32" in little endian byte order.
+type crc32Bzip2 struct {
+	crc uint32
+}
+
+var crc32Bzip2Table = func() [256]uint32 {
+	var table [256]uint32
+	for i := range table {
+		crc := uint32(i) << 24
+		for range 8 {
+			if crc&0x80000000 != 0 {
+				crc = crc<<1 ^ 0x04C11DB7
+			} else {
+				crc <<= 1
+			}
+		}
+		table[i] = crc
+	}
+	return table
+}()
+
+func newCrc32Bzip2() *crc32Bzip2 {
+	return &crc32Bzip2{crc: 0xFFFFFFFF}
+}
+
+func (hash *crc32Bzip2) Write(data []byte) (int, error) {
+	for _, char := range data {
+		hash.crc = hash.crc<<8 ^ crc32Bzip2Table[byte(hash.crc>>24)^char]
+	}
+	return len(data), nil
+}
+
+func (hash *crc32Bzip2) Sum(data []byte) []byte {
+	return binary.LittleEndian.AppendUint32(data, ^hash.crc)
+}
+
+func (hash *crc32Bzip2) Reset()         { hash.crc = 0xFFFFFFFF }
+func (hash *crc32Bzip2) Size() int      { return 4 }
+func (hash *crc32Bzip2) BlockSize() int { return 1 }
+
+func (hash *crc32Bzip2) MarshalBinary() ([]byte, error) {
+	return binary.BigEndian.AppendUint32(nil, hash.crc), nil
+}
+
+func (hash *crc32Bzip2) UnmarshalBinary(data []byte) error {
+	if len(data) != 4 {
+		return fmt.Errorf("invalid state size %d", len(data))
+	}
+	hash.crc = binary.BigEndian.Uint32(data)
+	return nil
+}
+
+// -------------------------------------- Joaat -------------------------------------- MARK: Joaat
+
+// Jenkins one-at-a-time hash
+// Spec: https://en.wikipedia.org/wiki/Jenkins_hash_function#one_at_a_time
+type joaat struct {
+	hash uint32
+}
+
+func (hash *joaat) Write(data []byte) (int, error) {
+	for _, char := range data {
+		hash.hash += uint32(char)
+		hash.hash += hash.hash << 10
+		hash.hash ^= hash.hash >> 6
+	}
+	return len(data), nil
+}
+
+func (hash *joaat) Sum(data []byte) []byte {
+	result := hash.hash
+	result += result << 3
+	result ^= result >> 11
+	result += result << 15
+	return binary.BigEndian.AppendUint32(data, result)
+}
+
+func (hash *joaat) Reset()         { hash.hash = 0 }
+func (hash *joaat) Size() int      { return 4 }
+func (hash *joaat) BlockSize() int { return 4 }
+
+func (hash *joaat) MarshalBinary() ([]byte, error) {
+	return binary.BigEndian.AppendUint32(nil, hash.hash), nil
+}
+
+func (hash *joaat) UnmarshalBinary(data []byte) error {
+	if len(data) != 4 {
+		return fmt.Errorf("invalid state size %d", len(data))
+	}
+	hash.hash = binary.BigEndian.Uint32(data)
+	return nil
+}
@@ -0,0 +1,530 @@
+package hash
+
+import (
+	"QIQ/cmd/qiq/common"
+	"QIQ/cmd/qiq/phpError"
+	"QIQ/cmd/qiq/runtime"
+	"QIQ/cmd/qiq/runtime/funcParamValidator"
+	"QIQ/cmd/qiq/runtime/values"
+	"crypto/hkdf"
+	"crypto/pbkdf2"
+	"crypto/subtle"
+	"encoding/hex"
+	"hash"
+)
+
+const HASH_HMAC int64 = 1
+
+func Register(environment runtime.Environment) {
+	// Category: Hash Functions
+	environment.AddNativeFunction("hash", nativeFn_hash)
+	environment.AddNativeFunction("hash_algos", nativeFn_hash_algos)
+	environment.AddNativeFunction("hash_copy", nativeFn_hash_copy)
+	environment.AddNativeFunction("hash_equals", nativeFn_hash_equals)
+	environment.AddNativeFunction("hash_file", nativeFn_hash_file)
+	environment.AddNativeFunction("hash_final", nativeFn_hash_final)
+	environment.AddNativeFunction("hash_hkdf", nativeFn_hash_hkdf)
+	environment.AddNativeFunction("hash_hmac", nativeFn_hash_hmac)
+	environment.AddNativeFunction("hash_hmac_algos", nativeFn_hash_hmac_algos)
+	environment.AddNativeFunction("hash_hmac_file", nativeFn_hash_hmac_file)
+	environment.AddNativeFunction("hash_init", nativeFn_hash_init)
+	environment.AddNativeFunction("hash_pbkdf2", nativeFn_hash_pbkdf2)
+	environment.AddNativeFunction("hash_update", nativeFn_hash_update)
+
+	// Const Category: Hash Constants
+	// Spec: https://www.php.net/manual/en/hash.constants.php
+	environment.AddPredefinedConstant("HASH_HMAC", values.NewInt(HASH_HMAC))
+}
+
+// -------------------------------------- Helpers -------------------------------------- MARK: Helpers
+
+// Get the algorithm for the parameter $algo. Returns a ValueError if the algorithm is unknown
+// or, if onlyCrypto is set, not a cryptographic algorithm.
+func getAlgorithmArg(funcName string, name string, onlyCrypto bool) (*algorithm, phpError.Error) {
+	algorithm, found := getAlgorithm(name)
+	if onlyCrypto && (!found || !algorithm.isCrypto) {
+		return nil, phpError.NewError(
+			"Uncaught ValueError: %s(): Argument #1 ($algo) must be a valid cryptographic hashing algorithm", funcName,
+		)
+	}
+	if !found {
+		return nil, phpError.NewError("Uncaught ValueError: %s(): Argument #1 ($algo) must be a valid hashing algorithm", funcName)
+	}
+	return algorithm, nil
+}
+
+// Get the options of the algorithm from the parameter $options
+func getOptionsArg(algorithm *algorithm, options *values.Array) (hashOptions, phpError.Error) {
+	result := hashOptions{}
+	if algorithm.name != "xxh32" && algorithm.name != "xxh64" && algorithm.name != "xxh3" && algorithm.name != "xxh128" {
+		return result, nil
+	}
+
+	seed, hasSeed := options.GetElement(values.NewStr("seed"))
+	if hasSeed && seed.GetType() == values.IntValue {
+		result.seed = uint64(seed.(*values.Int).Value)
+	}
+
+	if algorithm.name != "xxh3" && algorithm.name != "xxh128" {
+		return result, nil
+	}
+	secret, hasSecret := options.GetElement(values.NewStr("secret"))
+	if !hasSecret || secret.GetType() != values.StrValue {
+		return result, nil
+	}
+	if hasSeed && seed.GetType() == values.IntValue {
+		return result, phpError.NewError(
+			"Uncaught Error: %s: Only one of seed or secret is to be passed for initialization", algorithm.name,
+		)
+	}
+	if len(secret.(*values.Str).Value) < xxh3SecretSizeMin {
+		return result, phpError.NewError(
+			"Uncaught Error: %s: Secret length must be >= %d bytes, %d bytes passed",
+			algorithm.name, xxh3SecretSizeMin, len(secret.(*values.Str).Value),
+		)
+	}
+	result.secret = []byte(secret.(*values.Str).Value)
+	return result, nil
+}
+
+// Get the internal state of the parameter $context.
+// Returns a TypeError if it is not a HashContext or, if it must be active, it is already finalized.
+func getContextArg(funcName string, value values.RuntimeValue, mustBeActive bool) (*hashContext, phpError.Error) {
+	object := value.(*values.Object)
+	context, ok := object.Internal.(*hashContext)
+	if !ok {
+		return nil, phpError.NewError(
+			"Uncaught TypeError: %s(): Argument #1 ($context) must be of type HashContext, %s given", funcName, object.Class.Name,
+		)
+	}
+	if mustBeActive && context.isFinalized {
+		return nil, phpError.NewError(
+			"Uncaught TypeError: %s(): Argument #1 ($context) must be a valid, non-finalized HashContext", funcName,
+		)
+	}
+	return context, nil
+}
+
+func newContextObject(context runtime.Context, state *hashContext) *values.Object {
+	class, _ := context.Interpreter.GetClass("HashContext")
+	object := context.Interpreter.NewObject(class)
+	object.Internal = state
+	return object
+}
+
+// Read the file for the hashing functions. Prints a warning if the file does not exist.
+func readFile(funcName string, filename string, context runtime.Context) (string, bool) {
+	if !common.PathExists(filename) {
+		context.Interpreter.PrintError(phpError.NewWarning(
+			"%s(%s): Failed to open stream: No such file or directory in %s", funcName, filename, context.Stmt.GetPosString(),
+		))
+		return "", false
+	}
+	content, err := common.ReadFile(filename)
+	return content, err == nil
+}
+
+// Get the digest as raw binary string or as lowercase hexits
+func digestToStr(digest []byte, binary bool) *values.Str {
+	if binary {
+		return values.NewStr(string(digest))
+	}
+	return values.NewStr(hex.EncodeToString(digest))
+}
+
+// Calculate the digest of the data with the given algorithm and HMAC key (nil if HMAC is not used)
+func calculateDigest(algorithm *algorithm, options hashOptions, hmacKey []byte, data string) []byte {
+	context := newHashContext(algorithm, options, hmacKey)
+	context.hash.Write([]byte(data))
+	return context.final()
+}
+
+// -------------------------------------- hash -------------------------------------- MARK: hash
+
+func nativeFn_hash(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.hash.php
+
+	args, err := funcParamValidator.NewValidator("hash").
+		AddParam("$algo", []string{"string"}, nil).
+		AddParam("$data", []string{"string"}, nil).
+		AddParam("$binary", []string{"bool"}, values.NewBool(false)).
+		AddParam("$options", []string{"array"}, values.NewArray()).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	algorithm, err := getAlgorithmArg("hash", args[0].(*values.Str).Value, false)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+	options, err := getOptionsArg(algorithm, args[3].(*values.Array))
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	digest := calculateDigest(algorithm, options, nil, args[1].(*values.Str).Value)
+	return digestToStr(digest, args[2].(*values.Bool).Value), nil
+}
+
+// -------------------------------------- hash_algos -------------------------------------- MARK: hash_algos
+
+func nativeFn_hash_algos(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.hash-algos.php
+
+	_, err := funcParamValidator.NewValidator("hash_algos").Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	result := values.NewArray()
+	for _, algorithm := range algorithms {
+		result.SetElement(nil, values.NewStr(algorithm.name))
+	}
+	return result, nil
+}
+
+// -------------------------------------- hash_copy -------------------------------------- MARK: hash_copy
+
+func nativeFn_hash_copy(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.hash-copy.php
+
+	args, err := funcParamValidator.NewValidator("hash_copy").AddParam("$context", []string{"object"}, nil).Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	state, err := getContextArg("hash_copy", args[0], true)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	clone, err := state.CloneInternal()
+	if err != nil {
+		return values.NewVoid(), err
+	}
+	return newContextObject(context, clone.(*hashContext)), nil
+}
+
+// -------------------------------------- hash_equals -------------------------------------- MARK: hash_equals
+
+func nativeFn_hash_equals(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.hash-equals.php
+
+	args, err := funcParamValidator.NewValidator("hash_equals").
+		AddParam("$known_string", []string{"string"}, nil).
+		AddParam("$user_string", []string{"string"}, nil).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Timing attack safe comparison
+	return values.NewBool(subtle.ConstantTimeCompare(
+		[]byte(args[0].(*values.Str).Value), []byte(args[1].(*values.Str).Value),
+	) == 1), nil
+}
+
+// -------------------------------------- hash_file -------------------------------------- MARK: hash_file
+
+func nativeFn_hash_file(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.hash-file.php
+
+	args, err := funcParamValidator.NewValidator("hash_file").
+		AddParam("$algo", []string{"string"}, nil).
+		AddParam("$filename", []string{"string"}, nil).
+		AddParam("$binary", []string{"bool"}, values.NewBool(false)).
+		AddParam("$options", []string{"array"}, values.NewArray()).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	algorithm, err := getAlgorithmArg("hash_file", args[0].(*values.Str).Value, false)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+	options, err := getOptionsArg(algorithm, args[3].(*values.Array))
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	content, found := readFile("hash_file", args[1].(*values.Str).Value, context)
+	if !found {
+		return values.NewBool(false), nil
+	}
+
+	digest := calculateDigest(algorithm, options, nil, content)
+	return digestToStr(digest, args[2].(*values.Bool).Value), nil
+}
+
+// -------------------------------------- hash_final -------------------------------------- MARK: hash_final
+
+func nativeFn_hash_final(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.hash-final.php
+
+	args, err := funcParamValidator.NewValidator("hash_final").
+		AddParam("$context", []string{"object"}, nil).
+		AddParam("$binary", []string{"bool"}, values.NewBool(false)).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	state, err := getContextArg("hash_final", args[0], true)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	return digestToStr(state.final(), args[1].(*values.Bool).Value), nil
+}
+
+// -------------------------------------- hash_hkdf -------------------------------------- MARK: hash_hkdf
+
+func nativeFn_hash_hkdf(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.hash-hkdf.php
+
+	args, err := funcParamValidator.NewValidator("hash_hkdf").
+		AddParam("$algo", []string{"string"}, nil).
+		AddParam("$key", []string{"string"}, nil).
+		AddParam("$length", []string{"int"}, values.NewInt(0)).
+		AddParam("$info", []string{"string"}, values.NewStr("")).
+		AddParam("$salt", []string{"string"}, values.NewStr("")).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	algorithm, err := getAlgorithmArg("hash_hkdf", args[0].(*values.Str).Value, true)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	key := args[1].(*values.Str).Value
+	if key == "" {
+		return values.NewVoid(), phpError.NewError("Uncaught ValueError: hash_hkdf(): Argument #2 ($key) cannot be empty")
+	}
+
+	newHash := func() hash.Hash { return algorithm.newHash(hashOptions{}) }
+	size := newHash().Size()
+	length := args[2].(*values.Int).Value
+	if length < 0 {
+		return values.NewVoid(), phpError.NewError(
+			"Uncaught ValueError: hash_hkdf(): Argument #3 ($length) must be greater than or equal to 0",
+		)
+	}
+	if length > int64(size)*255 {
+		return values.NewVoid(), phpError.NewError(
+			"Uncaught ValueError: hash_hkdf(): Argument #3 ($length) must be less than or equal to %d", size*255,
+		)
+	}
+	if length == 0 {
+		length = int64(size)
+	}
+
+	result, goErr := hkdf.Key(newHash, []byte(key), []byte(args[4].(*values.Str).Value), args[3].(*values.Str).Value, int(length))
+	if goErr != nil {
+		return values.NewVoid(), phpError.NewError("hash_hkdf(): %s", goErr)
+	}
+	return values.NewStr(string(result)), nil
+}
+
+// -------------------------------------- hash_hmac -------------------------------------- MARK: hash_hmac
+
+func nativeFn_hash_hmac(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.hash-hmac.php
+
+	args, err := funcParamValidator.NewValidator("hash_hmac").
+		AddParam("$algo", []string{"string"}, nil).
+		AddParam("$data", []string{"string"}, nil).
+		AddParam("$key", []string{"string"}, nil).
+		AddParam("$binary", []string{"bool"}, values.NewBool(false)).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	algorithm, err := getAlgorithmArg("hash_hmac", args[0].(*values.Str).Value, true)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	digest := calculateDigest(algorithm, hashOptions{}, []byte(args[2].(*values.Str).Value), args[1].(*values.Str).Value)
+	return digestToStr(digest, args[3].(*values.Bool).Value), nil
+}
+
+// -------------------------------------- hash_hmac_algos -------------------------------------- MARK: hash_hmac_algos
+
+func nativeFn_hash_hmac_algos(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.hash-hmac-algos.php
+
+	_, err := funcParamValidator.NewValidator("hash_hmac_algos").Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	result := values.NewArray()
+	for _, algorithm := range algorithms {
+		if algorithm.isCrypto {
+			result.SetElement(nil, values.NewStr(algorithm.name))
+		}
+	}
+	return result, nil
+}
+
+// -------------------------------------- hash_hmac_file -------------------------------------- MARK: hash_hmac_file
+
+func nativeFn_hash_hmac_file(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.hash-hmac-file.php
+
+	args, err := funcParamValidator.NewValidator("hash_hmac_file").
+		AddParam("$algo", []string{"string"}, nil).
+		AddParam("$filename", []string{"string"}, nil).
+		AddParam("$key", []string{"string"}, nil).
+		AddParam("$binary", []string{"bool"}, values.NewBool(false)).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	algorithm, err := getAlgorithmArg("hash_hmac_file", args[0].(*values.Str).Value, true)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	content, found := readFile("hash_hmac_file", args[1].(*values.Str).Value, context)
+	if !found {
+		return values.NewBool(false), nil
+	}
+
+	digest := calculateDigest(algorithm, hashOptions{}, []byte(args[2].(*values.Str).Value), content)
+	return digestToStr(digest, args[3].(*values.Bool).Value), nil
+}
+
+// -------------------------------------- hash_init -------------------------------------- MARK: hash_init
+
+func nativeFn_hash_init(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.hash-init.php
+
+	args, err := funcParamValidator.NewValidator("hash_init").
+		AddParam("$algo", []string{"string"}, nil).
+		AddParam("$flags", []string{"int"}, values.NewInt(0)).
+		AddParam("$key", []string{"string"}, values.NewStr("")).
+		AddParam("$options", []string{"array"}, values.NewArray()).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	algorithm, err := getAlgorithmArg("hash_init", args[0].(*values.Str).Value, false)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	var hmacKey []byte
+	if args[1].(*values.Int).Value&HASH_HMAC != 0 {
+		if !algorithm.isCrypto {
+			return values.NewVoid(), phpError.NewError(
+				"Uncaught ValueError: hash_init(): Argument #1 ($algo) must be a cryptographic hashing algorithm if HMAC is requested",
+			)
+		}
+		key := args[2].(*values.Str).Value
+		if key == "" {
+			return values.NewVoid(), phpError.NewError(
+				"Uncaught ValueError: hash_init(): Argument #3 ($key) cannot be empty when HMAC is requested",
+			)
+		}
+		hmacKey = []byte(key)
+	}
+
+	options, err := getOptionsArg(algorithm, args[3].(*values.Array))
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	return newContextObject(context, newHashContext(algorithm, options, hmacKey)), nil
+}
+
+// -------------------------------------- hash_pbkdf2 -------------------------------------- MARK: hash_pbkdf2
+
+func nativeFn_hash_pbkdf2(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.hash-pbkdf2.php
+
+	args, err := funcParamValidator.NewValidator("hash_pbkdf2").
+		AddParam("$algo", []string{"string"}, nil).
+		AddParam("$password", []string{"string"}, nil).
+		AddParam("$salt", []string{"string"}, nil).
+		AddParam("$iterations", []string{"int"}, nil).
+		AddParam("$length", []string{"int"}, values.NewInt(0)).
+		AddParam("$binary", []string{"bool"}, values.NewBool(false)).
+		AddParam("$options", []string{"array"}, values.NewArray()).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	algorithm, err := getAlgorithmArg("hash_pbkdf2", args[0].(*values.Str).Value, true)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	iterations := args[3].(*values.Int).Value
+	if iterations <= 0 {
+		return values.NewVoid(), phpError.NewError(
+			"Uncaught ValueError: hash_pbkdf2(): Argument #4 ($iterations) must be greater than 0",
+		)
+	}
+	length := args[4].(*values.Int).Value
+	if length < 0 {
+		return values.NewVoid(), phpError.NewError(
+			"Uncaught ValueError: hash_pbkdf2(): Argument #5 ($length) must be greater than or equal to 0",
+		)
+	}
+
+	binary := args[5].(*values.Bool).Value
+	newHash := func() hash.Hash { return algorithm.newHash(hashOptions{}) }
+	// The length is the number of bytes for binary output and otherwise the number of hexits
+	keyLength := int(length)
+	if keyLength == 0 {
+		keyLength = newHash().Size()
+		if !binary {
+			length = int64(keyLength) * 2
+		}
+	} else if !binary {
+		keyLength = (keyLength + 1) / 2
+	}
+
+	key, goErr := pbkdf2.Key(newHash, args[1].(*values.Str).Value, []byte(args[2].(*values.Str).Value), int(iterations), keyLength)
+	if goErr != nil {
+		return values.NewVoid(), phpError.NewError("hash_pbkdf2(): %s", goErr)
+	}
+	if binary {
+		return values.NewStr(string(key)), nil
+	}
+	return values.NewStr(hex.EncodeToString(key)[:length]), nil
+}
+
+// -------------------------------------- hash_update -------------------------------------- MARK: hash_update
+
+func nativeFn_hash_update(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.hash-update.php
+
+	args, err := funcParamValidator.NewValidator("hash_update").
+		AddParam("$context", []string{"object"}, nil).
+		AddParam("$data", []string{"string"}, nil).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	state, err := getContextArg("hash_update", args[0], true)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	state.hash.Write([]byte(args[1].(*values.Str).Value))
+	return values.NewBool(true), nil
+}
+
+// TODO hash_update_file
+// TODO hash_update_stream
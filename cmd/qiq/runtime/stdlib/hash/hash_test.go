@@ -0,0 +1,105 @@
+package hash
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// -------------------------------------- algorithms -------------------------------------- MARK: algorithms
+
+func TestAlgorithms(t *testing.T) {
+	doTest := func(t *testing.T, name string, input string, options hashOptions, expected string) {
+		algorithm, found := getAlgorithm(name)
+		if !found {
+			t.Fatalf("%s - Algorithm not found", name)
+		}
+		if actual := hex.EncodeToString(algorithm.sum([]byte(input), options)); actual != expected {
+			t.Errorf("%s(%q) - Expected: %s, Got %s", name, input, expected, actual)
+		}
+	}
+
+	doTest(t, "crc32", "a", hashOptions{}, "6b9b9319")
+	doTest(t, "crc32b", "The quick brown fox jumps over the lazy dog", hashOptions{}, "414fa339")
+	doTest(t, "crc32c", "123456789", hashOptions{}, "e3069283")
+	doTest(t, "joaat", "a", hashOptions{}, "ca2e9442")
+	doTest(t, "fnv1a32", "", hashOptions{}, "811c9dc5")
+	doTest(t, "xxh32", "", hashOptions{}, "02cc5d05")
+	doTest(t, "xxh32", "abc", hashOptions{}, "32d153ff")
+	doTest(t, "xxh64", "", hashOptions{}, "ef46db3751d8e999")
+	doTest(t, "xxh64", "abc", hashOptions{}, "44bc2cf5ad770999")
+	doTest(t, "xxh3", "", hashOptions{}, "2d06800538d394c2")
+	doTest(t, "xxh3", "abc", hashOptions{}, "78af5f94892f3950")
+	doTest(t, "xxh3", "hello world", hashOptions{}, "d447b1ea40e6988b")
+	doTest(t, "xxh128", "", hashOptions{}, "99aa06d3014798d86001c324468d497f")
+	doTest(t, "xxh128", "abc", hashOptions{}, "06b05ab6733a618578af5f94892f3950")
+	doTest(t, "xxh128", "hello world", hashOptions{}, "df8d09e93f874900a99b8775cc15b6c7")
+}
+
+func TestXxhashStreaming(t *testing.T) {
+	// Writing in chunks must give the same result as a single write for all code paths
+	// (e.g. up to 16, 128, 240 and more bytes for xxh3, full blocks and a partially buffered last stripe)
+	input := make([]byte, 2100)
+	for i := range input {
+		input[i] = byte(i*7 + i/13)
+	}
+	for _, name := range []string{"xxh32", "xxh64", "xxh3", "xxh128"} {
+		algorithm, _ := getAlgorithm(name)
+		for _, length := range []int{0, 1, 3, 4, 8, 9, 16, 17, 31, 33, 128, 129, 240, 241, 255, 256, 257, 1024, 1025, 2048, 2100} {
+			for _, seed := range []uint64{0, 42} {
+				for _, chunkSize := range []int{1, 7, 64, 100, 300} {
+					options := hashOptions{seed: seed}
+					expected := hex.EncodeToString(algorithm.sum(input[:length], options))
+					hash := algorithm.newHash(options)
+					for offset := 0; offset < length; offset += chunkSize {
+						hash.Write(input[offset:min(offset+chunkSize, length)])
+					}
+					if actual := hex.EncodeToString(hash.Sum(nil)); actual != expected {
+						t.Errorf("%s length %d seed %d chunk size %d - Expected: %s, Got %s", name, length, seed, chunkSize, expected, actual)
+					}
+				}
+			}
+		}
+	}
+}
+
+// -------------------------------------- hash context -------------------------------------- MARK: hash context
+
+func TestHashContext(t *testing.T) {
+	doTest := func(t *testing.T, name string, key string, expected string) {
+		algorithm, _ := getAlgorithm(name)
+		var hmacKey []byte
+		if key != "" {
+			hmacKey = []byte(key)
+		}
+		context := newHashContext(algorithm, hashOptions{}, hmacKey)
+		context.hash.Write([]byte("The quick brown fox "))
+		internal, err := context.CloneInternal()
+		if err != nil {
+			t.Fatalf("%s with key %q - Unexpected error: %s", name, key, err)
+		}
+		clone := internal.(*hashContext)
+		context.hash.Write([]byte("jumps over the lazy dog"))
+		clone.hash.Write([]byte("jumps over the lazy dog"))
+		if actual := hex.EncodeToString(context.final()); actual != expected {
+			t.Errorf("%s with key %q - Expected: %s, Got %s", name, key, expected, actual)
+		}
+		if actual := hex.EncodeToString(clone.final()); actual != expected {
+			t.Errorf("Clone of %s with key %q - Expected: %s, Got %s", name, key, expected, actual)
+		}
+	}
+
+	doTest(t, "md5", "key", "80070713463e7749b90c2dc24911e275")
+	doTest(t, "sha1", "key", "de7c9b85b8b78aa6bc8a7a36f70a90701c9db4d9")
+	doTest(t, "sha256", "key", "f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8")
+	doTest(t, "sha256", strings.Repeat("key", 30), hmacSha256(strings.Repeat("key", 30), "The quick brown fox jumps over the lazy dog"))
+	doTest(t, "xxh64", "", "0b242d361fda71bc")
+}
+
+func hmacSha256(key string, data string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(data))
+	return hex.EncodeToString(mac.Sum(nil))
+}
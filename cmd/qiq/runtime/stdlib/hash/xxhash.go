@@ -0,0 +1,626 @@
+package hash
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// Implementation of the xxHash algorithms XXH32, XXH64, XXH3 (64 bit) and XXH128.
+// Spec: https://github.com/Cyan4973/xxHash/blob/dev/doc/xxhash_spec.md
+
+const (
+	xxhPrime32_1 uint32 = 0x9E3779B1
+	xxhPrime32_2 uint32 = 0x85EBCA77
+	xxhPrime32_3 uint32 = 0xC2B2AE3D
+	xxhPrime32_4 uint32 = 0x27D4EB2F
+	xxhPrime32_5 uint32 = 0x165667B1
+
+	xxhPrime64_1 uint64 = 0x9E3779B185EBCA87
+	xxhPrime64_2 uint64 = 0xC2B2AE3D27D4EB4F
+	xxhPrime64_3 uint64 = 0x165667B19E3779F9
+	xxhPrime64_4 uint64 = 0x85EBCA77C2B2AE63
+	xxhPrime64_5 uint64 = 0x27D4EB2F165667C5
+
+	xxhPrimeMx1 uint64 = 0x165667919E3779F9
+	xxhPrimeMx2 uint64 = 0x9FB21C651E98DF25
+)
+
+// Minimum size of a custom secret for XXH3 and XXH128
+const xxh3SecretSizeMin = 136
+
+// Default secret of XXH3 and XXH128
+var xxh3DefaultSecret = []byte{
+	0xb8, 0xfe, 0x6c, 0x39, 0x23, 0xa4, 0x4b, 0xbe, 0x7c, 0x01, 0x81, 0x2c, 0xf7, 0x21, 0xad, 0x1c,
+	0xde, 0xd4, 0x6d, 0xe9, 0x83, 0x90, 0x97, 0xdb, 0x72, 0x40, 0xa4, 0xa4, 0xb7, 0xb3, 0x67, 0x1f,
+	0xcb, 0x79, 0xe6, 0x4e, 0xcc, 0xc0, 0xe5, 0x78, 0x82, 0x5a, 0xd0, 0x7d, 0xcc, 0xff, 0x72, 0x21,
+	0xb8, 0x08, 0x46, 0x74, 0xf7, 0x43, 0x24, 0x8e, 0xe0, 0x35, 0x90, 0xe6, 0x81, 0x3a, 0x26, 0x4c,
+	0x3c, 0x28, 0x52, 0xbb, 0x91, 0xc3, 0x00, 0xcb, 0x88, 0xd0, 0x65, 0x8b, 0x1b, 0x53, 0x2e, 0xa3,
+	0x71, 0x64, 0x48, 0x97, 0xa2, 0x0d, 0xf9, 0x4e, 0x38, 0x19, 0xef, 0x46, 0xa9, 0xde, 0xac, 0xd8,
+	0xa8, 0xfa, 0x76, 0x3f, 0xe3, 0x9c, 0x34, 0x3f, 0xf9, 0xdc, 0xbb, 0xc7, 0xc7, 0x0b, 0x4f, 0x1d,
+	0x8a, 0x51, 0xe0, 0x4b, 0xcd, 0xb4, 0x59, 0x31, 0xc8, 0x9f, 0x7e, 0xc9, 0xd9, 0x78, 0x73, 0x64,
+	0xea, 0xc5, 0xac, 0x83, 0x34, 0xd3, 0xeb, 0xc3, 0xc5, 0x81, 0xa0, 0xff, 0xfa, 0x13, 0x63, 0xeb,
+	0x17, 0x0d, 0xdd, 0x51, 0xb7, 0xf0, 0xda, 0x49, 0xd3, 0x16, 0x55, 0x26, 0x29, 0xd4, 0x68, 0x9e,
+	0x2b, 0x16, 0xbe, 0x58, 0x7d, 0x47, 0xa1, 0xfc, 0x8f, 0xf8, 0xb8, 0xd1, 0x7a, 0xd0, 0x31, 0xce,
+	0x45, 0xcb, 0x3a, 0x8f, 0x95, 0x16, 0x04, 0x28, 0xaf, 0xd7, 0xfb, 0xca, 0xbb, 0x4b, 0x40, 0x7e,
+}
+
+func read32(data []byte, offset int) uint32 {
+	return binary.LittleEndian.Uint32(data[offset:])
+}
+
+func read64(data []byte, offset int) uint64 {
+	return binary.LittleEndian.Uint64(data[offset:])
+}
+
+// -------------------------------------- XXH32 -------------------------------------- MARK: XXH32
+
+func xxh32Round(acc uint32, lane uint32) uint32 {
+	return bits.RotateLeft32(acc+lane*xxhPrime32_2, 13) * xxhPrime32_1
+}
+
+// Streaming state of XXH32.
+// The fields are exported so that the state can be marshaled with encoding/binary (see hashContext.CloneInternal).
+type xxh32State struct {
+	Acc          [4]uint32
+	Buffer       [16]byte
+	BufferedSize uint64
+	TotalLen     uint64
+}
+
+type xxh32Hash struct {
+	seed  uint32
+	state xxh32State
+}
+
+func newXxh32(seed uint32) *xxh32Hash {
+	hash := &xxh32Hash{seed: seed}
+	hash.Reset()
+	return hash
+}
+
+func (hash *xxh32Hash) Reset() {
+	seed := hash.seed
+	hash.state = xxh32State{Acc: [4]uint32{seed + xxhPrime32_1 + xxhPrime32_2, seed + xxhPrime32_2, seed, seed - xxhPrime32_1}}
+}
+
+func (hash *xxh32Hash) Size() int      { return 4 }
+func (hash *xxh32Hash) BlockSize() int { return 16 }
+
+func (hash *xxh32Hash) Write(data []byte) (int, error) {
+	state := &hash.state
+	length := len(data)
+	state.TotalLen += uint64(length)
+	if state.BufferedSize > 0 {
+		copied := copy(state.Buffer[state.BufferedSize:], data)
+		state.BufferedSize += uint64(copied)
+		data = data[copied:]
+		if state.BufferedSize < uint64(len(state.Buffer)) {
+			return length, nil
+		}
+		hash.consumeStripe(state.Buffer[:])
+	}
+	for ; len(data) >= len(state.Buffer); data = data[len(state.Buffer):] {
+		hash.consumeStripe(data)
+	}
+	state.BufferedSize = uint64(copy(state.Buffer[:], data))
+	return length, nil
+}
+
+func (hash *xxh32Hash) consumeStripe(stripe []byte) {
+	for i := range hash.state.Acc {
+		hash.state.Acc[i] = xxh32Round(hash.state.Acc[i], read32(stripe, 4*i))
+	}
+}
+
+func (hash *xxh32Hash) Sum(data []byte) []byte {
+	state := &hash.state
+	var result uint32
+	if state.TotalLen >= uint64(len(state.Buffer)) {
+		result = bits.RotateLeft32(state.Acc[0], 1) + bits.RotateLeft32(state.Acc[1], 7) +
+			bits.RotateLeft32(state.Acc[2], 12) + bits.RotateLeft32(state.Acc[3], 18)
+	} else {
+		result = hash.seed + xxhPrime32_5
+	}
+	result += uint32(state.TotalLen)
+
+	tail := state.Buffer[:state.BufferedSize]
+	offset := 0
+	for ; offset+4 <= len(tail); offset += 4 {
+		result += read32(tail, offset) * xxhPrime32_3
+		result = bits.RotateLeft32(result, 17) * xxhPrime32_4
+	}
+	for ; offset < len(tail); offset++ {
+		result += uint32(tail[offset]) * xxhPrime32_5
+		result = bits.RotateLeft32(result, 11) * xxhPrime32_1
+	}
+
+	result ^= result >> 15
+	result *= xxhPrime32_2
+	result ^= result >> 13
+	result *= xxhPrime32_3
+	result ^= result >> 16
+	return binary.BigEndian.AppendUint32(data, result)
+}
+
+func (hash *xxh32Hash) MarshalBinary() ([]byte, error) {
+	return binary.Append(nil, binary.BigEndian, &hash.state)
+}
+
+func (hash *xxh32Hash) UnmarshalBinary(data []byte) error {
+	_, err := binary.Decode(data, binary.BigEndian, &hash.state)
+	return err
+}
+
+// -------------------------------------- XXH64 -------------------------------------- MARK: XXH64
+
+func xxh64Round(acc uint64, lane uint64) uint64 {
+	return bits.RotateLeft64(acc+lane*xxhPrime64_2, 31) * xxhPrime64_1
+}
+
+func xxh64MergeRound(acc uint64, value uint64) uint64 {
+	acc ^= xxh64Round(0, value)
+	return acc*xxhPrime64_1 + xxhPrime64_4
+}
+
+func xxh64Avalanche(hash uint64) uint64 {
+	hash ^= hash >> 33
+	hash *= xxhPrime64_2
+	hash ^= hash >> 29
+	hash *= xxhPrime64_3
+	hash ^= hash >> 32
+	return hash
+}
+
+// Streaming state of XXH64 (see xxh32State)
+type xxh64State struct {
+	Acc          [4]uint64
+	Buffer       [32]byte
+	BufferedSize uint64
+	TotalLen     uint64
+}
+
+type xxh64Hash struct {
+	seed  uint64
+	state xxh64State
+}
+
+func newXxh64(seed uint64) *xxh64Hash {
+	hash := &xxh64Hash{seed: seed}
+	hash.Reset()
+	return hash
+}
+
+func (hash *xxh64Hash) Reset() {
+	seed := hash.seed
+	hash.state = xxh64State{Acc: [4]uint64{seed + xxhPrime64_1 + xxhPrime64_2, seed + xxhPrime64_2, seed, seed - xxhPrime64_1}}
+}
+
+func (hash *xxh64Hash) Size() int      { return 8 }
+func (hash *xxh64Hash) BlockSize() int { return 32 }
+
+func (hash *xxh64Hash) Write(data []byte) (int, error) {
+	state := &hash.state
+	length := len(data)
+	state.TotalLen += uint64(length)
+	if state.BufferedSize > 0 {
+		copied := copy(state.Buffer[state.BufferedSize:], data)
+		state.BufferedSize += uint64(copied)
+		data = data[copied:]
+		if state.BufferedSize < uint64(len(state.Buffer)) {
+			return length, nil
+		}
+		hash.consumeStripe(state.Buffer[:])
+	}
+	for ; len(data) >= len(state.Buffer); data = data[len(state.Buffer):] {
+		hash.consumeStripe(data)
+	}
+	state.BufferedSize = uint64(copy(state.Buffer[:], data))
+	return length, nil
+}
+
+func (hash *xxh64Hash) consumeStripe(stripe []byte) {
+	for i := range hash.state.Acc {
+		hash.state.Acc[i] = xxh64Round(hash.state.Acc[i], read64(stripe, 8*i))
+	}
+}
+
+func (hash *xxh64Hash) Sum(data []byte) []byte {
+	state := &hash.state
+	var result uint64
+	if state.TotalLen >= uint64(len(state.Buffer)) {
+		result = bits.RotateLeft64(state.Acc[0], 1) + bits.RotateLeft64(state.Acc[1], 7) +
+			bits.RotateLeft64(state.Acc[2], 12) + bits.RotateLeft64(state.Acc[3], 18)
+		for _, acc := range state.Acc {
+			result = xxh64MergeRound(result, acc)
+		}
+	} else {
+		result = hash.seed + xxhPrime64_5
+	}
+	result += state.TotalLen
+
+	tail := state.Buffer[:state.BufferedSize]
+	offset := 0
+	for ; offset+8 <= len(tail); offset += 8 {
+		result ^= xxh64Round(0, read64(tail, offset))
+		result = bits.RotateLeft64(result, 27)*xxhPrime64_1 + xxhPrime64_4
+	}
+	if offset+4 <= len(tail) {
+		result ^= uint64(read32(tail, offset)) * xxhPrime64_1
+		result = bits.RotateLeft64(result, 23)*xxhPrime64_2 + xxhPrime64_3
+		offset += 4
+	}
+	for ; offset < len(tail); offset++ {
+		result ^= uint64(tail[offset]) * xxhPrime64_5
+		result = bits.RotateLeft64(result, 11) * xxhPrime64_1
+	}
+
+	return binary.BigEndian.AppendUint64(data, xxh64Avalanche(result))
+}
+
+func (hash *xxh64Hash) MarshalBinary() ([]byte, error) {
+	return binary.Append(nil, binary.BigEndian, &hash.state)
+}
+
+func (hash *xxh64Hash) UnmarshalBinary(data []byte) error {
+	_, err := binary.Decode(data, binary.BigEndian, &hash.state)
+	return err
+}
+
+// -------------------------------------- XXH3 -------------------------------------- MARK: XXH3
+
+const (
+	xxh3StripeLen           = 64
+	xxh3SecretConsumeRate   = 8
+	xxh3MidSizeStartOffset  = 3
+	xxh3MidSizeLastOffset   = 17
+	xxh3SecretLastAccStart  = 7
+	xxh3SecretMergeAccStart = 11
+)
+
+func mul128Fold64(lhs uint64, rhs uint64) uint64 {
+	high, low := bits.Mul64(lhs, rhs)
+	return high ^ low
+}
+
+func xxh3Avalanche(hash uint64) uint64 {
+	hash ^= hash >> 37
+	hash *= xxhPrimeMx1
+	hash ^= hash >> 32
+	return hash
+}
+
+func xxh3Rrmxmx(hash uint64, length uint64) uint64 {
+	hash ^= bits.RotateLeft64(hash, 49) ^ bits.RotateLeft64(hash, 24)
+	hash *= xxhPrimeMx2
+	hash ^= (hash >> 35) + length
+	hash *= xxhPrimeMx2
+	hash ^= hash >> 28
+	return hash
+}
+
+func xxh3Mix16B(input []byte, inputOffset int, secret []byte, secretOffset int, seed uint64) uint64 {
+	return mul128Fold64(
+		read64(input, inputOffset)^(read64(secret, secretOffset)+seed),
+		read64(input, inputOffset+8)^(read64(secret, secretOffset+8)-seed),
+	)
+}
+
+// Derive the secret used for long inputs from the default secret and a seed
+func xxh3CustomSecret(seed uint64) []byte {
+	secret := make([]byte, len(xxh3DefaultSecret))
+	for i := 0; i < len(secret); i += 16 {
+		binary.LittleEndian.PutUint64(secret[i:], read64(xxh3DefaultSecret, i)+seed)
+		binary.LittleEndian.PutUint64(secret[i+8:], read64(xxh3DefaultSecret, i+8)-seed)
+	}
+	return secret
+}
+
+func xxh3Accumulate512(acc *[8]uint64, input []byte, inputOffset int, secret []byte, secretOffset int) {
+	for i := range 8 {
+		dataValue := read64(input, inputOffset+8*i)
+		dataKey := dataValue ^ read64(secret, secretOffset+8*i)
+		acc[i^1] += dataValue
+		acc[i] += uint64(uint32(dataKey)) * (dataKey >> 32)
+	}
+}
+
+func xxh3Scramble(acc *[8]uint64, secret []byte, secretOffset int) {
+	for i := range 8 {
+		value := acc[i]
+		value ^= value >> 47
+		value ^= read64(secret, secretOffset+8*i)
+		value *= uint64(xxhPrime32_1)
+		acc[i] = value
+	}
+}
+
+// Calculate the 64 bit XXH3 hash of an input of up to 240 bytes (see xxh3Hash for longer inputs).
+// If secret is nil, the default secret combined with the seed is used.
+func xxh3Short(input []byte, seed uint64, secret []byte) uint64 {
+	if secret == nil {
+		secret = xxh3DefaultSecret
+	}
+	length := len(input)
+	switch {
+	case length == 0:
+		return xxh64Avalanche(seed ^ read64(secret, 56) ^ read64(secret, 64))
+	case length <= 3:
+		combined := uint32(input[0])<<16 | uint32(input[length>>1])<<24 | uint32(input[length-1]) | uint32(length)<<8
+		bitflip := uint64(read32(secret, 0)^read32(secret, 4)) + seed
+		return xxh64Avalanche(uint64(combined) ^ bitflip)
+	case length <= 8:
+		seed ^= uint64(bits.ReverseBytes32(uint32(seed))) << 32
+		bitflip := (read64(secret, 8) ^ read64(secret, 16)) - seed
+		input64 := uint64(read32(input, length-4)) + uint64(read32(input, 0))<<32
+		return xxh3Rrmxmx(input64^bitflip, uint64(length))
+	case length <= 16:
+		bitflip1 := (read64(secret, 24) ^ read64(secret, 32)) + seed
+		bitflip2 := (read64(secret, 40) ^ read64(secret, 48)) - seed
+		inputLow := read64(input, 0) ^ bitflip1
+		inputHigh := read64(input, length-8) ^ bitflip2
+		acc := uint64(length) + bits.ReverseBytes64(inputLow) + inputHigh + mul128Fold64(inputLow, inputHigh)
+		return xxh3Avalanche(acc)
+	case length <= 128:
+		acc := uint64(length) * xxhPrime64_1
+		if length > 32 {
+			if length > 64 {
+				if length > 96 {
+					acc += xxh3Mix16B(input, 48, secret, 96, seed)
+					acc += xxh3Mix16B(input, length-64, secret, 112, seed)
+				}
+				acc += xxh3Mix16B(input, 32, secret, 64, seed)
+				acc += xxh3Mix16B(input, length-48, secret, 80, seed)
+			}
+			acc += xxh3Mix16B(input, 16, secret, 32, seed)
+			acc += xxh3Mix16B(input, length-32, secret, 48, seed)
+		}
+		acc += xxh3Mix16B(input, 0, secret, 0, seed)
+		acc += xxh3Mix16B(input, length-16, secret, 16, seed)
+		return xxh3Avalanche(acc)
+	case length <= 240:
+		acc := uint64(length) * xxhPrime64_1
+		for i := range 8 {
+			acc += xxh3Mix16B(input, 16*i, secret, 16*i, seed)
+		}
+		acc = xxh3Avalanche(acc)
+		for i := 8; i < length/16; i++ {
+			acc += xxh3Mix16B(input, 16*i, secret, 16*(i-8)+xxh3MidSizeStartOffset, seed)
+		}
+		acc += xxh3Mix16B(input, length-16, secret, xxh3SecretSizeMin-xxh3MidSizeLastOffset, seed)
+		return xxh3Avalanche(acc)
+	default:
+		panic("xxh3Short: Input longer than 240 bytes")
+	}
+}
+
+// -------------------------------------- XXH128 -------------------------------------- MARK: XXH128
+
+func xxh128Mix32B(low uint64, high uint64, input []byte, offset1 int, offset2 int, secret []byte, secretOffset int, seed uint64) (uint64, uint64) {
+	low += xxh3Mix16B(input, offset1, secret, secretOffset, seed)
+	low ^= read64(input, offset2) + read64(input, offset2+8)
+	high += xxh3Mix16B(input, offset2, secret, secretOffset+16, seed)
+	high ^= read64(input, offset1) + read64(input, offset1+8)
+	return low, high
+}
+
+// Calculate the 128 bit XXH3 hash of an input of up to 240 bytes (see xxh3Hash for longer inputs).
+// If secret is nil, the default secret combined with the seed is used. Returns the high and the low 64 bits.
+func xxh128Short(input []byte, seed uint64, secret []byte) (uint64, uint64) {
+	if secret == nil {
+		secret = xxh3DefaultSecret
+	}
+	length := len(input)
+	switch {
+	case length == 0:
+		return xxh64Avalanche(seed ^ read64(secret, 80) ^ read64(secret, 88)), xxh64Avalanche(seed ^ read64(secret, 64) ^ read64(secret, 72))
+	case length <= 3:
+		combinedLow := uint32(input[0])<<16 | uint32(input[length>>1])<<24 | uint32(input[length-1]) | uint32(length)<<8
+		combinedHigh := bits.RotateLeft32(bits.ReverseBytes32(combinedLow), 13)
+		bitflipLow := uint64(read32(secret, 0)^read32(secret, 4)) + seed
+		bitflipHigh := uint64(read32(secret, 8)^read32(secret, 12)) - seed
+		return xxh64Avalanche(uint64(combinedHigh) ^ bitflipHigh), xxh64Avalanche(uint64(combinedLow) ^ bitflipLow)
+	case length <= 8:
+		seed ^= uint64(bits.ReverseBytes32(uint32(seed))) << 32
+		input64 := uint64(read32(input, 0)) + uint64(read32(input, length-4))<<32
+		bitflip := (read64(secret, 16) ^ read64(secret, 24)) + seed
+		high, low := bits.Mul64(input64^bitflip, xxhPrime64_1+uint64(length)<<2)
+		high += low << 1
+		low ^= high >> 3
+		low ^= low >> 35
+		low *= xxhPrimeMx2
+		low ^= low >> 28
+		return xxh3Avalanche(high), low
+	case length <= 16:
+		bitflipLow := (read64(secret, 32) ^ read64(secret, 40)) - seed
+		bitflipHigh := (read64(secret, 48) ^ read64(secret, 56)) + seed
+		inputLow := read64(input, 0)
+		inputHigh := read64(input, length-8)
+		mHigh, mLow := bits.Mul64(inputLow^inputHigh^bitflipLow, xxhPrime64_1)
+		mLow += uint64(length-1) << 54
+		inputHigh ^= bitflipHigh
+		mHigh += inputHigh + uint64(uint32(inputHigh))*uint64(xxhPrime32_2-1)
+		mLow ^= bits.ReverseBytes64(mHigh)
+		high, low := bits.Mul64(mLow, xxhPrime64_2)
+		high += mHigh * xxhPrime64_2
+		return xxh3Avalanche(high), xxh3Avalanche(low)
+	case length <= 240:
+		accLow, accHigh := uint64(length)*xxhPrime64_1, uint64(0)
+		if length <= 128 {
+			if length > 32 {
+				if length > 64 {
+					if length > 96 {
+						accLow, accHigh = xxh128Mix32B(accLow, accHigh, input, 48, length-64, secret, 96, seed)
+					}
+					accLow, accHigh = xxh128Mix32B(accLow, accHigh, input, 32, length-48, secret, 64, seed)
+				}
+				accLow, accHigh = xxh128Mix32B(accLow, accHigh, input, 16, length-32, secret, 32, seed)
+			}
+			accLow, accHigh = xxh128Mix32B(accLow, accHigh, input, 0, length-16, secret, 0, seed)
+		} else {
+			for i := range 4 {
+				accLow, accHigh = xxh128Mix32B(accLow, accHigh, input, 32*i, 32*i+16, secret, 32*i, seed)
+			}
+			accLow, accHigh = xxh3Avalanche(accLow), xxh3Avalanche(accHigh)
+			for i := 4; i < length/32; i++ {
+				accLow, accHigh = xxh128Mix32B(accLow, accHigh, input, 32*i, 32*i+16, secret, xxh3MidSizeStartOffset+32*(i-4), seed)
+			}
+			accLow, accHigh = xxh128Mix32B(accLow, accHigh, input, length-16, length-32, secret, xxh3SecretSizeMin-xxh3MidSizeLastOffset-16, -seed)
+		}
+		low := accLow + accHigh
+		high := accLow*xxhPrime64_1 + accHigh*xxhPrime64_4 + (uint64(length)-seed)*xxhPrime64_2
+		return -xxh3Avalanche(high), xxh3Avalanche(low)
+	default:
+		panic("xxh128Short: Input longer than 240 bytes")
+	}
+}
+
+// -------------------------------------- XXH3 streaming -------------------------------------- MARK: XXH3 streaming
+
+const (
+	xxh3MidSizeMax = 240
+	// The buffer holds 4 stripes. It is only consumed when more data is written,
+	// so that the last stripe of the input is still available when the digest is calculated.
+	xxh3BufferSize = 4 * xxh3StripeLen
+)
+
+// Streaming state of XXH3 and XXH128 (see xxh32State)
+type xxh3State struct {
+	Acc          [8]uint64
+	Buffer       [xxh3BufferSize]byte
+	BufferedSize uint64
+	// Number of stripes of the current block that are accumulated
+	StripesSoFar uint64
+	TotalLen     uint64
+}
+
+// Inputs of up to 240 bytes are stored in the buffer and hashed with xxh3Short or xxh128Short.
+// The stripes of longer inputs are accumulated in blocks like in xxh3HashLong of the reference implementation.
+type xxh3Hash struct {
+	seed uint64
+	// Secret of the short inputs (nil for the default secret)
+	secret []byte
+	// Secret of the long inputs (derived from the seed if a seed is given)
+	longSecret []byte
+	is128      bool
+	state      xxh3State
+}
+
+func newXxh3(seed uint64, secret []byte, is128 bool) *xxh3Hash {
+	hash := &xxh3Hash{seed: seed, secret: secret, longSecret: secret, is128: is128}
+	if seed != 0 {
+		hash.longSecret = xxh3CustomSecret(seed)
+	} else if secret == nil {
+		hash.longSecret = xxh3DefaultSecret
+	}
+	hash.Reset()
+	return hash
+}
+
+func (hash *xxh3Hash) Reset() {
+	hash.state = xxh3State{Acc: [8]uint64{
+		uint64(xxhPrime32_3), xxhPrime64_1, xxhPrime64_2, xxhPrime64_3,
+		xxhPrime64_4, uint64(xxhPrime32_2), xxhPrime64_5, uint64(xxhPrime32_1),
+	}}
+}
+
+func (hash *xxh3Hash) Size() int {
+	if hash.is128 {
+		return 16
+	}
+	return 8
+}
+
+func (hash *xxh3Hash) BlockSize() int { return xxh3StripeLen }
+
+func (hash *xxh3Hash) Write(data []byte) (int, error) {
+	state := &hash.state
+	length := len(data)
+	state.TotalLen += uint64(length)
+	if int(state.BufferedSize)+len(data) <= xxh3BufferSize {
+		state.BufferedSize += uint64(copy(state.Buffer[state.BufferedSize:], data))
+		return length, nil
+	}
+
+	if state.BufferedSize > 0 {
+		data = data[copy(state.Buffer[state.BufferedSize:], data):]
+		hash.consumeStripes(&state.Acc, &state.StripesSoFar, state.Buffer[:], xxh3BufferSize/xxh3StripeLen)
+	}
+	if len(data) > xxh3BufferSize {
+		offset := 0
+		for ; len(data)-offset > xxh3BufferSize; offset += xxh3BufferSize {
+			hash.consumeStripes(&state.Acc, &state.StripesSoFar, data[offset:], xxh3BufferSize/xxh3StripeLen)
+		}
+		// Keep the last consumed stripe, it is part of the last stripe if less than a stripe is buffered
+		copy(state.Buffer[xxh3BufferSize-xxh3StripeLen:], data[offset-xxh3StripeLen:offset])
+		data = data[offset:]
+	}
+	state.BufferedSize = uint64(copy(state.Buffer[:], data))
+	return length, nil
+}
+
+// Accumulate the stripes and scramble the accumulators at the end of each block
+func (hash *xxh3Hash) consumeStripes(acc *[8]uint64, stripesSoFar *uint64, input []byte, stripes int) {
+	secret := hash.longSecret
+	stripesPerBlock := uint64((len(secret) - xxh3StripeLen) / xxh3SecretConsumeRate)
+	for stripe := range stripes {
+		xxh3Accumulate512(acc, input, stripe*xxh3StripeLen, secret, int(*stripesSoFar)*xxh3SecretConsumeRate)
+		*stripesSoFar++
+		if *stripesSoFar == stripesPerBlock {
+			xxh3Scramble(acc, secret, len(secret)-xxh3StripeLen)
+			*stripesSoFar = 0
+		}
+	}
+}
+
+func (hash *xxh3Hash) Sum(data []byte) []byte {
+	state := &hash.state
+	var high, low uint64
+	if state.TotalLen <= xxh3MidSizeMax {
+		input := state.Buffer[:state.TotalLen]
+		if hash.is128 {
+			high, low = xxh128Short(input, hash.seed, hash.secret)
+		} else {
+			low = xxh3Short(input, hash.seed, hash.secret)
+		}
+	} else {
+		// The digest is calculated on a copy, so that more data can be written
+		acc, stripesSoFar := state.Acc, state.StripesSoFar
+		var lastStripe [xxh3StripeLen]byte
+		if state.BufferedSize >= xxh3StripeLen {
+			hash.consumeStripes(&acc, &stripesSoFar, state.Buffer[:], int(state.BufferedSize-1)/xxh3StripeLen)
+			copy(lastStripe[:], state.Buffer[state.BufferedSize-xxh3StripeLen:state.BufferedSize])
+		} else {
+			catchUp := xxh3StripeLen - int(state.BufferedSize)
+			copy(lastStripe[:], state.Buffer[xxh3BufferSize-catchUp:])
+			copy(lastStripe[catchUp:], state.Buffer[:state.BufferedSize])
+		}
+		secret := hash.longSecret
+		xxh3Accumulate512(&acc, lastStripe[:], 0, secret, len(secret)-xxh3StripeLen-xxh3SecretLastAccStart)
+
+		low = xxh3MergeAccs(acc, secret, xxh3SecretMergeAccStart, state.TotalLen*xxhPrime64_1)
+		if hash.is128 {
+			high = xxh3MergeAccs(acc, secret, len(secret)-xxh3StripeLen-xxh3SecretMergeAccStart, ^(state.TotalLen * xxhPrime64_2))
+		}
+	}
+	if hash.is128 {
+		data = binary.BigEndian.AppendUint64(data, high)
+	}
+	return binary.BigEndian.AppendUint64(data, low)
+}
+
+func (hash *xxh3Hash) MarshalBinary() ([]byte, error) {
+	return binary.Append(nil, binary.BigEndian, &hash.state)
+}
+
+func (hash *xxh3Hash) UnmarshalBinary(data []byte) error {
+	_, err := binary.Decode(data, binary.BigEndian, &hash.state)
+	return err
+}
+
+func xxh3MergeAccs(acc [8]uint64, secret []byte, secretOffset int, start uint64) uint64 {
+	result := start
+	for i := range 4 {
+		result += mul128Fold64(acc[2*i]^read64(secret, secretOffset+16*i), acc[2*i+1]^read64(secret, secretOffset+16*i+8))
+	}
+	return xxh3Avalanche(result)
+}
@@ -0,0 +1,370 @@
+package json
+
+import (
+	"QIQ/cmd/qiq/runtime"
+	"QIQ/cmd/qiq/runtime/values"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+type decoder struct {
+	context  runtime.Context
+	json     string
+	pos      int
+	flags    int64
+	maxDepth int
+	depth    int
+	// First error that occurred
+	errorCode int64
+}
+
+// Decode the JSON and return the value and the error code
+func decode(json string, maxDepth int, flags int64, context runtime.Context) (values.RuntimeValue, int64) {
+	decoder := &decoder{context: context, json: json, flags: flags, maxDepth: maxDepth}
+	value, ok := decoder.decodeValue()
+	if ok {
+		decoder.skipWhitespace()
+		if decoder.pos < len(decoder.json) {
+			decoder.unexpectedChar()
+			ok = false
+		}
+	}
+	if !ok {
+		return values.NewNull(), decoder.errorCode
+	}
+	return value, JSON_ERROR_NONE
+}
+
+func (decoder *decoder) hasFlag(flag int64) bool {
+	return decoder.flags&flag != 0
+}
+
+func (decoder *decoder) fail(errorCode int64) bool {
+	decoder.errorCode = errorCode
+	return false
+}
+
+// Report an unexpected character (or the unexpected end of the JSON)
+func (decoder *decoder) unexpectedChar() bool {
+	// A NUL byte is reported as control character
+	if decoder.pos < len(decoder.json) && decoder.json[decoder.pos] == 0 {
+		return decoder.fail(JSON_ERROR_CTRL_CHAR)
+	}
+	return decoder.fail(JSON_ERROR_SYNTAX)
+}
+
+func (decoder *decoder) skipWhitespace() {
+	for decoder.pos < len(decoder.json) && strings.IndexByte(" \t\n\r", decoder.json[decoder.pos]) >= 0 {
+		decoder.pos++
+	}
+}
+
+func (decoder *decoder) decodeValue() (values.RuntimeValue, bool) {
+	decoder.skipWhitespace()
+	if decoder.pos >= len(decoder.json) {
+		return nil, decoder.unexpectedChar()
+	}
+
+	switch char := decoder.json[decoder.pos]; {
+	case char == '{':
+		return decoder.decodeObject()
+	case char == '[':
+		return decoder.decodeArray()
+	case char == '"':
+		str, ok := decoder.decodeString()
+		if !ok {
+			return nil, false
+		}
+		return values.NewStr(str), true
+	case char == '-' || (char >= '0' && char <= '9'):
+		return decoder.decodeNumber()
+	case strings.HasPrefix(decoder.json[decoder.pos:], "true"):
+		decoder.pos += 4
+		return values.NewBool(true), true
+	case strings.HasPrefix(decoder.json[decoder.pos:], "false"):
+		decoder.pos += 5
+		return values.NewBool(false), true
+	case strings.HasPrefix(decoder.json[decoder.pos:], "null"):
+		decoder.pos += 4
+		return values.NewNull(), true
+	}
+	return nil, decoder.unexpectedChar()
+}
+
+func (decoder *decoder) enterContainer() bool {
+	decoder.depth++
+	if decoder.depth > decoder.maxDepth {
+		return decoder.fail(JSON_ERROR_DEPTH)
+	}
+	return true
+}
+
+func (decoder *decoder) decodeArray() (values.RuntimeValue, bool) {
+	if !decoder.enterContainer() {
+		return nil, false
+	}
+	decoder.pos++ // "["
+
+	array := values.NewArray()
+	decoder.skipWhitespace()
+	if decoder.pos < len(decoder.json) && decoder.json[decoder.pos] == ']' {
+		decoder.pos++
+		decoder.depth--
+		return array, true
+	}
+	for {
+		value, ok := decoder.decodeValue()
+		if !ok {
+			return nil, false
+		}
+		array.SetElement(nil, value)
+
+		decoder.skipWhitespace()
+		if decoder.pos < len(decoder.json) && decoder.json[decoder.pos] == ',' {
+			decoder.pos++
+			continue
+		}
+		if decoder.pos < len(decoder.json) && decoder.json[decoder.pos] == ']' {
+			decoder.pos++
+			decoder.depth--
+			return array, true
+		}
+		return nil, decoder.unexpectedChar()
+	}
+}
+
+func (decoder *decoder) decodeObject() (values.RuntimeValue, bool) {
+	if !decoder.enterContainer() {
+		return nil, false
+	}
+	decoder.pos++ // "{"
+
+	// JSON objects are decoded as associative arrays or as objects of the class stdClass
+	asArray := decoder.hasFlag(JSON_OBJECT_AS_ARRAY)
+	var array *values.Array
+	var object *values.Object
+	if asArray {
+		array = values.NewArray()
+	} else {
+		class, _ := decoder.context.Interpreter.GetClass("stdClass")
+		object = decoder.context.Interpreter.NewObject(class)
+	}
+	result := func() (values.RuntimeValue, bool) {
+		decoder.depth--
+		if asArray {
+			return array, true
+		}
+		return object, true
+	}
+
+	decoder.skipWhitespace()
+	if decoder.pos < len(decoder.json) && decoder.json[decoder.pos] == '}' {
+		decoder.pos++
+		return result()
+	}
+	for {
+		decoder.skipWhitespace()
+		if decoder.pos >= len(decoder.json) || decoder.json[decoder.pos] != '"' {
+			return nil, decoder.unexpectedChar()
+		}
+		key, ok := decoder.decodeString()
+		if !ok {
+			return nil, false
+		}
+		decoder.skipWhitespace()
+		if decoder.pos >= len(decoder.json) || decoder.json[decoder.pos] != ':' {
+			return nil, decoder.unexpectedChar()
+		}
+		decoder.pos++
+		value, ok := decoder.decodeValue()
+		if !ok {
+			return nil, false
+		}
+
+		if asArray {
+			array.SetElement(values.NewStr(key), value)
+		} else {
+			// Property names starting with a NUL byte are reserved for mangled (private and protected) properties
+			if strings.HasPrefix(key, "\x00") {
+				return nil, decoder.fail(JSON_ERROR_INVALID_PROPERTY_NAME)
+			}
+			object.SetProperty("$"+key, value)
+		}
+
+		decoder.skipWhitespace()
+		if decoder.pos < len(decoder.json) && decoder.json[decoder.pos] == ',' {
+			decoder.pos++
+			continue
+		}
+		if decoder.pos < len(decoder.json) && decoder.json[decoder.pos] == '}' {
+			decoder.pos++
+			return result()
+		}
+		return nil, decoder.unexpectedChar()
+	}
+}
+
+func (decoder *decoder) decodeString() (string, bool) {
+	decoder.pos++ // '"'
+	var result strings.Builder
+	for {
+		if decoder.pos >= len(decoder.json) {
+			return "", decoder.fail(JSON_ERROR_SYNTAX)
+		}
+		char := decoder.json[decoder.pos]
+		switch {
+		case char == '"':
+			decoder.pos++
+			return result.String(), true
+		case char < 0x20:
+			return "", decoder.fail(JSON_ERROR_CTRL_CHAR)
+		case char == '\\':
+			if !decoder.decodeEscapeSequence(&result) {
+				return "", false
+			}
+		case char < 0x80:
+			result.WriteByte(char)
+			decoder.pos++
+		default:
+			r, size := utf8.DecodeRuneInString(decoder.json[decoder.pos:])
+			if r == utf8.RuneError && size <= 1 {
+				// Invalid UTF-8
+				decoder.pos++
+				switch {
+				case decoder.hasFlag(JSON_INVALID_UTF8_IGNORE):
+				case decoder.hasFlag(JSON_INVALID_UTF8_SUBSTITUTE):
+					result.WriteRune(utf8.RuneError)
+				default:
+					return "", decoder.fail(JSON_ERROR_UTF8)
+				}
+				continue
+			}
+			result.WriteString(decoder.json[decoder.pos : decoder.pos+size])
+			decoder.pos += size
+		}
+	}
+}
+
+func (decoder *decoder) decodeEscapeSequence(result *strings.Builder) bool {
+	decoder.pos++ // "\"
+	if decoder.pos >= len(decoder.json) {
+		return decoder.fail(JSON_ERROR_SYNTAX)
+	}
+	char := decoder.json[decoder.pos]
+	decoder.pos++
+	switch char {
+	case '"', '\\', '/':
+		result.WriteByte(char)
+	case 'b':
+		result.WriteByte('\b')
+	case 'f':
+		result.WriteByte('\f')
+	case 'n':
+		result.WriteByte('\n')
+	case 'r':
+		result.WriteByte('\r')
+	case 't':
+		result.WriteByte('\t')
+	case 'u':
+		r, ok := decoder.decodeHex()
+		if !ok {
+			return decoder.fail(JSON_ERROR_SYNTAX)
+		}
+		// Characters outside the Basic Multilingual Plane are escaped as UTF-16 surrogate pair
+		if utf16.IsSurrogate(r) {
+			if r >= 0xDC00 || !strings.HasPrefix(decoder.json[decoder.pos:], `\u`) {
+				return decoder.fail(JSON_ERROR_UTF16)
+			}
+			decoder.pos += 2
+			low, ok := decoder.decodeHex()
+			if !ok {
+				return decoder.fail(JSON_ERROR_SYNTAX)
+			}
+			if low < 0xDC00 || low > 0xDFFF {
+				return decoder.fail(JSON_ERROR_UTF16)
+			}
+			r = utf16.DecodeRune(r, low)
+		}
+		result.WriteRune(r)
+	default:
+		return decoder.fail(JSON_ERROR_SYNTAX)
+	}
+	return true
+}
+
+// Decode the four hex digits of an escape sequence "\uXXXX"
+func (decoder *decoder) decodeHex() (rune, bool) {
+	if decoder.pos+4 > len(decoder.json) {
+		return 0, false
+	}
+	value, err := strconv.ParseUint(decoder.json[decoder.pos:decoder.pos+4], 16, 32)
+	if err != nil || strings.ContainsAny(decoder.json[decoder.pos:decoder.pos+4], "+-") {
+		return 0, false
+	}
+	decoder.pos += 4
+	return rune(value), true
+}
+
+func (decoder *decoder) decodeNumber() (values.RuntimeValue, bool) {
+	// Spec: https://www.rfc-editor.org/rfc/rfc8259#section-6
+	// number = [ minus ] int [ frac ] [ exp ]
+	start := decoder.pos
+	isDigit := func() bool {
+		return decoder.pos < len(decoder.json) && decoder.json[decoder.pos] >= '0' && decoder.json[decoder.pos] <= '9'
+	}
+	skipDigits := func() bool {
+		if !isDigit() {
+			return false
+		}
+		for isDigit() {
+			decoder.pos++
+		}
+		return true
+	}
+
+	if decoder.json[decoder.pos] == '-' {
+		decoder.pos++
+	}
+	// int = zero / ( digit1-9 *DIGIT )
+	if decoder.pos < len(decoder.json) && decoder.json[decoder.pos] == '0' {
+		decoder.pos++
+	} else if !skipDigits() {
+		return nil, decoder.unexpectedChar()
+	}
+	isFloat := false
+	// frac = decimal-point 1*DIGIT
+	if decoder.pos < len(decoder.json) && decoder.json[decoder.pos] == '.' {
+		decoder.pos++
+		if !skipDigits() {
+			return nil, decoder.unexpectedChar()
+		}
+		isFloat = true
+	}
+	// exp = e [ minus / plus ] 1*DIGIT
+	if decoder.pos < len(decoder.json) && (decoder.json[decoder.pos] == 'e' || decoder.json[decoder.pos] == 'E') {
+		decoder.pos++
+		if decoder.pos < len(decoder.json) && (decoder.json[decoder.pos] == '+' || decoder.json[decoder.pos] == '-') {
+			decoder.pos++
+		}
+		if !skipDigits() {
+			return nil, decoder.unexpectedChar()
+		}
+		isFloat = true
+	}
+
+	number := decoder.json[start:decoder.pos]
+	if !isFloat {
+		if intValue, err := strconv.ParseInt(number, 10, 64); err == nil {
+			return values.NewInt(intValue), true
+		}
+		// JSON_BIGINT_AS_STRING: Decodes large integers as their original string value.
+		if decoder.hasFlag(JSON_BIGINT_AS_STRING) {
+			return values.NewStr(number), true
+		}
+	}
+	// Numbers out of range are decoded as INF
+	floatValue, _ := strconv.ParseFloat(number, 64)
+	return values.NewFloat(floatValue), true
+}
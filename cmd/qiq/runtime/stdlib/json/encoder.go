@@ -0,0 +1,281 @@
+package json
+
+import (
+	"QIQ/cmd/qiq/common"
+	"QIQ/cmd/qiq/phpError"
+	"QIQ/cmd/qiq/runtime"
+	"QIQ/cmd/qiq/runtime/values"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Spec: https://www.rfc-editor.org/rfc/rfc8259
+
+type encoder struct {
+	context  runtime.Context
+	flags    int64
+	maxDepth int
+	depth    int
+	// Objects that are currently encoded (used to detect recursion)
+	objects   map[*values.Object]bool
+	errorCode int64
+	result    strings.Builder
+}
+
+// Encode the value and return the JSON and the error code.
+// Errors of called jsonSerialize methods are returned as phpError.
+func encode(value values.RuntimeValue, flags int64, maxDepth int, context runtime.Context) (string, int64, phpError.Error) {
+	encoder := &encoder{context: context, flags: flags, maxDepth: maxDepth, objects: map[*values.Object]bool{}}
+	if err := encoder.encodeValue(value); err != nil {
+		return "", JSON_ERROR_NONE, err
+	}
+	return encoder.result.String(), encoder.errorCode, nil
+}
+
+func (encoder *encoder) hasFlag(flag int64) bool {
+	return encoder.flags&flag != 0
+}
+
+func (encoder *encoder) encodeValue(value values.RuntimeValue) phpError.Error {
+	switch value.GetType() {
+	case values.NullValue, values.VoidValue:
+		encoder.result.WriteString("null")
+	case values.BoolValue:
+		if value.(*values.Bool).Value {
+			encoder.result.WriteString("true")
+		} else {
+			encoder.result.WriteString("false")
+		}
+	case values.IntValue:
+		encoder.result.WriteString(strconv.FormatInt(value.(*values.Int).Value, 10))
+	case values.FloatValue:
+		encoder.encodeFloat(value.(*values.Float).Value)
+	case values.StrValue:
+		encoder.encodeString(value.(*values.Str).Value)
+	case values.ArrayValue:
+		array := value.(*values.Array)
+		return encoder.encodeArray(array.GetKeys(), array.GetElement, !encoder.hasFlag(JSON_FORCE_OBJECT) && isList(array))
+	case values.ObjectValue:
+		return encoder.encodeObject(value.(*values.Object))
+	default:
+		encoder.errorCode = JSON_ERROR_UNSUPPORTED_TYPE
+		encoder.result.WriteString("null")
+	}
+	return nil
+}
+
+func (encoder *encoder) encodeFloat(value float64) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		encoder.errorCode = JSON_ERROR_INF_OR_NAN
+		encoder.result.WriteString("0")
+		return
+	}
+	// Floats are encoded with the precision of the ini directive "serialize_precision"
+	str := common.FormatFloatWithExponentChar(value, encoder.context.Interpreter.GetIni().GetInt("serialize_precision"), 'e')
+	// JSON_PRESERVE_ZERO_FRACTION: Ensures that float values are always encoded as a float value (e.g. "10.0" instead of "10").
+	if encoder.hasFlag(JSON_PRESERVE_ZERO_FRACTION) && !strings.ContainsAny(str, ".e") {
+		str += ".0"
+	}
+	encoder.result.WriteString(str)
+}
+
+const hexDigits = "0123456789abcdef"
+
+func (encoder *encoder) writeUnicodeEscape(r rune) {
+	encoder.result.WriteString(`\u`)
+	for shift := 12; shift >= 0; shift -= 4 {
+		encoder.result.WriteByte(hexDigits[(r>>shift)&0xF])
+	}
+}
+
+func (encoder *encoder) encodeString(str string) {
+	start := encoder.result.Len()
+	encoder.result.WriteByte('"')
+	for pos := 0; pos < len(str); {
+		r, size := utf8.DecodeRuneInString(str[pos:])
+		if r == utf8.RuneError && size <= 1 {
+			// Invalid UTF-8
+			pos++
+			switch {
+			case encoder.hasFlag(JSON_INVALID_UTF8_IGNORE):
+			case encoder.hasFlag(JSON_INVALID_UTF8_SUBSTITUTE):
+				if encoder.hasFlag(JSON_UNESCAPED_UNICODE) {
+					encoder.result.WriteRune(utf8.RuneError)
+				} else {
+					encoder.writeUnicodeEscape(utf8.RuneError)
+				}
+			default:
+				encoder.errorCode = JSON_ERROR_UTF8
+				str := encoder.result.String()[:start]
+				encoder.result.Reset()
+				encoder.result.WriteString(str + "null")
+				return
+			}
+			continue
+		}
+		pos += size
+
+		switch {
+		case r == '"':
+			if encoder.hasFlag(JSON_HEX_QUOT) {
+				encoder.result.WriteString(`\u0022`)
+			} else {
+				encoder.result.WriteString(`\"`)
+			}
+		case r == '\\':
+			encoder.result.WriteString(`\\`)
+		case r == '/':
+			if encoder.hasFlag(JSON_UNESCAPED_SLASHES) {
+				encoder.result.WriteByte('/')
+			} else {
+				encoder.result.WriteString(`\/`)
+			}
+		case r == '\b':
+			encoder.result.WriteString(`\b`)
+		case r == '\f':
+			encoder.result.WriteString(`\f`)
+		case r == '\n':
+			encoder.result.WriteString(`\n`)
+		case r == '\r':
+			encoder.result.WriteString(`\r`)
+		case r == '\t':
+			encoder.result.WriteString(`\t`)
+		case r == '<' && encoder.hasFlag(JSON_HEX_TAG):
+			encoder.result.WriteString(`\u003C`)
+		case r == '>' && encoder.hasFlag(JSON_HEX_TAG):
+			encoder.result.WriteString(`\u003E`)
+		case r == '&' && encoder.hasFlag(JSON_HEX_AMP):
+			encoder.result.WriteString(`\u0026`)
+		case r == '\'' && encoder.hasFlag(JSON_HEX_APOS):
+			encoder.result.WriteString(`\u0027`)
+		case r < 0x20:
+			encoder.writeUnicodeEscape(r)
+		case r < 0x80:
+			encoder.result.WriteRune(r)
+		case encoder.hasFlag(JSON_UNESCAPED_UNICODE):
+			// The line terminators are escaped unless JSON_UNESCAPED_LINE_TERMINATORS is given
+			if (r == 0x2028 || r == 0x2029) && !encoder.hasFlag(JSON_UNESCAPED_LINE_TERMINATORS) {
+				encoder.writeUnicodeEscape(r)
+			} else {
+				encoder.result.WriteRune(r)
+			}
+		case r > 0xFFFF:
+			// Characters outside the Basic Multilingual Plane are escaped as UTF-16 surrogate pair
+			r -= 0x10000
+			encoder.writeUnicodeEscape(0xD800 | (r >> 10))
+			encoder.writeUnicodeEscape(0xDC00 | (r & 0x3FF))
+		default:
+			encoder.writeUnicodeEscape(r)
+		}
+	}
+	encoder.result.WriteByte('"')
+}
+
+// Arrays with the keys 0 to n-1 in ascending order are encoded as JSON arrays, all other arrays as JSON objects
+func isList(array *values.Array) bool {
+	for index, key := range array.GetKeys() {
+		if key.GetType() != values.IntValue || key.(*values.Int).Value != int64(index) {
+			return false
+		}
+	}
+	return true
+}
+
+func (encoder *encoder) writeNewline() {
+	if !encoder.hasFlag(JSON_PRETTY_PRINT) {
+		return
+	}
+	encoder.result.WriteByte('\n')
+	encoder.result.WriteString(strings.Repeat("    ", encoder.depth))
+}
+
+// Encode the elements as JSON array or JSON object
+func (encoder *encoder) encodeArray(
+	keys []values.RuntimeValue, getElement func(key values.RuntimeValue) (values.RuntimeValue, bool), asList bool,
+) phpError.Error {
+	open, close := "{", "}"
+	if asList {
+		open, close = "[", "]"
+	}
+
+	encoder.depth++
+	if encoder.depth > encoder.maxDepth {
+		encoder.errorCode = JSON_ERROR_DEPTH
+	}
+	if len(keys) == 0 {
+		encoder.result.WriteString(open + close)
+		encoder.depth--
+		return nil
+	}
+
+	encoder.result.WriteString(open)
+	for index, key := range keys {
+		if index > 0 {
+			encoder.result.WriteByte(',')
+		}
+		encoder.writeNewline()
+		if !asList {
+			if key.GetType() == values.IntValue {
+				encoder.encodeString(strconv.FormatInt(key.(*values.Int).Value, 10))
+			} else {
+				encoder.encodeString(key.(*values.Str).Value)
+			}
+			encoder.result.WriteByte(':')
+			if encoder.hasFlag(JSON_PRETTY_PRINT) {
+				encoder.result.WriteByte(' ')
+			}
+		}
+		element, _ := getElement(key)
+		if err := encoder.encodeValue(element); err != nil {
+			return err
+		}
+	}
+	encoder.depth--
+	encoder.writeNewline()
+	encoder.result.WriteString(close)
+	return nil
+}
+
+func (encoder *encoder) encodeObject(object *values.Object) phpError.Error {
+	if encoder.objects[object] {
+		encoder.errorCode = JSON_ERROR_RECURSION
+		encoder.result.WriteString("null")
+		return nil
+	}
+	encoder.objects[object] = true
+	defer delete(encoder.objects, object)
+
+	// Spec: https://www.php.net/manual/en/jsonserializable.jsonserialize.php
+	// Objects implementing JsonSerializable can customize their JSON representation when encoded with json_encode().
+	if encoder.context.Interpreter.IsSubclassOf(object.Class, "JsonSerializable") {
+		callable := values.NewArray()
+		callable.SetElement(nil, object)
+		callable.SetElement(nil, values.NewStr("jsonSerialize"))
+		value, err := encoder.context.Interpreter.CallFunction(encoder.context, callable, []values.RuntimeValue{})
+		if err != nil {
+			return err
+		}
+		// The object itself is encoded with its properties
+		if value != object {
+			return encoder.encodeValue(value)
+		}
+	}
+
+	// Only the public properties are encoded
+	keys := []values.RuntimeValue{}
+	for _, name := range object.PropertyNames {
+		if _, found := object.Properties[name]; !found {
+			continue
+		}
+		if property, found := object.Class.Properties[name]; found && property.Visibility != "public" {
+			continue
+		}
+		keys = append(keys, values.NewStr(name[1:]))
+	}
+	getProperty := func(key values.RuntimeValue) (values.RuntimeValue, bool) {
+		return object.GetProperty("$" + key.(*values.Str).Value)
+	}
+	return encoder.encodeArray(keys, getProperty, false)
+}
@@ -15,10 +15,12 @@ func Register(environment runtime.Environment) {
 	environment.AddNativeFunction("acosh", nativeFn_acosh)
 	environment.AddNativeFunction("asin", nativeFn_asin)
 	environment.AddNativeFunction("asinh", nativeFn_asinh)
+	environment.AddNativeFunction("intdiv", nativeFn_intdiv)
 	environment.AddNativeFunction("pi", nativeFn_pi)
 
 	// Const Category: Mathematical Constants
 	// Spec: https://www.php.net/manual/en/math.constants.php
+	environment.AddPredefinedConstant("INF", values.NewFloat(goMath.Inf(1)))
 	environment.AddPredefinedConstant("M_1_PI", values.NewFloat(1/goMath.Pi))
 	environment.AddPredefinedConstant("M_2_PI", values.NewFloat(2/goMath.Pi))
 	environment.AddPredefinedConstant("M_2_SQRTPI", values.NewFloat(2/goMath.SqrtPi))
@@ -36,6 +38,7 @@ func Register(environment runtime.Environment) {
 	environment.AddPredefinedConstant("M_SQRT2", values.NewFloat(goMath.Sqrt2))
 	environment.AddPredefinedConstant("M_SQRT3", values.NewFloat(goMath.Sqrt(3)))
 	environment.AddPredefinedConstant("M_SQRTPI", values.NewFloat(goMath.SqrtPi))
+	environment.AddPredefinedConstant("NAN", values.NewFloat(goMath.NaN()))
 	environment.AddPredefinedConstant("PHP_ROUND_HALF_UP", values.NewInt(1))
 	environment.AddPredefinedConstant("PHP_ROUND_HALF_DOWN", values.NewInt(2))
 	environment.AddPredefinedConstant("PHP_ROUND_HALF_EVEN", values.NewInt(3))
@@ -51,25 +54,18 @@ func nativeFn_abs(args []values.RuntimeValue, _ runtime.Context) (values.Runtime
 	}
 
 	// Spec: https://www.php.net/manual/en/function.abs.php
-
-	numType := args[0].GetType()
-
-	var numValue float64 = 0
-	if numType == values.FloatValue {
-		numValue = args[0].(*values.Float).Value
-	}
-	if numType == values.IntValue {
-		numValue = float64(args[0].(*values.Int).Value)
-	}
-	numValue = goMath.Abs(numValue)
-	if numType == values.FloatValue {
-		return values.NewFloat(numValue), nil
+	if args[0].GetType() == values.IntValue {
+		value := args[0].(*values.Int).Value
+		// The absolute value of PHP_INT_MIN is beyond the bounds of the int type
+		if value == goMath.MinInt64 {
+			return values.NewFloat(-float64(value)), nil
+		}
+		if value < 0 {
+			return values.NewInt(-value), nil
+		}
+		return values.NewInt(value), nil
 	}
-	if numType == values.IntValue {
-		return values.NewInt(int64(numValue)), nil
-	}
-
-	return values.NewVoid(), phpError.NewError("Unsupported value type %s", numType)
+	return values.NewFloat(goMath.Abs(args[0].(*values.Float).Value)), nil
 }
 
 // -------------------------------------- acos -------------------------------------- MARK: acos
@@ -120,6 +116,33 @@ func nativeFn_asinh(args []values.RuntimeValue, _ runtime.Context) (values.Runti
 	return values.NewFloat(goMath.Asinh(args[0].(*values.Float).Value)), nil
 }
 
+// -------------------------------------- intdiv -------------------------------------- MARK: intdiv
+
+func nativeFn_intdiv(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	args, err := funcParamValidator.NewValidator("intdiv").
+		AddParam("$num1", []string{"int"}, nil).AddParam("$num2", []string{"int"}, nil).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Spec: https://www.php.net/manual/en/function.intdiv.php
+	// Returns the integer quotient of the division of num1 by num2.
+	num1 := args[0].(*values.Int).Value
+	num2 := args[1].(*values.Int).Value
+
+	// If divisor is 0, a DivisionByZeroError exception is thrown.
+	if num2 == 0 {
+		return values.NewVoid(), phpError.NewError("Uncaught DivisionByZeroError: Division by zero")
+	}
+	// If the num1 is PHP_INT_MIN and the num2 is -1, then an ArithmeticError exception is thrown.
+	if num1 == goMath.MinInt64 && num2 == -1 {
+		return values.NewVoid(), phpError.NewError("Uncaught ArithmeticError: Division of PHP_INT_MIN by -1 is not an integer")
+	}
+	// The result is truncated towards zero
+	return values.NewInt(num1 / num2), nil
+}
+
 // -------------------------------------- pi -------------------------------------- MARK: pi
 
 func nativeFn_pi(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
@@ -151,7 +174,6 @@ func nativeFn_pi(args []values.RuntimeValue, context runtime.Context) (values.Ru
 // TODO fmod
 // TODO hexdec
 // TODO hypot
-// TODO intdiv
 // TODO is_finite
 // TODO is_infinite
 // TODO is_nan
@@ -0,0 +1,191 @@
+package mbstring
+
+import (
+	"slices"
+	"unicode"
+)
+
+// Spec: https://www.php.net/manual/en/mbstring.constants.php
+const (
+	MB_CASE_UPPER        int64 = 0
+	MB_CASE_LOWER        int64 = 1
+	MB_CASE_TITLE        int64 = 2
+	MB_CASE_FOLD         int64 = 3
+	MB_CASE_UPPER_SIMPLE int64 = 4
+	MB_CASE_LOWER_SIMPLE int64 = 5
+	MB_CASE_TITLE_SIMPLE int64 = 6
+	MB_CASE_FOLD_SIMPLE  int64 = 7
+)
+
+// Full case mappings that map one codepoint to multiple codepoints (Unicode SpecialCasing.txt and CaseFolding.txt)
+var (
+	fullUpper = map[rune][]rune{
+		0x00DF: {'S', 'S'}, 0x0149: {0x02BC, 'N'}, 0x01F0: {'J', 0x030C}, 0x0390: {0x0399, 0x0308, 0x0301},
+		0x03B0: {0x03A5, 0x0308, 0x0301}, 0x0587: {0x0535, 0x0552}, 0x1E96: {'H', 0x0331}, 0x1E97: {'T', 0x0308},
+		0x1E98: {'W', 0x030A}, 0x1E99: {'Y', 0x030A}, 0x1E9A: {'A', 0x02BE}, 0xFB00: {'F', 'F'}, 0xFB01: {'F', 'I'},
+		0xFB02: {'F', 'L'}, 0xFB03: {'F', 'F', 'I'}, 0xFB04: {'F', 'F', 'L'}, 0xFB05: {'S', 'T'}, 0xFB06: {'S', 'T'},
+	}
+	fullTitle = map[rune][]rune{
+		0x00DF: {'S', 's'}, 0x0149: {0x02BC, 'N'}, 0x01F0: {'J', 0x030C}, 0x0390: {0x0399, 0x0308, 0x0301},
+		0x03B0: {0x03A5, 0x0308, 0x0301}, 0x0587: {0x0535, 0x0582}, 0x1E96: {'H', 0x0331}, 0x1E97: {'T', 0x0308},
+		0x1E98: {'W', 0x030A}, 0x1E99: {'Y', 0x030A}, 0x1E9A: {'A', 0x02BE}, 0xFB00: {'F', 'f'}, 0xFB01: {'F', 'i'},
+		0xFB02: {'F', 'l'}, 0xFB03: {'F', 'f', 'i'}, 0xFB04: {'F', 'f', 'l'}, 0xFB05: {'S', 't'}, 0xFB06: {'S', 't'},
+	}
+	fullLower = map[rune][]rune{
+		0x0130: {'i', 0x0307},
+	}
+	fullFold = map[rune][]rune{
+		0x00DF: {'s', 's'}, 0x0130: {'i', 0x0307}, 0x0149: {0x02BC, 'n'}, 0x01F0: {'j', 0x030C},
+		0x0390: {0x03B9, 0x0308, 0x0301}, 0x03B0: {0x03C5, 0x0308, 0x0301}, 0x0587: {0x0565, 0x0582},
+		0x1E96: {'h', 0x0331}, 0x1E97: {'t', 0x0308}, 0x1E98: {'w', 0x030A}, 0x1E99: {'y', 0x030A},
+		0x1E9A: {'a', 0x02BE}, 0x1E9E: {'s', 's'}, 0xFB00: {'f', 'f'}, 0xFB01: {'f', 'i'}, 0xFB02: {'f', 'l'},
+		0xFB03: {'f', 'f', 'i'}, 0xFB04: {'f', 'f', 'l'}, 0xFB05: {'s', 't'}, 0xFB06: {'s', 't'},
+	}
+)
+
+// Word break characters that are ignored when determining the start of a word (Unicode property Case_Ignorable)
+var caseIgnorableChars = []rune{
+	'\'', '.', ':', 0x00B7, 0x0387, 0x055F, 0x05F4, 0x2018, 0x2019, 0x2024, 0x2027, 0xFE13, 0xFE52, 0xFE55, 0xFF07, 0xFF0E, 0xFF1A,
+}
+
+func isCaseIgnorable(codepoint rune) bool {
+	return unicode.In(codepoint, unicode.Mn, unicode.Me, unicode.Cf, unicode.Lm, unicode.Sk) ||
+		slices.Contains(caseIgnorableChars, codepoint)
+}
+
+func isCased(codepoint rune) bool {
+	return unicode.IsUpper(codepoint) || unicode.IsLower(codepoint) || unicode.IsTitle(codepoint) ||
+		unicode.Is(unicode.Other_Lowercase, codepoint) || unicode.Is(unicode.Other_Uppercase, codepoint)
+}
+
+func simpleFold(codepoint rune) rune {
+	if codepoint == 0x0130 {
+		// Only has a full and a Turkic case folding
+		return codepoint
+	}
+	return unicode.ToLower(unicode.ToUpper(codepoint))
+}
+
+// Check if a capital sigma is at the end of a word and has to be lowered to a final sigma
+func isFinalSigma(codepoints []rune, index int) bool {
+	before := false
+	for i := index - 1; i >= 0; i-- {
+		if !isCaseIgnorable(codepoints[i]) {
+			before = isCased(codepoints[i])
+			break
+		}
+	}
+	if !before {
+		return false
+	}
+	for i := index + 1; i < len(codepoints); i++ {
+		if !isCaseIgnorable(codepoints[i]) {
+			return !isCased(codepoints[i])
+		}
+	}
+	return true
+}
+
+// Convert the case of the codepoints according to the given MB_CASE_* mode
+func convertCase(codepoints []rune, mode int64) []rune {
+	result := make([]rune, 0, len(codepoints))
+
+	mapFull := func(codepoint rune, table map[rune][]rune, simple func(rune) rune) {
+		if mapped, found := table[codepoint]; found {
+			result = append(result, mapped...)
+		} else {
+			result = append(result, simple(codepoint))
+		}
+	}
+	lower := func(index int, full bool) {
+		codepoint := codepoints[index]
+		if !full {
+			result = append(result, unicode.ToLower(codepoint))
+		} else if codepoint == 0x03A3 && isFinalSigma(codepoints, index) {
+			result = append(result, 0x03C2)
+		} else {
+			mapFull(codepoint, fullLower, unicode.ToLower)
+		}
+	}
+
+	// Inside of a word while title casing
+	inWord := false
+	for index, codepoint := range codepoints {
+		if codepoint == badInput {
+			result = append(result, codepoint)
+			inWord = false
+			continue
+		}
+		switch mode {
+		case MB_CASE_UPPER:
+			mapFull(codepoint, fullUpper, unicode.ToUpper)
+		case MB_CASE_UPPER_SIMPLE:
+			result = append(result, unicode.ToUpper(codepoint))
+		case MB_CASE_LOWER, MB_CASE_LOWER_SIMPLE:
+			lower(index, mode == MB_CASE_LOWER)
+		case MB_CASE_FOLD:
+			mapFull(codepoint, fullFold, simpleFold)
+		case MB_CASE_FOLD_SIMPLE:
+			result = append(result, simpleFold(codepoint))
+		case MB_CASE_TITLE, MB_CASE_TITLE_SIMPLE:
+			if inWord {
+				lower(index, mode == MB_CASE_TITLE)
+			} else if mode == MB_CASE_TITLE {
+				mapFull(codepoint, fullTitle, unicode.ToTitle)
+			} else {
+				result = append(result, unicode.ToTitle(codepoint))
+			}
+			if !isCaseIgnorable(codepoint) {
+				inWord = isCased(codepoint)
+			}
+		}
+	}
+	return result
+}
+
+// -------------------------------------- Width -------------------------------------- MARK: Width
+
+// Ranges of fullwidth and wide characters (Unicode EastAsianWidth.txt, properties F and W)
+var wideRanges = [][2]rune{
+	{0x1100, 0x115F}, {0x231A, 0x231B}, {0x2329, 0x232A}, {0x23E9, 0x23EC}, {0x23F0, 0x23F0}, {0x23F3, 0x23F3},
+	{0x25FD, 0x25FE}, {0x2614, 0x2615}, {0x2648, 0x2653}, {0x267F, 0x267F}, {0x2693, 0x2693}, {0x26A1, 0x26A1},
+	{0x26AA, 0x26AB}, {0x26BD, 0x26BE}, {0x26C4, 0x26C5}, {0x26CE, 0x26CE}, {0x26D4, 0x26D4}, {0x26EA, 0x26EA},
+	{0x26F2, 0x26F3}, {0x26F5, 0x26F5}, {0x26FA, 0x26FA}, {0x26FD, 0x26FD}, {0x2705, 0x2705}, {0x270A, 0x270B},
+	{0x2728, 0x2728}, {0x274C, 0x274C}, {0x274E, 0x274E}, {0x2753, 0x2755}, {0x2757, 0x2757}, {0x2795, 0x2797},
+	{0x27B0, 0x27B0}, {0x27BF, 0x27BF}, {0x2B1B, 0x2B1C}, {0x2B50, 0x2B50}, {0x2B55, 0x2B55}, {0x2E80, 0x303E},
+	{0x3041, 0x33FF}, {0x3400, 0x4DBF}, {0x4E00, 0x9FFF}, {0xA000, 0xA4CF}, {0xA960, 0xA97F}, {0xAC00, 0xD7A3},
+	{0xF900, 0xFAFF}, {0xFE10, 0xFE19}, {0xFE30, 0xFE6F}, {0xFF00, 0xFF60}, {0xFFE0, 0xFFE6}, {0x16FE0, 0x16FE4},
+	{0x17000, 0x18CFF}, {0x1B000, 0x1B2FF}, {0x1F004, 0x1F004}, {0x1F0CF, 0x1F0CF}, {0x1F18E, 0x1F18E},
+	{0x1F191, 0x1F19A}, {0x1F200, 0x1F251}, {0x1F300, 0x1F320}, {0x1F32D, 0x1F335}, {0x1F337, 0x1F37C},
+	{0x1F37E, 0x1F393}, {0x1F3A0, 0x1F3CA}, {0x1F3CF, 0x1F3D3}, {0x1F3E0, 0x1F3F0}, {0x1F3F4, 0x1F3F4},
+	{0x1F3F8, 0x1F43E}, {0x1F440, 0x1F440}, {0x1F442, 0x1F4FC}, {0x1F4FF, 0x1F53D}, {0x1F54B, 0x1F54E},
+	{0x1F550, 0x1F567}, {0x1F57A, 0x1F57A}, {0x1F595, 0x1F596}, {0x1F5A4, 0x1F5A4}, {0x1F5FB, 0x1F64F},
+	{0x1F680, 0x1F6C5}, {0x1F6CC, 0x1F6CC}, {0x1F6D0, 0x1F6D2}, {0x1F6D5, 0x1F6D7}, {0x1F6EB, 0x1F6EC},
+	{0x1F6F4, 0x1F6FC}, {0x1F7E0, 0x1F7EB}, {0x1F90C, 0x1F93A}, {0x1F93C, 0x1F945}, {0x1F947, 0x1F9FF},
+	{0x1FA70, 0x1FAFF}, {0x20000, 0x2FFFD}, {0x30000, 0x3FFFD},
+}
+
+// Get the display width of a codepoint: 2 for fullwidth and wide characters, otherwise 1
+func charWidth(codepoint rune) int {
+	_, found := slices.BinarySearchFunc(wideRanges, codepoint, func(wideRange [2]rune, codepoint rune) int {
+		if wideRange[1] < codepoint {
+			return -1
+		}
+		if wideRange[0] > codepoint {
+			return 1
+		}
+		return 0
+	})
+	if found {
+		return 2
+	}
+	return 1
+}
+
+func stringWidth(codepoints []rune) int {
+	width := 0
+	for _, codepoint := range codepoints {
+		width += charWidth(codepoint)
+	}
+	return width
+}
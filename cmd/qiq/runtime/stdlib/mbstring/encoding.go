@@ -0,0 +1,292 @@
+package mbstring
+
+import (
+	"slices"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Codepoint used for byte sequences that are invalid in the source encoding
+const badInput rune = -1
+
+// Character used for invalid input and for characters that cannot be represented in the target encoding
+const substituteChar byte = '?'
+
+type encoding struct {
+	name    string
+	aliases []string
+	decode  func(str string) []rune
+	encode  func(codepoints []rune) string
+}
+
+var encodings = []*encoding{
+	{name: "ASCII", aliases: []string{"us-ascii", "ANSI_X3.4-1968", "iso646-us"}, decode: decodeAscii, encode: encodeAscii},
+	{name: "UTF-8", aliases: []string{"utf8"}, decode: decodeUtf8, encode: encodeUtf8},
+	{name: "UTF-16", aliases: []string{"utf16"}, decode: decodeUtf16, encode: encodeUtf16BE},
+	{name: "UTF-16BE", decode: decodeUtf16BE, encode: encodeUtf16BE},
+	{name: "UTF-16LE", decode: decodeUtf16LE, encode: encodeUtf16LE},
+	{name: "ISO-8859-1", aliases: []string{"ISO8859-1", "ISO_8859-1", "latin1"}, decode: decodeLatin1, encode: encodeLatin1},
+	{name: "Windows-1252", aliases: []string{"cp1252"}, decode: decodeCp1252, encode: encodeCp1252},
+}
+
+// Get the encoding with the given name or alias (case-insensitive)
+func getEncoding(name string) (*encoding, bool) {
+	for _, encoding := range encodings {
+		if strings.EqualFold(encoding.name, name) {
+			return encoding, true
+		}
+		for _, alias := range encoding.aliases {
+			if strings.EqualFold(alias, name) {
+				return encoding, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// Check if the string is valid in the given encoding
+func checkEncoding(str string, encoding *encoding) bool {
+	return !slices.Contains(encoding.decode(str), badInput)
+}
+
+// Convert the string from one encoding into another
+func convertEncoding(str string, from *encoding, to *encoding) string {
+	return to.encode(from.decode(str))
+}
+
+// -------------------------------------- ASCII -------------------------------------- MARK: ASCII
+
+func decodeAscii(str string) []rune {
+	result := make([]rune, len(str))
+	for i := 0; i < len(str); i++ {
+		if str[i] < 0x80 {
+			result[i] = rune(str[i])
+		} else {
+			result[i] = badInput
+		}
+	}
+	return result
+}
+
+func encodeAscii(codepoints []rune) string {
+	result := make([]byte, len(codepoints))
+	for i, codepoint := range codepoints {
+		if codepoint >= 0 && codepoint < 0x80 {
+			result[i] = byte(codepoint)
+		} else {
+			result[i] = substituteChar
+		}
+	}
+	return string(result)
+}
+
+// -------------------------------------- UTF-8 -------------------------------------- MARK: UTF-8
+
+func decodeUtf8(str string) []rune {
+	result := make([]rune, 0, len(str))
+	for len(str) > 0 {
+		codepoint, size := utf8.DecodeRuneInString(str)
+		if codepoint == utf8.RuneError && size <= 1 {
+			codepoint = badInput
+		}
+		result = append(result, codepoint)
+		str = str[size:]
+	}
+	return result
+}
+
+func encodeUtf8(codepoints []rune) string {
+	var result strings.Builder
+	for _, codepoint := range codepoints {
+		if codepoint < 0 || !utf8.ValidRune(codepoint) {
+			result.WriteByte(substituteChar)
+			continue
+		}
+		result.WriteRune(codepoint)
+	}
+	return result.String()
+}
+
+// -------------------------------------- UTF-16 -------------------------------------- MARK: UTF-16
+
+// Decode UTF-16 with an optional byte order mark. Without a byte order mark, big endian is assumed.
+func decodeUtf16(str string) []rune {
+	if strings.HasPrefix(str, "\xFF\xFE") {
+		return decodeUtf16LE(str[2:])
+	}
+	if strings.HasPrefix(str, "\xFE\xFF") {
+		return decodeUtf16BE(str[2:])
+	}
+	return decodeUtf16BE(str)
+}
+
+func decodeUtf16BE(str string) []rune {
+	return decodeUtf16Units(str, func(high byte, low byte) uint16 { return uint16(high)<<8 | uint16(low) })
+}
+
+func decodeUtf16LE(str string) []rune {
+	return decodeUtf16Units(str, func(high byte, low byte) uint16 { return uint16(low)<<8 | uint16(high) })
+}
+
+func decodeUtf16Units(str string, unit func(first byte, second byte) uint16) []rune {
+	result := make([]rune, 0, len(str)/2)
+	i := 0
+	for ; i+1 < len(str); i += 2 {
+		current := unit(str[i], str[i+1])
+		switch {
+		case utf16.IsSurrogate(rune(current)) && current < 0xDC00 && i+3 < len(str):
+			next := unit(str[i+2], str[i+3])
+			if codepoint := utf16.DecodeRune(rune(current), rune(next)); codepoint != utf8.RuneError {
+				result = append(result, codepoint)
+				i += 2
+			} else {
+				result = append(result, badInput)
+			}
+		case utf16.IsSurrogate(rune(current)):
+			result = append(result, badInput)
+		default:
+			result = append(result, rune(current))
+		}
+	}
+	if i < len(str) {
+		// Incomplete code unit at the end
+		result = append(result, badInput)
+	}
+	return result
+}
+
+func encodeUtf16BE(codepoints []rune) string {
+	return encodeUtf16Units(codepoints, func(unit uint16) (byte, byte) { return byte(unit >> 8), byte(unit) })
+}
+
+func encodeUtf16LE(codepoints []rune) string {
+	return encodeUtf16Units(codepoints, func(unit uint16) (byte, byte) { return byte(unit), byte(unit >> 8) })
+}
+
+func encodeUtf16Units(codepoints []rune, bytes func(unit uint16) (byte, byte)) string {
+	result := make([]byte, 0, len(codepoints)*2)
+	for _, codepoint := range codepoints {
+		if codepoint < 0 || !utf8.ValidRune(codepoint) {
+			codepoint = rune(substituteChar)
+		}
+		for _, unit := range utf16.Encode([]rune{codepoint}) {
+			first, second := bytes(unit)
+			result = append(result, first, second)
+		}
+	}
+	return string(result)
+}
+
+// -------------------------------------- ISO-8859-1 -------------------------------------- MARK: ISO-8859-1
+
+func decodeLatin1(str string) []rune {
+	result := make([]rune, len(str))
+	for i := 0; i < len(str); i++ {
+		result[i] = rune(str[i])
+	}
+	return result
+}
+
+func encodeLatin1(codepoints []rune) string {
+	result := make([]byte, len(codepoints))
+	for i, codepoint := range codepoints {
+		if codepoint >= 0 && codepoint <= 0xFF {
+			result[i] = byte(codepoint)
+		} else {
+			result[i] = substituteChar
+		}
+	}
+	return string(result)
+}
+
+// -------------------------------------- Windows-1252 -------------------------------------- MARK: Windows-1252
+
+// Codepoints of the bytes 0x80 to 0x9F. Unassigned bytes are zero.
+var cp1252Table = [32]rune{
+	0x20AC, 0, 0x201A, 0x0192, 0x201E, 0x2026, 0x2020, 0x2021, 0x02C6, 0x2030, 0x0160, 0x2039, 0x0152, 0, 0x017D, 0,
+	0, 0x2018, 0x2019, 0x201C, 0x201D, 0x2022, 0x2013, 0x2014, 0x02DC, 0x2122, 0x0161, 0x203A, 0x0153, 0, 0x017E, 0x0178,
+}
+
+func decodeCp1252(str string) []rune {
+	result := make([]rune, len(str))
+	for i := 0; i < len(str); i++ {
+		char := str[i]
+		switch {
+		case char < 0x80 || char > 0x9F:
+			result[i] = rune(char)
+		case cp1252Table[char-0x80] != 0:
+			result[i] = cp1252Table[char-0x80]
+		default:
+			result[i] = badInput
+		}
+	}
+	return result
+}
+
+func encodeCp1252(codepoints []rune) string {
+	result := make([]byte, len(codepoints))
+	for i, codepoint := range codepoints {
+		switch {
+		case codepoint >= 0 && codepoint < 0x80, codepoint > 0x9F && codepoint <= 0xFF:
+			result[i] = byte(codepoint)
+		default:
+			result[i] = substituteChar
+			if index := slices.Index(cp1252Table[:], codepoint); index >= 0 && codepoint > 0 {
+				result[i] = byte(0x80 + index)
+			}
+		}
+	}
+	return string(result)
+}
+
+// -------------------------------------- Detection -------------------------------------- MARK: Detection
+
+// Detect the encoding of the string from the candidates.
+// Each candidate is rated by the number of unusual characters the string decodes to,
+// the candidate with the least demerits wins. Ties are won by the earlier candidate.
+// In strict mode, candidates for which the string is invalid are ignored.
+// Otherwise the candidate with the least invalid sequences is used if no candidate is valid.
+func detectEncoding(str string, candidates []*encoding, strict bool) (*encoding, bool) {
+	var best *encoding
+	bestInvalid, bestDemerits := 0, 0
+	for _, candidate := range candidates {
+		invalid, demerits := 0, 0
+		for _, codepoint := range candidate.decode(str) {
+			if codepoint == badInput {
+				invalid++
+				continue
+			}
+			demerits += codepointDemerits(codepoint)
+		}
+		if strict && invalid > 0 {
+			continue
+		}
+		if best == nil || invalid < bestInvalid || (invalid == bestInvalid && demerits < bestDemerits) {
+			best, bestInvalid, bestDemerits = candidate, invalid, demerits
+		}
+	}
+	return best, best != nil
+}
+
+// Rate how unusual a codepoint is in regular text
+func codepointDemerits(codepoint rune) int {
+	switch {
+	case codepoint > 0xFFFF:
+		return 40
+	case codepoint < 0x20 && codepoint != '\t' && codepoint != '\n' && codepoint != '\r',
+		codepoint >= 0x7F && codepoint <= 0x9F:
+		// Control characters
+		return 30
+	case codepoint >= 0xA0 && codepoint <= 0xBF && !strings.ContainsRune(" ¡£§©«®°±´·»¿", codepoint):
+		// Rarely used Latin-1 symbols
+		return 30
+	case codepoint >= 0xD800 && codepoint <= 0xF8FF:
+		// Surrogates and private use area
+		return 30
+	case codepoint >= 0x21 && codepoint <= 0x2F:
+		return 6
+	default:
+		return 1
+	}
+}
@@ -0,0 +1,909 @@
+package mbstring
+
+import (
+	"QIQ/cmd/qiq/phpError"
+	"QIQ/cmd/qiq/runtime"
+	"QIQ/cmd/qiq/runtime/funcParamValidator"
+	"QIQ/cmd/qiq/runtime/stdlib/strings"
+	"QIQ/cmd/qiq/runtime/stdlib/variableHandling"
+	"QIQ/cmd/qiq/runtime/values"
+	"slices"
+	goStrings "strings"
+)
+
+func Register(environment runtime.Environment) {
+	// Category: Multibyte String Functions
+	environment.AddNativeFunction("mb_check_encoding", nativeFn_mb_check_encoding)
+	environment.AddNativeFunction("mb_convert_case", nativeFn_mb_convert_case)
+	environment.AddNativeFunction("mb_convert_encoding", nativeFn_mb_convert_encoding)
+	environment.AddNativeFunction("mb_detect_encoding", nativeFn_mb_detect_encoding)
+	environment.AddNativeFunction("mb_detect_order", nativeFn_mb_detect_order)
+	environment.AddNativeFunction("mb_internal_encoding", nativeFn_mb_internal_encoding)
+	environment.AddNativeFunction("mb_list_encodings", nativeFn_mb_list_encodings)
+	environment.AddNativeFunction("mb_str_pad", nativeFn_mb_str_pad)
+	environment.AddNativeFunction("mb_str_split", nativeFn_mb_str_split)
+	environment.AddNativeFunction("mb_strimwidth", nativeFn_mb_strimwidth)
+	environment.AddNativeFunction("mb_stripos", nativeFn_mb_stripos)
+	environment.AddNativeFunction("mb_stristr", nativeFn_mb_stristr)
+	environment.AddNativeFunction("mb_strlen", nativeFn_mb_strlen)
+	environment.AddNativeFunction("mb_strpos", nativeFn_mb_strpos)
+	environment.AddNativeFunction("mb_strrchr", nativeFn_mb_strrchr)
+	environment.AddNativeFunction("mb_strrichr", nativeFn_mb_strrichr)
+	environment.AddNativeFunction("mb_strripos", nativeFn_mb_strripos)
+	environment.AddNativeFunction("mb_strrpos", nativeFn_mb_strrpos)
+	environment.AddNativeFunction("mb_strstr", nativeFn_mb_strstr)
+	environment.AddNativeFunction("mb_strtolower", nativeFn_mb_strtolower)
+	environment.AddNativeFunction("mb_strtoupper", nativeFn_mb_strtoupper)
+	environment.AddNativeFunction("mb_strwidth", nativeFn_mb_strwidth)
+	environment.AddNativeFunction("mb_substr", nativeFn_mb_substr)
+	environment.AddNativeFunction("mb_substr_count", nativeFn_mb_substr_count)
+
+	// TODO mb_chr
+	// TODO mb_convert_kana
+	// TODO mb_convert_variables
+	// TODO mb_decode_mimeheader
+	// TODO mb_decode_numericentity
+	// TODO mb_encode_mimeheader
+	// TODO mb_encode_numericentity
+	// TODO mb_encoding_aliases
+	// TODO mb_ereg
+	// TODO mb_ereg_match
+	// TODO mb_ereg_replace
+	// TODO mb_ereg_replace_callback
+	// TODO mb_ereg_search
+	// TODO mb_ereg_search_getpos
+	// TODO mb_ereg_search_getregs
+	// TODO mb_ereg_search_init
+	// TODO mb_ereg_search_pos
+	// TODO mb_ereg_search_regs
+	// TODO mb_ereg_search_setpos
+	// TODO mb_eregi
+	// TODO mb_eregi_replace
+	// TODO mb_get_info
+	// TODO mb_http_input
+	// TODO mb_http_output
+	// TODO mb_language
+	// TODO mb_lcfirst
+	// TODO mb_ltrim
+	// TODO mb_ord
+	// TODO mb_output_handler
+	// TODO mb_parse_str
+	// TODO mb_preferred_mime_name
+	// TODO mb_regex_encoding
+	// TODO mb_regex_set_options
+	// TODO mb_rtrim
+	// TODO mb_scrub
+	// TODO mb_send_mail
+	// TODO mb_split
+	// TODO mb_strcut
+	// TODO mb_substitute_character
+	// TODO mb_trim
+	// TODO mb_ucfirst
+
+	// Const Category: Multibyte String Constants
+	// Spec: https://www.php.net/manual/en/mbstring.constants.php
+	environment.AddPredefinedConstant("MB_CASE_UPPER", values.NewInt(MB_CASE_UPPER))
+	environment.AddPredefinedConstant("MB_CASE_LOWER", values.NewInt(MB_CASE_LOWER))
+	environment.AddPredefinedConstant("MB_CASE_TITLE", values.NewInt(MB_CASE_TITLE))
+	environment.AddPredefinedConstant("MB_CASE_FOLD", values.NewInt(MB_CASE_FOLD))
+	environment.AddPredefinedConstant("MB_CASE_UPPER_SIMPLE", values.NewInt(MB_CASE_UPPER_SIMPLE))
+	environment.AddPredefinedConstant("MB_CASE_LOWER_SIMPLE", values.NewInt(MB_CASE_LOWER_SIMPLE))
+	environment.AddPredefinedConstant("MB_CASE_TITLE_SIMPLE", values.NewInt(MB_CASE_TITLE_SIMPLE))
+	environment.AddPredefinedConstant("MB_CASE_FOLD_SIMPLE", values.NewInt(MB_CASE_FOLD_SIMPLE))
+}
+
+// -------------------------------------- Helpers -------------------------------------- MARK: Helpers
+
+// Request-scoped state of the mbstring functions
+type mbstringState struct {
+	// Encoding set with mb_internal_encoding()
+	internalEncoding *encoding
+	// Encodings set with mb_detect_order()
+	detectOrder []*encoding
+}
+
+func getState(context runtime.Context) *mbstringState {
+	return context.Interpreter.GetExtensionState("mbstring", func() any { return &mbstringState{} }).(*mbstringState)
+}
+
+// Get the internal encoding.
+// If not set with mb_internal_encoding(), the ini setting "internal_encoding" and then "default_charset" is used.
+func getInternalEncoding(context runtime.Context) *encoding {
+	if state := getState(context); state.internalEncoding != nil {
+		return state.internalEncoding
+	}
+	name := context.Interpreter.GetIni().GetStr("internal_encoding")
+	if name == "" {
+		name = context.Interpreter.GetIni().GetStr("default_charset")
+	}
+	if encoding, found := getEncoding(name); found {
+		return encoding
+	}
+	utf8, _ := getEncoding("UTF-8")
+	return utf8
+}
+
+// Get the encodings used by mb_detect_encoding() if no encodings are given.
+// If not set with mb_detect_order(), the ini setting "mbstring.detect_order" is used. The default is ASCII, UTF-8.
+func getDetectOrder(context runtime.Context) []*encoding {
+	if state := getState(context); state.detectOrder != nil {
+		return state.detectOrder
+	}
+	if order, invalid := parseEncodingList(context.Interpreter.GetIni().GetStr("mbstring.detect_order"), nil); invalid == "" && len(order) > 0 {
+		return order
+	}
+	ascii, _ := getEncoding("ASCII")
+	utf8, _ := getEncoding("UTF-8")
+	return []*encoding{ascii, utf8}
+}
+
+// Get the encoding of the encoding argument. If the argument is null, the internal encoding is used.
+func getEncodingArg(funcName string, argNum int, value values.RuntimeValue, context runtime.Context) (*encoding, phpError.Error) {
+	if value.GetType() == values.NullValue {
+		return getInternalEncoding(context), nil
+	}
+	name := value.(*values.Str).Value
+	encoding, found := getEncoding(name)
+	if !found {
+		return nil, phpError.NewError("Uncaught ValueError: %s(): Argument #%d ($encoding) must be a valid encoding, \"%s\" given", funcName, argNum, name)
+	}
+	return encoding, nil
+}
+
+// Parse a comma separated list of encodings. The name "auto" is expanded to the detect order.
+// If the list contains an unknown encoding, its name is returned.
+func parseEncodingList(list string, autoEncodings []*encoding) ([]*encoding, string) {
+	result := []*encoding{}
+	for name := range goStrings.SplitSeq(list, ",") {
+		name = goStrings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if goStrings.EqualFold(name, "auto") {
+			result = append(result, autoEncodings...)
+			continue
+		}
+		encoding, found := getEncoding(name)
+		if !found {
+			return nil, name
+		}
+		result = append(result, encoding)
+	}
+	return result, ""
+}
+
+// Get the encodings of an encoding list argument given as array or comma separated string
+func getEncodingListArg(funcName string, argNum int, argName string, value values.RuntimeValue, context runtime.Context) ([]*encoding, phpError.Error) {
+	var names []string
+	if value.GetType() == values.ArrayValue {
+		for _, name := range value.(*values.Array).GetValues() {
+			str, err := variableHandling.StrVal(name, context.Interpreter.GetIni().GetInt("precision"))
+			if err != nil {
+				return nil, err
+			}
+			names = append(names, str)
+		}
+	} else {
+		names = []string{value.(*values.Str).Value}
+	}
+
+	result := []*encoding{}
+	for _, name := range names {
+		encodings, invalid := parseEncodingList(name, getDetectOrder(context))
+		if invalid != "" {
+			return nil, phpError.NewError("Uncaught ValueError: %s(): Argument #%d (%s) contains invalid encoding \"%s\"", funcName, argNum, argName, invalid)
+		}
+		result = append(result, encodings...)
+	}
+	if len(result) == 0 {
+		return nil, phpError.NewError("Uncaught ValueError: %s(): Argument #%d (%s) must specify at least one encoding", funcName, argNum, argName)
+	}
+	return result, nil
+}
+
+// Decode the string arguments and the encoding argument at the given index
+func decodeArgs(funcName string, args []values.RuntimeValue, stringCount int, encodingIndex int, context runtime.Context) ([][]rune, *encoding, phpError.Error) {
+	encoding, err := getEncodingArg(funcName, encodingIndex+1, args[encodingIndex], context)
+	if err != nil {
+		return nil, nil, err
+	}
+	result := make([][]rune, stringCount)
+	for i := range stringCount {
+		result[i] = encoding.decode(args[i].(*values.Str).Value)
+	}
+	return result, encoding, nil
+}
+
+// Find the first occurrence of the needle starting at the given offset
+func indexRunes(haystack []rune, needle []rune, offset int) int {
+	for i := offset; i+len(needle) <= len(haystack); i++ {
+		if slices.Equal(haystack[i:i+len(needle)], needle) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Find the last occurrence of the needle that starts between the given bounds
+func lastIndexRunes(haystack []rune, needle []rune, from int, to int) int {
+	for i := min(to, len(haystack)-len(needle)); i >= from; i-- {
+		if slices.Equal(haystack[i:i+len(needle)], needle) {
+			return i
+		}
+	}
+	return -1
+}
+
+// -------------------------------------- mb_check_encoding -------------------------------------- MARK: mb_check_encoding
+
+func nativeFn_mb_check_encoding(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.mb-check-encoding.php
+
+	args, err := funcParamValidator.NewValidator("mb_check_encoding").
+		AddParam("$value", []string{"array", "string", "null"}, values.NewNull()).
+		AddParam("$encoding", []string{"string", "null"}, values.NewNull()).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	encoding, err := getEncodingArg("mb_check_encoding", 2, args[1], context)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	if args[0].GetType() == values.NullValue {
+		context.Interpreter.PrintError(phpError.NewDeprecated("Calling mb_check_encoding() without argument is deprecated in %s", context.Stmt.GetPosString()))
+		return values.NewBool(true), nil
+	}
+
+	// Spec: https://www.php.net/manual/en/function.mb-check-encoding.php
+	// If value is an array, all keys and values are validated recursively.
+	var check func(value values.RuntimeValue) bool
+	check = func(value values.RuntimeValue) bool {
+		switch value.GetType() {
+		case values.StrValue:
+			return checkEncoding(value.(*values.Str).Value, encoding)
+		case values.ArrayValue:
+			for key, element := range value.(*values.Array).Iterate() {
+				if !check(key) || !check(element) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	return values.NewBool(check(args[0])), nil
+}
+
+// -------------------------------------- mb_convert_case -------------------------------------- MARK: mb_convert_case
+
+func nativeFn_mb_convert_case(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.mb-convert-case.php
+
+	args, err := funcParamValidator.NewValidator("mb_convert_case").
+		AddParam("$string", []string{"string"}, nil).
+		AddParam("$mode", []string{"int"}, nil).
+		AddParam("$encoding", []string{"string", "null"}, values.NewNull()).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	mode := args[1].(*values.Int).Value
+	if mode < MB_CASE_UPPER || mode > MB_CASE_FOLD_SIMPLE {
+		return values.NewVoid(), phpError.NewError("Uncaught ValueError: mb_convert_case(): Argument #2 ($mode) must be one of the MB_CASE_* constants")
+	}
+
+	return changeCase("mb_convert_case", []values.RuntimeValue{args[0], args[2]}, mode, context)
+}
+
+func changeCase(funcName string, args []values.RuntimeValue, mode int64, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	decoded, encoding, err := decodeArgs(funcName, args, 1, 1, context)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+	return values.NewStr(encoding.encode(convertCase(decoded[0], mode))), nil
+}
+
+// -------------------------------------- mb_convert_encoding -------------------------------------- MARK: mb_convert_encoding
+
+func nativeFn_mb_convert_encoding(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.mb-convert-encoding.php
+
+	args, err := funcParamValidator.NewValidator("mb_convert_encoding").
+		AddParam("$string", []string{"array", "string"}, nil).
+		AddParam("$to_encoding", []string{"string"}, nil).
+		AddParam("$from_encoding", []string{"array", "string", "null"}, values.NewNull()).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	toEncoding, found := getEncoding(args[1].(*values.Str).Value)
+	if !found {
+		return values.NewVoid(), phpError.NewError(
+			"Uncaught ValueError: mb_convert_encoding(): Argument #2 ($to_encoding) must be a valid encoding, \"%s\" given", args[1].(*values.Str).Value,
+		)
+	}
+
+	var fromEncodings []*encoding
+	if args[2].GetType() == values.NullValue {
+		fromEncodings = []*encoding{getInternalEncoding(context)}
+	} else if fromEncodings, err = getEncodingListArg("mb_convert_encoding", 3, "$from_encoding", args[2], context); err != nil {
+		return values.NewVoid(), err
+	}
+	strict := context.Interpreter.GetIni().GetBool("mbstring.strict_detection")
+
+	// Spec: https://www.php.net/manual/en/function.mb-convert-encoding.php
+	// If from_encoding is an array or comma separated list, the encoding is detected for each string.
+	// If string is an array, all its keys and values are converted recursively.
+	var convert func(value values.RuntimeValue) (values.RuntimeValue, bool, phpError.Error)
+	convert = func(value values.RuntimeValue) (values.RuntimeValue, bool, phpError.Error) {
+		switch value.GetType() {
+		case values.StrValue:
+			str := value.(*values.Str).Value
+			fromEncoding := fromEncodings[0]
+			if len(fromEncodings) > 1 {
+				if fromEncoding, found = detectEncoding(str, fromEncodings, strict); !found {
+					context.Interpreter.PrintError(phpError.NewWarning("mb_convert_encoding(): Unable to detect character encoding in %s", context.Stmt.GetPosString()))
+					return values.NewBool(false), false, nil
+				}
+			}
+			return values.NewStr(convertEncoding(str, fromEncoding, toEncoding)), true, nil
+		case values.ArrayValue:
+			result := values.NewArray()
+			for key, element := range value.(*values.Array).Iterate() {
+				convertedKey, ok, err := convert(key)
+				if !ok || err != nil {
+					return convertedKey, ok, err
+				}
+				convertedElement, ok, err := convert(element)
+				if !ok || err != nil {
+					return convertedElement, ok, err
+				}
+				if err := result.SetElement(convertedKey, convertedElement); err != nil {
+					return values.NewVoid(), false, err
+				}
+			}
+			return result, true, nil
+		default:
+			return value, true, nil
+		}
+	}
+	result, _, err := convert(args[0])
+	return result, err
+}
+
+// -------------------------------------- mb_detect_encoding -------------------------------------- MARK: mb_detect_encoding
+
+func nativeFn_mb_detect_encoding(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.mb-detect-encoding.php
+
+	args, err := funcParamValidator.NewValidator("mb_detect_encoding").
+		AddParam("$string", []string{"string"}, nil).
+		AddParam("$encodings", []string{"array", "string", "null"}, values.NewNull()).
+		AddParam("$strict", []string{"bool"}, values.NewBool(false)).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	var candidates []*encoding
+	if args[1].GetType() == values.NullValue {
+		candidates = getDetectOrder(context)
+	} else if candidates, err = getEncodingListArg("mb_detect_encoding", 2, "$encodings", args[1], context); err != nil {
+		return values.NewVoid(), err
+	}
+
+	encoding, found := detectEncoding(args[0].(*values.Str).Value, candidates, args[2].(*values.Bool).Value)
+	if !found {
+		return values.NewBool(false), nil
+	}
+	return values.NewStr(encoding.name), nil
+}
+
+// -------------------------------------- mb_detect_order -------------------------------------- MARK: mb_detect_order
+
+func nativeFn_mb_detect_order(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.mb-detect-order.php
+
+	args, err := funcParamValidator.NewValidator("mb_detect_order").
+		AddParam("$encoding", []string{"array", "string", "null"}, values.NewNull()).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Spec: https://www.php.net/manual/en/function.mb-detect-order.php
+	// When getting the encoding detection order, an ordered array of the encodings is returned.
+	if args[0].GetType() == values.NullValue {
+		result := values.NewArray()
+		for _, encoding := range getDetectOrder(context) {
+			if err := result.SetElement(nil, values.NewStr(encoding.name)); err != nil {
+				return values.NewVoid(), err
+			}
+		}
+		return result, nil
+	}
+
+	order, err := getEncodingListArg("mb_detect_order", 1, "$encoding", args[0], context)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+	getState(context).detectOrder = order
+	return values.NewBool(true), nil
+}
+
+// -------------------------------------- mb_internal_encoding -------------------------------------- MARK: mb_internal_encoding
+
+func nativeFn_mb_internal_encoding(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.mb-internal-encoding.php
+
+	args, err := funcParamValidator.NewValidator("mb_internal_encoding").
+		AddParam("$encoding", []string{"string", "null"}, values.NewNull()).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Spec: https://www.php.net/manual/en/function.mb-internal-encoding.php
+	// If encoding is omitted or null, then the current character encoding name is returned.
+	if args[0].GetType() == values.NullValue {
+		return values.NewStr(getInternalEncoding(context).name), nil
+	}
+
+	encoding, err := getEncodingArg("mb_internal_encoding", 1, args[0], context)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+	getState(context).internalEncoding = encoding
+	return values.NewBool(true), nil
+}
+
+// -------------------------------------- mb_list_encodings -------------------------------------- MARK: mb_list_encodings
+
+func nativeFn_mb_list_encodings(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.mb-list-encodings.php
+
+	_, err := funcParamValidator.NewValidator("mb_list_encodings").Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	result := values.NewArray()
+	for _, encoding := range encodings {
+		if err := result.SetElement(nil, values.NewStr(encoding.name)); err != nil {
+			return values.NewVoid(), err
+		}
+	}
+	return result, nil
+}
+
+// -------------------------------------- mb_str_pad -------------------------------------- MARK: mb_str_pad
+
+func nativeFn_mb_str_pad(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.mb-str-pad.php
+
+	args, err := funcParamValidator.NewValidator("mb_str_pad").
+		AddParam("$string", []string{"string"}, nil).
+		AddParam("$length", []string{"int"}, nil).
+		AddParam("$pad_string", []string{"string"}, values.NewStr(" ")).
+		AddParam("$pad_type", []string{"int"}, values.NewInt(strings.STR_PAD_RIGHT)).
+		AddParam("$encoding", []string{"string", "null"}, values.NewNull()).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	length := args[1].(*values.Int).Value
+	padType := args[3].(*values.Int).Value
+	if args[2].(*values.Str).Value == "" {
+		return values.NewVoid(), phpError.NewError("Uncaught ValueError: mb_str_pad(): Argument #3 ($pad_string) must be a non-empty string")
+	}
+	if padType != strings.STR_PAD_LEFT && padType != strings.STR_PAD_RIGHT && padType != strings.STR_PAD_BOTH {
+		return values.NewVoid(), phpError.NewError("Uncaught ValueError: mb_str_pad(): Argument #4 ($pad_type) must be STR_PAD_LEFT, STR_PAD_RIGHT, or STR_PAD_BOTH")
+	}
+
+	decoded, encoding, err := decodeArgs("mb_str_pad", []values.RuntimeValue{args[0], args[2], args[4]}, 2, 2, context)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+	input, padString := decoded[0], decoded[1]
+
+	// Spec: https://www.php.net/manual/en/function.mb-str-pad.php
+	// If the value of length is negative, less than, or equal to the length of the input string, no padding takes place.
+	if length <= int64(len(input)) {
+		return args[0], nil
+	}
+
+	padLength := int(length) - len(input)
+	leftLength := 0
+	switch padType {
+	case strings.STR_PAD_LEFT:
+		leftLength = padLength
+	case strings.STR_PAD_BOTH:
+		leftLength = padLength / 2
+	}
+	pad := func(length int) []rune {
+		return slices.Repeat(padString, length/len(padString)+1)[:length]
+	}
+
+	return values.NewStr(encoding.encode(slices.Concat(pad(leftLength), input, pad(padLength-leftLength)))), nil
+}
+
+// -------------------------------------- mb_str_split -------------------------------------- MARK: mb_str_split
+
+func nativeFn_mb_str_split(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.mb-str-split.php
+
+	args, err := funcParamValidator.NewValidator("mb_str_split").
+		AddParam("$string", []string{"string"}, nil).
+		AddParam("$length", []string{"int"}, values.NewInt(1)).
+		AddParam("$encoding", []string{"string", "null"}, values.NewNull()).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	length := args[1].(*values.Int).Value
+	if length < 1 {
+		return values.NewVoid(), phpError.NewError("Uncaught ValueError: mb_str_split(): Argument #2 ($length) must be greater than 0")
+	}
+
+	decoded, encoding, err := decodeArgs("mb_str_split", []values.RuntimeValue{args[0], args[2]}, 1, 1, context)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	result := values.NewArray()
+	for chunk := range slices.Chunk(decoded[0], int(min(length, int64(max(len(decoded[0]), 1))))) {
+		if err := result.SetElement(nil, values.NewStr(encoding.encode(chunk))); err != nil {
+			return values.NewVoid(), err
+		}
+	}
+	return result, nil
+}
+
+// -------------------------------------- mb_strimwidth -------------------------------------- MARK: mb_strimwidth
+
+func nativeFn_mb_strimwidth(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.mb-strimwidth.php
+
+	args, err := funcParamValidator.NewValidator("mb_strimwidth").
+		AddParam("$string", []string{"string"}, nil).
+		AddParam("$start", []string{"int"}, nil).
+		AddParam("$width", []string{"int"}, nil).
+		AddParam("$trim_marker", []string{"string"}, values.NewStr("")).
+		AddParam("$encoding", []string{"string", "null"}, values.NewNull()).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	decoded, encoding, err := decodeArgs("mb_strimwidth", []values.RuntimeValue{args[0], args[3], args[4]}, 2, 2, context)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+	input, trimMarker := decoded[0], decoded[1]
+	start := int(args[1].(*values.Int).Value)
+	width := int(args[2].(*values.Int).Value)
+
+	// Spec: https://www.php.net/manual/en/function.mb-strimwidth.php
+	// Negative start counts from the end of the string.
+	if start < 0 {
+		start += len(input)
+	}
+	if start < 0 || start > len(input) {
+		return values.NewVoid(), phpError.NewError("Uncaught ValueError: mb_strimwidth(): Argument #2 ($start) is out of range")
+	}
+	input = input[start:]
+
+	// Spec: https://www.php.net/manual/en/function.mb-strimwidth.php
+	// Negative width counts from the end of the string.
+	if width < 0 {
+		context.Interpreter.PrintError(phpError.NewDeprecated(
+			"mb_strimwidth(): Passing a negative integer to argument #3 ($width) is deprecated in %s", context.Stmt.GetPosString(),
+		))
+		width += stringWidth(input)
+		if width < 0 {
+			return values.NewVoid(), phpError.NewError("Uncaught ValueError: mb_strimwidth(): Argument #3 ($width) is out of range")
+		}
+	}
+
+	if stringWidth(input) <= width {
+		return values.NewStr(encoding.encode(input)), nil
+	}
+
+	available := width - stringWidth(trimMarker)
+	end, usedWidth := 0, 0
+	for ; end < len(input) && usedWidth+charWidth(input[end]) <= available; end++ {
+		usedWidth += charWidth(input[end])
+	}
+	return values.NewStr(encoding.encode(slices.Concat(input[:end], trimMarker))), nil
+}
+
+// -------------------------------------- mb_stripos -------------------------------------- MARK: mb_stripos
+
+func nativeFn_mb_stripos(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.mb-stripos.php
+	return position("mb_stripos", args, true, false, context)
+}
+
+// -------------------------------------- mb_stristr -------------------------------------- MARK: mb_stristr
+
+func nativeFn_mb_stristr(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.mb-stristr.php
+	return find("mb_stristr", args, true, false, context)
+}
+
+// -------------------------------------- mb_strlen -------------------------------------- MARK: mb_strlen
+
+func nativeFn_mb_strlen(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.mb-strlen.php
+
+	args, err := funcParamValidator.NewValidator("mb_strlen").
+		AddParam("$string", []string{"string"}, nil).
+		AddParam("$encoding", []string{"string", "null"}, values.NewNull()).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	decoded, _, err := decodeArgs("mb_strlen", args, 1, 1, context)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+	return values.NewInt(int64(len(decoded[0]))), nil
+}
+
+// -------------------------------------- mb_strpos -------------------------------------- MARK: mb_strpos
+
+func nativeFn_mb_strpos(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.mb-strpos.php
+	return position("mb_strpos", args, false, false, context)
+}
+
+func position(funcName string, args []values.RuntimeValue, ignoreCase bool, last bool, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	args, err := funcParamValidator.NewValidator(funcName).
+		AddParam("$haystack", []string{"string"}, nil).
+		AddParam("$needle", []string{"string"}, nil).
+		AddParam("$offset", []string{"int"}, values.NewInt(0)).
+		AddParam("$encoding", []string{"string", "null"}, values.NewNull()).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	decoded, _, err := decodeArgs(funcName, []values.RuntimeValue{args[0], args[1], args[3]}, 2, 2, context)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+	haystack, needle := decoded[0], decoded[1]
+	if ignoreCase {
+		haystack, needle = convertCase(haystack, MB_CASE_FOLD_SIMPLE), convertCase(needle, MB_CASE_FOLD_SIMPLE)
+	}
+
+	offset := int(args[2].(*values.Int).Value)
+	if offset < -len(haystack) || offset > len(haystack) {
+		return values.NewVoid(), phpError.NewError("Uncaught ValueError: %s(): Argument #3 ($offset) must be contained in argument #1 ($haystack)", funcName)
+	}
+
+	var index int
+	switch {
+	case !last:
+		if offset < 0 {
+			offset += len(haystack)
+		}
+		index = indexRunes(haystack, needle, offset)
+	case offset >= 0:
+		index = lastIndexRunes(haystack, needle, offset, len(haystack))
+	default:
+		// Spec: https://www.php.net/manual/en/function.mb-strrpos.php
+		// If offset is negative, the search starts that many characters from the end of the string and proceeds backwards.
+		index = lastIndexRunes(haystack, needle, 0, len(haystack)+offset)
+	}
+
+	if index < 0 {
+		return values.NewBool(false), nil
+	}
+	return values.NewInt(int64(index)), nil
+}
+
+// -------------------------------------- mb_strrchr -------------------------------------- MARK: mb_strrchr
+
+func nativeFn_mb_strrchr(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.mb-strrchr.php
+	return find("mb_strrchr", args, false, true, context)
+}
+
+// -------------------------------------- mb_strrichr -------------------------------------- MARK: mb_strrichr
+
+func nativeFn_mb_strrichr(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.mb-strrichr.php
+	return find("mb_strrichr", args, true, true, context)
+}
+
+// -------------------------------------- mb_strripos -------------------------------------- MARK: mb_strripos
+
+func nativeFn_mb_strripos(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.mb-strripos.php
+	return position("mb_strripos", args, true, true, context)
+}
+
+// -------------------------------------- mb_strrpos -------------------------------------- MARK: mb_strrpos
+
+func nativeFn_mb_strrpos(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.mb-strrpos.php
+	return position("mb_strrpos", args, false, true, context)
+}
+
+// -------------------------------------- mb_strstr -------------------------------------- MARK: mb_strstr
+
+func nativeFn_mb_strstr(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.mb-strstr.php
+	return find("mb_strstr", args, false, false, context)
+}
+
+func find(funcName string, args []values.RuntimeValue, ignoreCase bool, last bool, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	args, err := funcParamValidator.NewValidator(funcName).
+		AddParam("$haystack", []string{"string"}, nil).
+		AddParam("$needle", []string{"string"}, nil).
+		AddParam("$before_needle", []string{"bool"}, values.NewBool(false)).
+		AddParam("$encoding", []string{"string", "null"}, values.NewNull()).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	decoded, encoding, err := decodeArgs(funcName, []values.RuntimeValue{args[0], args[1], args[3]}, 2, 2, context)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+	haystack, needle := decoded[0], decoded[1]
+	searchHaystack, searchNeedle := haystack, needle
+	if ignoreCase {
+		searchHaystack, searchNeedle = convertCase(haystack, MB_CASE_FOLD_SIMPLE), convertCase(needle, MB_CASE_FOLD_SIMPLE)
+	}
+
+	var index int
+	if last {
+		index = lastIndexRunes(searchHaystack, searchNeedle, 0, len(searchHaystack))
+	} else {
+		index = indexRunes(searchHaystack, searchNeedle, 0)
+	}
+	if index < 0 {
+		return values.NewBool(false), nil
+	}
+
+	// Spec: https://www.php.net/manual/en/function.mb-strstr.php
+	// If before_needle is true, the part of haystack before the first occurrence of the needle is returned.
+	if args[2].(*values.Bool).Value {
+		return values.NewStr(encoding.encode(haystack[:index])), nil
+	}
+	return values.NewStr(encoding.encode(haystack[index:])), nil
+}
+
+// -------------------------------------- mb_strtolower -------------------------------------- MARK: mb_strtolower
+
+func nativeFn_mb_strtolower(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.mb-strtolower.php
+
+	args, err := funcParamValidator.NewValidator("mb_strtolower").
+		AddParam("$string", []string{"string"}, nil).
+		AddParam("$encoding", []string{"string", "null"}, values.NewNull()).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	return changeCase("mb_strtolower", args, MB_CASE_LOWER, context)
+}
+
+// -------------------------------------- mb_strtoupper -------------------------------------- MARK: mb_strtoupper
+
+func nativeFn_mb_strtoupper(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.mb-strtoupper.php
+
+	args, err := funcParamValidator.NewValidator("mb_strtoupper").
+		AddParam("$string", []string{"string"}, nil).
+		AddParam("$encoding", []string{"string", "null"}, values.NewNull()).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	return changeCase("mb_strtoupper", args, MB_CASE_UPPER, context)
+}
+
+// -------------------------------------- mb_strwidth -------------------------------------- MARK: mb_strwidth
+
+func nativeFn_mb_strwidth(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.mb-strwidth.php
+
+	args, err := funcParamValidator.NewValidator("mb_strwidth").
+		AddParam("$string", []string{"string"}, nil).
+		AddParam("$encoding", []string{"string", "null"}, values.NewNull()).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	decoded, _, err := decodeArgs("mb_strwidth", args, 1, 1, context)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+	return values.NewInt(int64(stringWidth(decoded[0]))), nil
+}
+
+// -------------------------------------- mb_substr -------------------------------------- MARK: mb_substr
+
+func nativeFn_mb_substr(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.mb-substr.php
+
+	args, err := funcParamValidator.NewValidator("mb_substr").
+		AddParam("$string", []string{"string"}, nil).
+		AddParam("$start", []string{"int"}, nil).
+		AddParam("$length", []string{"int", "null"}, values.NewNull()).
+		AddParam("$encoding", []string{"string", "null"}, values.NewNull()).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	decoded, encoding, err := decodeArgs("mb_substr", []values.RuntimeValue{args[0], args[3]}, 1, 1, context)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+	input := decoded[0]
+
+	// Spec: https://www.php.net/manual/en/function.mb-substr.php
+	// If start is negative, the returned string will start at the start'th character from the end of string.
+	// If length is negative, that many characters will be omitted from the end of string.
+	start := args[1].(*values.Int).Value
+	if start < 0 {
+		start = max(int64(len(input))+start, 0)
+	}
+	start = min(start, int64(len(input)))
+	end := int64(len(input))
+	if args[2].GetType() == values.IntValue {
+		if length := args[2].(*values.Int).Value; length < 0 {
+			end = max(end+length, start)
+		} else {
+			end = min(start+min(length, end), end)
+		}
+	}
+
+	return values.NewStr(encoding.encode(input[start:end])), nil
+}
+
+// -------------------------------------- mb_substr_count -------------------------------------- MARK: mb_substr_count
+
+func nativeFn_mb_substr_count(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.mb-substr-count.php
+
+	args, err := funcParamValidator.NewValidator("mb_substr_count").
+		AddParam("$haystack", []string{"string"}, nil).
+		AddParam("$needle", []string{"string"}, nil).
+		AddParam("$encoding", []string{"string", "null"}, values.NewNull()).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	if args[1].(*values.Str).Value == "" {
+		return values.NewVoid(), phpError.NewError("Uncaught ValueError: mb_substr_count(): Argument #2 ($needle) must not be empty")
+	}
+
+	decoded, _, err := decodeArgs("mb_substr_count", args, 2, 2, context)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+	haystack, needle := decoded[0], decoded[1]
+
+	count := int64(0)
+	for offset := indexRunes(haystack, needle, 0); offset >= 0; offset = indexRunes(haystack, needle, offset+len(needle)) {
+		count++
+	}
+	return values.NewInt(count), nil
+}
@@ -0,0 +1,114 @@
+package mbstring
+
+import (
+	"testing"
+)
+
+// -------------------------------------- encoding -------------------------------------- MARK: encoding
+
+func TestConvertEncoding(t *testing.T) {
+	doTest := func(t *testing.T, input string, from string, to string, expected string) {
+		fromEncoding, _ := getEncoding(from)
+		toEncoding, _ := getEncoding(to)
+		if actual := convertEncoding(input, fromEncoding, toEncoding); actual != expected {
+			t.Errorf("%q from %s to %s - Expected: %q, Got %q", input, from, to, expected, actual)
+		}
+	}
+
+	doTest(t, "aä€", "UTF-8", "ASCII", "a??")
+	doTest(t, "aä€", "UTF-8", "ISO-8859-1", "a\xE4?")
+	doTest(t, "aä€", "UTF-8", "Windows-1252", "a\xE4\x80")
+	doTest(t, "aä€", "UTF-8", "UTF-16", "\x00a\x00\xE4\x20\xAC")
+	doTest(t, "a😀", "UTF-8", "UTF-16LE", "a\x00\x3D\xD8\x00\xDE")
+	doTest(t, "a\x00\x3D\xD8\x00\xDE", "UTF-16LE", "UTF-8", "a😀")
+	doTest(t, "\xFE\xFF\x00a", "UTF-16", "UTF-8", "a")
+	doTest(t, "\xFF\xFEa\x00", "UTF-16", "UTF-8", "a")
+	doTest(t, "\x00a\xD8\x00\x00", "UTF-16BE", "UTF-8", "a??")
+	doTest(t, "\x81\x80", "Windows-1252", "UTF-8", "?€")
+	doTest(t, "a\xFFb", "UTF-8", "UTF-8", "a?b")
+}
+
+func TestGetEncoding(t *testing.T) {
+	doTest := func(t *testing.T, name string, expected string) {
+		encoding, found := getEncoding(name)
+		if expected == "" {
+			if found {
+				t.Errorf("%q - Expected no encoding, Got %s", name, encoding.name)
+			}
+		} else if !found || encoding.name != expected {
+			t.Errorf("%q - Expected: %s, Got %v", name, expected, encoding)
+		}
+	}
+
+	doTest(t, "utf-8", "UTF-8")
+	doTest(t, "UTF8", "UTF-8")
+	doTest(t, "latin1", "ISO-8859-1")
+	doTest(t, "CP1252", "Windows-1252")
+	doTest(t, "us-ascii", "ASCII")
+	doTest(t, "EBCDIC", "")
+}
+
+func TestDetectEncoding(t *testing.T) {
+	doTest := func(t *testing.T, input string, candidates []string, strict bool, expected string) {
+		encodings := []*encoding{}
+		for _, name := range candidates {
+			encoding, _ := getEncoding(name)
+			encodings = append(encodings, encoding)
+		}
+		encoding, found := detectEncoding(input, encodings, strict)
+		if expected == "" {
+			if found {
+				t.Errorf("%q - Expected no encoding, Got %s", input, encoding.name)
+			}
+		} else if !found || encoding.name != expected {
+			t.Errorf("%q - Expected: %s, Got %v", input, expected, encoding)
+		}
+	}
+
+	doTest(t, "abc", []string{"ASCII", "UTF-8"}, true, "ASCII")
+	doTest(t, "äbc", []string{"ASCII", "UTF-8"}, true, "UTF-8")
+	doTest(t, "äbc", []string{"ISO-8859-1", "UTF-8"}, true, "UTF-8")
+	doTest(t, "\xE4bc", []string{"ASCII", "UTF-8", "ISO-8859-1"}, true, "ISO-8859-1")
+	doTest(t, "\xE4bc", []string{"ASCII", "UTF-8"}, true, "")
+	doTest(t, "\xE4bc", []string{"ASCII", "UTF-8"}, false, "ASCII")
+}
+
+// -------------------------------------- casing -------------------------------------- MARK: casing
+
+func TestConvertCase(t *testing.T) {
+	doTest := func(t *testing.T, input string, mode int64, expected string) {
+		if actual := string(convertCase([]rune(input), mode)); actual != expected {
+			t.Errorf("%q, mode %d - Expected: %q, Got %q", input, mode, expected, actual)
+		}
+	}
+
+	doTest(t, "straße", MB_CASE_UPPER, "STRASSE")
+	doTest(t, "straße", MB_CASE_UPPER_SIMPLE, "STRAßE")
+	doTest(t, "ĞİŞ", MB_CASE_LOWER, "ği̇ş")
+	doTest(t, "ĞİŞ", MB_CASE_LOWER_SIMPLE, "ğiş")
+	doTest(t, "ΟΔΟΣ ΣΑΣ. Σ", MB_CASE_LOWER, "οδος σας. σ")
+	doTest(t, "ΟΔΟΣ", MB_CASE_LOWER_SIMPLE, "οδοσ")
+	doTest(t, "ǆ ǆ", MB_CASE_TITLE, "ǅ ǅ")
+	doTest(t, "hello WORLD-foo it's 2nd", MB_CASE_TITLE, "Hello World-Foo It's 2Nd")
+	doTest(t, "ßen ﬁsh", MB_CASE_TITLE, "Ssen Fish")
+	doTest(t, "ßen ﬁsh", MB_CASE_TITLE_SIMPLE, "ßen ﬁsh")
+	doTest(t, "Straße ΣΑΣ ſ", MB_CASE_FOLD, "strasse σασ s")
+	doTest(t, "Straße ΣΑΣ ſ", MB_CASE_FOLD_SIMPLE, "straße σασ s")
+}
+
+func TestStringWidth(t *testing.T) {
+	doTest := func(t *testing.T, input string, expected int) {
+		if actual := stringWidth([]rune(input)); actual != expected {
+			t.Errorf("%q - Expected: %d, Got %d", input, expected, actual)
+		}
+	}
+
+	doTest(t, "", 0)
+	doTest(t, "abc", 3)
+	doTest(t, "äöü", 3)
+	doTest(t, "日本語", 6)
+	doTest(t, "ｱｲｳ", 3)
+	doTest(t, "ＡＢ", 4)
+	doTest(t, "한국어", 6)
+	doTest(t, "a😀", 3)
+}
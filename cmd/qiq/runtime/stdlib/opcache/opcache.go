@@ -0,0 +1,147 @@
+package opcache
+
+import (
+	"QIQ/cmd/qiq/common"
+	cache "QIQ/cmd/qiq/opcache"
+	"QIQ/cmd/qiq/phpError"
+	"QIQ/cmd/qiq/runtime"
+	"QIQ/cmd/qiq/runtime/funcParamValidator"
+	"QIQ/cmd/qiq/runtime/values"
+	"time"
+)
+
+func Register(environment runtime.Environment) {
+	// Category: OPcache Functions
+	environment.AddNativeFunction("opcache_get_status", nativeFn_opcache_get_status)
+	environment.AddNativeFunction("opcache_invalidate", nativeFn_opcache_invalidate)
+	environment.AddNativeFunction("opcache_is_script_cached", nativeFn_opcache_is_script_cached)
+	environment.AddNativeFunction("opcache_reset", nativeFn_opcache_reset)
+}
+
+// -------------------------------------- opcache_get_status -------------------------------------- MARK: opcache_get_status
+
+func nativeFn_opcache_get_status(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.opcache-get-status.php
+
+	args, err := funcParamValidator.NewValidator("opcache_get_status").
+		AddParam("$include_scripts", []string{"bool"}, values.NewBool(true)).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Returns an array of information, optionally containing script specific state information, or false on failure.
+	if !context.Interpreter.GetIni().GetBool("opcache.enable") {
+		return values.NewBool(false), nil
+	}
+
+	status := cache.GetStatus()
+
+	statistics := values.NewArray()
+	statistics.SetElement(values.NewStr("num_cached_scripts"), values.NewInt(int64(len(status.Scripts))))
+	statistics.SetElement(values.NewStr("hits"), values.NewInt(status.Hits))
+	statistics.SetElement(values.NewStr("start_time"), values.NewInt(status.StartTime.Unix()))
+	statistics.SetElement(values.NewStr("last_restart_time"), values.NewInt(toTimestamp(status.LastRestartTime)))
+	statistics.SetElement(values.NewStr("misses"), values.NewInt(status.Misses))
+	hitRate := 0.0
+	if status.Hits+status.Misses > 0 {
+		hitRate = float64(status.Hits) * 100 / float64(status.Hits+status.Misses)
+	}
+	statistics.SetElement(values.NewStr("opcache_hit_rate"), values.NewFloat(hitRate))
+
+	result := values.NewArray()
+	result.SetElement(values.NewStr("opcache_enabled"), values.NewBool(true))
+	result.SetElement(values.NewStr("cache_full"), values.NewBool(false))
+	result.SetElement(values.NewStr("restart_pending"), values.NewBool(false))
+	result.SetElement(values.NewStr("restart_in_progress"), values.NewBool(false))
+	result.SetElement(values.NewStr("opcache_statistics"), statistics)
+
+	if args[0].(*values.Bool).Value {
+		scripts := values.NewArray()
+		for _, script := range status.Scripts {
+			scriptStatus := values.NewArray()
+			scriptStatus.SetElement(values.NewStr("full_path"), values.NewStr(script.FullPath))
+			scriptStatus.SetElement(values.NewStr("hits"), values.NewInt(script.Hits))
+			scriptStatus.SetElement(values.NewStr("last_used"), values.NewStr(script.LastUsed.Format("Mon Jan 2 15:04:05 2006")))
+			scriptStatus.SetElement(values.NewStr("last_used_timestamp"), values.NewInt(script.LastUsed.Unix()))
+			scriptStatus.SetElement(values.NewStr("timestamp"), values.NewInt(script.Timestamp.Unix()))
+			scripts.SetElement(values.NewStr(script.FullPath), scriptStatus)
+		}
+		result.SetElement(values.NewStr("scripts"), scripts)
+	}
+
+	return result, nil
+}
+
+func toTimestamp(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// -------------------------------------- opcache_invalidate -------------------------------------- MARK: opcache_invalidate
+
+func nativeFn_opcache_invalidate(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.opcache-invalidate.php
+
+	args, err := funcParamValidator.NewValidator("opcache_invalidate").
+		AddParam("$filename", []string{"string"}, nil).
+		AddParam("$force", []string{"bool"}, values.NewBool(false)).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Returns true if the opcode cache for filename was invalidated or if there was nothing to be invalidated,
+	// or false if the opcode cache is disabled.
+	if !context.Interpreter.GetIni().GetBool("opcache.enable") {
+		return values.NewBool(false), nil
+	}
+
+	// This function invalidates a particular script from the opcode cache.
+	// If force is unset or false, the script will only be invalidated if the modification time of the script
+	// is newer than the cached opcodes.
+	cache.Invalidate(common.ToAbsPath(args[0].(*values.Str).Value), args[1].(*values.Bool).Value)
+	return values.NewBool(true), nil
+}
+
+// -------------------------------------- opcache_is_script_cached -------------------------------------- MARK: opcache_is_script_cached
+
+func nativeFn_opcache_is_script_cached(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.opcache-is-script-cached.php
+
+	args, err := funcParamValidator.NewValidator("opcache_is_script_cached").AddParam("$filename", []string{"string"}, nil).Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Returns true if filename is cached in OPCache, false otherwise.
+	if !context.Interpreter.GetIni().GetBool("opcache.enable") {
+		return values.NewBool(false), nil
+	}
+	return values.NewBool(cache.IsCached(common.ToAbsPath(args[0].(*values.Str).Value))), nil
+}
+
+// -------------------------------------- opcache_reset -------------------------------------- MARK: opcache_reset
+
+func nativeFn_opcache_reset(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.opcache-reset.php
+
+	_, err := funcParamValidator.NewValidator("opcache_reset").Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Returns true if the opcode cache was reset, or false if the opcode cache is disabled.
+	if !context.Interpreter.GetIni().GetBool("opcache.enable") {
+		return values.NewBool(false), nil
+	}
+
+	// This function resets the entire opcode cache.
+	cache.Reset()
+	return values.NewBool(true), nil
+}
+
+// TODO opcache_compile_file
+// TODO opcache_get_configuration
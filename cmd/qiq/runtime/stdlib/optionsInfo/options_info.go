@@ -11,6 +11,11 @@ import (
 
 func Register(environment runtime.Environment) {
 	// Category: Options/Info Functions
+	environment.AddNativeFunction("gc_collect_cycles", nativeFn_gc_collect_cycles)
+	environment.AddNativeFunction("gc_disable", nativeFn_gc_disable)
+	environment.AddNativeFunction("gc_enable", nativeFn_gc_enable)
+	environment.AddNativeFunction("gc_enabled", nativeFn_gc_enabled)
+	environment.AddNativeFunction("gc_status", nativeFn_gc_status)
 	environment.AddNativeFunction("getenv", nativeFn_getenv)
 	environment.AddNativeFunction("ini_get", nativeFn_ini_get)
 	environment.AddNativeFunction("ini_set", nativeFn_ini_set)
@@ -23,6 +28,107 @@ func Register(environment runtime.Environment) {
 	environment.AddPredefinedConstant("INI_ALL", values.NewInt(int64(ini.INI_ALL)))
 }
 
+// -------------------------------------- gc_collect_cycles -------------------------------------- MARK: gc_collect_cycles
+
+func nativeFn_gc_collect_cycles(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.gc-collect-cycles.php
+
+	// gc_collect_cycles(): int
+
+	// Forces collection of any existing garbage cycles.
+	// Returns number of collected cycles.
+
+	_, err := funcParamValidator.NewValidator("gc_collect_cycles").Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	return values.NewInt(int64(context.Interpreter.CollectCycles())), nil
+}
+
+// -------------------------------------- gc_disable -------------------------------------- MARK: gc_disable
+
+func nativeFn_gc_disable(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.gc-disable.php
+
+	// gc_disable(): void
+
+	// Deactivates the circular reference collector, setting zend.enable_gc to 0.
+
+	_, err := funcParamValidator.NewValidator("gc_disable").Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	return values.NewVoid(), context.Interpreter.GetIni().Set("zend.enable_gc", "0", ini.INI_USER)
+}
+
+// -------------------------------------- gc_enable -------------------------------------- MARK: gc_enable
+
+func nativeFn_gc_enable(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.gc-enable.php
+
+	// gc_enable(): void
+
+	// Activates the circular reference collector, setting zend.enable_gc to 1.
+
+	_, err := funcParamValidator.NewValidator("gc_enable").Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	return values.NewVoid(), context.Interpreter.GetIni().Set("zend.enable_gc", "1", ini.INI_USER)
+}
+
+// -------------------------------------- gc_enabled -------------------------------------- MARK: gc_enabled
+
+func nativeFn_gc_enabled(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.gc-enabled.php
+
+	// gc_enabled(): bool
+
+	// Returns status of the circular reference collector.
+
+	_, err := funcParamValidator.NewValidator("gc_enabled").Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	return values.NewBool(context.Interpreter.GetIni().GetBool("zend.enable_gc")), nil
+}
+
+// -------------------------------------- gc_status -------------------------------------- MARK: gc_status
+
+func nativeFn_gc_status(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.gc-status.php
+
+	// gc_status(): array
+
+	// Gets information about the garbage collector.
+
+	_, err := funcParamValidator.NewValidator("gc_status").Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	status := context.Interpreter.GetGarbageCollector().GetStatus()
+	result := values.NewArray()
+	result.SetElement(values.NewStr("running"), values.NewBool(status.Running))
+	// The collector is never protected and its root buffer grows on demand
+	result.SetElement(values.NewStr("protected"), values.NewBool(false))
+	result.SetElement(values.NewStr("full"), values.NewBool(false))
+	result.SetElement(values.NewStr("runs"), values.NewInt(status.Runs))
+	result.SetElement(values.NewStr("collected"), values.NewInt(status.Collected))
+	result.SetElement(values.NewStr("threshold"), values.NewInt(status.Threshold))
+	result.SetElement(values.NewStr("buffer_size"), values.NewInt(status.BufferSize))
+	result.SetElement(values.NewStr("roots"), values.NewInt(status.Roots))
+	result.SetElement(values.NewStr("application_time"), values.NewFloat(status.ApplicationTime))
+	result.SetElement(values.NewStr("collector_time"), values.NewFloat(status.CollectorTime))
+	result.SetElement(values.NewStr("destructor_time"), values.NewFloat(status.DestructorTime))
+	result.SetElement(values.NewStr("free_time"), values.NewFloat(status.FreeTime))
+	return result, nil
+}
+
 // -------------------------------------- getenv -------------------------------------- MARK: getenv
 
 func nativeFn_getenv(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
@@ -85,7 +191,7 @@ func nativeFn_ini_set(args []values.RuntimeValue, context runtime.Context) (valu
 		return values.NewVoid(), err
 	}
 
-	value, err := variableHandling.StrVal(args[1])
+	value, err := variableHandling.StrVal(args[1], context.Interpreter.GetIni().GetInt("precision"))
 	if err != nil {
 		return values.NewVoid(), err
 	}
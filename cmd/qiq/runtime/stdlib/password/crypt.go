@@ -0,0 +1,539 @@
+package password
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"hash"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/blowfish"
+)
+
+// Alphabet of the base64 variant used by crypt (in a different order than the standard base64 alphabet)
+const cryptAlphabet = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// Hash the password with the algorithm given by the salt (see crypt).
+// Returns false if the salt is invalid or the algorithm is not supported.
+// Spec: https://www.php.net/manual/en/function.crypt.php
+func Crypt(password string, salt string) (string, bool) {
+	switch {
+	case strings.HasPrefix(salt, "$1$"):
+		return md5Crypt(password, salt), true
+	case strings.HasPrefix(salt, "$2"):
+		return bcrypt(password, salt)
+	case strings.HasPrefix(salt, "$5$"):
+		return shaCrypt(password, salt, "$5$", sha256.New, sha256Order)
+	case strings.HasPrefix(salt, "$6$"):
+		return shaCrypt(password, salt, "$6$", sha512.New, sha512Order)
+	case strings.HasPrefix(salt, "_"):
+		return extendedDesCrypt(password, salt)
+	case len(salt) >= 2 && strings.IndexByte(cryptAlphabet, salt[0]) >= 0 && strings.IndexByte(cryptAlphabet, salt[1]) >= 0:
+		return desCrypt(password, salt), true
+	default:
+		return "", false
+	}
+}
+
+// Encode the 24 bit value as n characters of the crypt alphabet (least significant bits first)
+func encode24Bit(output *strings.Builder, value uint32, n int) {
+	for range n {
+		output.WriteByte(cryptAlphabet[value&0x3f])
+		value >>= 6
+	}
+}
+
+// -------------------------------------- MD5 -------------------------------------- MARK: MD5
+
+// Spec: https://www.usenix.org/legacy/publications/library/proceedings/usenix99/full_papers/provos/provos_html/node10.html
+func md5Crypt(password string, salt string) string {
+	salt = salt[3:]
+	if index := strings.IndexByte(salt, '$'); index >= 0 {
+		salt = salt[:index]
+	}
+	if len(salt) > 8 {
+		salt = salt[:8]
+	}
+
+	alternate := md5.Sum([]byte(password + salt + password))
+	context := md5.New()
+	context.Write([]byte(password + "$1$" + salt))
+	for length := len(password); length > 0; length -= 16 {
+		context.Write(alternate[:min(length, 16)])
+	}
+	for length := len(password); length > 0; length >>= 1 {
+		if length&1 != 0 {
+			context.Write([]byte{0})
+		} else {
+			context.Write([]byte{password[0]})
+		}
+	}
+	final := context.Sum(nil)
+
+	// Slow down brute force attacks
+	for i := range 1000 {
+		context := md5.New()
+		if i&1 != 0 {
+			context.Write([]byte(password))
+		} else {
+			context.Write(final)
+		}
+		if i%3 != 0 {
+			context.Write([]byte(salt))
+		}
+		if i%7 != 0 {
+			context.Write([]byte(password))
+		}
+		if i&1 != 0 {
+			context.Write(final)
+		} else {
+			context.Write([]byte(password))
+		}
+		final = context.Sum(nil)
+	}
+
+	var output strings.Builder
+	output.WriteString("$1$" + salt + "$")
+	for _, indices := range [][3]int{{0, 6, 12}, {1, 7, 13}, {2, 8, 14}, {3, 9, 15}, {4, 10, 5}} {
+		encode24Bit(&output, uint32(final[indices[0]])<<16|uint32(final[indices[1]])<<8|uint32(final[indices[2]]), 4)
+	}
+	encode24Bit(&output, uint32(final[11]), 2)
+	return output.String()
+}
+
+// -------------------------------------- SHA -------------------------------------- MARK: SHA
+
+const (
+	shaRoundsDefault = 5000
+	shaRoundsMin     = 1000
+	shaRoundsMax     = 999999999
+)
+
+// Order in which the bytes of the digest are encoded (groups of three bytes)
+var (
+	sha256Order = []int{
+		0, 10, 20, 21, 1, 11, 12, 22, 2, 3, 13, 23, 24, 4, 14, 15, 25, 5, 6, 16, 26, 27, 7, 17, 18, 28, 8, 9, 19, 29,
+		-1, 31, 30,
+	}
+	sha512Order = []int{
+		0, 21, 42, 22, 43, 1, 44, 2, 23, 3, 24, 45, 25, 46, 4, 47, 5, 26, 6, 27, 48, 28, 49, 7, 50, 8, 29, 9, 30, 51,
+		31, 52, 10, 53, 11, 32, 12, 33, 54, 34, 55, 13, 56, 14, 35, 15, 36, 57, 37, 58, 16, 59, 17, 38, 18, 39, 60,
+		40, 61, 19, 62, 20, 41, -1, -1, 63,
+	}
+)
+
+// Repeat the digest until the length is reached
+func repeatDigest(digest []byte, length int) []byte {
+	result := make([]byte, 0, length)
+	for len(result)+len(digest) <= length {
+		result = append(result, digest...)
+	}
+	return append(result, digest[:length-len(result)]...)
+}
+
+// Spec: https://www.akkadia.org/drepper/SHA-crypt.txt
+func shaCrypt(password string, salt string, prefix string, newHash func() hash.Hash, order []int) (string, bool) {
+	salt = salt[len(prefix):]
+	rounds, customRounds := shaRoundsDefault, false
+	if strings.HasPrefix(salt, "rounds=") {
+		if end := strings.IndexByte(salt, '$'); end >= 0 {
+			if value, err := strconv.ParseUint(salt[len("rounds="):end], 10, 64); err == nil {
+				if value < shaRoundsMin || value > shaRoundsMax {
+					return "", false
+				}
+				rounds, customRounds, salt = int(value), true, salt[end+1:]
+			}
+		}
+	}
+	if index := strings.IndexByte(salt, '$'); index >= 0 {
+		salt = salt[:index]
+	}
+	if len(salt) > 16 {
+		salt = salt[:16]
+	}
+
+	sum := func(parts ...[]byte) []byte {
+		context := newHash()
+		for _, part := range parts {
+			context.Write(part)
+		}
+		return context.Sum(nil)
+	}
+
+	passwordBytes, saltBytes := []byte(password), []byte(salt)
+	alternate := sum(passwordBytes, saltBytes, passwordBytes)
+	context := newHash()
+	context.Write(passwordBytes)
+	context.Write(saltBytes)
+	context.Write(repeatDigest(alternate, len(password)))
+	for length := len(password); length > 0; length >>= 1 {
+		if length&1 != 0 {
+			context.Write(alternate)
+		} else {
+			context.Write(passwordBytes)
+		}
+	}
+	final := context.Sum(nil)
+
+	context = newHash()
+	for range len(password) {
+		context.Write(passwordBytes)
+	}
+	p := repeatDigest(context.Sum(nil), len(password))
+
+	context = newHash()
+	for range 16 + int(final[0]) {
+		context.Write(saltBytes)
+	}
+	s := repeatDigest(context.Sum(nil), len(salt))
+
+	for i := range rounds {
+		context := newHash()
+		if i&1 != 0 {
+			context.Write(p)
+		} else {
+			context.Write(final)
+		}
+		if i%3 != 0 {
+			context.Write(s)
+		}
+		if i%7 != 0 {
+			context.Write(p)
+		}
+		if i&1 != 0 {
+			context.Write(final)
+		} else {
+			context.Write(p)
+		}
+		final = context.Sum(nil)
+	}
+
+	var output strings.Builder
+	output.WriteString(prefix)
+	if customRounds {
+		output.WriteString("rounds=" + strconv.Itoa(rounds) + "$")
+	}
+	output.WriteString(salt + "$")
+	for i := 0; i < len(order); i += 3 {
+		value, n := uint32(0), 4
+		for j, index := range order[i : i+3] {
+			if index < 0 {
+				// Incomplete last group
+				n--
+				continue
+			}
+			value |= uint32(final[index]) << (16 - 8*j)
+		}
+		encode24Bit(&output, value, n)
+	}
+	return output.String(), true
+}
+
+// -------------------------------------- Blowfish -------------------------------------- MARK: Blowfish
+
+const (
+	bcryptMinCost = 4
+	bcryptMaxCost = 31
+	// Number of characters of the encoded salt
+	bcryptSaltLen = 22
+)
+
+// bcrypt uses a base64 variant with its own alphabet and without padding
+var bcryptEncoding = base64.NewEncoding("./ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789").WithPadding(base64.NoPadding)
+
+// The salt has the format "$2y$<two digit cost>$<22 characters salt>".
+// The prefixes $2a$ and $2b$ are treated like $2y$.
+// $2x$ emulates the sign extension bug of old implementations (only relevant for passwords with non-ASCII characters).
+// Spec: https://www.openwall.com/crypt/
+func bcrypt(password string, salt string) (string, bool) {
+	if len(salt) < 7+bcryptSaltLen || !strings.Contains("abxy", salt[2:3]) || salt[3] != '$' || salt[6] != '$' {
+		return "", false
+	}
+	cost, err := strconv.Atoi(salt[4:6])
+	if err != nil || cost < bcryptMinCost || cost > bcryptMaxCost {
+		return "", false
+	}
+	saltBytes, err := bcryptEncoding.DecodeString(salt[7 : 7+bcryptSaltLen])
+	if err != nil {
+		return "", false
+	}
+
+	return salt[:7] + bcryptEncoding.EncodeToString(saltBytes) + bcryptHash(password, saltBytes, cost, salt[2] == 'x'), true
+}
+
+// Calculate the encoded bcrypt hash of the password with the 16 byte salt
+func bcryptHash(password string, salt []byte, cost int, signExtensionBug bool) string {
+	key := append([]byte(password), 0)
+	if signExtensionBug {
+		key = bcryptSignExtendedKey(key)
+	}
+	cipher, _ := blowfish.NewSaltedCipher(key, salt)
+	for range uint64(1) << cost {
+		blowfish.ExpandKey(key, cipher)
+		blowfish.ExpandKey(salt, cipher)
+	}
+
+	text := []byte("OrpheanBeholderScryDoubt")
+	for i := 0; i < len(text); i += blowfish.BlockSize {
+		for range 64 {
+			cipher.Encrypt(text[i:i+blowfish.BlockSize], text[i:i+blowfish.BlockSize])
+		}
+	}
+	// Only 23 of the 24 bytes are part of the hash
+	return bcryptEncoding.EncodeToString(text[:23])
+}
+
+// Old implementations of bcrypt sign extended the characters of the key when combining them to the 18 words
+// that are XORed with the P-array. Return a key of exactly 18 words that contains the sign extended words.
+func bcryptSignExtendedKey(key []byte) []byte {
+	result := make([]byte, 0, 72)
+	offset := 0
+	for range 18 {
+		var word uint32
+		for range 4 {
+			word = word<<8 | uint32(int32(int8(key[offset])))
+			offset = (offset + 1) % len(key)
+		}
+		result = binary.BigEndian.AppendUint32(result, word)
+	}
+	return result
+}
+
+// -------------------------------------- DES -------------------------------------- MARK: DES
+
+// Tables of the Data Encryption Standard (bit positions starting at 1 for the most significant bit)
+// Spec: https://csrc.nist.gov/files/pubs/fips/46-3/final/docs/fips46-3.pdf
+var (
+	desInitialPermutation = []byte{
+		58, 50, 42, 34, 26, 18, 10, 2, 60, 52, 44, 36, 28, 20, 12, 4,
+		62, 54, 46, 38, 30, 22, 14, 6, 64, 56, 48, 40, 32, 24, 16, 8,
+		57, 49, 41, 33, 25, 17, 9, 1, 59, 51, 43, 35, 27, 19, 11, 3,
+		61, 53, 45, 37, 29, 21, 13, 5, 63, 55, 47, 39, 31, 23, 15, 7,
+	}
+	desFinalPermutation = func() []byte {
+		table := make([]byte, len(desInitialPermutation))
+		for i, position := range desInitialPermutation {
+			table[position-1] = byte(i + 1)
+		}
+		return table
+	}()
+	desPermutation = []byte{
+		16, 7, 20, 21, 29, 12, 28, 17, 1, 15, 23, 26, 5, 18, 31, 10,
+		2, 8, 24, 14, 32, 27, 3, 9, 19, 13, 30, 6, 22, 11, 4, 25,
+	}
+	desPermutedChoice1 = []byte{
+		57, 49, 41, 33, 25, 17, 9, 1, 58, 50, 42, 34, 26, 18,
+		10, 2, 59, 51, 43, 35, 27, 19, 11, 3, 60, 52, 44, 36,
+		63, 55, 47, 39, 31, 23, 15, 7, 62, 54, 46, 38, 30, 22,
+		14, 6, 61, 53, 45, 37, 29, 21, 13, 5, 28, 20, 12, 4,
+	}
+	desPermutedChoice2 = []byte{
+		14, 17, 11, 24, 1, 5, 3, 28, 15, 6, 21, 10,
+		23, 19, 12, 4, 26, 8, 16, 7, 27, 20, 13, 2,
+		41, 52, 31, 37, 47, 55, 30, 40, 51, 45, 33, 48,
+		44, 49, 39, 56, 34, 53, 46, 42, 50, 36, 29, 32,
+	}
+	desKeyShifts = []int{1, 1, 2, 2, 2, 2, 2, 2, 1, 2, 2, 2, 2, 2, 2, 1}
+	desSBoxes    = [8][64]byte{
+		{
+			14, 4, 13, 1, 2, 15, 11, 8, 3, 10, 6, 12, 5, 9, 0, 7,
+			0, 15, 7, 4, 14, 2, 13, 1, 10, 6, 12, 11, 9, 5, 3, 8,
+			4, 1, 14, 8, 13, 6, 2, 11, 15, 12, 9, 7, 3, 10, 5, 0,
+			15, 12, 8, 2, 4, 9, 1, 7, 5, 11, 3, 14, 10, 0, 6, 13,
+		},
+		{
+			15, 1, 8, 14, 6, 11, 3, 4, 9, 7, 2, 13, 12, 0, 5, 10,
+			3, 13, 4, 7, 15, 2, 8, 14, 12, 0, 1, 10, 6, 9, 11, 5,
+			0, 14, 7, 11, 10, 4, 13, 1, 5, 8, 12, 6, 9, 3, 2, 15,
+			13, 8, 10, 1, 3, 15, 4, 2, 11, 6, 7, 12, 0, 5, 14, 9,
+		},
+		{
+			10, 0, 9, 14, 6, 3, 15, 5, 1, 13, 12, 7, 11, 4, 2, 8,
+			13, 7, 0, 9, 3, 4, 6, 10, 2, 8, 5, 14, 12, 11, 15, 1,
+			13, 6, 4, 9, 8, 15, 3, 0, 11, 1, 2, 12, 5, 10, 14, 7,
+			1, 10, 13, 0, 6, 9, 8, 7, 4, 15, 14, 3, 11, 5, 2, 12,
+		},
+		{
+			7, 13, 14, 3, 0, 6, 9, 10, 1, 2, 8, 5, 11, 12, 4, 15,
+			13, 8, 11, 5, 6, 15, 0, 3, 4, 7, 2, 12, 1, 10, 14, 9,
+			10, 6, 9, 0, 12, 11, 7, 13, 15, 1, 3, 14, 5, 2, 8, 4,
+			3, 15, 0, 6, 10, 1, 13, 8, 9, 4, 5, 11, 12, 7, 2, 14,
+		},
+		{
+			2, 12, 4, 1, 7, 10, 11, 6, 8, 5, 3, 15, 13, 0, 14, 9,
+			14, 11, 2, 12, 4, 7, 13, 1, 5, 0, 15, 10, 3, 9, 8, 6,
+			4, 2, 1, 11, 10, 13, 7, 8, 15, 9, 12, 5, 6, 3, 0, 14,
+			11, 8, 12, 7, 1, 14, 2, 13, 6, 15, 0, 9, 10, 4, 5, 3,
+		},
+		{
+			12, 1, 10, 15, 9, 2, 6, 8, 0, 13, 3, 4, 14, 7, 5, 11,
+			10, 15, 4, 2, 7, 12, 9, 5, 6, 1, 13, 14, 0, 11, 3, 8,
+			9, 14, 15, 5, 2, 8, 12, 3, 7, 0, 4, 10, 1, 13, 11, 6,
+			4, 3, 2, 12, 9, 5, 15, 10, 11, 14, 1, 7, 6, 0, 8, 13,
+		},
+		{
+			4, 11, 2, 14, 15, 0, 8, 13, 3, 12, 9, 7, 5, 10, 6, 1,
+			13, 0, 11, 7, 4, 9, 1, 10, 14, 3, 5, 12, 2, 15, 8, 6,
+			1, 4, 11, 13, 12, 3, 7, 14, 10, 15, 6, 8, 0, 5, 9, 2,
+			6, 11, 13, 8, 1, 4, 10, 7, 9, 5, 0, 15, 14, 2, 3, 12,
+		},
+		{
+			13, 2, 8, 4, 6, 15, 11, 1, 10, 9, 3, 14, 5, 0, 12, 7,
+			1, 15, 13, 8, 10, 3, 7, 4, 12, 5, 6, 11, 0, 14, 9, 2,
+			7, 11, 4, 1, 9, 12, 14, 2, 0, 6, 10, 13, 15, 3, 5, 8,
+			2, 1, 14, 7, 4, 10, 8, 13, 15, 12, 9, 0, 3, 5, 6, 11,
+		},
+	}
+	// Output of the S-boxes for each 6 bit input with the permutation P already applied
+	desSPBoxes = func() [8][64]uint32 {
+		var table [8][64]uint32
+		for box := range desSBoxes {
+			for input := range 64 {
+				// The outer bits select the row, the inner bits the column
+				index := input&0x20 | (input&1)<<4 | (input>>1)&0xf
+				output := uint64(desSBoxes[box][index]) << (28 - 4*box)
+				table[box][input] = uint32(desPermute(output, 32, desPermutation))
+			}
+		}
+		return table
+	}()
+)
+
+// Permute the bits of the input with the given number of bits
+func desPermute(input uint64, bits int, table []byte) uint64 {
+	var result uint64
+	for _, position := range table {
+		result = result<<1 | (input>>(bits-int(position)))&1
+	}
+	return result
+}
+
+// Calculate the 16 subkeys of 48 bits, each split into the left and the right 24 bits
+func desKeySchedule(key [8]byte) [16][2]uint32 {
+	permuted := desPermute(binary.BigEndian.Uint64(key[:]), 64, desPermutedChoice1)
+	c, d := uint32(permuted>>28), uint32(permuted&0xfffffff)
+	var subkeys [16][2]uint32
+	for round, shift := range desKeyShifts {
+		c = (c<<shift | c>>(28-shift)) & 0xfffffff
+		d = (d<<shift | d>>(28-shift)) & 0xfffffff
+		subkey := desPermute(uint64(c)<<28|uint64(d), 56, desPermutedChoice2)
+		subkeys[round] = [2]uint32{uint32(subkey >> 24), uint32(subkey & 0xffffff)}
+	}
+	return subkeys
+}
+
+// Encrypt the block count times. Each set bit of the 24 bit salt swaps two bits of the expansion E
+// (bit 0 of the salt swaps the first bits of the left and the right 24 bits).
+func desEncrypt(subkeys [16][2]uint32, block uint64, salt uint32, count int) uint64 {
+	var saltBits uint32
+	for i := range 24 {
+		if salt&(1<<i) != 0 {
+			saltBits |= 0x800000 >> i
+		}
+	}
+
+	block = desPermute(block, 64, desInitialPermutation)
+	left, right := uint32(block>>32), uint32(block)
+	for range count {
+		for _, subkey := range subkeys {
+			// Expansion E of the right half to 48 bits
+			expandedLeft := (right&0x00000001)<<23 | (right&0xf8000000)>>9 | (right&0x1f800000)>>11 |
+				(right&0x01f80000)>>13 | (right&0x001f8000)>>15
+			expandedRight := (right&0x0001f800)<<7 | (right&0x00001f80)<<5 | (right&0x000001f8)<<3 |
+				(right&0x0000001f)<<1 | (right&0x80000000)>>31
+			swap := (expandedLeft ^ expandedRight) & saltBits
+			expandedLeft ^= swap ^ subkey[0]
+			expandedRight ^= swap ^ subkey[1]
+
+			f := desSPBoxes[0][expandedLeft>>18] | desSPBoxes[1][(expandedLeft>>12)&0x3f] |
+				desSPBoxes[2][(expandedLeft>>6)&0x3f] | desSPBoxes[3][expandedLeft&0x3f] |
+				desSPBoxes[4][expandedRight>>18] | desSPBoxes[5][(expandedRight>>12)&0x3f] |
+				desSPBoxes[6][(expandedRight>>6)&0x3f] | desSPBoxes[7][expandedRight&0x3f]
+			left, right = right, left^f
+		}
+		// The halves are not swapped after the last round
+		left, right = right, left
+	}
+	return desPermute(uint64(left)<<32|uint64(right), 64, desFinalPermutation)
+}
+
+// Encode the 64 bit result of the encryption as 11 characters (most significant bits first)
+func desEncode(output *strings.Builder, value uint64) {
+	for shift := 58; shift > 0; shift -= 6 {
+		output.WriteByte(cryptAlphabet[(value>>shift)&0x3f])
+	}
+	// The last character contains two bits of padding
+	output.WriteByte(cryptAlphabet[(value<<2)&0x3f])
+}
+
+// Decode characters of the crypt alphabet (least significant characters first)
+func desDecode(characters string) (uint32, bool) {
+	var value uint32
+	for i := range len(characters) {
+		index := strings.IndexByte(cryptAlphabet, characters[i])
+		if index < 0 {
+			return 0, false
+		}
+		value |= uint32(index) << (6 * i)
+	}
+	return value, true
+}
+
+// The characters of the key are shifted by one bit, as the least significant bit of each byte is the parity bit
+func desKey(password string) [8]byte {
+	var key [8]byte
+	for i := range min(len(password), len(key)) {
+		key[i] = password[i] << 1
+	}
+	return key
+}
+
+// Traditional DES-based crypt with a salt of two characters. Only the first 8 characters of the password are used.
+// Spec: https://man.freebsd.org/cgi/man.cgi?query=crypt&sektion=3
+func desCrypt(password string, salt string) string {
+	// The password is a C string
+	password, _, _ = strings.Cut(password, "\x00")
+	saltValue, _ := desDecode(salt[:2])
+
+	var output strings.Builder
+	output.WriteString(salt[:2])
+	desEncode(&output, desEncrypt(desKeySchedule(desKey(password)), 0, saltValue, 25))
+	return output.String()
+}
+
+// Extended DES-based crypt of BSDi with the salt "_<4 characters count><4 characters salt>".
+// All characters of the password are used.
+// Spec: https://man.freebsd.org/cgi/man.cgi?query=crypt&sektion=3
+func extendedDesCrypt(password string, salt string) (string, bool) {
+	if len(salt) < 9 {
+		return "", false
+	}
+	count, ok := desDecode(salt[1:5])
+	if !ok || count == 0 {
+		return "", false
+	}
+	saltValue, ok := desDecode(salt[5:9])
+	if !ok {
+		return "", false
+	}
+
+	// The password is a C string
+	password, _, _ = strings.Cut(password, "\x00")
+	key := desKey(password)
+	// Fold the remaining characters into the key by encrypting the key with itself
+	for password = password[min(len(password), 8):]; password != ""; password = password[min(len(password), 8):] {
+		encrypted := desEncrypt(desKeySchedule(key), binary.BigEndian.Uint64(key[:]), 0, 1)
+		binary.BigEndian.PutUint64(key[:], encrypted)
+		for i := range min(len(password), len(key)) {
+			key[i] ^= password[i] << 1
+		}
+	}
+
+	var output strings.Builder
+	output.WriteString(salt[:9])
+	desEncode(&output, desEncrypt(desKeySchedule(key), 0, saltValue, int(count)))
+	return output.String(), true
+}
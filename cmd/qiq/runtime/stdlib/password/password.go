@@ -0,0 +1,345 @@
+package password
+
+import (
+	"QIQ/cmd/qiq/phpError"
+	"QIQ/cmd/qiq/runtime"
+	"QIQ/cmd/qiq/runtime/funcParamValidator"
+	"QIQ/cmd/qiq/runtime/stdlib/variableHandling"
+	"QIQ/cmd/qiq/runtime/values"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	PASSWORD_BCRYPT                     = "2y"
+	PASSWORD_ARGON2I                    = "argon2i"
+	PASSWORD_ARGON2ID                   = "argon2id"
+	PASSWORD_DEFAULT                    = PASSWORD_BCRYPT
+	PASSWORD_BCRYPT_DEFAULT_COST        = 10
+	PASSWORD_ARGON2_DEFAULT_MEMORY_COST = 65536
+	PASSWORD_ARGON2_DEFAULT_TIME_COST   = 4
+	PASSWORD_ARGON2_DEFAULT_THREADS     = 1
+)
+
+func Register(environment runtime.Environment) {
+	// Category: Password Hashing Functions
+	environment.AddNativeFunction("password_algos", nativeFn_password_algos)
+	environment.AddNativeFunction("password_get_info", nativeFn_password_get_info)
+	environment.AddNativeFunction("password_hash", nativeFn_password_hash)
+	environment.AddNativeFunction("password_needs_rehash", nativeFn_password_needs_rehash)
+	environment.AddNativeFunction("password_verify", nativeFn_password_verify)
+
+	// Const Category: Password Hashing Constants
+	// Spec: https://www.php.net/manual/en/password.constants.php
+	environment.AddPredefinedConstant("PASSWORD_DEFAULT", values.NewStr(PASSWORD_DEFAULT))
+	environment.AddPredefinedConstant("PASSWORD_BCRYPT", values.NewStr(PASSWORD_BCRYPT))
+	environment.AddPredefinedConstant("PASSWORD_BCRYPT_DEFAULT_COST", values.NewInt(PASSWORD_BCRYPT_DEFAULT_COST))
+	environment.AddPredefinedConstant("PASSWORD_ARGON2I", values.NewStr(PASSWORD_ARGON2I))
+	environment.AddPredefinedConstant("PASSWORD_ARGON2ID", values.NewStr(PASSWORD_ARGON2ID))
+	environment.AddPredefinedConstant("PASSWORD_ARGON2_DEFAULT_MEMORY_COST", values.NewInt(PASSWORD_ARGON2_DEFAULT_MEMORY_COST))
+	environment.AddPredefinedConstant("PASSWORD_ARGON2_DEFAULT_TIME_COST", values.NewInt(PASSWORD_ARGON2_DEFAULT_TIME_COST))
+	environment.AddPredefinedConstant("PASSWORD_ARGON2_DEFAULT_THREADS", values.NewInt(PASSWORD_ARGON2_DEFAULT_THREADS))
+	environment.AddPredefinedConstant("PASSWORD_ARGON2_PROVIDER", values.NewStr("standard"))
+}
+
+// -------------------------------------- Helpers -------------------------------------- MARK: Helpers
+
+// Get the algorithm for the parameter $algo.
+// Besides the PASSWORD_* constants, the legacy integer identifiers of PHP 7.3 are accepted.
+func getAlgorithmArg(value values.RuntimeValue) (string, bool) {
+	switch value.GetType() {
+	case values.NullValue:
+		return PASSWORD_DEFAULT, true
+	case values.IntValue:
+		switch value.(*values.Int).Value {
+		case 0, 1:
+			return PASSWORD_BCRYPT, true
+		case 2:
+			return PASSWORD_ARGON2I, true
+		case 3:
+			return PASSWORD_ARGON2ID, true
+		}
+	case values.StrValue:
+		switch algorithm := value.(*values.Str).Value; algorithm {
+		case PASSWORD_BCRYPT, PASSWORD_ARGON2I, PASSWORD_ARGON2ID:
+			return algorithm, true
+		}
+	}
+	return "", false
+}
+
+// Get the algorithm of the hash from the identifier between the first two "$". Returns "" if the algorithm is unknown.
+func identifyAlgorithm(hash string) string {
+	if len(hash) < 3 || hash[0] != '$' {
+		return ""
+	}
+	identifier, _, _ := strings.Cut(hash[1:], "$")
+	switch identifier {
+	case PASSWORD_BCRYPT:
+		if len(hash) == 60 {
+			return identifier
+		}
+	case PASSWORD_ARGON2I, PASSWORD_ARGON2ID:
+		return identifier
+	}
+	return ""
+}
+
+// Get the integer option or the default value if it is not set
+func getOption(options *values.Array, name string, defaultValue int64) (int64, phpError.Error) {
+	value, found := options.GetElement(values.NewStr(name))
+	if !found {
+		return defaultValue, nil
+	}
+	return variableHandling.IntVal(value, true)
+}
+
+type argon2Params struct {
+	memoryCost int64
+	timeCost   int64
+	threads    int64
+}
+
+func getArgon2Options(options *values.Array) (argon2Params, phpError.Error) {
+	params := argon2Params{}
+	var err phpError.Error
+	if params.memoryCost, err = getOption(options, "memory_cost", PASSWORD_ARGON2_DEFAULT_MEMORY_COST); err != nil {
+		return params, err
+	}
+	if params.timeCost, err = getOption(options, "time_cost", PASSWORD_ARGON2_DEFAULT_TIME_COST); err != nil {
+		return params, err
+	}
+	if params.threads, err = getOption(options, "threads", PASSWORD_ARGON2_DEFAULT_THREADS); err != nil {
+		return params, err
+	}
+	return params, nil
+}
+
+// Parse a hash in the format "$argon2id$v=19$m=65536,t=4,p=1$<salt>$<hash>".
+// Only version 19 (0x13) is supported.
+func parseArgon2Hash(hash string) (params argon2Params, salt []byte, key []byte, ok bool) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[2] != "v=19" {
+		return params, nil, nil, false
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memoryCost, &params.timeCost, &params.threads); err != nil {
+		return params, nil, nil, false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, false
+	}
+	key, err = base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return params, nil, nil, false
+	}
+	return params, salt, key, true
+}
+
+func argon2Key(algorithm string, password string, salt []byte, params argon2Params, keyLen int) []byte {
+	if algorithm == PASSWORD_ARGON2I {
+		return argon2.Key([]byte(password), salt, uint32(params.timeCost), uint32(params.memoryCost), uint8(params.threads), uint32(keyLen))
+	}
+	return argon2.IDKey([]byte(password), salt, uint32(params.timeCost), uint32(params.memoryCost), uint8(params.threads), uint32(keyLen))
+}
+
+// Get the cost of a bcrypt hash in the format "$2y$<cost>$..."
+func getBcryptCost(hash string) int64 {
+	cost, _ := strconv.ParseInt(hash[4:6], 10, 64)
+	return cost
+}
+
+// -------------------------------------- password_algos -------------------------------------- MARK: password_algos
+
+func nativeFn_password_algos(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.password-algos.php
+
+	_, err := funcParamValidator.NewValidator("password_algos").Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	result := values.NewArray()
+	for _, algorithm := range []string{PASSWORD_BCRYPT, PASSWORD_ARGON2I, PASSWORD_ARGON2ID} {
+		result.SetElement(nil, values.NewStr(algorithm))
+	}
+	return result, nil
+}
+
+// -------------------------------------- password_get_info -------------------------------------- MARK: password_get_info
+
+func nativeFn_password_get_info(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.password-get-info.php
+
+	args, err := funcParamValidator.NewValidator("password_get_info").AddParam("$hash", []string{"string"}, nil).Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	hash := args[0].(*values.Str).Value
+	result := values.NewArray()
+	options := values.NewArray()
+	algorithm := identifyAlgorithm(hash)
+	switch algorithm {
+	case PASSWORD_BCRYPT:
+		options.SetElement(values.NewStr("cost"), values.NewInt(getBcryptCost(hash)))
+		result.SetElement(values.NewStr("algo"), values.NewStr(algorithm))
+		result.SetElement(values.NewStr("algoName"), values.NewStr("bcrypt"))
+	case PASSWORD_ARGON2I, PASSWORD_ARGON2ID:
+		if params, _, _, ok := parseArgon2Hash(hash); ok {
+			options.SetElement(values.NewStr("memory_cost"), values.NewInt(params.memoryCost))
+			options.SetElement(values.NewStr("time_cost"), values.NewInt(params.timeCost))
+			options.SetElement(values.NewStr("threads"), values.NewInt(params.threads))
+		}
+		result.SetElement(values.NewStr("algo"), values.NewStr(algorithm))
+		result.SetElement(values.NewStr("algoName"), values.NewStr(algorithm))
+	default:
+		result.SetElement(values.NewStr("algo"), values.NewNull())
+		result.SetElement(values.NewStr("algoName"), values.NewStr("unknown"))
+	}
+	result.SetElement(values.NewStr("options"), options)
+	return result, nil
+}
+
+// -------------------------------------- password_hash -------------------------------------- MARK: password_hash
+
+func nativeFn_password_hash(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.password-hash.php
+
+	args, err := funcParamValidator.NewValidator("password_hash").
+		AddParam("$password", []string{"string"}, nil).
+		AddParam("$algo", []string{"string", "int", "null"}, nil).
+		AddParam("$options", []string{"array"}, values.NewArray()).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	password := args[0].(*values.Str).Value
+	options := args[2].(*values.Array)
+	algorithm, found := getAlgorithmArg(args[1])
+	if !found {
+		return values.NewVoid(), phpError.NewError(
+			"Uncaught ValueError: password_hash(): Argument #2 ($algo) must be a valid password hashing algorithm",
+		)
+	}
+
+	salt := make([]byte, 16)
+	if _, goErr := rand.Read(salt); goErr != nil {
+		return values.NewVoid(), phpError.NewError("Uncaught Error: Unable to generate salt")
+	}
+
+	if algorithm == PASSWORD_BCRYPT {
+		cost, err := getOption(options, "cost", PASSWORD_BCRYPT_DEFAULT_COST)
+		if err != nil {
+			return values.NewVoid(), err
+		}
+		if cost < bcryptMinCost || cost > bcryptMaxCost {
+			return values.NewVoid(), phpError.NewError("Uncaught ValueError: Invalid bcrypt cost parameter specified: %d", cost)
+		}
+		if options.Contains(values.NewStr("salt")) {
+			context.Interpreter.PrintError(phpError.NewWarning(
+				"password_hash(): The \"salt\" option has been ignored, since providing a custom salt is no longer supported in %s",
+				context.Stmt.GetPosString(),
+			))
+		}
+		return values.NewStr(fmt.Sprintf("$2y$%02d$%s%s",
+			cost, bcryptEncoding.EncodeToString(salt), bcryptHash(password, salt, int(cost), false),
+		)), nil
+	}
+
+	params, err := getArgon2Options(options)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+	if params.memoryCost < 8 || params.memoryCost > 0xFFFFFFFF {
+		return values.NewVoid(), phpError.NewError("Uncaught ValueError: Memory cost is outside of allowed memory range")
+	}
+	if params.timeCost < 1 || params.timeCost > 0xFFFFFFFF {
+		return values.NewVoid(), phpError.NewError("Uncaught ValueError: Time cost is outside of allowed time range")
+	}
+	if params.threads < 1 || params.threads > 255 {
+		return values.NewVoid(), phpError.NewError("Uncaught ValueError: Invalid number of threads")
+	}
+	return values.NewStr(fmt.Sprintf("$%s$v=19$m=%d,t=%d,p=%d$%s$%s",
+		algorithm, params.memoryCost, params.timeCost, params.threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(argon2Key(algorithm, password, salt, params, 32)),
+	)), nil
+}
+
+// -------------------------------------- password_needs_rehash -------------------------------------- MARK: password_needs_rehash
+
+func nativeFn_password_needs_rehash(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.password-needs-rehash.php
+
+	args, err := funcParamValidator.NewValidator("password_needs_rehash").
+		AddParam("$hash", []string{"string"}, nil).
+		AddParam("$algo", []string{"string", "int", "null"}, nil).
+		AddParam("$options", []string{"array"}, values.NewArray()).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	hash := args[0].(*values.Str).Value
+	options := args[2].(*values.Array)
+	algorithm, found := getAlgorithmArg(args[1])
+	if !found {
+		// Never prompt to rehash for an unknown algorithm
+		return values.NewBool(false), nil
+	}
+	if identifyAlgorithm(hash) != algorithm {
+		return values.NewBool(true), nil
+	}
+
+	if algorithm == PASSWORD_BCRYPT {
+		cost, err := getOption(options, "cost", PASSWORD_BCRYPT_DEFAULT_COST)
+		if err != nil {
+			return values.NewVoid(), err
+		}
+		return values.NewBool(getBcryptCost(hash) != cost), nil
+	}
+
+	newParams, err := getArgon2Options(options)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+	params, _, _, ok := parseArgon2Hash(hash)
+	return values.NewBool(!ok || params != newParams), nil
+}
+
+// -------------------------------------- password_verify -------------------------------------- MARK: password_verify
+
+func nativeFn_password_verify(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.password-verify.php
+
+	args, err := funcParamValidator.NewValidator("password_verify").
+		AddParam("$password", []string{"string"}, nil).
+		AddParam("$hash", []string{"string"}, nil).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	password := args[0].(*values.Str).Value
+	hash := args[1].(*values.Str).Value
+
+	if algorithm := identifyAlgorithm(hash); algorithm == PASSWORD_ARGON2I || algorithm == PASSWORD_ARGON2ID {
+		params, salt, key, ok := parseArgon2Hash(hash)
+		if !ok || params.timeCost < 1 || params.threads < 1 || params.threads > 255 || len(key) == 0 {
+			return values.NewBool(false), nil
+		}
+		return values.NewBool(subtle.ConstantTimeCompare(argon2Key(algorithm, password, salt, params, len(key)), key) == 1), nil
+	}
+
+	// All other hashes (e.g. bcrypt) are verified with crypt()
+	result, ok := Crypt(password, hash)
+	return values.NewBool(ok && subtle.ConstantTimeCompare([]byte(result), []byte(hash)) == 1), nil
+}
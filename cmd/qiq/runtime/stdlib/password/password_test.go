@@ -0,0 +1,54 @@
+package password
+
+import (
+	"testing"
+)
+
+// -------------------------------------- crypt -------------------------------------- MARK: crypt
+
+func TestCrypt(t *testing.T) {
+	doTest := func(t *testing.T, password string, salt string, expected string) {
+		actual, ok := Crypt(password, salt)
+		if expected == "" {
+			if ok {
+				t.Errorf("%q, %q - Expected failure, Got %q", password, salt, actual)
+			}
+		} else if !ok || actual != expected {
+			t.Errorf("%q, %q - Expected: %q, Got %q", password, salt, expected, actual)
+		}
+	}
+
+	// MD5
+	doTest(t, "rasmuslerdorf", "$1$rasmusle$", "$1$rasmusle$rISCgZzpwk3UhDidwXvin0")
+	doTest(t, "", "$1$", "$1$$qRPK7m23GJusamGpoGLby/")
+
+	// SHA-256 and SHA-512
+	doTest(t, "Hello world!", "$5$saltstring", "$5$saltstring$5B8vYYiY.CVt1RlTTf8KbXBH3hsxY/GNooZaBBGWEc5")
+	doTest(t, "Hello world!", "$5$rounds=10000$saltstringsaltstring", "$5$rounds=10000$saltstringsaltst$3xv.VbSHBb41AL9AvLeujZkZRBAwqFMz2.opqey6IcA")
+	doTest(t, "Hello world!", "$6$saltstring", "$6$saltstring$svn8UoSVapNtMuq1ukKS4tPQd8iKwSMHWjl/O817G3uBnIFNjnQJuesI68u4OTLiBFdcbYEdFCoEOfaS35inz1")
+	doTest(t, "Hello world!", "$6$rounds=10000$saltstringsaltstring", "$6$rounds=10000$saltstringsaltst$OW1/O6BYHV6BcXZu8QVeXbDWra3Oeqh0sbHbbMCVNSnCM/UrjmM0Dp8vOuZeHBy/YTBmSK6H9qs/y3RnOaw5v.")
+	doTest(t, "Hello world!", "$5$rounds=10$saltstring", "")
+
+	// Blowfish
+	doTest(t, "rasmuslerdorf", "$2y$07$usesomesillystringforsalt$", "$2y$07$usesomesillystringfore2uDLvp1Ii2e./U9C8sBjqp8I90dH6hi")
+	doTest(t, "U*U", "$2a$05$CCCCCCCCCCCCCCCCCCCCC.", "$2a$05$CCCCCCCCCCCCCCCCCCCCC.E5YPO9kmyuRGyh0XouQYb4YMJKvyOeW")
+	doTest(t, "", "$2a$05$CCCCCCCCCCCCCCCCCCCCC.", "$2a$05$CCCCCCCCCCCCCCCCCCCCC.7uG0VCzI2bS7j6ymqJi9CdcdxiRTWNy")
+	doTest(t, "rasmuslerdorf", "$2y$03$usesomesillystringforsalt$", "")
+	doTest(t, "rasmuslerdorf", "$2y$07$short$", "")
+	doTest(t, "\xa3", "$2a$05$/OK.fbVrR/bpIqNJ5ianF.", "$2a$05$/OK.fbVrR/bpIqNJ5ianF.Sa7shbm4.OzKpvFnX1pQLmQW96oUlCq")
+	doTest(t, "\xa3", "$2x$05$/OK.fbVrR/bpIqNJ5ianF.", "$2x$05$/OK.fbVrR/bpIqNJ5ianF.CE5elHaaO4EbggVDjb8P19RukzXSM3e")
+	doTest(t, "\xd1\x91", "$2x$05$6bNw2HLQYeqHYyBfLMsv/O", "$2x$05$6bNw2HLQYeqHYyBfLMsv/OiwqTymGIGzFsA4hOTWebfehXHNprcAS")
+
+	// Standard DES
+	doTest(t, "rasmuslerdorf", "rl", "rl.3StKT.4T8M")
+	doTest(t, "", "ab", "abmF1QH4PEr.E")
+	doTest(t, "abc\x00def", "ab", "abFZSxKKdq5s6")
+	doTest(t, "x", "r", "")
+	doTest(t, "x", "!!", "")
+
+	// Extended DES
+	doTest(t, "rasmuslerdorf", "_J9..rasm", "_J9..rasmBYk8r9AiWNc")
+	doTest(t, "x", "_J9.", "")
+	doTest(t, "x", "_....abcd", "")
+	doTest(t, "x", "_J9..ra!m", "")
+}
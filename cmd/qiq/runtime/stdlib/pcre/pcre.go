@@ -0,0 +1,952 @@
+package pcre
+
+import (
+	"QIQ/cmd/qiq/phpError"
+	"QIQ/cmd/qiq/runtime"
+	"QIQ/cmd/qiq/runtime/funcParamValidator"
+	"QIQ/cmd/qiq/runtime/regex"
+	"QIQ/cmd/qiq/runtime/stdlib/variableHandling"
+	"QIQ/cmd/qiq/runtime/values"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+func Register(environment runtime.Environment) {
+	// Category: PCRE Functions
+	environment.AddNativeFunction("preg_grep", nativeFn_preg_grep)
+	environment.AddNativeFunction("preg_last_error", nativeFn_preg_last_error)
+	environment.AddNativeFunction("preg_last_error_msg", nativeFn_preg_last_error_msg)
+	environment.AddNativeFunction("preg_match", nativeFn_preg_match, 2)
+	environment.AddNativeFunction("preg_match_all", nativeFn_preg_match_all, 2)
+	environment.AddNativeFunction("preg_quote", nativeFn_preg_quote)
+	environment.AddNativeFunction("preg_replace", nativeFn_preg_replace, 4)
+	environment.AddNativeFunction("preg_replace_callback", nativeFn_preg_replace_callback, 4)
+	environment.AddNativeFunction("preg_split", nativeFn_preg_split)
+
+	// Const Category: PCRE Constants
+	// Spec: https://www.php.net/manual/en/pcre.constants.php
+	environment.AddPredefinedConstant("PREG_PATTERN_ORDER", values.NewInt(PREG_PATTERN_ORDER))
+	environment.AddPredefinedConstant("PREG_SET_ORDER", values.NewInt(PREG_SET_ORDER))
+	environment.AddPredefinedConstant("PREG_OFFSET_CAPTURE", values.NewInt(PREG_OFFSET_CAPTURE))
+	environment.AddPredefinedConstant("PREG_UNMATCHED_AS_NULL", values.NewInt(PREG_UNMATCHED_AS_NULL))
+	environment.AddPredefinedConstant("PREG_SPLIT_NO_EMPTY", values.NewInt(PREG_SPLIT_NO_EMPTY))
+	environment.AddPredefinedConstant("PREG_SPLIT_DELIM_CAPTURE", values.NewInt(PREG_SPLIT_DELIM_CAPTURE))
+	environment.AddPredefinedConstant("PREG_SPLIT_OFFSET_CAPTURE", values.NewInt(PREG_SPLIT_OFFSET_CAPTURE))
+	environment.AddPredefinedConstant("PREG_GREP_INVERT", values.NewInt(PREG_GREP_INVERT))
+	environment.AddPredefinedConstant("PREG_NO_ERROR", values.NewInt(PREG_NO_ERROR))
+	environment.AddPredefinedConstant("PREG_INTERNAL_ERROR", values.NewInt(PREG_INTERNAL_ERROR))
+	environment.AddPredefinedConstant("PREG_BACKTRACK_LIMIT_ERROR", values.NewInt(PREG_BACKTRACK_LIMIT_ERROR))
+	environment.AddPredefinedConstant("PREG_RECURSION_LIMIT_ERROR", values.NewInt(PREG_RECURSION_LIMIT_ERROR))
+	environment.AddPredefinedConstant("PREG_BAD_UTF8_ERROR", values.NewInt(PREG_BAD_UTF8_ERROR))
+	environment.AddPredefinedConstant("PREG_BAD_UTF8_OFFSET_ERROR", values.NewInt(PREG_BAD_UTF8_OFFSET_ERROR))
+	environment.AddPredefinedConstant("PREG_JIT_STACKLIMIT_ERROR", values.NewInt(PREG_JIT_STACKLIMIT_ERROR))
+	environment.AddPredefinedConstant("PCRE_VERSION", values.NewStr("10.42 2022-12-11"))
+	environment.AddPredefinedConstant("PCRE_VERSION_MAJOR", values.NewInt(10))
+	environment.AddPredefinedConstant("PCRE_VERSION_MINOR", values.NewInt(42))
+	environment.AddPredefinedConstant("PCRE_JIT_SUPPORT", values.NewBool(false))
+}
+
+const (
+	PREG_PATTERN_ORDER        int64 = 1
+	PREG_SET_ORDER            int64 = 2
+	PREG_OFFSET_CAPTURE       int64 = 256
+	PREG_UNMATCHED_AS_NULL    int64 = 512
+	PREG_SPLIT_NO_EMPTY       int64 = 1
+	PREG_SPLIT_DELIM_CAPTURE  int64 = 2
+	PREG_SPLIT_OFFSET_CAPTURE int64 = 4
+	PREG_GREP_INVERT          int64 = 1
+
+	PREG_NO_ERROR              int64 = 0
+	PREG_INTERNAL_ERROR        int64 = 1
+	PREG_BACKTRACK_LIMIT_ERROR int64 = 2
+	PREG_RECURSION_LIMIT_ERROR int64 = 3
+	PREG_BAD_UTF8_ERROR        int64 = 4
+	PREG_BAD_UTF8_OFFSET_ERROR int64 = 5
+	PREG_JIT_STACKLIMIT_ERROR  int64 = 6
+)
+
+var errorMessages = map[int64]string{
+	PREG_NO_ERROR:              "No error",
+	PREG_INTERNAL_ERROR:        "Internal error",
+	PREG_BACKTRACK_LIMIT_ERROR: "Backtrack limit exhausted",
+	PREG_RECURSION_LIMIT_ERROR: "Recursion limit exhausted",
+	PREG_BAD_UTF8_ERROR:        "Malformed UTF-8 characters, possibly incorrectly encoded",
+	PREG_BAD_UTF8_OFFSET_ERROR: "The offset did not correspond to the beginning of a valid UTF-8 code point",
+	PREG_JIT_STACKLIMIT_ERROR:  "JIT stack limit exhausted",
+}
+
+// -------------------------------------- Helpers -------------------------------------- MARK: Helpers
+
+// Request-scoped state of the PCRE functions
+type pcreState struct {
+	lastError int64
+}
+
+func getState(context runtime.Context) *pcreState {
+	return context.Interpreter.GetExtensionState("pcre", func() any { return &pcreState{} }).(*pcreState)
+}
+
+func setLastError(context runtime.Context, code int64) {
+	getState(context).lastError = code
+}
+
+type compiledPattern struct {
+	regex *regex.Regex
+	// Warning if the pattern is invalid
+	warning string
+}
+
+// Compiled patterns are shared by all requests
+var (
+	cacheMutex sync.Mutex
+	cache      = map[string]*compiledPattern{}
+)
+
+const maxCacheSize = 4096
+
+// Compile a pattern with delimiters and modifiers (e.g. "/abc/i").
+// If the pattern is invalid, a warning is printed and nil is returned.
+func compile(functionName string, pattern string, context runtime.Context) *regex.Regex {
+	cacheMutex.Lock()
+	compiled, found := cache[pattern]
+	cacheMutex.Unlock()
+	if !found {
+		compiled = compilePattern(pattern)
+		cacheMutex.Lock()
+		if len(cache) >= maxCacheSize {
+			clear(cache)
+		}
+		cache[pattern] = compiled
+		cacheMutex.Unlock()
+	}
+
+	if compiled.warning != "" {
+		setLastError(context, PREG_INTERNAL_ERROR)
+		context.Interpreter.PrintError(phpError.NewWarning("%s(): %s in %s", functionName, compiled.warning, context.Stmt.GetPosString()))
+		return nil
+	}
+	return compiled.regex
+}
+
+// Spec: https://www.php.net/manual/en/regexp.reference.delimiters.php
+// Spec: https://www.php.net/manual/en/reference.pcre.pattern.modifiers.php
+func compilePattern(pattern string) *compiledPattern {
+	pos := 0
+	for pos < len(pattern) && strings.IndexByte(" \t\n\v\f\r", pattern[pos]) >= 0 {
+		pos++
+	}
+	if pos >= len(pattern) {
+		return &compiledPattern{warning: "Empty regular expression"}
+	}
+
+	delimiter := pattern[pos]
+	if isAlphanumeric(delimiter) || delimiter == '\\' || delimiter == 0 {
+		return &compiledPattern{warning: "Delimiter must not be alphanumeric, backslash, or NUL byte"}
+	}
+	pos++
+	start := pos
+
+	// Bracket style delimiters can be nested
+	endDelimiter := map[byte]byte{'(': ')', '[': ']', '{': '}', '<': '>'}[delimiter]
+	if endDelimiter == 0 {
+		for pos < len(pattern) && pattern[pos] != delimiter {
+			if pattern[pos] == '\\' {
+				pos++
+			}
+			pos++
+		}
+		if pos >= len(pattern) {
+			return &compiledPattern{warning: "No ending delimiter '" + string(delimiter) + "' found"}
+		}
+	} else {
+		depth := 1
+		for pos < len(pattern) {
+			if pattern[pos] == '\\' {
+				pos += 2
+				continue
+			}
+			if pattern[pos] == endDelimiter {
+				depth--
+				if depth == 0 {
+					break
+				}
+			} else if pattern[pos] == delimiter {
+				depth++
+			}
+			pos++
+		}
+		if pos >= len(pattern) {
+			return &compiledPattern{warning: "No ending matching delimiter '" + string(endDelimiter) + "' found"}
+		}
+	}
+
+	body := pattern[start:pos]
+	flags := regex.Flags{}
+	for _, modifier := range []byte(pattern[pos+1:]) {
+		switch modifier {
+		case 'i':
+			flags.CaseInsensitive = true
+		case 'm':
+			flags.Multiline = true
+		case 's':
+			flags.DotAll = true
+		case 'x':
+			flags.Extended = true
+		case 'u':
+			flags.UTF8 = true
+		case 'U':
+			flags.Ungreedy = true
+		case 'D':
+			flags.DollarEndOnly = true
+		case 'A':
+			flags.Anchored = true
+		case 'J':
+			flags.DupNames = true
+		case 'n':
+			flags.NoAutoCapture = true
+		case 'S', 'X', ' ', '\n', '\r':
+			// "S" (extra analysis) and "X" (strict escapes) are always enabled
+		case 0:
+			return &compiledPattern{warning: "NUL is not a valid modifier"}
+		default:
+			return &compiledPattern{warning: "Unknown modifier '" + string(modifier) + "'"}
+		}
+	}
+
+	compiled, err := regex.Compile(body, flags)
+	if err != nil {
+		return &compiledPattern{warning: "Compilation failed: " + err.Error()}
+	}
+	return &compiledPattern{regex: compiled}
+}
+
+func isAlphanumeric(char byte) bool {
+	return (char >= '0' && char <= '9') || (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z')
+}
+
+// Check the subject and the offset for UTF-8 patterns. Returns false and sets the last error if they are invalid.
+func checkSubject(context runtime.Context, compiled *regex.Regex, subject string, offset int) bool {
+	if !compiled.IsUTF8() {
+		return true
+	}
+	if !utf8.ValidString(subject) {
+		setLastError(context, PREG_BAD_UTF8_ERROR)
+		return false
+	}
+	if offset < len(subject) && !utf8.RuneStart(subject[offset]) {
+		setLastError(context, PREG_BAD_UTF8_OFFSET_ERROR)
+		return false
+	}
+	return true
+}
+
+func matchOptions(context runtime.Context) regex.MatchOptions {
+	ini := context.Interpreter.GetIni()
+	return regex.MatchOptions{
+		BacktrackLimit: int(ini.GetInt("pcre.backtrack_limit")),
+		RecursionLimit: int(ini.GetInt("pcre.recursion_limit")),
+	}
+}
+
+// Match the regex and set the last error if the matching failed
+func match(context runtime.Context, compiled *regex.Regex, subject string, offset int, options regex.MatchOptions) ([]int, bool) {
+	offsets, err := compiled.Match(subject, offset, options)
+	switch err {
+	case nil:
+		return offsets, true
+	case regex.ErrBacktrackLimit:
+		setLastError(context, PREG_BACKTRACK_LIMIT_ERROR)
+	case regex.ErrRecursionLimit:
+		setLastError(context, PREG_RECURSION_LIMIT_ERROR)
+	default:
+		setLastError(context, PREG_INTERNAL_ERROR)
+	}
+	return nil, false
+}
+
+// Call the handler for each match like Perl's "/g" modifier: After an empty match, a non-empty match
+// is tried at the same position before the search continues at the next character.
+// The handler returns false to stop. Returns false if the matching failed.
+func forEachMatch(context runtime.Context, compiled *regex.Regex, subject string, offset int, handler func(offsets []int) bool) bool {
+	options := matchOptions(context)
+	afterEmptyMatch := false
+	for offset <= len(subject) {
+		matchOptions := options
+		if afterEmptyMatch {
+			matchOptions.NotEmptyAtStart = true
+			matchOptions.Anchored = true
+		}
+		offsets, ok := match(context, compiled, subject, offset, matchOptions)
+		if !ok {
+			return false
+		}
+		if offsets == nil {
+			if !afterEmptyMatch || offset >= len(subject) {
+				return true
+			}
+			offset += charLen(compiled, subject, offset)
+			afterEmptyMatch = false
+			continue
+		}
+		if !handler(offsets) {
+			return true
+		}
+		afterEmptyMatch = offsets[0] == offsets[1]
+		offset = offsets[1]
+	}
+	return true
+}
+
+// Length of the character at the offset (one byte for non-UTF-8 patterns)
+func charLen(compiled *regex.Regex, subject string, offset int) int {
+	if !compiled.IsUTF8() {
+		return 1
+	}
+	_, size := utf8.DecodeRuneInString(subject[offset:])
+	return size
+}
+
+// Number of groups up to the last group that is set
+func setGroupCount(offsets []int) int {
+	count := len(offsets) / 2
+	for count > 1 && offsets[2*(count-1)] < 0 {
+		count--
+	}
+	return count
+}
+
+// Value of a group in the result arrays
+func groupValue(subject string, offsets []int, index int, flags int64) values.RuntimeValue {
+	start, end := -1, -1
+	if 2*index < len(offsets) {
+		start, end = offsets[2*index], offsets[2*index+1]
+	}
+
+	var value values.RuntimeValue = values.NewStr("")
+	if start >= 0 {
+		value = values.NewStr(subject[start:end])
+	} else if flags&PREG_UNMATCHED_AS_NULL != 0 {
+		value = values.NewNull()
+	}
+	if flags&PREG_OFFSET_CAPTURE == 0 {
+		return value
+	}
+	return newOffsetPair(value, start)
+}
+
+func newOffsetPair(value values.RuntimeValue, offset int) *values.Array {
+	pair := values.NewArray()
+	pair.SetElement(nil, value)
+	pair.SetElement(nil, values.NewInt(int64(offset)))
+	return pair
+}
+
+// Array of a match with the groups by number and name.
+// Unset groups at the end are omitted unless PREG_UNMATCHED_AS_NULL is set.
+func matchArray(compiled *regex.Regex, subject string, offsets []int, flags int64) *values.Array {
+	count := setGroupCount(offsets)
+	if flags&PREG_UNMATCHED_AS_NULL != 0 {
+		count = compiled.GroupCount() + 1
+	}
+
+	names := compiled.GroupNames()
+	result := values.NewArray()
+	for index := 0; index < count; index++ {
+		if names[index] != "" {
+			result.SetElement(values.NewStr(names[index]), groupValue(subject, offsets, index, flags))
+		}
+		result.SetElement(values.NewInt(int64(index)), groupValue(subject, offsets, index, flags))
+	}
+	return result
+}
+
+func toString(value values.RuntimeValue, context runtime.Context) (string, phpError.Error) {
+	return variableHandling.StrVal(value, context.Interpreter.GetIni().GetInt("precision"))
+}
+
+// Normalize a negative offset (counted from the end of the subject)
+func normalizeOffset(offset int64, subject string) int {
+	if offset < 0 {
+		offset = max(0, int64(len(subject))+offset)
+	}
+	return int(offset)
+}
+
+// -------------------------------------- preg_grep -------------------------------------- MARK: preg_grep
+
+func nativeFn_preg_grep(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	args, err := funcParamValidator.NewValidator("preg_grep").
+		AddParam("$pattern", []string{"string"}, nil).
+		AddParam("$array", []string{"array"}, nil).
+		AddParam("$flags", []string{"int"}, values.NewInt(0)).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Spec: https://www.php.net/manual/en/function.preg-grep.php
+	// Returns an array indexed using the keys from the array provided, or false on failure.
+	compiled := compile("preg_grep", args[0].(*values.Str).Value, context)
+	if compiled == nil {
+		return values.NewBool(false), nil
+	}
+	setLastError(context, PREG_NO_ERROR)
+
+	invert := args[2].(*values.Int).Value&PREG_GREP_INVERT != 0
+	array := args[1].(*values.Array)
+	result := values.NewArray()
+	options := matchOptions(context)
+	for _, key := range array.GetKeys() {
+		element, _ := array.GetElement(key)
+		subject, err := toString(element, context)
+		if err != nil {
+			return values.NewVoid(), err
+		}
+		if !checkSubject(context, compiled, subject, 0) {
+			break
+		}
+		offsets, ok := match(context, compiled, subject, 0, options)
+		if !ok {
+			break
+		}
+		if (offsets != nil) != invert {
+			result.SetElement(key, values.Copy(element))
+		}
+	}
+	return result, nil
+}
+
+// -------------------------------------- preg_last_error -------------------------------------- MARK: preg_last_error
+
+func nativeFn_preg_last_error(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	_, err := funcParamValidator.NewValidator("preg_last_error").Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Spec: https://www.php.net/manual/en/function.preg-last-error.php
+	return values.NewInt(getState(context).lastError), nil
+}
+
+// -------------------------------------- preg_last_error_msg -------------------------------------- MARK: preg_last_error_msg
+
+func nativeFn_preg_last_error_msg(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	_, err := funcParamValidator.NewValidator("preg_last_error_msg").Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Spec: https://www.php.net/manual/en/function.preg-last-error-msg.php
+	return values.NewStr(errorMessages[getState(context).lastError]), nil
+}
+
+// -------------------------------------- preg_match -------------------------------------- MARK: preg_match
+
+func nativeFn_preg_match(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	args, err := funcParamValidator.NewValidator("preg_match").
+		AddParam("$pattern", []string{"string"}, nil).
+		AddParam("$subject", []string{"string"}, nil).
+		AddParam("$matches", []string{"mixed"}, values.NewNull()).
+		AddParam("$flags", []string{"int"}, values.NewInt(0)).
+		AddParam("$offset", []string{"int"}, values.NewInt(0)).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Spec: https://www.php.net/manual/en/function.preg-match.php
+	// preg_match() returns 1 if the pattern matches given subject, 0 if it does not, or false on failure.
+	flags := args[3].(*values.Int).Value
+	if flags&0xFF != 0 {
+		return values.NewVoid(), phpError.NewError("Uncaught ValueError: preg_match(): Argument #4 ($flags) must be a PREG_* constant")
+	}
+
+	compiled := compile("preg_match", args[0].(*values.Str).Value, context)
+	if compiled == nil {
+		return values.NewBool(false), nil
+	}
+	setLastError(context, PREG_NO_ERROR)
+
+	subject := args[1].(*values.Str).Value
+	offset := normalizeOffset(args[4].(*values.Int).Value, subject)
+	matches := values.NewArray()
+	if offset > len(subject) {
+		setLastError(context, PREG_INTERNAL_ERROR)
+		return values.NewBool(false), context.Interpreter.SetByRefArgument(context, 2, matches)
+	}
+	if !checkSubject(context, compiled, subject, offset) {
+		return values.NewBool(false), context.Interpreter.SetByRefArgument(context, 2, matches)
+	}
+
+	offsets, ok := match(context, compiled, subject, offset, matchOptions(context))
+	if !ok {
+		return values.NewBool(false), context.Interpreter.SetByRefArgument(context, 2, matches)
+	}
+	if offsets != nil {
+		matches = matchArray(compiled, subject, offsets, flags)
+	}
+	if err := context.Interpreter.SetByRefArgument(context, 2, matches); err != nil {
+		return values.NewVoid(), err
+	}
+	if offsets == nil {
+		return values.NewInt(0), nil
+	}
+	return values.NewInt(1), nil
+}
+
+// -------------------------------------- preg_match_all -------------------------------------- MARK: preg_match_all
+
+func nativeFn_preg_match_all(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	args, err := funcParamValidator.NewValidator("preg_match_all").
+		AddParam("$pattern", []string{"string"}, nil).
+		AddParam("$subject", []string{"string"}, nil).
+		AddParam("$matches", []string{"mixed"}, values.NewNull()).
+		AddParam("$flags", []string{"int"}, values.NewInt(0)).
+		AddParam("$offset", []string{"int"}, values.NewInt(0)).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Spec: https://www.php.net/manual/en/function.preg-match-all.php
+	// Returns the number of full pattern matches (which might be zero), or false on failure.
+	flags := args[3].(*values.Int).Value
+	order := flags & 0xFF
+	if order == 0 {
+		order = PREG_PATTERN_ORDER
+	}
+	if order != PREG_PATTERN_ORDER && order != PREG_SET_ORDER {
+		return values.NewVoid(), phpError.NewError("Uncaught ValueError: preg_match_all(): Argument #4 ($flags) must be a PREG_* constant")
+	}
+
+	compiled := compile("preg_match_all", args[0].(*values.Str).Value, context)
+	if compiled == nil {
+		return values.NewBool(false), nil
+	}
+	setLastError(context, PREG_NO_ERROR)
+
+	subject := args[1].(*values.Str).Value
+	offset := normalizeOffset(args[4].(*values.Int).Value, subject)
+	if offset > len(subject) {
+		setLastError(context, PREG_INTERNAL_ERROR)
+		return values.NewBool(false), context.Interpreter.SetByRefArgument(context, 2, values.NewArray())
+	}
+	if !checkSubject(context, compiled, subject, offset) {
+		return values.NewBool(false), context.Interpreter.SetByRefArgument(context, 2, values.NewArray())
+	}
+
+	numGroups := compiled.GroupCount() + 1
+	groupMatches := make([]*values.Array, numGroups)
+	for index := range groupMatches {
+		groupMatches[index] = values.NewArray()
+	}
+	setMatches := values.NewArray()
+	count := 0
+	ok := forEachMatch(context, compiled, subject, offset, func(offsets []int) bool {
+		count++
+		if order == PREG_SET_ORDER {
+			setMatches.SetElement(nil, matchArray(compiled, subject, offsets, flags))
+			return true
+		}
+		for index := range groupMatches {
+			groupMatches[index].SetElement(nil, groupValue(subject, offsets, index, flags))
+		}
+		return true
+	})
+	if !ok {
+		return values.NewBool(false), context.Interpreter.SetByRefArgument(context, 2, values.NewArray())
+	}
+
+	matches := setMatches
+	if order == PREG_PATTERN_ORDER {
+		matches = values.NewArray()
+		names := compiled.GroupNames()
+		for index, groupMatch := range groupMatches {
+			if names[index] != "" {
+				matches.SetElement(values.NewStr(names[index]), groupMatch.Copy())
+			}
+			matches.SetElement(values.NewInt(int64(index)), groupMatch)
+		}
+	}
+	if err := context.Interpreter.SetByRefArgument(context, 2, matches); err != nil {
+		return values.NewVoid(), err
+	}
+	return values.NewInt(int64(count)), nil
+}
+
+// -------------------------------------- preg_quote -------------------------------------- MARK: preg_quote
+
+func nativeFn_preg_quote(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	args, err := funcParamValidator.NewValidator("preg_quote").
+		AddParam("$str", []string{"string"}, nil).
+		AddParam("$delimiter", []string{"string", "null"}, values.NewNull()).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Spec: https://www.php.net/manual/en/function.preg-quote.php
+	// preg_quote() takes str and puts a backslash in front of every character that is part of the regular expression syntax.
+	// The special regular expression characters are: . \ + * ? [ ^ ] $ ( ) { } = ! < > | : - # and NUL.
+	// The delimiter is also escaped.
+	delimiter := -1
+	if args[1].GetType() == values.StrValue && args[1].(*values.Str).Value != "" {
+		delimiter = int(args[1].(*values.Str).Value[0])
+	}
+
+	str := args[0].(*values.Str).Value
+	var result strings.Builder
+	for i := 0; i < len(str); i++ {
+		char := str[i]
+		switch {
+		case char == 0:
+			result.WriteString(`\000`)
+			continue
+		case strings.IndexByte(`.\+*?[^]$(){}=!<>|:-#`, char) >= 0 || int(char) == delimiter:
+			result.WriteByte('\\')
+		}
+		result.WriteByte(char)
+	}
+	return values.NewStr(result.String()), nil
+}
+
+// -------------------------------------- preg_replace -------------------------------------- MARK: preg_replace
+
+func nativeFn_preg_replace(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	args, err := funcParamValidator.NewValidator("preg_replace").
+		AddParam("$pattern", []string{"string", "array"}, nil).
+		AddParam("$replacement", []string{"string", "array"}, nil).
+		AddParam("$subject", []string{"string", "array"}, nil).
+		AddParam("$limit", []string{"int"}, values.NewInt(-1)).
+		AddParam("$count", []string{"mixed"}, values.NewNull()).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Spec: https://www.php.net/manual/en/function.preg-replace.php
+	// preg_replace() returns an array if the subject parameter is an array, or a string otherwise.
+	// If matches are found, the new subject will be returned, otherwise subject will be returned unchanged or null if an error occurred.
+	if args[0].GetType() == values.StrValue && args[1].GetType() == values.ArrayValue {
+		return values.NewVoid(), phpError.NewError(
+			"Uncaught TypeError: preg_replace(): Argument #1 ($pattern) must be of type array when argument #2 ($replacement) is an array, string given",
+		)
+	}
+
+	patterns, err := toStringList(args[0], context)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+	var replacements []string
+	if args[1].GetType() == values.ArrayValue {
+		if replacements, err = toStringList(args[1], context); err != nil {
+			return values.NewVoid(), err
+		}
+	}
+
+	replacer := func(index int) replaceFunc {
+		replacement := ""
+		if args[1].GetType() == values.StrValue {
+			replacement = args[1].(*values.Str).Value
+		} else if index < len(replacements) {
+			replacement = replacements[index]
+		}
+		return func(compiled *regex.Regex, subject string, offsets []int) (string, phpError.Error) {
+			return expandReplacement(replacement, subject, offsets), nil
+		}
+	}
+	return replace("preg_replace", patterns, replacer, args[2], args[3].(*values.Int).Value, 4, context)
+}
+
+// Expand the references "\n", "$n" and "${n}" of a replacement
+func expandReplacement(replacement string, subject string, offsets []int) string {
+	groupCount := setGroupCount(offsets)
+	var result strings.Builder
+	var lastChar byte
+	for i := 0; i < len(replacement); {
+		char := replacement[i]
+		if char == '\\' || char == '$' {
+			// "\\" and "\$" are a literal backslash and dollar sign
+			if lastChar == '\\' {
+				str := result.String()
+				result.Reset()
+				result.WriteString(str[:len(str)-1])
+				result.WriteByte(char)
+				lastChar = 0
+				i++
+				continue
+			}
+			if group, length, ok := parseBackref(replacement[i:]); ok {
+				if group < groupCount && offsets[2*group] >= 0 {
+					result.WriteString(subject[offsets[2*group]:offsets[2*group+1]])
+				}
+				i += length
+				lastChar = replacement[i-1]
+				continue
+			}
+		}
+		result.WriteByte(char)
+		lastChar = char
+		i++
+	}
+	return result.String()
+}
+
+// Parse a reference like "\1", "$12" or "${1}" and return the group and the length of the reference
+func parseBackref(str string) (int, int, bool) {
+	pos := 1
+	inBraces := str[0] == '$' && strings.HasPrefix(str[1:], "{")
+	if inBraces {
+		pos++
+	}
+	group := 0
+	digits := 0
+	for pos < len(str) && digits < 2 && str[pos] >= '0' && str[pos] <= '9' {
+		group = group*10 + int(str[pos]-'0')
+		pos++
+		digits++
+	}
+	if digits == 0 {
+		return 0, 0, false
+	}
+	if inBraces {
+		if pos >= len(str) || str[pos] != '}' {
+			return 0, 0, false
+		}
+		pos++
+	}
+	return group, pos, true
+}
+
+func toStringList(value values.RuntimeValue, context runtime.Context) ([]string, phpError.Error) {
+	if value.GetType() != values.ArrayValue {
+		str, err := toString(value, context)
+		return []string{str}, err
+	}
+	array := value.(*values.Array)
+	list := []string{}
+	for _, key := range array.GetKeys() {
+		element, _ := array.GetElement(key)
+		str, err := toString(element, context)
+		if err != nil {
+			return list, err
+		}
+		list = append(list, str)
+	}
+	return list, nil
+}
+
+// Function that returns the replacement of a match
+type replaceFunc func(compiled *regex.Regex, subject string, offsets []int) (string, phpError.Error)
+
+// Replace the matches of the patterns in the subject (a string or an array of strings)
+// and assign the number of replacements to the by-reference parameter at countIndex.
+func replace(
+	functionName string, patterns []string, replacer func(patternIndex int) replaceFunc,
+	subject values.RuntimeValue, limit int64, countIndex int, context runtime.Context,
+) (values.RuntimeValue, phpError.Error) {
+	count := 0
+	replaceSubject := func(subject string) (values.RuntimeValue, phpError.Error) {
+		for index, pattern := range patterns {
+			compiled := compile(functionName, pattern, context)
+			if compiled == nil {
+				return values.NewNull(), nil
+			}
+			setLastError(context, PREG_NO_ERROR)
+			result, replaced, ok, err := replaceMatches(compiled, subject, limit, replacer(index), context)
+			if err != nil || !ok {
+				return values.NewNull(), err
+			}
+			subject = result
+			count += replaced
+		}
+		return values.NewStr(subject), nil
+	}
+
+	var result values.RuntimeValue
+	if subject.GetType() == values.ArrayValue {
+		array := subject.(*values.Array)
+		resultArray := values.NewArray()
+		for _, key := range array.GetKeys() {
+			element, _ := array.GetElement(key)
+			str, err := toString(element, context)
+			if err != nil {
+				return values.NewVoid(), err
+			}
+			replaced, err := replaceSubject(str)
+			if err != nil {
+				return values.NewVoid(), err
+			}
+			// Elements with errors are omitted
+			if replaced.GetType() != values.NullValue {
+				resultArray.SetElement(key, replaced)
+			}
+		}
+		result = resultArray
+	} else {
+		var err phpError.Error
+		if result, err = replaceSubject(subject.(*values.Str).Value); err != nil {
+			return values.NewVoid(), err
+		}
+	}
+
+	if err := context.Interpreter.SetByRefArgument(context, countIndex, values.NewInt(int64(count))); err != nil {
+		return values.NewVoid(), err
+	}
+	return result, nil
+}
+
+// Replace up to limit matches (-1 = no limit) and return the result and the number of replacements.
+// It returns false if the matching failed.
+func replaceMatches(compiled *regex.Regex, subject string, limit int64, replacement replaceFunc, context runtime.Context) (string, int, bool, phpError.Error) {
+	if !checkSubject(context, compiled, subject, 0) {
+		return "", 0, false, nil
+	}
+
+	var result strings.Builder
+	lastEnd := 0
+	count := 0
+	var replaceErr phpError.Error
+	ok := forEachMatch(context, compiled, subject, 0, func(offsets []int) bool {
+		if limit >= 0 && int64(count) >= limit {
+			return false
+		}
+		replaced, err := replacement(compiled, subject, offsets)
+		if err != nil {
+			replaceErr = err
+			return false
+		}
+		result.WriteString(subject[lastEnd:offsets[0]])
+		result.WriteString(replaced)
+		lastEnd = offsets[1]
+		count++
+		return true
+	})
+	if replaceErr != nil || !ok {
+		return "", 0, false, replaceErr
+	}
+	result.WriteString(subject[lastEnd:])
+	return result.String(), count, true, nil
+}
+
+// -------------------------------------- preg_replace_callback -------------------------------------- MARK: preg_replace_callback
+
+func nativeFn_preg_replace_callback(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	args, err := funcParamValidator.NewValidator("preg_replace_callback").
+		AddParam("$pattern", []string{"string", "array"}, nil).
+		AddParam("$callback", []string{"mixed"}, nil).
+		AddParam("$subject", []string{"string", "array"}, nil).
+		AddParam("$limit", []string{"int"}, values.NewInt(-1)).
+		AddParam("$count", []string{"mixed"}, values.NewNull()).
+		AddParam("$flags", []string{"int"}, values.NewInt(0)).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Spec: https://www.php.net/manual/en/function.preg-replace-callback.php
+	// The behavior of this function is almost identical to preg_replace(), except for the fact that
+	// instead of replacement parameter, one should specify a callback.
+	callback := args[1]
+	if err := variableHandling.ValidateCallback("preg_replace_callback", 2, "$callback", callback, false, context); err != nil {
+		return values.NewVoid(), err
+	}
+
+	patterns, err := toStringList(args[0], context)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+	flags := args[5].(*values.Int).Value
+	replacer := func(int) replaceFunc {
+		return func(compiled *regex.Regex, subject string, offsets []int) (string, phpError.Error) {
+			matches := matchArray(compiled, subject, offsets, flags)
+			result, err := context.Interpreter.CallFunction(context, callback, []values.RuntimeValue{matches})
+			if err != nil {
+				return "", err
+			}
+			return toString(result, context)
+		}
+	}
+	return replace("preg_replace_callback", patterns, replacer, args[2], args[3].(*values.Int).Value, 4, context)
+}
+
+// -------------------------------------- preg_split -------------------------------------- MARK: preg_split
+
+func nativeFn_preg_split(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	args, err := funcParamValidator.NewValidator("preg_split").
+		AddParam("$pattern", []string{"string"}, nil).
+		AddParam("$subject", []string{"string"}, nil).
+		AddParam("$limit", []string{"int"}, values.NewInt(-1)).
+		AddParam("$flags", []string{"int"}, values.NewInt(0)).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Spec: https://www.php.net/manual/en/function.preg-split.php
+	// Returns an array containing substrings of subject split along boundaries matched by pattern, or false on failure.
+	compiled := compile("preg_split", args[0].(*values.Str).Value, context)
+	if compiled == nil {
+		return values.NewBool(false), nil
+	}
+	setLastError(context, PREG_NO_ERROR)
+
+	subject := args[1].(*values.Str).Value
+	if !checkSubject(context, compiled, subject, 0) {
+		return values.NewBool(false), nil
+	}
+
+	// limit: If specified, then only substrings up to limit are returned with the rest of the string being placed in the last substring.
+	// A limit of -1 or 0 means "no limit".
+	limit := args[2].(*values.Int).Value
+	if limit == 0 {
+		limit = -1
+	}
+	flags := args[3].(*values.Int).Value
+	noEmpty := flags&PREG_SPLIT_NO_EMPTY != 0
+	delimCapture := flags&PREG_SPLIT_DELIM_CAPTURE != 0
+	offsetCapture := flags&PREG_SPLIT_OFFSET_CAPTURE != 0
+
+	result := values.NewArray()
+	addPiece := func(start int, end int) {
+		piece := values.NewStr(subject[start:end])
+		if offsetCapture {
+			result.SetElement(nil, newOffsetPair(piece, start))
+		} else {
+			result.SetElement(nil, piece)
+		}
+	}
+
+	lastEnd := 0
+	if limit == -1 || limit > 1 {
+		ok := forEachMatch(context, compiled, subject, 0, func(offsets []int) bool {
+			if !noEmpty || offsets[0] != lastEnd {
+				addPiece(lastEnd, offsets[0])
+				if limit != -1 {
+					limit--
+				}
+			}
+			if delimCapture {
+				for group := 1; group < setGroupCount(offsets); group++ {
+					if !noEmpty || offsets[2*group] != offsets[2*group+1] {
+						addPiece(max(offsets[2*group], 0), max(offsets[2*group+1], 0))
+					}
+				}
+			}
+			lastEnd = offsets[1]
+			return limit == -1 || limit > 1
+		})
+		if !ok {
+			return values.NewBool(false), nil
+		}
+	}
+
+	if !noEmpty || lastEnd < len(subject) {
+		addPiece(lastEnd, len(subject))
+	}
+	return result, nil
+}
+
+// TODO preg_filter
+// TODO preg_replace_callback_array
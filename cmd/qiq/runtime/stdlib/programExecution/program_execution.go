@@ -0,0 +1,581 @@
+package programExecution
+
+import (
+	"QIQ/cmd/qiq/common/os"
+	"QIQ/cmd/qiq/phpError"
+	"QIQ/cmd/qiq/runtime"
+	"QIQ/cmd/qiq/runtime/funcParamValidator"
+	"QIQ/cmd/qiq/runtime/stdlib/variableHandling"
+	"QIQ/cmd/qiq/runtime/stream"
+	"QIQ/cmd/qiq/runtime/values"
+	"bufio"
+	"errors"
+	"io"
+	GoOs "os"
+	"os/exec"
+	"strings"
+)
+
+func Register(environment runtime.Environment) {
+	// Category: Program execution Functions
+	environment.AddNativeFunction("escapeshellarg", nativeFn_escapeshellarg)
+	environment.AddNativeFunction("escapeshellcmd", nativeFn_escapeshellcmd)
+	environment.AddNativeFunction("exec", nativeFn_exec, 1, 2)
+	environment.AddNativeFunction("passthru", nativeFn_passthru, 1)
+	environment.AddNativeFunction("proc_close", nativeFn_proc_close)
+	environment.AddNativeFunction("proc_open", nativeFn_proc_open, 2)
+	environment.AddNativeFunction("shell_exec", nativeFn_shell_exec)
+	environment.AddNativeFunction("system", nativeFn_system, 1)
+}
+
+// -------------------------------------- escapeshellarg -------------------------------------- MARK: escapeshellarg
+
+func nativeFn_escapeshellarg(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.escapeshellarg.php
+
+	args, err := funcParamValidator.NewValidator("escapeshellarg").AddParam("$arg", []string{"string"}, nil).Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	arg := args[0].(*values.Str).Value
+
+	// On Windows, escapeshellarg() instead replaces percent signs, exclamation marks (delayed variable substitution)
+	// and double quotes with spaces and adds double quotes around the string.
+	if os.OS == "Windows" {
+		return values.NewStr(`"` + strings.NewReplacer(`"`, " ", "%", " ", "!", " ").Replace(arg) + `"`), nil
+	}
+
+	// escapeshellarg() adds single quotes around a string and quotes/escapes any existing single quotes
+	return values.NewStr("'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"), nil
+}
+
+// -------------------------------------- escapeshellcmd -------------------------------------- MARK: escapeshellcmd
+
+func nativeFn_escapeshellcmd(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.escapeshellcmd.php
+
+	args, err := funcParamValidator.NewValidator("escapeshellcmd").AddParam("$command", []string{"string"}, nil).Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	return values.NewStr(escapeShellCmd(args[0].(*values.Str).Value)), nil
+}
+
+func escapeShellCmd(command string) string {
+	// Following characters are preceded by a backslash: &#;`|*?~<>^()[]{}$\, \x0A and \xFF.
+	// ' and " are escaped only if they are not paired.
+	// On Windows, all these characters plus % and ! are preceded by a caret (^).
+
+	escapeChar := byte('\\')
+	specialChars := "&#;`|*?~<>^()[]{}$\\\x0A\xFF"
+	if os.OS == "Windows" {
+		escapeChar = '^'
+		specialChars += `%!"'`
+	}
+
+	var result strings.Builder
+	var openQuote byte = 0
+	for i := 0; i < len(command); i++ {
+		char := command[i]
+		if (char == '"' || char == '\'') && os.OS != "Windows" {
+			if openQuote == 0 && strings.IndexByte(command[i+1:], char) >= 0 {
+				// Opening quote of a pair
+				openQuote = char
+			} else if openQuote == char {
+				// Closing quote of a pair
+				openQuote = 0
+			} else {
+				result.WriteByte(escapeChar)
+			}
+			result.WriteByte(char)
+			continue
+		}
+		if strings.IndexByte(specialChars, char) >= 0 {
+			result.WriteByte(escapeChar)
+		}
+		result.WriteByte(char)
+	}
+	return result.String()
+}
+
+// -------------------------------------- exec -------------------------------------- MARK: exec
+
+func nativeFn_exec(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.exec.php
+
+	args, err := funcParamValidator.NewValidator("exec").
+		AddParam("$command", []string{"string"}, nil).
+		AddParam("$output", []string{"mixed"}, values.NewNull()).
+		AddParam("$result_code", []string{"mixed"}, values.NewNull()).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	command, err := getCommand("exec", args[0])
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	cmd := shellCommand(command)
+	cmd.Stdin = GoOs.Stdin
+	cmd.Stderr = GoOs.Stderr
+	stdout, goErr := cmd.Output()
+	resultCode, ok := getResultCode(goErr)
+	if !ok {
+		context.Interpreter.PrintError(phpError.NewWarning("exec(): Unable to fork [%s] in %s", command, context.Stmt.GetPosString()))
+		return values.NewBool(false), nil
+	}
+
+	// If the output argument is present, then the specified array will be filled with every line of output from the command.
+	// Trailing whitespace, such as \n, is not included in this array.
+	// Note that if the array already contains some elements, exec() will append to the end of the array.
+	output := values.NewArray()
+	if args[1].GetType() == values.ArrayValue {
+		output = args[1].(*values.Array)
+	}
+	lastLine := ""
+	for _, line := range splitLines(string(stdout)) {
+		lastLine = strings.TrimRight(line, " \t\n\r\v\f")
+		if err := output.SetElement(nil, values.NewStr(lastLine)); err != nil {
+			return values.NewVoid(), err
+		}
+	}
+
+	if err := context.Interpreter.SetByRefArgument(context, 1, output); err != nil {
+		return values.NewVoid(), err
+	}
+	if err := context.Interpreter.SetByRefArgument(context, 2, values.NewInt(int64(resultCode))); err != nil {
+		return values.NewVoid(), err
+	}
+
+	// The last line from the result of the command.
+	return values.NewStr(lastLine), nil
+}
+
+// -------------------------------------- passthru -------------------------------------- MARK: passthru
+
+func nativeFn_passthru(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.passthru.php
+
+	args, err := funcParamValidator.NewValidator("passthru").
+		AddParam("$command", []string{"string"}, nil).
+		AddParam("$result_code", []string{"mixed"}, values.NewNull()).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	command, err := getCommand("passthru", args[0])
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Execute an external program and display raw output
+	resultCode, _, ok := runAndPrintLines(command, context)
+	if !ok {
+		context.Interpreter.PrintError(phpError.NewWarning("passthru(): Unable to fork [%s] in %s", command, context.Stmt.GetPosString()))
+		return values.NewBool(false), nil
+	}
+
+	if err := context.Interpreter.SetByRefArgument(context, 1, values.NewInt(int64(resultCode))); err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Returns null on success or false on failure.
+	return values.NewNull(), nil
+}
+
+// -------------------------------------- proc_close -------------------------------------- MARK: proc_close
+
+func nativeFn_proc_close(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.proc-close.php
+
+	args, err := funcParamValidator.NewValidator("proc_close").AddParam("$process", []string{"resource"}, nil).Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	resource := args[0].(*values.Resource)
+	process, ok := resource.Handle.(*process)
+	if !ok || resource.IsClosed {
+		return values.NewVoid(), phpError.NewError("Uncaught TypeError: proc_close(): supplied resource is not a valid process resource")
+	}
+
+	// proc_close() closes all pipes that are still open to avoid a deadlock and waits for the process to terminate.
+	resource.Close()
+	for _, pipe := range process.pipes {
+		pipe.Close()
+	}
+	resultCode, ok := getResultCode(process.cmd.Wait())
+	if !ok {
+		return values.NewInt(-1), nil
+	}
+
+	// Returns the termination status of the process that was run. In case of an error then -1 is returned.
+	return values.NewInt(int64(resultCode)), nil
+}
+
+// -------------------------------------- proc_open -------------------------------------- MARK: proc_open
+
+type process struct {
+	cmd   *exec.Cmd
+	pipes []*stream.Stream
+}
+
+func nativeFn_proc_open(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.proc-open.php
+
+	args, err := funcParamValidator.NewValidator("proc_open").
+		AddParam("$command", []string{"array", "string"}, nil).
+		AddParam("$descriptor_spec", []string{"array"}, nil).
+		AddParam("$pipes", []string{"mixed"}, nil).
+		AddParam("$cwd", []string{"string"}, values.NewNull()).
+		AddParam("$env_vars", []string{"array"}, values.NewNull()).
+		AddParam("$options", []string{"array"}, values.NewNull()).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// The commandline to execute as string. Special characters have to be properly escaped, and proper quoting has to be applied.
+	// As of PHP 7.4.0, command may be passed as array of command parameters.
+	// In this case the process will be opened directly (without going through a shell).
+	var cmd *exec.Cmd
+	if args[0].GetType() == values.StrValue {
+		command, err := getCommand("proc_open", args[0])
+		if err != nil {
+			return values.NewVoid(), err
+		}
+		cmd = shellCommand(command)
+	} else {
+		commandArray := args[0].(*values.Array)
+		if commandArray.Count() == 0 {
+			return values.NewVoid(), phpError.NewError("Uncaught ValueError: proc_open(): Argument #1 ($command) must have at least one element")
+		}
+		command := []string{}
+		for _, key := range commandArray.GetKeys() {
+			element, _ := commandArray.GetElement(key)
+			elementStr, err := variableHandling.StrVal(element, context.Interpreter.GetIni().GetInt("precision"))
+			if err != nil {
+				return values.NewVoid(), err
+			}
+			command = append(command, elementStr)
+		}
+		cmd = exec.Command(command[0], command[1:]...)
+	}
+
+	if args[3].GetType() == values.StrValue {
+		cmd.Dir = args[3].(*values.Str).Value
+	}
+
+	if args[4].GetType() == values.ArrayValue {
+		envVars := args[4].(*values.Array)
+		cmd.Env = []string{}
+		for _, key := range envVars.GetKeys() {
+			element, _ := envVars.GetElement(key)
+			keyStr, err := variableHandling.StrVal(key, context.Interpreter.GetIni().GetInt("precision"))
+			if err != nil {
+				return values.NewVoid(), err
+			}
+			elementStr, err := variableHandling.StrVal(element, context.Interpreter.GetIni().GetInt("precision"))
+			if err != nil {
+				return values.NewVoid(), err
+			}
+			cmd.Env = append(cmd.Env, keyStr+"="+elementStr)
+		}
+	}
+
+	// Descriptors that are not specified are inherited from the parent process
+	cmd.Stdin = GoOs.Stdin
+	cmd.Stdout = GoOs.Stdout
+	cmd.Stderr = GoOs.Stderr
+
+	process := &process{cmd: cmd, pipes: []*stream.Stream{}}
+	pipes := values.NewArray()
+	// Files of the child process that must be closed in the parent process after the child process started
+	childFiles := []*GoOs.File{}
+	closeAll := func() {
+		for _, file := range childFiles {
+			file.Close()
+		}
+		for _, pipe := range process.pipes {
+			pipe.Close()
+		}
+	}
+
+	descriptorSpec := args[1].(*values.Array)
+	for _, key := range descriptorSpec.GetKeys() {
+		if key.GetType() != values.IntValue || key.(*values.Int).Value < 0 {
+			closeAll()
+			return values.NewVoid(), phpError.NewError("Uncaught ValueError: proc_open(): Argument #2 ($descriptor_spec) must be an integer indexed array")
+		}
+		descriptor := int(key.(*values.Int).Value)
+
+		element, _ := descriptorSpec.GetElement(key)
+		if element.GetType() != values.ArrayValue {
+			// TODO proc_open: Support streams as descriptor
+			closeAll()
+			return values.NewVoid(), phpError.NewError("Uncaught ValueError: proc_open(): Argument #2 ($descriptor_spec) must only contain arrays and streams")
+		}
+
+		childFile, parentStream, err := openDescriptor(element.(*values.Array), context.Interpreter.GetIni().GetInt("precision"))
+		if err != nil {
+			closeAll()
+			return values.NewVoid(), err
+		}
+		childFiles = append(childFiles, childFile)
+		if parentStream != nil {
+			process.pipes = append(process.pipes, parentStream)
+			if err := pipes.SetElement(key, context.Interpreter.NewResource("stream", parentStream)); err != nil {
+				closeAll()
+				return values.NewVoid(), err
+			}
+		}
+
+		switch descriptor {
+		case 0:
+			cmd.Stdin = childFile
+		case 1:
+			cmd.Stdout = childFile
+		case 2:
+			cmd.Stderr = childFile
+		default:
+			// Entry i of ExtraFiles becomes file descriptor 3+i
+			for len(cmd.ExtraFiles) < descriptor-2 {
+				cmd.ExtraFiles = append(cmd.ExtraFiles, nil)
+			}
+			cmd.ExtraFiles[descriptor-3] = childFile
+		}
+	}
+
+	if goErr := cmd.Start(); goErr != nil {
+		closeAll()
+		context.Interpreter.PrintError(phpError.NewWarning("proc_open(): Exec failed: %s in %s", goErr, context.Stmt.GetPosString()))
+		return values.NewBool(false), nil
+	}
+	for _, file := range childFiles {
+		file.Close()
+	}
+
+	// Will be set to an indexed array of file pointers that correspond to PHP's end of any pipes that are created.
+	if err := context.Interpreter.SetByRefArgument(context, 2, pipes); err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Returns a resource representing the process, which should be freed using proc_close() when you are finished with it.
+	return context.Interpreter.NewResource("process", process), nil
+}
+
+// Open the descriptor given as array (e.g. `["pipe", "r"]` or `["file", "/tmp/error.log", "a"]`).
+// Returns the file for the child process and for pipes the stream for the parent process.
+func openDescriptor(descriptor *values.Array, precision int64) (*GoOs.File, *stream.Stream, phpError.Error) {
+	getString := func(index int64) (string, bool) {
+		value, found := descriptor.GetElement(values.NewInt(index))
+		if !found {
+			return "", false
+		}
+		str, err := variableHandling.StrVal(value, precision)
+		return str, err == nil
+	}
+
+	descriptorType, found := getString(0)
+	if !found {
+		return nil, nil, phpError.NewError("Uncaught ValueError: proc_open(): Missing handle qualifier in array")
+	}
+
+	switch descriptorType {
+	case "pipe":
+		mode, found := getString(1)
+		if !found {
+			return nil, nil, phpError.NewError("Uncaught ValueError: proc_open(): Missing mode parameter for \"pipe\"")
+		}
+		reader, writer, goErr := GoOs.Pipe()
+		if goErr != nil {
+			return nil, nil, phpError.NewError("proc_open(): Unable to create pipe %s", goErr)
+		}
+		// The mode is given from the perspective of the child process
+		if strings.HasPrefix(mode, "r") {
+			return reader, stream.NewWriteStream(writer), nil
+		}
+		return writer, stream.NewReadStream(reader), nil
+
+	case "file":
+		filename, found := getString(1)
+		if !found {
+			return nil, nil, phpError.NewError("Uncaught ValueError: proc_open(): Missing file name parameter for \"file\"")
+		}
+		mode, found := getString(2)
+		if !found {
+			return nil, nil, phpError.NewError("Uncaught ValueError: proc_open(): Missing mode parameter for \"file\"")
+		}
+		flag := GoOs.O_RDONLY
+		switch strings.TrimSuffix(mode, "b") {
+		case "r":
+			flag = GoOs.O_RDONLY
+		case "r+":
+			flag = GoOs.O_RDWR
+		case "w":
+			flag = GoOs.O_WRONLY | GoOs.O_CREATE | GoOs.O_TRUNC
+		case "w+":
+			flag = GoOs.O_RDWR | GoOs.O_CREATE | GoOs.O_TRUNC
+		case "a":
+			flag = GoOs.O_WRONLY | GoOs.O_CREATE | GoOs.O_APPEND
+		case "a+":
+			flag = GoOs.O_RDWR | GoOs.O_CREATE | GoOs.O_APPEND
+		default:
+			return nil, nil, phpError.NewError("Uncaught ValueError: proc_open(): %s is not a valid mode for \"file\"", mode)
+		}
+		file, goErr := GoOs.OpenFile(filename, flag, 0666)
+		if goErr != nil {
+			return nil, nil, phpError.NewError("proc_open(): Failed to open %s: %s", filename, goErr)
+		}
+		return file, nil, nil
+
+	default:
+		return nil, nil, phpError.NewError("Uncaught ValueError: proc_open(): %s is not a valid descriptor spec/mode", descriptorType)
+	}
+}
+
+// -------------------------------------- shell_exec -------------------------------------- MARK: shell_exec
+
+func nativeFn_shell_exec(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.shell-exec.php
+
+	args, err := funcParamValidator.NewValidator("shell_exec").AddParam("$command", []string{"string"}, nil).Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	command, err := getCommand("shell_exec", args[0])
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	cmd := shellCommand(command)
+	cmd.Stdin = GoOs.Stdin
+	cmd.Stderr = GoOs.Stderr
+	stdout, goErr := cmd.Output()
+	if _, ok := getResultCode(goErr); !ok {
+		context.Interpreter.PrintError(phpError.NewWarning("shell_exec(): Unable to execute '%s' in %s", command, context.Stmt.GetPosString()))
+		return values.NewBool(false), nil
+	}
+
+	// A string containing the output from the executed command,
+	// false if the pipe cannot be established or null if an error occurs or the command produces no output.
+	if len(stdout) == 0 {
+		return values.NewNull(), nil
+	}
+	return values.NewStr(string(stdout)), nil
+}
+
+// -------------------------------------- system -------------------------------------- MARK: system
+
+func nativeFn_system(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.system.php
+
+	args, err := funcParamValidator.NewValidator("system").
+		AddParam("$command", []string{"string"}, nil).
+		AddParam("$result_code", []string{"mixed"}, values.NewNull()).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	command, err := getCommand("system", args[0])
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Execute the command and output the result line by line
+	resultCode, lastLine, ok := runAndPrintLines(command, context)
+	if !ok {
+		context.Interpreter.PrintError(phpError.NewWarning("system(): Unable to fork [%s] in %s", command, context.Stmt.GetPosString()))
+		return values.NewBool(false), nil
+	}
+
+	if err := context.Interpreter.SetByRefArgument(context, 1, values.NewInt(int64(resultCode))); err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Returns the last line of the command output on success, and false on failure.
+	return values.NewStr(strings.TrimRight(lastLine, " \t\n\r\v\f")), nil
+}
+
+// -------------------------------------- helpers -------------------------------------- MARK: helpers
+
+func getCommand(functionName string, command values.RuntimeValue) (string, phpError.Error) {
+	commandStr := command.(*values.Str).Value
+	if commandStr == "" {
+		return "", phpError.NewError("Uncaught ValueError: %s(): Argument #1 ($command) cannot be empty", functionName)
+	}
+	if strings.Contains(commandStr, "\x00") {
+		return "", phpError.NewError("Uncaught ValueError: %s(): Argument #1 ($command) must not contain any null bytes", functionName)
+	}
+	return commandStr, nil
+}
+
+// Create a command that is executed by the shell of the operating system
+func shellCommand(command string) *exec.Cmd {
+	if os.OS == "Windows" {
+		return exec.Command("cmd", "/c", command)
+	}
+	return exec.Command("/bin/sh", "-c", command)
+}
+
+// Get the exit code of an executed command. Returns false if the command could not be executed.
+func getResultCode(err error) (int, bool) {
+	if err == nil {
+		return 0, true
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), true
+	}
+	return -1, false
+}
+
+// Split the output into lines without the trailing empty line
+func splitLines(output string) []string {
+	if output == "" {
+		return []string{}
+	}
+	return strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+}
+
+// Run the command and print each line of the output as soon as it is available.
+// Returns the exit code and the last line of the output.
+func runAndPrintLines(command string, context runtime.Context) (int, string, bool) {
+	cmd := shellCommand(command)
+	cmd.Stdin = GoOs.Stdin
+	cmd.Stderr = GoOs.Stderr
+	stdout, goErr := cmd.StdoutPipe()
+	if goErr != nil {
+		return -1, "", false
+	}
+	if goErr := cmd.Start(); goErr != nil {
+		return -1, "", false
+	}
+
+	lastLine := ""
+	reader := bufio.NewReader(stdout)
+	for {
+		line, goErr := reader.ReadString('\n')
+		if line != "" {
+			context.Interpreter.Print(line)
+			lastLine = line
+		}
+		if goErr == io.EOF {
+			break
+		}
+		if goErr != nil {
+			cmd.Wait()
+			return -1, lastLine, false
+		}
+	}
+
+	resultCode, ok := getResultCode(cmd.Wait())
+	return resultCode, lastLine, ok
+}
@@ -0,0 +1,77 @@
+package random
+
+import (
+	"QIQ/cmd/qiq/phpError"
+	"QIQ/cmd/qiq/runtime"
+	"QIQ/cmd/qiq/runtime/funcParamValidator"
+	"QIQ/cmd/qiq/runtime/values"
+	"crypto/rand"
+	"math/big"
+)
+
+func Register(environment runtime.Environment) {
+	// Category: Random Functions
+	environment.AddNativeFunction("random_bytes", nativeFn_random_bytes)
+	environment.AddNativeFunction("random_int", nativeFn_random_int)
+}
+
+// -------------------------------------- random_bytes -------------------------------------- MARK: random_bytes
+
+func nativeFn_random_bytes(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.random-bytes.php
+
+	args, err := funcParamValidator.NewValidator("random_bytes").AddParam("$length", []string{"int"}, nil).Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	length := args[0].(*values.Int).Value
+	if length < 1 {
+		return values.NewVoid(), phpError.NewError("Uncaught ValueError: random_bytes(): Argument #1 ($length) must be greater than 0")
+	}
+
+	bytes := make([]byte, length)
+	if _, goErr := rand.Read(bytes); goErr != nil {
+		return values.NewVoid(), phpError.NewError("Uncaught Random\\RandomException: Cannot open source device")
+	}
+	return values.NewStr(string(bytes)), nil
+}
+
+// -------------------------------------- random_int -------------------------------------- MARK: random_int
+
+func nativeFn_random_int(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.random-int.php
+
+	args, err := funcParamValidator.NewValidator("random_int").
+		AddParam("$min", []string{"int"}, nil).
+		AddParam("$max", []string{"int"}, nil).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	min := args[0].(*values.Int).Value
+	max := args[1].(*values.Int).Value
+	if min > max {
+		return values.NewVoid(), phpError.NewError(
+			"Uncaught ValueError: random_int(): Argument #1 ($min) must be less than or equal to argument #2 ($max)",
+		)
+	}
+
+	// The size of the range can exceed int64 (e.g. PHP_INT_MIN to PHP_INT_MAX)
+	size := new(big.Int).Sub(big.NewInt(max), big.NewInt(min))
+	size.Add(size, big.NewInt(1))
+	offset, goErr := rand.Int(rand.Reader, size)
+	if goErr != nil {
+		return values.NewVoid(), phpError.NewError("Uncaught Random\\RandomException: Cannot open source device")
+	}
+	return values.NewInt(offset.Add(offset, big.NewInt(min)).Int64()), nil
+}
+
+// TODO getrandmax
+// TODO lcg_value
+// TODO mt_getrandmax
+// TODO mt_rand
+// TODO mt_srand
+// TODO rand
+// TODO srand
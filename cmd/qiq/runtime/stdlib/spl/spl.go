@@ -0,0 +1,43 @@
+package spl
+
+import (
+	"QIQ/cmd/qiq/phpError"
+	"QIQ/cmd/qiq/runtime"
+	"QIQ/cmd/qiq/runtime/funcParamValidator"
+	"QIQ/cmd/qiq/runtime/values"
+	"fmt"
+)
+
+func Register(environment runtime.Environment) {
+	// Category: SPL Functions
+	environment.AddNativeFunction("spl_object_hash", nativeFn_spl_object_hash)
+	environment.AddNativeFunction("spl_object_id", nativeFn_spl_object_id)
+}
+
+// -------------------------------------- spl_object_hash -------------------------------------- MARK: spl_object_hash
+
+func nativeFn_spl_object_hash(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	args, err := funcParamValidator.NewValidator("spl_object_hash").AddParam("$object", []string{"object"}, nil).Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Spec: https://www.php.net/manual/en/function.spl-object-hash.php
+	// This function returns a unique identifier for the object.
+	// This id can be used as a hash key for storing objects, or for identifying an object, as long as the object is not destroyed.
+	return values.NewStr(fmt.Sprintf("%016x%016x", args[0].(*values.Object).Handle, 0)), nil
+}
+
+// -------------------------------------- spl_object_id -------------------------------------- MARK: spl_object_id
+
+func nativeFn_spl_object_id(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	args, err := funcParamValidator.NewValidator("spl_object_id").AddParam("$object", []string{"object"}, nil).Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Spec: https://www.php.net/manual/en/function.spl-object-id.php
+	// This function returns a unique identifier for the object. The object id is unique for the lifetime of the object.
+	// Once the object is destroyed, its id may be reused for other objects. This behavior is similar to spl_object_hash().
+	return values.NewInt(args[0].(*values.Object).Handle), nil
+}
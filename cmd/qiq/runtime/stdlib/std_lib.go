@@ -8,10 +8,19 @@ import (
 	"QIQ/cmd/qiq/runtime/stdlib/errorHandling"
 	"QIQ/cmd/qiq/runtime/stdlib/filesystem"
 	"QIQ/cmd/qiq/runtime/stdlib/functionHandling"
+	"QIQ/cmd/qiq/runtime/stdlib/hash"
+	"QIQ/cmd/qiq/runtime/stdlib/json"
 	"QIQ/cmd/qiq/runtime/stdlib/math"
+	"QIQ/cmd/qiq/runtime/stdlib/mbstring"
 	"QIQ/cmd/qiq/runtime/stdlib/misc"
+	"QIQ/cmd/qiq/runtime/stdlib/opcache"
 	"QIQ/cmd/qiq/runtime/stdlib/optionsInfo"
 	"QIQ/cmd/qiq/runtime/stdlib/outputControl"
+	"QIQ/cmd/qiq/runtime/stdlib/password"
+	"QIQ/cmd/qiq/runtime/stdlib/pcre"
+	"QIQ/cmd/qiq/runtime/stdlib/programExecution"
+	"QIQ/cmd/qiq/runtime/stdlib/random"
+	"QIQ/cmd/qiq/runtime/stdlib/spl"
 	"QIQ/cmd/qiq/runtime/stdlib/strings"
 	"QIQ/cmd/qiq/runtime/stdlib/variableHandling"
 )
@@ -23,10 +32,19 @@ func Register(environment runtime.Environment) {
 	errorHandling.Register(environment)
 	filesystem.Register(environment)
 	functionHandling.Register(environment)
+	hash.Register(environment)
+	json.Register(environment)
 	math.Register(environment)
+	mbstring.Register(environment)
 	misc.Register(environment)
+	opcache.Register(environment)
 	optionsInfo.Register(environment)
 	outputControl.Register(environment)
+	password.Register(environment)
+	pcre.Register(environment)
+	programExecution.Register(environment)
+	random.Register(environment)
+	spl.Register(environment)
 	strings.Register(environment)
 	variableHandling.Register(environment)
 }
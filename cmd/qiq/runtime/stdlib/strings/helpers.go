@@ -0,0 +1,191 @@
+package strings
+
+import (
+	"QIQ/cmd/qiq/common"
+	"QIQ/cmd/qiq/phpError"
+	"QIQ/cmd/qiq/runtime"
+	"QIQ/cmd/qiq/runtime/stdlib/variableHandling"
+	"QIQ/cmd/qiq/runtime/values"
+	"encoding/hex"
+)
+
+// Convert a value to string with the precision of the current ini
+func toString(value values.RuntimeValue, context runtime.Context) (string, phpError.Error) {
+	return variableHandling.StrVal(value, context.Interpreter.GetIni().GetInt("precision"))
+}
+
+// Convert the ASCII letters of the string to lowercase
+func asciiToLower(str string) string {
+	bytes := []byte(str)
+	for index, char := range bytes {
+		if char >= 'A' && char <= 'Z' {
+			bytes[index] = char + 32
+		}
+	}
+	return string(bytes)
+}
+
+// Convert the ASCII letters of the string to uppercase
+func asciiToUpper(str string) string {
+	bytes := []byte(str)
+	for index, char := range bytes {
+		if char >= 'a' && char <= 'z' {
+			bytes[index] = char - 32
+		}
+	}
+	return string(bytes)
+}
+
+// Convert an ASCII letter to uppercase
+func upperByte(char byte) byte {
+	if char >= 'a' && char <= 'z' {
+		return char - 32
+	}
+	return char
+}
+
+// Convert an ASCII letter to lowercase
+func lowerByte(char byte) byte {
+	if char >= 'A' && char <= 'Z' {
+		return char + 32
+	}
+	return char
+}
+
+func isAsciiAlpha(char byte) bool {
+	return (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z')
+}
+
+func isAsciiDigit(char byte) bool {
+	return char >= '0' && char <= '9'
+}
+
+func isHexDigit(char byte) bool {
+	return isAsciiDigit(char) || (char >= 'a' && char <= 'f') || (char >= 'A' && char <= 'F')
+}
+
+func hexValue(char byte) byte {
+	switch {
+	case char >= 'a':
+		return char - 'a' + 10
+	case char >= 'A':
+		return char - 'A' + 10
+	default:
+		return char - '0'
+	}
+}
+
+// Whitespace as defined by the C function isspace
+func isSpace(char byte) bool {
+	return char == ' ' || (char >= '\t' && char <= '\r')
+}
+
+// Get the byte at the given index or 0 if the index is out of range (like reading a C string)
+func byteAt(str string, index int) byte {
+	if index < 0 || index >= len(str) {
+		return 0
+	}
+	return str[index]
+}
+
+// Compare the strings and return -1, 0 or 1
+func compareStrings(lhs string, rhs string) int64 {
+	switch {
+	case lhs < rhs:
+		return -1
+	case lhs > rhs:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Create a mask of the given characters. Ranges like "a..z" are supported.
+// Invalid ranges emit a warning and are ignored.
+func charMask(funcName string, characters string, context runtime.Context) [256]bool {
+	// Spec: https://www.php.net/manual/en/function.trim.php
+	var mask [256]bool
+	for index := 0; index < len(characters); index++ {
+		char := characters[index]
+		if index+3 < len(characters) && characters[index+1] == '.' && characters[index+2] == '.' && characters[index+3] >= char {
+			for rangeChar := int(char); rangeChar <= int(characters[index+3]); rangeChar++ {
+				mask[rangeChar] = true
+			}
+			index += 3
+			continue
+		}
+		if index+1 < len(characters) && char == '.' && characters[index+1] == '.' {
+			var message string
+			switch {
+			case index == 0:
+				message = "no character to the left of '..'"
+			case index+2 >= len(characters):
+				message = "no character to the right of '..'"
+			case characters[index-1] > characters[index+2]:
+				message = "'..'-range needs to be incrementing"
+			}
+			if message == "" {
+				context.Interpreter.PrintError(phpError.NewWarning("%s(): Invalid '..'-range in %s", funcName, context.Stmt.GetPosString()))
+			} else {
+				context.Interpreter.PrintError(phpError.NewWarning("%s(): Invalid '..'-range, %s in %s", funcName, message, context.Stmt.GetPosString()))
+			}
+			continue
+		}
+		mask[char] = true
+	}
+	return mask
+}
+
+// Trim the characters of the mask from the start (mode 1), the end (mode 2) or both sides (mode 3) of the string
+func trimMask(str string, mask [256]bool, mode int) string {
+	start, end := 0, len(str)
+	if mode&1 != 0 {
+		for start < end && mask[str[start]] {
+			start++
+		}
+	}
+	if mode&2 != 0 {
+		for end > start && mask[str[end-1]] {
+			end--
+		}
+	}
+	return str[start:end]
+}
+
+// Resolve a possibly negative offset and length like substr does.
+// The returned bounds are always within the string.
+func substrBounds(length int, offset int64, sublength int64, hasLength bool) (int, int) {
+	if offset > int64(length) {
+		return length, length
+	}
+	if offset < 0 {
+		offset = max(int64(length)+offset, 0)
+	}
+	remaining := int64(length) - offset
+	if !hasLength || sublength > remaining {
+		sublength = remaining
+	} else if sublength < 0 {
+		sublength = max(remaining+sublength, 0)
+	}
+	return int(offset), int(offset + sublength)
+}
+
+// Read the file for the hashing functions. Prints a warning if the file does not exist.
+func readFile(funcName string, filename string, context runtime.Context) (string, bool) {
+	if !common.PathExists(filename) {
+		context.Interpreter.PrintError(phpError.NewWarning(
+			"%s(%s): Failed to open stream: No such file or directory in %s", funcName, filename, context.Stmt.GetPosString(),
+		))
+		return "", false
+	}
+	content, err := common.ReadFile(filename)
+	return content, err == nil
+}
+
+// Get the digest as raw binary string or as lowercase hexits
+func digestToStr(digest []byte, binary bool) *values.Str {
+	if binary {
+		return values.NewStr(string(digest))
+	}
+	return values.NewStr(hex.EncodeToString(digest))
+}
@@ -0,0 +1,472 @@
+package strings
+
+import (
+	"QIQ/cmd/qiq/phpError"
+	"QIQ/cmd/qiq/runtime"
+	"QIQ/cmd/qiq/runtime/values"
+	"html"
+	"slices"
+	goStrings "strings"
+	"unicode/utf8"
+)
+
+const (
+	HTML_SPECIALCHARS int64 = 0
+	HTML_ENTITIES     int64 = 1
+	ENT_NOQUOTES      int64 = 0
+	ENT_COMPAT        int64 = 2
+	ENT_QUOTES        int64 = 3
+	ENT_IGNORE        int64 = 4
+	ENT_SUBSTITUTE    int64 = 8
+	ENT_DISALLOWED    int64 = 128
+	ENT_HTML401       int64 = 0
+	ENT_XML1          int64 = 16
+	ENT_XHTML         int64 = 32
+	ENT_HTML5         int64 = 48
+)
+
+const (
+	// Bit of ENT_QUOTES that enables the conversion of single quotes
+	entQuoteSingle int64 = 1
+	// Bit of ENT_QUOTES that enables the conversion of double quotes
+	entQuoteDouble int64 = 2
+	// Bits of the flags that select the document type
+	entDocTypeMask int64 = ENT_HTML5
+)
+
+type htmlEntity struct {
+	codepoint rune
+	name      string
+}
+
+// The named character references of HTML 4.01 ordered by code point
+// Spec: https://www.w3.org/TR/html401/sgml/entities.html
+var html401Entities = []htmlEntity{
+	{34, "quot"}, {38, "amp"}, {60, "lt"}, {62, "gt"},
+	{160, "nbsp"}, {161, "iexcl"}, {162, "cent"}, {163, "pound"}, {164, "curren"}, {165, "yen"}, {166, "brvbar"}, {167, "sect"},
+	{168, "uml"}, {169, "copy"}, {170, "ordf"}, {171, "laquo"}, {172, "not"}, {173, "shy"}, {174, "reg"}, {175, "macr"},
+	{176, "deg"}, {177, "plusmn"}, {178, "sup2"}, {179, "sup3"}, {180, "acute"}, {181, "micro"}, {182, "para"}, {183, "middot"},
+	{184, "cedil"}, {185, "sup1"}, {186, "ordm"}, {187, "raquo"}, {188, "frac14"}, {189, "frac12"}, {190, "frac34"}, {191, "iquest"},
+	{192, "Agrave"}, {193, "Aacute"}, {194, "Acirc"}, {195, "Atilde"}, {196, "Auml"}, {197, "Aring"}, {198, "AElig"}, {199, "Ccedil"},
+	{200, "Egrave"}, {201, "Eacute"}, {202, "Ecirc"}, {203, "Euml"}, {204, "Igrave"}, {205, "Iacute"}, {206, "Icirc"}, {207, "Iuml"},
+	{208, "ETH"}, {209, "Ntilde"}, {210, "Ograve"}, {211, "Oacute"}, {212, "Ocirc"}, {213, "Otilde"}, {214, "Ouml"}, {215, "times"},
+	{216, "Oslash"}, {217, "Ugrave"}, {218, "Uacute"}, {219, "Ucirc"}, {220, "Uuml"}, {221, "Yacute"}, {222, "THORN"}, {223, "szlig"},
+	{224, "agrave"}, {225, "aacute"}, {226, "acirc"}, {227, "atilde"}, {228, "auml"}, {229, "aring"}, {230, "aelig"}, {231, "ccedil"},
+	{232, "egrave"}, {233, "eacute"}, {234, "ecirc"}, {235, "euml"}, {236, "igrave"}, {237, "iacute"}, {238, "icirc"}, {239, "iuml"},
+	{240, "eth"}, {241, "ntilde"}, {242, "ograve"}, {243, "oacute"}, {244, "ocirc"}, {245, "otilde"}, {246, "ouml"}, {247, "divide"},
+	{248, "oslash"}, {249, "ugrave"}, {250, "uacute"}, {251, "ucirc"}, {252, "uuml"}, {253, "yacute"}, {254, "thorn"}, {255, "yuml"},
+	{338, "OElig"}, {339, "oelig"}, {352, "Scaron"}, {353, "scaron"}, {376, "Yuml"}, {402, "fnof"}, {710, "circ"}, {732, "tilde"},
+	{913, "Alpha"}, {914, "Beta"}, {915, "Gamma"}, {916, "Delta"}, {917, "Epsilon"}, {918, "Zeta"}, {919, "Eta"}, {920, "Theta"},
+	{921, "Iota"}, {922, "Kappa"}, {923, "Lambda"}, {924, "Mu"}, {925, "Nu"}, {926, "Xi"}, {927, "Omicron"}, {928, "Pi"},
+	{929, "Rho"}, {931, "Sigma"}, {932, "Tau"}, {933, "Upsilon"}, {934, "Phi"}, {935, "Chi"}, {936, "Psi"}, {937, "Omega"},
+	{945, "alpha"}, {946, "beta"}, {947, "gamma"}, {948, "delta"}, {949, "epsilon"}, {950, "zeta"}, {951, "eta"}, {952, "theta"},
+	{953, "iota"}, {954, "kappa"}, {955, "lambda"}, {956, "mu"}, {957, "nu"}, {958, "xi"}, {959, "omicron"}, {960, "pi"},
+	{961, "rho"}, {962, "sigmaf"}, {963, "sigma"}, {964, "tau"}, {965, "upsilon"}, {966, "phi"}, {967, "chi"}, {968, "psi"},
+	{969, "omega"}, {977, "thetasym"}, {978, "upsih"}, {982, "piv"},
+	{8194, "ensp"}, {8195, "emsp"}, {8201, "thinsp"}, {8204, "zwnj"}, {8205, "zwj"}, {8206, "lrm"}, {8207, "rlm"}, {8211, "ndash"},
+	{8212, "mdash"}, {8216, "lsquo"}, {8217, "rsquo"}, {8218, "sbquo"}, {8220, "ldquo"}, {8221, "rdquo"}, {8222, "bdquo"}, {8224, "dagger"},
+	{8225, "Dagger"}, {8226, "bull"}, {8230, "hellip"}, {8240, "permil"}, {8242, "prime"}, {8243, "Prime"}, {8249, "lsaquo"}, {8250, "rsaquo"},
+	{8254, "oline"}, {8260, "frasl"}, {8364, "euro"}, {8465, "image"}, {8472, "weierp"}, {8476, "real"}, {8482, "trade"}, {8501, "alefsym"},
+	{8592, "larr"}, {8593, "uarr"}, {8594, "rarr"}, {8595, "darr"}, {8596, "harr"}, {8629, "crarr"}, {8656, "lArr"}, {8657, "uArr"},
+	{8658, "rArr"}, {8659, "dArr"}, {8660, "hArr"}, {8704, "forall"}, {8706, "part"}, {8707, "exist"}, {8709, "empty"}, {8711, "nabla"},
+	{8712, "isin"}, {8713, "notin"}, {8715, "ni"}, {8719, "prod"}, {8721, "sum"}, {8722, "minus"}, {8727, "lowast"}, {8730, "radic"},
+	{8733, "prop"}, {8734, "infin"}, {8736, "ang"}, {8743, "and"}, {8744, "or"}, {8745, "cap"}, {8746, "cup"}, {8747, "int"},
+	{8756, "there4"}, {8764, "sim"}, {8773, "cong"}, {8776, "asymp"}, {8800, "ne"}, {8801, "equiv"}, {8804, "le"}, {8805, "ge"},
+	{8834, "sub"}, {8835, "sup"}, {8836, "nsub"}, {8838, "sube"}, {8839, "supe"}, {8853, "oplus"}, {8855, "otimes"}, {8869, "perp"},
+	{8901, "sdot"}, {8968, "lceil"}, {8969, "rceil"}, {8970, "lfloor"}, {8971, "rfloor"}, {9001, "lang"}, {9002, "rang"}, {9674, "loz"},
+	{9824, "spades"}, {9827, "clubs"}, {9829, "hearts"}, {9830, "diams"},
+}
+
+var (
+	html401EntityNames      = map[rune]string{}
+	html401EntityCodepoints = map[string]rune{}
+)
+
+func init() {
+	for _, entity := range html401Entities {
+		html401EntityNames[entity.codepoint] = entity.name
+		html401EntityCodepoints[entity.name] = entity.codepoint
+	}
+}
+
+// -------------------------------------- Charsets -------------------------------------- MARK: Charsets
+
+type htmlCharset int
+
+const (
+	charsetUtf8 htmlCharset = iota
+	charsetIso88591
+	charsetCp1252
+)
+
+// Code points of the bytes 0x80 to 0x9F in Windows-1252. Undefined bytes are mapped to themselves.
+var cp1252Codepoints = [32]rune{
+	0x20AC, 0x81, 0x201A, 0x0192, 0x201E, 0x2026, 0x2020, 0x2021, 0x02C6, 0x2030, 0x0160, 0x2039, 0x0152, 0x8D, 0x017D, 0x8F,
+	0x90, 0x2018, 0x2019, 0x201C, 0x201D, 0x2022, 0x2013, 0x2014, 0x02DC, 0x2122, 0x0161, 0x203A, 0x0153, 0x9D, 0x017E, 0x0178,
+}
+
+// Get the charset of the encoding argument. If the encoding is null or empty, the ini setting "default_charset" is used.
+func getHtmlCharset(funcName string, encoding values.RuntimeValue, context runtime.Context) htmlCharset {
+	name := ""
+	if encoding != nil && encoding.GetType() == values.StrValue {
+		name = encoding.(*values.Str).Value
+	}
+	if name == "" {
+		name = context.Interpreter.GetIni().GetStr("default_charset")
+	}
+
+	switch asciiToLower(name) {
+	case "", "utf-8", "utf8":
+		return charsetUtf8
+	case "iso-8859-1", "iso8859-1", "latin1":
+		return charsetIso88591
+	case "windows-1252", "cp1252", "1252":
+		return charsetCp1252
+	default:
+		// TODO Support the other charsets (e.g. ISO-8859-15, cp866, KOI8-R, BIG5, Shift_JIS)
+		context.Interpreter.PrintError(phpError.NewWarning(
+			"%s(): Charset \"%s\" is not supported, assuming UTF-8 in %s", funcName, name, context.Stmt.GetPosString(),
+		))
+		return charsetUtf8
+	}
+}
+
+// Decode the character at the start of the string.
+// Returns the code point and the number of bytes or ok=false if the byte sequence is invalid.
+func decodeChar(str string, charset htmlCharset) (rune, int, bool) {
+	switch charset {
+	case charsetUtf8:
+		char, size := utf8.DecodeRuneInString(str)
+		return char, size, char != utf8.RuneError || size > 1
+	case charsetCp1252:
+		if str[0] >= 0x80 && str[0] <= 0x9F {
+			return cp1252Codepoints[str[0]-0x80], 1, true
+		}
+		return rune(str[0]), 1, true
+	default:
+		return rune(str[0]), 1, true
+	}
+}
+
+// Encode the code point in the charset. Returns ok=false if the code point is not representable.
+func encodeChar(codepoint rune, charset htmlCharset) (string, bool) {
+	switch charset {
+	case charsetUtf8:
+		return string(codepoint), true
+	case charsetCp1252:
+		if codepoint >= 0x80 && codepoint <= 0x9F {
+			return "", false
+		}
+		for index, cp1252Codepoint := range cp1252Codepoints {
+			if cp1252Codepoint == codepoint {
+				return string([]byte{byte(0x80 + index)}), true
+			}
+		}
+		if codepoint > 0xFF {
+			return "", false
+		}
+		return string([]byte{byte(codepoint)}), true
+	default:
+		if codepoint > 0xFF {
+			return "", false
+		}
+		return string([]byte{byte(codepoint)}), true
+	}
+}
+
+// -------------------------------------- Entities -------------------------------------- MARK: Entities
+
+// Check if the code point may appear in a document of the given type
+func isAllowedCodepoint(codepoint rune, docType int64) bool {
+	isNonCharacter := codepoint&0xFFFF >= 0xFFFE || (codepoint >= 0xFDD0 && codepoint <= 0xFDEF)
+	switch docType {
+	case ENT_HTML401:
+		return (codepoint >= 0x20 && codepoint <= 0x7E) ||
+			codepoint == 0x0A || codepoint == 0x09 || codepoint == 0x0D ||
+			(codepoint >= 0xA0 && codepoint <= 0xD7FF) ||
+			(codepoint >= 0xE000 && codepoint <= 0x10FFFF && !isNonCharacter)
+	case ENT_HTML5:
+		return (codepoint >= 0x20 && codepoint <= 0x7E) ||
+			(codepoint >= 0x09 && codepoint <= 0x0D && codepoint != 0x0B) ||
+			(codepoint >= 0xA0 && codepoint <= 0xD7FF) ||
+			(codepoint >= 0xE000 && codepoint <= 0x10FFFF && !isNonCharacter)
+	default:
+		return (codepoint >= 0x20 && codepoint <= 0xD7FF) ||
+			codepoint == 0x0A || codepoint == 0x09 || codepoint == 0x0D ||
+			(codepoint >= 0xE000 && codepoint <= 0x10FFFF && codepoint != 0xFFFE && codepoint != 0xFFFF)
+	}
+}
+
+// Get the entity name of the single quote for the document type
+func singleQuoteEntity(docType int64) string {
+	if docType == ENT_HTML401 {
+		return "#039"
+	}
+	return "apos"
+}
+
+// Get the entity name of a character that is converted by htmlspecialchars
+func basicEntityName(codepoint rune, flags int64) (string, bool) {
+	switch codepoint {
+	case '&':
+		return "amp", true
+	case '<':
+		return "lt", true
+	case '>':
+		return "gt", true
+	case '"':
+		return "quot", flags&entQuoteDouble != 0
+	case '\'':
+		return singleQuoteEntity(flags & entDocTypeMask), flags&entQuoteSingle != 0
+	default:
+		return "", false
+	}
+}
+
+// Get the entity name of a character that is converted by htmlentities
+func entityName(codepoint rune, flags int64) (string, bool) {
+	if name, ok := basicEntityName(codepoint, flags); ok || codepoint == '"' || codepoint == '\'' {
+		return name, ok
+	}
+	if flags&entDocTypeMask == ENT_XML1 {
+		return "", false
+	}
+	// TODO htmlentities - Use the table of the HTML 5 named character references for ENT_HTML5
+	name, ok := html401EntityNames[codepoint]
+	return name, ok
+}
+
+// Resolve a named entity (without "&" and ";") for the document type.
+// The result contains one or (only for HTML 5) two code points.
+func resolveNamedEntity(name string, docType int64, all bool) ([]rune, bool) {
+	if !all {
+		switch name {
+		case "amp":
+			return []rune{'&'}, true
+		case "lt":
+			return []rune{'<'}, true
+		case "gt":
+			return []rune{'>'}, true
+		case "quot":
+			return []rune{'"'}, true
+		case "apos":
+			return []rune{'\''}, docType != ENT_HTML401
+		}
+		return nil, false
+	}
+
+	switch docType {
+	case ENT_HTML401, ENT_XHTML:
+		if codepoint, ok := html401EntityCodepoints[name]; ok {
+			return []rune{codepoint}, true
+		}
+		return []rune{'\''}, docType == ENT_XHTML && name == "apos"
+	case ENT_HTML5:
+		// The decoding of Go also accepts some legacy entities without semicolon (e.g. "&notin;" -> "¬in;")
+		decoded := html.UnescapeString("&" + name + ";")
+		if decoded == "&"+name+";" || (goStrings.HasSuffix(decoded, ";") && name != "semi") {
+			return nil, false
+		}
+		return []rune(decoded), true
+	default:
+		return resolveNamedEntity(name, docType, false)
+	}
+}
+
+// Parse a numeric entity (e.g. "#65;" or "#x41;") at the start of the string (after the "&").
+// Returns the code point and the length of the entity without the "&".
+func parseNumericEntity(str string) (rune, int, bool) {
+	index := 1
+	base := 10
+	if byteAt(str, index) == 'x' || byteAt(str, index) == 'X' {
+		base = 16
+		index++
+	}
+	start := index
+	codepoint := 0
+	for index < len(str) && ((base == 10 && isAsciiDigit(str[index])) || (base == 16 && isHexDigit(str[index]))) {
+		codepoint = min(codepoint*base+int(hexValue(str[index])), 0x110000)
+		index++
+	}
+	if index == start || byteAt(str, index) != ';' || codepoint > 0x10FFFF {
+		return 0, 0, false
+	}
+	return rune(codepoint), index + 1, true
+}
+
+// Parse a named entity (e.g. "amp;") at the start of the string (after the "&").
+// Returns the name and the length of the entity without the "&".
+func parseNamedEntity(str string) (string, int, bool) {
+	index := 0
+	for index < len(str) && (isAsciiAlpha(str[index]) || isAsciiDigit(str[index])) {
+		index++
+	}
+	if index == 0 || byteAt(str, index) != ';' {
+		return "", 0, false
+	}
+	return str[:index], index + 1, true
+}
+
+// Convert special characters (or if all is set all characters with a named entity) to HTML entities
+func htmlEncode(str string, flags int64, charset htmlCharset, doubleEncode bool, all bool) string {
+	docType := flags & entDocTypeMask
+	replacement := "�"
+	if charset != charsetUtf8 {
+		replacement = "&#xFFFD;"
+	}
+
+	var result goStrings.Builder
+	for index := 0; index < len(str); {
+		codepoint, size, ok := decodeChar(str[index:], charset)
+		char := str[index : index+size]
+		index += size
+
+		if !ok {
+			switch {
+			case flags&ENT_IGNORE != 0:
+				continue
+			case flags&ENT_SUBSTITUTE != 0:
+				result.WriteString(replacement)
+				continue
+			default:
+				return ""
+			}
+		}
+
+		if codepoint == '&' && !doubleEncode {
+			// Existing valid entities are not encoded again
+			if byteAt(str, index) == '#' {
+				if entityCodepoint, length, ok := parseNumericEntity(str[index:]); ok &&
+					(flags&ENT_DISALLOWED == 0 || isAllowedNumericEntity(entityCodepoint, docType)) {
+					result.WriteString("&" + str[index:index+length])
+					index += length
+					continue
+				}
+			} else if name, length, ok := parseNamedEntity(str[index:]); ok {
+				if _, ok := resolveNamedEntity(name, docType, true); ok {
+					result.WriteString("&" + str[index:index+length])
+					index += length
+					continue
+				}
+			}
+		}
+
+		var name string
+		if all {
+			name, ok = entityName(codepoint, flags)
+		} else {
+			name, ok = basicEntityName(codepoint, flags)
+		}
+		if ok {
+			result.WriteString("&" + name + ";")
+			continue
+		}
+
+		if flags&ENT_DISALLOWED != 0 && codepoint != '"' && codepoint != '\'' && !isAllowedCodepoint(codepoint, docType) {
+			result.WriteString(replacement)
+			continue
+		}
+		result.WriteString(char)
+	}
+	return result.String()
+}
+
+// Check if a numeric entity is valid in the document type
+func isAllowedNumericEntity(codepoint rune, docType int64) bool {
+	switch docType {
+	case ENT_HTML401:
+		return codepoint <= 0x10FFFF
+	case ENT_HTML5:
+		return isAllowedCodepoint(codepoint, docType) || codepoint == 0x0D
+	default:
+		return isAllowedCodepoint(codepoint, docType)
+	}
+}
+
+// Convert HTML entities to their corresponding characters.
+// If all is not set, only the entities of htmlspecialchars are decoded.
+func htmlDecode(str string, flags int64, charset htmlCharset, all bool) string {
+	docType := flags & entDocTypeMask
+
+	var result goStrings.Builder
+	for index := 0; index < len(str); {
+		if str[index] != '&' || index+3 >= len(str) {
+			result.WriteByte(str[index])
+			index++
+			continue
+		}
+
+		var codepoints []rune
+		var length int
+		var ok bool
+		if str[index+1] == '#' {
+			var codepoint rune
+			codepoint, length, ok = parseNumericEntity(str[index+1:])
+			if ok && !all {
+				_, ok = basicEntityName(codepoint, ENT_QUOTES|ENT_XML1)
+			}
+			ok = ok && isAllowedCodepoint(codepoint, docType) && !(docType == ENT_HTML5 && codepoint == 0x0D)
+			codepoints = []rune{codepoint}
+		} else {
+			var name string
+			name, length, ok = parseNamedEntity(str[index+1:])
+			if ok {
+				codepoints, ok = resolveNamedEntity(name, docType, all)
+			}
+		}
+
+		if ok && len(codepoints) == 1 &&
+			((codepoints[0] == '\'' && flags&entQuoteSingle == 0) || (codepoints[0] == '"' && flags&entQuoteDouble == 0)) {
+			ok = false
+		}
+
+		var decoded string
+		for _, codepoint := range codepoints {
+			if !ok {
+				break
+			}
+			var char string
+			char, ok = encodeChar(codepoint, charset)
+			if charset != charsetUtf8 && len(codepoints) > 1 {
+				ok = false
+			}
+			decoded += char
+		}
+
+		if !ok {
+			result.WriteByte('&')
+			index++
+			continue
+		}
+		result.WriteString(decoded)
+		index += length + 1
+	}
+	return result.String()
+}
+
+// Get the translation table used by htmlspecialchars (or if all is set by htmlentities) ordered by code point
+func htmlTranslationTable(flags int64, charset htmlCharset, all bool) (*values.Array, phpError.Error) {
+	docType := flags & entDocTypeMask
+	entities := []htmlEntity{{'"', "quot"}, {'&', "amp"}, {'\'', singleQuoteEntity(docType)}, {'<', "lt"}, {'>', "gt"}}
+	if all && docType != ENT_XML1 {
+		// The single quote is not part of the HTML 4.01 table
+		entities = append(slices.Clone(html401Entities), htmlEntity{'\'', singleQuoteEntity(docType)})
+		slices.SortFunc(entities, func(lhs, rhs htmlEntity) int { return int(lhs.codepoint - rhs.codepoint) })
+	}
+
+	result := values.NewArray()
+	for _, entity := range entities {
+		name, ok := entityName(entity.codepoint, flags)
+		if !ok {
+			continue
+		}
+		char, ok := encodeChar(entity.codepoint, charset)
+		if !ok {
+			continue
+		}
+		if err := result.SetElement(values.NewStr(char), values.NewStr("&"+name+";")); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
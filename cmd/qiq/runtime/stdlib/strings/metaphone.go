@@ -0,0 +1,216 @@
+package strings
+
+import goStrings "strings"
+
+// Encoding flags of the letters A to Z used by the metaphone algorithm
+var metaphoneCodes = [26]byte{
+	1, 16, 4, 16, 9, 2, 4, 16, 9, 2, 0, 2, 2, 2, 1, 4, 0, 2, 4, 4, 1, 0, 0, 0, 8, 0,
+	// a b  c  d  e  f  g  h  i  j  k  l  m  n  o  p  q  r  s  t  u  v  w  x  y  z
+}
+
+const (
+	metaphoneVowel    byte = 1  // AEIOU
+	metaphoneAffectH  byte = 4  // CGPST
+	metaphoneMakeSoft byte = 8  // EIY
+	metaphoneNoGhToF  byte = 16 // BDH
+)
+
+func metaphoneHasCode(char byte, code byte) bool {
+	if !isAsciiAlpha(char) {
+		return false
+	}
+	return metaphoneCodes[upperByte(char)-'A']&code != 0
+}
+
+// Calculate the metaphone key of the word. A maxPhonemes of 0 means no limit.
+// This is a port of the implementation of PHP (ext/standard/metaphone.c).
+func metaphone(word string, maxPhonemes int) string {
+	letterAt := func(index int) byte { return upperByte(byteAt(word, index)) }
+	// Get the letter "howFar" positions ahead without going past the end of the word
+	lookAhead := func(index int, howFar int) byte {
+		offset := 0
+		for offset < howFar && byteAt(word, index+offset) != 0 {
+			offset++
+		}
+		return letterAt(index + offset)
+	}
+
+	var result goStrings.Builder
+	index := 0
+
+	// Skip leading non-alpha characters
+	for !isAsciiAlpha(letterAt(index)) {
+		if letterAt(index) == 0 {
+			return ""
+		}
+		index++
+	}
+
+	// Handle the prefixes
+	switch letterAt(index) {
+	case 'A':
+		// AE becomes E
+		if letterAt(index+1) == 'E' {
+			result.WriteByte('E')
+			index += 2
+		} else {
+			result.WriteByte('A')
+			index++
+		}
+	case 'G', 'K', 'P':
+		// [GKP]N becomes N
+		if letterAt(index+1) == 'N' {
+			result.WriteByte('N')
+			index += 2
+		}
+	case 'W':
+		// WR becomes R, WH and W followed by a vowel become W
+		if letterAt(index+1) == 'R' {
+			result.WriteByte('R')
+			index += 2
+		} else if letterAt(index+1) == 'H' || metaphoneHasCode(letterAt(index+1), metaphoneVowel) {
+			result.WriteByte('W')
+			index += 2
+		}
+	case 'X':
+		// X becomes S
+		result.WriteByte('S')
+		index++
+	case 'E', 'I', 'O', 'U':
+		// Vowels at the beginning are kept
+		result.WriteByte(letterAt(index))
+		index++
+	}
+
+	for ; letterAt(index) != 0 && (maxPhonemes == 0 || result.Len() < maxPhonemes); index++ {
+		current := letterAt(index)
+		next := letterAt(index + 1)
+		var previous, afterNext byte
+		if index >= 1 {
+			previous = letterAt(index - 1)
+		}
+		if next != 0 {
+			afterNext = letterAt(index + 2)
+		}
+		lookBack := func(n int) byte {
+			if index >= n {
+				return letterAt(index - n)
+			}
+			return 0
+		}
+
+		// Ignore non-alpha characters and drop duplicates except CC
+		if !isAsciiAlpha(current) || (current == previous && current != 'C') {
+			continue
+		}
+
+		skip := 0
+		switch current {
+		case 'B':
+			// B unless in MB
+			if previous != 'M' {
+				result.WriteByte('B')
+			}
+		case 'C':
+			if metaphoneHasCode(next, metaphoneMakeSoft) {
+				if next == 'I' && afterNext == 'A' {
+					// CIA
+					result.WriteByte('X')
+				} else if previous != 'S' {
+					// C[IEY] but not SC[IEY]
+					result.WriteByte('S')
+				}
+			} else if next == 'H' {
+				result.WriteByte('X')
+				skip++
+			} else {
+				result.WriteByte('K')
+			}
+		case 'D':
+			// J if in -DGE-, -DGI- or -DGY-, else T
+			if next == 'G' && metaphoneHasCode(afterNext, metaphoneMakeSoft) {
+				result.WriteByte('J')
+				skip++
+			} else {
+				result.WriteByte('T')
+			}
+		case 'G':
+			if next == 'H' {
+				// F if in -GH and not B--GH, D--GH, -H--GH, -H---GH, else silent
+				if !(metaphoneHasCode(lookBack(3), metaphoneNoGhToF) || lookBack(4) == 'H') {
+					result.WriteByte('F')
+					skip++
+				}
+			} else if next == 'N' {
+				// Dropped if -GNED or -GN
+				if isAsciiAlpha(afterNext) && !(afterNext == 'E' && lookAhead(index, 3) == 'D') {
+					result.WriteByte('K')
+				}
+			} else if metaphoneHasCode(next, metaphoneMakeSoft) && previous != 'G' {
+				// J if in -GE-, -GI or -GY and not GG
+				result.WriteByte('J')
+			} else {
+				result.WriteByte('K')
+			}
+		case 'H':
+			// H if before a vowel and not after C, G, P, S or T
+			if metaphoneHasCode(next, metaphoneVowel) && !metaphoneHasCode(previous, metaphoneAffectH) {
+				result.WriteByte('H')
+			}
+		case 'K':
+			// Dropped if after C
+			if previous != 'C' {
+				result.WriteByte('K')
+			}
+		case 'P':
+			if next == 'H' {
+				result.WriteByte('F')
+			} else {
+				result.WriteByte('P')
+			}
+		case 'Q':
+			result.WriteByte('K')
+		case 'S':
+			if next == 'I' && (afterNext == 'O' || afterNext == 'A') {
+				// SIO or SIA
+				result.WriteByte('X')
+			} else if next == 'H' {
+				result.WriteByte('X')
+				skip++
+			} else {
+				result.WriteByte('S')
+			}
+		case 'T':
+			if next == 'I' && (afterNext == 'O' || afterNext == 'A') {
+				// TIA or TIO
+				result.WriteByte('X')
+			} else if next == 'H' {
+				result.WriteByte('0')
+				skip++
+			} else if !(next == 'C' && afterNext == 'H') {
+				result.WriteByte('T')
+			}
+		case 'V':
+			result.WriteByte('F')
+		case 'W':
+			// W before a vowel, else dropped
+			if metaphoneHasCode(next, metaphoneVowel) {
+				result.WriteByte('W')
+			}
+		case 'X':
+			result.WriteString("KS")
+		case 'Y':
+			// Y if followed by a vowel
+			if metaphoneHasCode(next, metaphoneVowel) {
+				result.WriteByte('Y')
+			}
+		case 'Z':
+			result.WriteByte('S')
+		case 'F', 'J', 'L', 'M', 'N', 'R':
+			result.WriteByte(current)
+		}
+		index += skip
+	}
+
+	return result.String()
+}
@@ -1,31 +1,238 @@
 package strings
 
 import (
+	"QIQ/cmd/qiq/common"
 	"QIQ/cmd/qiq/phpError"
 	"QIQ/cmd/qiq/runtime"
+	"QIQ/cmd/qiq/runtime/format"
 	"QIQ/cmd/qiq/runtime/funcParamValidator"
+	"QIQ/cmd/qiq/runtime/stdlib/filesystem"
+	"QIQ/cmd/qiq/runtime/stdlib/password"
 	"QIQ/cmd/qiq/runtime/stdlib/variableHandling"
 	"QIQ/cmd/qiq/runtime/values"
+	"crypto/md5"
+	"crypto/sha1"
 	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strconv"
 	goStrings "strings"
 )
 
 func Register(environment runtime.Environment) {
 	// Category: String Functions
+	environment.AddNativeFunction("addcslashes", nativeFn_addcslashes)
+	environment.AddNativeFunction("addslashes", nativeFn_addslashes)
 	environment.AddNativeFunction("bin2hex", nativeFn_bin2hex)
+	environment.AddNativeFunction("chop", nativeFn_rtrim)
 	environment.AddNativeFunction("chr", nativeFn_chr)
+	environment.AddNativeFunction("chunk_split", nativeFn_chunk_split)
+	environment.AddNativeFunction("convert_uudecode", nativeFn_convert_uudecode)
+	environment.AddNativeFunction("convert_uuencode", nativeFn_convert_uuencode)
+	environment.AddNativeFunction("count_chars", nativeFn_count_chars)
+	environment.AddNativeFunction("crc32", nativeFn_crc32)
+	environment.AddNativeFunction("crypt", nativeFn_crypt)
+	environment.AddNativeFunction("explode", nativeFn_explode)
+	environment.AddNativeFunction("fprintf", nativeFn_fprintf)
+	environment.AddNativeFunction("get_html_translation_table", nativeFn_get_html_translation_table)
+	environment.AddNativeFunction("hex2bin", nativeFn_hex2bin)
+	environment.AddNativeFunction("html_entity_decode", nativeFn_html_entity_decode)
+	environment.AddNativeFunction("htmlentities", nativeFn_htmlentities)
+	environment.AddNativeFunction("htmlspecialchars", nativeFn_htmlspecialchars)
+	environment.AddNativeFunction("htmlspecialchars_decode", nativeFn_htmlspecialchars_decode)
 	environment.AddNativeFunction("implode", nativeFn_implode)
 	environment.AddNativeFunction("join", nativeFn_implode)
 	environment.AddNativeFunction("lcfirst", nativeFn_lcfirst)
+	environment.AddNativeFunction("levenshtein", nativeFn_levenshtein)
+	environment.AddNativeFunction("ltrim", nativeFn_ltrim)
+	environment.AddNativeFunction("md5", nativeFn_md5)
+	environment.AddNativeFunction("md5_file", nativeFn_md5_file)
+	environment.AddNativeFunction("metaphone", nativeFn_metaphone)
+	environment.AddNativeFunction("nl2br", nativeFn_nl2br)
+	environment.AddNativeFunction("number_format", nativeFn_number_format)
+	environment.AddNativeFunction("ord", nativeFn_ord)
+	environment.AddNativeFunction("parse_str", nativeFn_parse_str, 1)
+	environment.AddNativeFunction("printf", nativeFn_printf)
+	environment.AddNativeFunction("quoted_printable_decode", nativeFn_quoted_printable_decode)
+	environment.AddNativeFunction("quoted_printable_encode", nativeFn_quoted_printable_encode)
 	environment.AddNativeFunction("quotemeta", nativeFn_quotemeta)
+	environment.AddNativeFunction("rtrim", nativeFn_rtrim)
+	environment.AddNativeFunction("sha1", nativeFn_sha1)
+	environment.AddNativeFunction("sha1_file", nativeFn_sha1_file)
+	environment.AddNativeFunction("similar_text", nativeFn_similar_text, 2)
+	environment.AddNativeFunction("soundex", nativeFn_soundex)
+	environment.AddNativeFunction("sprintf", nativeFn_sprintf)
+	environment.AddNativeFunction("sscanf", nativeFn_sscanf, 2, runtime.VariadicByRef)
 	environment.AddNativeFunction("str_contains", nativeFn_str_contains)
+	environment.AddNativeFunction("str_decrement", nativeFn_str_decrement)
 	environment.AddNativeFunction("str_ends_with", nativeFn_str_ends_with)
+	environment.AddNativeFunction("str_getcsv", nativeFn_str_getcsv)
+	environment.AddNativeFunction("str_increment", nativeFn_str_increment)
+	environment.AddNativeFunction("str_ireplace", nativeFn_str_ireplace, 3)
+	environment.AddNativeFunction("str_pad", nativeFn_str_pad)
 	environment.AddNativeFunction("str_repeat", nativeFn_str_repeat)
+	environment.AddNativeFunction("str_replace", nativeFn_str_replace, 3)
+	environment.AddNativeFunction("str_rot13", nativeFn_str_rot13)
+	environment.AddNativeFunction("str_shuffle", nativeFn_str_shuffle)
+	environment.AddNativeFunction("str_split", nativeFn_str_split)
 	environment.AddNativeFunction("str_starts_with", nativeFn_str_starts_with)
+	environment.AddNativeFunction("str_word_count", nativeFn_str_word_count)
+	environment.AddNativeFunction("strcasecmp", nativeFn_strcasecmp)
+	environment.AddNativeFunction("strchr", nativeFn_strstr)
+	environment.AddNativeFunction("strcmp", nativeFn_strcmp)
+	environment.AddNativeFunction("strcoll", nativeFn_strcoll)
+	environment.AddNativeFunction("strcspn", nativeFn_strcspn)
+	environment.AddNativeFunction("strip_tags", nativeFn_strip_tags)
+	environment.AddNativeFunction("stripcslashes", nativeFn_stripcslashes)
+	environment.AddNativeFunction("stripos", nativeFn_stripos)
+	environment.AddNativeFunction("stripslashes", nativeFn_stripslashes)
+	environment.AddNativeFunction("stristr", nativeFn_stristr)
 	environment.AddNativeFunction("strlen", nativeFn_strlen)
+	environment.AddNativeFunction("strnatcasecmp", nativeFn_strnatcasecmp)
+	environment.AddNativeFunction("strnatcmp", nativeFn_strnatcmp)
+	environment.AddNativeFunction("strncasecmp", nativeFn_strncasecmp)
+	environment.AddNativeFunction("strncmp", nativeFn_strncmp)
+	environment.AddNativeFunction("strpbrk", nativeFn_strpbrk)
+	environment.AddNativeFunction("strpos", nativeFn_strpos)
+	environment.AddNativeFunction("strrchr", nativeFn_strrchr)
+	environment.AddNativeFunction("strrev", nativeFn_strrev)
+	environment.AddNativeFunction("strripos", nativeFn_strripos)
+	environment.AddNativeFunction("strrpos", nativeFn_strrpos)
+	environment.AddNativeFunction("strspn", nativeFn_strspn)
+	environment.AddNativeFunction("strstr", nativeFn_strstr)
+	environment.AddNativeFunction("strtok", nativeFn_strtok)
 	environment.AddNativeFunction("strtolower", nativeFn_strtolower)
 	environment.AddNativeFunction("strtoupper", nativeFn_strtoupper)
+	environment.AddNativeFunction("strtr", nativeFn_strtr)
+	environment.AddNativeFunction("substr", nativeFn_substr)
+	environment.AddNativeFunction("substr_compare", nativeFn_substr_compare)
+	environment.AddNativeFunction("substr_count", nativeFn_substr_count)
+	environment.AddNativeFunction("substr_replace", nativeFn_substr_replace)
+	environment.AddNativeFunction("trim", nativeFn_trim)
 	environment.AddNativeFunction("ucfirst", nativeFn_ucfirst)
+	environment.AddNativeFunction("ucwords", nativeFn_ucwords)
+	environment.AddNativeFunction("vfprintf", nativeFn_vfprintf)
+	environment.AddNativeFunction("vprintf", nativeFn_vprintf)
+	environment.AddNativeFunction("vsprintf", nativeFn_vsprintf)
+	environment.AddNativeFunction("wordwrap", nativeFn_wordwrap)
+
+	// Const Category: String Constants
+	// Spec: https://www.php.net/manual/en/string.constants.php
+	environment.AddPredefinedConstant("CRYPT_BLOWFISH", values.NewInt(1))
+	environment.AddPredefinedConstant("CRYPT_EXT_DES", values.NewInt(1))
+	environment.AddPredefinedConstant("CRYPT_MD5", values.NewInt(1))
+	environment.AddPredefinedConstant("CRYPT_SALT_LENGTH", values.NewInt(123))
+	environment.AddPredefinedConstant("CRYPT_SHA256", values.NewInt(1))
+	environment.AddPredefinedConstant("CRYPT_SHA512", values.NewInt(1))
+	environment.AddPredefinedConstant("CRYPT_STD_DES", values.NewInt(1))
+	environment.AddPredefinedConstant("ENT_COMPAT", values.NewInt(ENT_COMPAT))
+	environment.AddPredefinedConstant("ENT_DISALLOWED", values.NewInt(ENT_DISALLOWED))
+	environment.AddPredefinedConstant("ENT_HTML401", values.NewInt(ENT_HTML401))
+	environment.AddPredefinedConstant("ENT_HTML5", values.NewInt(ENT_HTML5))
+	environment.AddPredefinedConstant("ENT_IGNORE", values.NewInt(ENT_IGNORE))
+	environment.AddPredefinedConstant("ENT_NOQUOTES", values.NewInt(ENT_NOQUOTES))
+	environment.AddPredefinedConstant("ENT_QUOTES", values.NewInt(ENT_QUOTES))
+	environment.AddPredefinedConstant("ENT_SUBSTITUTE", values.NewInt(ENT_SUBSTITUTE))
+	environment.AddPredefinedConstant("ENT_XHTML", values.NewInt(ENT_XHTML))
+	environment.AddPredefinedConstant("ENT_XML1", values.NewInt(ENT_XML1))
+	environment.AddPredefinedConstant("HTML_ENTITIES", values.NewInt(HTML_ENTITIES))
+	environment.AddPredefinedConstant("HTML_SPECIALCHARS", values.NewInt(HTML_SPECIALCHARS))
+	environment.AddPredefinedConstant("STR_PAD_BOTH", values.NewInt(STR_PAD_BOTH))
+	environment.AddPredefinedConstant("STR_PAD_LEFT", values.NewInt(STR_PAD_LEFT))
+	environment.AddPredefinedConstant("STR_PAD_RIGHT", values.NewInt(STR_PAD_RIGHT))
+}
+
+const (
+	STR_PAD_LEFT  int64 = 0
+	STR_PAD_RIGHT int64 = 1
+	STR_PAD_BOTH  int64 = 2
+)
+
+// -------------------------------------- addcslashes -------------------------------------- MARK: addcslashes
+
+func nativeFn_addcslashes(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.addcslashes.php
+
+	args, err := funcParamValidator.NewValidator("addcslashes").
+		AddParam("$string", []string{"string"}, nil).
+		AddParam("$characters", []string{"string"}, nil).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	input := args[0].(*values.Str).Value
+	mask := charMask("addcslashes", args[1].(*values.Str).Value, context)
+
+	// Spec: https://www.php.net/manual/en/function.addcslashes.php
+	// Non-printable characters with ASCII codes lower than 32 and higher than 126 are converted to octal representation.
+	// The C-style escape sequences \n, \t, \r, \a, \v, \b and \f are used for the corresponding characters.
+	var output goStrings.Builder
+	for i := 0; i < len(input); i++ {
+		char := input[i]
+		if !mask[char] {
+			output.WriteByte(char)
+			continue
+		}
+		output.WriteByte('\\')
+		if char >= 32 && char <= 126 {
+			output.WriteByte(char)
+			continue
+		}
+		switch char {
+		case '\n':
+			output.WriteByte('n')
+		case '\t':
+			output.WriteByte('t')
+		case '\r':
+			output.WriteByte('r')
+		case '\a':
+			output.WriteByte('a')
+		case '\v':
+			output.WriteByte('v')
+		case '\b':
+			output.WriteByte('b')
+		case '\f':
+			output.WriteByte('f')
+		default:
+			output.WriteString(fmt.Sprintf("%03o", char))
+		}
+	}
+
+	return values.NewStr(output.String()), nil
+}
+
+// -------------------------------------- addslashes -------------------------------------- MARK: addslashes
+
+func nativeFn_addslashes(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.addslashes.php
+
+	args, err := funcParamValidator.NewValidator("addslashes").AddParam("$string", []string{"string"}, nil).Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	input := args[0].(*values.Str).Value
+
+	// Spec: https://www.php.net/manual/en/function.addslashes.php
+	// The characters to be escaped are: single quote ('), double quote ("), backslash (\) and NUL (the NUL byte)
+	var output goStrings.Builder
+	for i := 0; i < len(input); i++ {
+		switch input[i] {
+		case '\'', '"', '\\':
+			output.WriteByte('\\')
+			output.WriteByte(input[i])
+		case 0:
+			output.WriteString("\\0")
+		default:
+			output.WriteByte(input[i])
+		}
+	}
+
+	return values.NewStr(output.String()), nil
 }
 
 // -------------------------------------- bin2hex -------------------------------------- MARK: bin2hex
@@ -67,279 +274,3014 @@ func nativeFn_chr(args []values.RuntimeValue, _ runtime.Context) (values.Runtime
 	}
 	codepoint %= 256
 
-	return values.NewStr(string(rune(codepoint))), nil
+	// Strings are byte arrays: string(rune(codepoint)) would return the UTF-8 encoding for codepoints above 127
+	return values.NewStr(string([]byte{byte(codepoint)})), nil
 }
 
-// -------------------------------------- implode -------------------------------------- MARK: implode
+// -------------------------------------- chunk_split -------------------------------------- MARK: chunk_split
 
-func nativeFn_implode(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
-	// Spec: https://www.php.net/manual/en/function.implode.php
+func nativeFn_chunk_split(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.chunk-split.php
 
-	isAlternative := false
-	var valArgs []values.RuntimeValue
-	var err phpError.Error
+	args, err := funcParamValidator.NewValidator("chunk_split").
+		AddParam("$string", []string{"string"}, nil).
+		AddParam("$length", []string{"int"}, values.NewInt(76)).
+		AddParam("$separator", []string{"string"}, values.NewStr("\r\n")).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
 
-	// Spec: https://www.php.net/manual/en/function.implode.php
-	// implode(array $array): string
-	if len(args) == 1 {
-		valArgs, err = funcParamValidator.NewValidator("implode").
-			AddParam("$array", []string{"array"}, nil).
-			Validate(args)
+	input := args[0].(*values.Str).Value
+	length := args[1].(*values.Int).Value
+	separator := args[2].(*values.Str).Value
 
-		isAlternative = err == nil
+	if length < 1 {
+		return values.NewVoid(), phpError.NewError("Uncaught ValueError: chunk_split(): Argument #2 ($length) must be greater than 0")
 	}
 
-	// Spec: https://www.php.net/manual/en/function.implode.php
-	//  implode(string $separator, array $array): string
-	if !isAlternative {
-		valArgs, err = funcParamValidator.NewValidator("implode").
-			AddParam("$separator", []string{"string"}, nil).
-			AddParam("$array", []string{"array"}, nil).
-			Validate(args)
+	var output goStrings.Builder
+	for len(input) > int(length) {
+		output.WriteString(input[:length] + separator)
+		input = input[length:]
+	}
+	// The separator is also appended to the last (possibly incomplete) chunk
+	if len(input) > 0 || output.Len() == 0 {
+		output.WriteString(input + separator)
 	}
 
+	return values.NewStr(output.String()), nil
+}
+
+// -------------------------------------- convert_uudecode -------------------------------------- MARK: convert_uudecode
+
+func nativeFn_convert_uudecode(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.convert-uudecode.php
+
+	args, err := funcParamValidator.NewValidator("convert_uudecode").AddParam("$string", []string{"string"}, nil).Validate(args)
 	if err != nil {
 		return values.NewVoid(), err
 	}
 
-	// Spec: https://www.php.net/manual/en/function.implode.php
-	// separator [...] Defaults to an empty string.
-	var separator = " "
-	if !isAlternative {
-		separator = valArgs[0].(*values.Str).Value
+	input := args[0].(*values.Str).Value
+	if input == "" {
+		return values.NewBool(false), nil
 	}
 
-	var array *values.Array
-	if isAlternative {
-		array = args[0].(*values.Array)
-	} else {
-		array = args[1].(*values.Array)
+	decode := func(index int) byte { return (byteAt(input, index) - ' ') & 0o77 }
+	invalid := func() (values.RuntimeValue, phpError.Error) {
+		context.Interpreter.PrintError(phpError.NewWarning(
+			"convert_uudecode(): Argument #1 ($data) is not a valid uuencoded string in %s", context.Stmt.GetPosString(),
+		))
+		return values.NewBool(false), nil
 	}
 
-	var result goStrings.Builder
-	for i, key := range array.Keys {
-		value, _ := array.GetElement(key)
-		strValue, err := variableHandling.StrVal(value)
-		if err != nil {
-			return values.NewStr(result.String()), err
+	output := []byte{}
+	totalLength := 0
+	index := 0
+	lineLength := 0
+	for index < len(input) {
+		lineLength = int(decode(index))
+		index++
+		if lineLength == 0 {
+			break
 		}
-		result.WriteString(strValue)
-		if i < len(array.Keys)-1 {
-			result.WriteString(separator)
+		if lineLength > len(input) {
+			return invalid()
+		}
+		totalLength += lineLength
+
+		lineEnd := index + int(math.Floor(float64(lineLength)*1.33))
+		if lineLength == 45 {
+			lineEnd = index + 60
 		}
+		if lineEnd > len(input) {
+			return invalid()
+		}
+		for index < lineEnd {
+			if index+4 > len(input) {
+				return invalid()
+			}
+			output = append(output,
+				decode(index)<<2|decode(index+1)>>4,
+				decode(index+1)<<4|decode(index+2)>>2,
+				decode(index+2)<<6|decode(index+3),
+			)
+			index += 4
+		}
+		if lineLength < 45 {
+			break
+		}
+		// Skip the line break
+		index++
 	}
 
-	return values.NewStr(result.String()), nil
+	if totalLength > len(output) {
+		output = append(output, decode(index)<<2|decode(index+1)>>4)
+		if totalLength-len(output) > 0 {
+			output = append(output, decode(index+1)<<4|decode(index+2)>>2)
+		}
+		if totalLength-len(output) > 0 {
+			output = append(output, decode(index+2)<<6|decode(index+3))
+		}
+	}
+
+	return values.NewStr(string(output[:min(totalLength, len(output))])), nil
 }
 
-// -------------------------------------- lcfirst -------------------------------------- MARK: lcfirst
+// -------------------------------------- convert_uuencode -------------------------------------- MARK: convert_uuencode
 
-func nativeFn_lcfirst(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
-	// Spec: https://www.php.net/manual/en/function.lcfirst.php
+func nativeFn_convert_uuencode(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.convert-uuencode.php
 
-	args, err := funcParamValidator.NewValidator("lcfirst").AddParam("$string", []string{"string"}, nil).Validate(args)
+	args, err := funcParamValidator.NewValidator("convert_uuencode").AddParam("$string", []string{"string"}, nil).Validate(args)
 	if err != nil {
 		return values.NewVoid(), err
 	}
 
 	input := args[0].(*values.Str).Value
-
-	if len(input) == 0 {
+	if input == "" {
 		return values.NewStr(""), nil
 	}
 
-	// Spec: https://www.php.net/manual/en/function.lcfirst.php
-	// Returns a string with the first character of string lowercased
-	// if that character is an ASCII character in the range "A" (0x41) to "Z" (0x5a).
-	if input[0] >= 'A' && input[0] <= 'Z' {
-		input = string(input[0]+32) + input[1:]
+	encode := func(char byte) byte {
+		if char == 0 {
+			return '`'
+		}
+		return (char & 0o77) + ' '
+	}
+	encodeGroup := func(index int, count int) []byte {
+		first, second, third := byteAt(input, index), byteAt(input, index+1), byteAt(input, index+2)
+		group := []byte{encode(first >> 2), encode((first<<4)&0o60 | (second>>4)&0o17), encode(0), encode(0)}
+		if count > 1 {
+			group[2] = encode((second<<2)&0o74 | (third>>6)&0o3)
+		}
+		if count > 2 {
+			group[3] = encode(third & 0o77)
+		}
+		return group
 	}
 
-	return values.NewStr(input), nil
+	// Each line encodes up to 45 bytes and starts with the encoded number of bytes
+	output := []byte{}
+	index := 0
+	lineLength := 45
+	for index+3 < len(input) {
+		lineEnd := index + lineLength
+		if lineEnd > len(input) {
+			lineEnd = len(input)
+			lineLength = lineEnd - index
+			if lineLength%3 != 0 {
+				lineEnd = index + lineLength/3*3
+			}
+		}
+		output = append(output, encode(byte(lineLength)))
+		for index < lineEnd {
+			output = append(output, encodeGroup(index, 3)...)
+			index += 3
+		}
+		if lineLength == 45 {
+			output = append(output, '\n')
+		}
+	}
+
+	if index < len(input) {
+		if lineLength == 45 {
+			output = append(output, encode(byte(len(input)-index)))
+			lineLength = 0
+		}
+		output = append(output, encodeGroup(index, len(input)-index)...)
+	}
+	if lineLength < 45 {
+		output = append(output, '\n')
+	}
+	output = append(output, encode(0), '\n')
+
+	return values.NewStr(string(output)), nil
 }
 
-// -------------------------------------- quotemeta -------------------------------------- MARK: quotemeta
+// -------------------------------------- count_chars -------------------------------------- MARK: count_chars
 
-func nativeFn_quotemeta(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
-	// Spec: https://www.php.net/manual/en/function.quotemeta.php
+func nativeFn_count_chars(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.count-chars.php
 
-	args, err := funcParamValidator.NewValidator("quotemeta").AddParam("$string", []string{"string"}, nil).Validate(args)
+	args, err := funcParamValidator.NewValidator("count_chars").
+		AddParam("$string", []string{"string"}, nil).
+		AddParam("$mode", []string{"int"}, values.NewInt(0)).
+		Validate(args)
 	if err != nil {
 		return values.NewVoid(), err
 	}
 
 	input := args[0].(*values.Str).Value
+	mode := args[1].(*values.Int).Value
 
-	if input == "" {
-		return values.NewBool(false), nil
+	if mode < 0 || mode > 4 {
+		return values.NewVoid(), phpError.NewError("Uncaught ValueError: count_chars(): Argument #2 ($mode) must be between 0 and 4 (inclusive)")
 	}
 
-	var output goStrings.Builder
+	var counts [256]int64
 	for i := 0; i < len(input); i++ {
-		if goStrings.ContainsAny(string(input[i]), `.\+*?[^($)`) {
-			output.WriteByte('\\')
+		counts[input[i]]++
+	}
+
+	// Spec: https://www.php.net/manual/en/function.count-chars.php
+	// 0 - an array with the byte-value as key and the frequency of every byte as value.
+	// 1 - same as 0 but only byte-values with a frequency greater than zero are listed.
+	// 2 - same as 0 but only byte-values with a frequency equal to zero are listed.
+	// 3 - a string containing all unique characters is returned.
+	// 4 - a string containing all not used characters is returned.
+	if mode >= 3 {
+		var output goStrings.Builder
+		for char, count := range counts {
+			if (mode == 3) == (count > 0) {
+				output.WriteByte(byte(char))
+			}
 		}
-		output.WriteByte(input[i])
+		return values.NewStr(output.String()), nil
 	}
 
-	return values.NewStr(output.String()), nil
+	result := values.NewArray()
+	for char, count := range counts {
+		if mode == 0 || (mode == 1) == (count > 0) {
+			if err := result.SetElement(values.NewInt(int64(char)), values.NewInt(count)); err != nil {
+				return values.NewVoid(), err
+			}
+		}
+	}
+	return result, nil
 }
 
-// -------------------------------------- str_contains -------------------------------------- MARK: str_contains
+// -------------------------------------- crc32 -------------------------------------- MARK: crc32
 
-func nativeFn_str_contains(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
-	// Spec: https://www.php.net/manual/en/function.str-contains.php
+func nativeFn_crc32(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.crc32.php
 
-	args, err := funcParamValidator.NewValidator("str_contains").
-		AddParam("$haystack", []string{"string"}, nil).
-		AddParam("$needle", []string{"string"}, nil).
-		Validate(args)
+	args, err := funcParamValidator.NewValidator("crc32").AddParam("$string", []string{"string"}, nil).Validate(args)
 	if err != nil {
 		return values.NewVoid(), err
 	}
 
-	haystack := args[0].(*values.Str).Value
-	needle := args[1].(*values.Str).Value
-
-	return values.NewBool(goStrings.Contains(haystack, needle)), nil
+	return values.NewInt(int64(crc32.ChecksumIEEE([]byte(args[0].(*values.Str).Value)))), nil
 }
 
-// -------------------------------------- str_ends_with -------------------------------------- MARK: str_ends_with
+// -------------------------------------- crypt -------------------------------------- MARK: crypt
 
-func nativeFn_str_ends_with(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
-	// Spec: https://www.php.net/manual/en/function.str-ends-with.php
+func nativeFn_crypt(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.crypt.php
 
-	args, err := funcParamValidator.NewValidator("str_ends_with").
-		AddParam("$haystack", []string{"string"}, nil).
-		AddParam("$needle", []string{"string"}, nil).
+	args, err := funcParamValidator.NewValidator("crypt").
+		AddParam("$string", []string{"string"}, nil).
+		AddParam("$salt", []string{"string"}, nil).
 		Validate(args)
 	if err != nil {
 		return values.NewVoid(), err
 	}
 
-	haystack := args[0].(*values.Str).Value
-	needle := args[1].(*values.Str).Value
-
-	return values.NewBool(goStrings.HasSuffix(haystack, needle)), nil
+	salt := args[1].(*values.Str).Value
+	if result, ok := password.Crypt(args[0].(*values.Str).Value, salt); ok {
+		return values.NewStr(result), nil
+	}
+	// The failure result must differ from the salt
+	if goStrings.HasPrefix(salt, "*0") {
+		return values.NewStr("*1"), nil
+	}
+	return values.NewStr("*0"), nil
 }
 
-// -------------------------------------- str_repeat -------------------------------------- MARK: str_repeat
+// -------------------------------------- explode -------------------------------------- MARK: explode
 
-func nativeFn_str_repeat(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
-	// Spec: https://www.php.net/manual/en/function.str-repeat.php
+func nativeFn_explode(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.explode.php
 
-	args, err := funcParamValidator.NewValidator("str_repeat").
+	args, err := funcParamValidator.NewValidator("explode").
+		AddParam("$separator", []string{"string"}, nil).
 		AddParam("$string", []string{"string"}, nil).
-		AddParam("$times", []string{"int"}, nil).
+		AddParam("$limit", []string{"int"}, values.NewInt(math.MaxInt64)).
 		Validate(args)
 	if err != nil {
 		return values.NewVoid(), err
 	}
 
-	input := args[0].(*values.Str).Value
-	times := args[1].(*values.Int).Value
+	separator := args[0].(*values.Str).Value
+	input := args[1].(*values.Str).Value
+	limit := args[2].(*values.Int).Value
 
-	// Spec: https://www.php.net/manual/en/function.str-repeat.php
-	// times has to be greater than or equal to 0.
-	// If the times is set to 0, the function will return an empty string.
-	if times < 0 {
-		return values.NewVoid(), phpError.NewError("Uncaught ValueError: str_repeat(): Argument #2 ($times) must be greater than or equal to 0")
+	if separator == "" {
+		return values.NewVoid(), phpError.NewError("Uncaught ValueError: explode(): Argument #1 ($separator) cannot be empty")
 	}
 
-	return values.NewStr(goStrings.Repeat(input, int(times))), nil
+	// Spec: https://www.php.net/manual/en/function.explode.php
+	// If limit is set and positive, the returned array will contain a maximum of limit elements
+	// with the last element containing the rest of string.
+	// If the limit parameter is negative, all components except the last -limit are returned.
+	// If the limit parameter is zero, then this is treated as 1.
+	var parts []string
+	switch {
+	case limit > 0:
+		parts = goStrings.SplitN(input, separator, int(min(limit, math.MaxInt32)))
+	case limit == 0:
+		parts = []string{input}
+	default:
+		parts = goStrings.Split(input, separator)
+		parts = parts[:max(int64(len(parts))+limit, 0)]
+	}
+
+	result := values.NewArray()
+	for _, part := range parts {
+		if err := result.SetElement(nil, values.NewStr(part)); err != nil {
+			return values.NewVoid(), err
+		}
+	}
+	return result, nil
 }
 
-// -------------------------------------- str_starts_with -------------------------------------- MARK: str_starts_with
+// -------------------------------------- fprintf -------------------------------------- MARK: fprintf
 
-func nativeFn_str_starts_with(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
-	// Spec: https://www.php.net/manual/en/function.str-starts-with.php
+func nativeFn_fprintf(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.fprintf.php
 
-	args, err := funcParamValidator.NewValidator("str_starts_with").
-		AddParam("$haystack", []string{"string"}, nil).
-		AddParam("$needle", []string{"string"}, nil).
+	args, err := funcParamValidator.NewValidator("fprintf").
+		AddParam("$stream", []string{"resource"}, nil).
+		AddParam("$format", []string{"string"}, nil).
+		AddVariableLenParam("$values", []string{"mixed"}).
 		Validate(args)
 	if err != nil {
 		return values.NewVoid(), err
 	}
 
-	haystack := args[0].(*values.Str).Value
-	needle := args[1].(*values.Str).Value
+	str, err := format.Sprintf(context, args[1].(*values.Str).Value, args[2].(*values.Array).GetValues(), 2)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+	return lib_fprintf("fprintf", args[0], str, context)
+}
 
-	return values.NewBool(goStrings.HasPrefix(haystack, needle)), nil
+// Write the formatted string to the stream and return the length of the written string
+func lib_fprintf(functionName string, resource values.RuntimeValue, str string, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	stream, err := filesystem.GetStream(functionName, resource)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	written, goErr := stream.Write(str)
+	if goErr != nil {
+		context.Interpreter.PrintError(phpError.NewNotice("%s(): Write of %d bytes failed: %s in %s", functionName, len(str), goErr, context.Stmt.GetPosString()))
+	}
+	return values.NewInt(int64(written)), nil
 }
 
-// -------------------------------------- strlen -------------------------------------- MARK: strlen
+// -------------------------------------- get_html_translation_table -------------------------------------- MARK: get_html_translation_table
 
-func nativeFn_strlen(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
-	// Spec: https://www.php.net/manual/en/function.strlen.php
+func nativeFn_get_html_translation_table(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.get-html-translation-table.php
 
-	args, err := funcParamValidator.NewValidator("strlen").AddParam("$string", []string{"string"}, nil).Validate(args)
+	args, err := funcParamValidator.NewValidator("get_html_translation_table").
+		AddParam("$table", []string{"int"}, values.NewInt(HTML_SPECIALCHARS)).
+		AddParam("$flags", []string{"int"}, values.NewInt(ENT_QUOTES|ENT_SUBSTITUTE|ENT_HTML401)).
+		AddParam("$encoding", []string{"string"}, values.NewStr("UTF-8")).
+		Validate(args)
 	if err != nil {
 		return values.NewVoid(), err
 	}
 
-	return values.NewInt(int64(len(args[0].(*values.Str).Value))), nil
+	charset := getHtmlCharset("get_html_translation_table", args[2], context)
+	return htmlTranslationTable(args[1].(*values.Int).Value, charset, args[0].(*values.Int).Value == HTML_ENTITIES)
 }
 
-// -------------------------------------- strtolower -------------------------------------- MARK: strtolower
+// -------------------------------------- hex2bin -------------------------------------- MARK: hex2bin
 
-func nativeFn_strtolower(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
-	// Spec: https://www.php.net/manual/en/function.strtolower.php
+func nativeFn_hex2bin(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.hex2bin.php
 
-	args, err := funcParamValidator.NewValidator("strtolower").AddParam("$string", []string{"string"}, nil).Validate(args)
+	args, err := funcParamValidator.NewValidator("hex2bin").AddParam("$string", []string{"string"}, nil).Validate(args)
 	if err != nil {
 		return values.NewVoid(), err
 	}
 
-	// Spec: https://www.php.net/manual/en/function.strtolower.php
-	// Bytes in the range "A" (0x41) to "Z" (0x5a) will be converted to the corresponding lowercase letter by adding 32 to each byte value.
-
 	input := args[0].(*values.Str).Value
 
-	for i := 0; i < len(input); i++ {
-		if input[i] >= 'A' && input[i] <= 'Z' {
-			input = input[:i] + string(input[i]+32) + input[i+1:]
-		}
+	if len(input)%2 != 0 {
+		context.Interpreter.PrintError(phpError.NewWarning("hex2bin(): Hexadecimal input string must have an even length in %s", context.Stmt.GetPosString()))
+		return values.NewBool(false), nil
 	}
 
-	return values.NewStr(input), nil
+	output, decodeErr := hex.DecodeString(input)
+	if decodeErr != nil {
+		context.Interpreter.PrintError(phpError.NewWarning("hex2bin(): Input string must be hexadecimal string in %s", context.Stmt.GetPosString()))
+		return values.NewBool(false), nil
+	}
+
+	return values.NewStr(string(output)), nil
 }
 
-// -------------------------------------- strtoupper -------------------------------------- MARK: strtoupper
+// -------------------------------------- html_entity_decode -------------------------------------- MARK: html_entity_decode
 
-func nativeFn_strtoupper(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
-	// Spec: https://www.php.net/manual/en/function.strtoupper.php
+func nativeFn_html_entity_decode(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.html-entity-decode.php
 
-	args, err := funcParamValidator.NewValidator("strtoupper").AddParam("$string", []string{"string"}, nil).Validate(args)
+	args, err := funcParamValidator.NewValidator("html_entity_decode").
+		AddParam("$string", []string{"string"}, nil).
+		AddParam("$flags", []string{"int"}, values.NewInt(ENT_QUOTES|ENT_SUBSTITUTE|ENT_HTML401)).
+		AddParam("$encoding", []string{"string", "null"}, values.NewNull()).
+		Validate(args)
 	if err != nil {
 		return values.NewVoid(), err
 	}
 
-	// Spec: https://www.php.net/manual/en/function.strtoupper.php
-	// Bytes in the range "a" (0x61) to "z" (0x7a) will be converted to the corresponding uppercase letter by subtracting 32 from each byte value.
+	charset := getHtmlCharset("html_entity_decode", args[2], context)
+	return values.NewStr(htmlDecode(args[0].(*values.Str).Value, args[1].(*values.Int).Value, charset, true)), nil
+}
 
-	input := args[0].(*values.Str).Value
+// -------------------------------------- htmlentities -------------------------------------- MARK: htmlentities
 
-	for i := 0; i < len(input); i++ {
-		if input[i] >= 'a' && input[i] <= 'z' {
-			input = input[:i] + string(input[i]-32) + input[i+1:]
-		}
+func nativeFn_htmlentities(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.htmlentities.php
+	return htmlEscape("htmlentities", args, true, context)
+}
+
+// Convert special characters (or if all is set all applicable characters) to HTML entities (htmlentities and htmlspecialchars)
+func htmlEscape(funcName string, args []values.RuntimeValue, all bool, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	args, err := funcParamValidator.NewValidator(funcName).
+		AddParam("$string", []string{"string"}, nil).
+		AddParam("$flags", []string{"int"}, values.NewInt(ENT_QUOTES|ENT_SUBSTITUTE|ENT_HTML401)).
+		AddParam("$encoding", []string{"string", "null"}, values.NewNull()).
+		AddParam("$double_encode", []string{"bool"}, values.NewBool(true)).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
 	}
 
-	return values.NewStr(input), nil
+	charset := getHtmlCharset(funcName, args[2], context)
+	return values.NewStr(htmlEncode(
+		args[0].(*values.Str).Value, args[1].(*values.Int).Value, charset, args[3].(*values.Bool).Value, all,
+	)), nil
 }
 
-// -------------------------------------- ucfirst -------------------------------------- MARK: ucfirst
+// -------------------------------------- htmlspecialchars -------------------------------------- MARK: htmlspecialchars
 
-func nativeFn_ucfirst(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
-	// Spec: https://www.php.net/manual/en/function.ucfirst.php
+func nativeFn_htmlspecialchars(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.htmlspecialchars.php
+	return htmlEscape("htmlspecialchars", args, false, context)
+}
 
-	args, err := funcParamValidator.NewValidator("ucfirst").AddParam("$string", []string{"string"}, nil).Validate(args)
+// -------------------------------------- htmlspecialchars_decode -------------------------------------- MARK: htmlspecialchars_decode
+
+func nativeFn_htmlspecialchars_decode(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.htmlspecialchars-decode.php
+
+	args, err := funcParamValidator.NewValidator("htmlspecialchars_decode").
+		AddParam("$string", []string{"string"}, nil).
+		AddParam("$flags", []string{"int"}, values.NewInt(ENT_QUOTES|ENT_SUBSTITUTE|ENT_HTML401)).
+		Validate(args)
 	if err != nil {
 		return values.NewVoid(), err
 	}
 
-	input := args[0].(*values.Str).Value
+	return values.NewStr(htmlDecode(args[0].(*values.Str).Value, args[1].(*values.Int).Value, charsetUtf8, false)), nil
+}
+
+// -------------------------------------- implode -------------------------------------- MARK: implode
+
+func nativeFn_implode(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.implode.php
+
+	isAlternative := false
+	var valArgs []values.RuntimeValue
+	var err phpError.Error
+
+	// Spec: https://www.php.net/manual/en/function.implode.php
+	// implode(array $array): string
+	if len(args) == 1 {
+		valArgs, err = funcParamValidator.NewValidator("implode").
+			AddParam("$array", []string{"array"}, nil).
+			Validate(args)
+
+		isAlternative = err == nil
+	}
+
+	// Spec: https://www.php.net/manual/en/function.implode.php
+	//  implode(string $separator, array $array): string
+	if !isAlternative {
+		valArgs, err = funcParamValidator.NewValidator("implode").
+			AddParam("$separator", []string{"string"}, nil).
+			AddParam("$array", []string{"array"}, nil).
+			Validate(args)
+	}
+
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Spec: https://www.php.net/manual/en/function.implode.php
+	// separator [...] Defaults to an empty string.
+	var separator = ""
+	if !isAlternative {
+		separator = valArgs[0].(*values.Str).Value
+	}
+
+	var array *values.Array
+	if isAlternative {
+		array = args[0].(*values.Array)
+	} else {
+		array = args[1].(*values.Array)
+	}
+
+	var result goStrings.Builder
+	for i, key := range array.GetKeys() {
+		value, _ := array.GetElement(key)
+		strValue, err := variableHandling.StrVal(value, context.Interpreter.GetIni().GetInt("precision"))
+		if err != nil {
+			return values.NewStr(result.String()), err
+		}
+		result.WriteString(strValue)
+		if i < array.Count()-1 {
+			result.WriteString(separator)
+		}
+	}
+
+	return values.NewStr(result.String()), nil
+}
+
+// -------------------------------------- lcfirst -------------------------------------- MARK: lcfirst
+
+func nativeFn_lcfirst(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.lcfirst.php
+
+	args, err := funcParamValidator.NewValidator("lcfirst").AddParam("$string", []string{"string"}, nil).Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	input := args[0].(*values.Str).Value
+
+	if len(input) == 0 {
+		return values.NewStr(""), nil
+	}
+
+	// Spec: https://www.php.net/manual/en/function.lcfirst.php
+	// Returns a string with the first character of string lowercased
+	// if that character is an ASCII character in the range "A" (0x41) to "Z" (0x5a).
+	if input[0] >= 'A' && input[0] <= 'Z' {
+		input = string(input[0]+32) + input[1:]
+	}
+
+	return values.NewStr(input), nil
+}
+
+// -------------------------------------- levenshtein -------------------------------------- MARK: levenshtein
+
+func nativeFn_levenshtein(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.levenshtein.php
+
+	args, err := funcParamValidator.NewValidator("levenshtein").
+		AddParam("$string1", []string{"string"}, nil).
+		AddParam("$string2", []string{"string"}, nil).
+		AddParam("$insertion_cost", []string{"int"}, values.NewInt(1)).
+		AddParam("$replacement_cost", []string{"int"}, values.NewInt(1)).
+		AddParam("$deletion_cost", []string{"int"}, values.NewInt(1)).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	string1 := args[0].(*values.Str).Value
+	string2 := args[1].(*values.Str).Value
+	insertionCost := args[2].(*values.Int).Value
+	replacementCost := args[3].(*values.Int).Value
+	deletionCost := args[4].(*values.Int).Value
+
+	if len(string1) == 0 {
+		return values.NewInt(int64(len(string2)) * insertionCost), nil
+	}
+	if len(string2) == 0 {
+		return values.NewInt(int64(len(string1)) * deletionCost), nil
+	}
+
+	previous := make([]int64, len(string2)+1)
+	current := make([]int64, len(string2)+1)
+	for i := range previous {
+		previous[i] = int64(i) * insertionCost
+	}
+	for i := 0; i < len(string1); i++ {
+		current[0] = previous[0] + deletionCost
+		for j := 0; j < len(string2); j++ {
+			cost := previous[j]
+			if string1[i] != string2[j] {
+				cost += replacementCost
+			}
+			cost = min(cost, previous[j+1]+deletionCost, current[j]+insertionCost)
+			current[j+1] = cost
+		}
+		previous, current = current, previous
+	}
+
+	return values.NewInt(previous[len(string2)]), nil
+}
+
+// -------------------------------------- ltrim -------------------------------------- MARK: ltrim
+
+func nativeFn_ltrim(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.ltrim.php
+	return trim("ltrim", args, 1, context)
+}
+
+// -------------------------------------- md5 -------------------------------------- MARK: md5
+
+func nativeFn_md5(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.md5.php
+
+	args, err := funcParamValidator.NewValidator("md5").
+		AddParam("$string", []string{"string"}, nil).
+		AddParam("$binary", []string{"bool"}, values.NewBool(false)).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	digest := md5.Sum([]byte(args[0].(*values.Str).Value))
+	return digestToStr(digest[:], args[1].(*values.Bool).Value), nil
+}
+
+// -------------------------------------- md5_file -------------------------------------- MARK: md5_file
+
+func nativeFn_md5_file(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.md5-file.php
+
+	args, err := funcParamValidator.NewValidator("md5_file").
+		AddParam("$filename", []string{"string"}, nil).
+		AddParam("$binary", []string{"bool"}, values.NewBool(false)).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	content, found := readFile("md5_file", args[0].(*values.Str).Value, context)
+	if !found {
+		return values.NewBool(false), nil
+	}
+	digest := md5.Sum([]byte(content))
+	return digestToStr(digest[:], args[1].(*values.Bool).Value), nil
+}
+
+// -------------------------------------- metaphone -------------------------------------- MARK: metaphone
+
+func nativeFn_metaphone(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.metaphone.php
+
+	args, err := funcParamValidator.NewValidator("metaphone").
+		AddParam("$string", []string{"string"}, nil).
+		AddParam("$max_phonemes", []string{"int"}, values.NewInt(0)).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	maxPhonemes := args[1].(*values.Int).Value
+	if maxPhonemes < 0 {
+		return values.NewVoid(), phpError.NewError("Uncaught ValueError: metaphone(): Argument #2 ($max_phonemes) must be greater than or equal to 0")
+	}
+
+	return values.NewStr(metaphone(args[0].(*values.Str).Value, int(min(maxPhonemes, math.MaxInt32)))), nil
+}
+
+// -------------------------------------- nl2br -------------------------------------- MARK: nl2br
+
+func nativeFn_nl2br(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.nl2br.php
+
+	args, err := funcParamValidator.NewValidator("nl2br").
+		AddParam("$string", []string{"string"}, nil).
+		AddParam("$use_xhtml", []string{"bool"}, values.NewBool(true)).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	input := args[0].(*values.Str).Value
+	lineBreak := "<br />"
+	if !args[1].(*values.Bool).Value {
+		lineBreak = "<br>"
+	}
+
+	// Spec: https://www.php.net/manual/en/function.nl2br.php
+	// Returns string with <br /> or <br> inserted before all newlines (\r\n, \n\r, \n and \r).
+	var output goStrings.Builder
+	for i := 0; i < len(input); i++ {
+		char := input[i]
+		if char != '\r' && char != '\n' {
+			output.WriteByte(char)
+			continue
+		}
+		output.WriteString(lineBreak)
+		output.WriteByte(char)
+		if next := byteAt(input, i+1); (next == '\r' || next == '\n') && next != char {
+			output.WriteByte(next)
+			i++
+		}
+	}
+
+	return values.NewStr(output.String()), nil
+}
+
+// -------------------------------------- number_format -------------------------------------- MARK: number_format
+
+func nativeFn_number_format(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.number-format.php
+
+	args, err := funcParamValidator.NewValidator("number_format").
+		AddParam("$num", []string{"int", "float"}, nil).
+		AddParam("$decimals", []string{"int"}, values.NewInt(0)).
+		AddParam("$decimal_separator", []string{"string", "null"}, values.NewStr(".")).
+		AddParam("$thousands_separator", []string{"string", "null"}, values.NewStr(",")).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	num, err := variableHandling.FloatVal(args[0], false)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+	decimals := args[1].(*values.Int).Value
+	decimalSeparator := "."
+	if args[2].GetType() == values.StrValue {
+		decimalSeparator = args[2].(*values.Str).Value
+	}
+	thousandsSeparator := ","
+	if args[3].GetType() == values.StrValue {
+		thousandsSeparator = args[3].(*values.Str).Value
+	}
+
+	// Spec: https://www.php.net/manual/en/function.number-format.php
+	// The number is rounded half up to the given number of decimals.
+	// A negative number of decimals rounds to the left of the decimal point.
+	num = common.RoundHalfUp(num, int(max(min(decimals, 1000), -1000)))
+	decimals = max(decimals, 0)
+
+	switch {
+	case math.IsNaN(num):
+		return values.NewStr("NAN"), nil
+	case math.IsInf(num, 1):
+		return values.NewStr("INF"), nil
+	case math.IsInf(num, -1):
+		return values.NewStr("-INF"), nil
+	}
+
+	integerPart, fractionPart, _ := goStrings.Cut(strconv.FormatFloat(math.Abs(num), 'f', int(decimals), 64), ".")
+
+	var result goStrings.Builder
+	// No sign is added if the number is zero after rounding
+	if num < 0 {
+		result.WriteString("-")
+	}
+	for i := 0; i < len(integerPart); i++ {
+		if i > 0 && (len(integerPart)-i)%3 == 0 {
+			result.WriteString(thousandsSeparator)
+		}
+		result.WriteByte(integerPart[i])
+	}
+	if decimals > 0 {
+		result.WriteString(decimalSeparator + fractionPart)
+	}
+
+	return values.NewStr(result.String()), nil
+}
+
+// -------------------------------------- ord -------------------------------------- MARK: ord
+
+func nativeFn_ord(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.ord.php
+
+	args, err := funcParamValidator.NewValidator("ord").AddParam("$character", []string{"string"}, nil).Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Spec: https://www.php.net/manual/en/function.ord.php
+	// Interprets the binary value of the first byte of character as an unsigned integer between 0 and 255.
+	return values.NewInt(int64(byteAt(args[0].(*values.Str).Value, 0))), nil
+}
+
+// -------------------------------------- parse_str -------------------------------------- MARK: parse_str
+
+func nativeFn_parse_str(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.parse-str.php
+
+	args, err := funcParamValidator.NewValidator("parse_str").
+		AddParam("$string", []string{"string"}, nil).
+		AddParam("$result", []string{"mixed"}, nil).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	input := args[0].(*values.Str).Value
+	separators := context.Interpreter.GetIni().GetStr("arg_separator.input")
+	maxDepth := int(context.Interpreter.GetIni().GetInt("max_input_nesting_level"))
+
+	// Spec: https://www.php.net/manual/en/function.parse-str.php
+	// Parses string as if it were the query string passed via a URL and sets variables in the result array.
+	// Every character of "arg_separator.input" separates the variables.
+	result := values.NewArray()
+	pairs := goStrings.FieldsFunc(input, func(char rune) bool { return goStrings.ContainsRune(separators, char) })
+	for _, pair := range pairs {
+		name, value, _ := goStrings.Cut(pair, "=")
+		if err := registerVariable(result, urlDecode(name), values.NewStr(urlDecode(value)), maxDepth); err != nil {
+			return values.NewVoid(), err
+		}
+	}
+
+	return values.NewVoid(), context.Interpreter.SetByRefArgument(context, 1, result)
+}
+
+// Decode a URL-encoded string ("+" is decoded to a space). Invalid percent sequences are kept.
+func urlDecode(str string) string {
+	var output goStrings.Builder
+	for i := 0; i < len(str); i++ {
+		switch {
+		case str[i] == '+':
+			output.WriteByte(' ')
+		case str[i] == '%' && isHexDigit(byteAt(str, i+1)) && isHexDigit(byteAt(str, i+2)):
+			output.WriteByte(hexValue(str[i+1])<<4 | hexValue(str[i+2]))
+			i += 2
+		default:
+			output.WriteByte(str[i])
+		}
+	}
+	return output.String()
+}
+
+// Set a variable like "a[b][]" in the result array like PHP does for the superglobals
+func registerVariable(result *values.Array, name string, value values.RuntimeValue, maxDepth int) phpError.Error {
+	name = goStrings.TrimLeft(name, " ")
+	if index := goStrings.IndexByte(name, 0); index >= 0 {
+		name = name[:index]
+	}
+
+	// Spaces and dots in the variable name are converted to underscores
+	base, indices, isArray := goStrings.Cut(name, "[")
+	base = goStrings.NewReplacer(" ", "_", ".", "_").Replace(base)
+	if base == "" {
+		return nil
+	}
+	if !isArray {
+		return result.SetElement(values.NewStr(base), value)
+	}
+
+	array := result
+	var key values.RuntimeValue = values.NewStr(base)
+	for depth := 1; ; depth++ {
+		if depth > maxDepth {
+			// The variable is dropped if it is nested too deep
+			return result.RemoveElement(values.NewStr(base))
+		}
+
+		var nextKey values.RuntimeValue
+		if goStrings.HasPrefix(indices, "]") {
+			indices = indices[1:]
+		} else {
+			index, rest, found := goStrings.Cut(indices, "]")
+			if !found {
+				if depth == 1 {
+					// Not an index: The bracket is part of the variable name
+					key = values.NewStr(base + "_" + goStrings.NewReplacer(" ", "_", ".", "_", "[", "_").Replace(indices))
+				}
+				break
+			}
+			nextKey = values.NewStr(index)
+			indices = rest
+		}
+
+		// Get or create the nested array
+		if key == nil {
+			if err := array.SetElement(nil, values.NewArray()); err != nil {
+				return err
+			}
+			keys := array.GetKeys()
+			key = keys[len(keys)-1]
+		} else if element, found := array.GetElement(key); !found || element.GetType() != values.ArrayValue {
+			if err := array.SetElement(key, values.NewArray()); err != nil {
+				return err
+			}
+		}
+		element, _ := array.GetElementForWrite(key)
+		array = element.(*values.Array)
+		key = nextKey
+
+		// Everything after the closing bracket that is not another index is ignored
+		if !goStrings.HasPrefix(indices, "[") {
+			break
+		}
+		indices = indices[1:]
+	}
+
+	return array.SetElement(key, value)
+}
+
+// -------------------------------------- printf -------------------------------------- MARK: printf
+
+func nativeFn_printf(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.printf.php
+
+	args, err := funcParamValidator.NewValidator("printf").
+		AddParam("$format", []string{"string"}, nil).
+		AddVariableLenParam("$values", []string{"mixed"}).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	str, err := format.Sprintf(context, args[0].(*values.Str).Value, args[1].(*values.Array).GetValues(), 1)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+	context.Interpreter.Print(str)
+	return values.NewInt(int64(len(str))), nil
+}
+
+// -------------------------------------- quoted_printable_decode -------------------------------------- MARK: quoted_printable_decode
+
+func nativeFn_quoted_printable_decode(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.quoted-printable-decode.php
+
+	args, err := funcParamValidator.NewValidator("quoted_printable_decode").AddParam("$string", []string{"string"}, nil).Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	input := args[0].(*values.Str).Value
+
+	var output goStrings.Builder
+	// Like in PHP the decoding stops at the first NUL byte
+	for i := 0; i < len(input) && input[i] != 0; {
+		if input[i] != '=' {
+			output.WriteByte(input[i])
+			i++
+			continue
+		}
+		if isHexDigit(byteAt(input, i+1)) && isHexDigit(byteAt(input, i+2)) {
+			output.WriteByte(hexValue(input[i+1])<<4 | hexValue(input[i+2]))
+			i += 3
+			continue
+		}
+
+		// Soft line break (RFC 2045): "=" followed by optional spaces or tabs and the end of the line
+		k := 1
+		for byteAt(input, i+k) == ' ' || byteAt(input, i+k) == '\t' {
+			k++
+		}
+		switch {
+		case byteAt(input, i+k) == 0:
+			i += k
+		case byteAt(input, i+k) == '\r' && byteAt(input, i+k+1) == '\n':
+			i += k + 2
+		case byteAt(input, i+k) == '\r' || byteAt(input, i+k) == '\n':
+			i += k + 1
+		default:
+			output.WriteByte(input[i])
+			i++
+		}
+	}
+
+	return values.NewStr(output.String()), nil
+}
+
+// -------------------------------------- quoted_printable_encode -------------------------------------- MARK: quoted_printable_encode
+
+func nativeFn_quoted_printable_encode(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.quoted-printable-encode.php
+
+	args, err := funcParamValidator.NewValidator("quoted_printable_encode").AddParam("$string", []string{"string"}, nil).Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	input := args[0].(*values.Str).Value
+
+	// Spec: https://www.php.net/manual/en/function.quoted-printable-encode.php
+	// Lines are split at 75 characters with a soft line break. Multibyte UTF-8 sequences are not split.
+	const maxLineLength = 75
+	const hexDigits = "0123456789ABCDEF"
+	var output goStrings.Builder
+	lineLength := 0
+	for i := 0; i < len(input); i++ {
+		char := input[i]
+		if char == '\r' && byteAt(input, i+1) == '\n' {
+			output.WriteString("\r\n")
+			i++
+			lineLength = 0
+			continue
+		}
+
+		if char < 32 || char >= 0x7f || char == '=' || (char == ' ' && byteAt(input, i+1) == '\r') {
+			lineLength += 3
+			if (lineLength > maxLineLength && char <= 0x7f) ||
+				(char > 0x7f && char <= 0xdf && lineLength+3 > maxLineLength) ||
+				(char > 0xdf && char <= 0xef && lineLength+6 > maxLineLength) ||
+				(char > 0xef && char <= 0xf4 && lineLength+9 > maxLineLength) {
+				output.WriteString("=\r\n")
+				lineLength = 3
+			}
+			output.WriteByte('=')
+			output.WriteByte(hexDigits[char>>4])
+			output.WriteByte(hexDigits[char&0xf])
+			continue
+		}
+
+		lineLength++
+		if lineLength > maxLineLength {
+			output.WriteString("=\r\n")
+			lineLength = 1
+		}
+		output.WriteByte(char)
+	}
+
+	return values.NewStr(output.String()), nil
+}
+
+// -------------------------------------- quotemeta -------------------------------------- MARK: quotemeta
+
+func nativeFn_quotemeta(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.quotemeta.php
+
+	args, err := funcParamValidator.NewValidator("quotemeta").AddParam("$string", []string{"string"}, nil).Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	input := args[0].(*values.Str).Value
+
+	if input == "" {
+		return values.NewBool(false), nil
+	}
+
+	var output goStrings.Builder
+	for i := 0; i < len(input); i++ {
+		if goStrings.ContainsAny(string(input[i]), `.\+*?[^($)`) {
+			output.WriteByte('\\')
+		}
+		output.WriteByte(input[i])
+	}
+
+	return values.NewStr(output.String()), nil
+}
+
+// -------------------------------------- rtrim -------------------------------------- MARK: rtrim
+
+func nativeFn_rtrim(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.rtrim.php
+	return trim("rtrim", args, 2, context)
+}
+
+// -------------------------------------- sha1 -------------------------------------- MARK: sha1
+
+func nativeFn_sha1(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.sha1.php
+
+	args, err := funcParamValidator.NewValidator("sha1").
+		AddParam("$string", []string{"string"}, nil).
+		AddParam("$binary", []string{"bool"}, values.NewBool(false)).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	digest := sha1.Sum([]byte(args[0].(*values.Str).Value))
+	return digestToStr(digest[:], args[1].(*values.Bool).Value), nil
+}
+
+// -------------------------------------- sha1_file -------------------------------------- MARK: sha1_file
+
+func nativeFn_sha1_file(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.sha1-file.php
+
+	args, err := funcParamValidator.NewValidator("sha1_file").
+		AddParam("$filename", []string{"string"}, nil).
+		AddParam("$binary", []string{"bool"}, values.NewBool(false)).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	content, found := readFile("sha1_file", args[0].(*values.Str).Value, context)
+	if !found {
+		return values.NewBool(false), nil
+	}
+	digest := sha1.Sum([]byte(content))
+	return digestToStr(digest[:], args[1].(*values.Bool).Value), nil
+}
+
+// -------------------------------------- similar_text -------------------------------------- MARK: similar_text
+
+func nativeFn_similar_text(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.similar-text.php
+
+	hasPercent := len(args) > 2
+	args, err := funcParamValidator.NewValidator("similar_text").
+		AddParam("$string1", []string{"string"}, nil).
+		AddParam("$string2", []string{"string"}, nil).
+		AddParam("$percent", []string{"mixed"}, values.NewNull()).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	string1 := args[0].(*values.Str).Value
+	string2 := args[1].(*values.Str).Value
+
+	similarity := 0
+	percent := 0.0
+	if len(string1)+len(string2) > 0 {
+		similarity = similarChars(string1, string2)
+		percent = float64(similarity) * 200.0 / float64(len(string1)+len(string2))
+	}
+
+	if hasPercent {
+		if err := context.Interpreter.SetByRefArgument(context, 2, values.NewFloat(percent)); err != nil {
+			return values.NewVoid(), err
+		}
+	}
+	return values.NewInt(int64(similarity)), nil
+}
+
+// Count the matching characters of the strings (Oliver algorithm like PHP's php_similar_char)
+func similarChars(string1 string, string2 string) int {
+	// Find the first longest common substring
+	position1, position2, maxLength, count := 0, 0, 0, 0
+	for i := 0; i < len(string1); i++ {
+		for j := 0; j < len(string2); j++ {
+			length := 0
+			for i+length < len(string1) && j+length < len(string2) && string1[i+length] == string2[j+length] {
+				length++
+			}
+			if length > maxLength {
+				maxLength = length
+				count++
+				position1, position2 = i, j
+			}
+		}
+	}
+
+	sum := maxLength
+	if sum == 0 {
+		return 0
+	}
+	if position1 > 0 && position2 > 0 && count > 1 {
+		sum += similarChars(string1[:position1], string2[:position2])
+	}
+	if position1+maxLength < len(string1) && position2+maxLength < len(string2) {
+		sum += similarChars(string1[position1+maxLength:], string2[position2+maxLength:])
+	}
+	return sum
+}
+
+// -------------------------------------- soundex -------------------------------------- MARK: soundex
+
+func nativeFn_soundex(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.soundex.php
+
+	args, err := funcParamValidator.NewValidator("soundex").AddParam("$string", []string{"string"}, nil).Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	input := args[0].(*values.Str).Value
+	if input == "" {
+		return values.NewStr(""), nil
+	}
+
+	// Soundex codes of the letters A to Z (0 for vowels and the letters H, W and Y)
+	const codes = "01230120022455012623010202"
+
+	output := []byte{}
+	var last byte
+	for i := 0; i < len(input) && len(output) < 4; i++ {
+		char := upperByte(input[i])
+		if char < 'A' || char > 'Z' {
+			continue
+		}
+		code := codes[char-'A']
+		if len(output) == 0 {
+			// The first letter is kept
+			output = append(output, char)
+			last = code
+			continue
+		}
+		// Sequences of letters with the same code are ignored
+		if code != last {
+			if code != '0' {
+				output = append(output, code)
+			}
+			last = code
+		}
+	}
+	for len(output) < 4 {
+		output = append(output, '0')
+	}
+
+	return values.NewStr(string(output)), nil
+}
+
+// -------------------------------------- sprintf -------------------------------------- MARK: sprintf
+
+func nativeFn_sprintf(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.sprintf.php
+
+	args, err := funcParamValidator.NewValidator("sprintf").
+		AddParam("$format", []string{"string"}, nil).
+		AddVariableLenParam("$values", []string{"mixed"}).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	str, err := format.Sprintf(context, args[0].(*values.Str).Value, args[1].(*values.Array).GetValues(), 1)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+	return values.NewStr(str), nil
+}
+
+// -------------------------------------- sscanf -------------------------------------- MARK: sscanf
+
+func nativeFn_sscanf(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.sscanf.php
+
+	args, err := funcParamValidator.NewValidator("sscanf").
+		AddParam("$string", []string{"string"}, nil).
+		AddParam("$format", []string{"string"}, nil).
+		AddVariableLenParam("$vars", []string{"mixed"}).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	numVars := args[2].(*values.Array).Count()
+	parsedValues, conversions, err := format.Sscanf(args[0].(*values.Str).Value, args[1].(*values.Str).Value, numVars)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Spec: https://www.php.net/manual/en/function.sscanf.php
+	// If only two parameters were passed to this function, the values parsed will be returned as an array.
+	// Otherwise, if optional parameters are passed, the function will return the number of assigned values.
+	// If there are more substrings expected in the format than there are available within string, null will be returned.
+	if numVars == 0 {
+		if conversions == -1 {
+			return values.NewNull(), nil
+		}
+		result := values.NewArray()
+		for _, value := range parsedValues {
+			if err := result.SetElement(nil, value); err != nil {
+				return values.NewVoid(), err
+			}
+		}
+		return result, nil
+	}
+
+	if conversions == -1 {
+		return values.NewInt(-1), nil
+	}
+	for index, value := range parsedValues {
+		// Variables of conversions that failed keep their value
+		if value.GetType() == values.NullValue {
+			continue
+		}
+		if err := context.Interpreter.SetByRefArgument(context, index+2, value); err != nil {
+			return values.NewVoid(), err
+		}
+	}
+	return values.NewInt(int64(conversions)), nil
+}
+
+// -------------------------------------- str_contains -------------------------------------- MARK: str_contains
+
+func nativeFn_str_contains(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.str-contains.php
+
+	args, err := funcParamValidator.NewValidator("str_contains").
+		AddParam("$haystack", []string{"string"}, nil).
+		AddParam("$needle", []string{"string"}, nil).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	haystack := args[0].(*values.Str).Value
+	needle := args[1].(*values.Str).Value
+
+	return values.NewBool(goStrings.Contains(haystack, needle)), nil
+}
+
+// -------------------------------------- str_decrement -------------------------------------- MARK: str_decrement
+
+func nativeFn_str_decrement(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.str-decrement.php
+
+	args, err := funcParamValidator.NewValidator("str_decrement").AddParam("$string", []string{"string"}, nil).Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	input := args[0].(*values.Str).Value
+	if err := validateAlphanumeric("str_decrement", input); err != nil {
+		return values.NewVoid(), err
+	}
+
+	output := []byte(input)
+	borrow := true
+	for i := len(output) - 1; i >= 0 && borrow; i-- {
+		switch output[i] {
+		case 'a':
+			output[i] = 'z'
+		case 'A':
+			output[i] = 'Z'
+		case '0':
+			output[i] = '9'
+		default:
+			output[i]--
+			borrow = false
+		}
+	}
+
+	// Spec: https://www.php.net/manual/en/function.str-decrement.php
+	// The leading character is removed if it was borrowed from (e.g. "Aa" => "z") or if it is a leading zero (e.g. "10" => "9").
+	if borrow || (output[0] == '0' && len(output) > 1) {
+		if len(output) == 1 {
+			return values.NewVoid(), phpError.NewError("Uncaught ValueError: str_decrement(): Argument #1 ($string) \"%s\" is out of decrement range", input)
+		}
+		output = output[1:]
+	}
+
+	return values.NewStr(string(output)), nil
+}
+
+// Check that the string is not empty and only consists of ASCII letters and digits (str_increment and str_decrement)
+func validateAlphanumeric(funcName string, input string) phpError.Error {
+	if input == "" {
+		return phpError.NewError("Uncaught ValueError: %s(): Argument #1 ($string) cannot be empty", funcName)
+	}
+	for i := 0; i < len(input); i++ {
+		if !isAsciiAlpha(input[i]) && !isAsciiDigit(input[i]) {
+			return phpError.NewError("Uncaught ValueError: %s(): Argument #1 ($string) must be composed only of alphanumeric ASCII characters", funcName)
+		}
+	}
+	return nil
+}
+
+// -------------------------------------- str_ends_with -------------------------------------- MARK: str_ends_with
+
+func nativeFn_str_ends_with(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.str-ends-with.php
+
+	args, err := funcParamValidator.NewValidator("str_ends_with").
+		AddParam("$haystack", []string{"string"}, nil).
+		AddParam("$needle", []string{"string"}, nil).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	haystack := args[0].(*values.Str).Value
+	needle := args[1].(*values.Str).Value
+
+	return values.NewBool(goStrings.HasSuffix(haystack, needle)), nil
+}
+
+// -------------------------------------- str_getcsv -------------------------------------- MARK: str_getcsv
+
+func nativeFn_str_getcsv(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.str-getcsv.php
+
+	args, err := funcParamValidator.NewValidator("str_getcsv").
+		AddParam("$string", []string{"string"}, nil).
+		AddParam("$separator", []string{"string"}, values.NewStr(",")).
+		AddParam("$enclosure", []string{"string"}, values.NewStr("\"")).
+		AddParam("$escape", []string{"string"}, values.NewStr("\\")).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	input := args[0].(*values.Str).Value
+	separator := args[1].(*values.Str).Value
+	enclosure := args[2].(*values.Str).Value
+	escape := args[3].(*values.Str).Value
+
+	if len(separator) != 1 {
+		return values.NewVoid(), phpError.NewError("Uncaught ValueError: str_getcsv(): Argument #2 ($separator) must be a single character")
+	}
+	if len(enclosure) != 1 {
+		return values.NewVoid(), phpError.NewError("Uncaught ValueError: str_getcsv(): Argument #3 ($enclosure) must be a single character")
+	}
+	if len(escape) > 1 {
+		return values.NewVoid(), phpError.NewError("Uncaught ValueError: str_getcsv(): Argument #4 ($escape) must be empty or a single character")
+	}
+
+	result := values.NewArray()
+	if input == "" {
+		// Spec: https://www.php.net/manual/en/function.str-getcsv.php
+		// An empty string returns an array with a single null field.
+		return result, result.SetElement(nil, values.NewNull())
+	}
+
+	for _, field := range parseCsvLine(input, separator[0], enclosure[0], escape) {
+		if err := result.SetElement(nil, values.NewStr(field)); err != nil {
+			return values.NewVoid(), err
+		}
+	}
+	return result, nil
+}
+
+// Parse the fields of a CSV line like PHP's php_fgetcsv.
+// An escape character does not end an enclosed field and is kept in the field.
+func parseCsvLine(line string, separator byte, enclosure byte, escape string) []string {
+	// Trailing line breaks are only part of an enclosed field
+	limit := len(goStrings.TrimRight(line, "\r\n"))
+
+	fields := []string{}
+	position := 0
+	for {
+		// Whitespace in front of an enclosure is ignored
+		start := position
+		for start < limit && line[start] != separator && isSpace(line[start]) {
+			start++
+		}
+
+		var field goStrings.Builder
+		if start < limit && line[start] == enclosure {
+			position = start + 1
+			for position < len(line) {
+				char := line[position]
+				if escape != "" && char == escape[0] && char != enclosure && position+1 < len(line) {
+					field.WriteByte(char)
+					field.WriteByte(line[position+1])
+					position += 2
+					continue
+				}
+				if char == enclosure {
+					if byteAt(line, position+1) == enclosure {
+						field.WriteByte(enclosure)
+						position += 2
+						continue
+					}
+					position++
+					break
+				}
+				field.WriteByte(char)
+				position++
+			}
+			// Characters after the closing enclosure are appended to the field
+			for position < limit && line[position] != separator {
+				field.WriteByte(line[position])
+				position++
+			}
+		} else {
+			for position < limit && line[position] != separator {
+				field.WriteByte(line[position])
+				position++
+			}
+		}
+		fields = append(fields, field.String())
+
+		if position >= limit || line[position] != separator {
+			return fields
+		}
+		position++
+	}
+}
+
+// -------------------------------------- str_increment -------------------------------------- MARK: str_increment
+
+func nativeFn_str_increment(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.str-increment.php
+
+	args, err := funcParamValidator.NewValidator("str_increment").AddParam("$string", []string{"string"}, nil).Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	input := args[0].(*values.Str).Value
+	if err := validateAlphanumeric("str_increment", input); err != nil {
+		return values.NewVoid(), err
+	}
+
+	return values.NewStr(IncrementString(input)), nil
+}
+
+// IncrementString increments the string like the ++ operator does.
+// The right-most alphanumeric characters are incremented with carry ("a9" => "b0").
+// The carry stops at a non-alphanumeric character ("a-z" => "a-a").
+func IncrementString(input string) string {
+	output := []byte(input)
+	carry := true
+	// Kind of the left-most incremented character
+	var last byte
+	for i := len(output) - 1; i >= 0 && carry; i-- {
+		switch char := output[i]; {
+		case char >= 'a' && char <= 'z':
+			last = 'a'
+		case char >= 'A' && char <= 'Z':
+			last = 'A'
+		case char >= '0' && char <= '9':
+			last = '1'
+		default:
+			return string(output)
+		}
+		switch output[i] {
+		case 'z':
+			output[i] = 'a'
+		case 'Z':
+			output[i] = 'A'
+		case '9':
+			output[i] = '0'
+		default:
+			output[i]++
+			carry = false
+		}
+	}
+
+	// Spec: https://www.php.net/manual/en/function.str-increment.php
+	// If the first character overflows, a character of the same kind is prepended (e.g. "zz" => "aaa", "Zz" => "AAa", "9" => "10").
+	if carry {
+		output = append([]byte{last}, output...)
+	}
+
+	return string(output)
+}
+
+// -------------------------------------- str_ireplace -------------------------------------- MARK: str_ireplace
+
+func nativeFn_str_ireplace(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.str-ireplace.php
+	return replace("str_ireplace", args, true, context)
+}
+
+// -------------------------------------- str_pad -------------------------------------- MARK: str_pad
+
+func nativeFn_str_pad(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.str-pad.php
+
+	args, err := funcParamValidator.NewValidator("str_pad").
+		AddParam("$string", []string{"string"}, nil).
+		AddParam("$length", []string{"int"}, nil).
+		AddParam("$pad_string", []string{"string"}, values.NewStr(" ")).
+		AddParam("$pad_type", []string{"int"}, values.NewInt(STR_PAD_RIGHT)).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	input := args[0].(*values.Str).Value
+	length := args[1].(*values.Int).Value
+	padString := args[2].(*values.Str).Value
+	padType := args[3].(*values.Int).Value
+
+	// Spec: https://www.php.net/manual/en/function.str-pad.php
+	// If the value of length is negative, less than, or equal to the length of the input string, no padding takes place.
+	if length <= int64(len(input)) {
+		return values.NewStr(input), nil
+	}
+	if padString == "" {
+		return values.NewVoid(), phpError.NewError("Uncaught ValueError: str_pad(): Argument #3 ($pad_string) must be a non-empty string")
+	}
+	if padType != STR_PAD_LEFT && padType != STR_PAD_RIGHT && padType != STR_PAD_BOTH {
+		return values.NewVoid(), phpError.NewError("Uncaught ValueError: str_pad(): Argument #4 ($pad_type) must be STR_PAD_LEFT, STR_PAD_RIGHT, or STR_PAD_BOTH")
+	}
+
+	padLength := int(length) - len(input)
+	leftLength := 0
+	switch padType {
+	case STR_PAD_LEFT:
+		leftLength = padLength
+	case STR_PAD_BOTH:
+		leftLength = padLength / 2
+	}
+	pad := func(length int) string {
+		return goStrings.Repeat(padString, length/len(padString)+1)[:length]
+	}
+
+	return values.NewStr(pad(leftLength) + input + pad(padLength-leftLength)), nil
+}
+
+// -------------------------------------- str_repeat -------------------------------------- MARK: str_repeat
+
+func nativeFn_str_repeat(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.str-repeat.php
+
+	args, err := funcParamValidator.NewValidator("str_repeat").
+		AddParam("$string", []string{"string"}, nil).
+		AddParam("$times", []string{"int"}, nil).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	input := args[0].(*values.Str).Value
+	times := args[1].(*values.Int).Value
+
+	// Spec: https://www.php.net/manual/en/function.str-repeat.php
+	// times has to be greater than or equal to 0.
+	// If the times is set to 0, the function will return an empty string.
+	if times < 0 {
+		return values.NewVoid(), phpError.NewError("Uncaught ValueError: str_repeat(): Argument #2 ($times) must be greater than or equal to 0")
+	}
+
+	return values.NewStr(goStrings.Repeat(input, int(times))), nil
+}
+
+// -------------------------------------- str_replace -------------------------------------- MARK: str_replace
+
+func nativeFn_str_replace(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.str-replace.php
+	return replace("str_replace", args, false, context)
+}
+
+// Replace all occurrences of the search string(s) with the replacement string(s) (str_replace and str_ireplace)
+func replace(funcName string, args []values.RuntimeValue, ignoreCase bool, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	hasCount := len(args) > 3
+	args, err := funcParamValidator.NewValidator(funcName).
+		AddParam("$search", []string{"array", "string"}, nil).
+		AddParam("$replace", []string{"array", "string"}, nil).
+		AddParam("$subject", []string{"array", "string"}, nil).
+		AddParam("$count", []string{"mixed"}, values.NewNull()).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	if args[0].GetType() == values.StrValue && args[1].GetType() == values.ArrayValue {
+		return values.NewVoid(), phpError.NewError(
+			"Uncaught TypeError: %s(): Argument #2 ($replace) must be of type string when argument #1 ($search) is a string", funcName,
+		)
+	}
+
+	// Spec: https://www.php.net/manual/en/function.str-replace.php
+	// If search and replace are arrays, then str_replace() takes a value from each array and uses them to search and replace on subject.
+	// If replace has fewer values than search, then an empty string is used for the rest of replacement values.
+	// If search is an array and replace is a string, then this replacement string is used for every value of search.
+	var searches, replacements []string
+	if args[0].GetType() == values.StrValue {
+		searches = []string{args[0].(*values.Str).Value}
+		replacements = []string{args[1].(*values.Str).Value}
+	} else {
+		var replaceValues []values.RuntimeValue
+		if args[1].GetType() == values.ArrayValue {
+			replaceValues = args[1].(*values.Array).GetValues()
+		}
+		for index, searchValue := range args[0].(*values.Array).GetValues() {
+			search, err := toString(searchValue, context)
+			if err != nil {
+				return values.NewVoid(), err
+			}
+			replacement := ""
+			if args[1].GetType() == values.StrValue {
+				replacement = args[1].(*values.Str).Value
+			} else if index < len(replaceValues) {
+				if replacement, err = toString(replaceValues[index], context); err != nil {
+					return values.NewVoid(), err
+				}
+			}
+			searches = append(searches, search)
+			replacements = append(replacements, replacement)
+		}
+	}
+
+	count := int64(0)
+	replaceAll := func(subject string) string {
+		for index, search := range searches {
+			var replaced int64
+			subject, replaced = replaceString(subject, search, replacements[index], ignoreCase)
+			count += replaced
+		}
+		return subject
+	}
+
+	var result values.RuntimeValue
+	if args[2].GetType() == values.StrValue {
+		result = values.NewStr(replaceAll(args[2].(*values.Str).Value))
+	} else {
+		// Spec: https://www.php.net/manual/en/function.str-replace.php
+		// If subject is an array, then the search and replace is performed with every entry of subject.
+		// Nested arrays and objects are returned unchanged.
+		subjects := args[2].(*values.Array)
+		resultArray := values.NewArray()
+		for key, subject := range subjects.Iterate() {
+			if subject.GetType() != values.ArrayValue && subject.GetType() != values.ObjectValue {
+				str, err := toString(subject, context)
+				if err != nil {
+					return values.NewVoid(), err
+				}
+				subject = values.NewStr(replaceAll(str))
+			}
+			if err := resultArray.SetElement(key, subject); err != nil {
+				return values.NewVoid(), err
+			}
+		}
+		result = resultArray
+	}
+
+	if hasCount {
+		if err := context.Interpreter.SetByRefArgument(context, 3, values.NewInt(count)); err != nil {
+			return values.NewVoid(), err
+		}
+	}
+	return result, nil
+}
+
+// Replace all occurrences of search and return the number of replacements. An empty search string is ignored.
+func replaceString(subject string, search string, replacement string, ignoreCase bool) (string, int64) {
+	if search == "" {
+		return subject, 0
+	}
+
+	haystack := subject
+	if ignoreCase {
+		haystack, search = asciiToLower(subject), asciiToLower(search)
+	}
+
+	var output goStrings.Builder
+	count := int64(0)
+	position := 0
+	for {
+		index := goStrings.Index(haystack[position:], search)
+		if index < 0 {
+			break
+		}
+		output.WriteString(subject[position:position+index] + replacement)
+		position += index + len(search)
+		count++
+	}
+	if count == 0 {
+		return subject, 0
+	}
+	output.WriteString(subject[position:])
+	return output.String(), count
+}
+
+// -------------------------------------- str_rot13 -------------------------------------- MARK: str_rot13
+
+func nativeFn_str_rot13(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.str-rot13.php
+
+	args, err := funcParamValidator.NewValidator("str_rot13").AddParam("$string", []string{"string"}, nil).Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Spec: https://www.php.net/manual/en/function.str-rot13.php
+	// The ROT13 encoding simply shifts every letter by 13 places in the alphabet while leaving non-alpha characters untouched.
+	output := []byte(args[0].(*values.Str).Value)
+	for i, char := range output {
+		switch {
+		case (char >= 'a' && char <= 'm') || (char >= 'A' && char <= 'M'):
+			output[i] = char + 13
+		case (char >= 'n' && char <= 'z') || (char >= 'N' && char <= 'Z'):
+			output[i] = char - 13
+		}
+	}
+
+	return values.NewStr(string(output)), nil
+}
+
+// -------------------------------------- str_shuffle -------------------------------------- MARK: str_shuffle
+
+func nativeFn_str_shuffle(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.str-shuffle.php
+
+	args, err := funcParamValidator.NewValidator("str_shuffle").AddParam("$string", []string{"string"}, nil).Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	output := []byte(args[0].(*values.Str).Value)
+	rand.Shuffle(len(output), func(i, j int) { output[i], output[j] = output[j], output[i] })
+
+	return values.NewStr(string(output)), nil
+}
+
+// -------------------------------------- str_split -------------------------------------- MARK: str_split
+
+func nativeFn_str_split(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.str-split.php
+
+	args, err := funcParamValidator.NewValidator("str_split").
+		AddParam("$string", []string{"string"}, nil).
+		AddParam("$length", []string{"int"}, values.NewInt(1)).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	input := args[0].(*values.Str).Value
+	length := args[1].(*values.Int).Value
+
+	if length < 1 {
+		return values.NewVoid(), phpError.NewError("Uncaught ValueError: str_split(): Argument #2 ($length) must be greater than 0")
+	}
+
+	// Spec: https://www.php.net/manual/en/function.str-split.php
+	// If length is greater than the length of string, the entire string is returned as the first (and only) array element.
+	// Returns an empty array if string is empty (as of PHP 8.2.0).
+	result := values.NewArray()
+	for len(input) > 0 {
+		chunkLength := int(min(length, int64(len(input))))
+		if err := result.SetElement(nil, values.NewStr(input[:chunkLength])); err != nil {
+			return values.NewVoid(), err
+		}
+		input = input[chunkLength:]
+	}
+	return result, nil
+}
+
+// -------------------------------------- str_starts_with -------------------------------------- MARK: str_starts_with
+
+func nativeFn_str_starts_with(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.str-starts-with.php
+
+	args, err := funcParamValidator.NewValidator("str_starts_with").
+		AddParam("$haystack", []string{"string"}, nil).
+		AddParam("$needle", []string{"string"}, nil).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	haystack := args[0].(*values.Str).Value
+	needle := args[1].(*values.Str).Value
+
+	return values.NewBool(goStrings.HasPrefix(haystack, needle)), nil
+}
+
+// -------------------------------------- str_word_count -------------------------------------- MARK: str_word_count
+
+func nativeFn_str_word_count(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.str-word-count.php
+
+	args, err := funcParamValidator.NewValidator("str_word_count").
+		AddParam("$string", []string{"string"}, nil).
+		AddParam("$format", []string{"int"}, values.NewInt(0)).
+		AddParam("$characters", []string{"string", "null"}, values.NewNull()).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	input := args[0].(*values.Str).Value
+	format := args[1].(*values.Int).Value
+
+	if format < 0 || format > 2 {
+		return values.NewVoid(), phpError.NewError("Uncaught ValueError: str_word_count(): Argument #2 ($format) must be a valid format value")
+	}
+
+	var mask [256]bool
+	if args[2].GetType() == values.StrValue {
+		mask = charMask("str_word_count", args[2].(*values.Str).Value, context)
+	}
+
+	// Spec: https://www.php.net/manual/en/function.str-word-count.php
+	// Words are strings of alphabetic characters which also may contain, but not start with "'" and "-" characters.
+	// The last character of a word cannot be "-" either. Additional characters can be allowed with the characters parameter.
+	start, end := 0, len(input)
+	if end > 0 && ((input[0] == '\'' && !mask['\'']) || (input[0] == '-' && !mask['-'])) {
+		start++
+	}
+	if end > 0 && input[end-1] == '-' && !mask['-'] {
+		end--
+	}
+
+	result := values.NewArray()
+	count := int64(0)
+	for position := start; position < end; position++ {
+		wordStart := position
+		for position < end && (isAsciiAlpha(input[position]) || mask[input[position]] || input[position] == '\'' || input[position] == '-') {
+			position++
+		}
+		if position == wordStart {
+			continue
+		}
+		count++
+		var key values.RuntimeValue
+		if format == 2 {
+			key = values.NewInt(int64(wordStart))
+		}
+		if err := result.SetElement(key, values.NewStr(input[wordStart:position])); err != nil {
+			return values.NewVoid(), err
+		}
+	}
+
+	if format == 0 {
+		return values.NewInt(count), nil
+	}
+	return result, nil
+}
+
+// -------------------------------------- strcasecmp -------------------------------------- MARK: strcasecmp
+
+func nativeFn_strcasecmp(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.strcasecmp.php
+
+	args, err := funcParamValidator.NewValidator("strcasecmp").
+		AddParam("$string1", []string{"string"}, nil).
+		AddParam("$string2", []string{"string"}, nil).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	return values.NewInt(compareStrings(asciiToLower(args[0].(*values.Str).Value), asciiToLower(args[1].(*values.Str).Value))), nil
+}
+
+// -------------------------------------- strcmp -------------------------------------- MARK: strcmp
+
+func nativeFn_strcmp(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.strcmp.php
+
+	args, err := funcParamValidator.NewValidator("strcmp").
+		AddParam("$string1", []string{"string"}, nil).
+		AddParam("$string2", []string{"string"}, nil).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Spec: https://www.php.net/manual/en/function.strcmp.php
+	// Returns -1 if string1 is less than string2; 1 if string1 is greater than string2, and 0 if they are equal.
+	return values.NewInt(compareStrings(args[0].(*values.Str).Value, args[1].(*values.Str).Value)), nil
+}
+
+// -------------------------------------- strcoll -------------------------------------- MARK: strcoll
+
+func nativeFn_strcoll(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.strcoll.php
+
+	args, err := funcParamValidator.NewValidator("strcoll").
+		AddParam("$string1", []string{"string"}, nil).
+		AddParam("$string2", []string{"string"}, nil).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// TODO strcoll - Use the current locale (setlocale). The "C" locale compares the bytes like strcmp.
+	return values.NewInt(compareStrings(args[0].(*values.Str).Value, args[1].(*values.Str).Value)), nil
+}
+
+// -------------------------------------- strcspn -------------------------------------- MARK: strcspn
+
+func nativeFn_strcspn(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.strcspn.php
+	return span("strcspn", args, false)
+}
+
+// Get the length of the initial segment of the string that (if accept is set) only or (otherwise) not contains the characters (strspn and strcspn)
+func span(funcName string, args []values.RuntimeValue, accept bool) (values.RuntimeValue, phpError.Error) {
+	args, err := funcParamValidator.NewValidator(funcName).
+		AddParam("$string", []string{"string"}, nil).
+		AddParam("$characters", []string{"string"}, nil).
+		AddParam("$offset", []string{"int"}, values.NewInt(0)).
+		AddParam("$length", []string{"int", "null"}, values.NewNull()).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	input := args[0].(*values.Str).Value
+	characters := args[1].(*values.Str).Value
+	offset := args[2].(*values.Int).Value
+
+	var length int64
+	hasLength := args[3].GetType() == values.IntValue
+	if hasLength {
+		length = args[3].(*values.Int).Value
+	}
+	start, end := substrBounds(len(input), offset, length, hasLength)
+
+	count := 0
+	for _, char := range []byte(input[start:end]) {
+		if (goStrings.IndexByte(characters, char) >= 0) != accept {
+			break
+		}
+		count++
+	}
+	return values.NewInt(int64(count)), nil
+}
+
+// -------------------------------------- strip_tags -------------------------------------- MARK: strip_tags
+
+func nativeFn_strip_tags(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.strip-tags.php
+
+	args, err := funcParamValidator.NewValidator("strip_tags").
+		AddParam("$string", []string{"string"}, nil).
+		AddParam("$allowed_tags", []string{"array", "string", "null"}, values.NewNull()).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Spec: https://www.php.net/manual/en/function.strip-tags.php
+	// The allowed tags can be given as string (e.g. "<a><b>") or as array (e.g. ["a", "b"]).
+	allowedTags := ""
+	switch args[1].GetType() {
+	case values.StrValue:
+		allowedTags = asciiToLower(args[1].(*values.Str).Value)
+	case values.ArrayValue:
+		for _, tag := range args[1].(*values.Array).GetValues() {
+			tagName, err := toString(tag, context)
+			if err != nil {
+				return values.NewVoid(), err
+			}
+			allowedTags += "<" + asciiToLower(tagName) + ">"
+		}
+	}
+
+	return values.NewStr(stripTags(args[0].(*values.Str).Value, allowedTags)), nil
+}
+
+// Remove HTML and PHP tags and comments like PHP's php_strip_tags.
+// Tags whose normalized form (e.g. "<a>" for `<a href="">` or "</a>") is contained in allowedTags are kept.
+func stripTags(input string, allowedTags string) string {
+	const (
+		stateText = iota
+		stateHtmlTag
+		statePhpTag
+		stateDeclaration
+		stateComment
+	)
+
+	var output, tag goStrings.Builder
+	state := stateText
+	depth := 0
+	var quote byte
+	for i := 0; i < len(input); i++ {
+		char := input[i]
+		switch state {
+		case stateText:
+			if char == '<' && !(isSpace(byteAt(input, i+1)) && allowedTags == "") {
+				state = stateHtmlTag
+				tag.Reset()
+				tag.WriteByte(char)
+			} else {
+				output.WriteByte(char)
+			}
+
+		case stateHtmlTag:
+			switch {
+			case char == '<' && quote == 0 && !(isSpace(byteAt(input, i+1)) && allowedTags == ""):
+				depth++
+			case char == '>' && depth > 0:
+				depth--
+				continue
+			case char == '>' && quote == 0:
+				tag.WriteByte(char)
+				if allowedTags != "" && goStrings.Contains(allowedTags, normalizeTag(tag.String())) {
+					output.WriteString(tag.String())
+				}
+				state = stateText
+				continue
+			case char == '"' || char == '\'':
+				if quote == 0 {
+					quote = char
+				} else if quote == char {
+					quote = 0
+				}
+			case char == '!' && input[i-1] == '<':
+				state = stateDeclaration
+				continue
+			case char == '?' && input[i-1] == '<':
+				state = statePhpTag
+				continue
+			}
+			tag.WriteByte(char)
+
+		case statePhpTag:
+			switch {
+			case char == '"':
+				if quote == 0 {
+					quote = char
+				} else {
+					quote = 0
+				}
+			case char == '>' && quote == 0 && input[i-1] == '?':
+				state = stateText
+			}
+
+		case stateDeclaration:
+			switch {
+			case char == '-' && input[i-1] == '-' && input[i-2] == '!':
+				state = stateComment
+			case char == '"' || char == '\'':
+				if quote == 0 {
+					quote = char
+				} else if quote == char {
+					quote = 0
+				}
+			case char == '<' && quote == 0:
+				depth++
+			case char == '>' && depth > 0:
+				depth--
+			case char == '>' && quote == 0:
+				state = stateText
+			}
+
+		case stateComment:
+			if char == '>' && input[i-1] == '-' && input[i-2] == '-' {
+				state = stateText
+				quote = 0
+			}
+		}
+	}
+
+	return output.String()
+}
+
+// Normalize a tag (e.g. `<A href="">` to "<a>", "</a>" to "<a>" and "<br/>" to "<br>")
+func normalizeTag(tag string) string {
+	output := []byte{'<'}
+	inName := false
+	for i := 1; i < len(tag) && tag[i] != '>'; i++ {
+		char := lowerByte(tag[i])
+		if isSpace(char) {
+			if inName {
+				break
+			}
+			continue
+		}
+		inName = true
+		if char != '/' || (tag[i-1] != '<' && byteAt(tag, i+1) != '>') {
+			output = append(output, char)
+		}
+	}
+	return string(append(output, '>'))
+}
+
+// -------------------------------------- stripcslashes -------------------------------------- MARK: stripcslashes
+
+func nativeFn_stripcslashes(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.stripcslashes.php
+
+	args, err := funcParamValidator.NewValidator("stripcslashes").AddParam("$string", []string{"string"}, nil).Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	input := args[0].(*values.Str).Value
+
+	// Spec: https://www.php.net/manual/en/function.stripcslashes.php
+	// Recognizes C-like \a, \b, \f, \n, \r, \t and \v, as well as octal and hexadecimal representation.
+	var output goStrings.Builder
+	for i := 0; i < len(input); i++ {
+		if input[i] != '\\' || i+1 >= len(input) {
+			output.WriteByte(input[i])
+			continue
+		}
+		i++
+		switch input[i] {
+		case 'n':
+			output.WriteByte('\n')
+		case 'r':
+			output.WriteByte('\r')
+		case 'a':
+			output.WriteByte('\a')
+		case 't':
+			output.WriteByte('\t')
+		case 'v':
+			output.WriteByte('\v')
+		case 'b':
+			output.WriteByte('\b')
+		case 'f':
+			output.WriteByte('\f')
+		case '\\':
+			output.WriteByte('\\')
+		default:
+			if input[i] == 'x' && isHexDigit(byteAt(input, i+1)) {
+				// Hexadecimal notation with one or two digits
+				char := hexValue(input[i+1])
+				i++
+				if isHexDigit(byteAt(input, i+1)) {
+					char = char<<4 | hexValue(input[i+1])
+					i++
+				}
+				output.WriteByte(char)
+				continue
+			}
+			// Octal notation with up to three digits
+			digits := 0
+			char := 0
+			for digits < 3 && i+digits < len(input) && input[i+digits] >= '0' && input[i+digits] <= '7' {
+				char = char*8 + int(input[i+digits]-'0')
+				digits++
+			}
+			if digits == 0 {
+				output.WriteByte(input[i])
+				continue
+			}
+			output.WriteByte(byte(char))
+			i += digits - 1
+		}
+	}
+
+	return values.NewStr(output.String()), nil
+}
+
+// -------------------------------------- stripos -------------------------------------- MARK: stripos
+
+func nativeFn_stripos(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.stripos.php
+	return position("stripos", args, true, false)
+}
+
+// Find the position of the first or (if last is set) the last occurrence of the needle (strpos, stripos, strrpos and strripos)
+func position(funcName string, args []values.RuntimeValue, ignoreCase bool, last bool) (values.RuntimeValue, phpError.Error) {
+	args, err := funcParamValidator.NewValidator(funcName).
+		AddParam("$haystack", []string{"string"}, nil).
+		AddParam("$needle", []string{"string"}, nil).
+		AddParam("$offset", []string{"int"}, values.NewInt(0)).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	haystack := args[0].(*values.Str).Value
+	needle := args[1].(*values.Str).Value
+	offset := args[2].(*values.Int).Value
+
+	if ignoreCase {
+		haystack, needle = asciiToLower(haystack), asciiToLower(needle)
+	}
+
+	// Spec: https://www.php.net/manual/en/function.strpos.php
+	// If the offset is negative, the search will start this number of characters counted from the end of the string.
+	if offset < -int64(len(haystack)) || offset > int64(len(haystack)) {
+		return values.NewVoid(), phpError.NewError("Uncaught ValueError: %s(): Argument #3 ($offset) must be contained in argument #1 ($haystack)", funcName)
+	}
+
+	var index int
+	if !last {
+		if offset < 0 {
+			offset += int64(len(haystack))
+		}
+		index = goStrings.Index(haystack[offset:], needle)
+		if index >= 0 {
+			index += int(offset)
+		}
+	} else {
+		// Spec: https://www.php.net/manual/en/function.strrpos.php
+		// If the offset is negative, the search will start this number of characters counted from the end of the string
+		// and the needle may not end after this position.
+		start, end := int(offset), len(haystack)
+		if offset < 0 {
+			start = 0
+			if -offset >= int64(len(needle)) {
+				end = len(haystack) + int(offset) + len(needle)
+			}
+		}
+		index = goStrings.LastIndex(haystack[start:end], needle)
+		if index >= 0 {
+			index += start
+		}
+	}
+
+	if index < 0 {
+		return values.NewBool(false), nil
+	}
+	return values.NewInt(int64(index)), nil
+}
+
+// -------------------------------------- stripslashes -------------------------------------- MARK: stripslashes
+
+func nativeFn_stripslashes(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.stripslashes.php
+
+	args, err := funcParamValidator.NewValidator("stripslashes").AddParam("$string", []string{"string"}, nil).Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	input := args[0].(*values.Str).Value
+
+	// Spec: https://www.php.net/manual/en/function.stripslashes.php
+	// Backslashes are removed (\' becomes ' and so on). Double backslashes (\\) are made into a single backslash (\).
+	// The escaped NUL byte (\0) becomes a NUL byte.
+	var output goStrings.Builder
+	for i := 0; i < len(input); i++ {
+		if input[i] != '\\' {
+			output.WriteByte(input[i])
+			continue
+		}
+		i++
+		if i < len(input) {
+			if input[i] == '0' {
+				output.WriteByte(0)
+			} else {
+				output.WriteByte(input[i])
+			}
+		}
+	}
+
+	return values.NewStr(output.String()), nil
+}
+
+// -------------------------------------- stristr -------------------------------------- MARK: stristr
+
+func nativeFn_stristr(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.stristr.php
+	return find("stristr", args, true)
+}
+
+// Get the part of the haystack starting at (or if before_needle is set ending before) the first occurrence of the needle (strstr and stristr)
+func find(funcName string, args []values.RuntimeValue, ignoreCase bool) (values.RuntimeValue, phpError.Error) {
+	args, err := funcParamValidator.NewValidator(funcName).
+		AddParam("$haystack", []string{"string"}, nil).
+		AddParam("$needle", []string{"string"}, nil).
+		AddParam("$before_needle", []string{"bool"}, values.NewBool(false)).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	haystack := args[0].(*values.Str).Value
+	needle := args[1].(*values.Str).Value
+
+	var index int
+	if ignoreCase {
+		index = goStrings.Index(asciiToLower(haystack), asciiToLower(needle))
+	} else {
+		index = goStrings.Index(haystack, needle)
+	}
+
+	if index < 0 {
+		return values.NewBool(false), nil
+	}
+	if args[2].(*values.Bool).Value {
+		return values.NewStr(haystack[:index]), nil
+	}
+	return values.NewStr(haystack[index:]), nil
+}
+
+// -------------------------------------- strlen -------------------------------------- MARK: strlen
+
+func nativeFn_strlen(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.strlen.php
+
+	args, err := funcParamValidator.NewValidator("strlen").AddParam("$string", []string{"string"}, nil).Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	return values.NewInt(int64(len(args[0].(*values.Str).Value))), nil
+}
+
+// -------------------------------------- strnatcasecmp -------------------------------------- MARK: strnatcasecmp
+
+func nativeFn_strnatcasecmp(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.strnatcasecmp.php
+
+	args, err := funcParamValidator.NewValidator("strnatcasecmp").
+		AddParam("$string1", []string{"string"}, nil).
+		AddParam("$string2", []string{"string"}, nil).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	return values.NewInt(int64(common.NaturalCompare(args[0].(*values.Str).Value, args[1].(*values.Str).Value, true))), nil
+}
+
+// -------------------------------------- strnatcmp -------------------------------------- MARK: strnatcmp
+
+func nativeFn_strnatcmp(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.strnatcmp.php
+
+	args, err := funcParamValidator.NewValidator("strnatcmp").
+		AddParam("$string1", []string{"string"}, nil).
+		AddParam("$string2", []string{"string"}, nil).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	return values.NewInt(int64(common.NaturalCompare(args[0].(*values.Str).Value, args[1].(*values.Str).Value, false))), nil
+}
+
+// -------------------------------------- strncasecmp -------------------------------------- MARK: strncasecmp
+
+func nativeFn_strncasecmp(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.strncasecmp.php
+	return compareFirstBytes("strncasecmp", args, true)
+}
+
+// Compare the first length bytes of the strings (strncmp and strncasecmp)
+func compareFirstBytes(funcName string, args []values.RuntimeValue, ignoreCase bool) (values.RuntimeValue, phpError.Error) {
+	args, err := funcParamValidator.NewValidator(funcName).
+		AddParam("$string1", []string{"string"}, nil).
+		AddParam("$string2", []string{"string"}, nil).
+		AddParam("$length", []string{"int"}, nil).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	string1 := args[0].(*values.Str).Value
+	string2 := args[1].(*values.Str).Value
+	length := args[2].(*values.Int).Value
+
+	if length < 0 {
+		return values.NewVoid(), phpError.NewError("Uncaught ValueError: %s(): Argument #3 ($length) must be greater than or equal to 0", funcName)
+	}
+
+	if ignoreCase {
+		string1, string2 = asciiToLower(string1), asciiToLower(string2)
+	}
+	return values.NewInt(compareStrings(string1[:min(length, int64(len(string1)))], string2[:min(length, int64(len(string2)))])), nil
+}
+
+// -------------------------------------- strncmp -------------------------------------- MARK: strncmp
+
+func nativeFn_strncmp(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.strncmp.php
+	return compareFirstBytes("strncmp", args, false)
+}
+
+// -------------------------------------- strpbrk -------------------------------------- MARK: strpbrk
+
+func nativeFn_strpbrk(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.strpbrk.php
+
+	args, err := funcParamValidator.NewValidator("strpbrk").
+		AddParam("$string", []string{"string"}, nil).
+		AddParam("$characters", []string{"string"}, nil).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	input := args[0].(*values.Str).Value
+	characters := args[1].(*values.Str).Value
+
+	if characters == "" {
+		return values.NewVoid(), phpError.NewError("Uncaught ValueError: strpbrk(): Argument #2 ($characters) must be a non-empty string")
+	}
+
+	for i := 0; i < len(input); i++ {
+		if goStrings.IndexByte(characters, input[i]) >= 0 {
+			return values.NewStr(input[i:]), nil
+		}
+	}
+	return values.NewBool(false), nil
+}
+
+// -------------------------------------- strpos -------------------------------------- MARK: strpos
+
+func nativeFn_strpos(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.strpos.php
+	return position("strpos", args, false, false)
+}
+
+// -------------------------------------- strrchr -------------------------------------- MARK: strrchr
+
+func nativeFn_strrchr(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.strrchr.php
+
+	args, err := funcParamValidator.NewValidator("strrchr").
+		AddParam("$haystack", []string{"string"}, nil).
+		AddParam("$needle", []string{"string"}, nil).
+		AddParam("$before_needle", []string{"bool"}, values.NewBool(false)).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	haystack := args[0].(*values.Str).Value
+
+	// Spec: https://www.php.net/manual/en/function.strrchr.php
+	// If needle contains more than one character, only the first is used.
+	index := goStrings.LastIndexByte(haystack, byteAt(args[1].(*values.Str).Value, 0))
+	if index < 0 {
+		return values.NewBool(false), nil
+	}
+	if args[2].(*values.Bool).Value {
+		return values.NewStr(haystack[:index]), nil
+	}
+	return values.NewStr(haystack[index:]), nil
+}
+
+// -------------------------------------- strrev -------------------------------------- MARK: strrev
+
+func nativeFn_strrev(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.strrev.php
+
+	args, err := funcParamValidator.NewValidator("strrev").AddParam("$string", []string{"string"}, nil).Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	output := []byte(args[0].(*values.Str).Value)
+	slices.Reverse(output)
+
+	return values.NewStr(string(output)), nil
+}
+
+// -------------------------------------- strripos -------------------------------------- MARK: strripos
+
+func nativeFn_strripos(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.strripos.php
+	return position("strripos", args, true, true)
+}
+
+// -------------------------------------- strrpos -------------------------------------- MARK: strrpos
+
+func nativeFn_strrpos(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.strrpos.php
+	return position("strrpos", args, false, true)
+}
+
+// -------------------------------------- strspn -------------------------------------- MARK: strspn
+
+func nativeFn_strspn(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.strspn.php
+	return span("strspn", args, true)
+}
+
+// -------------------------------------- strstr -------------------------------------- MARK: strstr
+
+func nativeFn_strstr(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.strstr.php
+	return find("strstr", args, false)
+}
+
+// -------------------------------------- strtok -------------------------------------- MARK: strtok
+
+// Request-scoped state of the string functions
+type stringsState struct {
+	// The string that is tokenized by strtok and the position of the next token (-1 if there are no more tokens)
+	strtokString   string
+	strtokPosition int
+}
+
+func getState(context runtime.Context) *stringsState {
+	return context.Interpreter.GetExtensionState("strings", func() any { return &stringsState{strtokPosition: -1} }).(*stringsState)
+}
+
+func nativeFn_strtok(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.strtok.php
+
+	args, err := funcParamValidator.NewValidator("strtok").
+		AddParam("$string", []string{"string"}, nil).
+		AddParam("$token", []string{"string", "null"}, values.NewNull()).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Spec: https://www.php.net/manual/en/function.strtok.php
+	// Only the first call to strtok uses the string argument.
+	// Every subsequent call to strtok only needs the token to use, as it keeps track of where it is in the current string.
+	state := getState(context)
+	var token string
+	if args[1].GetType() == values.NullValue {
+		token = args[0].(*values.Str).Value
+	} else {
+		token = args[1].(*values.Str).Value
+		state.strtokString = args[0].(*values.Str).Value
+		state.strtokPosition = 0
+	}
+
+	if state.strtokPosition < 0 || state.strtokPosition >= len(state.strtokString) {
+		return values.NewBool(false), nil
+	}
+
+	// Skip leading delimiters
+	input := state.strtokString
+	start := state.strtokPosition
+	for start < len(input) && goStrings.IndexByte(token, input[start]) >= 0 {
+		start++
+	}
+	if start >= len(input) {
+		state.strtokString, state.strtokPosition = "", -1
+		return values.NewBool(false), nil
+	}
+
+	end := start + 1
+	for end < len(input) && goStrings.IndexByte(token, input[end]) < 0 {
+		end++
+	}
+	state.strtokPosition = end + 1
+	return values.NewStr(input[start:end]), nil
+}
+
+// -------------------------------------- strtolower -------------------------------------- MARK: strtolower
+
+func nativeFn_strtolower(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.strtolower.php
+
+	args, err := funcParamValidator.NewValidator("strtolower").AddParam("$string", []string{"string"}, nil).Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Spec: https://www.php.net/manual/en/function.strtolower.php
+	// Bytes in the range "A" (0x41) to "Z" (0x5a) will be converted to the corresponding lowercase letter by adding 32 to each byte value.
+
+	input := args[0].(*values.Str).Value
+
+	for i := 0; i < len(input); i++ {
+		if input[i] >= 'A' && input[i] <= 'Z' {
+			input = input[:i] + string(input[i]+32) + input[i+1:]
+		}
+	}
+
+	return values.NewStr(input), nil
+}
+
+// -------------------------------------- strtoupper -------------------------------------- MARK: strtoupper
+
+func nativeFn_strtoupper(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.strtoupper.php
+
+	args, err := funcParamValidator.NewValidator("strtoupper").AddParam("$string", []string{"string"}, nil).Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Spec: https://www.php.net/manual/en/function.strtoupper.php
+	// Bytes in the range "a" (0x61) to "z" (0x7a) will be converted to the corresponding uppercase letter by subtracting 32 from each byte value.
+
+	input := args[0].(*values.Str).Value
+
+	for i := 0; i < len(input); i++ {
+		if input[i] >= 'a' && input[i] <= 'z' {
+			input = input[:i] + string(input[i]-32) + input[i+1:]
+		}
+	}
+
+	return values.NewStr(input), nil
+}
+
+// -------------------------------------- strtr -------------------------------------- MARK: strtr
+
+func nativeFn_strtr(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.strtr.php
+
+	args, err := funcParamValidator.NewValidator("strtr").
+		AddParam("$string", []string{"string"}, nil).
+		AddParam("$from", []string{"array", "string"}, nil).
+		AddParam("$to", []string{"string", "null"}, values.NewNull()).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	input := args[0].(*values.Str).Value
+
+	if args[2].GetType() == values.NullValue {
+		if args[1].GetType() != values.ArrayValue {
+			return values.NewVoid(), phpError.NewError("Uncaught TypeError: strtr(): Argument #2 ($from) must be of type array, string given")
+		}
+		return strtrPairs(input, args[1].(*values.Array), context)
+	}
+
+	if args[1].GetType() != values.StrValue {
+		return values.NewVoid(), phpError.NewError("Uncaught TypeError: strtr(): Argument #2 ($from) must be of type string, array given")
+	}
+
+	// Spec: https://www.php.net/manual/en/function.strtr.php
+	// If from and to have different lengths, the extra characters in the longer of the two are ignored.
+	from := args[1].(*values.Str).Value
+	to := args[2].(*values.Str).Value
+	length := min(len(from), len(to))
+	var table [256]byte
+	for i := range table {
+		table[i] = byte(i)
+	}
+	for i := 0; i < length; i++ {
+		table[from[i]] = to[i]
+	}
+
+	output := []byte(input)
+	for i, char := range output {
+		output[i] = table[char]
+	}
+	return values.NewStr(string(output)), nil
+}
+
+// Replace the keys of the array with the values. The longest keys are tried first
+// and replaced parts are not searched again. Empty keys are ignored.
+func strtrPairs(input string, pairs *values.Array, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	replacements := map[string]string{}
+	minLength, maxLength := math.MaxInt, 0
+	for key, value := range pairs.Iterate() {
+		search, err := toString(key, context)
+		if err != nil {
+			return values.NewVoid(), err
+		}
+		if search == "" {
+			continue
+		}
+		replacement, err := toString(value, context)
+		if err != nil {
+			return values.NewVoid(), err
+		}
+		replacements[search] = replacement
+		minLength, maxLength = min(minLength, len(search)), max(maxLength, len(search))
+	}
+	if len(replacements) == 0 {
+		return values.NewStr(input), nil
+	}
+
+	var output goStrings.Builder
+	for i := 0; i < len(input); {
+		found := false
+		for length := min(maxLength, len(input)-i); length >= minLength; length-- {
+			if replacement, ok := replacements[input[i:i+length]]; ok {
+				output.WriteString(replacement)
+				i += length
+				found = true
+				break
+			}
+		}
+		if !found {
+			output.WriteByte(input[i])
+			i++
+		}
+	}
+	return values.NewStr(output.String()), nil
+}
+
+// -------------------------------------- substr -------------------------------------- MARK: substr
+
+func nativeFn_substr(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.substr.php
+
+	args, err := funcParamValidator.NewValidator("substr").
+		AddParam("$string", []string{"string"}, nil).
+		AddParam("$offset", []string{"int"}, nil).
+		AddParam("$length", []string{"int", "null"}, values.NewNull()).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	input := args[0].(*values.Str).Value
+	offset := args[1].(*values.Int).Value
+
+	// Spec: https://www.php.net/manual/en/function.substr.php
+	// If offset is negative, the returned string will start at the offset'th character from the end of string.
+	// If length is negative, then that many characters will be omitted from the end of string.
+	// If offset denotes the position of this truncation or beyond, an empty string will be returned.
+	var length int64
+	hasLength := args[2].GetType() == values.IntValue
+	if hasLength {
+		length = args[2].(*values.Int).Value
+	}
+	start, end := substrBounds(len(input), offset, length, hasLength)
+
+	return values.NewStr(input[start:end]), nil
+}
+
+// -------------------------------------- substr_compare -------------------------------------- MARK: substr_compare
+
+func nativeFn_substr_compare(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.substr-compare.php
+
+	args, err := funcParamValidator.NewValidator("substr_compare").
+		AddParam("$haystack", []string{"string"}, nil).
+		AddParam("$needle", []string{"string"}, nil).
+		AddParam("$offset", []string{"int"}, nil).
+		AddParam("$length", []string{"int", "null"}, values.NewNull()).
+		AddParam("$case_insensitive", []string{"bool"}, values.NewBool(false)).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	haystack := args[0].(*values.Str).Value
+	needle := args[1].(*values.Str).Value
+	offset := args[2].(*values.Int).Value
+
+	hasLength := args[3].GetType() == values.IntValue
+	var length int64
+	if hasLength {
+		length = args[3].(*values.Int).Value
+		if length == 0 {
+			return values.NewInt(0), nil
+		}
+		if length < 0 {
+			return values.NewVoid(), phpError.NewError("Uncaught ValueError: substr_compare(): Argument #4 ($length) must be greater than or equal to 0")
+		}
+	}
+
+	if offset < 0 {
+		offset = max(int64(len(haystack))+offset, 0)
+	}
+	if offset > int64(len(haystack)) {
+		return values.NewVoid(), phpError.NewError("Uncaught ValueError: substr_compare(): Argument #3 ($offset) must be contained in argument #1 ($haystack)")
+	}
+
+	haystack = haystack[offset:]
+	if !hasLength {
+		length = int64(max(len(haystack), len(needle)))
+	}
+	if args[4].(*values.Bool).Value {
+		haystack, needle = asciiToLower(haystack), asciiToLower(needle)
+	}
+	return values.NewInt(compareStrings(haystack[:min(length, int64(len(haystack)))], needle[:min(length, int64(len(needle)))])), nil
+}
+
+// -------------------------------------- substr_count -------------------------------------- MARK: substr_count
+
+func nativeFn_substr_count(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.substr-count.php
+
+	args, err := funcParamValidator.NewValidator("substr_count").
+		AddParam("$haystack", []string{"string"}, nil).
+		AddParam("$needle", []string{"string"}, nil).
+		AddParam("$offset", []string{"int"}, values.NewInt(0)).
+		AddParam("$length", []string{"int", "null"}, values.NewNull()).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	haystack := args[0].(*values.Str).Value
+	needle := args[1].(*values.Str).Value
+	offset := args[2].(*values.Int).Value
+
+	if needle == "" {
+		return values.NewVoid(), phpError.NewError("Uncaught ValueError: substr_count(): Argument #2 ($needle) cannot be empty")
+	}
+
+	// Spec: https://www.php.net/manual/en/function.substr-count.php
+	// The offset and the length may be negative to count from the end of the string.
+	// This function doesn't count overlapped substrings.
+	if offset < 0 {
+		offset += int64(len(haystack))
+	}
+	if offset < 0 || offset > int64(len(haystack)) {
+		return values.NewVoid(), phpError.NewError("Uncaught ValueError: substr_count(): Argument #3 ($offset) must be contained in argument #1 ($haystack)")
+	}
+	haystack = haystack[offset:]
+
+	if args[3].GetType() == values.IntValue {
+		length := args[3].(*values.Int).Value
+		if length < 0 {
+			length += int64(len(haystack))
+		}
+		if length < 0 || length > int64(len(haystack)) {
+			return values.NewVoid(), phpError.NewError("Uncaught ValueError: substr_count(): Argument #4 ($length) must be contained in argument #1 ($haystack)")
+		}
+		haystack = haystack[:length]
+	}
+
+	return values.NewInt(int64(goStrings.Count(haystack, needle))), nil
+}
+
+// -------------------------------------- substr_replace -------------------------------------- MARK: substr_replace
+
+func nativeFn_substr_replace(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.substr-replace.php
+
+	args, err := funcParamValidator.NewValidator("substr_replace").
+		AddParam("$string", []string{"array", "string"}, nil).
+		AddParam("$replace", []string{"array", "string"}, nil).
+		AddParam("$offset", []string{"array", "int"}, nil).
+		AddParam("$length", []string{"array", "int", "null"}, values.NewNull()).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	if args[0].GetType() == values.StrValue {
+		if args[2].GetType() == values.ArrayValue {
+			return values.NewVoid(), phpError.NewError("Uncaught TypeError: substr_replace(): Argument #3 ($offset) cannot be an array when working on a single string")
+		}
+		if args[3].GetType() == values.ArrayValue {
+			return values.NewVoid(), phpError.NewError("Uncaught TypeError: substr_replace(): Argument #4 ($length) cannot be an array when working on a single string")
+		}
+
+		// Spec: https://www.php.net/manual/en/function.substr-replace.php
+		// If replace is an array, its first element is used.
+		replacement := ""
+		if args[1].GetType() == values.StrValue {
+			replacement = args[1].(*values.Str).Value
+		} else if replaceValues := args[1].(*values.Array).GetValues(); len(replaceValues) > 0 {
+			if replacement, err = toString(replaceValues[0], context); err != nil {
+				return values.NewVoid(), err
+			}
+		}
+		return values.NewStr(replaceSubstring(args[0].(*values.Str).Value, replacement, args[2].(*values.Int).Value, args[3])), nil
+	}
+
+	// Spec: https://www.php.net/manual/en/function.substr-replace.php
+	// If string is an array, the replacement is performed for each string.
+	// Arrays of replace, offset and length are applied element by element.
+	getNth := func(value values.RuntimeValue, index int) (values.RuntimeValue, bool) {
+		if value.GetType() != values.ArrayValue {
+			return value, true
+		}
+		arrayValues := value.(*values.Array).GetValues()
+		if index >= len(arrayValues) {
+			return nil, false
+		}
+		return arrayValues[index], true
+	}
+
+	result := values.NewArray()
+	index := 0
+	for key, value := range args[0].(*values.Array).Iterate() {
+		input, err := toString(value, context)
+		if err != nil {
+			return values.NewVoid(), err
+		}
+
+		replacement := ""
+		if replaceValue, found := getNth(args[1], index); found {
+			if replacement, err = toString(replaceValue, context); err != nil {
+				return values.NewVoid(), err
+			}
+		}
+		offset := int64(0)
+		if offsetValue, found := getNth(args[2], index); found {
+			if offset, err = variableHandling.IntVal(offsetValue, false); err != nil {
+				return values.NewVoid(), err
+			}
+		}
+		var length values.RuntimeValue = values.NewNull()
+		if lengthValue, found := getNth(args[3], index); found && lengthValue.GetType() != values.NullValue {
+			intLength, err := variableHandling.IntVal(lengthValue, false)
+			if err != nil {
+				return values.NewVoid(), err
+			}
+			length = values.NewInt(intLength)
+		} else if args[3].GetType() == values.ArrayValue {
+			length = values.NewInt(int64(len(input)))
+		}
+
+		if err := result.SetElement(key, values.NewStr(replaceSubstring(input, replacement, offset, length))); err != nil {
+			return values.NewVoid(), err
+		}
+		index++
+	}
+	return result, nil
+}
+
+// Replace the part of the string given by offset and length (an int or null) with the replacement
+func replaceSubstring(input string, replacement string, offset int64, length values.RuntimeValue) string {
+	if offset < 0 {
+		offset = max(int64(len(input))+offset, 0)
+	} else if offset > int64(len(input)) {
+		offset = int64(len(input))
+	}
+
+	remaining := int64(len(input)) - offset
+	end := int64(len(input))
+	if length.GetType() == values.IntValue {
+		sublength := length.(*values.Int).Value
+		if sublength < 0 {
+			sublength = max(remaining+sublength, 0)
+		}
+		end = offset + min(sublength, remaining)
+	}
+
+	return input[:offset] + replacement + input[end:]
+}
+
+// -------------------------------------- trim -------------------------------------- MARK: trim
+
+func nativeFn_trim(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.trim.php
+	return trim("trim", args, 3, context)
+}
+
+// Strip whitespace (or other characters) from the start (mode 1), the end (mode 2) or both sides (mode 3) of the string (trim, ltrim and rtrim)
+func trim(funcName string, args []values.RuntimeValue, mode int, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	args, err := funcParamValidator.NewValidator(funcName).
+		AddParam("$string", []string{"string"}, nil).
+		AddParam("$characters", []string{"string"}, values.NewStr(" \n\r\t\v\x00")).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Spec: https://www.php.net/manual/en/function.trim.php
+	// With ".." a range of characters can be specified (e.g. "a..z").
+	mask := charMask(funcName, args[1].(*values.Str).Value, context)
+	return values.NewStr(trimMask(args[0].(*values.Str).Value, mask, mode)), nil
+}
+
+// -------------------------------------- ucfirst -------------------------------------- MARK: ucfirst
+
+func nativeFn_ucfirst(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.ucfirst.php
+
+	args, err := funcParamValidator.NewValidator("ucfirst").AddParam("$string", []string{"string"}, nil).Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	input := args[0].(*values.Str).Value
 
 	if len(input) == 0 {
 		return values.NewStr(""), nil
@@ -355,93 +3297,186 @@ func nativeFn_ucfirst(args []values.RuntimeValue, _ runtime.Context) (values.Run
 	return values.NewStr(input), nil
 }
 
-// TODO addcslashes
-// TODO addslashes
-// TODO chop
-// TODO chunk_split
-// TODO convert_uudecode
-// TODO convert_uuencode
-// TODO count_hars
-// TODO crc32
-// TODO crypt
-// TODO explode
-// TODO fprintf
-// TODO get_html_translation_table
+// -------------------------------------- ucwords -------------------------------------- MARK: ucwords
+
+func nativeFn_ucwords(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.ucwords.php
+
+	args, err := funcParamValidator.NewValidator("ucwords").
+		AddParam("$string", []string{"string"}, nil).
+		AddParam("$separators", []string{"string"}, values.NewStr(" \t\r\n\f\v")).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Spec: https://www.php.net/manual/en/function.ucwords.php
+	// Returns a string with the first character of each word in string capitalized,
+	// if that character is an ASCII character between "a" (0x61) and "z" (0x7a).
+	output := []byte(args[0].(*values.Str).Value)
+	mask := charMask("ucwords", args[1].(*values.Str).Value, context)
+	for i := range output {
+		if i == 0 || mask[output[i-1]] {
+			output[i] = upperByte(output[i])
+		}
+	}
+
+	return values.NewStr(string(output)), nil
+}
+
+// -------------------------------------- vfprintf -------------------------------------- MARK: vfprintf
+
+func nativeFn_vfprintf(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.vfprintf.php
+
+	args, err := funcParamValidator.NewValidator("vfprintf").
+		AddParam("$stream", []string{"resource"}, nil).
+		AddParam("$format", []string{"string"}, nil).
+		AddParam("$values", []string{"array"}, nil).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	str, err := format.Sprintf(context, args[1].(*values.Str).Value, args[2].(*values.Array).GetValues(), format.ArrayArguments)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+	return lib_fprintf("vfprintf", args[0], str, context)
+}
+
+// -------------------------------------- vprintf -------------------------------------- MARK: vprintf
+
+func nativeFn_vprintf(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.vprintf.php
+
+	args, err := funcParamValidator.NewValidator("vprintf").
+		AddParam("$format", []string{"string"}, nil).
+		AddParam("$values", []string{"array"}, nil).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	str, err := format.Sprintf(context, args[0].(*values.Str).Value, args[1].(*values.Array).GetValues(), format.ArrayArguments)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+	context.Interpreter.Print(str)
+	return values.NewInt(int64(len(str))), nil
+}
+
+// -------------------------------------- vsprintf -------------------------------------- MARK: vsprintf
+
+func nativeFn_vsprintf(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.vsprintf.php
+
+	args, err := funcParamValidator.NewValidator("vsprintf").
+		AddParam("$format", []string{"string"}, nil).
+		AddParam("$values", []string{"array"}, nil).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	str, err := format.Sprintf(context, args[0].(*values.Str).Value, args[1].(*values.Array).GetValues(), format.ArrayArguments)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+	return values.NewStr(str), nil
+}
+
+// -------------------------------------- wordwrap -------------------------------------- MARK: wordwrap
+
+func nativeFn_wordwrap(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.wordwrap.php
+
+	args, err := funcParamValidator.NewValidator("wordwrap").
+		AddParam("$string", []string{"string"}, nil).
+		AddParam("$width", []string{"int"}, values.NewInt(75)).
+		AddParam("$break", []string{"string"}, values.NewStr("\n")).
+		AddParam("$cut_long_words", []string{"bool"}, values.NewBool(false)).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	input := args[0].(*values.Str).Value
+	width := int(max(min(args[1].(*values.Int).Value, math.MaxInt32), math.MinInt32))
+	lineBreak := args[2].(*values.Str).Value
+	cut := args[3].(*values.Bool).Value
+
+	if input == "" {
+		return values.NewStr(""), nil
+	}
+	if lineBreak == "" {
+		return values.NewVoid(), phpError.NewError("Uncaught ValueError: wordwrap(): Argument #3 ($break) cannot be empty")
+	}
+	if width == 0 && cut {
+		return values.NewVoid(), phpError.NewError("Uncaught ValueError: wordwrap(): Argument #4 ($cut_long_words) cannot be true when argument #2 ($width) is 0")
+	}
+
+	// Special case for a single character break without cutting: The spaces are replaced in place
+	if len(lineBreak) == 1 && !cut {
+		output := []byte(input)
+		lastStart, lastSpace := 0, 0
+		for current := 0; current < len(input); current++ {
+			switch {
+			case input[current] == lineBreak[0]:
+				lastStart, lastSpace = current+1, current+1
+			case input[current] == ' ':
+				if current-lastStart >= width {
+					output[current] = lineBreak[0]
+					lastStart = current + 1
+				}
+				lastSpace = current
+			case current-lastStart >= width && lastStart != lastSpace:
+				output[lastSpace] = lineBreak[0]
+				lastStart = lastSpace + 1
+			}
+		}
+		return values.NewStr(string(output)), nil
+	}
+
+	var output goStrings.Builder
+	lastStart, lastSpace := 0, 0
+	current := 0
+	for ; current < len(input); current++ {
+		switch {
+		case input[current] == lineBreak[0] && current+len(lineBreak) < len(input) && goStrings.HasPrefix(input[current:], lineBreak):
+			// Existing line breaks are kept
+			output.WriteString(input[lastStart : current+len(lineBreak)])
+			current += len(lineBreak) - 1
+			lastStart, lastSpace = current+1, current+1
+		case input[current] == ' ':
+			// A space at the line boundary is replaced with the break
+			if current-lastStart >= width {
+				output.WriteString(input[lastStart:current] + lineBreak)
+				lastStart = current + 1
+			}
+			lastSpace = current
+		case current-lastStart >= width && cut && lastStart >= lastSpace:
+			// A long word is cut
+			output.WriteString(input[lastStart:current] + lineBreak)
+			lastStart, lastSpace = current, current
+		case current-lastStart >= width && lastStart < lastSpace:
+			// The line is broken at the last space
+			output.WriteString(input[lastStart:lastSpace] + lineBreak)
+			lastStart, lastSpace = lastSpace+1, lastSpace+1
+		}
+	}
+	if lastStart != current {
+		output.WriteString(input[lastStart:current])
+	}
+
+	return values.NewStr(output.String()), nil
+}
+
 // TODO hebrev
-// TODO hex2bin
-// TODO html_entity_decode
-// TODO htmlentities
-// TODO htmlspecialchars
-// TODO htmlspecialchars_decode
-// TODO implode
-// TODO join
-// TODO levenshtein
 // TODO localeconv
-// TODO ltrim
-// TODO md5
-// TODO md5_file
-// TODO metaphone
 // TODO money_format
 // TODO nl_langinfo
-// TODO nl2br
-// TODO number_format
-// TODO ord
-// TODO parse_str
-// TODO printf
-// TODO quoted_printable_decode
-// TODO quoted_printable_encode
-// TODO rtrim
 // TODO setlocale
-// TODO sha1
-// TODO sha1_file
-// TODO similar_text
-// TODO soundex
-// TODO sprintf
-// TODO sscanf
-// TODO str_decrement
-// TODO str_getcsv
-// TODO str_increment
-// TODO str_ireplace
-// TODO str_pad
-// TODO str_replace
-// TODO str_rot13
-// TODO str_shuffle
-// TODO str_split
-// TODO str_word_count
-// TODO strcasecmp
-// TODO strchr
-// TODO strcmp
-// TODO strcoll
-// TODO strcspn
-// TODO strip_tags
-// TODO stripcslashes
-// TODO stripos
-// TODO stripslashes
-// TODO stristr
-// TODO strnatcasecmp
-// TODO strnatcmp
-// TODO strncasecmp
-// TODO strncmp
-// TODO strpbrk
-// TODO strpos
-// TODO strrchr
-// TODO strrev
-// TODO strripos
-// TODO strrpos
-// TODO strspn
-// TODO strstr
-// TODO strtok
-// TODO strtr
-// TODO substr
-// TODO substr_compare
-// TODO substr_count
-// TODO substr_replace
-// TODO trim
-// TODO ucwords
-// TODO vfprintf
-// TODO vprintf
-// TODO vsprintf
-// TODO wordwrap
 // Deprecated:
 // TODO convert_cyr_string
 // TODO hebrevc
@@ -0,0 +1,157 @@
+package strings
+
+import (
+	"QIQ/cmd/qiq/runtime"
+	"QIQ/cmd/qiq/runtime/values"
+	"testing"
+)
+
+// -------------------------------------- metaphone -------------------------------------- MARK: metaphone
+
+func TestMetaphone(t *testing.T) {
+	doTest := func(t *testing.T, word string, maxPhonemes int, expected string) {
+		if actual := metaphone(word, maxPhonemes); actual != expected {
+			t.Errorf("metaphone(%q, %d) - Expected: %q, Got %q", word, maxPhonemes, expected, actual)
+		}
+	}
+
+	doTest(t, "", 0, "")
+	doTest(t, "123", 0, "")
+	doTest(t, "Thumb", 0, "0M")
+	doTest(t, "Knight", 0, "NFT")
+	doTest(t, "Wright", 0, "RFT")
+	doTest(t, "Xavier", 0, "SFR")
+	doTest(t, "Science", 0, "SNS")
+	doTest(t, "Chiaroscuro", 0, "XRSKR")
+	doTest(t, "Thumb", 1, "0")
+}
+
+// -------------------------------------- str_increment -------------------------------------- MARK: str_increment
+
+func TestStrIncrementDecrement(t *testing.T) {
+	context := runtime.NewContext(nil, nil, nil)
+
+	doTest := func(t *testing.T, increment bool, input string, expected string) {
+		fn := nativeFn_str_decrement
+		if increment {
+			fn = nativeFn_str_increment
+		}
+		actual, err := fn([]values.RuntimeValue{values.NewStr(input)}, context)
+		if err != nil {
+			t.Errorf("%q - Unexpected error: %s", input, err)
+		} else if actual.(*values.Str).Value != expected {
+			t.Errorf("%q - Expected: %q, Got %q", input, expected, actual.(*values.Str).Value)
+		}
+	}
+
+	doTest(t, true, "a", "b")
+	doTest(t, true, "Az", "Ba")
+	doTest(t, true, "zz", "aaa")
+	doTest(t, true, "Zz", "AAa")
+	doTest(t, true, "a9", "b0")
+	doTest(t, true, "99", "100")
+	doTest(t, false, "b", "a")
+	doTest(t, false, "Ba", "Az")
+	doTest(t, false, "aaa", "zz")
+	doTest(t, false, "b0", "a9")
+	doTest(t, false, "100", "99")
+
+	if _, err := nativeFn_str_increment([]values.RuntimeValue{values.NewStr("")}, context); err == nil {
+		t.Errorf("Expected ValueError for an empty string")
+	}
+	if _, err := nativeFn_str_decrement([]values.RuntimeValue{values.NewStr("a")}, context); err == nil {
+		t.Errorf("Expected ValueError for out of range string")
+	}
+}
+
+// -------------------------------------- substr -------------------------------------- MARK: substr
+
+func TestSubstrBounds(t *testing.T) {
+	doTest := func(t *testing.T, offset int64, length int64, hasLength bool, expectedStart int, expectedEnd int) {
+		start, end := substrBounds(5, offset, length, hasLength)
+		if start != expectedStart || end != expectedEnd {
+			t.Errorf("substrBounds(5, %d, %d, %t) - Expected: (%d, %d), Got (%d, %d)",
+				offset, length, hasLength, expectedStart, expectedEnd, start, end)
+		}
+	}
+
+	doTest(t, 0, 0, false, 0, 5)
+	doTest(t, 1, 0, false, 1, 5)
+	doTest(t, -2, 0, false, 3, 5)
+	doTest(t, -10, 0, false, 0, 5)
+	doTest(t, 10, 0, false, 5, 5)
+	doTest(t, 1, 2, true, 1, 3)
+	doTest(t, 1, -1, true, 1, 4)
+	doTest(t, 3, -3, true, 3, 3)
+	doTest(t, 0, 10, true, 0, 5)
+}
+
+// -------------------------------------- substr_count -------------------------------------- MARK: substr_count
+
+func TestSubstrCount(t *testing.T) {
+	context := runtime.NewContext(nil, nil, nil)
+
+	doTest := func(t *testing.T, haystack string, needle string, expected int64) {
+		actual, err := nativeFn_substr_count([]values.RuntimeValue{values.NewStr(haystack), values.NewStr(needle)}, context)
+		if err != nil {
+			t.Errorf("%q, %q - Unexpected error: %s", haystack, needle, err)
+		} else if actual.(*values.Int).Value != expected {
+			t.Errorf("%q, %q - Expected: %d, Got %d", haystack, needle, expected, actual.(*values.Int).Value)
+		}
+	}
+
+	doTest(t, "hello world", "o", 2)
+	doTest(t, "aaa", "aa", 1)
+	doTest(t, "abcabcabc", "abc", 3)
+	doTest(t, "abc", "d", 0)
+
+	if _, err := nativeFn_substr_count([]values.RuntimeValue{values.NewStr("abc"), values.NewStr("")}, context); err == nil {
+		t.Errorf("Expected ValueError for an empty needle")
+	}
+}
+
+// -------------------------------------- trim -------------------------------------- MARK: trim
+
+func TestTrimMask(t *testing.T) {
+	var mask [256]bool
+	mask[' '] = true
+	mask['x'] = true
+
+	doTest := func(t *testing.T, input string, mode int, expected string) {
+		if actual := trimMask(input, mask, mode); actual != expected {
+			t.Errorf("trimMask(%q, %d) - Expected: %q, Got %q", input, mode, expected, actual)
+		}
+	}
+
+	doTest(t, " xabx ", 1, "abx ")
+	doTest(t, " xabx ", 2, " xab")
+	doTest(t, " xabx ", 3, "ab")
+	doTest(t, "xx  x", 3, "")
+	doTest(t, "", 3, "")
+}
+
+// -------------------------------------- wordwrap -------------------------------------- MARK: wordwrap
+
+func TestWordwrap(t *testing.T) {
+	context := runtime.NewContext(nil, nil, nil)
+
+	doTest := func(t *testing.T, input string, width int64, cut bool, expected string) {
+		args := []values.RuntimeValue{values.NewStr(input), values.NewInt(width), values.NewStr("\n"), values.NewBool(cut)}
+		actual, err := nativeFn_wordwrap(args, context)
+		if err != nil {
+			t.Errorf("%q - Unexpected error: %s", input, err)
+		} else if actual.(*values.Str).Value != expected {
+			t.Errorf("%q - Expected: %q, Got %q", input, expected, actual.(*values.Str).Value)
+		}
+	}
+
+	doTest(t, "The quick brown fox", 10, false, "The quick\nbrown fox")
+	doTest(t, "A very long woooooooooooord.", 8, true, "A very\nlong\nwooooooo\nooooord.")
+	doTest(t, "A very long woooooooooooord.", 8, false, "A very\nlong\nwoooooooooooord.")
+	doTest(t, "short", 10, false, "short")
+
+	args := []values.RuntimeValue{values.NewStr("abc"), values.NewInt(0), values.NewStr("\n"), values.NewBool(true)}
+	if _, err := nativeFn_wordwrap(args, context); err == nil {
+		t.Errorf("Expected ValueError for a zero width with cut")
+	}
+}
@@ -4,7 +4,7 @@ import (
 	"QIQ/cmd/qiq/common"
 	"QIQ/cmd/qiq/phpError"
 	"QIQ/cmd/qiq/runtime/values"
-	"strings"
+	"strconv"
 )
 
 // -------------------------------------- CompareRelation -------------------------------------- MARK: CompareRelation
@@ -64,6 +64,11 @@ func CompareRelation(lhs values.RuntimeValue, operator string, rhs values.Runtim
 		return CompareRelation(rhs, "<=", lhs, leadingNumeric)
 	}
 
+	// Resources are converted to their id (see 2.)
+	if rhs.GetType() == values.ResourceValue {
+		rhs = values.NewInt(rhs.(*values.Resource).Id)
+	}
+
 	switch lhs.GetType() {
 	case values.ArrayValue:
 		return compareRelationArray(lhs.(*values.Array), operator, rhs, leadingNumeric)
@@ -79,6 +84,8 @@ func CompareRelation(lhs values.RuntimeValue, operator string, rhs values.Runtim
 		return compareRelationNull(operator, rhs, leadingNumeric)
 	case values.ObjectValue:
 		return compareRelationObject(lhs.(*values.Object), operator, rhs)
+	case values.ResourceValue:
+		return compareRelationResource(lhs.(*values.Resource), operator, rhs, leadingNumeric)
 	default:
 		return values.NewVoid(), phpError.NewError("compareRelation: Type \"%s\" not implemented", lhs.GetType())
 	}
@@ -101,7 +108,6 @@ func compareRelationArray(lhs *values.Array, operator string, rhs values.Runtime
 	//      If all the values are equal, then the arrays are considered equal.
 
 	// TODO compareRelationArray - object
-	// TODO compareRelationArray - resource
 
 	if rhs.GetType() == values.NullValue {
 		var err phpError.Error
@@ -115,14 +121,14 @@ func compareRelationArray(lhs *values.Array, operator string, rhs values.Runtime
 	case values.ArrayValue:
 		rhsArray := rhs.(*values.Array)
 		var result int64 = 0
-		if len(lhs.Keys) != len(rhsArray.Keys) {
-			if len(lhs.Keys) < len(rhsArray.Keys) {
+		if lhs.Count() != rhsArray.Count() {
+			if lhs.Count() < rhsArray.Count() {
 				result = -1
 			} else {
 				result = 1
 			}
 		} else {
-			for _, key := range lhs.Keys {
+			for _, key := range lhs.GetKeys() {
 				lhsValue, _ := lhs.GetElement(key)
 				rhsValue, found := rhsArray.GetElement(key)
 				if found {
@@ -235,33 +241,17 @@ func compareRelationFloating(lhs *values.Float, operator string, rhs values.Runt
 	// float   <-    ->    2    2      <-      <      3       <-
 
 	// TODO compareRelationFloating - object
-	// TODO compareRelationFloating - resource
 
 	if rhs.GetType() == values.StrValue {
 		rhsStr := rhs.(*values.Str).Value
-		if strings.Trim(rhsStr, " \t") == "" {
-			switch operator {
-			case "<", "<=":
-				return values.NewBool(false), nil
-			case "<=>":
-				return values.NewInt(1), nil
-			default:
-				return values.NewVoid(), phpError.NewError("compareRelationFloating: Operator \"%s\" not implemented for type string", operator)
-			}
-		}
-		if !leadingNumeric && !common.IsIntegerLiteralWithSign(rhsStr, !leadingNumeric) && !common.IsFloatingLiteralWithSign(rhsStr, !leadingNumeric) {
-			switch operator {
-			case "<", "<=":
-				return values.NewBool(true), nil
-			case "<=>":
-				return values.NewInt(-1), nil
-			default:
-				return values.NewVoid(), phpError.NewError("compareRelationFloating: Operator \"%s\" not implemented for type string", operator)
-			}
+		if number, ok := numericStringVal(rhsStr); ok {
+			return compareRelationFloating(lhs, operator, number, leadingNumeric)
 		}
+		// The float is compared as string with a non-numeric string
+		return compareRelationString(values.NewStr(lhs.ToPhpString(comparisonPrecision)), operator, rhs, leadingNumeric)
 	}
 
-	if rhs.GetType() == values.NullValue || rhs.GetType() == values.IntValue || rhs.GetType() == values.StrValue {
+	if rhs.GetType() == values.NullValue || rhs.GetType() == values.IntValue {
 		var err phpError.Error
 		rhs, err = ToValueType(values.FloatValue, rhs, leadingNumeric)
 		if err != nil {
@@ -318,33 +308,17 @@ func compareRelationInteger(lhs *values.Int, operator string, rhs values.Runtime
 	// int   <-    ->    2    2      <-      <      3       <-
 
 	// TODO compareRelationInteger - object
-	// TODO compareRelationInteger - resource
 
 	if rhs.GetType() == values.StrValue {
 		rhsStr := rhs.(*values.Str).Value
-		if strings.Trim(rhsStr, " \t") == "" {
-			switch operator {
-			case "<", "<=":
-				return values.NewBool(false), nil
-			case "<=>":
-				return values.NewInt(1), nil
-			default:
-				return values.NewVoid(), phpError.NewError("compareRelationInteger: Operator \"%s\" not implemented for type string", operator)
-			}
-		}
-		if !leadingNumeric && !common.IsIntegerLiteralWithSign(rhsStr, leadingNumeric) && !common.IsFloatingLiteralWithSign(rhsStr, leadingNumeric) {
-			switch operator {
-			case "<", "<=":
-				return values.NewBool(true), nil
-			case "<=>":
-				return values.NewInt(-1), nil
-			default:
-				return values.NewVoid(), phpError.NewError("compareRelationInteger: Operator \"%s\" not implemented for type string", operator)
-			}
+		if number, ok := numericStringVal(rhsStr); ok {
+			return compareRelationInteger(lhs, operator, number, leadingNumeric)
 		}
+		// The integer is compared as string with a non-numeric string
+		return compareRelationString(values.NewStr(strconv.FormatInt(lhs.Value, 10)), operator, rhs, leadingNumeric)
 	}
 
-	if rhs.GetType() == values.NullValue || rhs.GetType() == values.StrValue {
+	if rhs.GetType() == values.NullValue {
 		var err phpError.Error
 		rhs, err = ToValueType(values.IntValue, rhs, leadingNumeric)
 		if err != nil {
@@ -450,14 +424,10 @@ func compareRelationNull(operator string, rhs values.RuntimeValue, leadingNumeri
 		return values.NewVoid(), phpError.NewError("compareRelationNull: Operator \"%s\" not implemented for type NULL", operator)
 
 		// TODO compareRelationNull - object
-		// TODO compareRelationNull - resource
 
 	case values.StrValue:
-		lhs, err := StrVal(values.NewNull())
-		if err != nil {
-			return values.NewVoid(), err
-		}
-		return compareRelationString(values.NewStr(lhs), operator, rhs, leadingNumeric)
+		// NULL is converted to the empty string
+		return compareRelationString(values.NewStr(""), operator, rhs, leadingNumeric)
 
 	default:
 		return values.NewVoid(), phpError.NewError("compareRelationNull: Type \"%s\" not implemented", rhs.GetType())
@@ -471,38 +441,22 @@ func compareRelationString(lhs *values.Str, operator string, rhs values.RuntimeV
 	// string   <-    ->    ->   ->     2, 4    <      3       2
 
 	// TODO compareRelationString - object
-	// TODO compareRelationString - resource
 
 	if rhs.GetType() == values.FloatValue || rhs.GetType() == values.IntValue {
-		lhsStr := lhs.Value
-		if strings.Trim(lhsStr, " \t") == "" {
-			switch operator {
-			case "<", "<=":
-				return values.NewBool(true), nil
-			case "<=>":
-				return values.NewInt(-1), nil
-			default:
-				return values.NewVoid(), phpError.NewError("compareRelationInteger: Operator \"%s\" not implemented for type array", operator)
-			}
+		if number, ok := numericStringVal(lhs.Value); ok {
+			return CompareRelation(number, operator, rhs, leadingNumeric)
 		}
-		if !leadingNumeric && !common.IsIntegerLiteralWithSign(lhsStr, leadingNumeric) && !common.IsFloatingLiteralWithSign(lhsStr, leadingNumeric) {
-			switch operator {
-			case "<", "<=":
-				return values.NewBool(false), nil
-			case "<=>":
-				return values.NewInt(1), nil
-			default:
-				return values.NewVoid(), phpError.NewError("compareRelationInteger: Operator \"%s\" not implemented for type array", operator)
-			}
+		// The number is compared as string with a non-numeric string
+		rhsStr, err := StrVal(rhs, comparisonPrecision)
+		if err != nil {
+			return values.NewVoid(), err
 		}
+		return compareRelationString(lhs, operator, values.NewStr(rhsStr), leadingNumeric)
 	}
 
 	if rhs.GetType() == values.NullValue {
-		var err phpError.Error
-		rhs, err = ToValueType(values.StrValue, rhs, true)
-		if err != nil {
-			return values.NewVoid(), err
-		}
+		// NULL is converted to the empty string
+		rhs = values.NewStr("")
 	}
 
 	switch rhs.GetType() {
@@ -544,19 +498,10 @@ func compareRelationString(lhs *values.Str, operator string, rhs values.RuntimeV
 		//      the operands are converted to the corresponding arithmetic type, with float taking precedence over int,
 		//      and resources converting to int. The result is the numerical comparison of the two operands after conversion.
 		rhsStr := rhs.(*values.Str).Value
-		if common.IsFloatingLiteralWithSign(lhs.Value, false) && (common.IsIntegerLiteralWithSign(rhsStr, false) || common.IsFloatingLiteralWithSign(rhsStr, false)) {
-			lhs, err := FloatVal(lhs, false)
-			if err != nil {
-				return values.NewVoid(), err
+		if lhsNumber, ok := numericStringVal(lhs.Value); ok {
+			if rhsNumber, ok := numericStringVal(rhsStr); ok {
+				return CompareRelation(lhsNumber, operator, rhsNumber, leadingNumeric)
 			}
-			return compareRelationFloating(values.NewFloat(lhs), operator, rhs, leadingNumeric)
-		}
-		if common.IsIntegerLiteralWithSign(lhs.Value, false) && (common.IsIntegerLiteralWithSign(rhsStr, false) || common.IsFloatingLiteralWithSign(rhsStr, false)) {
-			lhs, err := IntVal(lhs, false)
-			if err != nil {
-				return values.NewVoid(), err
-			}
-			return compareRelationInteger(values.NewInt(lhs), operator, rhs, leadingNumeric)
 		}
 
 		// Spec: https://phplang.org/spec/10-expressions.html#grammar-relational-expression
@@ -618,13 +563,28 @@ func compareRelationObject(lhs *values.Object, operator string, rhs values.Runti
 		}
 
 	case values.ObjectValue:
-		// TODO 6. When comparing two objects, if any of the object types has its own compare semantics, that would define the result, with the left operand taking precedence. Otherwise, if the objects are of different types, the comparison result is FALSE. If the objects are of the same type, the properties of the objects are compares using the array comparison described above.
+		// 6. When comparing two objects, if any of the object types has its own compare semantics, that would define the result,
+		//    with the left operand taking precedence. Otherwise, if the objects are of different types, the comparison result is FALSE.
+		//    If the objects are of the same type, the properties of the objects are compares using the array comparison described above.
+		// TODO compareRelationObject - own compare semantics
+		rhsObject := rhs.(*values.Object)
+		var result int64 = 0
+		isComparable := true
+		if lhs != rhsObject {
+			var err phpError.Error
+			result, isComparable, err = compareObjectProperties(lhs, rhsObject)
+			if err != nil {
+				return values.NewVoid(), err
+			}
+		}
+
 		switch operator {
+		case "<":
+			return values.NewBool(isComparable && result == -1), nil
+		case "<=":
+			return values.NewBool(isComparable && result < 1), nil
 		case "<=>":
-			if lhs == rhs.(*values.Object) {
-				return values.NewInt(0), nil
-			}
-			return values.NewInt(-1), nil
+			return values.NewInt(result), nil
 		default:
 			return values.NewVoid(), phpError.NewError("compareRelationObject: Operator \"%s\" not implemented", operator)
 		}
@@ -640,10 +600,74 @@ func compareRelationObject(lhs *values.Object, operator string, rhs values.Runti
 	}
 }
 
-// TODO compareRelationResource
-// Spec: https://phplang.org/spec/10-expressions.html#grammar-relational-expression
-//           NULL  bool  int  float  string  array  object  resource
-// resource   >     ->    ->   ->     2       <      3       2
+// Compare the properties of two objects of the same class.
+// Returns false if the objects cannot be compared.
+func compareObjectProperties(lhs *values.Object, rhs *values.Object) (int64, bool, phpError.Error) {
+	// Uncomparable objects result in 1 for "<=>" so that "==" is false
+	if lhs.Class != rhs.Class {
+		return 1, false, nil
+	}
+
+	if len(lhs.Properties) != len(rhs.Properties) {
+		if len(lhs.Properties) < len(rhs.Properties) {
+			return -1, true, nil
+		}
+		return 1, true, nil
+	}
+
+	// An object that refers to itself (e.g. `$a->x = $a;`) would be compared endlessly
+	if lhs.IsCompared {
+		return 1, false, phpError.NewError("Nesting level too deep - recursive dependency?")
+	}
+	lhs.IsCompared = true
+	defer func() { lhs.IsCompared = false }()
+
+	for _, name := range lhs.PropertyNames {
+		lhsValue, found := lhs.Properties[name]
+		if !found {
+			continue
+		}
+		rhsValue, found := rhs.Properties[name]
+		if !found {
+			return 1, false, nil
+		}
+		result, err := CompareRelation(lhsValue, "<=>", rhsValue, false)
+		if err != nil {
+			return 1, false, err
+		}
+		if result.(*values.Int).Value != 0 {
+			return result.(*values.Int).Value, true, nil
+		}
+	}
+	return 0, true, nil
+}
+
+func compareRelationResource(lhs *values.Resource, operator string, rhs values.RuntimeValue, leadingNumeric bool) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://phplang.org/spec/10-expressions.html#grammar-relational-expression
+
+	//           NULL  bool  int  float  string  array  object  resource
+	// resource   >     ->    ->   ->     2       <      3       2
+
+	// The id of a resource is greater than zero, so that it compares like an integer with the other types.
+	// A resource on the right side is already converted to its id (see CompareRelation).
+	return compareRelationInteger(values.NewInt(lhs.Id), operator, rhs, leadingNumeric)
+}
+
+// Precision of floats that are compared with a non-numeric string (default of the ini directive "precision")
+const comparisonPrecision = 14
+
+// Convert a numeric string (e.g. " 1e2 ") to an integer or a float.
+// Leading-numeric strings (e.g. "1abc") are compared as non-numeric strings.
+func numericStringVal(str string) (values.RuntimeValue, bool) {
+	number, ok := common.ParseNumericString(str)
+	if !ok || number.TrailingData {
+		return nil, false
+	}
+	if number.IsFloat {
+		return values.NewFloat(number.Float), true
+	}
+	return values.NewInt(number.Int), true
+}
 
 // -------------------------------------- comparison -------------------------------------- MARK: comparison
 
@@ -686,10 +710,10 @@ func Compare(lhs values.RuntimeValue, operator string, rhs values.RuntimeValue)
 			case values.ArrayValue:
 				lhsArray := lhs.(*values.Array)
 				rhsArray := rhs.(*values.Array)
-				if len(lhsArray.Keys) != len(rhsArray.Keys) {
+				if lhsArray.Count() != rhsArray.Count() {
 					result = false
 				} else {
-					for _, key := range lhsArray.Keys {
+					for _, key := range lhsArray.GetKeys() {
 						lhsValue, found := lhsArray.GetElement(key)
 						if !found {
 							result = false
@@ -722,6 +746,8 @@ func Compare(lhs values.RuntimeValue, operator string, rhs values.RuntimeValue)
 				result = lhs.(*values.Str).Value == rhs.(*values.Str).Value
 			case values.ObjectValue:
 				result = lhs.(*values.Object) == rhs.(*values.Object)
+			case values.ResourceValue:
+				result = lhs.(*values.Resource).Id == rhs.(*values.Resource).Id
 			default:
 				return values.NewBool(false), phpError.NewError("compare: Runtime type %s for operator \"===\" not implemented", lhs.GetType())
 			}
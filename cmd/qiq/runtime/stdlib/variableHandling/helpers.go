@@ -2,10 +2,24 @@ package variableHandling
 
 import (
 	"QIQ/cmd/qiq/phpError"
+	"QIQ/cmd/qiq/runtime"
 	"QIQ/cmd/qiq/runtime/values"
+	"fmt"
+	"slices"
+	"strings"
 )
 
+// Convert the runtime value to a bool, float or int.
+// Strings are converted with StrVal because the conversion of floats depends on the ini directive "precision".
 func ToValueType(valueType values.ValueType, runtimeValue values.RuntimeValue, leadingNumeric bool) (values.RuntimeValue, phpError.Error) {
+	// Scalar values are immutable and can be returned as they are
+	if runtimeValue.GetType() == valueType {
+		switch valueType {
+		case values.BoolValue, values.FloatValue, values.IntValue:
+			return runtimeValue, nil
+		}
+	}
+
 	switch valueType {
 	case values.BoolValue:
 		boolean, err := BoolVal(runtimeValue)
@@ -16,10 +30,82 @@ func ToValueType(valueType values.ValueType, runtimeValue values.RuntimeValue, l
 	case values.IntValue:
 		integer, err := IntVal(runtimeValue, leadingNumeric)
 		return values.NewInt(integer), err
-	case values.StrValue:
-		str, err := StrVal(runtimeValue)
-		return values.NewStr(str), err
 	default:
 		return values.NewVoid(), phpError.NewError("runtimeValueToValueType: Unsupported runtime value: %s", valueType)
 	}
 }
+
+// -------------------------------------- Callbacks -------------------------------------- MARK: Callbacks
+
+// Check if the value is a valid callback: a function name, `[$object, "method"]`
+// or a static method (`"MyClass::method"` or `["MyClass", "method"]`)
+func ValidateCallback(funcName string, argument int, paramName string, callback values.RuntimeValue, isNullable bool, context runtime.Context) phpError.Error {
+	expected := "a valid callback"
+	if isNullable {
+		expected += " or null"
+	}
+	if paramName != "" {
+		paramName = " (" + paramName + ")"
+	}
+	invalid := func(format string, args ...any) phpError.Error {
+		return phpError.NewError(
+			"Uncaught TypeError: %s(): Argument #%d%s must be %s, %s", funcName, argument, paramName, expected, fmt.Sprintf(format, args...),
+		)
+	}
+
+	switch callback.GetType() {
+	case values.StrValue:
+		functionName := callback.(*values.Str).Value
+		if className, method, found := strings.Cut(functionName, "::"); found {
+			return validateStaticCallback(className, method, invalid, context)
+		}
+		if !context.Env.FunctionExists(functionName) {
+			return invalid("function \"%s\" not found or invalid function name", functionName)
+		}
+		return nil
+	case values.ArrayValue:
+		array := callback.(*values.Array)
+		if array.Count() != 2 {
+			return invalid("array callback must have exactly two members")
+		}
+		object, _ := array.GetElement(values.NewInt(0))
+		method, _ := array.GetElement(values.NewInt(1))
+		if method.GetType() == values.StrValue {
+			switch object.GetType() {
+			case values.ObjectValue:
+				return nil
+			case values.StrValue:
+				return validateStaticCallback(object.(*values.Str).Value, method.(*values.Str).Value, invalid, context)
+			}
+		}
+		return invalid("first array member is not a valid class name or object")
+	default:
+		return invalid("no array or string given")
+	}
+}
+
+func validateStaticCallback(
+	className string, method string, invalid func(format string, args ...any) phpError.Error, context runtime.Context,
+) phpError.Error {
+	class, found := context.Interpreter.GetClass(className)
+	if !found {
+		return invalid("class \"%s\" not found", className)
+	}
+	for current := class; current != nil; {
+		for name, methodDefinition := range current.Methods {
+			// Method names are case-insensitive
+			if !strings.EqualFold(name, method) {
+				continue
+			}
+			if !slices.Contains(methodDefinition.Modifiers, "static") {
+				return invalid("non-static method %s::%s() cannot be called statically", current.Name, methodDefinition.Name)
+			}
+			return nil
+		}
+		if current.BaseClass == "" {
+			break
+		}
+		current, _ = context.Interpreter.GetClass(current.BaseClass)
+	}
+	return invalid("class %s does not have a method \"%s\"", class.Name, method)
+}
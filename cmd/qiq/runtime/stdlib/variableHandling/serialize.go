@@ -0,0 +1,743 @@
+package variableHandling
+
+import (
+	"QIQ/cmd/qiq/ast"
+	"QIQ/cmd/qiq/common"
+	"QIQ/cmd/qiq/phpError"
+	"QIQ/cmd/qiq/runtime"
+	"QIQ/cmd/qiq/runtime/funcParamValidator"
+	"QIQ/cmd/qiq/runtime/values"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Spec: https://www.phpinternalsbook.com/php5/classes_objects/serialization.html
+
+// Name of the class of objects whose class is not defined or not allowed when unserializing
+const incompleteClassName = "__PHP_Incomplete_Class"
+
+// Property of incomplete objects that holds the name of the original class
+const incompleteClassNameProperty = "$__PHP_Incomplete_Class_Name"
+
+// Find the method in the class or one of its parent classes (case-insensitive)
+func hasMethod(class *ast.ClassDeclarationStatement, method string, context runtime.Context) bool {
+	for class != nil {
+		for name := range class.Methods {
+			if strings.EqualFold(name, method) {
+				return true
+			}
+		}
+		if class.BaseClass == "" {
+			return false
+		}
+		class, _ = context.Interpreter.GetClass(class.BaseClass)
+	}
+	return false
+}
+
+func callMethod(object *values.Object, method string, args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	callable := values.NewArray()
+	callable.SetElement(nil, object)
+	callable.SetElement(nil, values.NewStr(method))
+	return context.Interpreter.CallFunction(context, callable, args)
+}
+
+// -------------------------------------- serialize -------------------------------------- MARK: serialize
+
+func nativeFn_serialize(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	args, err := funcParamValidator.NewValidator("serialize").AddParam("$value", []string{"mixed"}, nil).Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Spec: https://www.php.net/manual/en/function.serialize.php
+	// Generates a storable representation of a value.
+	serializer := &serializer{context: context, objects: map[*values.Object]int{}}
+	if err := serializer.serializeValue(args[0]); err != nil {
+		return values.NewVoid(), err
+	}
+	return values.NewStr(serializer.result.String()), nil
+}
+
+type serializer struct {
+	context runtime.Context
+	result  strings.Builder
+	// Number of the last serialized value. Back-references ("r:") refer to values by this number.
+	slot int
+	// Numbers of the serialized objects
+	objects map[*values.Object]int
+}
+
+func (serializer *serializer) writeString(str string) {
+	serializer.result.WriteString(fmt.Sprintf(`s:%d:"%s";`, len(str), str))
+}
+
+func (serializer *serializer) serializeValue(value values.RuntimeValue) phpError.Error {
+	serializer.slot++
+	switch value.GetType() {
+	case values.NullValue, values.VoidValue:
+		serializer.result.WriteString("N;")
+	case values.BoolValue:
+		if value.(*values.Bool).Value {
+			serializer.result.WriteString("b:1;")
+		} else {
+			serializer.result.WriteString("b:0;")
+		}
+	case values.IntValue:
+		serializer.result.WriteString(fmt.Sprintf("i:%d;", value.(*values.Int).Value))
+	case values.FloatValue:
+		precision := serializer.context.Interpreter.GetIni().GetInt("serialize_precision")
+		serializer.result.WriteString("d:" + common.FormatFloat(value.(*values.Float).Value, precision) + ";")
+	case values.StrValue:
+		serializer.writeString(value.(*values.Str).Value)
+	case values.ArrayValue:
+		array := value.(*values.Array)
+		serializer.result.WriteString(fmt.Sprintf("a:%d:{", array.Count()))
+		if err := serializer.serializeElements(array); err != nil {
+			return err
+		}
+		serializer.result.WriteString("}")
+	case values.ObjectValue:
+		object := value.(*values.Object)
+		// An object that is serialized more than once is serialized as back-reference
+		if slot, found := serializer.objects[object]; found {
+			serializer.result.WriteString(fmt.Sprintf("r:%d;", slot))
+			return nil
+		}
+		serializer.objects[object] = serializer.slot
+		return serializer.serializeObject(object)
+	default:
+		// Resources are serialized as integer 0
+		serializer.result.WriteString("i:0;")
+	}
+	return nil
+}
+
+func (serializer *serializer) serializeElements(array *values.Array) phpError.Error {
+	for _, key := range array.GetKeys() {
+		if key.GetType() == values.IntValue {
+			serializer.result.WriteString(fmt.Sprintf("i:%d;", key.(*values.Int).Value))
+		} else {
+			serializer.writeString(key.(*values.Str).Value)
+		}
+		element, _ := array.GetElement(key)
+		if err := serializer.serializeValue(element); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (serializer *serializer) serializeObject(object *values.Object) phpError.Error {
+	context := serializer.context
+	className := object.Class.Name
+	if className == incompleteClassName {
+		if name, found := object.GetProperty(incompleteClassNameProperty); found && name.GetType() == values.StrValue {
+			className = name.(*values.Str).Value
+		}
+	}
+	writeObjectHeader := func(count int) {
+		serializer.result.WriteString(fmt.Sprintf(`O:%d:"%s":%d:{`, len(className), className, count))
+	}
+
+	// Spec: https://www.php.net/manual/en/language.oop5.magic.php#object.serialize
+	// serialize() checks if the class has a function with the magic name __serialize().
+	// If so, that function is executed prior to any serialization. It must construct and return an associative array
+	// of key/value pairs that represent the serialized form of the object.
+	if hasMethod(object.Class, "__serialize", context) {
+		data, err := callMethod(object, "__serialize", []values.RuntimeValue{}, context)
+		if err != nil {
+			return err
+		}
+		if data.GetType() != values.ArrayValue {
+			return phpError.NewError("Uncaught TypeError: %s::__serialize() must return an array", object.Class.Name)
+		}
+		writeObjectHeader(data.(*values.Array).Count())
+		if err := serializer.serializeElements(data.(*values.Array)); err != nil {
+			return err
+		}
+		serializer.result.WriteString("}")
+		return nil
+	}
+
+	// Spec: https://www.php.net/manual/en/class.serializable.php
+	// Classes that implement this interface no longer support __sleep() and __wakeup().
+	// The method serialize is called whenever an instance needs to be serialized.
+	if context.Interpreter.IsSubclassOf(object.Class, "Serializable") {
+		data, err := callMethod(object, "serialize", []values.RuntimeValue{}, context)
+		if err != nil {
+			return err
+		}
+		switch data.GetType() {
+		case values.NullValue:
+			serializer.result.WriteString("N;")
+		case values.StrValue:
+			str := data.(*values.Str).Value
+			serializer.result.WriteString(fmt.Sprintf(`C:%d:"%s":%d:{%s}`, len(className), className, len(str), str))
+		default:
+			return phpError.NewError("Uncaught Exception: %s::serialize() must return a string or NULL", object.Class.Name)
+		}
+		return nil
+	}
+
+	// Collect the properties to serialize
+	names := []string{}
+	for _, name := range object.PropertyNames {
+		if _, found := object.Properties[name]; found && !(name == incompleteClassNameProperty && object.Class.Name == incompleteClassName) {
+			names = append(names, name)
+		}
+	}
+
+	// Spec: https://www.php.net/manual/en/language.oop5.magic.php#object.sleep
+	// serialize() checks if the class has a function with the magic name __sleep(). If so, that function is executed
+	// prior to any serialization. It can clean up the object and is supposed to return an array with the names
+	// of all variables of that object that should be serialized.
+	if hasMethod(object.Class, "__sleep", context) {
+		sleepNames, err := callMethod(object, "__sleep", []values.RuntimeValue{}, context)
+		if err != nil {
+			return err
+		}
+		if sleepNames.GetType() != values.ArrayValue {
+			context.Interpreter.PrintError(phpError.NewWarning(
+				"serialize(): __sleep should return an array only containing the names of instance-variables to serialize in %s",
+				context.Stmt.GetPosString(),
+			))
+			serializer.result.WriteString("N;")
+			return nil
+		}
+		names = []string{}
+		for _, sleepName := range sleepNames.(*values.Array).GetValues() {
+			name, err := StrVal(sleepName, 0)
+			if err != nil {
+				return err
+			}
+			names = append(names, "$"+name)
+		}
+	}
+
+	writeObjectHeader(len(names))
+	for _, name := range names {
+		serializer.writeString(serializer.mangledPropertyName(object, name))
+		value, found := object.GetProperty(name)
+		if !found {
+			// If a name that does not exist is returned by __sleep, the property is serialized as null
+			context.Interpreter.PrintError(phpError.NewWarning(
+				"serialize(): \"%s\" returned as member variable from __sleep() but does not exist in %s",
+				name[1:], context.Stmt.GetPosString(),
+			))
+		}
+		if err := serializer.serializeValue(value); err != nil {
+			return err
+		}
+	}
+	serializer.result.WriteString("}")
+	return nil
+}
+
+// Spec: https://www.php.net/manual/en/language.types.array.php#language.types.array.casting
+// Private properties are serialized as "\0ClassName\0property", protected properties as "\0*\0property".
+func (serializer *serializer) mangledPropertyName(object *values.Object, name string) string {
+	class := object.Class
+	for class != nil {
+		if property, found := class.Properties[name]; found {
+			switch property.Visibility {
+			case "private":
+				return "\x00" + class.Name + "\x00" + name[1:]
+			case "protected":
+				return "\x00*\x00" + name[1:]
+			}
+			return name[1:]
+		}
+		if class.BaseClass == "" {
+			break
+		}
+		class, _ = serializer.context.Interpreter.GetClass(class.BaseClass)
+	}
+	return name[1:]
+}
+
+// -------------------------------------- unserialize -------------------------------------- MARK: unserialize
+
+func nativeFn_unserialize(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	args, err := funcParamValidator.NewValidator("unserialize").
+		AddParam("$data", []string{"string"}, nil).
+		AddParam("$options", []string{"array"}, values.NewArray()).
+		Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// Spec: https://www.php.net/manual/en/function.unserialize.php
+	// unserialize() takes a single serialized variable and converts it back into a PHP value.
+	// The converted value is returned, and can be a bool, int, float, string, array or object.
+	// In case the passed string is not unserializeable, false is returned and E_WARNING is issued.
+	unserializer := &unserializer{
+		context:  context,
+		data:     args[0].(*values.Str).Value,
+		maxDepth: int(context.Interpreter.GetIni().GetInt("unserialize_max_depth")),
+	}
+
+	options := args[1].(*values.Array)
+
+	// allowed_classes: Either an array of class names which should be accepted, false to accept no classes,
+	// or true to accept all classes. If this option is defined and unserialize() encounters an object of a class
+	// that isn't to be accepted, then the object will be instantiated as __PHP_Incomplete_Class instead.
+	if allowedClasses, found := options.GetElement(values.NewStr("allowed_classes")); found {
+		switch allowedClasses.GetType() {
+		case values.BoolValue:
+			allowAll := allowedClasses.(*values.Bool).Value
+			unserializer.isClassAllowed = func(string) bool { return allowAll }
+		case values.ArrayValue:
+			names := []string{}
+			for _, name := range allowedClasses.(*values.Array).GetValues() {
+				if name.GetType() != values.StrValue {
+					return values.NewVoid(), phpError.NewError(
+						`Uncaught TypeError: unserialize(): Option "allowed_classes" must be an array of class names, %s given`,
+						strings.ToLower(values.ToPhpType(name)),
+					)
+				}
+				names = append(names, strings.ToLower(name.(*values.Str).Value))
+			}
+			unserializer.isClassAllowed = func(name string) bool { return slices.Contains(names, strings.ToLower(name)) }
+		default:
+			return values.NewVoid(), phpError.NewError(
+				`Uncaught TypeError: unserialize(): Option "allowed_classes" must be an array or of type bool, %s given`,
+				strings.ToLower(values.ToPhpType(allowedClasses)),
+			)
+		}
+	}
+
+	// max_depth: The maximum depth of structures permitted during unserialization.
+	// The default (0) uses the ini directive "unserialize_max_depth".
+	if maxDepth, found := options.GetElement(values.NewStr("max_depth")); found {
+		if maxDepth.GetType() != values.IntValue {
+			return values.NewVoid(), phpError.NewError(
+				`Uncaught TypeError: unserialize(): Option "max_depth" must be of type int, %s given`,
+				strings.ToLower(values.ToPhpType(maxDepth)),
+			)
+		}
+		if maxDepth.(*values.Int).Value < 0 {
+			return values.NewVoid(), phpError.NewError(`Uncaught ValueError: unserialize(): Option "max_depth" must be greater than or equal to 0`)
+		}
+		if maxDepth.(*values.Int).Value > 0 {
+			unserializer.maxDepth = int(maxDepth.(*values.Int).Value)
+		}
+	}
+
+	value, ok, err := unserializer.unserializeValue()
+	if err != nil {
+		return values.NewVoid(), err
+	}
+	if !ok {
+		context.Interpreter.PrintError(phpError.NewWarning(
+			"unserialize(): Error at offset %d of %d bytes in %s", unserializer.offset, len(unserializer.data), context.Stmt.GetPosString(),
+		))
+		return values.NewBool(false), nil
+	}
+	if err := unserializer.callDeferredMethods(); err != nil {
+		return values.NewVoid(), err
+	}
+	if unserializer.pos < len(unserializer.data) {
+		context.Interpreter.PrintError(phpError.NewWarning(
+			"unserialize(): Extra data starting at offset %d of %d bytes in %s", unserializer.pos, len(unserializer.data), context.Stmt.GetPosString(),
+		))
+	}
+	return value, nil
+}
+
+type unserializer struct {
+	context runtime.Context
+	data    string
+	pos     int
+	// Position up to which the data was unserialized. It is reported if the data is invalid.
+	offset int
+	// Unserialized values by their number (see serializer)
+	slots          []values.RuntimeValue
+	isClassAllowed func(name string) bool
+	maxDepth       int
+	depth          int
+	// The methods __unserialize and __wakeup are called after the data is unserialized
+	deferredCalls []deferredCall
+}
+
+type deferredCall struct {
+	object *values.Object
+	method string
+	args   []values.RuntimeValue
+}
+
+func (unserializer *unserializer) callDeferredMethods() phpError.Error {
+	for _, call := range unserializer.deferredCalls {
+		if _, err := callMethod(call.object, call.method, call.args, unserializer.context); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Consume the expected string
+func (unserializer *unserializer) expect(str string) bool {
+	if !strings.HasPrefix(unserializer.data[unserializer.pos:], str) {
+		return false
+	}
+	unserializer.pos += len(str)
+	return true
+}
+
+// Read the characters up to the terminator and consume the terminator
+func (unserializer *unserializer) readUntil(terminator byte) (string, bool) {
+	end := strings.IndexByte(unserializer.data[unserializer.pos:], terminator)
+	if end < 0 {
+		return "", false
+	}
+	str := unserializer.data[unserializer.pos : unserializer.pos+end]
+	unserializer.pos += end + 1
+	return str, true
+}
+
+// Read a non-negative number followed by the terminator
+func (unserializer *unserializer) readLength(terminator byte) (int, bool) {
+	str, ok := unserializer.readUntil(terminator)
+	if !ok || str == "" || strings.Trim(str, "0123456789") != "" {
+		return 0, false
+	}
+	length, err := strconv.Atoi(str)
+	return length, err == nil
+}
+
+// Read a string with the given length and the delimiters, e.g. `"abc"`
+func (unserializer *unserializer) readQuotedString(length int, open string, close string) (string, bool) {
+	if !unserializer.expect(open) || unserializer.pos+length > len(unserializer.data) {
+		return "", false
+	}
+	str := unserializer.data[unserializer.pos : unserializer.pos+length]
+	unserializer.pos += length
+	if !unserializer.expect(close) {
+		// Report the position of the first unexpected character
+		for index := 0; index < len(close) && unserializer.pos+index < len(unserializer.data); index++ {
+			if unserializer.data[unserializer.pos+index] != close[index] {
+				unserializer.offset = unserializer.pos + index
+				break
+			}
+		}
+		return "", false
+	}
+	return str, true
+}
+
+func (unserializer *unserializer) readInt() (int64, bool) {
+	str, ok := unserializer.readUntil(';')
+	if !ok || strings.Trim(strings.TrimLeft(str, "+-"), "0123456789") != "" || len(str) > 0 && strings.ContainsAny(str[1:], "+-") {
+		return 0, false
+	}
+	value, err := strconv.ParseInt(strings.TrimPrefix(str, "+"), 10, 64)
+	return value, err == nil
+}
+
+func (unserializer *unserializer) enterNested() bool {
+	if unserializer.maxDepth > 0 && unserializer.depth >= unserializer.maxDepth {
+		unserializer.context.Interpreter.PrintError(phpError.NewWarning(
+			"unserialize(): Maximum depth of %d exceeded. "+
+				"The depth limit can be changed using the max_depth unserialize() option or the unserialize_max_depth ini setting in %s",
+			unserializer.maxDepth, unserializer.context.Stmt.GetPosString(),
+		))
+		return false
+	}
+	unserializer.depth++
+	return true
+}
+
+// Unserialize a value. It returns false if the data is invalid.
+// Errors of called methods (e.g. __unserialize) are returned as phpError.
+func (unserializer *unserializer) unserializeValue() (values.RuntimeValue, bool, phpError.Error) {
+	start := unserializer.pos
+	if start+1 >= len(unserializer.data) {
+		return nil, false, nil
+	}
+
+	kind := unserializer.data[start]
+	if kind == 'N' {
+		if !unserializer.expect("N;") {
+			return nil, false, nil
+		}
+		unserializer.slots = append(unserializer.slots, values.NewNull())
+		return values.NewNull(), true, nil
+	}
+	if unserializer.data[start+1] != ':' {
+		return nil, false, nil
+	}
+	unserializer.pos += 2
+
+	// References ("R:") do not get a number as they share the number of the referenced value
+	slot := len(unserializer.slots)
+	if kind != 'R' {
+		unserializer.slots = append(unserializer.slots, values.NewNull())
+	}
+	value, ok, err := unserializer.unserializeKind(kind)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	if kind != 'R' {
+		unserializer.slots[slot] = value
+	}
+	unserializer.offset = unserializer.pos
+	return value, true, nil
+}
+
+func (unserializer *unserializer) unserializeKind(kind byte) (values.RuntimeValue, bool, phpError.Error) {
+	switch kind {
+	case 'b':
+		switch {
+		case unserializer.expect("0;"):
+			return values.NewBool(false), true, nil
+		case unserializer.expect("1;"):
+			return values.NewBool(true), true, nil
+		}
+		return nil, false, nil
+
+	case 'i':
+		value, ok := unserializer.readInt()
+		return values.NewInt(value), ok, nil
+
+	case 'd':
+		str, ok := unserializer.readUntil(';')
+		if !ok {
+			return nil, false, nil
+		}
+		switch str {
+		case "INF":
+			return values.NewFloat(math.Inf(1)), true, nil
+		case "-INF":
+			return values.NewFloat(math.Inf(-1)), true, nil
+		case "NAN":
+			return values.NewFloat(math.NaN()), true, nil
+		}
+		if str == "" || strings.Trim(str, "0123456789.eE+-") != "" {
+			return nil, false, nil
+		}
+		value, err := strconv.ParseFloat(str, 64)
+		if err != nil && value == 0 {
+			return nil, false, nil
+		}
+		return values.NewFloat(value), true, nil
+
+	case 's':
+		length, ok := unserializer.readLength(':')
+		if !ok {
+			return nil, false, nil
+		}
+		str, ok := unserializer.readQuotedString(length, `"`, `";`)
+		return values.NewStr(str), ok, nil
+
+	case 'a':
+		count, ok := unserializer.readLength(':')
+		if !ok || !unserializer.expect("{") {
+			return nil, false, nil
+		}
+		unserializer.offset = unserializer.pos
+		// Empty arrays do not count towards the maximum depth
+		if count > 0 && !unserializer.enterNested() {
+			return nil, false, nil
+		}
+		array, ok, err := unserializer.unserializeElements(count)
+		if err != nil || !ok {
+			return nil, false, err
+		}
+		if count > 0 {
+			unserializer.depth--
+		}
+		return array, unserializer.expect("}"), nil
+
+	case 'O', 'C':
+		return unserializer.unserializeObject(kind)
+
+	case 'E':
+		// TODO Unserialize enum cases once enums are supported
+		length, ok := unserializer.readLength(':')
+		if !ok {
+			return nil, false, nil
+		}
+		str, ok := unserializer.readQuotedString(length, `"`, `";`)
+		className, _, hasCase := strings.Cut(str, ":")
+		if !ok || !hasCase {
+			return nil, false, nil
+		}
+		if _, found := unserializer.context.Interpreter.GetClass(className); found {
+			unserializer.context.Interpreter.PrintError(phpError.NewWarning(
+				"unserialize(): Class '%s' is not an enum in %s", className, unserializer.context.Stmt.GetPosString(),
+			))
+		} else {
+			unserializer.context.Interpreter.PrintError(phpError.NewWarning(
+				"unserialize(): Class '%s' not found in %s", className, unserializer.context.Stmt.GetPosString(),
+			))
+		}
+		return nil, false, nil
+
+	case 'r', 'R':
+		// Back-reference to a previously unserialized value.
+		// References ("R:") are unserialized as copy because variables cannot share values.
+		slot, ok := unserializer.readInt()
+		if !ok || slot < 1 || slot > int64(len(unserializer.slots)) || unserializer.slots[slot-1] == nil {
+			return nil, false, nil
+		}
+		return values.Copy(unserializer.slots[slot-1]), true, nil
+	}
+	return nil, false, nil
+}
+
+// Unserialize the given number of key/value pairs
+func (unserializer *unserializer) unserializeElements(count int) (*values.Array, bool, phpError.Error) {
+	array := values.NewArray()
+	for range count {
+		key, ok := unserializer.unserializeKey()
+		if !ok {
+			return nil, false, nil
+		}
+		value, ok, err := unserializer.unserializeValue()
+		if err != nil || !ok {
+			return nil, false, err
+		}
+		array.SetElement(key, value)
+	}
+	return array, true, nil
+}
+
+// Keys are integers or strings. They do not get a number for back-references.
+func (unserializer *unserializer) unserializeKey() (values.RuntimeValue, bool) {
+	switch {
+	case unserializer.expect("i:"):
+		key, ok := unserializer.readInt()
+		if ok {
+			unserializer.offset = unserializer.pos
+			return values.NewInt(key), true
+		}
+	case unserializer.expect("s:"):
+		length, ok := unserializer.readLength(':')
+		if ok {
+			if key, ok := unserializer.readQuotedString(length, `"`, `";`); ok {
+				unserializer.offset = unserializer.pos
+				return values.NewStr(key), true
+			}
+		}
+	}
+	return nil, false
+}
+
+func isValidClassName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		char := name[i]
+		if !(char == '_' || char == '\\' || char >= 0x80 || (char >= '0' && char <= '9') || (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z')) {
+			return false
+		}
+	}
+	return true
+}
+
+func (unserializer *unserializer) unserializeObject(kind byte) (values.RuntimeValue, bool, phpError.Error) {
+	context := unserializer.context
+	slot := len(unserializer.slots) - 1
+
+	length, ok := unserializer.readLength(':')
+	if !ok {
+		return nil, false, nil
+	}
+	className, ok := unserializer.readQuotedString(length, `"`, `":`)
+	if !ok || !isValidClassName(className) {
+		return nil, false, nil
+	}
+	count, ok := unserializer.readLength(':')
+	if !ok {
+		return nil, false, nil
+	}
+
+	// Objects of classes that are not defined or not allowed are unserialized as __PHP_Incomplete_Class
+	class, found := context.Interpreter.GetClass(className)
+	isIncomplete := !found || (unserializer.isClassAllowed != nil && !unserializer.isClassAllowed(className))
+	if isIncomplete {
+		class, _ = context.Interpreter.GetClass(incompleteClassName)
+	}
+	object, err := context.Interpreter.NewObjectWithoutConstructor(context, class)
+	if err != nil {
+		return nil, false, err
+	}
+	if isIncomplete {
+		object.SetProperty(incompleteClassNameProperty, values.NewStr(className))
+	}
+	// The object can be referenced by its properties
+	unserializer.slots[slot] = object
+
+	if kind == 'C' {
+		// Spec: https://www.php.net/manual/en/serializable.unserialize.php
+		// Objects of classes implementing Serializable are unserialized with the method unserialize.
+		data, ok := unserializer.readQuotedString(count, "{", "}")
+		if !ok {
+			return nil, false, nil
+		}
+		if isIncomplete {
+			context.Interpreter.PrintError(phpError.NewWarning(
+				"unserialize(): Class %s has no unserializer in %s", incompleteClassName, context.Stmt.GetPosString(),
+			))
+			return object, true, nil
+		}
+		if !context.Interpreter.IsSubclassOf(class, "Serializable") {
+			context.Interpreter.PrintError(phpError.NewWarning(
+				"unserialize(): Erroneous data format for unserializing '%s' in %s", className, context.Stmt.GetPosString(),
+			))
+			return nil, false, nil
+		}
+		if _, err := callMethod(object, "unserialize", []values.RuntimeValue{values.NewStr(data)}, context); err != nil {
+			return nil, false, err
+		}
+		return object, true, nil
+	}
+
+	if !unserializer.expect("{") {
+		return nil, false, nil
+	}
+	unserializer.offset = unserializer.pos
+	if !unserializer.enterNested() {
+		return nil, false, nil
+	}
+	properties, ok, err := unserializer.unserializeElements(count)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	unserializer.depth--
+	if !unserializer.expect("}") {
+		return nil, false, nil
+	}
+
+	// Spec: https://www.php.net/manual/en/language.oop5.magic.php#object.unserialize
+	// If the class has the magic method __unserialize(), it is called with the restored array
+	// that was returned from __serialize() instead of restoring the properties.
+	if !isIncomplete && hasMethod(class, "__unserialize", context) {
+		unserializer.deferredCalls = append(unserializer.deferredCalls, deferredCall{object: object, method: "__unserialize", args: []values.RuntimeValue{properties}})
+		return object, true, nil
+	}
+
+	for _, key := range properties.GetKeys() {
+		value, _ := properties.GetElement(key)
+		name, _ := StrVal(key, 0)
+		// Mangled names of private ("\0ClassName\0property") and protected ("\0*\0property") properties
+		if !isIncomplete && strings.HasPrefix(name, "\x00") {
+			if index := strings.IndexByte(name[1:], 0); index >= 0 {
+				name = name[index+2:]
+			}
+		}
+		object.SetProperty("$"+name, value)
+	}
+
+	// Spec: https://www.php.net/manual/en/language.oop5.magic.php#object.wakeup
+	// unserialize() checks for the presence of a function with the magic name __wakeup().
+	// If present, this function can reconstruct any resources that the object may have.
+	if !isIncomplete && hasMethod(class, "__wakeup", context) {
+		unserializer.deferredCalls = append(unserializer.deferredCalls, deferredCall{object: object, method: "__wakeup", args: []values.RuntimeValue{}})
+	}
+	return object, true, nil
+}
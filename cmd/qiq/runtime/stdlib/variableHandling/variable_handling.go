@@ -18,6 +18,9 @@ func Register(environment runtime.Environment) {
 	environment.AddNativeFunction("doubleval", nativeFn_floatval)
 	environment.AddNativeFunction("floatval", nativeFn_floatval)
 	environment.AddNativeFunction("get_debug_type", nativeFn_get_debug_type)
+	environment.AddNativeFunction("get_defined_vars", nativeFn_get_defined_vars)
+	environment.AddNativeFunction("get_resource_id", nativeFn_get_resource_id)
+	environment.AddNativeFunction("get_resource_type", nativeFn_get_resource_type)
 	environment.AddNativeFunction("gettype", nativeFn_gettype)
 	environment.AddNativeFunction("intval", nativeFn_intval)
 	environment.AddNativeFunction("is_array", nativeFn_is_array)
@@ -28,10 +31,13 @@ func Register(environment runtime.Environment) {
 	environment.AddNativeFunction("is_integer", nativeFn_is_int)
 	environment.AddNativeFunction("is_long", nativeFn_is_int)
 	environment.AddNativeFunction("is_null", nativeFn_is_null)
+	environment.AddNativeFunction("is_resource", nativeFn_is_resource)
 	environment.AddNativeFunction("is_scalar", nativeFn_is_scalar)
 	environment.AddNativeFunction("is_string", nativeFn_is_string)
 	environment.AddNativeFunction("print_r", nativeFn_print_r)
+	environment.AddNativeFunction("serialize", nativeFn_serialize)
 	environment.AddNativeFunction("strval", nativeFn_strval)
+	environment.AddNativeFunction("unserialize", nativeFn_unserialize)
 	environment.AddNativeFunction("var_dump", nativeFn_var_dump)
 	environment.AddNativeFunction("var_export", nativeFn_var_export)
 }
@@ -50,8 +56,7 @@ func ArrayVal(runtimeValue values.RuntimeValue) (*values.Array, phpError.Error)
 		return values.NewArray(), nil
 	}
 
-	// TODO ArrayVal - resource
-	if IsScalar(runtimeValue) {
+	if IsScalar(runtimeValue) || runtimeValue.GetType() == values.ResourceValue {
 		// Spec: https://phplang.org/spec/08-conversions.html#converting-to-array-type
 		// If the source type is scalar or resource and it is non-NULL,
 		// the result value is an array of one element under the key 0 whose value is that of the source.
@@ -94,7 +99,7 @@ func BoolVal(runtimeValue values.RuntimeValue) (bool, phpError.Error) {
 	case values.ArrayValue:
 		// Spec: https://phplang.org/spec/08-conversions.html#converting-to-boolean-type
 		// If the source is an array with zero elements, the result value is FALSE; otherwise, the result value is TRUE.
-		return len(runtimeValue.(*values.Array).Elements) != 0, nil
+		return runtimeValue.(*values.Array).Count() != 0, nil
 	case values.BoolValue:
 		return runtimeValue.(*values.Bool).Value, nil
 	case values.IntValue:
@@ -116,6 +121,10 @@ func BoolVal(runtimeValue values.RuntimeValue) (bool, phpError.Error) {
 		// If the source is an empty string or the string “0”, the result value is FALSE; otherwise, the result value is TRUE.
 		str := runtimeValue.(*values.Str).Value
 		return str != "" && str != "0", nil
+	case values.ResourceValue:
+		// Spec: https://phplang.org/spec/08-conversions.html#converting-to-boolean-type
+		// If the source is a resource, the result value is TRUE.
+		return true, nil
 	default:
 		return false, phpError.NewError("boolval: Unsupported runtime value %s", runtimeValue.GetType())
 	}
@@ -123,10 +132,6 @@ func BoolVal(runtimeValue values.RuntimeValue) (bool, phpError.Error) {
 	// TODO boolval - object
 	// Spec: https://phplang.org/spec/08-conversions.html#converting-to-boolean-type
 	// If the source is an object, the result value is TRUE.
-
-	// TODO boolval - resource
-	// Spec: https://phplang.org/spec/08-conversions.html#converting-to-boolean-type
-	// If the source is a resource, the result value is TRUE.
 }
 
 // -------------------------------------- floatval -------------------------------------- MARK: floatval
@@ -162,18 +167,14 @@ func FloatVal(runtimeValue values.RuntimeValue, leadingNumeric bool) (float64, p
 		// the result value is the closest approximation to the string’s floating-point value.
 		// The trailing non-numeric characters in leading-numeric strings are ignored.
 		// For any other string, the result value is 0.
-		intStr := runtimeValue.(*values.Str).Value
-		if common.IsFloatingLiteralWithSign(intStr, leadingNumeric) {
-			return common.FloatingLiteralToFloat64WithSign(intStr, leadingNumeric), nil
+		numericStr, ok := common.ParseNumericString(runtimeValue.(*values.Str).Value)
+		if !ok || (!leadingNumeric && numericStr.TrailingData) {
+			return 0, nil
 		}
-		if common.IsIntegerLiteralWithSign(intStr, leadingNumeric) {
-			intValue, err := common.IntegerLiteralToInt64WithSign(intStr, leadingNumeric)
-			if err != nil {
-				return 0, phpError.NewError("%s", err.Error())
-			}
-			return FloatVal(values.NewInt(intValue), leadingNumeric)
+		if numericStr.IsFloat {
+			return numericStr.Float, nil
 		}
-		return 0, nil
+		return FloatVal(values.NewInt(numericStr.Int), leadingNumeric)
 	default:
 		// Spec: https://phplang.org/spec/08-conversions.html#converting-to-floating-point-type
 		// For sources of all other types, the conversion result is obtained by first converting
@@ -206,8 +207,6 @@ func lib_get_debug_type(runtimeValue values.RuntimeValue) (string, phpError.Erro
 	// Spec: https://www.php.net/manual/en/function.get-debug-type
 
 	// TODO lib_get_debug_type - object
-	// TODO lib_get_debug_type - resource
-	// TODO lib_get_debug_type - resource (closed)
 	switch runtimeValue.GetType() {
 	case values.ArrayValue:
 		return "array", nil
@@ -221,11 +220,42 @@ func lib_get_debug_type(runtimeValue values.RuntimeValue) (string, phpError.Erro
 		return "null", nil
 	case values.StrValue:
 		return "string", nil
+	case values.ResourceValue:
+		resource := runtimeValue.(*values.Resource)
+		if resource.IsClosed {
+			return "resource (closed)", nil
+		}
+		return fmt.Sprintf("resource (%s)", resource.ResourceType), nil
 	default:
 		return "unknown type", nil
 	}
 }
 
+// -------------------------------------- get_defined_vars -------------------------------------- MARK: get_defined_vars
+
+func nativeFn_get_defined_vars(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.get-defined-vars.php
+	_, err := funcParamValidator.NewValidator("get_defined_vars").Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// This function returns a multidimensional array containing a list of all defined variables,
+	// be them environment, server or user-defined variables, within the scope that get_defined_vars() is called.
+	result := values.NewArray()
+	for _, variableName := range context.Env.GetVariableNames() {
+		// $this is not included
+		if variableName == "$this" {
+			continue
+		}
+		value, _ := context.Env.LookupVariable(variableName)
+		if err := result.SetElement(values.NewStr(variableName[1:]), values.Copy(value)); err != nil {
+			return values.NewVoid(), err
+		}
+	}
+	return result, nil
+}
+
 // -------------------------------------- gettype -------------------------------------- MARK: gettype
 
 func nativeFn_gettype(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
@@ -242,8 +272,6 @@ func GetType(runtimeValue values.RuntimeValue) (string, phpError.Error) {
 	// Spec: https://www.php.net/manual/en/function.gettype.php
 
 	// TODO GetType - object
-	// TODO GetType - resource
-	// TODO GetType - resource (closed)
 	switch runtimeValue.GetType() {
 	case values.ArrayValue:
 		return "array", nil
@@ -257,11 +285,44 @@ func GetType(runtimeValue values.RuntimeValue) (string, phpError.Error) {
 		return "NULL", nil
 	case values.StrValue:
 		return "string", nil
+	case values.ResourceValue:
+		if runtimeValue.(*values.Resource).IsClosed {
+			return "resource (closed)", nil
+		}
+		return "resource", nil
 	default:
 		return "unknown type", nil
 	}
 }
 
+// -------------------------------------- get_resource_id -------------------------------------- MARK: get_resource_id
+
+func nativeFn_get_resource_id(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.get-resource-id.php
+
+	args, err := funcParamValidator.NewValidator("get_resource_id").AddParam("$resource", []string{"resource"}, nil).Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	return values.NewInt(args[0].(*values.Resource).Id), nil
+}
+
+// -------------------------------------- get_resource_type -------------------------------------- MARK: get_resource_type
+
+func nativeFn_get_resource_type(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	// Spec: https://www.php.net/manual/en/function.get-resource-type.php
+
+	args, err := funcParamValidator.NewValidator("get_resource_type").AddParam("$resource", []string{"resource"}, nil).Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	// If the given resource is a resource, this function will return a string representing its type.
+	// If the type is not identified by this function, the return value will be the string Unknown.
+	return values.NewStr(args[0].(*values.Resource).GetResourceType()), nil
+}
+
 // -------------------------------------- intval -------------------------------------- MARK: intval
 
 func nativeFn_intval(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
@@ -281,7 +342,7 @@ func IntVal(runtimeValue values.RuntimeValue, leadingNumeric bool) (int64, phpEr
 	case values.ArrayValue:
 		// Spec: https://phplang.org/spec/08-conversions.html#converting-to-integer-type
 		// If the source is an array with zero elements, the result value is 0; otherwise, the result value is 1.
-		if len(runtimeValue.(*values.Array).Elements) == 0 {
+		if runtimeValue.(*values.Array).Count() == 0 {
 			return 0, nil
 		}
 		return 1, nil
@@ -313,18 +374,27 @@ func IntVal(runtimeValue values.RuntimeValue, leadingNumeric bool) (int64, phpEr
 		// the string’s floating-point value is treated as described above for a conversion from float.
 		// The trailing non-numeric characters in leading-numeric strings are ignored.
 		// For any other string, the result value is 0.
-		intStr := runtimeValue.(*values.Str).Value
-		if common.IsFloatingLiteralWithSign(intStr, leadingNumeric) {
-			return IntVal(values.NewFloat(common.FloatingLiteralToFloat64WithSign(intStr, leadingNumeric)), leadingNumeric)
+		numericStr, ok := common.ParseNumericString(runtimeValue.(*values.Str).Value)
+		if !ok || (!leadingNumeric && numericStr.TrailingData) {
+			return 0, nil
 		}
-		if common.IsIntegerLiteralWithSign(intStr, leadingNumeric) {
-			intValue, err := common.IntegerLiteralToInt64WithSign(intStr, leadingNumeric)
-			if err != nil {
-				return 0, phpError.NewError("%s", err.Error())
-			}
-			return intValue, nil
+		if !numericStr.IsFloat {
+			return numericStr.Int, nil
 		}
-		return 0, nil
+		// Floating-point values outside the range of an int are capped to the smallest or largest int
+		switch {
+		case math.IsNaN(numericStr.Float) || math.IsInf(numericStr.Float, 0):
+			return 0, nil
+		case numericStr.Float >= math.MaxInt64:
+			return math.MaxInt64, nil
+		case numericStr.Float <= math.MinInt64:
+			return math.MinInt64, nil
+		}
+		return int64(numericStr.Float), nil
+	case values.ResourceValue:
+		// Spec: https://phplang.org/spec/08-conversions.html#converting-to-integer-type
+		// If the source is a resource, the result is the resource’s unique ID.
+		return runtimeValue.(*values.Resource).Id, nil
 	default:
 		return 0, phpError.NewError("IntVal: Unsupported runtime value %s", runtimeValue.GetType())
 	}
@@ -332,10 +402,6 @@ func IntVal(runtimeValue values.RuntimeValue, leadingNumeric bool) (int64, phpEr
 	// TODO IntVal - object
 	// Spec: https://phplang.org/spec/08-conversions.html#converting-to-integer-type
 	// If the source is an object, if the class defines a conversion function, the result is determined by that function (this is currently available only to internal classes). If not, the conversion is invalid, the result is assumed to be 1 and a non-fatal error is produced.
-
-	// TODO IntVal - resource
-	// Spec: https://phplang.org/spec/08-conversions.html#converting-to-integer-type
-	// If the source is a resource, the result is the resource’s unique ID.
 }
 
 // -------------------------------------- is_array -------------------------------------- MARK: is_array
@@ -418,6 +484,24 @@ func lib_is_null(runtimeValue values.RuntimeValue) bool {
 	return runtimeValue.GetType() == values.NullValue
 }
 
+// -------------------------------------- is_resource -------------------------------------- MARK: is_resource
+
+func nativeFn_is_resource(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+	args, err := funcParamValidator.NewValidator("is_resource").AddParam("$value", []string{"mixed"}, nil).Validate(args)
+	if err != nil {
+		return values.NewVoid(), err
+	}
+
+	return values.NewBool(lib_is_resource(args[0])), nil
+}
+
+func lib_is_resource(runtimeValue values.RuntimeValue) bool {
+	// Spec: https://www.php.net/manual/en/function.is-resource.php
+	// Returns true if value is a resource, false otherwise. is_resource() is not a strict type-checking method:
+	// it will return false if value is a resource variable that has been closed.
+	return runtimeValue.GetType() == values.ResourceValue && !runtimeValue.(*values.Resource).IsClosed
+}
+
 // -------------------------------------- is_scalar -------------------------------------- MARK: is_scalar
 
 func nativeFn_is_scalar(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
@@ -465,7 +549,7 @@ func nativeFn_print_r(args []values.RuntimeValue, context runtime.Context) (valu
 }
 
 func lib_print_r(value values.RuntimeValue, returnValue bool, context runtime.Context) (values.RuntimeValue, phpError.Error) {
-	result, err := lib_print_r_var(value, 4)
+	result, err := lib_print_r_var(value, 4, context.Interpreter.GetIni().GetInt("precision"))
 	if err != nil {
 		return values.NewVoid(), err
 	}
@@ -478,20 +562,20 @@ func lib_print_r(value values.RuntimeValue, returnValue bool, context runtime.Co
 	}
 }
 
-func lib_print_r_var(value values.RuntimeValue, depth int) (string, phpError.Error) {
+func lib_print_r_var(value values.RuntimeValue, depth int, precision int64) (string, phpError.Error) {
 	result := ""
 	var err phpError.Error
 	switch value.GetType() {
 	case values.ArrayValue:
 		array := value.(*values.Array)
 		result = fmt.Sprintf("Array\n%s(\n", strings.Repeat(" ", depth-4))
-		for _, key := range array.Keys {
-			keyStr, err := lib_print_r_var(key, depth+4)
+		for _, key := range array.GetKeys() {
+			keyStr, err := lib_print_r_var(key, depth+4, precision)
 			if err != nil {
 				return "", err
 			}
 			elementValue, _ := array.GetElement(key)
-			valueStr, err := lib_print_r_var(elementValue, depth+8)
+			valueStr, err := lib_print_r_var(elementValue, depth+8, precision)
 			if err != nil {
 				return "", err
 			}
@@ -505,8 +589,8 @@ func lib_print_r_var(value values.RuntimeValue, depth int) (string, phpError.Err
 		} else {
 			result = ""
 		}
-	case values.FloatValue, values.IntValue:
-		result, err = StrVal(value)
+	case values.FloatValue, values.IntValue, values.ResourceValue:
+		result, err = StrVal(value, precision)
 		if err != nil {
 			return "", err
 		}
@@ -518,7 +602,7 @@ func lib_print_r_var(value values.RuntimeValue, depth int) (string, phpError.Err
 		object := value.(*values.Object)
 		result = fmt.Sprintf("%s Object\n%s(\n", object.Class.Name, strings.Repeat(" ", depth-4))
 		for name, value := range object.Properties {
-			valueStr, err := lib_print_r_var(value, depth+8)
+			valueStr, err := lib_print_r_var(value, depth+8, precision)
 			if err != nil {
 				return "", err
 			}
@@ -534,17 +618,19 @@ func lib_print_r_var(value values.RuntimeValue, depth int) (string, phpError.Err
 
 // -------------------------------------- strval -------------------------------------- MARK: strval
 
-func nativeFn_strval(args []values.RuntimeValue, _ runtime.Context) (values.RuntimeValue, phpError.Error) {
+func nativeFn_strval(args []values.RuntimeValue, context runtime.Context) (values.RuntimeValue, phpError.Error) {
 	args, err := funcParamValidator.NewValidator("strval").AddParam("$value", []string{"mixed"}, nil).Validate(args)
 	if err != nil {
 		return values.NewVoid(), err
 	}
 
-	str, err := StrVal(args[0])
+	str, err := StrVal(args[0], context.Interpreter.GetIni().GetInt("precision"))
 	return values.NewStr(str), err
 }
 
-func StrVal(runtimeValue values.RuntimeValue) (string, phpError.Error) {
+// Convert the runtime value to a string.
+// Floats are converted with the given precision (see ini directive "precision").
+func StrVal(runtimeValue values.RuntimeValue, precision int64) (string, phpError.Error) {
 	// Spec: https://phplang.org/spec/08-conversions.html#converting-to-string-type
 
 	switch runtimeValue.GetType() {
@@ -565,7 +651,7 @@ func StrVal(runtimeValue values.RuntimeValue) (string, phpError.Error) {
 		// Spec: https://phplang.org/spec/08-conversions.html#converting-to-string-type
 		// If the source type is int or float, then the result value is a string containing the textual representation
 		// of the source value (as specified by the library function sprintf).
-		return runtimeValue.(*values.Float).ToPhpString(), nil
+		return runtimeValue.(*values.Float).ToPhpString(precision), nil
 	case values.IntValue:
 		// Spec: https://phplang.org/spec/08-conversions.html#converting-to-string-type
 		// If the source type is int or float, then the result value is a string containing the textual representation
@@ -579,6 +665,10 @@ func StrVal(runtimeValue values.RuntimeValue) (string, phpError.Error) {
 		return runtimeValue.(*values.Str).Value, nil
 	case values.VoidValue:
 		return "", nil
+	case values.ResourceValue:
+		// Spec: https://phplang.org/spec/08-conversions.html#converting-to-string-type
+		// If the source is a resource, the result value is an implementation-defined string.
+		return fmt.Sprintf("Resource id #%d", runtimeValue.(*values.Resource).Id), nil
 	default:
 		return "", phpError.NewError("lib_strval: Unsupported runtime value %s", runtimeValue.GetType())
 	}
@@ -586,10 +676,6 @@ func StrVal(runtimeValue values.RuntimeValue) (string, phpError.Error) {
 	// TODO lib_strval - object
 	// Spec: https://phplang.org/spec/08-conversions.html#converting-to-string-type
 	// If the source is an object, then if that object’s class has a __toString method, the result value is the string returned by that method; otherwise, the conversion is invalid and a fatal error is produced.
-
-	// TODO lib_strval - resource
-	// Spec: https://phplang.org/spec/08-conversions.html#converting-to-string-type
-	// If the source is a resource, the result value is an implementation-defined string.
 }
 
 // -------------------------------------- var_dump -------------------------------------- MARK: var_dump
@@ -610,7 +696,7 @@ func nativeFn_var_dump(args []values.RuntimeValue, context runtime.Context) (val
 
 	if len(args) == 2 {
 		arrayValues := args[1].(*values.Array)
-		for _, key := range arrayValues.Keys {
+		for _, key := range arrayValues.GetKeys() {
 			argValue, _ := arrayValues.GetElement(key)
 			if err := lib_var_dump_var(context, argValue, 2); err != nil {
 				return values.NewVoid(), err
@@ -625,8 +711,8 @@ func lib_var_dump_var(context runtime.Context, value values.RuntimeValue, depth
 	switch value.GetType() {
 	case values.ArrayValue:
 		array := value.(*values.Array)
-		context.Interpreter.Println(fmt.Sprintf("array(%d) {", len(array.Keys)))
-		for _, key := range array.Keys {
+		context.Interpreter.Println(fmt.Sprintf("array(%d) {", array.Count()))
+		for _, key := range array.GetKeys() {
 			switch key.GetType() {
 			case values.IntValue:
 				keyValue := key.(*values.Int).Value
@@ -651,22 +737,17 @@ func lib_var_dump_var(context runtime.Context, value values.RuntimeValue, depth
 			context.Interpreter.Println("bool(false)")
 		}
 	case values.FloatValue:
-		strVal, err := StrVal(value)
-		if err != nil {
-			return err
-		}
-		context.Interpreter.Println("float(" + strVal + ")")
+		context.Interpreter.Println("float(" + value.(*values.Float).ToPhpString(context.Interpreter.GetIni().GetInt("serialize_precision")) + ")")
 	case values.IntValue:
-		strVal, err := StrVal(value)
-		if err != nil {
-			return err
-		}
-		context.Interpreter.Println("int(" + strVal + ")")
+		context.Interpreter.Println(fmt.Sprintf("int(%d)", value.(*values.Int).Value))
 	case values.NullValue:
 		context.Interpreter.Println("NULL")
 	case values.StrValue:
 		strVal := value.(*values.Str).Value
 		context.Interpreter.Println(fmt.Sprintf("string(%d) \"%s\"", len(strVal), strVal))
+	case values.ResourceValue:
+		resource := value.(*values.Resource)
+		context.Interpreter.Println(fmt.Sprintf("resource(%d) of type (%s)", resource.Id, resource.GetResourceType()))
 	default:
 		return phpError.NewError("lib_var_dump_var: Unsupported runtime value %s", value.GetType())
 	}
@@ -690,7 +771,7 @@ func nativeFn_var_export(args []values.RuntimeValue, interpreter runtime.Context
 }
 
 func lib_var_export(value values.RuntimeValue, returnValue bool, context runtime.Context) (values.RuntimeValue, phpError.Error) {
-	result, err := lib_var_export_var(value, 2)
+	result, err := lib_var_export_var(value, 2, context.Interpreter.GetIni().GetInt("serialize_precision"))
 	if err != nil {
 		return values.NewVoid(), err
 	}
@@ -703,20 +784,19 @@ func lib_var_export(value values.RuntimeValue, returnValue bool, context runtime
 	}
 }
 
-func lib_var_export_var(value values.RuntimeValue, depth int) (string, phpError.Error) {
+func lib_var_export_var(value values.RuntimeValue, depth int, serializePrecision int64) (string, phpError.Error) {
 	result := ""
-	var err phpError.Error
 	switch value.GetType() {
 	case values.ArrayValue:
 		array := value.(*values.Array)
 		result = fmt.Sprintf("%sarray (\n", strings.Repeat(" ", depth-2))
-		for _, key := range array.Keys {
-			keyStr, err := lib_var_export_var(key, depth+2)
+		for _, key := range array.GetKeys() {
+			keyStr, err := lib_var_export_var(key, depth+2, serializePrecision)
 			if err != nil {
 				return "", err
 			}
 			elementValue, _ := array.GetElement(key)
-			valueStr, err := lib_var_export_var(elementValue, depth+2)
+			valueStr, err := lib_var_export_var(elementValue, depth+2, serializePrecision)
 			if err != nil {
 				return "", err
 			}
@@ -737,13 +817,20 @@ func lib_var_export_var(value values.RuntimeValue, depth int) (string, phpError.
 		} else {
 			result = "false"
 		}
-	case values.FloatValue, values.IntValue:
-		result, err = StrVal(value)
-		if err != nil {
-			return "", err
+	case values.FloatValue:
+		// Floats are exported with a fraction so that they are read back as floats
+		floating := value.(*values.Float).Value
+		result = common.FormatFloat(floating, serializePrecision)
+		if !math.IsInf(floating, 0) && !math.IsNaN(floating) && !strings.ContainsAny(result, ".E") {
+			result += ".0"
 		}
+	case values.IntValue:
+		result = fmt.Sprintf("%d", value.(*values.Int).Value)
 	case values.NullValue:
 		result = "NULL"
+	case values.ResourceValue:
+		// var_export does not handle resources and exports them as NULL
+		result = "NULL"
 	case values.StrValue:
 		result = "'" + value.(*values.Str).Value + "'"
 	default:
@@ -753,15 +840,9 @@ func lib_var_export_var(value values.RuntimeValue, depth int) (string, phpError.
 }
 
 // TODO debug_​zval_​dump
-// TODO get_​defined_​vars
-// TODO get_​resource_​id
-// TODO get_​resource_​type
 // TODO is_​callable
 // TODO is_​countable
 // TODO is_​iterable
 // TODO is_​numeric
 // TODO is_​object
-// TODO is_​resource
-// TODO serialize
 // TODO settype
-// TODO unserialize
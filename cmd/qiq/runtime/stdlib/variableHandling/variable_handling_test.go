@@ -148,7 +148,7 @@ func TestLibIntval(t *testing.T) {
 
 func TestLibStrval(t *testing.T) {
 	doTest := func(runtimeValue values.RuntimeValue, expected string) {
-		actual, err := StrVal(runtimeValue)
+		actual, err := StrVal(runtimeValue, 14)
 		if err != nil {
 			t.Errorf("Unexpected error: \"%s\"", err)
 			return
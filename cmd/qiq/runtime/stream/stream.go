@@ -0,0 +1,119 @@
+package stream
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Spec: https://www.php.net/manual/en/intro.stream.php
+
+type Stream struct {
+	reader   *bufio.Reader
+	writer   io.Writer
+	closer   io.Closer
+	isEof    bool
+	isClosed bool
+}
+
+func NewReadStream(reader io.ReadCloser) *Stream {
+	return &Stream{reader: bufio.NewReader(reader), closer: reader}
+}
+
+func NewWriteStream(writer io.WriteCloser) *Stream {
+	return &Stream{writer: writer, closer: writer}
+}
+
+func (stream *Stream) IsClosed() bool {
+	return stream.isClosed
+}
+
+func (stream *Stream) Eof() bool {
+	return stream.isEof
+}
+
+func (stream *Stream) Write(data string) (int, error) {
+	if stream.isClosed {
+		return 0, fmt.Errorf("stream is closed")
+	}
+	if stream.writer == nil {
+		return 0, fmt.Errorf("stream is not writable")
+	}
+	return io.WriteString(stream.writer, data)
+}
+
+// Read up to length bytes. Returns less if no more data is available without blocking again.
+func (stream *Stream) Read(length int) (string, error) {
+	if err := stream.checkReadable(); err != nil {
+		return "", err
+	}
+
+	buffer := make([]byte, length)
+	n, err := stream.reader.Read(buffer)
+	if err == io.EOF {
+		stream.isEof = true
+		err = nil
+	}
+	return string(buffer[:n]), err
+}
+
+// Read until a new line is reached (including the new line) or length - 1 bytes are read.
+// A length less or equal 0 reads the whole line.
+func (stream *Stream) ReadLine(length int) (string, error) {
+	if err := stream.checkReadable(); err != nil {
+		return "", err
+	}
+
+	var line strings.Builder
+	for length <= 0 || line.Len() < length-1 {
+		b, err := stream.reader.ReadByte()
+		if err == io.EOF {
+			stream.isEof = true
+			break
+		}
+		if err != nil {
+			return line.String(), err
+		}
+		line.WriteByte(b)
+		if b == '\n' {
+			break
+		}
+	}
+	return line.String(), nil
+}
+
+// Read the remaining data. A negative length reads until the end of the stream.
+func (stream *Stream) ReadAll(length int) (string, error) {
+	if err := stream.checkReadable(); err != nil {
+		return "", err
+	}
+
+	var reader io.Reader = stream.reader
+	if length >= 0 {
+		reader = io.LimitReader(stream.reader, int64(length))
+	}
+	content, err := io.ReadAll(reader)
+	if length < 0 || len(content) < length {
+		stream.isEof = true
+	}
+	return string(content), err
+}
+
+func (stream *Stream) Close() error {
+	if stream.isClosed {
+		return nil
+	}
+	stream.isClosed = true
+	return stream.closer.Close()
+}
+
+func (stream *Stream) checkReadable() error {
+	if stream.isClosed {
+		return fmt.Errorf("stream is closed")
+	}
+	if stream.reader == nil {
+		return fmt.Errorf("stream is not readable")
+	}
+	return nil
+}
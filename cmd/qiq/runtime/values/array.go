@@ -1,26 +1,72 @@
 package values
 
 import (
-	"QIQ/cmd/qiq/common"
 	"QIQ/cmd/qiq/config"
 	"QIQ/cmd/qiq/phpError"
 	"fmt"
+	"iter"
+	"strconv"
 )
 
+// Spec: https://www.php.net/manual/en/language.types.array.php
+// Arrays are values: assigning or passing an array creates a copy.
+// Copies share their storage (copy-on-write) until one of them is modified.
+// Only then the storage is duplicated, so copying an array is O(1).
+
 type Array struct {
 	*abstractValue
-	Keys     []RuntimeValue
-	Elements map[string]RuntimeValue
+	data *arrayData
+	// Number of variables, properties and array elements that hold this array (see GarbageCollector)
+	held int
+	// Set when the last holder released the array. The array no longer counts as a user of its storage,
+	// so that the other copies can modify the storage without duplicating it (see release).
+	detached bool
+	// Position of the internal pointer (see current, next, reset).
+	// The pointer is beyond the array if it is negative or not less than the number of elements.
+	pointer int
+}
+
+// Storage of an array that can be shared by multiple copies.
+//
+// Arrays with the keys 0..n-1 in ascending order (lists) are stored "packed" in a slice.
+// All other arrays are stored in an ordered hash table:
+// The elements are stored in the order of insertion in a slice and the key maps contain the index of an element.
+// Removed elements leave a hole in the slice that is cleaned up once there are too many holes.
+type arrayData struct {
+	// Number of arrays sharing this storage
+	refCount int
+	// Number of variables, properties and array elements that hold an array with this storage.
+	// The elements of a held storage are references of the values they contain.
+	holders int
+	// Set once an object or array is stored, so that storages of scalars are not scanned for references
+	hasRefs bool
+	// Collector of the objects stored in this storage (nil if there are none)
+	gc       *GarbageCollector
+	gcState  gcState
+	isPacked bool
+	// Elements of a packed array
+	list []RuntimeValue
+	// Elements of a hash table
+	entries []arrayEntry
+	intKeys map[int64]int
+	strKeys map[string]int
+	// Number of removed entries
+	holes int
 	// Keeping track of next key
 	nextKey    int64
 	nextKeySet bool
 }
 
+type arrayEntry struct {
+	key RuntimeValue
+	// Value is nil if the entry was removed
+	value RuntimeValue
+}
+
 func NewArray() *Array {
 	return &Array{
 		abstractValue: newAbstractValue(ArrayValue),
-		Keys:          []RuntimeValue{},
-		Elements:      map[string]RuntimeValue{},
+		data:          &arrayData{refCount: 1, isPacked: true},
 	}
 }
 
@@ -34,14 +80,128 @@ func NewArrayFromMap(elements map[RuntimeValue]RuntimeValue) *Array {
 	return array
 }
 
-func keyToMapKey(key RuntimeValue) (string, phpError.Error) {
+// Create a copy of the array that shares the storage until one of the arrays is modified
+func (array *Array) Copy() *Array {
+	array.data.refCount++
+	return &Array{abstractValue: array.abstractValue, data: array.data, pointer: array.pointer}
+}
+
+// Make sure that the storage is not shared with other copies before it is modified
+func (array *Array) separate() {
+	if array.detached {
+		// The storage belongs to the other copies
+		array.detached = false
+	} else if array.data.refCount <= 1 {
+		return
+	} else {
+		array.data.refCount--
+	}
+
+	data := &arrayData{
+		refCount:   1,
+		hasRefs:    array.data.hasRefs,
+		gc:         array.data.gc,
+		isPacked:   array.data.isPacked,
+		nextKey:    array.data.nextKey,
+		nextKeySet: array.data.nextKeySet,
+	}
+	if data.isPacked {
+		data.list = make([]RuntimeValue, len(array.data.list))
+		for i, value := range array.data.list {
+			data.list[i] = copyElement(value)
+		}
+	} else {
+		data.rebuildHash(array.data.entries, copyElement)
+	}
+
+	// The holders of this array hold the new storage from now on
+	oldData := array.data
+	array.data = data
+	if array.held > 0 {
+		data.holders = array.held
+		data.retainElements()
+		oldData.holders -= array.held
+		oldData.releaseHolder()
+	}
+}
+
+// Nested arrays are copied lazily as well
+func copyElement(value RuntimeValue) RuntimeValue {
+	if value.GetType() == ArrayValue {
+		return value.(*Array).Copy()
+	}
+	return value
+}
+
+// Fill the hash table with the given entries without the removed ones
+func (data *arrayData) rebuildHash(entries []arrayEntry, convertValue func(RuntimeValue) RuntimeValue) {
+	count := len(entries) - data.holes
+	data.entries = make([]arrayEntry, 0, count)
+	data.intKeys = make(map[int64]int)
+	data.strKeys = make(map[string]int)
+	data.holes = 0
+	for _, entry := range entries {
+		if entry.value == nil {
+			continue
+		}
+		data.addEntry(entry.key, convertValue(entry.value))
+	}
+}
+
+func (data *arrayData) addEntry(key RuntimeValue, value RuntimeValue) {
 	if key.GetType() == IntValue {
-		return fmt.Sprintf("i_%d", key.(*Int).Value), nil
+		data.intKeys[key.(*Int).Value] = len(data.entries)
+	} else {
+		data.strKeys[key.(*Str).Value] = len(data.entries)
 	}
-	if key.GetType() == StrValue {
-		return fmt.Sprintf("s_%s", key.(*Str).Value), nil
+	data.entries = append(data.entries, arrayEntry{key: key, value: value})
+}
+
+// Switch from the packed list to the hash table
+func (data *arrayData) convertToHash() {
+	data.entries = make([]arrayEntry, 0, len(data.list))
+	data.intKeys = make(map[int64]int, len(data.list))
+	data.strKeys = make(map[string]int)
+	for i, value := range data.list {
+		data.addEntry(NewInt(int64(i)), value)
+	}
+	data.isPacked = false
+	data.list = nil
+}
+
+func (data *arrayData) getIndex(key RuntimeValue) (int, bool) {
+	switch key.GetType() {
+	case IntValue:
+		intKey := key.(*Int).Value
+		if data.isPacked {
+			return int(intKey), intKey >= 0 && intKey < int64(len(data.list))
+		}
+		index, found := data.intKeys[intKey]
+		return index, found
+	case StrValue:
+		index, found := data.strKeys[key.(*Str).Value]
+		return index, found
+	default:
+		return 0, false
 	}
-	return "", phpError.NewError("Array.keyToMapKey: Unsupported key type %s", ToPhpType(key))
+}
+
+func stringKeyToInt(str string) (int64, bool) {
+	digits := str
+	if len(digits) > 0 && digits[0] == '-' {
+		digits = digits[1:]
+	}
+	// Leading zeros and "-0" are not valid decimal integers
+	if len(digits) == 0 || (digits[0] == '0' && len(str) > 1) {
+		return 0, false
+	}
+	for i := range len(digits) {
+		if digits[i] < '0' || digits[i] > '9' {
+			return 0, false
+		}
+	}
+	intKey, err := strconv.ParseInt(str, 10, 64)
+	return intKey, err == nil
 }
 
 func convertKey(key RuntimeValue) (RuntimeValue, phpError.Error) {
@@ -49,6 +209,10 @@ func convertKey(key RuntimeValue) (RuntimeValue, phpError.Error) {
 		return NewVoid(), nil
 	}
 
+	if key.GetType() == IntValue {
+		return key, nil
+	}
+
 	if key.GetType() == ArrayValue {
 		// Spec: https://www.php.net/manual/en/language.types.array.php
 		// Arrays and objects can not be used as keys. Doing so will result in a warning: Illegal offset type.
@@ -70,11 +234,14 @@ func convertKey(key RuntimeValue) (RuntimeValue, phpError.Error) {
 		return NewInt(int64(key.(*Float).Value)), nil
 	}
 
-	if key.GetType() == StrValue && common.IsDecimalLiteral(key.(*Str).Value, false) {
+	if key.GetType() == StrValue {
 		// Spec: https://www.php.net/manual/en/language.types.array.php
 		// Strings containing valid decimal ints, unless the number is preceded by a + sign, will be cast to the int type.
 		// E.g. the key "8" will actually be stored under 8. On the other hand "08" will not be cast, as it isn't a valid decimal integer.
-		return NewInt(common.DecimalLiteralToInt64(key.(*Str).Value, false)), nil
+		if intKey, ok := stringKeyToInt(key.(*Str).Value); ok {
+			return NewInt(intKey), nil
+		}
+		return key, nil
 	}
 
 	if key.GetType() == NullValue {
@@ -99,106 +266,369 @@ func (array *Array) getNextKey(key RuntimeValue) (RuntimeValue, phpError.Error)
 		if key.GetType() == IntValue {
 			keyValue := key.(*Int).Value
 			// If no key is stored yet or the passed key is greater than nextKey
-			if !array.nextKeySet ||
-				(array.nextKeySet && keyValue > array.nextKey) {
+			if !array.data.nextKeySet || keyValue >= array.data.nextKey {
 				// Store value + 1 as next key
-				array.nextKey = keyValue + 1
-				array.nextKeySet = true
+				array.data.nextKey = keyValue + 1
+				array.data.nextKeySet = true
 			}
 		}
 
 		return key, nil
 	}
 
-	// If no key is passed and no nextKey is set yet
-	if !array.nextKeySet {
-		// lastFoundInt is -1 because at the end of the loop it will be increased by one
-		var lastFoundInt int64 = -1
-		found := false
-		// Iterate all keys and search for integer values
-		for i := len(array.Keys) - 1; i >= 0; i-- {
-			if array.Keys[i].GetType() != IntValue {
-				continue
-			}
-
-			foundInt := array.Keys[i].(*Int).Value
-			if !found {
-				lastFoundInt = foundInt
-				found = true
-				continue
-			}
-
-			if foundInt > lastFoundInt {
-				lastFoundInt = foundInt
-			}
-		}
-		array.nextKey = lastFoundInt + 1
-		array.nextKeySet = true
-	}
-
-	key = NewInt(array.nextKey)
-	array.nextKey++
+	key = NewInt(array.data.nextKey)
+	array.data.nextKey++
+	array.data.nextKeySet = true
 	return key, nil
 }
 
 func (array *Array) SetElement(key RuntimeValue, value RuntimeValue) phpError.Error {
+	array.separate()
+
+	// Fast path for appending to a list without creating the key
+	data := array.data
+	if key == nil && data.isPacked && data.nextKey == int64(len(data.list)) {
+		value = copyElement(value)
+		data.retainElement(value)
+		data.list = append(data.list, value)
+		data.nextKey++
+		data.nextKeySet = true
+		return nil
+	}
+
 	key, err := array.getNextKey(key)
 	if err != nil {
 		return err
 	}
+	if key.GetType() != IntValue && key.GetType() != StrValue {
+		return phpError.NewError("Array.SetElement: Unsupported key type %s", ToPhpType(key))
+	}
+
+	// An array stored in an array is a copy of the given array
+	value = copyElement(value)
+	data.retainElement(value)
+
+	if data.isPacked {
+		if index, found := data.getIndex(key); found {
+			data.releaseElement(data.list[index])
+			data.list[index] = value
+			return nil
+		}
+		if key.GetType() == IntValue && key.(*Int).Value == int64(len(data.list)) {
+			data.list = append(data.list, value)
+			return nil
+		}
+		data.convertToHash()
+	}
+
+	if index, found := data.getIndex(key); found {
+		data.releaseElement(data.entries[index].value)
+		data.entries[index].value = value
+		return nil
+	}
+	data.addEntry(key, value)
+	return nil
+}
 
-	mapKey, found, err := array.GetMapKey(key, false)
+func (array *Array) RemoveElement(key RuntimeValue) phpError.Error {
+	key, err := convertKey(key)
 	if err != nil {
 		return err
 	}
-
+	index, found := array.data.getIndex(key)
 	if !found {
-		array.Keys = append(array.Keys, key)
+		return phpError.NewError("Array.RemoveElement: Key %s not found", ToPhpType(key))
+	}
+
+	array.separate()
+	data := array.data
+	if data.isPacked {
+		data.releaseElement(data.list[index])
+		// Removing the last element keeps the list packed
+		if index == len(data.list)-1 {
+			data.list[index] = nil
+			data.list = data.list[:index]
+			return nil
+		}
+		data.convertToHash()
+	} else {
+		data.releaseElement(data.entries[index].value)
 	}
-	array.Elements[mapKey] = value
 
+	if key.GetType() == IntValue {
+		delete(data.intKeys, key.(*Int).Value)
+	} else {
+		delete(data.strKeys, key.(*Str).Value)
+	}
+	if index == len(data.entries)-1 {
+		data.entries[index] = arrayEntry{}
+		data.entries = data.entries[:index]
+		return nil
+	}
+	data.entries[index] = arrayEntry{}
+	data.holes++
+	if data.holes > 16 && data.holes > len(data.entries)/2 {
+		data.rebuildHash(data.entries, func(value RuntimeValue) RuntimeValue { return value })
+	}
 	return nil
 }
 
-func (array *Array) GetMapKey(key RuntimeValue, shouldConvertKey bool) (string, bool, phpError.Error) {
-	if shouldConvertKey {
-		var err phpError.Error
-		key, err = convertKey(key)
-		if err != nil {
-			return "", false, err
+// Remove the given key of the last element (see array_pop).
+// If it was the last appended key, the next appended element gets the same key again.
+func (array *Array) RemoveLastElement(key RuntimeValue) phpError.Error {
+	if err := array.RemoveElement(key); err != nil {
+		return err
+	}
+	if key.GetType() == IntValue && array.data.nextKeySet && key.(*Int).Value == array.data.nextKey-1 {
+		array.data.nextKey--
+	}
+	return nil
+}
+
+// Iterate over all keys and values in the order of insertion
+func (array *Array) Iterate() iter.Seq2[RuntimeValue, RuntimeValue] {
+	data := array.data
+	return func(yield func(RuntimeValue, RuntimeValue) bool) {
+		if data.isPacked {
+			for i, value := range data.list {
+				if !yield(NewInt(int64(i)), value) {
+					return
+				}
+			}
+			return
+		}
+		for _, entry := range data.entries {
+			if entry.value == nil {
+				continue
+			}
+			if !yield(entry.key, entry.value) {
+				return
+			}
 		}
 	}
+}
 
-	mapKey, err := keyToMapKey(key)
-	if err != nil {
-		return "", false, err
+// Get the next element starting at the given position.
+// Returns the position of the following element so that an array can be iterated step by step (e.g. by the VM).
+func (array *Array) NextElement(position int) (key RuntimeValue, value RuntimeValue, next int, found bool) {
+	data := array.data
+	if data.isPacked {
+		if position >= len(data.list) {
+			return nil, nil, position, false
+		}
+		return NewInt(int64(position)), data.list[position], position + 1, true
+	}
+	for ; position < len(data.entries); position++ {
+		if entry := data.entries[position]; entry.value != nil {
+			return entry.key, entry.value, position + 1, true
+		}
+	}
+	return nil, nil, position, false
+}
+
+// Get the keys in the order of insertion
+func (array *Array) GetKeys() []RuntimeValue {
+	keys := make([]RuntimeValue, 0, array.Count())
+	for key := range array.Iterate() {
+		keys = append(keys, key)
 	}
+	return keys
+}
 
-	_, found := array.Elements[mapKey]
+// Get the values in the order of insertion
+func (array *Array) GetValues() []RuntimeValue {
+	arrayValues := make([]RuntimeValue, 0, array.Count())
+	for _, value := range array.Iterate() {
+		arrayValues = append(arrayValues, value)
+	}
+	return arrayValues
+}
 
-	return mapKey, found, nil
+func (array *Array) Count() int {
+	if array.data.isPacked {
+		return len(array.data.list)
+	}
+	return len(array.data.entries) - array.data.holes
 }
 
 func (array *Array) IsEmpty() bool {
-	return len(array.Elements) == 0
+	return array.Count() == 0
+}
+
+// Get the position of the internal pointer
+func (array *Array) GetPointer() int {
+	return array.pointer
+}
+
+// Set the position of the internal pointer
+func (array *Array) SetPointer(position int) {
+	array.pointer = position
 }
 
 func (array *Array) Contains(key RuntimeValue) bool {
-	_, found, err := array.GetMapKey(key, true)
-	if err != nil && config.IsDevMode {
-		fmt.Println("Array.Contains: " + err.Error())
+	key, err := convertKey(key)
+	if err != nil {
+		if config.IsDevMode {
+			fmt.Println("Array.Contains: " + err.Error())
+		}
 		return false
 	}
+	_, found := array.data.getIndex(key)
 	return found
 }
 
 func (array *Array) GetElement(key RuntimeValue) (RuntimeValue, bool) {
-	mapKey, found, err := array.GetMapKey(key, true)
+	key, err := convertKey(key)
 	if err != nil {
 		return NewVoid(), false
 	}
+	index, found := array.data.getIndex(key)
 	if !found {
 		return NewVoid(), false
 	}
-	return array.Elements[mapKey], true
+	if array.data.isPacked {
+		return array.data.list[index], true
+	}
+	return array.data.entries[index].value, true
+}
+
+// Get an element that is going to be modified (e.g. the nested array in `$a[1][2] = 3;`).
+// Nested arrays returned by this function are not shared with other copies.
+func (array *Array) GetElementForWrite(key RuntimeValue) (RuntimeValue, bool) {
+	array.separate()
+	return array.GetElement(key)
+}
+
+// -------------------------------------- Reference counting -------------------------------------- MARK: Reference counting
+
+func (array *Array) retain() {
+	if array.detached {
+		array.detached = false
+		array.data.refCount++
+	}
+	array.held++
+	array.data.holders++
+	if array.data.holders == 1 {
+		array.data.retainElements()
+	}
+}
+
+func (array *Array) release() {
+	if array.held <= 0 {
+		return
+	}
+	array.held--
+	array.data.holders--
+	array.data.releaseHolder()
+	// A copy that is no longer held (e.g. a by-value parameter after the call) gives up its share of the storage
+	if array.held == 0 {
+		array.detach()
+	}
+}
+
+// Stop counting the array as a user of its storage. The array duplicates the storage before it is modified
+// and counts again once it is held (see retain).
+func (array *Array) detach() {
+	if array.detached || array.data.refCount <= 1 {
+		return
+	}
+	array.data.refCount--
+	array.detached = true
+}
+
+// Handle a removed holder: The elements of a storage that is no longer held are released.
+// Otherwise, the storage might be part of a garbage cycle.
+func (data *arrayData) releaseHolder() {
+	if !data.hasRefs {
+		return
+	}
+	if data.holders <= 0 {
+		data.holders = 0
+		data.releaseElements()
+		if data.gc != nil {
+			data.gc.removeRoot(data)
+		}
+		return
+	}
+	if data.gc != nil {
+		data.gc.addRoot(data)
+	}
+}
+
+// Add a reference to a new element if the storage is held
+func (data *arrayData) retainElement(value RuntimeValue) {
+	if !isReference(value) {
+		return
+	}
+	data.hasRefs = true
+	switch value := value.(type) {
+	case *Object:
+		if value.gc != nil {
+			data.gc = value.gc
+		}
+	case *Array:
+		if value.data.gc != nil {
+			data.gc = value.data.gc
+		}
+	}
+	if data.holders > 0 {
+		Retain(value)
+	}
+}
+
+// Remove the reference of an overwritten or removed element if the storage is held
+func (data *arrayData) releaseElement(value RuntimeValue) {
+	if data.holders > 0 {
+		Release(value)
+	}
+}
+
+func (data *arrayData) retainElements() {
+	if data.hasRefs {
+		data.forEachElement(Retain)
+	}
+}
+
+func (data *arrayData) releaseElements() {
+	if data.hasRefs {
+		data.forEachElement(Release)
+	}
+}
+
+func (data *arrayData) forEachElement(fn func(RuntimeValue)) {
+	if data.isPacked {
+		for _, value := range data.list {
+			fn(value)
+		}
+		return
+	}
+	for _, entry := range data.entries {
+		if entry.value != nil {
+			fn(entry.value)
+		}
+	}
+}
+
+func (data *arrayData) getGcState() *gcState {
+	return &data.gcState
+}
+
+func (data *arrayData) getRefCount() *int {
+	return &data.holders
+}
+
+func (data *arrayData) gcChildren(yield func(gcNode)) {
+	if !data.hasRefs {
+		return
+	}
+	data.forEachElement(func(value RuntimeValue) {
+		if node := toGcNode(value); node != nil {
+			yield(node)
+		}
+	})
+}
+
+func (data *arrayData) gcRelease() {
+	data.holders--
+	data.releaseHolder()
 }
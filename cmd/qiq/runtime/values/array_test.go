@@ -0,0 +1,119 @@
+package values
+
+import (
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+const benchmarkArraySize = 1_000_000
+
+func newBenchmarkList() *Array {
+	array := NewArray()
+	for i := range benchmarkArraySize {
+		array.SetElement(nil, NewInt(int64(i)))
+	}
+	return array
+}
+
+func newBenchmarkHash() *Array {
+	array := NewArray()
+	for i := range benchmarkArraySize {
+		array.SetElement(NewStr("key"+strconv.Itoa(i)), NewInt(int64(i)))
+	}
+	return array
+}
+
+func BenchmarkArrayAppend(b *testing.B) {
+	for b.Loop() {
+		newBenchmarkList()
+	}
+}
+
+func BenchmarkArrayRandomAccess(b *testing.B) {
+	array := newBenchmarkList()
+	keys := make([]RuntimeValue, benchmarkArraySize)
+	for i := range keys {
+		keys[i] = NewInt(rand.Int63n(benchmarkArraySize))
+	}
+	b.ResetTimer()
+	for b.Loop() {
+		for _, key := range keys {
+			array.GetElement(key)
+		}
+	}
+}
+
+func BenchmarkArrayRandomAccessHash(b *testing.B) {
+	array := newBenchmarkHash()
+	keys := make([]RuntimeValue, benchmarkArraySize)
+	for i := range keys {
+		keys[i] = NewStr("key" + strconv.Itoa(rand.Intn(benchmarkArraySize)))
+	}
+	b.ResetTimer()
+	for b.Loop() {
+		for _, key := range keys {
+			array.GetElement(key)
+		}
+	}
+}
+
+func BenchmarkArrayForeach(b *testing.B) {
+	array := newBenchmarkList()
+	b.ResetTimer()
+	for b.Loop() {
+		for key, value := range array.Iterate() {
+			_, _ = key, value
+		}
+	}
+}
+
+func BenchmarkArrayUnset(b *testing.B) {
+	for b.Loop() {
+		b.StopTimer()
+		array := newBenchmarkList()
+		b.StartTimer()
+		for i := range benchmarkArraySize {
+			array.RemoveElement(NewInt(int64(i)))
+		}
+	}
+}
+
+func TestArrayCopyReleased(t *testing.T) {
+	array := NewArray()
+	array.SetElement(nil, NewInt(1))
+	Retain(array)
+
+	// A held copy that is released (e.g. a parameter after the call) no longer shares the storage
+	parameter := array.Copy()
+	Retain(parameter)
+	Release(parameter)
+	data := array.data
+	array.SetElement(NewInt(0), NewInt(2))
+	if array.data != data {
+		t.Errorf("Expected the storage to be modified without a copy")
+	}
+	// The released copy duplicates the storage before it is modified
+	parameter.SetElement(NewInt(0), NewInt(3))
+	if parameter.data == array.data {
+		t.Errorf("Expected the released copy to have its own storage")
+	}
+
+	// A discarded temporary copy (e.g. an argument of a native function) no longer shares the storage
+	Discard(array.Copy())
+	data = array.data
+	array.SetElement(NewInt(0), NewInt(4))
+	if array.data != data {
+		t.Errorf("Expected the storage to be modified without a copy after discarding the temporary copy")
+	}
+
+	// A released copy that is held again shares the storage
+	copied := array.Copy()
+	Retain(copied)
+	Release(copied)
+	Retain(copied)
+	array.SetElement(NewInt(0), NewInt(5))
+	if value, _ := copied.GetElement(NewInt(0)); value.(*Int).Value != 4 {
+		t.Errorf("Expected the copy to keep its value 4, got %d", value.(*Int).Value)
+	}
+}
@@ -2,29 +2,83 @@ package values
 
 import (
 	"QIQ/cmd/qiq/ast"
+	"QIQ/cmd/qiq/phpError"
+	"slices"
 )
 
 type Object struct {
 	*abstractValue
+	// Unique identifier of the object during its lifetime (see spl_object_id)
+	Handle        int64
 	Class         *ast.ClassDeclarationStatement
 	PropertyNames []string
 	Properties    map[string]RuntimeValue
+	// Internal state of built-in classes (e.g. the reflected declaration of a ReflectionClass)
+	Internal any
 	// TODO methods
 	// TODO parent
+	// Number of variables, properties and array elements that hold the object (see GarbageCollector)
+	RefCount int
+	gc       *GarbageCollector
+	gcState  gcState
 	// Status
-	IsUsed       bool
 	IsDestructed bool
+	IsFreed      bool
+	// Set while the properties of the object are compared (used to detect recursive dependencies)
+	IsCompared bool
 }
 
-func NewObject(class *ast.ClassDeclarationStatement) *Object {
+// Internal state that must be copied when the object is cloned (e.g. the state of a HashContext)
+type InternalCloner interface {
+	CloneInternal() (any, phpError.Error)
+}
+
+func NewObject(class *ast.ClassDeclarationStatement, handle int64) *Object {
 	return &Object{abstractValue: newAbstractValue(ObjectValue),
+		Handle:        handle,
 		Class:         class,
 		PropertyNames: append([]string(nil), class.PropertieNames...),
 		Properties:    map[string]RuntimeValue{},
 	}
 }
 
+// Spec: https://www.php.net/manual/en/language.oop5.cloning.php
+// When an object is cloned, PHP will perform a shallow copy of all of the object's properties.
+// Any properties that are references to other variables will remain references.
+func (object *Object) Clone(handle int64) (*Object, phpError.Error) {
+	clone := &Object{abstractValue: newAbstractValue(ObjectValue),
+		Handle:        handle,
+		Class:         object.Class,
+		PropertyNames: append([]string(nil), object.PropertyNames...),
+		Properties:    make(map[string]RuntimeValue, len(object.Properties)),
+		Internal:      object.Internal,
+	}
+	for name, value := range object.Properties {
+		clone.Properties[name] = Copy(value)
+		Retain(clone.Properties[name])
+	}
+	if cloner, ok := object.Internal.(InternalCloner); ok {
+		internal, err := cloner.CloneInternal()
+		if err != nil {
+			return nil, err
+		}
+		clone.Internal = internal
+	}
+	if object.gc != nil {
+		object.gc.track(clone)
+	}
+	return clone, nil
+}
+
 func (object *Object) SetProperty(name string, value RuntimeValue) {
+	oldValue, found := object.Properties[name]
+	if !found && !slices.Contains(object.PropertyNames, name) {
+		object.PropertyNames = append(object.PropertyNames, name)
+	}
+	Retain(value)
+	if found {
+		Release(oldValue)
+	}
 	object.Properties[name] = value
 }
 
@@ -41,3 +95,70 @@ func (object *Object) GetMethod(name string) (*ast.MethodDefinitionStatement, bo
 	method, found := object.Class.Methods[name]
 	return method, found
 }
+
+// -------------------------------------- Reference counting -------------------------------------- MARK: Reference counting
+
+func (object *Object) release() {
+	if object.RefCount <= 0 {
+		return
+	}
+	object.RefCount--
+	if object.gc == nil || object.IsFreed {
+		return
+	}
+	if object.RefCount == 0 {
+		object.gc.candidates = append(object.gc.candidates, object)
+		return
+	}
+	object.gc.addRoot(object)
+}
+
+// Free an object whose reference count dropped to zero after its destructor was called.
+// The references held by the properties are released.
+func (object *Object) Free() {
+	if object.IsFreed {
+		return
+	}
+	object.IsFreed = true
+	if object.gc != nil {
+		object.gc.free(object)
+	}
+	for _, name := range object.PropertyNames {
+		if value, found := object.Properties[name]; found {
+			Release(value)
+		}
+	}
+}
+
+func (object *Object) getGcState() *gcState {
+	return &object.gcState
+}
+
+func (object *Object) getRefCount() *int {
+	return &object.RefCount
+}
+
+func (object *Object) gcChildren(yield func(gcNode)) {
+	for _, name := range object.PropertyNames {
+		if value, found := object.Properties[name]; found {
+			if node := toGcNode(value); node != nil {
+				yield(node)
+			}
+		}
+	}
+}
+
+func (object *Object) gcRelease() {
+	object.release()
+}
+
+func toGcNode(value RuntimeValue) gcNode {
+	switch value := value.(type) {
+	case *Object:
+		return value
+	case *Array:
+		return value.data
+	default:
+		return nil
+	}
+}
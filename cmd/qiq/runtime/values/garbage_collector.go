@@ -0,0 +1,403 @@
+package values
+
+import (
+	"QIQ/cmd/qiq/ast"
+	"slices"
+	"time"
+)
+
+// Spec: https://www.php.net/manual/en/features.gc.refcounting-basics.php
+// Objects are reference counted. The references are held by variables, properties and array elements.
+// The elements of an array only count as references while the array itself is held (see Array.retain),
+// so that a temporary array (e.g. the unused result of a function call) does not keep its objects alive.
+//
+// An object whose reference count drops to zero becomes a candidate. The interpreter destructs and frees
+// the candidates at the end of the statement in which their count dropped to zero. New objects are candidates
+// as well, so that an object that is never stored (e.g. `new C;`) is destructed at the end of its statement.
+//
+// Spec: https://www.php.net/manual/en/features.gc.collecting-cycles.php
+// Objects that refer to each other (garbage cycles) are never freed by reference counting.
+// An object or array whose reference count is decremented to a value greater than zero is buffered as possible root
+// of a garbage cycle. The cycle collector removes the references inside the subgraph of the roots (trial deletion).
+// The nodes whose count drops to zero are only referenced by garbage and are collected.
+
+const (
+	// Default number of roots that triggers the cycle collector
+	gcThresholdDefault = 10001
+	// Collections that free fewer than gcThresholdTrigger nodes increase the threshold by gcThresholdStep
+	gcThresholdStep    = 10000
+	gcThresholdMax     = 1_000_000_000
+	gcThresholdTrigger = 100
+	// Initial size of the root buffer
+	gcBufferSize = 16 * 1024
+)
+
+type gcColor uint8
+
+const (
+	// Referenced from outside of the examined subgraph
+	gcBlack gcColor = iota
+	// Examined: the references inside the subgraph were removed
+	gcGray
+	// Only referenced from inside the subgraph
+	gcWhite
+	// Collected as garbage
+	gcGarbage
+)
+
+type gcState struct {
+	color  gcColor
+	isRoot bool
+}
+
+// Objects and array storages are the nodes of the reference graph
+type gcNode interface {
+	getGcState() *gcState
+	getRefCount() *int
+	// Call the function for each node the node holds a reference to
+	gcChildren(yield func(gcNode))
+	// Release the reference of a node that is still alive
+	gcRelease()
+}
+
+type GarbageCollector struct {
+	// Objects whose reference count dropped to zero. They are freed at the next statement boundary of the scope
+	// they were added in: the scope only processes the candidates after its mark (see Mark).
+	candidates []*Object
+	// Objects that are not freed yet by handle
+	objects map[int64]*Object
+	// Possible roots of garbage cycles. Removed roots stay in the slice until it is compacted.
+	roots            []gcNode
+	rootCount        int
+	threshold        int
+	thresholdReached bool
+	bufferSize       int
+	// Status
+	running        bool
+	runs           int64
+	collected      int64
+	startTime      time.Time
+	collectorTime  time.Duration
+	destructorTime time.Duration
+	freeTime       time.Duration
+}
+
+type GcStatus struct {
+	Runs            int64
+	Collected       int64
+	Threshold       int64
+	Roots           int64
+	Running         bool
+	BufferSize      int64
+	ApplicationTime float64
+	CollectorTime   float64
+	DestructorTime  float64
+	FreeTime        float64
+}
+
+func NewGarbageCollector() *GarbageCollector {
+	return &GarbageCollector{
+		objects:    map[int64]*Object{},
+		threshold:  gcThresholdDefault,
+		bufferSize: gcBufferSize,
+		startTime:  time.Now(),
+	}
+}
+
+// Create a new object that is tracked by the garbage collector
+func (gc *GarbageCollector) NewObject(class *ast.ClassDeclarationStatement, handle int64) *Object {
+	object := NewObject(class, handle)
+	gc.track(object)
+	return object
+}
+
+func (gc *GarbageCollector) track(object *Object) {
+	object.gc = gc
+	gc.objects[object.Handle] = object
+	gc.candidates = append(gc.candidates, object)
+}
+
+// -------------------------------------- Reference counting -------------------------------------- MARK: Reference counting
+
+// Add a reference to the value. Must be called if the value is stored in a variable, property or array element.
+func Retain(value RuntimeValue) {
+	switch value := value.(type) {
+	case *Object:
+		value.RefCount++
+	case *Array:
+		value.retain()
+	}
+}
+
+// Remove a reference from the value. Must be called if the value of a variable, property or array element
+// is overwritten or removed. Releasing nil is ignored.
+func Release(value RuntimeValue) {
+	switch value := value.(type) {
+	case *Object:
+		value.release()
+	case *Array:
+		value.release()
+	}
+}
+
+// Give up the share of the storage of a temporary array copy that is not held by a variable, property or array element
+// (e.g. an argument of a native function after the call), so that the other copies can be modified without a copy.
+func Discard(value RuntimeValue) {
+	if array, ok := value.(*Array); ok && array.held == 0 {
+		array.detach()
+	}
+}
+
+// Objects and arrays hold references to other values
+func isReference(value RuntimeValue) bool {
+	switch value.(type) {
+	case *Object, *Array:
+		return true
+	default:
+		return false
+	}
+}
+
+func (gc *GarbageCollector) addRoot(node gcNode) {
+	state := node.getGcState()
+	if state.isRoot || gc.running {
+		return
+	}
+	state.isRoot = true
+	gc.roots = append(gc.roots, node)
+	gc.rootCount++
+	if gc.rootCount >= gc.threshold {
+		gc.thresholdReached = true
+	}
+	if gc.rootCount > gc.bufferSize {
+		gc.bufferSize *= 2
+	}
+	// Remove the roots that were freed in the meantime
+	if len(gc.roots) > 2*gc.rootCount+64 {
+		gc.roots = slices.DeleteFunc(gc.roots, func(node gcNode) bool { return !node.getGcState().isRoot })
+	}
+}
+
+func (gc *GarbageCollector) removeRoot(node gcNode) {
+	if state := node.getGcState(); state.isRoot {
+		state.isRoot = false
+		gc.rootCount--
+	}
+}
+
+func (gc *GarbageCollector) free(object *Object) {
+	delete(gc.objects, object.Handle)
+	gc.removeRoot(object)
+}
+
+// -------------------------------------- Candidates -------------------------------------- MARK: Candidates
+
+// Get the mark of a new scope (e.g. a function call). The scope only frees the candidates added after its mark.
+func (gc *GarbageCollector) Mark() int {
+	return len(gc.candidates)
+}
+
+// Check if there are candidates after the mark or if the cycle collector should run
+func (gc *GarbageCollector) HasWork(mark int) bool {
+	return len(gc.candidates) > mark || gc.thresholdReached
+}
+
+// Remove and return the candidates after the mark
+func (gc *GarbageCollector) TakeCandidates(mark int) []*Object {
+	if len(gc.candidates) <= mark {
+		return nil
+	}
+	candidates := slices.Clone(gc.candidates[mark:])
+	clear(gc.candidates[mark:])
+	gc.candidates = gc.candidates[:mark]
+	return candidates
+}
+
+// Get the objects that are not freed yet in the order of their creation
+func (gc *GarbageCollector) GetObjects() []*Object {
+	objects := make([]*Object, 0, len(gc.objects))
+	for _, object := range gc.objects {
+		objects = append(objects, object)
+	}
+	slices.SortFunc(objects, func(a, b *Object) int { return int(a.Handle - b.Handle) })
+	return objects
+}
+
+// -------------------------------------- Cycle collection -------------------------------------- MARK: Cycle collection
+
+// Check if the root buffer is full. The threshold is reset, so that a disabled collector is not asked again for every root.
+func (gc *GarbageCollector) ThresholdReached() bool {
+	reached := gc.thresholdReached
+	gc.thresholdReached = false
+	return reached
+}
+
+// Adjust the threshold after an automatic collection:
+// If only a few nodes were collected, the collector runs less often.
+func (gc *GarbageCollector) AdjustThreshold(collected int) {
+	if collected < gcThresholdTrigger || gc.rootCount >= gc.threshold {
+		gc.threshold = min(gc.threshold+gcThresholdStep, gcThresholdMax)
+	} else if gc.threshold > gcThresholdDefault {
+		gc.threshold = max(gc.threshold-gcThresholdStep, gcThresholdDefault)
+	}
+}
+
+// Collect the garbage cycles reachable from the roots and return the number of freed objects and arrays.
+// The destructors of the garbage objects are called before anything is freed.
+// Objects that are resurrected by a destructor (e.g. stored in a global variable) are not freed.
+func (gc *GarbageCollector) CollectCycles(destruct func(object *Object)) int {
+	if gc.running {
+		return 0
+	}
+	roots := gc.roots
+	gc.roots = nil
+	gc.rootCount = 0
+	gc.thresholdReached = false
+	for _, root := range roots {
+		root.getGcState().isRoot = false
+	}
+	roots = slices.DeleteFunc(roots, func(node gcNode) bool { return *node.getRefCount() <= 0 || isFreed(node) })
+	if len(roots) == 0 {
+		return 0
+	}
+
+	gc.running = true
+	defer func() { gc.running = false }()
+	gc.runs++
+
+	start := time.Now()
+	garbage := findGarbage(roots)
+	gc.collectorTime += time.Since(start)
+	if len(garbage) == 0 {
+		return 0
+	}
+
+	start = time.Now()
+	for _, node := range garbage {
+		if object, ok := node.(*Object); ok && !object.IsFreed {
+			destruct(object)
+		}
+	}
+	gc.destructorTime += time.Since(start)
+
+	// The destructors might have freed or resurrected some of the garbage
+	start = time.Now()
+	for _, node := range garbage {
+		node.getGcState().color = gcBlack
+	}
+	garbage = findGarbage(slices.DeleteFunc(garbage, func(node gcNode) bool { return *node.getRefCount() <= 0 || isFreed(node) }))
+	gc.collectorTime += time.Since(start)
+
+	start = time.Now()
+	for _, node := range garbage {
+		node.gcChildren(func(child gcNode) {
+			if child.getGcState().color != gcGarbage {
+				child.gcRelease()
+			}
+		})
+	}
+	for _, node := range garbage {
+		node.getGcState().color = gcBlack
+		*node.getRefCount() = 0
+		switch node := node.(type) {
+		case *Object:
+			node.IsFreed = true
+			gc.free(node)
+		case *arrayData:
+			gc.removeRoot(node)
+		}
+	}
+	gc.freeTime += time.Since(start)
+
+	gc.collected += int64(len(garbage))
+	return len(garbage)
+}
+
+func isFreed(node gcNode) bool {
+	object, ok := node.(*Object)
+	return ok && object.IsFreed
+}
+
+// Find the nodes reachable from the roots that are only referenced by each other.
+// The found nodes are colored as garbage. All reference counts are restored afterwards.
+func findGarbage(roots []gcNode) []gcNode {
+	for _, root := range roots {
+		markGray(root)
+	}
+	for _, root := range roots {
+		scan(root)
+	}
+	garbage := []gcNode{}
+	for _, root := range roots {
+		collectWhite(root, &garbage)
+	}
+	// Restore the references held by the garbage
+	for _, node := range garbage {
+		node.gcChildren(func(child gcNode) { *child.getRefCount()++ })
+	}
+	return garbage
+}
+
+// Remove the references inside the subgraph of the node
+func markGray(node gcNode) {
+	state := node.getGcState()
+	if state.color == gcGray {
+		return
+	}
+	state.color = gcGray
+	node.gcChildren(func(child gcNode) {
+		*child.getRefCount()--
+		markGray(child)
+	})
+}
+
+// Nodes that are still referenced are restored with their subgraph. All others are white.
+func scan(node gcNode) {
+	state := node.getGcState()
+	if state.color != gcGray {
+		return
+	}
+	if *node.getRefCount() > 0 {
+		scanBlack(node)
+		return
+	}
+	state.color = gcWhite
+	node.gcChildren(scan)
+}
+
+func scanBlack(node gcNode) {
+	node.getGcState().color = gcBlack
+	node.gcChildren(func(child gcNode) {
+		*child.getRefCount()++
+		if child.getGcState().color != gcBlack {
+			scanBlack(child)
+		}
+	})
+}
+
+func collectWhite(node gcNode, garbage *[]gcNode) {
+	state := node.getGcState()
+	if state.color != gcWhite {
+		return
+	}
+	state.color = gcGarbage
+	*garbage = append(*garbage, node)
+	node.gcChildren(func(child gcNode) { collectWhite(child, garbage) })
+}
+
+// -------------------------------------- Status -------------------------------------- MARK: Status
+
+func (gc *GarbageCollector) GetStatus() GcStatus {
+	return GcStatus{
+		Runs:            gc.runs,
+		Collected:       gc.collected,
+		Threshold:       int64(gc.threshold),
+		Roots:           int64(gc.rootCount),
+		Running:         gc.running,
+		BufferSize:      int64(gc.bufferSize),
+		ApplicationTime: time.Since(gc.startTime).Seconds(),
+		CollectorTime:   gc.collectorTime.Seconds(),
+		DestructorTime:  gc.destructorTime.Seconds(),
+		FreeTime:        gc.freeTime.Seconds(),
+	}
+}
@@ -0,0 +1,71 @@
+package values
+
+import (
+	"QIQ/cmd/qiq/ast"
+	"testing"
+)
+
+func TestGarbageCollectorArrayElements(t *testing.T) {
+	gc := NewGarbageCollector()
+	object := gc.NewObject(ast.NewClassDeclarationStmt(0, nil, "C", false, false), 1)
+
+	// Elements of a temporary array are no references
+	array := NewArray()
+	array.SetElement(nil, object)
+	if object.RefCount != 0 {
+		t.Errorf("Expected ref count 0 for element of temporary array, got %d", object.RefCount)
+	}
+
+	// Elements of a held array are references
+	Retain(array)
+	if object.RefCount != 1 {
+		t.Errorf("Expected ref count 1 for element of held array, got %d", object.RefCount)
+	}
+
+	// A copy shares the storage until it is modified
+	arrayCopy := array.Copy()
+	Retain(arrayCopy)
+	arrayCopy.SetElement(nil, NewInt(1))
+	if object.RefCount != 2 {
+		t.Errorf("Expected ref count 2 after separation, got %d", object.RefCount)
+	}
+
+	Release(array)
+	Release(arrayCopy)
+	if object.RefCount != 0 {
+		t.Errorf("Expected ref count 0 after release, got %d", object.RefCount)
+	}
+}
+
+func TestGarbageCollectorCycles(t *testing.T) {
+	gc := NewGarbageCollector()
+	class := ast.NewClassDeclarationStmt(0, nil, "C", false, false)
+	a := gc.NewObject(class, 1)
+	b := gc.NewObject(class, 2)
+	Retain(a)
+	Retain(b)
+	a.SetProperty("$other", b)
+	b.SetProperty("$other", a)
+	// An array in a cycle is collected as well
+	array := NewArray()
+	array.SetElement(nil, a)
+	a.SetProperty("$array", array)
+
+	// Still referenced by a variable
+	Release(b)
+	if collected := gc.CollectCycles(func(*Object) {}); collected != 0 {
+		t.Errorf("Expected nothing to be collected, got %d", collected)
+	}
+
+	destructed := []int64{}
+	Release(a)
+	if collected := gc.CollectCycles(func(object *Object) { destructed = append(destructed, object.Handle) }); collected != 3 {
+		t.Errorf("Expected 3 collected nodes, got %d", collected)
+	}
+	if len(destructed) != 2 || !a.IsFreed || !b.IsFreed {
+		t.Errorf("Expected both objects to be destructed and freed, got %v", destructed)
+	}
+	if len(gc.GetObjects()) != 0 {
+		t.Errorf("Expected no remaining objects, got %d", len(gc.GetObjects()))
+	}
+}
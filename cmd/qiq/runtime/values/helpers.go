@@ -8,7 +8,7 @@ func ToString(value RuntimeValue) string {
 	case ArrayValue:
 		result += "{ArrayValue: \n"
 		arrayValue := value.(*Array)
-		for _, key := range arrayValue.Keys {
+		for _, key := range arrayValue.GetKeys() {
 			result += "Key: "
 			result += ToString(key)
 			result += "Value: "
@@ -53,6 +53,8 @@ func ToPhpType(value RuntimeValue) string {
 		return "string"
 	case ObjectValue:
 		return "object"
+	case ResourceValue:
+		return "resource"
 	case VoidValue:
 		return "void"
 	default:
@@ -60,16 +62,10 @@ func ToPhpType(value RuntimeValue) string {
 	}
 }
 
-func DeepCopy(value RuntimeValue) RuntimeValue {
+// Copy a value. Arrays share their storage with the copy until one of them is modified.
+func Copy(value RuntimeValue) RuntimeValue {
 	if value.GetType() != ArrayValue {
 		return value
 	}
-
-	array := value.(*Array)
-	copy := NewArray()
-	for _, key := range array.Keys {
-		value, _ := array.GetElement(key)
-		copy.SetElement(key, DeepCopy(value))
-	}
-	return copy
+	return value.(*Array).Copy()
 }
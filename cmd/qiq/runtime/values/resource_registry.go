@@ -0,0 +1,41 @@
+package values
+
+import "slices"
+
+// The resource registry assigns the unique ids and keeps track of the open resources of an interpreter
+type ResourceRegistry struct {
+	lastId    int64
+	resources map[int64]*Resource
+}
+
+func NewResourceRegistry() *ResourceRegistry {
+	return &ResourceRegistry{resources: map[int64]*Resource{}}
+}
+
+// Create a new resource with a unique resource id
+func (registry *ResourceRegistry) NewResource(resourceType string, handle any) *Resource {
+	registry.lastId++
+	resource := NewResource(registry.lastId, resourceType, handle)
+	resource.registry = registry
+	registry.resources[resource.Id] = resource
+	return resource
+}
+
+// Get the open resource with the given id
+func (registry *ResourceRegistry) GetResource(id int64) (*Resource, bool) {
+	resource, found := registry.resources[id]
+	return resource, found
+}
+
+// Get the open resources in the order of their creation.
+// If the resource type is not empty, only the resources of this type are returned.
+func (registry *ResourceRegistry) GetResources(resourceType string) []*Resource {
+	resources := make([]*Resource, 0, len(registry.resources))
+	for _, resource := range registry.resources {
+		if resourceType == "" || resource.ResourceType == resourceType {
+			resources = append(resources, resource)
+		}
+	}
+	slices.SortFunc(resources, func(a, b *Resource) int { return int(a.Id - b.Id) })
+	return resources
+}
@@ -0,0 +1,30 @@
+package values
+
+import "testing"
+
+func TestResourceRegistry(t *testing.T) {
+	registry := NewResourceRegistry()
+	stream := registry.NewResource("stream", nil)
+	process := registry.NewResource("process", nil)
+	if stream.Id != 1 || process.Id != 2 {
+		t.Errorf("Expected resource ids 1 and 2, got %d and %d", stream.Id, process.Id)
+	}
+
+	if resources := registry.GetResources("process"); len(resources) != 1 || resources[0] != process {
+		t.Errorf("Expected only the process resource, got %v", resources)
+	}
+
+	// Closed resources are removed from the registry but keep their id
+	stream.Close()
+	if _, found := registry.GetResource(stream.Id); found {
+		t.Errorf("Expected closed resource to be removed from the registry")
+	}
+	if stream.GetResourceType() != "Unknown" {
+		t.Errorf("Expected type \"Unknown\" for closed resource, got \"%s\"", stream.GetResourceType())
+	}
+
+	// Ids are not reused
+	if next := registry.NewResource("stream", nil); next.Id != 3 {
+		t.Errorf("Expected resource id 3, got %d", next.Id)
+	}
+}
@@ -1,7 +1,8 @@
 package values
 
 import (
-	"strconv"
+	"QIQ/cmd/qiq/common"
+	"strings"
 )
 
 // MARK: RuntimeValue
@@ -16,8 +17,18 @@ type abstractValue struct {
 	valueType ValueType
 }
 
+// The abstract value is never modified, so all values of a type share the same instance
+var (
+	strAbstractValue = &abstractValue{valueType: StrValue}
+)
+
 func newAbstractValue(valueType ValueType) *abstractValue {
-	return &abstractValue{valueType: valueType}
+	switch valueType {
+	case StrValue:
+		return strAbstractValue
+	default:
+		return &abstractValue{valueType: valueType}
+	}
 }
 
 func (value *abstractValue) GetType() ValueType {
@@ -67,28 +78,54 @@ func NewBool(value bool) *Bool {
 
 // MARK: Int
 
+// Int and Float do not embed the abstract value: Without a pointer they are cheaper to allocate and to collect,
+// as the garbage collector does not scan them.
 type Int struct {
-	*abstractValue
 	Value int64
 }
 
+// Small integers are never modified, so they share preallocated instances (like the booleans)
+const (
+	minCachedInt = -128
+	maxCachedInt = 1023
+)
+
+var cachedInts = func() []Int {
+	ints := make([]Int, maxCachedInt-minCachedInt+1)
+	for index := range ints {
+		ints[index].Value = int64(index + minCachedInt)
+	}
+	return ints
+}()
+
 func NewInt(value int64) *Int {
-	return &Int{abstractValue: newAbstractValue(IntValue), Value: value}
+	if value >= minCachedInt && value <= maxCachedInt {
+		return &cachedInts[value-minCachedInt]
+	}
+	return &Int{Value: value}
+}
+
+func (value *Int) GetType() ValueType {
+	return IntValue
 }
 
 // MARK: Float
 
 type Float struct {
-	*abstractValue
 	Value float64
 }
 
 func NewFloat(value float64) *Float {
-	return &Float{abstractValue: newAbstractValue(FloatValue), Value: value}
+	return &Float{Value: value}
+}
+
+func (value *Float) GetType() ValueType {
+	return FloatValue
 }
 
-func (value *Float) ToPhpString() string {
-	return strconv.FormatFloat(value.Value, 'f', -1, 64)
+// Convert the float to a string with the given precision (see ini directives "precision" and "serialize_precision")
+func (value *Float) ToPhpString(precision int64) string {
+	return common.FormatFloat(value.Value, precision)
 }
 
 // MARK: Str
@@ -96,8 +133,74 @@ func (value *Float) ToPhpString() string {
 type Str struct {
 	*abstractValue
 	Value string
+	// Buffer the value was built in (see ConcatStr)
+	builder *strings.Builder
 }
 
 func NewStr(value string) *Str {
-	return &Str{abstractValue: newAbstractValue(StrValue), Value: value}
+	return &Str{abstractValue: strAbstractValue, Value: value}
+}
+
+// Strings shorter than this are concatenated without a buffer
+const minStrBuilderLength = 64
+
+// Concatenate two strings.
+// Longer strings are built in a buffer with spare capacity. If the left string is the latest value of its buffer,
+// the right string is appended in place, so that building a string in a loop (e.g. `$s .= "x";`) is not quadratic.
+// The bytes of a buffer are never overwritten, so all strings sharing a buffer stay valid.
+func ConcatStr(left *Str, right string) *Str {
+	if left.builder != nil && left.builder.Len() == len(left.Value) {
+		left.builder.WriteString(right)
+		return &Str{abstractValue: strAbstractValue, Value: left.builder.String(), builder: left.builder}
+	}
+
+	length := len(left.Value) + len(right)
+	if length < minStrBuilderLength {
+		return NewStr(left.Value + right)
+	}
+
+	builder := &strings.Builder{}
+	builder.Grow(2 * length)
+	builder.WriteString(left.Value)
+	builder.WriteString(right)
+	return &Str{abstractValue: strAbstractValue, Value: builder.String(), builder: builder}
+}
+
+// MARK: Resource
+
+// Spec: https://www.php.net/manual/en/language.types.resource.php
+
+type Resource struct {
+	*abstractValue
+	Id int64
+	// Type of the resource (e.g. "stream" or "process")
+	ResourceType string
+	// Go value the resource refers to (e.g. a stream or a process)
+	Handle any
+	// A closed resource keeps its id but has no type anymore (e.g. after fclose)
+	IsClosed bool
+	registry *ResourceRegistry
+}
+
+func NewResource(id int64, resourceType string, handle any) *Resource {
+	return &Resource{abstractValue: newAbstractValue(ResourceValue), Id: id, ResourceType: resourceType, Handle: handle}
+}
+
+// Get the type of the resource. Closed resources have the type "Unknown".
+func (resource *Resource) GetResourceType() string {
+	if resource.IsClosed {
+		return "Unknown"
+	}
+	return resource.ResourceType
+}
+
+// Mark the resource as closed and remove it from its registry. The handle must be closed by the caller.
+func (resource *Resource) Close() {
+	if resource.IsClosed {
+		return
+	}
+	resource.IsClosed = true
+	if resource.registry != nil {
+		delete(resource.registry.resources, resource.Id)
+	}
 }
@@ -0,0 +1,33 @@
+package values
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConcatStr(t *testing.T) {
+	base := ConcatStr(NewStr(strings.Repeat("a", minStrBuilderLength)), "b")
+	appended := ConcatStr(base, "c")
+	// The buffer was already extended by "c", so "d" must not overwrite it
+	branched := ConcatStr(base, "d")
+
+	expected := strings.Repeat("a", minStrBuilderLength)
+	if base.Value != expected+"b" {
+		t.Errorf("Expected: \"%s\", Got \"%s\"", expected+"b", base.Value)
+	}
+	if appended.Value != expected+"bc" {
+		t.Errorf("Expected: \"%s\", Got \"%s\"", expected+"bc", appended.Value)
+	}
+	if branched.Value != expected+"bd" {
+		t.Errorf("Expected: \"%s\", Got \"%s\"", expected+"bd", branched.Value)
+	}
+}
+
+func BenchmarkConcatStr(b *testing.B) {
+	for b.Loop() {
+		str := NewStr("")
+		for range 100_000 {
+			str = ConcatStr(str, "abc")
+		}
+	}
+}
@@ -4,4 +4,9 @@ go 1.24.0
 
 toolchain go1.24.3
 
-require golang.org/x/text v0.23.0
+require (
+	golang.org/x/crypto v0.36.0
+	golang.org/x/text v0.23.0
+)
+
+require golang.org/x/sys v0.31.0 // indirect